@@ -1,73 +1,94 @@
 package cli
 
 import (
+	"compress/gzip"
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/lukelzlz/s3backup/pkg/archive"
 	"github.com/lukelzlz/s3backup/pkg/config"
 	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/dbdump"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/pipeline"
 	"github.com/lukelzlz/s3backup/pkg/progress"
 	"github.com/lukelzlz/s3backup/pkg/state"
 	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/tracing"
 	"github.com/lukelzlz/s3backup/pkg/uploader"
+	"github.com/lukelzlz/s3backup/pkg/version"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
-	provider     string
-	bucket       string
-	endpoint     string
-	region       string
-	accessKey    string
-	secretKey    string
-	storageClass string
-	encrypt      bool
-	password     string
-	keyFile      string
-	excludes     []string
-	backupName   string
-	concurrency  int
-	chunkSize    int64
-	dryRun       bool
-	noProgress   bool
-	stateDir     string
+	backupName    string
+	dryRun        bool
+	noProgress    bool
+	estimateCost  bool
+	backupOnly    []string
+	dockerVolumes []string
+	filesFrom     string
+	excludeFrom   string
 )
 
 // backupCmd 备份命令
 var backupCmd = &cobra.Command{
 	Use:   "backup [paths...]",
 	Short: "执行备份",
-	Long:  `将指定路径打包压缩并上传到 S3 兼容存储`,
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  runBackup,
+	Long: `将指定路径打包压缩并上传到 S3 兼容存储
+
+也可以使用 --only 选择配置文件 backup.paths 中命名的路径组，使用 --docker-volume 备份 Docker 卷，
+与命令行路径合并备份。`,
+	RunE: withK8s(withTUI(runBackup)),
 }
 
 func init() {
 	rootCmd.AddCommand(backupCmd)
 
 	// backup 命令 flags
-	backupCmd.Flags().StringVarP(&provider, "provider", "p", "", "存储提供商 (aws/qiniu/aliyun)")
-	backupCmd.Flags().StringVarP(&bucket, "bucket", "b", "", "存储桶名称")
-	backupCmd.Flags().StringVar(&endpoint, "endpoint", "", "自定义端点")
-	backupCmd.Flags().StringVar(&region, "region", "", "区域")
-	backupCmd.Flags().StringVar(&accessKey, "access-key", "", "Access Key")
-	backupCmd.Flags().StringVar(&secretKey, "secret-key", "", "Secret Key")
-	backupCmd.Flags().StringVarP(&storageClass, "storage-class", "s", "", "存储类型 (standard/ia/archive/deep_archive)")
-	backupCmd.Flags().BoolVarP(&encrypt, "encrypt", "e", false, "启用加密")
-	backupCmd.Flags().StringVar(&password, "password", "", "加密密码")
-	backupCmd.Flags().StringVar(&keyFile, "key-file", "", "密钥文件")
-	backupCmd.Flags().StringSliceVar(&excludes, "exclude", []string{}, "排除模式（可多次指定）")
+	addConfigFlags(backupCmd)
 	backupCmd.Flags().StringVarP(&backupName, "name", "n", "", "备份文件名（默认：backup-{timestamp}.tar.gz.enc）")
-	backupCmd.Flags().IntVar(&concurrency, "concurrency", 0, "并发上传数")
-	backupCmd.Flags().Int64Var(&chunkSize, "chunk-size", 0, "分块大小（字节）")
 	backupCmd.Flags().BoolVar(&dryRun, "dry-run", false, "模拟运行，不实际上传")
 	backupCmd.Flags().BoolVar(&noProgress, "no-progress", false, "禁用进度条")
-	backupCmd.Flags().StringVar(&stateDir, "state-dir", "", "状态文件目录（用于断点续传）")
+	backupCmd.Flags().String("state-dir", "", "状态文件目录（用于断点续传，默认 ~/.s3backup/state/<机器标识>）")
+	backupCmd.Flags().Bool("no-resume-state", false, "不保存断点续传状态")
+	backupCmd.Flags().BoolVar(&estimateCost, "estimate-cost", false, "上传前估算存储和请求费用（可与 --dry-run 一起使用）")
+	backupCmd.Flags().String("source", "", "数据库连接地址（postgres://、mysql://、mongodb://），备份数据库导出数据而不是文件")
+	backupCmd.Flags().StringSliceVar(&dockerVolumes, "docker-volume", nil, "备份 Docker 卷（可多次指定）")
+	backupCmd.Flags().StringSliceVar(&backupOnly, "only", nil, "只备份 backup.paths 中指定名称的路径组（逗号分隔）")
+	_ = backupCmd.RegisterFlagCompletionFunc("only", completePathGroup)
+	backupCmd.Flags().Int64("max-total-size", 0, "待备份数据（压缩前）的大小上限（字节），超过时按 backup.max_total_size_action 处理")
+	backupCmd.Flags().String("sign-key", "", "Ed25519 签名私钥（PEM），上传后对备份签名并上传 <备份名>.sig")
+	backupCmd.Flags().Bool("report", false, "上传备份后同时上传 <备份名>.report.json 备份报告")
+	backupCmd.Flags().String("expire-after", "", "备份的保留时长（如 90d、12w），到期时间记录在对象元数据中，由 prune --honor-expiry 删除")
+	backupCmd.Flags().Bool("idempotent", false, "当天已有以相同 profile 成功完成的备份时跳过（用于重试的定时任务）")
+	backupCmd.Flags().Bool("overwrite", false, "允许覆盖存储中已存在的同名备份（默认已存在时失败）")
+	backupCmd.Flags().Bool("verify-upload", false, "上传完成后下载首尾数据段核对 SHA-256 并回读备份报告，不一致时失败退出")
+	backupCmd.Flags().Bool("smart-compression", false, "不压缩已压缩的文件类型（jpg、mp4、zip、gz 等，按扩展名），节省 CPU")
+	backupCmd.Flags().String("compression", "", "压缩格式 (gzip/zstd)")
+	backupCmd.Flags().String("zstd-dictionary", "", "zstd 字典文件（由 train-dict 生成）")
+	backupCmd.Flags().Int("parallel-roots", 0, "同时归档的包含路径数（多个包含路径位于不同磁盘时可加快归档）")
+	backupCmd.Flags().Bool("xattrs", false, "在 tar 中记录扩展属性（如 setcap 设置的 capabilities、user.*），仅支持 Linux")
+	backupCmd.Flags().Bool("selinux", false, "在 tar 中记录 SELinux 安全上下文（security.selinux），仅支持 Linux")
+	backupCmd.Flags().Bool("include-devices", false, "归档字符设备、块设备和命名管道（记录设备号，不读取内容），而不是作为特殊文件跳过")
+	backupCmd.Flags().Bool("strict", false, "遇到无法读取的文件、特殊文件（设备、管道、套接字）或目标不存在的符号链接时失败退出，而不是跳过")
+	backupCmd.Flags().Int("max-warnings", 0, "每类跳过警告逐条显示的数量，超出的只显示汇总（默认 20，-1 显示全部）")
+	backupCmd.Flags().String("spool-dir", "", "先将备份完整写入该目录中的临时文件再上传，上传中断后可用 resume 从磁盘续传（需要与备份大小相同的磁盘空间）")
+	backupCmd.Flags().Bool("allow-no-match", false, "包含路径中的通配符没有匹配时跳过而不是报错")
+	backupCmd.Flags().StringVar(&filesFrom, "files-from", "", "从文件读取要备份的路径（- 为标准输入，按行或 NUL 分隔），不做通配符展开")
+	backupCmd.Flags().StringVar(&excludeFrom, "exclude-from", "", "从文件读取排除模式（- 为标准输入，每行一个，# 开头为注释）")
+	addK8sFlags(backupCmd)
+	addTUIFlags(backupCmd)
 }
 
 func runBackup(cmd *cobra.Command, args []string) error {
@@ -75,240 +96,1102 @@ func runBackup(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
 	defer cancel()
 
-	// 加载配置
-	cfg, err := config.LoadConfig(cfgFile, envFile)
+	// 加载配置（命令行参数 > 环境变量 > 配置文件 > 默认值）
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return &configError{err: fmt.Errorf("failed to load config: %w", err)}
 	}
 
-	// 命令行参数覆盖配置
-	if provider != "" {
-		cfg.Storage.Provider = provider
+	// 验证配置
+	if err := cfg.Validate(); err != nil {
+		return &configError{err: fmt.Errorf("invalid config: %w", err)}
 	}
-	if bucket != "" {
-		cfg.Storage.Bucket = bucket
+
+	if filesFrom == "-" && excludeFrom == "-" {
+		return fmt.Errorf("--files-from and --exclude-from cannot both read from stdin")
 	}
-	if endpoint != "" {
-		cfg.Storage.Endpoint = endpoint
+	if excludeFrom != "" {
+		patterns, err := readList(excludeFrom)
+		if err != nil {
+			return err
+		}
+		for _, p := range patterns {
+			if !strings.HasPrefix(p, "#") {
+				cfg.Backup.Excludes = append(cfg.Backup.Excludes, p)
+			}
+		}
 	}
-	if region != "" {
-		cfg.Storage.Region = region
+
+	run := func() (string, error) {
+		return backupArgs(ctx, cmd, cfg, args, startTime)
 	}
-	if accessKey != "" {
-		cfg.Storage.AccessKey = accessKey
+	if !cfg.Backup.Idempotent || dryRun {
+		_, err := run()
+		return err
 	}
-	if secretKey != "" {
-		cfg.Storage.SecretKey = secretKey
+	profile := "default"
+	if cfgFile != "" {
+		profile = profileName(cfgFile)
 	}
-	if storageClass != "" {
-		cfg.Storage.StorageClass = storageClass
+	adapter, err := createStorageAdapter(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create storage adapter: %w", err)
 	}
-	if encrypt {
-		cfg.Encryption.Enabled = true
+	_, err = runIdempotent(ctx, cfg, adapter, profile, startTime, path.Dir(backupName), run)
+	return err
+}
+
+// backupArgs 按命令行参数和配置选择数据库导出源、Docker 卷或包含路径执行备份，返回备份名
+func backupArgs(ctx context.Context, cmd *cobra.Command, cfg *config.Config, args []string, startTime time.Time) (string, error) {
+	// 数据库导出源
+	if cfg.Source.URL != "" {
+		if len(args) > 0 || len(backupOnly) > 0 || len(dockerVolumes) > 0 || filesFrom != "" {
+			return "", fmt.Errorf("cannot combine a database source with backup paths")
+		}
+		dumper, err := dbdump.New(cfg.Source.URL, cfg.GetSourcePassword())
+		if err != nil {
+			return "", err
+		}
+		name := backupName
+		if name == "" {
+			name = sourceBackupName(startTime, dumper.Ext()+compressionSuffix(cfg), cfg.Encryption.Enabled)
+		}
+		return name, backupSource(ctx, cfg, dumper, name)
 	}
-	if password != "" {
-		cfg.Encryption.Password = password
+
+	// Docker 卷
+	volumes, err := resolveDockerVolumes(ctx, dockerVolumes)
+	if err != nil {
+		return "", err
 	}
-	if keyFile != "" {
-		cfg.Encryption.KeyFile = keyFile
+	if len(volumes) == 1 && len(args) == 0 && len(backupOnly) == 0 && filesFrom == "" && !volumes[0].Readable() {
+		name := backupName
+		if name == "" {
+			// 辅助容器总是输出 tar.gz
+			name = defaultBackupName(startTime, ".gz", cfg.Encryption.Enabled)
+		}
+		return name, backupVolumeWithHelper(ctx, cfg, volumes[0], name)
 	}
-	if len(excludes) > 0 {
-		cfg.Backup.Excludes = excludes
+	volPaths, err := volumePaths(volumes)
+	if err != nil {
+		return "", err
 	}
-	if concurrency > 0 {
-		cfg.Backup.Concurrency = concurrency
+
+	// 解析包含路径（命令行路径 + --only 选择的路径组 + Docker 卷目录）
+	selected, err := selectIncludes(append(args, volPaths...), backupOnly, cfg.Backup.Paths)
+	if err != nil {
+		return "", err
+	}
+	includes, err := resolveIncludes(cfg, selected)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve includes: %w", err)
+	}
+	// --files-from 中的路径按原样归档，不做通配符展开
+	if filesFrom != "" {
+		listed, err := readList(filesFrom)
+		if err != nil {
+			return "", err
+		}
+		includes = append(includes, listed...)
 	}
-	if chunkSize > 0 {
-		cfg.Backup.ChunkSize = chunkSize
+	if len(includes) == 0 {
+		return "", fmt.Errorf("no paths to back up")
 	}
 
-	// 验证配置
-	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("invalid config: %w", err)
+	// 生成备份文件名
+	name := backupName
+	if name == "" {
+		name = defaultBackupName(startTime, compressionSuffix(cfg), cfg.Encryption.Enabled)
 	}
 
-	// 解析包含路径
-	includes, err := archive.ResolveIncludes(args)
+	// 命令行显式指定存储类型时忽略按路径的存储类型规则
+	return name, backupIncludes(ctx, cfg, includes, name, !cmd.Flags().Changed("storage-class"))
+}
+
+// backupIncludes 按配置将 includes 备份为名为 baseName 的对象
+// applyRules 为 true 时按 storage_class_rules 拆分为多个备份
+func backupIncludes(ctx context.Context, cfg *config.Config, includes []string, baseName string, applyRules bool) error {
+	printBackupConfig(cfg, baseName)
+	i18n.Printf("  包含路径: %d 个\n", len(includes))
+	fmt.Println()
+
+	groups := []backupGroup{{StorageClass: cfg.Storage.StorageClass, Includes: includes}}
+	if applyRules {
+		groups = planBackupGroups(includes, cfg.Backup.StorageClassRules, cfg.Storage.StorageClass)
+	}
+	if len(groups) > 1 {
+		i18n.Printf("按存储类型规则拆分为 %d 个备份:\n", len(groups))
+		for _, g := range groups {
+			fmt.Printf("  %s: %s\n", groupBackupName(baseName, g.StorageClass), strings.Join(g.Includes, ", "))
+		}
+		fmt.Println()
+	}
+
+	// 统计待备份数据大小，用于费用估算和大小上限检查
+	if estimateCost || cfg.Backup.MaxTotalSize > 0 {
+		sizes, err := groupSizes(ctx, cfg, groups)
+		if err != nil {
+			return err
+		}
+		if estimateCost {
+			printCostEstimate(os.Stdout, cfg, groups, sizes)
+		}
+		var total int64
+		for _, size := range sizes {
+			total += size
+		}
+		if err := checkSizeBudget(os.Stdout, &cfg.Backup, total); err != nil {
+			return err
+		}
+	}
+
+	// 创建存储适配器
+	adapter, err := createStorageAdapter(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to resolve includes: %w", err)
+		return fmt.Errorf("failed to create storage adapter: %w", err)
 	}
 
-	// 生成备份文件名
-	if backupName == "" {
-		timestamp := startTime.Format("20060102-150405")
-		backupName = fmt.Sprintf("backup-%s.tar.gz", timestamp)
-		if cfg.Encryption.Enabled {
-			backupName += ".enc"
+	// 排除模式在每个备份中都没有匹配时才警告
+	unmatched := make(map[string]int)
+	archived := 0
+	for _, g := range groups {
+		name := baseName
+		if len(groups) > 1 {
+			name = groupBackupName(baseName, g.StorageClass)
+		}
+
+		groupCfg := *cfg
+		groupCfg.Storage.StorageClass = g.StorageClass
+		groupCfg.Backup.Excludes = append(append([]string(nil), cfg.Backup.Excludes...), g.Excludes...)
+		var groupUnmatched []string
+		produced := false
+		report := newBackupReport(&groupCfg, name)
+		err := backupStream(ctx, &groupCfg, adapter, name, report, archiveOptions(&groupCfg, g.Includes), func(ctx context.Context, w io.Writer, meter *archive.CompressionMeter) error {
+			arc, err := writeArchive(ctx, w, &groupCfg, g.Includes, meter)
+			if err != nil {
+				return err
+			}
+			groupUnmatched = arc.UnmatchedExcludes()
+			report.setArchive(g.Includes, cfg.Backup.Excludes, arc)
+			produced = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if produced {
+			archived++
+			for _, p := range groupUnmatched {
+				unmatched[p]++
+			}
+		}
+	}
+	if archived > 0 {
+		printUnmatchedExcludes(os.Stdout, cfg.Backup.Excludes, func(p string) bool { return unmatched[p] == archived })
+	}
+	return nil
+}
+
+// archiveOptions 返回按 cfg 归档 includes 时影响数据流内容的设置，保存到续传状态
+func archiveOptions(cfg *config.Config, includes []string) *state.ArchiveOptions {
+	return &state.ArchiveOptions{
+		Includes:         includes,
+		Excludes:         cfg.Backup.Excludes,
+		IgnoreCase:       cfg.Backup.IgnoreCase,
+		Compression:      cfg.Backup.Compression,
+		ZstdDictionary:   cfg.Backup.ZstdDictionary,
+		SmartCompression: cfg.Backup.SmartCompression,
+		StoreExtensions:  cfg.Backup.StoreExtensions,
+		ParallelRoots:    cfg.Backup.ParallelRoots,
+		IncludeDevices:   cfg.Backup.IncludeDevices,
+		Xattrs:           cfg.Backup.Xattrs,
+		SELinux:          cfg.Backup.SELinux,
+		Padding:          cfg.Encryption.Padding,
+		PaddingBucket:    cfg.Encryption.PaddingBucket,
+	}
+}
+
+// applyArchiveOptions 用续传状态中保存的归档设置覆盖 cfg，返回包含路径，重新归档时生成与中断的备份相同的数据流
+func applyArchiveOptions(cfg *config.Config, a *state.ArchiveOptions) []string {
+	cfg.Backup.Excludes = a.Excludes
+	cfg.Backup.IgnoreCase = a.IgnoreCase
+	cfg.Backup.Compression = a.Compression
+	cfg.Backup.ZstdDictionary = a.ZstdDictionary
+	cfg.Backup.SmartCompression = a.SmartCompression
+	cfg.Backup.StoreExtensions = a.StoreExtensions
+	cfg.Backup.ParallelRoots = a.ParallelRoots
+	cfg.Backup.IncludeDevices = a.IncludeDevices
+	cfg.Backup.Xattrs = a.Xattrs
+	cfg.Backup.SELinux = a.SELinux
+	cfg.Encryption.Padding = a.Padding
+	cfg.Encryption.PaddingBucket = a.PaddingBucket
+	return a.Includes
+}
+
+// printUnmatchedExcludes 输出没有匹配任何路径的排除模式
+func printUnmatchedExcludes(w io.Writer, excludes []string, isUnmatched func(pattern string) bool) {
+	seen := make(map[string]bool)
+	for _, p := range excludes {
+		if seen[p] || !isUnmatched(p) {
+			continue
 		}
+		seen[p] = true
+		i18n.Fprintf(w, "[警告] 排除模式没有匹配任何路径: %s\n", p)
+	}
+}
+
+// resolveIncludes 解析包含路径，backup.allow_no_match 为 true 时允许通配符没有匹配
+func resolveIncludes(cfg *config.Config, includes []string) ([]string, error) {
+	if cfg.Backup.AllowNoMatch {
+		return archive.ResolveIncludesAllowEmpty(includes)
 	}
+	return archive.ResolveIncludes(includes)
+}
 
-	fmt.Printf("备份配置:\n")
-	fmt.Printf("  存储提供商: %s\n", cfg.Storage.Provider)
-	fmt.Printf("  存储桶: %s\n", cfg.Storage.Bucket)
-	fmt.Printf("  存储类型: %s\n", cfg.Storage.StorageClass)
-	fmt.Printf("  加密: %v\n", cfg.Encryption.Enabled)
-	fmt.Printf("  并发数: %d\n", cfg.Backup.Concurrency)
-	fmt.Printf("  分块大小: %d MB\n", cfg.Backup.ChunkSize/1024/1024)
-	fmt.Printf("  备份文件: %s\n", backupName)
-	fmt.Printf("  包含路径: %d 个\n", len(includes))
+// readList 读取 --files-from/--exclude-from 指定的列表，path 为 "-" 时读取标准输入
+func readList(path string) ([]string, error) {
+	if path == "-" {
+		return archive.ReadFileList(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open list file: %w", err)
+	}
+	defer f.Close()
+	return archive.ReadFileList(f)
+}
+
+// printBackupConfig 输出备份使用的存储、加密和上传参数
+func printBackupConfig(cfg *config.Config, name string) {
+	i18n.Printf("备份配置:\n")
+	i18n.Printf("  存储提供商: %s\n", cfg.Storage.Provider)
+	i18n.Printf("  存储桶: %s\n", cfg.Storage.Bucket)
+	i18n.Printf("  存储类型: %s\n", cfg.Storage.StorageClass)
+	i18n.Printf("  加密: %v\n", cfg.Encryption.Enabled)
+	if cfg.Backup.AutoConcurrency {
+		i18n.Printf("  并发数: 自动 (初始 %d，上限 %d)\n", cfg.Backup.Concurrency, cfg.Backup.ConcurrencyMax)
+	} else {
+		i18n.Printf("  并发数: %d\n", cfg.Backup.Concurrency)
+	}
+	if cfg.Backup.AutoChunkSize {
+		i18n.Printf("  分块大小: 自动 (%d ~ %d MB)\n", cfg.Backup.ChunkSizeMin/1024/1024, cfg.Backup.ChunkSizeMax/1024/1024)
+	} else {
+		i18n.Printf("  分块大小: %d MB\n", cfg.Backup.ChunkSize/1024/1024)
+	}
+	i18n.Printf("  备份文件: %s\n", name)
+}
+
+// backupSource 将数据库导出数据压缩（可选加密）后上传为名为 name 的对象
+func backupSource(ctx context.Context, cfg *config.Config, dumper *dbdump.Dumper, name string) error {
+	printBackupConfig(cfg, name)
+	i18n.Printf("  数据源: %s\n", dumper)
 	fmt.Println()
 
-	// 创建存储适配器
 	adapter, err := createStorageAdapter(ctx, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create storage adapter: %w", err)
 	}
+	report := newBackupReport(cfg, name)
+	report.setSource(dumper.String())
+	return backupOnce(ctx, cfg, adapter, name, report, func(ctx context.Context, w io.Writer, meter *archive.CompressionMeter) error {
+		return writeDump(ctx, w, cfg, dumper, meter)
+	})
+}
+
+// producer 将备份数据写入 w，并通过 meter 统计压缩前后的字节数（数据由外部工具压缩时不统计）
+type producer func(ctx context.Context, w io.Writer, meter *archive.CompressionMeter) error
+
+// source 返回使用 meter 统计的 pipeline.Source
+func (p producer) source(meter *archive.CompressionMeter) pipeline.Source {
+	return func(ctx context.Context, w io.Writer) error {
+		return p(ctx, w, meter)
+	}
+}
 
-	// 创建状态管理器
-	stateMgr := state.NewStateManager(stateDir, backupName)
+// checkNotExists 上传前确认对象 name 不存在，避免同名的备份互相覆盖
+// 无法读取元数据（只写凭证等）时跳过检查，由支持条件写入的存储在完成上传时拒绝覆盖
+func checkNotExists(ctx context.Context, adapter storage.StorageAdapter, name string) error {
+	meta, ok := adapter.(storage.MetadataReader)
+	if !ok {
+		return nil
+	}
+	_, err := meta.ObjectMetadata(ctx, name)
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: %s", storage.ErrObjectExists, name)
+	case errors.Is(err, storage.ErrObjectNotFound), errors.Is(err, storage.ErrAuth):
+		return nil
+	default:
+		i18n.Printf("警告: 无法检查 %s 是否已存在: %v\n", name, err)
+		return nil
+	}
+}
 
-	// 创建 io.Pipe 连接归档和上传
-	pr, pw := io.Pipe()
+// backupOnce 将 produce 写出的数据上传为名为 name 的对象
+// 配置了 backup.sign_key 时上传成功后对对象的 SHA-256 签名，上传为 <name>.sig；
+// report 非 nil 时记录对象大小和 SHA-256，最后将报告上传为 <name>.report.json
+// 配置了 backup.spool_dir 时先写入本地临时文件再上传，见 spoolBackup
+// produce 的数据流（数据库导出、Docker 辅助容器）无法由 resume 重新生成，上传失败时不保存续传状态
+func backupOnce(ctx context.Context, cfg *config.Config, adapter storage.StorageAdapter, name string,
+	report *backupReport, produce producer) error {
+	return backupStream(ctx, cfg, adapter, name, report, nil, produce)
+}
 
-	// 错误通道
-	errChan := make(chan error, 3)
+// backupStream 同 backupOnce；arcOpts 不为 nil 时 produce 按 arcOpts 归档包含路径，arcOpts 保存到续传状态，
+// resume 按其重新归档续传，上传失败时保留已上传的分块和续传状态（启用加密时包括文件头，见 withEncryption）
+func backupStream(ctx context.Context, cfg *config.Config, adapter storage.StorageAdapter, name string,
+	report *backupReport, arcOpts *state.ArchiveOptions, produce producer) (err error) {
+	ctx, span := tracing.Start(ctx, "backup",
+		attribute.String("s3backup.object", name),
+		attribute.String("s3backup.provider", cfg.Storage.Provider),
+		attribute.String("s3backup.bucket", cfg.Storage.Bucket),
+		attribute.Bool("s3backup.encrypted", cfg.Encryption.Enabled),
+		attribute.Bool("s3backup.dry_run", dryRun),
+	)
+	defer func() { tracing.End(span, err) }()
 
-	// 启动归档 goroutine
-	go func() {
-		defer pw.Close()
-		var writer io.Writer = pw
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	started := time.Now()
 
-		// 创建加密器
-		if cfg.Encryption.Enabled {
-			encryptor, err := createEncryptor(cfg)
-			if err != nil {
-				cancel()
-				errChan <- err
-				return
-			}
-			encWriter, err := encryptor.WrapWriter(pw)
-			if err != nil {
-				cancel()
-				errChan <- fmt.Errorf("failed to create encrypt writer: %w", err)
-				return
-			}
-			defer func() {
-				if err := encWriter.Close(); err != nil {
-					errChan <- fmt.Errorf("failed to close encryptor: %w", err)
-				}
-			}()
-			writer = encWriter
+	// 上传前读取签名密钥，避免上传完成后才发现密钥不可用
+	var signKey ed25519.PrivateKey
+	if cfg.Backup.SignKey != "" {
+		var err error
+		if signKey, err = crypto.LoadSigningKey(cfg.Backup.SignKey); err != nil {
+			return err
 		}
+	}
 
-		// 创建归档器
-		archiver, err := archive.NewArchiver(includes, cfg.Backup.Excludes)
+	if !dryRun {
+		if err := checkBucketMarker(ctx, adapter, name); err != nil {
+			return err
+		}
+		// 持有共享锁，避免其他机器上的 prune 在上传期间清理同一前缀
+		lock, err := acquireLock(ctx, os.Stdout, adapter, path.Dir(name), "backup", false, cfg.Storage.ACL)
 		if err != nil {
-			cancel()
-			errChan <- fmt.Errorf("failed to create archiver: %w", err)
-			return
+			return err
 		}
+		defer lock.release(os.Stdout)
+		if !cfg.Backup.Overwrite {
+			if err := checkNotExists(ctx, adapter, name); err != nil {
+				return err
+			}
+		}
+	}
 
-		// 执行归档
-		if err := archiver.Archive(ctx, writer); err != nil {
-			cancel()
-			errChan <- fmt.Errorf("failed to archive: %w", err)
-			return
+	// 创建状态管理器，state.no_resume 时不保存续传状态
+	var stateMgr *state.StateManager
+	if !cfg.State.NoResume {
+		var err error
+		if stateMgr, err = newStateManager(cfg, name); err != nil {
+			return err
 		}
-	}()
+	}
 
-	// 上传
-	if !dryRun {
-		// 创建上传器
-		upl := uploader.NewUploader(adapter, cfg.Backup.ChunkSize, cfg.Backup.Concurrency)
-		upl.SetStateManager(stateMgr)
+	if cfg.Backup.SpoolDir != "" && !dryRun {
+		return spoolBackup(ctx, cfg, adapter, name, stateMgr, signKey, report, started, produce)
+	}
+	if arcOpts == nil {
+		stateMgr = nil
+	}
 
-		// 设置进度报告器
-		var reporter progress.Reporter
-		if noProgress {
-			reporter = progress.NewSilent()
-		} else {
-			reporter = progress.NewBar()
-		}
-		upl.SetProgressReporter(reporter)
-		defer reporter.Close()
+	meter := archive.NewCompressionMeter(cfg.Backup.ChunkSize)
 
-		// 上传选项
-		contentType := "application/gzip"
-		if cfg.Encryption.Enabled {
-			contentType = "application/octet-stream"
-		}
-		opts := storage.UploadOptions{
-			StorageClass: storage.ParseStorageClass(cfg.Storage.StorageClass),
-			ContentType:  contentType,
+	// 设置进度报告器，归档与上传同时进行，归档完成后才知道上传的总字节数
+	var reporter progress.Reporter = progress.NewSilent()
+	switch {
+	case dashboard != nil:
+		reporter = dashboard
+	case !dryRun:
+		reporter = newProgressReporter()
+	}
+	defer reporter.Close()
+	if cfg.Encryption.Enabled {
+		reporter.SetPhase("Archiving → Encrypting → Uploading")
+	} else {
+		reporter.SetPhase("Archiving → Uploading")
+	}
+
+	if dryRun {
+		// 模拟运行：只归档不上传
+		if err := pipeline.Run(ctx, produce.source(meter), pipeline.Discard); err != nil {
+			return err
 		}
+		printCompressionStats(os.Stdout, meter.Stats())
+		i18n.Printf("模拟运行完成（未实际上传）\n")
+		return nil
+	}
 
-		// 保存初始状态
+	// 创建上传器
+	upl := uploader.NewUploader(adapter, cfg.Backup.ChunkSize, cfg.Backup.Concurrency)
+	if upl.ChunkSize() != cfg.Backup.ChunkSize {
+		i18n.Printf("警告: 分块大小超出 %s 的限制，已调整为 %d 字节\n", cfg.Storage.Provider, upl.ChunkSize())
+	}
+	if stateMgr != nil {
+		upl.SetStateManager(stateMgr)
+	}
+	upl.SetHeartbeat(heartbeatFor(cfg))
+	upl.SetReadAhead(cfg.Backup.ReadAhead)
+	upl.SetETagRetries(cfg.Backup.ETagRetries)
+	if cfg.Backup.AutoChunkSize {
+		upl.SetPartSizeTuner(uploader.NewPartSizeTuner(cfg.Backup.ChunkSize, cfg.Backup.ChunkSizeMin, cfg.Backup.ChunkSizeMax))
+	}
+	if cfg.Backup.AutoConcurrency {
+		upl.SetConcurrencyController(uploader.NewConcurrencyController(cfg.Backup.Concurrency, 1, cfg.Backup.ConcurrencyMax))
+	}
+
+	upl.SetProgressReporter(reporter)
+
+	// 上传选项
+	opts := storage.UploadOptions{
+		StorageClass:       storage.ParseStorageClass(cfg.Storage.StorageClass),
+		ContentType:        backupContentType(name, cfg.Encryption.Enabled),
+		ContentDisposition: storage.ContentDispositionFor(name),
+		Metadata:           backupMetadata(cfg),
+		ACL:                cfg.Storage.ACL,
+		NoOverwrite:        !cfg.Backup.Overwrite,
+	}
+
+	// 保存初始状态，resume 按其中的存储信息重新连接
+	if stateMgr != nil {
 		initialState := &state.UploadState{
-			Key:          backupName,
+			Key:          name,
 			Bucket:       cfg.Storage.Bucket,
 			Provider:     cfg.Storage.Provider,
+			Endpoint:     cfg.Storage.Endpoint,
+			Region:       cfg.Storage.Region,
 			StorageClass: cfg.Storage.StorageClass,
-			Encrypted:    cfg.Encryption.Enabled,
 			Completed:    []state.CompletedPart{},
+			Archive:      arcOpts,
+			NoOverwrite:  opts.NoOverwrite,
+			Metadata:     opts.Metadata,
+		}
+		// 预先生成加密文件头并保存，resume 重新归档时生成相同的密文
+		var h *crypto.Header
+		if cfg.Encryption.Enabled {
+			encryptor, err := createEncryptor(cfg)
+			if err != nil {
+				return err
+			}
+			if h, err = encryptor.NewHeader(); err != nil {
+				return err
+			}
+			ctx = withEncryption(ctx, encryptor, h)
+			initialState.Header, initialState.KeyCheck = h.Bytes(), encryptor.KeyCheck(h)
+		}
+		if err := setStateEncryption(cfg, initialState, h); err != nil {
+			return err
 		}
 		stateMgr.Save(initialState)
+	}
 
-		// 启动上传 goroutine
-		go func() {
-			if err := upl.Upload(ctx, backupName, pr, opts); err != nil {
-				cancel()
-				errChan <- fmt.Errorf("failed to upload: %w", err)
-				return
-			}
-			errChan <- nil
-		}()
-
-		// 等待完成
-		if err := <-errChan; err != nil {
-			// 上传失败，状态已保存，可以使用 resume 恢复
-			fmt.Printf("\n上传失败，状态已保存。使用以下命令恢复:\n")
-			fmt.Printf("  s3backup resume %s\n", backupName)
+	// 归档数据写入 uploader.Writer，边归档边分块上传
+	sink := uploadSink{upl.NewWriter(ctx, name, opts)}
+
+	// 签名、生成报告或上传后校验时统计上传对象的大小和 SHA-256
+	cw := &countingWriter{w: io.Discard}
+	filters := []pipeline.Filter{pipeline.Tap(cw)}
+	var hw *hashingWriter
+	if report != nil || signKey != nil || cfg.Backup.VerifyUpload {
+		hw = newHashingWriter(io.Discard)
+		filters = append(filters, pipeline.Tap(hw))
+	}
+
+	src := func(ctx context.Context, w io.Writer) error {
+		if err := produce(ctx, w, meter); err != nil {
 			return err
 		}
+		// 归档完成，剩余的是等待已写入的分块上传完成
+		reporter.SetTotal(cw.n)
+		reporter.SetPhase("Uploading")
+		return nil
+	}
+	if err := pipeline.Run(ctx, src, sink, filters...); err != nil {
+		return uploadFailed(err, stateMgr, name)
+	}
 
-		// 删除状态文件
+	// 删除状态文件
+	if stateMgr != nil {
 		stateMgr.Delete()
-	} else {
-		// 模拟运行：只读取数据不上传
-		go func() {
-			buf := make([]byte, 32*1024)
-			for {
-				_, err := pr.Read(buf)
-				if err != nil {
-					if err == io.EOF {
-						errChan <- nil
-					} else {
-						cancel()
-						errChan <- fmt.Errorf("failed to read: %w", err)
-					}
-					return
-				}
-			}
-		}()
+	}
+
+	stats := upl.Stats()
+	i18n.Printf("上传统计: %d 个分块，限流 %d 次，重试 %d 次\n", stats.Parts, stats.Throttled, stats.Retries)
+	if stats.ETagMismatches > 0 {
+		i18n.Printf("警告: %d 次分块 ETag 与本地 MD5 不一致（数据在传输中损坏），已重新上传\n", stats.ETagMismatches)
+	}
+	printPipelineStats(stats)
+	printCompressionStats(os.Stdout, meter.Stats())
+
+	report.setCompression(meter.Stats())
+	if err := finishBackup(ctx, cfg, adapter, name, signKey, report, hw, started); err != nil {
+		return err
+	}
+
+	i18n.Printf("备份成功: %s\n", name)
+	return nil
+}
+
+// uploadSink 备份上传的 pipeline.Sink，Close 返回的上传错误加上上下文
+type uploadSink struct {
+	*uploader.Writer
+}
 
-		if err := <-errChan; err != nil {
+func (s uploadSink) Close() error {
+	if err := s.Writer.Close(); err != nil {
+		return fmt.Errorf("failed to upload: %w", err)
+	}
+	return nil
+}
+
+// uploadFailed 输出备份失败的排查建议，保留了未完成的上传和续传状态时提示使用 resume 恢复，返回 err
+func uploadFailed(err error, stateMgr *state.StateManager, name string) error {
+	if hint := errorHint(err); hint != "" {
+		i18n.Printf("\n提示: %s\n", hint)
+	}
+	if stateMgr == nil {
+		return err
+	}
+	// 数据源出错等情况下上传已被取消，状态随之删除，无法续传
+	stateMgr.Flush()
+	if s := stateMgr.GetState(); s == nil || s.UploadID == "" {
+		stateMgr.Delete()
+		return err
+	}
+	i18n.Printf("\n上传失败，状态已保存。使用以下命令恢复:\n")
+	fmt.Printf("  s3backup resume %s\n", name)
+	return err
+}
+
+// finishBackup 备份对象上传成功后上传签名和备份报告，hw 为统计上传对象大小和 SHA-256 的 hashingWriter
+// 签名和报告使用与备份相同的 ACL；启用 backup.verify_upload 时最后执行上传后校验（见 checkUpload）
+func finishBackup(ctx context.Context, cfg *config.Config, adapter storage.StorageAdapter, name string,
+	signKey ed25519.PrivateKey, report *backupReport, hw *hashingWriter, started time.Time) error {
+	if signKey != nil {
+		sigKey, err := uploadSignature(ctx, adapter, name, signKey, hw.Digest(), cfg.Storage.ACL)
+		if err != nil {
 			return err
 		}
-		fmt.Println("模拟运行完成（未实际上传）")
+		i18n.Printf("签名: %s\n", sigKey)
+		report.setSignature(sigKey)
+	}
+
+	// 报告上传失败不影响已完成的备份
+	var reportKey string
+	if report != nil {
+		report.Started = started
+		report.Finished = time.Now()
+		report.Duration = report.Finished.Sub(started).Seconds()
+		report.Size = hw.n
+		report.SHA256 = hw.Sum()
+		report.ChunkSize = sampleChunkSize
+		report.ChunkSHA256 = hw.Chunks()
+		if key, err := uploadReport(ctx, adapter, report, cfg.Storage.ACL); err != nil {
+			i18n.Printf("警告: %v\n", err)
+		} else {
+			i18n.Printf("备份报告: %s\n", key)
+			reportKey = key
+		}
+	}
+
+	if cfg.Backup.VerifyUpload {
+		if err := checkUpload(ctx, adapter, name, hw, reportKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectIncludes 合并命令行路径和 --only 选择的 backup.paths 路径组，重复的路径只保留一个
+func selectIncludes(args, only []string, groups map[string][]string) ([]string, error) {
+	includes := append([]string(nil), args...)
+	for _, name := range only {
+		// viper 会将配置键转为小写
+		paths, ok := groups[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown path group %q in backup.paths (available: %s)", name, strings.Join(pathGroupNames(groups), ", "))
+		}
+		includes = append(includes, paths...)
+	}
+	if len(includes) == 0 {
+		return nil, fmt.Errorf("no paths to back up: specify paths, --only or --docker-volume")
+	}
+
+	seen := make(map[string]bool, len(includes))
+	unique := includes[:0]
+	for _, p := range includes {
+		if !seen[p] {
+			seen[p] = true
+			unique = append(unique, p)
+		}
+	}
+	return unique, nil
+}
+
+// pathGroupNames 返回 backup.paths 中的路径组名称（已排序）
+func pathGroupNames(groups map[string][]string) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultBackupName 生成默认备份文件名 backup-{timestamp}.tar{suffix}[.enc]，suffix 为压缩格式的扩展名（.gz、.zst）
+func defaultBackupName(t time.Time, suffix string, encrypted bool) string {
+	name := fmt.Sprintf("backup-%s.tar%s", t.Format("20060102-150405"), suffix)
+	if encrypted {
+		name += ".enc"
+	}
+	return name
+}
+
+// backupMetadata 返回写入备份对象元数据的工具和格式信息，使多年后的备份仍能说明自己是如何生成的
+func backupMetadata(cfg *config.Config) map[string]string {
+	meta := map[string]string{
+		"s3backup-version":     version.Version,
+		"s3backup-format":      "plain",
+		"s3backup-cipher":      "none",
+		"s3backup-compression": compressionName(cfg),
+	}
+	if cfg.Encryption.Enabled {
+		meta["s3backup-format"] = strconv.Itoa(crypto.FormatVersion)
+		meta["s3backup-cipher"] = crypto.CipherName
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		meta["s3backup-host"] = host
+	}
+	if cfg.Backup.ExpireAfter != "" {
+		// 格式已由 Validate 检查
+		d, _ := config.ParseDuration(cfg.Backup.ExpireAfter)
+		meta[expiresMetaKey] = time.Now().Add(d).UTC().Format(time.RFC3339)
+	}
+	return meta
+}
+
+// backupContentType 返回备份对象的 Content-Type
+// 加密后的数据总是 application/octet-stream；未加密时按名称后缀推断，无法识别时按实际格式（tar.gz）设置
+func backupContentType(name string, encrypted bool) string {
+	if encrypted {
+		return "application/octet-stream"
+	}
+	if ct := storage.ContentTypeFor(name); ct != "application/octet-stream" {
+		return ct
+	}
+	return "application/gzip"
+}
+
+// writeArchive 将 includes 归档压缩后写入 w，启用加密时经过加密层，返回归档器以便查询统计和跳过的文件
+// backup 和 pack 共用此函数，保证本地生成的文件与上传的对象格式完全一致；meter 不为 nil 时统计压缩前后的字节数
+func writeArchive(ctx context.Context, w io.Writer, cfg *config.Config, includes []string, meter *archive.CompressionMeter) (*archive.Archiver, error) {
+	archiver, err := newArchiver(cfg, includes, cfg.Backup.Excludes, meter)
+	if err != nil {
+		return nil, err
+	}
+	err = writeEncrypted(ctx, w, cfg, func(w io.Writer) error {
+		if err := archiver.Archive(ctx, w); err != nil {
+			return fmt.Errorf("failed to archive: %w", err)
+		}
 		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// --quiet 时逐个文件的警告被丢弃，只在标准错误输出汇总
+	if n := len(archiver.Skipped()); quiet && n > 0 {
+		i18n.Fprintf(os.Stderr, "警告: 跳过了 %d 个无法访问或不支持的文件（使用 --report 记录完整列表）\n", n)
+	} else if archiver.SuppressedWarnings() > 0 {
+		i18n.Printf("提示: 使用 --max-warnings -1 显示全部警告，备份时使用 --report 在备份报告中记录完整的跳过列表\n")
 	}
+	return archiver, nil
+}
 
-	fmt.Printf("备份成功: %s\n", backupName)
+// newArchiver 按 backup 配置创建归档器
+func newArchiver(cfg *config.Config, includes, excludes []string, meter *archive.CompressionMeter) (*archive.Archiver, error) {
+	opts := archive.Options{IgnoreCase: cfg.Backup.IgnoreCase, Meter: meter}
+	// 其余包含路径的输出先暂存到临时文件，设置了 spool_dir 时与备份文件放在同一磁盘
+	opts.Parallel, opts.TempDir = cfg.Backup.ParallelRoots, cfg.Backup.SpoolDir
+	opts.MaxWarnings, opts.Strict = cfg.Backup.MaxWarnings, cfg.Backup.Strict
+	opts.Devices, opts.Xattrs, opts.SELinux = cfg.Backup.IncludeDevices, cfg.Backup.Xattrs, cfg.Backup.SELinux
+	if cfg.Backup.Report {
+		opts.HashSample = reportFileSamples
+	}
+	if cfg.Backup.Compression == "zstd" {
+		dictionary, err := loadDictionary(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts.Zstd, opts.ZstdDictionary = true, dictionary
+	}
+	if cfg.Backup.SmartCompression {
+		opts.StoreExtensions = cfg.Backup.StoreExtensions
+		if len(opts.StoreExtensions) == 0 {
+			opts.StoreExtensions = archive.DefaultStoreExtensions
+		}
+	}
+	if dashboard != nil {
+		opts.OnFile = dashboard.SetFile
+	}
+	archiver, err := archive.NewArchiverWithOptions(includes, excludes, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archiver: %w", err)
+	}
+	return archiver, nil
+}
+
+// writeEncrypted 调用 write 写出数据，启用加密时经过填充和加密处理阶段（见 encryptFilters）后写入 w
+// write 写出的必须是 backup.compression 对应格式的压缩流，填充才能在解压时被忽略
+func writeEncrypted(ctx context.Context, w io.Writer, cfg *config.Config, write func(w io.Writer) error) (err error) {
+	if !cfg.Encryption.Enabled {
+		return write(w)
+	}
+
+	// 加密与归档在同一个 goroutine 中流式进行，encrypt span 覆盖整个写出过程
+	_, span := tracing.Start(ctx, "encrypt", attribute.String("s3backup.cipher", crypto.CipherName))
+	defer func() { tracing.End(span, err) }()
+
+	filters, err := encryptFilters(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	src := func(ctx context.Context, w io.Writer) error { return write(w) }
+	return pipeline.Run(ctx, src, pipeline.ToWriter(w), filters...)
+}
+
+// encryptFilters 返回启用加密时的处理阶段：先按 encryption.padding 在压缩流之后追加填充，再经过加密层，
+// 关闭时写入 HMAC；ctx 由 withEncryption 指定了文件头时使用该文件头，否则随机生成
+func encryptFilters(ctx context.Context, cfg *config.Config) ([]pipeline.Filter, error) {
+	fixed, _ := ctx.Value(encryptionKey{}).(*fixedEncryption)
+	if fixed == nil {
+		encryptor, err := createEncryptor(cfg)
+		if err != nil {
+			return nil, err
+		}
+		fixed = &fixedEncryption{encryptor: encryptor}
+	}
+	encrypt := func(w io.Writer) (io.WriteCloser, error) {
+		var encWriter io.WriteCloser
+		var err error
+		if fixed.header != nil {
+			encWriter, err = fixed.encryptor.WrapWriterWithHeader(w, fixed.header)
+		} else {
+			encWriter, err = fixed.encryptor.WrapWriter(w)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create encrypt writer: %w", err)
+		}
+		return &closeWrapper{WriteCloser: encWriter, msg: "failed to close encryptor"}, nil
+	}
+	pad := func(w io.Writer) (io.WriteCloser, error) {
+		return &paddingWriter{countingWriter: countingWriter{w: w}, cfg: cfg}, nil
+	}
+	return []pipeline.Filter{pad, encrypt}, nil
+}
+
+// encryptionKey 见 withEncryption
+type encryptionKey struct{}
+
+// fixedEncryption 预先生成的加密器和文件头
+type fixedEncryption struct {
+	encryptor *crypto.StreamEncryptor
+	header    *crypto.Header
+}
+
+// withEncryption 返回的 ctx 中，writeEncrypted 使用加密器 e 和文件头 h，而不是每次随机生成 IV（和盐值）
+// 流式备份上传前生成文件头并保存到续传状态，resume 重新归档时用同一个文件头生成与已上传分块相同的密文
+func withEncryption(ctx context.Context, e *crypto.StreamEncryptor, h *crypto.Header) context.Context {
+	return context.WithValue(ctx, encryptionKey{}, &fixedEncryption{encryptor: e, header: h})
+}
+
+// paddingWriter 统计写入的压缩流长度，Close 时按 encryption.padding 追加填充
+type paddingWriter struct {
+	countingWriter
+	cfg *config.Config
+}
+
+func (p *paddingWriter) Close() error {
+	return writePadding(p.w, p.cfg, p.n)
+}
+
+// closeWrapper 为 Close 返回的错误加上 msg 说明
+type closeWrapper struct {
+	io.WriteCloser
+	msg string
+}
+
+func (c *closeWrapper) Close() error {
+	if err := c.WriteCloser.Close(); err != nil {
+		return fmt.Errorf("%s: %w", c.msg, err)
+	}
 	return nil
 }
 
-// createStorageAdapter 创建存储适配器
+// writePadding 按 encryption.padding 在 n 字节的压缩流之后写入填充，使加密后的对象（含文件头和 trailer）落在档位上
+func writePadding(w io.Writer, cfg *config.Config, n int64) error {
+	var bucket int64
+	switch cfg.Encryption.Padding {
+	case "pow2":
+	case "bucket":
+		bucket = cfg.Encryption.PaddingBucket
+	default:
+		return nil
+	}
+	zstd := cfg.Backup.Compression == "zstd"
+	pad := archive.PadLength(int64(crypto.HeaderSize)+n+crypto.TrailerSize, bucket, zstd)
+	return archive.WritePadding(w, pad, zstd)
+}
+
+// writeDump 将数据库导出数据按 backup.compression 以 gzip 或 zstd 压缩后写入 w，启用加密时经过加密层；
+// meter 不为 nil 时统计压缩前后的字节数
+func writeDump(ctx context.Context, w io.Writer, cfg *config.Config, dumper *dbdump.Dumper, meter *archive.CompressionMeter) error {
+	var dictionary []byte
+	if cfg.Backup.Compression == "zstd" {
+		var err error
+		if dictionary, err = loadDictionary(cfg); err != nil {
+			return err
+		}
+	}
+	dump := func(ctx context.Context, w io.Writer) error {
+		if err := dumper.Dump(ctx, w); err != nil {
+			return fmt.Errorf("failed to dump %s: %w", dumper.Kind(), err)
+		}
+		return nil
+	}
+	return writeEncrypted(ctx, w, cfg, func(w io.Writer) error {
+		return pipeline.Run(ctx, dump, pipeline.ToWriter(w), compressFilter(cfg, dictionary, meter))
+	})
+}
+
+// compressFilter 返回按 backup.compression 以 gzip 或 zstd 压缩的处理阶段，meter 不为 nil 时统计压缩前后的字节数
+func compressFilter(cfg *config.Config, dictionary []byte, meter *archive.CompressionMeter) pipeline.Filter {
+	return func(w io.Writer) (io.WriteCloser, error) {
+		if meter != nil {
+			w = meter.CompressedWriter(w)
+		}
+		var zw io.WriteCloser = gzip.NewWriter(w)
+		if cfg.Backup.Compression == "zstd" {
+			var err error
+			if zw, err = archive.NewZstdWriter(w, dictionary); err != nil {
+				return nil, err
+			}
+		}
+		c := &closeWrapper{WriteCloser: zw, msg: "failed to close compressor"}
+		if meter == nil {
+			return c, nil
+		}
+		return struct {
+			io.Writer
+			io.Closer
+		}{meter.RawWriter(zw), c}, nil
+	}
+}
+
+// sourceBackupName 生成数据库导出的默认备份文件名 backup-{timestamp}{ext}[.enc]，ext 包含压缩格式的扩展名（如 .sql.gz）
+func sourceBackupName(t time.Time, ext string, encrypted bool) string {
+	name := fmt.Sprintf("backup-%s%s", t.Format("20060102-150405"), ext)
+	if encrypted {
+		name += ".enc"
+	}
+	return name
+}
+
+// errorHint 根据存储错误分类给出排查建议
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, storage.ErrAuth):
+		return i18n.T("认证失败，请检查 access_key/secret_key 是否正确以及是否有该存储桶的写权限")
+	case errors.Is(err, storage.ErrBucketNotFound):
+		return i18n.T("存储桶不存在，请检查 bucket 名称以及 region/endpoint 是否正确")
+	case errors.Is(err, storage.ErrEntityTooSmall):
+		return i18n.T("分块小于存储提供商的最小分块大小，请增大 chunk_size")
+	case errors.Is(err, storage.ErrThrottled):
+		return i18n.T("请求被存储提供商限流，请降低 concurrency 或启用 --auto-concurrency")
+	case errors.Is(err, storage.ErrNetwork):
+		return i18n.T("网络错误，请检查网络连接和 endpoint 配置")
+	case errors.Is(err, storage.ErrUploadNotFound):
+		return i18n.T("分块上传在服务端已不存在（可能已被取消或被生命周期规则清理），需要重新上传")
+	case errors.Is(err, storage.ErrStorageClassNotApplied):
+		return i18n.T("备份已上传，但存储中的存储类型与配置不一致，请检查存储桶是否支持该存储类型")
+	case errors.Is(err, archive.ErrStrict):
+		return i18n.T("--strict 模式下遇到无法完整备份的路径，请修复权限、将其加入排除模式或去掉 --strict")
+	case errors.Is(err, storage.ErrObjectExists):
+		return i18n.T("存储中已存在同名备份，请使用 --name 指定其他名称（如在名称模板中加入主机名），或使用 --overwrite 覆盖")
+	case errors.Is(err, ErrReadOnlyCredentials):
+		return i18n.T("当前只配置了只读凭证（storage.restore_access_key），只能执行 restore、rehearse、verify、thaw 和 freshness；备份需要配置 access_key 和 secret_key")
+	case errors.Is(err, ErrWriteOnly):
+		return i18n.T("已开启 storage.write_only，当前凭证只能上传备份；请在持有列出和删除权限的管理主机上运行 prune，或配置只读凭证 restore_access_key 用于还原和校验")
+	case errors.Is(err, ErrLocked):
+		return i18n.T("其他机器上的 backup 或 prune 正在使用该前缀，请等待其完成；持有者已退出时锁会在 30 分钟未刷新后过期")
+	case errors.Is(err, ErrBucketMarker):
+		return i18n.T("备份前缀中的 .s3backup.json 标记对象要求其他工具或更高版本，请升级 s3backup 或使用其他前缀（--name dir/...）")
+	case errors.Is(err, ErrUploadCheck):
+		return i18n.T("存储中的备份与本地生成的数据不一致，可能在传输或存储中损坏，请检查后重新备份")
+	case errors.Is(err, uploader.ErrStreamMismatch):
+		return i18n.T("重新生成的数据与已上传的分块不一致，说明源文件在中断后发生了变化，无法续传；使用 resume --force-restart 取消已上传的分块并重新开始")
+	default:
+		return ""
+	}
+}
+
+// printPipelineStats 输出流水线等待时间，并根据瓶颈提示调整方向
+func printPipelineStats(stats uploader.Stats) {
+	if stats.Elapsed <= 0 {
+		return
+	}
+	percent := func(d time.Duration) float64 {
+		return d.Seconds() / stats.Elapsed.Seconds() * 100
+	}
+	i18n.Printf("流水线: 等待数据 %s（%.0f%%），等待上传 %s（%.0f%%）\n",
+		stats.ReadWait.Round(time.Millisecond), percent(stats.ReadWait),
+		stats.SendWait.Round(time.Millisecond), percent(stats.SendWait))
+
+	switch stats.Bottleneck() {
+	case uploader.BottleneckSource:
+		i18n.Printf("提示: 上传器大部分时间在等待归档数据，瓶颈在读取文件和压缩，增加并发数不会加快备份；可以排除视频、压缩包等无法再压缩的大文件\n")
+	case uploader.BottleneckNetwork:
+		i18n.Printf("提示: 归档数据大部分时间在等待上传，瓶颈在网络；可以增加 --concurrency 或使用 --auto-concurrency\n")
+	}
+}
+
+// incompressibleRatio 压缩比不低于该值时认为数据几乎无法压缩
+const incompressibleRatio = 0.95
+
+// printCompressionStats 输出压缩前后的大小和压缩比，帮助判断数据是否值得压缩；数据不是本程序压缩的时不输出
+func printCompressionStats(w io.Writer, stats archive.CompressionStats) {
+	if stats.Raw == 0 {
+		return
+	}
+	i18n.Fprintf(w, "压缩: 原始 %.1f MB，压缩后 %.1f MB，压缩比 %.2f\n",
+		float64(stats.Raw)/1024/1024, float64(stats.Compressed)/1024/1024, stats.Ratio())
+	if len(stats.Parts) > 1 {
+		min, max := stats.PartRatioRange()
+		i18n.Fprintf(w, "  各分块压缩比: %.2f ~ %.2f\n", min, max)
+	}
+	if stats.Ratio() >= incompressibleRatio {
+		i18n.Fprintf(w, "提示: 数据几乎无法压缩（如已压缩的媒体文件、压缩包），gzip 压缩只会消耗 CPU；可以使用 --smart-compression 不压缩这些文件类型\n")
+	}
+}
+
+// defaultCheckpointInterval 未设置 backup.heartbeat_interval 时写入状态检查点的间隔
+const defaultCheckpointInterval = time.Minute
+
+// heartbeatFor 返回上传器的心跳设置：设置了 backup.heartbeat_interval 时按该间隔输出心跳日志并写入检查点，
+// 否则只按 defaultCheckpointInterval 将累计用时写入状态文件
+func heartbeatFor(cfg *config.Config) (time.Duration, func(uploader.Heartbeat)) {
+	if cfg.Backup.HeartbeatInterval <= 0 {
+		return defaultCheckpointInterval, nil
+	}
+	return cfg.Backup.HeartbeatInterval, printHeartbeat
+}
+
+// printHeartbeat 输出心跳日志；心跳是显式要求的输出，--quiet 时仍写入原标准输出
+func printHeartbeat(hb uploader.Heartbeat) {
+	var w io.Writer = os.Stdout
+	if stdout != nil {
+		w = stdout
+	}
+	elapsed, idle := hb.Elapsed.Round(time.Second), hb.Idle.Round(time.Second)
+	if hb.Total > 0 {
+		i18n.Fprintf(w, "[心跳] %s: 已上传 %d / %d MB，%d 个分块，已用时 %s，距上次完成分块 %s\n",
+			hb.Key, hb.Uploaded/1024/1024, hb.Total/1024/1024, hb.Parts, elapsed, idle)
+		return
+	}
+	i18n.Fprintf(w, "[心跳] %s: 已上传 %d MB，%d 个分块，已用时 %s，距上次完成分块 %s\n",
+		hb.Key, hb.Uploaded/1024/1024, hb.Parts, elapsed, idle)
+}
+
+// addConfigFlags 注册可覆盖配置文件的 flags，由 config.LoadConfigWithFlags 绑定
+func addConfigFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("provider", "p", "", "存储提供商 (aws/qiniu/aliyun)")
+	cmd.Flags().StringP("bucket", "b", "", "存储桶名称")
+	cmd.Flags().String("endpoint", "", "自定义端点")
+	cmd.Flags().Bool("path-style", false, "使用路径风格访问（MinIO 等自建 S3 兼容存储）")
+	cmd.Flags().Bool("write-only", false, "只上传，不列出、读取或删除对象（凭证为只写凭证时使用；prune 等命令会直接报错）")
+	cmd.Flags().String("acl", "", "上传对象的预设 ACL（如写入其他账号的存储桶时使用 bucket-owner-full-control）")
+	cmd.Flags().String("region", "", "区域")
+	cmd.Flags().String("access-key", "", "Access Key")
+	cmd.Flags().String("secret-key", "", "Secret Key")
+	cmd.Flags().String("restore-access-key", "", "只读 Access Key（restore、verify 等只读命令优先使用）")
+	cmd.Flags().String("restore-secret-key", "", "只读 Secret Key")
+	cmd.Flags().StringP("storage-class", "s", "", "存储类型 (standard/ia/archive/deep_archive 等，见 s3backup storage-classes)")
+	cmd.Flags().BoolP("encrypt", "e", false, "启用加密")
+	cmd.Flags().String("password", "", "加密密码")
+	cmd.Flags().String("key-file", "", "密钥文件")
+	cmd.Flags().Bool("encrypt-state", false, "使用备份密钥加密本地续传状态文件")
+	cmd.Flags().String("padding", "", "加密前填充备份以隐藏准确大小 (none/pow2/bucket)")
+	cmd.Flags().Int64("padding-bucket", 0, "--padding bucket 时填充到该大小（字节）的整数倍")
+	cmd.Flags().StringSlice("exclude", []string{}, "排除模式（可多次指定）")
+	cmd.Flags().Bool("ignore-case", false, "排除模式不区分大小写")
+	cmd.Flags().Int("concurrency", 0, "并发上传数")
+	cmd.Flags().Int64("chunk-size", 0, "分块大小（字节）")
+	cmd.Flags().Bool("auto-chunk-size", false, "根据上传吞吐量自动调整分块大小")
+	cmd.Flags().Int64("chunk-size-min", 0, "自动调整分块大小的下限（字节）")
+	cmd.Flags().Int64("chunk-size-max", 0, "自动调整分块大小的上限（字节）")
+	cmd.Flags().Bool("auto-concurrency", false, "根据吞吐量和限流响应自动调整并发数")
+	cmd.Flags().Int("concurrency-max", 0, "自动调整并发数的上限")
+	cmd.Flags().Int("read-ahead", 0, "预读等待上传的分块数（默认为并发数的 2 倍），内存不足时调小，链路延迟波动大时调大")
+	cmd.Flags().Int("etag-retries", 0, "分块 ETag 与本地 MD5 不一致时重新上传的次数（默认 3，-1 不校验）")
+	cmd.Flags().Duration("heartbeat-interval", 0, "上传期间每隔指定时间输出心跳日志（如 5m），便于外部监控发现挂起的备份")
+
+	_ = cmd.RegisterFlagCompletionFunc("provider", completeProvider)
+	_ = cmd.RegisterFlagCompletionFunc("storage-class", completeStorageClass)
+}
+
+// ErrReadOnlyCredentials 只配置了只读凭证（storage.restore_access_key），拒绝执行写入存储的命令
+var ErrReadOnlyCredentials = errors.New("only restore credentials are configured")
+
+// ErrWriteOnly 开启了 storage.write_only，拒绝执行需要列出、读取或删除对象的命令
+var ErrWriteOnly = errors.New("storage credentials are write-only")
+
+// createStorageAdapter 使用读写凭证创建存储适配器，只配置了只读凭证时返回 ErrReadOnlyCredentials
+// 开启 storage.write_only 时返回的适配器只能上传，见 storage.WriteOnly
 func createStorageAdapter(ctx context.Context, cfg *config.Config) (storage.StorageAdapter, error) {
-	accessKey := cfg.GetAccessKey()
-	secretKey := cfg.GetSecretKey()
+	if cfg.ReadOnly() {
+		return nil, ErrReadOnlyCredentials
+	}
+	adapter, err := newStorageAdapter(ctx, cfg, cfg.GetAccessKey(), cfg.GetSecretKey())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Storage.WriteOnly {
+		return storage.WriteOnly(adapter), nil
+	}
+	return adapter, nil
+}
+
+// createRestoreAdapter 为只读取存储的命令创建存储适配器，配置了只读凭证时优先使用
+// 开启 storage.write_only 且没有只读凭证时返回 ErrWriteOnly
+func createRestoreAdapter(ctx context.Context, cfg *config.Config) (storage.StorageAdapter, error) {
+	if accessKey := cfg.GetRestoreAccessKey(); accessKey != "" {
+		return newStorageAdapter(ctx, cfg, accessKey, cfg.GetRestoreSecretKey())
+	}
+	if cfg.Storage.WriteOnly {
+		return nil, ErrWriteOnly
+	}
+	return newStorageAdapter(ctx, cfg, cfg.GetAccessKey(), cfg.GetSecretKey())
+}
+
+// requireReadAccess 开启 storage.write_only 时拒绝 command 这类需要列出、读取或删除对象的命令
+func requireReadAccess(cfg *config.Config, command string) error {
+	if cfg.Storage.WriteOnly {
+		return fmt.Errorf("%w: %s needs to list, read or delete objects", ErrWriteOnly, command)
+	}
+	return nil
+}
 
+// newStorageAdapter 使用给定的凭证创建存储适配器
+func newStorageAdapter(ctx context.Context, cfg *config.Config, accessKey, secretKey string) (storage.StorageAdapter, error) {
+	opts := httpTraceOptions()
 	switch strings.ToLower(cfg.Storage.Provider) {
 	case "aws":
-		return storage.NewAWSAdapter(ctx, cfg.Storage.Region, cfg.Storage.Endpoint, cfg.Storage.Bucket, accessKey, secretKey)
+		return storage.NewAWSAdapter(ctx, cfg.Storage.Region, cfg.Storage.Endpoint, cfg.Storage.Bucket, accessKey, secretKey,
+			append(opts, storage.WithPathStyle(cfg.Storage.PathStyle))...)
 	case "qiniu":
-		return storage.NewQiniuAdapter(ctx, cfg.Storage.Endpoint, cfg.Storage.Bucket, accessKey, secretKey)
+		return storage.NewQiniuAdapter(ctx, cfg.Storage.Endpoint, cfg.Storage.Bucket, accessKey, secretKey, opts...)
 	case "aliyun":
-		return storage.NewAliyunAdapter(ctx, cfg.Storage.Region, cfg.Storage.Endpoint, cfg.Storage.Bucket, accessKey, secretKey)
+		return storage.NewAliyunAdapter(ctx, cfg.Storage.Region, cfg.Storage.Endpoint, cfg.Storage.Bucket, accessKey, secretKey, opts...)
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", cfg.Storage.Provider)
 	}
@@ -316,30 +1199,117 @@ func createStorageAdapter(ctx context.Context, cfg *config.Config) (storage.Stor
 
 // createEncryptor 创建加密器
 func createEncryptor(cfg *config.Config) (*crypto.StreamEncryptor, error) {
-	var aesKey, hmacKey []byte
-	var err error
-
 	if cfg.Encryption.KeyFile != "" {
 		// 从密钥文件读取
 		keyData, err := os.ReadFile(cfg.Encryption.KeyFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read key file: %w", err)
 		}
-		aesKey, hmacKey, err = crypto.DeriveKeyFromKeyFile(keyData)
+		aesKey, hmacKey, err := crypto.DeriveKeyFromKeyFile(keyData)
 		if err != nil {
 			return nil, fmt.Errorf("failed to derive key from file: %w", err)
 		}
-	} else {
-		// 从密码派生密钥
-		password := cfg.GetPassword()
-		if password == "" {
-			return nil, fmt.Errorf("encryption password is required")
+		return crypto.NewStreamEncryptor(aesKey, hmacKey)
+	}
+
+	// 从密码派生密钥，盐值写入加密文件头
+	password := cfg.GetPassword()
+	if password == "" {
+		return nil, fmt.Errorf("encryption password is required")
+	}
+	encryptor, err := crypto.NewPasswordEncryptor(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return encryptor, nil
+}
+
+// keySource 根据配置返回解密使用的密钥来源
+func keySource(cfg *config.Config) (crypto.KeySource, error) {
+	if cfg.Encryption.KeyFile != "" {
+		keyData, err := os.ReadFile(cfg.Encryption.KeyFile)
+		if err != nil {
+			return crypto.KeySource{}, fmt.Errorf("failed to read key file: %w", err)
 		}
-		aesKey, hmacKey, err = crypto.DeriveKeyFromPasswordFile(password)
+		return crypto.KeySource{KeyFile: keyData}, nil
+	}
+
+	password := cfg.GetPassword()
+	if password == "" {
+		return crypto.KeySource{}, fmt.Errorf("encryption password or key_file is required")
+	}
+	return crypto.KeySource{Password: password}, nil
+}
+
+// newStateManager 在 state.dir 中创建状态管理器，启用 encryption.encrypt_state 时状态文件使用备份密钥加密
+func newStateManager(cfg *config.Config, name string) (*state.StateManager, error) {
+	stateMgr := state.NewStateManager(cfg.State.Dir, name)
+	if cfg.Encryption.EncryptState {
+		keys, err := keySource(cfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to derive key: %w", err)
+			return nil, err
 		}
+		stateMgr.SetKeySource(keys)
+	}
+	return stateMgr, nil
+}
+
+// loadState 加载状态文件，状态文件已加密时使用配置的密码或密钥文件解密
+func loadState(cfg *config.Config, stateMgr *state.StateManager) (*state.UploadState, error) {
+	saved, err := stateMgr.Load()
+	if !errors.Is(err, state.ErrEncrypted) {
+		return saved, err
+	}
+	keys, keyErr := keySource(cfg)
+	if keyErr != nil {
+		return nil, err
 	}
+	stateMgr.SetKeySource(keys)
+	return stateMgr.Load()
+}
 
-	return crypto.NewStreamEncryptor(aesKey, hmacKey)
+// setStateEncryption 在状态中记录加密算法、密钥派生方式和密钥文件指纹，续传时确认使用相同的密钥和格式
+// h 为已生成的加密文件头，流式备份的文件头在归档时才生成，此时为 nil
+func setStateEncryption(cfg *config.Config, s *state.UploadState, h *crypto.Header) error {
+	s.Encrypted = cfg.Encryption.Enabled
+	if !cfg.Encryption.Enabled {
+		return nil
+	}
+	keys, err := keySource(cfg)
+	if err != nil {
+		return err
+	}
+	s.Cipher = crypto.CipherName
+	s.FormatVersion = crypto.FormatVersion
+	s.KDF = keys.KDF().String()
+	s.KeyFingerprint = keys.Fingerprint()
+	if h != nil && h.KDF == crypto.KDFArgon2id {
+		s.Salt = h.Salt
+	}
+	return nil
+}
+
+// checkStateEncryption 确认当前的加密配置与中断的上传一致
+// 旧版本的状态文件没有记录加密参数，只比较是否加密
+func checkStateEncryption(cfg *config.Config, saved *state.UploadState) error {
+	if saved.Encrypted != cfg.Encryption.Enabled {
+		return fmt.Errorf("encryption setting differs from the interrupted upload (encrypted: %v)", saved.Encrypted)
+	}
+	if !saved.Encrypted || saved.Cipher == "" {
+		return nil
+	}
+	if saved.Cipher != crypto.CipherName || saved.FormatVersion != crypto.FormatVersion {
+		return fmt.Errorf("interrupted upload uses %s format v%d, which this version cannot resume", saved.Cipher, saved.FormatVersion)
+	}
+	keys, err := keySource(cfg)
+	if err != nil {
+		return err
+	}
+	if kdf := keys.KDF().String(); kdf != saved.KDF {
+		return fmt.Errorf("interrupted upload was encrypted with %s, but %s was provided", saved.KDF, kdf)
+	}
+	if fp := keys.Fingerprint(); fp != saved.KeyFingerprint {
+		return fmt.Errorf("key file differs from the interrupted upload (fingerprint %s, got %s)", saved.KeyFingerprint, fp)
+	}
+	return nil
 }
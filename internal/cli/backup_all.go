@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/dbdump"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profilesDir       string
+	backupAllParallel int
+	stateDir          string
+)
+
+// backupAllCmd 批量备份命令
+var backupAllCmd = &cobra.Command{
+	Use:   "backup-all [profile.yaml...]",
+	Short: "执行所有备份配置",
+	Long: `每个配置文件视为一个 profile，按其中的 backup.includes（或 source.url 数据库导出源）执行备份，最后汇总结果。
+任一 profile 失败时命令以错误退出。
+
+未指定配置文件时使用 --profiles-dir 目录（默认 ~/.config/s3backup/profiles）中的所有 .yaml/.yml 文件。
+备份文件名为 backup-{profile}-{timestamp}.tar.gz[.enc]（数据库导出为 .sql.gz 等），profile 为配置文件名（不含扩展名）。
+并行执行时自动禁用进度条。`,
+	RunE: withK8s(runBackupAll),
+}
+
+func init() {
+	rootCmd.AddCommand(backupAllCmd)
+
+	backupAllCmd.Flags().StringVar(&profilesDir, "profiles-dir", "", "profile 配置文件目录（默认 ~/.config/s3backup/profiles）")
+	backupAllCmd.Flags().IntVar(&backupAllParallel, "parallel", 1, "同时执行的 profile 数")
+	backupAllCmd.Flags().BoolVar(&dryRun, "dry-run", false, "模拟运行，不实际上传")
+	backupAllCmd.Flags().BoolVar(&noProgress, "no-progress", false, "禁用进度条")
+	backupAllCmd.Flags().StringVar(&stateDir, "state-dir", "", "状态文件目录（用于断点续传，覆盖各 profile 的 state.dir）")
+	addK8sFlags(backupAllCmd)
+}
+
+// profileResult 单个 profile 的备份结果
+type profileResult struct {
+	Profile  string
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+func runBackupAll(cmd *cobra.Command, args []string) error {
+	if backupAllParallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+
+	profiles := args
+	if len(profiles) == 0 {
+		dir := profilesDir
+		if dir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("failed to get home directory: %w", err)
+			}
+			dir = filepath.Join(home, ".config", "s3backup", "profiles")
+		}
+		var err error
+		profiles, err = findProfiles(dir)
+		if err != nil {
+			return err
+		}
+		if len(profiles) == 0 {
+			return fmt.Errorf("no profiles found in %s", dir)
+		}
+	}
+
+	if backupAllParallel > 1 {
+		noProgress = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
+	defer cancel()
+
+	results := runProfiles(ctx, profiles, backupAllParallel, backupProfile)
+
+	i18n.Printf("\n备份汇总:\n")
+	var failed int
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			i18n.Printf("  [失败] %s (%s): %v\n", r.Profile, r.Duration.Round(time.Second), r.Err)
+		} else {
+			i18n.Printf("  [成功] %s -> %s (%s)\n", r.Profile, r.Name, r.Duration.Round(time.Second))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d profiles failed", failed, len(results))
+	}
+	return nil
+}
+
+// findProfiles 返回目录中的 .yaml/.yml 配置文件（按文件名排序）
+func findProfiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	var profiles []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		profiles = append(profiles, filepath.Join(dir, e.Name()))
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// profileName 返回 profile 名称（配置文件名去掉扩展名）
+func profileName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// runProfiles 最多同时执行 parallel 个 profile，结果按输入顺序返回
+func runProfiles(ctx context.Context, profiles []string, parallel int,
+	run func(ctx context.Context, profile string) (string, error)) []profileResult {
+	results := make([]profileResult, len(profiles))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i, profile := range profiles {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, profile string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			start := time.Now()
+			name, err := run(ctx, profile)
+			results[i] = profileResult{
+				Profile:  profileName(profile),
+				Name:     name,
+				Err:      err,
+				Duration: time.Since(start),
+			}
+		}(i, profile)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// backupProfile 加载 profile 配置文件并执行备份，返回备份文件名
+// profile 配置了 backup.idempotent 时当天已有成功的备份则跳过，返回已有的备份名
+func backupProfile(ctx context.Context, path string) (string, error) {
+	profile := profileName(path)
+	i18n.Printf("==> 开始备份 profile %s (%s)\n", profile, path)
+
+	cfg, err := config.LoadConfig(path, envFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+	if stateDir != "" {
+		cfg.State.Dir = stateDir
+	}
+	if err := cfg.Validate(); err != nil {
+		return "", fmt.Errorf("invalid config: %w", err)
+	}
+	started := time.Now()
+	if cfg.Backup.Idempotent && !dryRun {
+		adapter, err := createStorageAdapter(ctx, cfg)
+		if err != nil {
+			return "", fmt.Errorf("failed to create storage adapter: %w", err)
+		}
+		return runIdempotent(ctx, cfg, adapter, profile, started, "", func() (string, error) {
+			return backupProfileConfig(ctx, cfg, profile, started)
+		})
+	}
+	return backupProfileConfig(ctx, cfg, profile, started)
+}
+
+// backupProfileConfig 按 profile 的配置执行备份，返回备份文件名
+func backupProfileConfig(ctx context.Context, cfg *config.Config, profile string, started time.Time) (string, error) {
+	if cfg.Source.URL != "" {
+		dumper, err := dbdump.New(cfg.Source.URL, cfg.GetSourcePassword())
+		if err != nil {
+			return "", err
+		}
+		name := profileBackupName(profile, sourceBackupName(started, dumper.Ext()+compressionSuffix(cfg), cfg.Encryption.Enabled))
+		if err := backupSource(ctx, cfg, dumper, name); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+	if len(cfg.Backup.Includes) == 0 {
+		return "", fmt.Errorf("backup includes is empty")
+	}
+
+	includes, err := resolveIncludes(cfg, cfg.Backup.Includes)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve includes: %w", err)
+	}
+
+	name := profileBackupName(profile, defaultBackupName(started, compressionSuffix(cfg), cfg.Encryption.Enabled))
+	if err := backupIncludes(ctx, cfg, includes, name, true); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// profileBackupName 在默认备份文件名中插入 profile 名称，例如 backup-{profile}-{timestamp}.tar.gz[.enc]
+func profileBackupName(profile, name string) string {
+	return strings.Replace(name, "backup-", "backup-"+profile+"-", 1)
+}
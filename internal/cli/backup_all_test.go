@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRunProfiles 测试并发数限制，结果按输入顺序返回
+func TestRunProfiles(t *testing.T) {
+	var running, maxRunning int32
+	run := func(ctx context.Context, profile string) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		if profile == "/p/bad.yaml" {
+			return "", errors.New("boom")
+		}
+		return "backup-" + profileName(profile), nil
+	}
+
+	profiles := []string{"/p/a.yaml", "/p/bad.yaml", "/p/c.yml", "/p/d.yaml"}
+	results := runProfiles(context.Background(), profiles, 2, run)
+
+	if maxRunning != 2 {
+		t.Errorf("max concurrent runs = %d, want 2", maxRunning)
+	}
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Profile)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "bad", "c", "d"}) {
+		t.Errorf("result order = %v", names)
+	}
+	if results[1].Err == nil || results[0].Err != nil || results[2].Name != "backup-c" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+// TestFindProfiles 测试只返回 yaml 配置文件
+func TestFindProfiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"web.yaml", "db.yml", "notes.txt"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	os.Mkdir(filepath.Join(dir, "sub.yaml"), 0755)
+
+	got, err := findProfiles(dir)
+	if err != nil {
+		t.Fatalf("findProfiles() error = %v", err)
+	}
+	want := []string{filepath.Join(dir, "db.yml"), filepath.Join(dir, "web.yaml")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findProfiles() = %v, want %v", got, want)
+	}
+}
+
+// TestProfileBackupName 测试 profile 备份文件名
+func TestProfileBackupName(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := profileBackupName("web", defaultBackupName(ts, ".gz", true)); got != "backup-web-20240102-030405.tar.gz.enc" {
+		t.Errorf("profileBackupName() = %q", got)
+	}
+}
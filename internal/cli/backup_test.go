@@ -2,11 +2,27 @@ package cli
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
-
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/dbdump"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+	"github.com/lukelzlz/s3backup/pkg/version"
 	"github.com/spf13/cobra"
 )
 
@@ -712,3 +728,395 @@ func executeCommand(cmd *cobra.Command, args ...string) (string, error) {
 
 	return buf.String(), err
 }
+
+// TestErrorHint 测试根据错误分类给出提示
+func TestErrorHint(t *testing.T) {
+	authErr := fmt.Errorf("failed to upload: %w", &storage.ProviderError{Kind: storage.ErrAuth, Provider: "aws", Err: errors.New("denied")})
+	if hint := errorHint(authErr); !strings.Contains(hint, "access_key") {
+		t.Errorf("unexpected hint for auth error: %q", hint)
+	}
+
+	bucketErr := &storage.ProviderError{Kind: storage.ErrBucketNotFound, Provider: "aws", Err: errors.New("no such bucket")}
+	if hint := errorHint(bucketErr); !strings.Contains(hint, "bucket") {
+		t.Errorf("unexpected hint for bucket error: %q", hint)
+	}
+
+	if hint := errorHint(errors.New("unknown")); hint != "" {
+		t.Errorf("expected no hint for unclassified error, got %q", hint)
+	}
+}
+
+// TestReadOnlyCredentials 测试只配置只读凭证时拒绝创建可写入的存储适配器
+func TestReadOnlyCredentials(t *testing.T) {
+	t.Setenv("S3BACKUP_ACCESS_KEY", "")
+	t.Setenv("S3BACKUP_SECRET_KEY", "")
+	cfg := &config.Config{Storage: config.StorageConfig{
+		Provider:         "none",
+		Bucket:           "bucket",
+		RestoreAccessKey: "ro-key",
+		RestoreSecretKey: "ro-secret",
+	}}
+
+	_, err := createStorageAdapter(context.Background(), cfg)
+	if !errors.Is(err, ErrReadOnlyCredentials) {
+		t.Fatalf("expected ErrReadOnlyCredentials, got %v", err)
+	}
+	if hint := errorHint(err); !strings.Contains(hint, "restore_access_key") {
+		t.Errorf("unexpected hint: %q", hint)
+	}
+
+	// 只读命令使用只读凭证，错误来自不支持的 provider 而不是凭证检查
+	_, err = createRestoreAdapter(context.Background(), cfg)
+	if err == nil || errors.Is(err, ErrReadOnlyCredentials) {
+		t.Fatalf("expected unsupported provider error, got %v", err)
+	}
+
+	cfg.Storage.AccessKey = "rw-key"
+	cfg.Storage.SecretKey = "rw-secret"
+	if _, err := createStorageAdapter(context.Background(), cfg); errors.Is(err, ErrReadOnlyCredentials) {
+		t.Fatal("read-write credentials should not be refused")
+	}
+}
+
+// TestWriteOnly 测试 storage.write_only 时拒绝需要列出、读取或删除对象的命令，备份不加锁
+func TestWriteOnly(t *testing.T) {
+	cfg := &config.Config{Storage: config.StorageConfig{Provider: "none", WriteOnly: true}}
+
+	if err := requireReadAccess(cfg, "prune"); !errors.Is(err, ErrWriteOnly) {
+		t.Fatalf("expected ErrWriteOnly, got %v", err)
+	}
+	if _, err := createRestoreAdapter(context.Background(), cfg); !errors.Is(err, ErrWriteOnly) {
+		t.Fatalf("expected ErrWriteOnly, got %v", err)
+	}
+	if hint := errorHint(ErrWriteOnly); !strings.Contains(hint, "write_only") {
+		t.Errorf("unexpected hint: %q", hint)
+	}
+
+	// 配置了只读凭证时还原命令使用它
+	cfg.Storage.RestoreAccessKey = "ro-key"
+	cfg.Storage.RestoreSecretKey = "ro-secret"
+	if _, err := createRestoreAdapter(context.Background(), cfg); errors.Is(err, ErrWriteOnly) {
+		t.Fatal("restore credentials should be used in write-only mode")
+	}
+
+	adapter := mock.New()
+	lock, err := acquireLock(context.Background(), io.Discard, storage.WriteOnly(adapter), "", "backup", false, "")
+	if err != nil || lock != nil {
+		t.Fatalf("acquireLock() = %v, %v, want no lock", lock, err)
+	}
+	if n := adapter.Calls(mock.OpListObjects); n != 0 {
+		t.Errorf("write-only backup listed objects %d times", n)
+	}
+
+	cfg.Storage.WriteOnly = false
+	if err := requireReadAccess(cfg, "prune"); err != nil {
+		t.Errorf("requireReadAccess() = %v", err)
+	}
+}
+
+// TestBackupContentType 测试备份对象的 Content-Type
+func TestBackupContentType(t *testing.T) {
+	tests := []struct {
+		name      string
+		encrypted bool
+		want      string
+	}{
+		{"backup-20240101-120000.tar.gz", false, "application/gzip"},
+		{"backup-20240101-120000.tar.gz.enc", true, "application/octet-stream"},
+		{"custom.tar.gz", true, "application/octet-stream"},
+		{"nightly", false, "application/gzip"},
+	}
+	for _, tt := range tests {
+		if got := backupContentType(tt.name, tt.encrypted); got != tt.want {
+			t.Errorf("backupContentType(%q, %v) = %q, want %q", tt.name, tt.encrypted, got, tt.want)
+		}
+	}
+}
+
+// TestBackupMetadata 测试备份对象记录工具版本、格式和加密方式
+func TestBackupMetadata(t *testing.T) {
+	defer func(n bool) { noProgress = n }(noProgress)
+	noProgress = true
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{Provider: "aws", Bucket: "bucket"},
+		State:   config.StateConfig{Dir: t.TempDir()},
+	}
+	adapter := mock.New()
+	name := "backup-20260101-000000.tar.gz"
+	err := backupOnce(context.Background(), cfg, adapter, name, nil, func(ctx context.Context, w io.Writer, _ *archive.CompressionMeter) error {
+		_, err := w.Write([]byte("data"))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("backupOnce() error = %v", err)
+	}
+	obj, ok := adapter.Object(name)
+	if !ok {
+		t.Fatal("backup object not uploaded")
+	}
+	meta := obj.Metadata
+	if meta["s3backup-version"] != version.Version || meta["s3backup-format"] != "plain" ||
+		meta["s3backup-cipher"] != "none" || meta["s3backup-compression"] != "gzip" {
+		t.Errorf("unexpected metadata for plain backup: %v", meta)
+	}
+
+	cfg.Encryption.Enabled = true
+	meta = backupMetadata(cfg)
+	if meta["s3backup-format"] != fmt.Sprint(crypto.FormatVersion) || meta["s3backup-cipher"] != "aes-256-ctr+hmac-sha512" {
+		t.Errorf("unexpected metadata for encrypted backup: %v", meta)
+	}
+	if _, ok := meta[expiresMetaKey]; ok {
+		t.Errorf("expiry recorded without expire_after: %v", meta)
+	}
+
+	cfg.Backup.ExpireAfter = "90d"
+	expires, err := time.Parse(time.RFC3339, backupMetadata(cfg)[expiresMetaKey])
+	if want := time.Now().Add(90 * 24 * time.Hour); err != nil || expires.Sub(want).Abs() > time.Minute {
+		t.Errorf("expiry = %v, %v, want about %v", expires, err, want)
+	}
+}
+
+// TestSelectIncludes 测试合并命令行路径和 --only 选择的路径组
+func TestSelectIncludes(t *testing.T) {
+	groups := map[string][]string{
+		"etc":  {"/etc"},
+		"home": {"/home", "/root"},
+	}
+
+	got, err := selectIncludes([]string{"/srv", "/etc"}, []string{"ETC", "home"}, groups)
+	if err != nil {
+		t.Fatalf("selectIncludes() error = %v", err)
+	}
+	want := []string{"/srv", "/etc", "/home", "/root"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selectIncludes() = %v, want %v", got, want)
+	}
+
+	if _, err := selectIncludes(nil, []string{"var"}, groups); err == nil || !strings.Contains(err.Error(), "etc, home") {
+		t.Errorf("expected unknown group error listing available groups, got %v", err)
+	}
+	if _, err := selectIncludes(nil, nil, groups); err == nil {
+		t.Error("expected error when nothing is selected")
+	}
+}
+
+// TestWriteDump 测试数据库导出数据经过压缩和加密后可以还原
+func TestWriteDump(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"CREATE TABLE t (id int); -- $PGPASSWORD\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "pg_dump"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	dumper, err := dbdump.New("postgres://app@db/shop", "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Encryption: config.EncryptionConfig{Enabled: true, Password: "secret"}}
+
+	var buf bytes.Buffer
+	if err := writeDump(context.Background(), &buf, cfg, dumper, nil); err != nil {
+		t.Fatalf("writeDump() error = %v", err)
+	}
+
+	r, _, err := crypto.OpenReader(&buf, crypto.KeySource{Password: "secret"})
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	got, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("read error = %v", err)
+	}
+	if string(got) != "CREATE TABLE t (id int); -- pw\n" {
+		t.Errorf("dump = %q", got)
+	}
+
+	if name := sourceBackupName(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), dumper.Ext()+".gz", true); name != "backup-20240102-030405.sql.gz.enc" {
+		t.Errorf("sourceBackupName() = %q", name)
+	}
+}
+
+// TestWritePadding 测试 encryption.padding 将加密后的对象填充到档位，解密解压后的数据不变
+func TestWritePadding(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"CREATE TABLE t (id int);\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "pg_dump"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	dumper, err := dbdump.New("postgres://app@db/shop", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "backup.key")
+	os.WriteFile(keyFile, bytes.Repeat([]byte{7}, 96), 0600)
+	key, _ := os.ReadFile(keyFile)
+
+	tests := []struct {
+		compression string
+		padding     string
+		bucket      int64
+		wantSize    int
+	}{
+		{"gzip", "pow2", 0, 256},
+		{"zstd", "pow2", 0, 256},
+		{"gzip", "bucket", 1000, 1000},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{
+			Encryption: config.EncryptionConfig{Enabled: true, KeyFile: keyFile, Padding: tt.padding, PaddingBucket: tt.bucket},
+			Backup:     config.BackupConfig{Compression: tt.compression},
+		}
+		var buf bytes.Buffer
+		if err := writeDump(context.Background(), &buf, cfg, dumper, nil); err != nil {
+			t.Fatalf("writeDump() error = %v", err)
+		}
+		if buf.Len() != tt.wantSize {
+			t.Errorf("%s/%s: size = %d, want %d", tt.compression, tt.padding, buf.Len(), tt.wantSize)
+		}
+
+		r, _, err := crypto.OpenReader(&buf, crypto.KeySource{KeyFile: key})
+		if err != nil {
+			t.Fatalf("OpenReader() error = %v", err)
+		}
+		var got []byte
+		if tt.compression == "zstd" {
+			dec, err := zstd.NewReader(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err = io.ReadAll(dec)
+			if err != nil {
+				t.Fatalf("%s/%s: read error = %v", tt.compression, tt.padding, err)
+			}
+		} else {
+			gz, err := gzip.NewReader(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err = io.ReadAll(gz)
+			if err != nil {
+				t.Fatalf("%s/%s: read error = %v", tt.compression, tt.padding, err)
+			}
+		}
+		if string(got) != "CREATE TABLE t (id int);\n" {
+			t.Errorf("%s/%s: dump = %q", tt.compression, tt.padding, got)
+		}
+	}
+}
+
+// TestBackupOnceResumeState 测试可重新归档的备份在分块上传失败时保留上传并保存可续传的状态；
+// state.no_resume、无法重新生成的数据流和完成上传失败时不保存，上传被取消
+func TestBackupOnceResumeState(t *testing.T) {
+	defer func(n bool) { noProgress = n }(noProgress)
+	noProgress = true
+
+	tests := []struct {
+		name        string
+		noResume    bool
+		regenerable bool
+		fault       mock.Fault
+		resumable   bool
+	}{
+		{"part failure", false, true, mock.Fault{Op: mock.OpUploadPart, PartNumber: 1}, true},
+		{"no_resume", true, true, mock.Fault{Op: mock.OpUploadPart, PartNumber: 1}, false},
+		{"not regenerable", false, false, mock.Fault{Op: mock.OpUploadPart, PartNumber: 1}, false},
+		{"complete failure", false, true, mock.Fault{Op: mock.OpComplete}, false},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{
+			Storage: config.StorageConfig{Provider: "aws", Bucket: "bucket", Endpoint: "https://s3.example.com", Region: "us-east-1"},
+			State:   config.StateConfig{Dir: t.TempDir(), NoResume: tt.noResume},
+		}
+		adapter := mock.New()
+		adapter.AddFault(tt.fault)
+		name := "backup-20260101-000000.tar.gz"
+		var arcOpts *state.ArchiveOptions
+		if tt.regenerable {
+			arcOpts = &state.ArchiveOptions{Includes: []string{"/data"}}
+		}
+		err := backupStream(context.Background(), cfg, adapter, name, nil, arcOpts, func(ctx context.Context, w io.Writer, _ *archive.CompressionMeter) error {
+			_, err := w.Write([]byte("data"))
+			return err
+		})
+		if err == nil {
+			t.Fatalf("%s: expected upload to fail", tt.name)
+		}
+
+		saved, err := state.NewStateManager(cfg.State.Dir, name).Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tt.resumable {
+			if saved != nil {
+				t.Errorf("%s: state saved: %+v", tt.name, saved)
+			}
+			if len(adapter.PendingUploads()) != 0 {
+				t.Errorf("%s: upload should be aborted, pending: %v", tt.name, adapter.PendingUploads())
+			}
+			continue
+		}
+		if saved == nil || saved.UploadID == "" || saved.Archive == nil || saved.Archive.Includes[0] != "/data" {
+			t.Fatalf("%s: expected resumable state, got %+v", tt.name, saved)
+		}
+		if saved.Provider != "aws" || saved.Bucket != "bucket" || saved.Endpoint != cfg.Storage.Endpoint || saved.Region != "us-east-1" {
+			t.Errorf("%s: state missing storage fields: %+v", tt.name, saved)
+		}
+		if len(adapter.PendingUploads()) != 1 {
+			t.Errorf("%s: upload should be kept for resume, pending: %v", tt.name, adapter.PendingUploads())
+		}
+	}
+}
+
+// TestBackupOnceNoOverwrite 测试同名备份已存在时失败，--overwrite 时覆盖
+func TestBackupOnceNoOverwrite(t *testing.T) {
+	defer func(n bool) { noProgress = n }(noProgress)
+	noProgress = true
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{Provider: "aws", Bucket: "bucket"},
+		State:   config.StateConfig{NoResume: true},
+	}
+	name := "backup-20260101-000000.tar.gz"
+	produce := func(ctx context.Context, w io.Writer, _ *archive.CompressionMeter) error {
+		_, err := w.Write([]byte("new"))
+		return err
+	}
+
+	adapter := mock.New()
+	adapter.SetObject(name, &mock.Object{Data: []byte("old")})
+	err := backupOnce(context.Background(), cfg, adapter, name, nil, produce)
+	if !errors.Is(err, storage.ErrObjectExists) {
+		t.Fatalf("backupOnce() error = %v, want ErrObjectExists", err)
+	}
+	if adapter.Calls(mock.OpInit) != 0 {
+		t.Error("upload should not start when the backup exists")
+	}
+
+	// 上传前检查之后才出现的同名备份由条件完成上传拒绝
+	adapter = mock.New()
+	err = backupOnce(context.Background(), cfg, adapter, name, nil, func(ctx context.Context, w io.Writer, m *archive.CompressionMeter) error {
+		adapter.SetObject(name, &mock.Object{Data: []byte("other host")})
+		return produce(ctx, w, m)
+	})
+	if !errors.Is(err, storage.ErrObjectExists) {
+		t.Fatalf("backupOnce() with concurrent backup error = %v, want ErrObjectExists", err)
+	}
+	if obj, _ := adapter.Object(name); string(obj.Data) != "other host" {
+		t.Errorf("existing backup overwritten: %q", obj.Data)
+	}
+
+	cfg.Backup.Overwrite = true
+	if err := backupOnce(context.Background(), cfg, adapter, name, nil, produce); err != nil {
+		t.Fatalf("backupOnce() with overwrite error = %v", err)
+	}
+	if obj, _ := adapter.Object(name); string(obj.Data) != "new" {
+		t.Errorf("data = %q, want the new backup", obj.Data)
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"os"
+	"strings"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// completionCmd 生成 shell 补全脚本
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "生成 shell 自动补全脚本",
+	Long: `生成指定 shell 的自动补全脚本。
+
+Bash:
+  source <(s3backup completion bash)
+  # 永久生效（Linux）:
+  s3backup completion bash > /etc/bash_completion.d/s3backup
+
+Zsh:
+  s3backup completion zsh > "${fpath[1]}/_s3backup"
+
+Fish:
+  s3backup completion fish > ~/.config/fish/completions/s3backup.fish
+
+PowerShell:
+  s3backup completion powershell | Out-String | Invoke-Expression`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		default:
+			return rootCmd.GenPowerShellCompletionWithDesc(out)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// completeProvider 补全存储提供商
+func completeProvider(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{
+		"aws\tAWS S3",
+		"qiniu\t" + i18n.T(providerNames["qiniu"]),
+		"aliyun\t" + i18n.T(providerNames["aliyun"]),
+	}, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeStorageClass 根据 --provider（未指定时使用默认值 aws）补全支持的存储类型
+func completeStorageClass(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	provider, _ := cmd.Flags().GetString("provider")
+	if provider == "" {
+		provider = os.Getenv("S3BACKUP_PROVIDER")
+	}
+	if provider == "" {
+		provider = "aws"
+	}
+
+	var names []string
+	for _, sc := range storage.SupportedStorageClassesFor(provider) {
+		names = append(names, sc.Name()+"\t"+storage.NativeStorageClass(provider, sc))
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeBackupName 从状态目录补全未完成的备份名称
+func completeBackupName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// 状态目录可能来自 --state-dir 或配置文件的 state.dir
+	var dir string
+	if cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags()); err == nil {
+		dir = cfg.State.Dir
+	}
+	states, err := state.ListStates(dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var names []string
+	for _, s := range states {
+		if strings.HasPrefix(s.Key, toComplete) {
+			names = append(names, s.Key)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePathGroup 从配置文件补全 backup.paths 中的路径组名称
+func completePathGroup(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.LoadConfig(cfgFile, envFile)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return pathGroupNames(cfg.Backup.Paths), cobra.ShellCompDirectiveNoFileComp
+}
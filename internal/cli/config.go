@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/term"
+)
+
+// configCmd 配置管理命令
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "配置文件管理",
+}
+
+// configEncryptCmd 加密配置文件中的凭证
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "加密配置文件中的凭证",
+	Long: `使用口令加密配置文件中的 access_key、secret_key 和加密密码，
+加载配置时设置 ` + config.ConfigPassphraseEnv + ` 环境变量即可透明解密。
+适用于通过 dotfiles 仓库同步配置文件的场景。`,
+	Args: cobra.NoArgs,
+	RunE: runConfigEncrypt,
+}
+
+// configShowCmd 显示实际生效的配置
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "显示合并后实际生效的配置（敏感信息已屏蔽）",
+	Long: `显示合并配置文件、环境变量和命令行参数后实际生效的配置，
+用于排查配置优先级问题。凭证和密码会被屏蔽显示。`,
+	Args: cobra.NoArgs,
+	RunE: runConfigShow,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configEncryptCmd)
+	configCmd.AddCommand(configShowCmd)
+
+	// 与 backup 命令相同的覆盖 flags
+	addConfigFlags(configShowCmd)
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	path, err := config.FindConfigFile(cfgFile)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		path = i18n.T("(未找到，使用默认值)")
+	}
+
+	out := cmd.OutOrStdout()
+	i18n.Fprintf(out, "# 配置文件: %s\n", path)
+
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg.Redacted()); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	return enc.Close()
+}
+
+func runConfigEncrypt(cmd *cobra.Command, args []string) error {
+	path, err := config.FindConfigFile(cfgFile)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return fmt.Errorf("config file not found, use --config to specify one")
+	}
+
+	passphrase, err := readConfigPassphrase()
+	if err != nil {
+		return err
+	}
+
+	count, err := config.EncryptConfigFile(path, passphrase)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		i18n.Printf("没有需要加密的凭证: %s\n", path)
+		return nil
+	}
+	i18n.Printf("已加密 %d 个凭证: %s\n", count, path)
+	i18n.Printf("加载配置时请设置环境变量 %s\n", config.ConfigPassphraseEnv)
+	return nil
+}
+
+// readConfigPassphrase 从环境变量读取口令，未设置时在终端中提示输入
+func readConfigPassphrase() (string, error) {
+	if passphrase := os.Getenv(config.ConfigPassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("passphrase is required, set %s", config.ConfigPassphraseEnv)
+	}
+
+	i18n.Fprintf(os.Stderr, "请输入口令: ")
+	first, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	i18n.Fprintf(os.Stderr, "请再次输入口令: ")
+	second, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	if string(first) != string(second) {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	if len(first) == 0 {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+
+	return string(first), nil
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+)
+
+var (
+	debugHTTP   bool
+	httpMetrics *storage.RequestMetrics // 启用 --debug-http 后创建第一个存储适配器时初始化
+)
+
+// httpTraceOptions 返回存储适配器的请求跟踪选项，未启用 --debug-http 时返回 nil
+func httpTraceOptions() []storage.AWSOption {
+	if !debugHTTP {
+		return nil
+	}
+	if httpMetrics == nil {
+		httpMetrics = storage.NewRequestMetrics()
+	}
+	return []storage.AWSOption{storage.WithRequestTrace(traceHTTP)}
+}
+
+// traceHTTP 在标准错误输出一行请求日志并计入统计
+// 日志只包含路径和分块号，不包含 UploadID、签名等敏感信息
+func traceHTTP(t storage.RequestTrace) {
+	httpMetrics.Record(t)
+	status := strconv.Itoa(t.Status)
+	if t.Err != nil {
+		status = t.Err.Error()
+	}
+	fmt.Fprintf(os.Stderr, "[HTTP] %s %s %s %s %s retry=%d\n",
+		t.Operation, t.Method, t.Path, status, t.Duration.Round(time.Millisecond), t.Retry)
+}
+
+// printHTTPMetrics 命令结束时在标准错误输出各操作的请求耗时分布
+func printHTTPMetrics() {
+	if httpMetrics == nil {
+		return
+	}
+	stats := httpMetrics.Summary()
+	if len(stats) == 0 {
+		return
+	}
+	round := func(d time.Duration) time.Duration { return d.Round(time.Millisecond) }
+	i18n.Fprintf(os.Stderr, "存储请求统计:\n")
+	for _, s := range stats {
+		i18n.Fprintf(os.Stderr, "  %s: %d 次，失败 %d 次，重试 %d 次，p50 %s，p90 %s，p99 %s，最大 %s\n",
+			s.Operation, s.Count, s.Errors, s.Retries, round(s.P50), round(s.P90), round(s.P99), round(s.Max))
+	}
+}
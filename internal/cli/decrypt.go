@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	decryptOutput string
+	decryptForce  bool
+)
+
+// decryptCmd 离线解密命令
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt <file.enc>",
+	Short: "离线解密本地的加密备份文件",
+	Long: `使用密码或密钥文件解密本地的加密备份文件，不需要访问存储桶。
+
+输出默认为去掉 .enc 后缀的文件名，"-" 表示标准输出。输入为 "-" 时从标准输入读取。
+数据边读边解密，HMAC 在读到文件末尾时校验：写入文件时先写入临时文件，
+校验通过后才重命名为目标文件；输出到标准输出时，校验失败会以错误退出，
+此前输出的数据不可信。
+
+密码可以通过 --password、` + "S3BACKUP_ENCRYPT_PASSWORD" + ` 环境变量或配置文件提供，
+都未提供时在终端中提示输入。`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDecrypt,
+}
+
+func init() {
+	rootCmd.AddCommand(decryptCmd)
+
+	decryptCmd.Flags().StringVarP(&decryptOutput, "output", "o", "", "输出文件（默认去掉 .enc 后缀，- 表示标准输出）")
+	decryptCmd.Flags().BoolVarP(&decryptForce, "force", "f", false, "覆盖已存在的输出文件")
+	decryptCmd.Flags().String("password", "", "解密密码")
+	decryptCmd.Flags().String("key-file", "", "密钥文件")
+}
+
+func runDecrypt(cmd *cobra.Command, args []string) error {
+	input := args[0]
+
+	// 只使用配置中的加密部分，不要求存储配置完整
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	keys, err := decryptKeys(cfg)
+	if err != nil {
+		return err
+	}
+
+	output := decryptOutput
+	if output == "" {
+		if input == "-" {
+			output = "-"
+		} else {
+			output = defaultDecryptOutput(input)
+		}
+	}
+
+	header, n, err := decryptFile(input, output, keys, decryptForce)
+	if err != nil {
+		return err
+	}
+
+	msg := cmd.OutOrStdout()
+	if output == "-" {
+		msg = cmd.ErrOrStderr()
+	}
+	i18n.Fprintf(msg, "解密成功: %s -> %s（%d 字节，加密格式 v%d）\n", input, output, n, header.Version)
+	return nil
+}
+
+// defaultDecryptOutput 返回默认输出文件名：去掉 .enc 后缀，没有该后缀时追加 .dec
+func defaultDecryptOutput(input string) string {
+	if trimmed := strings.TrimSuffix(input, ".enc"); trimmed != input && trimmed != "" {
+		return trimmed
+	}
+	return input + ".dec"
+}
+
+// decryptFile 解密 input 写入 output（"-" 表示标准输入/输出），返回文件头和明文字节数
+// 写入文件时 HMAC 校验通过后才生成输出文件，见 writeOutput
+func decryptFile(input, output string, keys crypto.KeySource, force bool) (*crypto.Header, int64, error) {
+	var in io.Reader = os.Stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to open input: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	plaintext, header, err := crypto.OpenReader(in, keys)
+	if err != nil {
+		return header, 0, fmt.Errorf("failed to open encrypted file: %w", err)
+	}
+
+	var n int64
+	err = writeOutput(output, force, func(w io.Writer) error {
+		n, err = io.Copy(w, plaintext)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt: %w", err)
+		}
+		return nil
+	})
+	return header, n, err
+}
+
+// decryptKeys 返回解密密钥，配置中没有密码和密钥文件时在终端中提示输入密码
+func decryptKeys(cfg *config.Config) (crypto.KeySource, error) {
+	keys, err := keySource(cfg)
+	if err == nil || cfg.Encryption.KeyFile != "" {
+		return keys, err
+	}
+	password, err := promptPassword(i18n.T("请输入解密密码: "))
+	if err != nil {
+		return crypto.KeySource{}, err
+	}
+	return crypto.KeySource{Password: password}, nil
+}
+
+// promptPassword 在终端中提示输入密码
+func promptPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("encryption password or key_file is required")
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	if len(password) == 0 {
+		return "", fmt.Errorf("password cannot be empty")
+	}
+	return string(password), nil
+}
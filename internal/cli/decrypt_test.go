@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+)
+
+// TestDecryptFile 测试离线解密本地文件
+func TestDecryptFile(t *testing.T) {
+	dir := t.TempDir()
+	keyFile, _ := crypto.GenerateKeyFile()
+	aesKey, hmacKey, _ := crypto.DeriveKeyFromKeyFile(keyFile)
+	encryptor, _ := crypto.NewStreamEncryptor(aesKey, hmacKey)
+	keys := crypto.KeySource{KeyFile: keyFile}
+
+	plaintext := bytes.Repeat([]byte("offline recovery "), 10000)
+	var buf bytes.Buffer
+	w, _ := encryptor.WrapWriter(&buf)
+	w.Write(plaintext)
+	w.Close()
+
+	input := filepath.Join(dir, "backup.tar.gz.enc")
+	if err := os.WriteFile(input, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	output := defaultDecryptOutput(input)
+
+	header, n, err := decryptFile(input, output, keys, false)
+	if err != nil {
+		t.Fatalf("decryptFile() error = %v", err)
+	}
+	if header.Version != crypto.FormatVersion || n != int64(len(plaintext)) {
+		t.Errorf("version = %d, bytes = %d", header.Version, n)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "backup.tar.gz"))
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Error("decrypted data does not match original plaintext")
+	}
+
+	// 不覆盖已存在的文件
+	if _, _, err := decryptFile(input, output, keys, false); err == nil {
+		t.Error("expected error when output exists without force")
+	}
+	if _, _, err := decryptFile(input, output, keys, true); err != nil {
+		t.Errorf("decryptFile() with force error = %v", err)
+	}
+}
+
+// TestDecryptFileTampered 测试校验失败时不留下输出文件
+func TestDecryptFileTampered(t *testing.T) {
+	dir := t.TempDir()
+	keyFile, _ := crypto.GenerateKeyFile()
+
+	blob := legacyBlob(t, keyFile, bytes.Repeat([]byte("x"), 4096))
+	blob[crypto.LegacyHeaderSize+100] ^= 0xff
+	input := filepath.Join(dir, "tampered.enc")
+	if err := os.WriteFile(input, blob, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "tampered")
+	_, _, err := decryptFile(input, output, crypto.KeySource{KeyFile: keyFile}, false)
+	if !errors.Is(err, crypto.ErrHMACMismatch) {
+		t.Fatalf("expected ErrHMACMismatch, got %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the input file to remain, got %d entries", len(entries))
+	}
+}
+
+// TestDefaultDecryptOutput 测试默认输出文件名
+func TestDefaultDecryptOutput(t *testing.T) {
+	tests := map[string]string{
+		"backup.tar.gz.enc": "backup.tar.gz",
+		"dir/a.enc":         "dir/a",
+		"backup.bin":        "backup.bin.dec",
+		".enc":              ".enc.dec",
+	}
+	for input, want := range tests {
+		if got := defaultDecryptOutput(input); got != want {
+			t.Errorf("defaultDecryptOutput(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
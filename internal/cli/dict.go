@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// dictDirName zstd 字典所在的目录，位于备份所在的前缀下
+const dictDirName = ".s3backup-dicts"
+
+var (
+	trainDictOutput   string
+	trainDictPrefix   string
+	trainDictMaxSize  int
+	trainDictNoUpload bool
+)
+
+// trainDictCmd 训练 zstd 字典
+var trainDictCmd = &cobra.Command{
+	Use:   "train-dict <paths>...",
+	Short: "用样本文件训练 zstd 压缩字典",
+	Long: `读取给定路径下的文件（如一批配置文件或以往的数据库导出）训练 zstd 字典，
+写入本地文件并上传到存储桶的 <prefix>/.s3backup-dicts/<字典 ID>.zdict。
+
+大量相似的小备份单独压缩时，每次都要从头学习数据中的重复内容；使用字典后这些内容可以直接引用，
+备份明显变小。在备份主机上设置 backup.compression: zstd 和 backup.zstd_dictionary 指向本地字典文件，
+restore 和 rehearse 按备份帧头中的字典 ID 从存储桶下载字典，不需要额外配置。
+
+数据的结构发生较大变化后重新训练即可：新字典使用新的 ID，旧备份仍使用各自的字典还原，
+因此不要删除存储桶中旧的字典。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runTrainDict,
+}
+
+func init() {
+	rootCmd.AddCommand(trainDictCmd)
+
+	addConfigFlags(trainDictCmd)
+	trainDictCmd.Flags().StringVarP(&trainDictOutput, "output", "o", "", "字典文件（默认：s3backup-{字典 ID}.zdict）")
+	trainDictCmd.Flags().StringVar(&trainDictPrefix, "prefix", "", "上传字典的前缀，应与备份所在的前缀相同")
+	trainDictCmd.Flags().IntVar(&trainDictMaxSize, "max-size", archive.DefaultDictionarySize, "字典大小上限（字节）")
+	trainDictCmd.Flags().BoolVar(&trainDictNoUpload, "no-upload", false, "只写入本地文件，不上传到存储桶")
+}
+
+func runTrainDict(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	dictionary, err := archive.TrainDictionary(args, trainDictMaxSize)
+	if err != nil {
+		return err
+	}
+	id, err := archive.DictionaryID(dictionary)
+	if err != nil {
+		return err
+	}
+
+	output := trainDictOutput
+	if output == "" {
+		output = fmt.Sprintf("s3backup-%d.zdict", id)
+	}
+	if err := os.WriteFile(output, dictionary, 0644); err != nil {
+		return fmt.Errorf("failed to write dictionary: %w", err)
+	}
+	i18n.Fprintf(cmd.OutOrStdout(), "已生成字典: %s（ID %d，%d 字节）\n", output, id, len(dictionary))
+
+	if !trainDictNoUpload {
+		cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		adapter, err := createStorageAdapter(ctx, cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create storage adapter: %w", err)
+		}
+		key := dictKey(trainDictPrefix, id)
+		if err := putSmallObject(ctx, adapter, key, "application/octet-stream", dictionary, cfg.Storage.ACL); err != nil {
+			return fmt.Errorf("failed to upload dictionary: %w", err)
+		}
+		i18n.Fprintf(cmd.OutOrStdout(), "已上传字典: %s\n", key)
+	}
+
+	i18n.Fprintf(cmd.OutOrStdout(), "在配置文件中启用:\n")
+	fmt.Fprintf(cmd.OutOrStdout(), "  backup:\n    compression: zstd\n    zstd_dictionary: %s\n", output)
+	return nil
+}
+
+// dictKey 返回前缀下 ID 为 id 的字典对象，prefix 为空或 . 时位于存储桶根目录
+func dictKey(prefix string, id uint32) string {
+	prefix = strings.Trim(prefix, "/")
+	name := strconv.FormatUint(uint64(id), 10) + ".zdict"
+	if prefix == "" || prefix == "." {
+		return dictDirName + "/" + name
+	}
+	return prefix + "/" + dictDirName + "/" + name
+}
+
+// isDictKey 判断对象是否为 zstd 字典，清理备份时跳过
+func isDictKey(key string) bool {
+	return strings.HasPrefix(key, dictDirName+"/") || strings.Contains(key, "/"+dictDirName+"/")
+}
+
+// compressionSuffix 返回备份压缩格式的扩展名
+func compressionSuffix(cfg *config.Config) string {
+	if cfg.Backup.Compression == "zstd" {
+		return ".zst"
+	}
+	return ".gz"
+}
+
+// compressionName 返回写入备份元数据和报告的压缩格式
+func compressionName(cfg *config.Config) string {
+	if cfg.Backup.Compression == "zstd" {
+		return "zstd"
+	}
+	return "gzip"
+}
+
+// loadDictionary 读取 backup.zstd_dictionary 配置的字典，未配置时返回 nil
+func loadDictionary(cfg *config.Config) ([]byte, error) {
+	if cfg.Backup.ZstdDictionary == "" {
+		return nil, nil
+	}
+	dictionary, err := os.ReadFile(cfg.Backup.ZstdDictionary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zstd dictionary: %w", err)
+	}
+	if _, err := archive.DictionaryID(dictionary); err != nil {
+		return nil, err
+	}
+	return dictionary, nil
+}
+
+// dictionaryLoader 返回还原 name 时获取 zstd 字典的函数：ID 与 backup.zstd_dictionary 相同时使用本地文件，
+// 否则依次从备份所在前缀和存储桶根目录的 .s3backup-dicts 下载；reader 为 nil（还原本地文件）时只使用本地文件
+func dictionaryLoader(ctx context.Context, cfg *config.Config, reader storage.ObjectReader, name string) func(uint32) ([]byte, error) {
+	return func(id uint32) ([]byte, error) {
+		if local, err := loadDictionary(cfg); err == nil && local != nil {
+			if localID, _ := archive.DictionaryID(local); localID == id {
+				return local, nil
+			}
+		}
+		if reader == nil {
+			return nil, fmt.Errorf("dictionary %d not found locally, set backup.zstd_dictionary to its file", id)
+		}
+
+		keys := []string{dictKey(path.Dir(name), id)}
+		if root := dictKey("", id); root != keys[0] {
+			keys = append(keys, root)
+		}
+		var err error
+		for _, key := range keys {
+			var data []byte
+			data, err = readObject(ctx, reader, key)
+			if err == nil {
+				return data, nil
+			}
+			if !errors.Is(err, storage.ErrObjectNotFound) {
+				return nil, fmt.Errorf("failed to download %s: %w", key, err)
+			}
+		}
+		return nil, fmt.Errorf("dictionary not found in the bucket (%s): %w", strings.Join(keys, ", "), err)
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+func TestDictKey(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"", ".s3backup-dicts/42.zdict"},
+		{".", ".s3backup-dicts/42.zdict"},
+		{"db", "db/.s3backup-dicts/42.zdict"},
+		{"/db/daily/", "db/daily/.s3backup-dicts/42.zdict"},
+	}
+	for _, tt := range tests {
+		key := dictKey(tt.prefix, 42)
+		if key != tt.want {
+			t.Errorf("dictKey(%q) = %q, want %q", tt.prefix, key, tt.want)
+		}
+		if !isDictKey(key) {
+			t.Errorf("isDictKey(%q) = false", key)
+		}
+	}
+	if isDictKey("db/backup-20260101-000000.tar.zst") {
+		t.Error("isDictKey() matched a backup")
+	}
+}
+
+// TestDictionaryLoader 测试还原时先从备份所在前缀、再从存储桶根目录下载字典
+func TestDictionaryLoader(t *testing.T) {
+	adapter := mock.New()
+	adapter.SetObject(dictKey("db", 7), &mock.Object{Data: []byte("prefix dict")})
+	adapter.SetObject(dictKey("", 8), &mock.Object{Data: []byte("root dict")})
+
+	cfg := &config.Config{}
+	load := dictionaryLoader(context.Background(), cfg, adapter, "db/backup-20260101-000000.tar.zst")
+	if data, err := load(7); err != nil || string(data) != "prefix dict" {
+		t.Errorf("load(7) = %q, %v", data, err)
+	}
+	if data, err := load(8); err != nil || string(data) != "root dict" {
+		t.Errorf("load(8) = %q, %v", data, err)
+	}
+	if _, err := load(9); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Errorf("load(9) error = %v, want ErrObjectNotFound", err)
+	}
+
+	// 还原本地文件时只能使用配置的字典
+	if _, err := dictionaryLoader(context.Background(), cfg, nil, "backup.tar.zst")(7); err == nil {
+		t.Error("load without reader succeeded")
+	}
+}
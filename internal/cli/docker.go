@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/docker"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+)
+
+// resolveDockerVolumes 通过 Docker Engine API 查询卷的挂载点
+func resolveDockerVolumes(ctx context.Context, names []string) ([]*docker.Volume, error) {
+	host := docker.Host()
+	volumes := make([]*docker.Volume, 0, len(names))
+	for _, name := range names {
+		vol, err := docker.InspectVolume(ctx, host, name)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, vol)
+	}
+	return volumes, nil
+}
+
+// volumePaths 返回卷在宿主机上的目录，任一目录无法直接读取时返回错误
+func volumePaths(volumes []*docker.Volume) ([]string, error) {
+	paths := make([]string, 0, len(volumes))
+	for _, vol := range volumes {
+		if !vol.Readable() {
+			return nil, fmt.Errorf("docker volume %s mountpoint %s is not readable: run as root, "+
+				"or back up this volume alone to use a helper container", vol.Name, vol.Mountpoint)
+		}
+		paths = append(paths, vol.Mountpoint)
+	}
+	return paths, nil
+}
+
+// backupVolumeWithHelper 使用辅助容器打包卷内容并上传为名为 name 的对象
+// 用于无法直接读取卷目录的情况（rootless Docker、Docker Desktop 等），此时不应用排除规则
+func backupVolumeWithHelper(ctx context.Context, cfg *config.Config, vol *docker.Volume, name string) error {
+	// 辅助容器总是输出 tar.gz，不使用 backup.compression
+	gzipCfg := *cfg
+	gzipCfg.Backup.Compression, gzipCfg.Backup.ZstdDictionary = "gzip", ""
+	cfg = &gzipCfg
+
+	printBackupConfig(cfg, name)
+	i18n.Printf("  Docker 卷: %s（无法直接读取 %s，使用辅助容器 %s 打包）\n", vol.Name, vol.Mountpoint, docker.HelperImage)
+	fmt.Println()
+
+	adapter, err := createStorageAdapter(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create storage adapter: %w", err)
+	}
+	report := newBackupReport(cfg, name)
+	report.setSource("docker-volume:" + vol.Name)
+	return backupOnce(ctx, cfg, adapter, name, report, func(ctx context.Context, w io.Writer, _ *archive.CompressionMeter) error {
+		return writeEncrypted(ctx, w, cfg, func(w io.Writer) error {
+			return docker.ArchiveVolume(ctx, vol.Name, docker.HelperImage, w)
+		})
+	})
+}
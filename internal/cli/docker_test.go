@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/docker"
+)
+
+// TestVolumePaths 测试卷目录可读时作为包含路径，不可读时提示使用辅助容器
+func TestVolumePaths(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := volumePaths([]*docker.Volume{{Name: "data", Mountpoint: dir}})
+	if err != nil {
+		t.Fatalf("volumePaths() error = %v", err)
+	}
+	if !reflect.DeepEqual(paths, []string{dir}) {
+		t.Errorf("volumePaths() = %v", paths)
+	}
+
+	_, err = volumePaths([]*docker.Volume{{Name: "data", Mountpoint: dir}, {Name: "db", Mountpoint: filepath.Join(dir, "missing")}})
+	if err == nil || !strings.Contains(err.Error(), "helper container") {
+		t.Errorf("expected unreadable volume error, got %v", err)
+	}
+}
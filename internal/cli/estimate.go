@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/uploader"
+)
+
+// groupSizes 统计各备份组待备份数据的大小（压缩前）
+func groupSizes(ctx context.Context, cfg *config.Config, groups []backupGroup) ([]int64, error) {
+	sizes := make([]int64, len(groups))
+	for i, g := range groups {
+		excludes := append(append([]string(nil), cfg.Backup.Excludes...), g.Excludes...)
+		arc, err := newArchiver(cfg, g.Includes, excludes, nil)
+		if err != nil {
+			return nil, err
+		}
+		sizes[i], err = arc.GetTotalSize(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate backup size: %w", err)
+		}
+	}
+	return sizes, nil
+}
+
+// printCostEstimate 按备份组输出参考存储费用和上传请求费用
+//
+// 大小按压缩前计算，实际费用通常更低；没有价格数据的存储类型只输出大小。
+func printCostEstimate(w io.Writer, cfg *config.Config, groups []backupGroup, sizes []int64) {
+	i18n.Fprintf(w, "费用估算（%s 参考价格，按压缩前大小计算，仅供参考）:\n", cfg.Storage.Provider)
+
+	for i, g := range groups {
+		size := sizes[i]
+		sc := storage.ParseStorageClass(g.StorageClass)
+		chunkSize := uploader.ChunkSizeFor(size, cfg.Backup.ChunkSize)
+		est, ok := storage.EstimateCost(cfg.Storage.Provider, sc, size, chunkSize)
+		if !ok {
+			i18n.Fprintf(w, "  %s: %.2f GB，无价格数据\n", g.StorageClass, float64(size)/(1<<30))
+			continue
+		}
+
+		i18n.Fprintf(w, "  %s: %.2f GB，存储约 %.4f %s/月，上传请求 %d 次约 %.4f %s\n",
+			g.StorageClass, float64(size)/(1<<30),
+			est.MonthlyStorage, est.Currency, est.Requests, est.UploadRequests, est.Currency)
+		if est.MinStorageDays > 0 {
+			i18n.Fprintf(w, "    最短存储 %d 天，提前删除仍按 %d 天计费\n", est.MinStorageDays, est.MinStorageDays)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// checkSizeBudget 检查待备份数据总大小是否超过 backup.max_total_size
+// 超过时按 max_total_size_action 返回错误（abort）或输出警告（warn）
+func checkSizeBudget(w io.Writer, cfg *config.BackupConfig, total int64) error {
+	if cfg.MaxTotalSize <= 0 || total <= cfg.MaxTotalSize {
+		return nil
+	}
+	if cfg.MaxTotalSizeAction == "warn" {
+		i18n.Fprintf(w, "警告: 待备份数据 %.2f GB 超过上限 %.2f GB (backup.max_total_size)\n\n",
+			float64(total)/(1<<30), float64(cfg.MaxTotalSize)/(1<<30))
+		return nil
+	}
+	return fmt.Errorf("backup size %d bytes exceeds max_total_size %d bytes, check includes or raise the limit", total, cfg.MaxTotalSize)
+}
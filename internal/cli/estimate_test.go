@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+)
+
+// TestPrintCostEstimate 测试按备份组统计大小并输出费用估算，组内排除的路径不计入大小
+func TestPrintCostEstimate(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "logs"), 0755)
+	os.WriteFile(filepath.Join(root, "app.conf"), make([]byte, 1000), 0644)
+	os.WriteFile(filepath.Join(root, "logs", "a.log"), make([]byte, 5000), 0644)
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{Provider: "aws"},
+		Backup:  config.BackupConfig{ChunkSize: 5 * 1024 * 1024},
+	}
+	rules := []config.StorageClassRule{{Path: filepath.Join(root, "logs"), StorageClass: "deep_archive"}}
+	groups := planBackupGroups([]string{root}, rules, "standard")
+
+	sizes, err := groupSizes(context.Background(), cfg, groups)
+	if err != nil {
+		t.Fatalf("groupSizes() error = %v", err)
+	}
+	if !reflect.DeepEqual(sizes, []int64{1000, 5000}) {
+		t.Errorf("sizes = %v, want [1000 5000]", sizes)
+	}
+
+	var buf bytes.Buffer
+	printCostEstimate(&buf, cfg, groups, sizes)
+	out := buf.String()
+	for _, want := range []string{"standard: 0.00 GB", "deep_archive: 0.00 GB", "上传请求 3 次", "USD", "最短存储 180 天"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestCheckSizeBudget 测试超过大小上限时中止或警告
+func TestCheckSizeBudget(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &config.BackupConfig{MaxTotalSize: 1000, MaxTotalSizeAction: "abort"}
+	if err := checkSizeBudget(&buf, cfg, 1000); err != nil {
+		t.Errorf("size at limit should pass, got %v", err)
+	}
+	if err := checkSizeBudget(&buf, cfg, 1001); err == nil || !strings.Contains(err.Error(), "max_total_size") {
+		t.Errorf("expected max_total_size error, got %v", err)
+	}
+
+	cfg.MaxTotalSizeAction = "warn"
+	if err := checkSizeBudget(&buf, cfg, 1001); err != nil {
+		t.Errorf("warn should not fail, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "警告") {
+		t.Errorf("expected warning output, got %q", buf.String())
+	}
+
+	if err := checkSizeBudget(&buf, &config.BackupConfig{}, 1<<40); err != nil {
+		t.Errorf("zero limit should not fail, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// ErrStale 前缀下最近一次成功的备份早于 backup.max_age，或者没有任何备份
+var ErrStale = errors.New("no recent backup")
+
+var freshnessPrefix string
+
+// freshnessCmd 检查最近一次备份的时间
+var freshnessCmd = &cobra.Command{
+	Use:   "freshness",
+	Short: "检查最近一次成功的备份是否超过允许的时长（RPO）",
+	Long: `列出 --prefix 下的备份，最近一次完成上传的备份早于 --max-age（配置项 backup.max_age）
+或前缀下没有备份时以失败状态退出。
+
+s3backup 没有常驻进程，备份失败或定时任务没有运行时不会有任何输出。
+将 freshness 作为独立的定时任务或监控探针运行，可以发现这类静默的失败，
+由 systemd（OnFailure=）、cron（MAILTO）或监控系统发出通知。每个 profile 使用各自的配置文件和 max_age。
+
+示例:
+  s3backup freshness --max-age 26h
+  s3backup -c /etc/s3backup/db.yaml freshness --prefix db/ --max-age 2h`,
+	Args: cobra.NoArgs,
+	RunE: runFreshness,
+}
+
+func init() {
+	rootCmd.AddCommand(freshnessCmd)
+
+	addConfigFlags(freshnessCmd)
+	freshnessCmd.Flags().StringVar(&freshnessPrefix, "prefix", "backup-", "备份对象的 key 前缀")
+	freshnessCmd.Flags().Duration("max-age", 0, "最近一次成功的备份允许的最长时间（如 26h）")
+}
+
+func runFreshness(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.Backup.MaxAge <= 0 {
+		return fmt.Errorf("--max-age (backup.max_age) must be positive")
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	adapter, err := createRestoreAdapter(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create storage adapter: %w", err)
+	}
+	lister, ok := adapter.(storage.ObjectLister)
+	if !ok {
+		return fmt.Errorf("provider %s does not support listing objects", cfg.Storage.Provider)
+	}
+	return checkFreshness(ctx, os.Stdout, lister, freshnessPrefix, cfg.Backup.MaxAge, time.Now())
+}
+
+// checkFreshness 找出 prefix 下最近完成上传的备份（跳过报告、签名、标记对象、锁和运行记录），
+// 早于 maxAge 或没有备份时返回 ErrStale
+func checkFreshness(ctx context.Context, w io.Writer, lister storage.ObjectLister, prefix string, maxAge time.Duration, now time.Time) error {
+	objects, err := listPrunable(ctx, lister, prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+	var latest *storage.ObjectInfo
+	for i, obj := range objects {
+		if isCompanionKey(obj.Key) || path.Base(obj.Key) == markerName {
+			continue
+		}
+		if latest == nil || obj.LastModified.After(latest.LastModified) {
+			latest = &objects[i]
+		}
+	}
+	if latest == nil {
+		return fmt.Errorf("%w: no backups under %q", ErrStale, prefix)
+	}
+
+	age := now.Sub(latest.LastModified).Round(time.Minute)
+	i18n.Fprintf(w, "最近一次备份: %s（%s，%s 前）\n", latest.Key, latest.LastModified.Local().Format("2006-01-02 15:04:05"), age)
+	if age > maxAge {
+		return fmt.Errorf("%w: latest backup %s is %s old (max %s)", ErrStale, latest.Key, age, maxAge)
+	}
+	i18n.Fprintf(w, "最近一次备份在允许的时长 %s 内\n", maxAge)
+	return nil
+}
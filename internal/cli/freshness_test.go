@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestCheckFreshness 测试按最近完成上传的备份判断是否超过 max_age，报告、签名、锁和运行记录不计入
+func TestCheckFreshness(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	adapter := mock.New()
+	adapter.SetObject("backup-1.tar.gz", &mock.Object{LastModified: now.Add(-50 * time.Hour)})
+	adapter.SetObject("backup-2.tar.gz", &mock.Object{LastModified: now.Add(-30 * time.Hour)})
+	adapter.SetObject("backup-2.tar.gz"+reportSuffix, &mock.Object{LastModified: now.Add(-time.Hour)})
+	adapter.SetObject(runRecordKey("", "default-20260302"), &mock.Object{LastModified: now.Add(-time.Hour)})
+
+	var out bytes.Buffer
+	err := checkFreshness(context.Background(), &out, adapter, "", 26*time.Hour, now)
+	if !errors.Is(err, ErrStale) {
+		t.Fatalf("checkFreshness() error = %v, want ErrStale", err)
+	}
+	if !strings.Contains(err.Error(), "backup-2.tar.gz") {
+		t.Errorf("error %q does not name the latest backup", err)
+	}
+
+	adapter.SetObject("backup-3.tar.gz", &mock.Object{LastModified: now.Add(-2 * time.Hour)})
+	out.Reset()
+	if err := checkFreshness(context.Background(), &out, adapter, "", 26*time.Hour, now); err != nil {
+		t.Fatalf("checkFreshness() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "backup-3.tar.gz") {
+		t.Errorf("output %q does not name the latest backup", out.String())
+	}
+
+	if err := checkFreshness(context.Background(), &out, adapter, "db/", 26*time.Hour, now); !errors.Is(err, ErrStale) {
+		t.Errorf("checkFreshness() with no backups error = %v, want ErrStale", err)
+	}
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// i18nDirs 输出用户可见消息的包，其消息目录都由本包导入时注册
+var i18nDirs = []string{".", "../../pkg/archive", "../../pkg/tui"}
+
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestCatalogComplete 检查源代码中的每条中文消息都经过 i18n 输出，并且有英文翻译
+func TestCatalogComplete(t *testing.T) {
+	defer i18n.SetLang(i18n.Current())
+	i18n.SetLang(i18n.En)
+
+	for _, dir := range i18nDirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range files {
+			// 消息目录 messages_<lang>.go 的键本身就是中文消息
+			if strings.HasSuffix(path, "_test.go") || strings.HasPrefix(filepath.Base(path), "messages_") {
+				continue
+			}
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkFileMessages(t, fset, file)
+		}
+	}
+}
+
+func checkFileMessages(t *testing.T, fset *token.FileSet, file *ast.File) {
+	// 允许出现中文的位置：i18n 函数的参数、命令的 Short/Long 和参数说明（由 localizeCommands 翻译）
+	// deferred 为拼接的命令说明，由 TestCommandHelpTranslated 在运行时检查
+	localized := make(map[*ast.BasicLit]bool)
+	deferred := make(map[*ast.BasicLit]bool)
+	mark := func(e ast.Expr) {
+		ast.Inspect(e, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.BasicLit); ok {
+				if _, concat := e.(*ast.BinaryExpr); concat {
+					deferred[lit] = true
+				} else {
+					localized[lit] = true
+				}
+			}
+			return true
+		})
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			if n.Tag != nil {
+				deferred[n.Tag] = true
+			}
+		case *ast.KeyValueExpr:
+			if key, ok := n.Key.(*ast.Ident); ok && (key.Name == "Short" || key.Name == "Long") {
+				mark(n.Value)
+			}
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "i18n" {
+				for _, arg := range n.Args {
+					mark(arg)
+				}
+			}
+			if call, ok := sel.X.(*ast.CallExpr); ok {
+				if fn, ok := call.Fun.(*ast.SelectorExpr); ok && (fn.Sel.Name == "Flags" || fn.Sel.Name == "PersistentFlags") {
+					for _, arg := range n.Args {
+						mark(arg)
+					}
+				}
+			}
+		case *ast.BasicLit:
+			if n.Kind != token.STRING || deferred[n] || !hasHan(n.Value) {
+				return true
+			}
+			s, err := strconv.Unquote(n.Value)
+			if err != nil {
+				t.Fatalf("%s: %v", fset.Position(n.Pos()), err)
+			}
+			if !localized[n] {
+				t.Errorf("%s: Chinese message %q is not localized, use i18n", fset.Position(n.Pos()), s)
+			} else if i18n.T(s) == s {
+				t.Errorf("%s: missing English translation for %q", fset.Position(n.Pos()), s)
+			}
+		}
+		return true
+	})
+}
+
+// TestCommandHelpTranslated 检查所有命令的说明、参数说明和参数弃用提示都有英文翻译
+func TestCommandHelpTranslated(t *testing.T) {
+	defer i18n.SetLang(i18n.Current())
+	i18n.SetLang(i18n.En)
+
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for _, s := range []string{cmd.Short, cmd.Long} {
+			if hasHan(s) && hasHan(i18n.T(s)) {
+				t.Errorf("%s: missing English help %q", cmd.CommandPath(), s)
+			}
+		}
+		check := func(f *pflag.Flag) {
+			if hasHan(f.Usage) && hasHan(i18n.T(f.Usage)) {
+				t.Errorf("%s --%s: missing English usage %q", cmd.CommandPath(), f.Name, f.Usage)
+			}
+			if hasHan(f.Deprecated) && hasHan(i18n.T(f.Deprecated)) {
+				t.Errorf("%s --%s: missing English deprecation message %q", cmd.CommandPath(), f.Name, f.Deprecated)
+			}
+		}
+		cmd.LocalFlags().VisitAll(check)
+		cmd.PersistentFlags().VisitAll(check)
+		for _, c := range cmd.Commands() {
+			walk(c)
+		}
+	}
+	walk(rootCmd)
+}
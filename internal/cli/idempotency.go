@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+)
+
+// runRecordDirName 成功运行记录所在的目录，位于备份所在的前缀下
+const runRecordDirName = ".s3backup-runs"
+
+// runRecord 启用 backup.idempotent 时备份成功后写入的运行记录，重试的任务据此跳过
+type runRecord struct {
+	Key       string    `json:"key"`
+	Backup    string    `json:"backup"`
+	Host      string    `json:"host"`
+	Completed time.Time `json:"completed"`
+}
+
+// idempotencyKey 由 profile 和本地日期生成幂等键，同一 profile 每天最多一次成功的备份
+func idempotencyKey(profile string, t time.Time) string {
+	return profile + "-" + t.Local().Format("20060102")
+}
+
+// runRecordKey 返回前缀下幂等键 key 的运行记录对象，prefix 为空或 . 时位于存储桶根目录
+func runRecordKey(prefix, key string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" || prefix == "." {
+		return runRecordDirName + "/" + key + ".json"
+	}
+	return prefix + "/" + runRecordDirName + "/" + key + ".json"
+}
+
+// isRunRecordKey 判断对象是否为运行记录，清理备份时跳过
+func isRunRecordKey(key string) bool {
+	return strings.HasPrefix(key, runRecordDirName+"/") || strings.Contains(key, "/"+runRecordDirName+"/")
+}
+
+// runIdempotent 按 profile 和 started 的日期生成幂等键，prefix 下已有该键的运行记录时跳过 run 并返回记录中的备份名，
+// 否则执行 run，成功后写入运行记录。运行记录无法读取（只写凭证等）时照常备份
+func runIdempotent(ctx context.Context, cfg *config.Config, adapter storage.StorageAdapter, profile string,
+	started time.Time, prefix string, run func() (string, error)) (string, error) {
+	key := idempotencyKey(profile, started)
+	recordKey := runRecordKey(prefix, key)
+	record, err := readRunRecord(ctx, adapter, recordKey)
+	if err != nil {
+		i18n.Printf("警告: %v\n", err)
+	}
+	if record != nil {
+		i18n.Printf("幂等键 %s 的备份已于 %s 在 %s 完成: %s，跳过\n",
+			key, record.Completed.Local().Format("2006-01-02 15:04:05"), record.Host, record.Backup)
+		return record.Backup, nil
+	}
+
+	name, err := run()
+	if err != nil {
+		return name, err
+	}
+
+	host, _ := os.Hostname()
+	data, err := json.MarshalIndent(runRecord{Key: key, Backup: name, Host: host, Completed: time.Now().UTC()}, "", "  ")
+	if err != nil {
+		return name, fmt.Errorf("failed to encode run record: %w", err)
+	}
+	// 备份已经成功，运行记录写入失败只影响重试时的去重
+	if err := putSmallObject(ctx, adapter, recordKey, "application/json", data, cfg.Storage.ACL); err != nil {
+		i18n.Printf("警告: 写入运行记录 %s 失败: %v\n", recordKey, err)
+	}
+	return name, nil
+}
+
+// readRunRecord 读取运行记录，不存在或没有读取权限时返回 nil
+func readRunRecord(ctx context.Context, adapter storage.StorageAdapter, key string) (*runRecord, error) {
+	reader, ok := adapter.(storage.ObjectReader)
+	if !ok {
+		return nil, nil
+	}
+	data, err := readObject(ctx, reader, key)
+	if errors.Is(err, storage.ErrObjectNotFound) || errors.Is(err, storage.ErrAuth) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run record %s: %w", key, err)
+	}
+	var record runRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse run record %s: %w", key, err)
+	}
+	return &record, nil
+}
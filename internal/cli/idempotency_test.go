@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestRunRecordKey 测试运行记录位于备份所在的前缀下
+func TestRunRecordKey(t *testing.T) {
+	day := time.Date(2026, 1, 2, 12, 0, 0, 0, time.Local)
+	key := idempotencyKey("db", day)
+	if key != "db-20260102" {
+		t.Errorf("idempotencyKey() = %q", key)
+	}
+	for prefix, want := range map[string]string{"": ".s3backup-runs/db-20260102.json", ".": ".s3backup-runs/db-20260102.json", "host1": "host1/.s3backup-runs/db-20260102.json"} {
+		got := runRecordKey(prefix, key)
+		if got != want {
+			t.Errorf("runRecordKey(%q) = %q, want %q", prefix, got, want)
+		}
+		if !isRunRecordKey(got) {
+			t.Errorf("isRunRecordKey(%q) = false", got)
+		}
+	}
+}
+
+// TestRunIdempotent 测试当天已有成功的运行记录时跳过，失败的运行不写入记录
+func TestRunIdempotent(t *testing.T) {
+	adapter := mock.New()
+	cfg := &config.Config{}
+	ctx := context.Background()
+	day := time.Date(2026, 1, 2, 3, 0, 0, 0, time.Local)
+
+	runs := 0
+	run := func(name string, err error) func() (string, error) {
+		return func() (string, error) {
+			runs++
+			return name, err
+		}
+	}
+
+	if _, err := runIdempotent(ctx, cfg, adapter, "db", day, "", run("", errors.New("dump failed"))); err == nil {
+		t.Fatal("expected the failed run to return its error")
+	}
+	if _, ok := adapter.Object(runRecordKey("", "db-20260102")); ok {
+		t.Error("failed run should not be recorded")
+	}
+
+	name, err := runIdempotent(ctx, cfg, adapter, "db", day, "", run("backup-db-1.tar.gz", nil))
+	if err != nil || name != "backup-db-1.tar.gz" {
+		t.Fatalf("runIdempotent() = %q, %v", name, err)
+	}
+
+	// 同一天重试时跳过，返回已完成的备份
+	name, err = runIdempotent(ctx, cfg, adapter, "db", day.Add(5*time.Hour), "", run("backup-db-2.tar.gz", nil))
+	if err != nil || name != "backup-db-1.tar.gz" {
+		t.Errorf("retried runIdempotent() = %q, %v, want the earlier backup", name, err)
+	}
+	if runs != 2 {
+		t.Errorf("runs = %d, want the retry to be skipped", runs)
+	}
+
+	// 其他 profile 和第二天不受影响
+	if _, err := runIdempotent(ctx, cfg, adapter, "web", day, "", run("backup-web-1.tar.gz", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runIdempotent(ctx, cfg, adapter, "db", day.AddDate(0, 0, 1), "", run("backup-db-3.tar.gz", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 4 {
+		t.Errorf("runs = %d, want 4", runs)
+	}
+}
@@ -0,0 +1,286 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	k8sMode        bool
+	secretsDir     string
+	terminationLog string
+)
+
+const (
+	// defaultSecretsDir --k8s 模式下默认的 Secret 挂载目录
+	defaultSecretsDir = "/var/run/secrets/s3backup"
+	// defaultTerminationLog Kubernetes 默认的 terminationMessagePath
+	defaultTerminationLog = "/dev/termination-log"
+	// maxTerminationMessage Kubernetes 终止消息的长度上限
+	maxTerminationMessage = 4096
+)
+
+// --k8s 模式下的退出码
+const (
+	exitOK             = 0
+	exitFailure        = 1
+	exitConfig         = 2
+	exitAuth           = 3
+	exitBucketNotFound = 4
+	exitNetwork        = 5
+	exitThrottled      = 6
+	exitUploadCheck    = 7
+)
+
+// exitError 带退出码的错误，Execute 按 code 退出进程
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// configError 配置加载或校验失败，--k8s 模式下以 exitConfig 退出
+type configError struct {
+	err error
+}
+
+func (e *configError) Error() string { return e.err.Error() }
+func (e *configError) Unwrap() error { return e.err }
+
+// exitCode 返回错误对应的退出码
+func exitCode(err error) int {
+	var cfgErr *configError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &cfgErr):
+		return exitConfig
+	case errors.Is(err, storage.ErrAuth):
+		return exitAuth
+	case errors.Is(err, storage.ErrBucketNotFound):
+		return exitBucketNotFound
+	case errors.Is(err, storage.ErrNetwork):
+		return exitNetwork
+	case errors.Is(err, storage.ErrThrottled):
+		return exitThrottled
+	case errors.Is(err, ErrUploadCheck):
+		return exitUploadCheck
+	default:
+		return exitFailure
+	}
+}
+
+// addK8sFlags 注册 --k8s 模式相关的 flags
+func addK8sFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&k8sMode, "k8s", false, "Kubernetes CronJob 模式：读取挂载的 Secret、输出 JSON 日志、写入终止消息并使用结构化退出码")
+	cmd.Flags().StringVar(&secretsDir, "secrets-dir", defaultSecretsDir, "--k8s 模式下读取凭证的 Secret 挂载目录")
+	cmd.Flags().StringVar(&terminationLog, "termination-log", defaultTerminationLog, "--k8s 模式下写入终止消息的文件")
+}
+
+// withK8s 包装命令的 RunE，启用 --k8s 时：
+//   - 将 secretsDir 中的文件导出为环境变量（见 config.LoadSecretDir）
+//   - 禁用进度条，将标准输出和标准错误的每一行转换为 JSON 日志
+//   - 结束时输出包含退出码的 JSON 日志，并将结果写入 terminationLog
+//   - 按错误类型返回结构化退出码
+func withK8s(run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if !k8sMode {
+			return run(cmd, args)
+		}
+
+		// 错误已输出为 JSON 日志，不再由 cobra 输出错误和用法
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		noProgress = true
+
+		start := time.Now()
+		logger := &jsonLogger{w: os.Stdout}
+		restoreStdout, err := captureOutput(&os.Stdout, func(msg string) { logger.log(logEntry{Level: "info", Msg: msg}) })
+		if err != nil {
+			return err
+		}
+		restoreStderr, err := captureOutput(&os.Stderr, func(msg string) { logger.log(logEntry{Level: "warn", Msg: msg}) })
+		if err != nil {
+			restoreStdout()
+			return err
+		}
+
+		runErr := loadSecrets(cmd, logger)
+		if runErr == nil {
+			runErr = run(cmd, args)
+		}
+		restoreStderr()
+		restoreStdout()
+
+		code := exitCode(runErr)
+		duration := time.Since(start)
+		entry := logEntry{Level: "info", Msg: cmd.Name() + " succeeded", ExitCode: &code, Duration: duration.Seconds()}
+		if runErr != nil {
+			entry.Level = "error"
+			entry.Msg = cmd.Name() + " failed"
+			entry.Error = runErr.Error()
+			entry.Hint = errorHint(runErr)
+		}
+		logger.log(entry)
+
+		if terminationLog != "" {
+			msg := newTerminationMessage(cmd.Name(), code, runErr, duration)
+			if err := writeTerminationMessage(terminationLog, msg); err != nil {
+				logger.log(logEntry{Level: "warn", Msg: "failed to write termination message", Error: err.Error()})
+			}
+		}
+
+		if runErr != nil {
+			return &exitError{code: code, err: runErr}
+		}
+		return nil
+	}
+}
+
+// loadSecrets 从 secretsDir 导出凭证，默认目录不存在时跳过
+func loadSecrets(cmd *cobra.Command, logger *jsonLogger) error {
+	if secretsDir == "" {
+		return nil
+	}
+	names, err := config.LoadSecretDir(secretsDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !cmd.Flags().Changed("secrets-dir") {
+			return nil
+		}
+		return &configError{err: err}
+	}
+	if len(names) > 0 {
+		logger.log(logEntry{Level: "info", Msg: fmt.Sprintf("loaded %d secrets from %s: %s", len(names), secretsDir, strings.Join(names, ", "))})
+	}
+	return nil
+}
+
+// logEntry 一条 JSON 日志
+type logEntry struct {
+	Time     string  `json:"time"`
+	Level    string  `json:"level"`
+	Msg      string  `json:"msg"`
+	ExitCode *int    `json:"exit_code,omitempty"`
+	Duration float64 `json:"duration_seconds,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Hint     string  `json:"hint,omitempty"`
+}
+
+// jsonLogger 并发安全的 JSON Lines 日志输出
+type jsonLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *jsonLogger) log(e logEntry) {
+	if e.Time == "" {
+		e.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(append(data, '\n'))
+}
+
+// captureOutput 将 *target 替换为管道，管道中的每一个非空行（去掉首尾空白）传给 onLine
+// 返回的 restore 关闭管道、等待剩余输出处理完后恢复 *target
+func captureOutput(target **os.File, onLine func(msg string)) (func(), error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+
+	orig := *target
+	*target = w
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadString('\n')
+			if msg := strings.TrimSpace(line); msg != "" {
+				onLine(msg)
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return func() {
+		*target = orig
+		w.Close()
+		<-done
+		r.Close()
+	}, nil
+}
+
+// terminationMessage 写入终止消息文件的执行结果，可通过 kubectl get pod -o jsonpath 读取
+type terminationMessage struct {
+	Command  string  `json:"command"`
+	Status   string  `json:"status"`
+	ExitCode int     `json:"exit_code"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration_seconds"`
+	Finished string  `json:"finished"`
+}
+
+// newTerminationMessage 构造终止消息
+func newTerminationMessage(command string, code int, err error, duration time.Duration) terminationMessage {
+	msg := terminationMessage{
+		Command:  command,
+		Status:   "succeeded",
+		ExitCode: code,
+		Duration: duration.Seconds(),
+		Finished: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err != nil {
+		msg.Status = "failed"
+		msg.Error = err.Error()
+	}
+	return msg
+}
+
+// writeTerminationMessage 将终止消息以 JSON 写入 path
+// 超过 Kubernetes 长度上限时截断错误信息
+func writeTerminationMessage(path string, msg terminationMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	errText := msg.Error
+	for n := len(errText); len(data) > maxTerminationMessage && n > 0; {
+		n -= len(data) - maxTerminationMessage + len("...")
+		if n < 0 {
+			n = 0
+		}
+		for n > 0 && !utf8.RuneStart(errText[n]) {
+			n--
+		}
+		msg.Error = errText[:n] + "..."
+		if data, err = json.Marshal(msg); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0644)
+}
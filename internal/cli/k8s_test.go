@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// TestExitCode 测试错误到退出码的映射
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{errors.New("boom"), exitFailure},
+		{&configError{err: errors.New("invalid config")}, exitConfig},
+		{fmt.Errorf("upload failed: %w", storage.ErrAuth), exitAuth},
+		{fmt.Errorf("upload failed: %w", storage.ErrBucketNotFound), exitBucketNotFound},
+		{fmt.Errorf("upload failed: %w", storage.ErrNetwork), exitNetwork},
+		{fmt.Errorf("upload failed: %w", storage.ErrThrottled), exitThrottled},
+		{fmt.Errorf("backup failed: %w", ErrUploadCheck), exitUploadCheck},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestWithK8s 测试 --k8s 模式的 JSON 日志、终止消息和退出码
+func TestWithK8s(t *testing.T) {
+	dir := t.TempDir()
+	secrets := filepath.Join(dir, "secrets")
+	if err := os.Mkdir(secrets, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(secrets, "secret_key"), []byte("from-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("S3BACKUP_SECRET_KEY", "")
+	os.Unsetenv("S3BACKUP_SECRET_KEY")
+
+	logFile, err := os.Create(filepath.Join(dir, "stdout.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logFile.Close()
+	stdout := os.Stdout
+	os.Stdout = logFile
+	defer func() { os.Stdout = stdout }()
+
+	// 注册 flags 会重置变量为默认值，需在之后设置
+	cmd := &cobra.Command{Use: "backup"}
+	addK8sFlags(cmd)
+	k8sMode, secretsDir, terminationLog = true, secrets, filepath.Join(dir, "termination-log")
+	defer func() { k8sMode, secretsDir, terminationLog = false, defaultSecretsDir, defaultTerminationLog }()
+	defer func(v bool) { noProgress = v }(noProgress)
+	var gotSecret string
+	err = withK8s(func(cmd *cobra.Command, args []string) error {
+		gotSecret = os.Getenv("S3BACKUP_SECRET_KEY")
+		fmt.Printf("开始备份\n")
+		fmt.Fprintf(os.Stderr, "警告: 跳过文件\n")
+		return fmt.Errorf("upload failed: %w", storage.ErrAuth)
+	})(cmd, nil)
+	os.Stdout = stdout
+
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitAuth {
+		t.Fatalf("expected exitError with code %d, got %v", exitAuth, err)
+	}
+	if gotSecret != "from-secret" {
+		t.Errorf("S3BACKUP_SECRET_KEY = %q, want from-secret", gotSecret)
+	}
+
+	data, err := os.ReadFile(logFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []logEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e logEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("log line is not JSON: %q", line)
+		}
+		entries = append(entries, e)
+	}
+	levels := map[string]string{}
+	for _, e := range entries {
+		levels[e.Msg] = e.Level
+	}
+	if levels["开始备份"] != "info" || levels["警告: 跳过文件"] != "warn" {
+		t.Errorf("captured output not logged as expected: %+v", entries)
+	}
+	last := entries[len(entries)-1]
+	if last.Level != "error" || last.ExitCode == nil || *last.ExitCode != exitAuth || last.Hint == "" {
+		t.Errorf("unexpected final entry: %+v", last)
+	}
+
+	msgData, err := os.ReadFile(terminationLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg terminationMessage
+	if err := json.Unmarshal(msgData, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Command != "backup" || msg.Status != "failed" || msg.ExitCode != exitAuth || !strings.Contains(msg.Error, "authentication") {
+		t.Errorf("unexpected termination message: %+v", msg)
+	}
+}
+
+// TestWriteTerminationMessageTruncate 测试终止消息超长时截断错误信息
+func TestWriteTerminationMessageTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termination-log")
+	msg := terminationMessage{Command: "backup", Status: "failed", ExitCode: exitFailure,
+		Error: strings.Repeat("错误<", 2000)}
+	if err := writeTerminationMessage(path, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) > maxTerminationMessage {
+		t.Errorf("termination message is %d bytes, want at most %d", len(data), maxTerminationMessage)
+	}
+	var got terminationMessage
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(got.Error, "...") || strings.ContainsRune(got.Error, '�') {
+		t.Errorf("unexpected truncated error: %q", got.Error)
+	}
+}
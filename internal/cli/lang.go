@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"os"
+	"strings"
+
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// lang --lang 参数，实际的语言在 cobra 解析参数之前由 setupLang 选择
+var lang string
+
+// setupLang 按 --lang、S3BACKUP_LANG 选择输出语言（默认中文），并翻译命令的说明和参数说明
+// 需要在 cobra 解析参数之前调用，--help 和参数错误的用法提示也使用所选语言；
+// 不读取 LANG 等系统区域设置，服务器上普遍设置的 en_US.UTF-8 不应改变已有用户的输出
+func setupLang(args []string) error {
+	value := os.Getenv(i18n.LangEnv)
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if v, ok := strings.CutPrefix(arg, "--lang="); ok {
+			value = v
+		} else if arg == "--lang" && i+1 < len(args) {
+			value = args[i+1]
+		}
+	}
+	if value == "" {
+		return nil
+	}
+
+	l, err := i18n.ParseLang(value)
+	if err != nil {
+		return err
+	}
+	i18n.SetLang(l)
+	localizeCommands(rootCmd)
+	return nil
+}
+
+// localizeCommands 将 cmd 及其子命令的说明、参数说明和参数弃用提示替换为当前语言的翻译
+func localizeCommands(cmd *cobra.Command) {
+	cmd.Short = i18n.T(cmd.Short)
+	cmd.Long = i18n.T(cmd.Long)
+	localize := func(f *pflag.Flag) {
+		f.Usage = i18n.T(f.Usage)
+		f.Deprecated = i18n.T(f.Deprecated)
+	}
+	cmd.LocalFlags().VisitAll(localize)
+	cmd.PersistentFlags().VisitAll(localize)
+	for _, c := range cmd.Commands() {
+		localizeCommands(c)
+	}
+}
@@ -0,0 +1,313 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+)
+
+const (
+	// lockDirName 锁对象所在的目录，位于备份所在的前缀下
+	lockDirName = ".s3backup-locks"
+	// exclusiveLockName 独占锁对象名，同一前缀下最多一个
+	exclusiveLockName = "exclusive.json"
+)
+
+// ErrLocked 备份前缀被其他机器上正在运行的 backup 或 prune 锁定
+var ErrLocked = errors.New("backup prefix is locked")
+
+var (
+	// lockStaleAfter 锁对象超过该时间没有刷新视为持有者已退出，可以忽略或删除
+	lockStaleAfter = 30 * time.Minute
+	// lockRefreshInterval 持有锁期间重写锁对象的间隔，必须远小于 lockStaleAfter
+	lockRefreshInterval = 5 * time.Minute
+)
+
+// lockInfo 锁对象的内容，说明持有锁的操作和机器
+type lockInfo struct {
+	Operation string    `json:"operation"`
+	Host      string    `json:"host"`
+	PID       int       `json:"pid"`
+	Exclusive bool      `json:"exclusive"`
+	Created   time.Time `json:"created"`
+	Refreshed time.Time `json:"refreshed"`
+}
+
+// bucketLock 存储桶中的咨询锁：backup 持有共享锁，prune 持有独占锁
+// 锁只对同样遵守它的 s3backup 有效，不阻止其他工具删除对象
+type bucketLock struct {
+	adapter storage.StorageAdapter
+	key     string
+	acl     string
+	info    lockInfo
+
+	mu   sync.Mutex // 保护 info.Refreshed
+	stop chan struct{}
+	done chan struct{}
+}
+
+// lockDir 返回前缀下锁目录的 key 前缀，prefix 为空或 . 时位于存储桶根目录
+func lockDir(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" || prefix == "." {
+		return lockDirName + "/"
+	}
+	return prefix + "/" + lockDirName + "/"
+}
+
+// isLockKey 判断对象是否为锁对象，清理备份时跳过
+func isLockKey(key string) bool {
+	return strings.HasPrefix(key, lockDirName+"/") || strings.Contains(key, "/"+lockDirName+"/")
+}
+
+// acquireLock 在 prefix 下获取锁
+// 共享锁先写入自己的锁对象再检查独占锁，独占锁先写入 exclusive.json 再检查共享锁，
+// 两边都是先写后查，并发时至少一方能看到另一方的锁并退出。
+// 存储不支持列出、读取或删除对象时无法可靠加锁，返回 nil 锁（release 为空操作）
+func acquireLock(ctx context.Context, w io.Writer, adapter storage.StorageAdapter, prefix, operation string,
+	exclusive bool, acl string) (*bucketLock, error) {
+	if _, ok := adapter.(storage.ObjectLister); !ok {
+		return nil, nil
+	}
+	if _, ok := adapter.(storage.ObjectDeleter); !ok {
+		return nil, nil
+	}
+
+	host, _ := os.Hostname()
+	now := time.Now().UTC()
+	l := &bucketLock{
+		adapter: adapter,
+		acl:     acl,
+		info: lockInfo{
+			Operation: operation,
+			Host:      host,
+			PID:       os.Getpid(),
+			Exclusive: exclusive,
+			Created:   now,
+			Refreshed: now,
+		},
+	}
+
+	var err error
+	if exclusive {
+		err = l.acquireExclusive(ctx, w, prefix)
+	} else {
+		err = l.acquireShared(ctx, prefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l.key == "" {
+		return nil, nil
+	}
+
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.refreshLoop(ctx, w)
+	return l, nil
+}
+
+// acquireShared 写入随机命名的共享锁，之后发现未过期的独占锁时删除自己的锁并返回 ErrLocked
+// 凭证无法写入锁对象时不加锁；只写凭证无法列出锁目录，此时只写入共享锁，不检查独占锁
+func (l *bucketLock) acquireShared(ctx context.Context, prefix string) error {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("failed to generate lock id: %w", err)
+	}
+	l.key = lockDir(prefix) + hex.EncodeToString(id) + ".json"
+	if err := l.write(ctx); err != nil {
+		if errors.Is(err, storage.ErrAuth) {
+			l.key = ""
+			return nil
+		}
+		return err
+	}
+
+	locks, err := l.listLocks(ctx, prefix)
+	if errors.Is(err, storage.ErrAuth) {
+		return nil
+	}
+	if err != nil {
+		l.remove(ctx)
+		return err
+	}
+	for _, obj := range locks {
+		if strings.HasSuffix(obj.Key, "/"+exclusiveLockName) && !lockStale(obj, time.Now()) {
+			l.remove(ctx)
+			return l.lockedError(ctx, obj.Key)
+		}
+	}
+	return nil
+}
+
+// acquireExclusive 条件写入 exclusive.json，之后发现其他未过期的锁时删除自己的锁并返回 ErrLocked
+// 过期的锁视为持有者已退出，先删除再加锁
+func (l *bucketLock) acquireExclusive(ctx context.Context, w io.Writer, prefix string) error {
+	l.key = lockDir(prefix) + exclusiveLockName
+
+	locks, err := l.listLocks(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, obj := range locks {
+		if !lockStale(obj, now) {
+			return l.lockedError(ctx, obj.Key)
+		}
+		i18n.Fprintf(w, "警告: 删除 %s 之前未刷新的过期锁 %s\n", obj.LastModified.Local().Format("2006-01-02 15:04:05"), obj.Key)
+		if err := l.adapter.(storage.ObjectDeleter).DeleteObject(ctx, obj.Key); err != nil {
+			return fmt.Errorf("failed to delete stale lock %s: %w", obj.Key, err)
+		}
+	}
+
+	// 支持条件写入时由存储保证只有一个 exclusive.json 写入成功，否则依赖写入后的检查
+	data, err := l.encode()
+	if err != nil {
+		return err
+	}
+	if putter, ok := l.adapter.(storage.ObjectPutter); ok {
+		err = putter.PutObjectIfAbsent(ctx, l.key, data, l.uploadOptions())
+		if errors.Is(err, storage.ErrObjectExists) {
+			return l.lockedError(ctx, l.key)
+		}
+	} else {
+		err = putSmallObject(ctx, l.adapter, l.key, "application/json", data, l.acl)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write lock %s: %w", l.key, err)
+	}
+
+	if locks, err = l.listLocks(ctx, prefix); err != nil {
+		l.remove(ctx)
+		return err
+	}
+	for _, obj := range locks {
+		if obj.Key != l.key && !lockStale(obj, time.Now()) {
+			l.remove(ctx)
+			return l.lockedError(ctx, obj.Key)
+		}
+	}
+	return nil
+}
+
+// listLocks 列出 prefix 锁目录下的锁对象
+func (l *bucketLock) listLocks(ctx context.Context, prefix string) ([]storage.ObjectInfo, error) {
+	locks, err := l.adapter.(storage.ObjectLister).ListObjects(ctx, lockDir(prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locks: %w", err)
+	}
+	return locks, nil
+}
+
+// lockStale 判断锁对象是否超过 lockStaleAfter 没有刷新
+func lockStale(obj storage.ObjectInfo, now time.Time) bool {
+	return now.Sub(obj.LastModified) > lockStaleAfter
+}
+
+// lockedError 返回说明持有者的 ErrLocked，锁对象无法读取时只包含 key
+func (l *bucketLock) lockedError(ctx context.Context, key string) error {
+	reader, ok := l.adapter.(storage.ObjectReader)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrLocked, key)
+	}
+	data, err := readObject(ctx, reader, key)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrLocked, key)
+	}
+	var info lockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return fmt.Errorf("%w: %s", ErrLocked, key)
+	}
+	return fmt.Errorf("%w: %s held by %s on %s (pid %d) since %s", ErrLocked, key,
+		info.Operation, info.Host, info.PID, info.Created.Format(time.RFC3339))
+}
+
+// encode 序列化锁对象
+func (l *bucketLock) encode() ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	data, err := json.MarshalIndent(l.info, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lock: %w", err)
+	}
+	return data, nil
+}
+
+// write 写入或覆盖锁对象，适配器支持时一次请求写入，不经过分块上传
+func (l *bucketLock) write(ctx context.Context) error {
+	data, err := l.encode()
+	if err != nil {
+		return err
+	}
+	if putter, ok := l.adapter.(storage.ObjectPutter); ok {
+		err = putter.PutObject(ctx, l.key, data, l.uploadOptions())
+	} else {
+		err = putSmallObject(ctx, l.adapter, l.key, "application/json", data, l.acl)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write lock %s: %w", l.key, err)
+	}
+	return nil
+}
+
+// uploadOptions 返回写入锁对象的上传选项
+func (l *bucketLock) uploadOptions() storage.UploadOptions {
+	return storage.UploadOptions{ContentType: "application/json", ACL: l.acl}
+}
+
+// remove 删除锁对象，失败时锁会在 lockStaleAfter 后过期
+func (l *bucketLock) remove(ctx context.Context) error {
+	if err := l.adapter.(storage.ObjectDeleter).DeleteObject(ctx, l.key); err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+		return fmt.Errorf("failed to delete lock %s: %w", l.key, err)
+	}
+	return nil
+}
+
+// refreshLoop 每隔 lockRefreshInterval 重写锁对象，使其不被视为过期
+func (l *bucketLock) refreshLoop(ctx context.Context, w io.Writer) {
+	defer close(l.done)
+	ticker := time.NewTicker(lockRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		l.mu.Lock()
+		l.info.Refreshed = time.Now().UTC()
+		l.mu.Unlock()
+		if err := l.write(ctx); err != nil {
+			i18n.Fprintf(w, "警告: 刷新锁失败: %v\n", err)
+		}
+	}
+}
+
+// release 停止刷新并删除锁对象，l 为 nil 时为空操作
+// 使用独立的 context，备份被取消或超时后仍能删除锁
+func (l *bucketLock) release(w io.Writer) {
+	if l == nil {
+		return
+	}
+	close(l.stop)
+	<-l.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := l.remove(ctx); err != nil {
+		i18n.Fprintf(w, "警告: %v\n", err)
+	}
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestLockDir 测试锁目录位于备份所在的前缀下
+func TestLockDir(t *testing.T) {
+	for prefix, want := range map[string]string{"": ".s3backup-locks/", ".": ".s3backup-locks/", "host1/": "host1/.s3backup-locks/", "a/b": "a/b/.s3backup-locks/"} {
+		if got := lockDir(prefix); got != want {
+			t.Errorf("lockDir(%q) = %q, want %q", prefix, got, want)
+		}
+		if !isLockKey(lockDir(prefix) + "x.json") {
+			t.Errorf("isLockKey(%q) = false", lockDir(prefix)+"x.json")
+		}
+	}
+	if isLockKey("host1/backup-20260101-000000.tar.gz") {
+		t.Error("backup should not be a lock key")
+	}
+}
+
+// TestLockSharedExclusive 测试共享锁与独占锁互斥，共享锁之间不互斥
+func TestLockSharedExclusive(t *testing.T) {
+	adapter := mock.New()
+	ctx := context.Background()
+	var buf bytes.Buffer
+
+	b1, err := acquireLock(ctx, &buf, adapter, "host1", "backup", false, "")
+	if err != nil {
+		t.Fatalf("shared lock error = %v", err)
+	}
+	b2, err := acquireLock(ctx, &buf, adapter, "host1", "backup", false, "")
+	if err != nil {
+		t.Fatalf("second shared lock error = %v", err)
+	}
+
+	if _, err := acquireLock(ctx, &buf, adapter, "host1", "prune", true, ""); !errors.Is(err, ErrLocked) {
+		t.Fatalf("exclusive lock error = %v, want ErrLocked", err)
+	} else if !strings.Contains(err.Error(), "held by backup") {
+		t.Errorf("error = %v, want the lock holder", err)
+	}
+	// 其他前缀不受影响
+	other, err := acquireLock(ctx, &buf, adapter, "host2", "prune", true, "")
+	if err != nil {
+		t.Fatalf("exclusive lock on other prefix error = %v", err)
+	}
+	other.release(&buf)
+
+	b1.release(&buf)
+	b2.release(&buf)
+	prune, err := acquireLock(ctx, &buf, adapter, "host1", "prune", true, "")
+	if err != nil {
+		t.Fatalf("exclusive lock after release error = %v", err)
+	}
+	if _, err := acquireLock(ctx, &buf, adapter, "host1", "backup", false, ""); !errors.Is(err, ErrLocked) {
+		t.Errorf("shared lock error = %v, want ErrLocked", err)
+	}
+	if _, err := acquireLock(ctx, &buf, adapter, "host1", "prune", true, ""); !errors.Is(err, ErrLocked) {
+		t.Errorf("second exclusive lock error = %v, want ErrLocked", err)
+	}
+	prune.release(&buf)
+
+	objects, _ := adapter.ListObjects(ctx, lockDir("host1"))
+	if len(objects) != 0 {
+		t.Errorf("locks left after release: %v", objects)
+	}
+}
+
+// TestLockStale 测试超过 lockStaleAfter 未刷新的锁被忽略或删除
+func TestLockStale(t *testing.T) {
+	adapter := mock.New()
+	ctx := context.Background()
+	var buf bytes.Buffer
+	old := time.Now().Add(-2 * lockStaleAfter)
+	adapter.SetObject(lockDir("")+"dead.json", &mock.Object{Data: []byte("{}"), LastModified: old})
+	adapter.SetObject(lockDir("")+exclusiveLockName, &mock.Object{Data: []byte("{}"), LastModified: old})
+
+	backup, err := acquireLock(ctx, &buf, adapter, "", "backup", false, "")
+	if err != nil {
+		t.Fatalf("shared lock with stale exclusive lock error = %v", err)
+	}
+	backup.release(&buf)
+
+	prune, err := acquireLock(ctx, &buf, adapter, "", "prune", true, "")
+	if err != nil {
+		t.Fatalf("exclusive lock with stale locks error = %v", err)
+	}
+	defer prune.release(&buf)
+	if _, ok := adapter.Object(lockDir("") + "dead.json"); ok {
+		t.Error("stale shared lock should be deleted")
+	}
+	if !strings.Contains(buf.String(), "dead.json") {
+		t.Errorf("output = %q, want a warning about the stale lock", buf.String())
+	}
+}
+
+// TestLockRefresh 测试持有锁期间定期重写锁对象
+func TestLockRefresh(t *testing.T) {
+	defer func(d time.Duration) { lockRefreshInterval = d }(lockRefreshInterval)
+	lockRefreshInterval = 5 * time.Millisecond
+
+	adapter := mock.New()
+	var buf bytes.Buffer
+	l, err := acquireLock(context.Background(), &buf, adapter, "", "backup", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	l.release(&buf)
+	if n := adapter.Calls(mock.OpPutObject); n < 2 {
+		t.Errorf("PutObject calls = %d, want the lock to be refreshed", n)
+	}
+}
+
+// TestPruneSkipsLocks 测试清理时不删除锁对象
+func TestPruneSkipsLocks(t *testing.T) {
+	adapter := mock.New()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	adapter.SetObject(lockDir("")+"a.json", &mock.Object{Data: make([]byte, 100), LastModified: base})
+	adapter.SetObject("backup-1", &mock.Object{Data: make([]byte, 100), LastModified: base.Add(time.Hour)})
+	adapter.SetObject("backup-2", &mock.Object{Data: make([]byte, 100), LastModified: base.Add(2 * time.Hour)})
+
+	var buf bytes.Buffer
+	if err := pruneBySize(context.Background(), &buf, adapter, adapter, "", 100, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := adapter.Object(lockDir("") + "a.json"); !ok {
+		t.Error("prune deleted a lock object")
+	}
+	if _, ok := adapter.Object("backup-1"); ok {
+		t.Error("backup-1 should be pruned")
+	}
+}
@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// markerName 标记对象名，位于备份所在的前缀下
+	markerName = ".s3backup.json"
+	// layoutVersion 当前的存储布局版本，对象命名或格式不兼容时递增
+	layoutVersion = 1
+)
+
+// ErrBucketMarker 备份前缀的标记对象表明它属于其他工具或不兼容的版本
+var ErrBucketMarker = errors.New("backup prefix is not compatible with this version")
+
+var (
+	initBucketPrefix     string
+	initBucketMinVersion string
+	initBucketForce      bool
+	initBucketPrint      bool
+)
+
+// bucketMarker init-bucket 写入的标记对象，说明前缀下备份的布局、格式版本和所需的工具版本
+type bucketMarker struct {
+	Tool          string    `json:"tool"`
+	LayoutVersion int       `json:"layout_version"`
+	MinVersion    string    `json:"min_version,omitempty"` // 读写该前缀所需的最低 s3backup 版本
+	CreatedBy     string    `json:"created_by"`
+	Created       time.Time `json:"created"`
+
+	Encrypted        bool   `json:"encrypted"`
+	EncryptionFormat int    `json:"encryption_format"`
+	Cipher           string `json:"cipher"`
+	ArchiveFormat    string `json:"archive_format"`
+
+	Layout map[string]string `json:"layout"`
+	Readme string            `json:"readme"`
+}
+
+// initBucketCmd 初始化备份前缀命令
+var initBucketCmd = &cobra.Command{
+	Use:   "init-bucket",
+	Short: "在存储桶中写入说明备份布局和版本要求的标记对象",
+	Long: `在存储桶（或 --prefix 指定的前缀）下写入 .s3backup.json 标记对象，记录备份对象的命名方式、
+归档和加密格式版本，以及读写这些备份所需的最低 s3backup 版本。
+
+之后 backup 上传前会读取备份所在前缀的标记对象，属于其他工具、布局版本更新或要求更高版本的 s3backup 时拒绝上传，
+避免不兼容的工具或版本把备份混在同一个前缀下。没有标记对象的前缀不受影响。
+
+标记对象已存在时不会覆盖，使用 --force 重新写入。`,
+	Args: cobra.NoArgs,
+	RunE: runInitBucket,
+}
+
+func init() {
+	rootCmd.AddCommand(initBucketCmd)
+
+	addConfigFlags(initBucketCmd)
+	initBucketCmd.Flags().StringVar(&initBucketPrefix, "prefix", "", "备份对象所在的前缀（如 host1/），默认为存储桶根目录")
+	initBucketCmd.Flags().StringVar(&initBucketMinVersion, "min-version", "", "要求的最低 s3backup 版本（默认为当前版本）")
+	initBucketCmd.Flags().BoolVar(&initBucketForce, "force", false, "覆盖已存在的标记对象")
+	initBucketCmd.Flags().BoolVar(&initBucketPrint, "print", false, "只打印标记对象，不上传")
+}
+
+func runInitBucket(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	minVersion := initBucketMinVersion
+	if minVersion == "" && version.Compare(version.Version, "dev") > 0 {
+		minVersion = version.Version
+	}
+	marker := newBucketMarker(cfg, minVersion)
+	data, err := json.MarshalIndent(marker, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode marker: %w", err)
+	}
+	if initBucketPrint {
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	adapter, err := createStorageAdapter(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create storage adapter: %w", err)
+	}
+
+	key := markerKey(initBucketPrefix)
+	if !initBucketForce {
+		existing, err := readBucketMarker(ctx, adapter, key)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			i18n.Printf("%s 已存在（%s，布局版本 %d），使用 --force 覆盖\n", key, existing.CreatedBy, existing.LayoutVersion)
+			return nil
+		}
+	}
+
+	if err := putSmallObject(ctx, adapter, key, "application/json", data, cfg.Storage.ACL); err != nil {
+		return fmt.Errorf("failed to upload marker: %w", err)
+	}
+	i18n.Printf("已写入标记对象: %s\n", key)
+	return nil
+}
+
+// newBucketMarker 按当前版本和配置生成标记对象
+func newBucketMarker(cfg *config.Config, minVersion string) *bucketMarker {
+	return &bucketMarker{
+		Tool:             "s3backup",
+		LayoutVersion:    layoutVersion,
+		MinVersion:       minVersion,
+		CreatedBy:        "s3backup " + version.Version,
+		Created:          time.Now().UTC(),
+		Encrypted:        cfg.Encryption.Enabled,
+		EncryptionFormat: crypto.FormatVersion,
+		Cipher:           crypto.CipherName,
+		ArchiveFormat:    "tar+gzip (PAX headers)",
+		Layout: map[string]string{
+			"backup":    "backup-YYYYMMDD-HHMMSS.tar.gz, .enc suffix when encrypted",
+			"report":    "<backup>" + reportSuffix,
+			"signature": "<backup>" + crypto.SignatureSuffix,
+		},
+		Readme: "This prefix holds backups written by s3backup (https://github.com/lukelzlz/s3backup). " +
+			"Do not write objects from other tools here. Restore with: s3backup restore <backup> <dir>",
+	}
+}
+
+// markerKey 返回前缀下标记对象的 key，prefix 为空或 . 时位于存储桶根目录
+func markerKey(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" || prefix == "." {
+		return markerName
+	}
+	return prefix + "/" + markerName
+}
+
+// readBucketMarker 读取标记对象，不存在时返回 nil
+func readBucketMarker(ctx context.Context, adapter storage.StorageAdapter, key string) (*bucketMarker, error) {
+	reader, ok := adapter.(storage.ObjectReader)
+	if !ok {
+		return nil, nil
+	}
+	data, err := readObject(ctx, reader, key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read marker %s: %w", key, err)
+	}
+	var marker bucketMarker
+	if err := json.Unmarshal(data, &marker); err != nil {
+		return nil, fmt.Errorf("failed to parse marker %s: %w", key, err)
+	}
+	return &marker, nil
+}
+
+// check 检查当前版本能否在标记对象所在的前缀下写入备份
+func (m *bucketMarker) check(key string) error {
+	switch {
+	case m.Tool != "s3backup":
+		return fmt.Errorf("%w: %s was written by %q", ErrBucketMarker, key, m.Tool)
+	case m.LayoutVersion > layoutVersion:
+		return fmt.Errorf("%w: %s uses layout version %d, this version supports up to %d", ErrBucketMarker, key, m.LayoutVersion, layoutVersion)
+	// 开发版本无法比较版本号，不检查最低版本
+	case m.MinVersion != "" && version.Compare(version.Version, "dev") > 0 && version.Compare(m.MinVersion, version.Version) > 0:
+		return fmt.Errorf("%w: %s requires s3backup %s or newer, running %s", ErrBucketMarker, key, m.MinVersion, version.Version)
+	}
+	return nil
+}
+
+// checkBucketMarker 上传前检查备份 name 所在前缀的标记对象
+// 只写凭证通常没有读取权限，标记对象无法读取时输出警告后继续，只有确认不兼容时返回 ErrBucketMarker
+func checkBucketMarker(ctx context.Context, adapter storage.StorageAdapter, name string) error {
+	key := markerKey(path.Dir(name))
+	marker, err := readBucketMarker(ctx, adapter, key)
+	if err != nil {
+		if !errors.Is(err, storage.ErrAuth) {
+			i18n.Printf("警告: %v\n", err)
+		}
+		return nil
+	}
+	if marker == nil {
+		return nil
+	}
+	return marker.check(key)
+}
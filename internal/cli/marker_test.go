@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+	"github.com/lukelzlz/s3backup/pkg/version"
+)
+
+// TestMarkerKey 测试标记对象位于备份所在的前缀下
+func TestMarkerKey(t *testing.T) {
+	for prefix, want := range map[string]string{
+		"":        ".s3backup.json",
+		".":       ".s3backup.json",
+		"host1":   "host1/.s3backup.json",
+		"/host1/": "host1/.s3backup.json",
+		"a/b":     "a/b/.s3backup.json",
+	} {
+		if got := markerKey(prefix); got != want {
+			t.Errorf("markerKey(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+}
+
+// TestBucketMarkerCheck 测试其他工具、更新的布局版本和更高的最低版本被拒绝，开发版本不检查最低版本
+func TestBucketMarkerCheck(t *testing.T) {
+	defer func(v string) { version.Version = v }(version.Version)
+	version.Version = "v1.2.0"
+
+	tests := []struct {
+		name   string
+		marker bucketMarker
+		ok     bool
+	}{
+		{"compatible", bucketMarker{Tool: "s3backup", LayoutVersion: layoutVersion, MinVersion: "v1.2.0"}, true},
+		{"older min version", bucketMarker{Tool: "s3backup", LayoutVersion: layoutVersion, MinVersion: "v1.0.0"}, true},
+		{"other tool", bucketMarker{Tool: "restic", LayoutVersion: layoutVersion}, false},
+		{"newer layout", bucketMarker{Tool: "s3backup", LayoutVersion: layoutVersion + 1}, false},
+		{"newer min version", bucketMarker{Tool: "s3backup", LayoutVersion: layoutVersion, MinVersion: "v1.3.0"}, false},
+	}
+	for _, tt := range tests {
+		err := tt.marker.check(markerName)
+		if tt.ok && err != nil || !tt.ok && !errors.Is(err, ErrBucketMarker) {
+			t.Errorf("%s: check() = %v", tt.name, err)
+		}
+	}
+
+	version.Version = "dev"
+	m := bucketMarker{Tool: "s3backup", LayoutVersion: layoutVersion, MinVersion: "v9.0.0"}
+	if err := m.check(markerName); err != nil {
+		t.Errorf("dev build should not check the minimum version, got %v", err)
+	}
+}
+
+// TestBackupOnceBucketMarker 测试备份前缀的标记对象不兼容时不上传
+func TestBackupOnceBucketMarker(t *testing.T) {
+	defer func(n bool) { noProgress = n }(noProgress)
+	noProgress = true
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{Provider: "aws", Bucket: "bucket"},
+		State:   config.StateConfig{Dir: filepath.Join(t.TempDir(), "state")},
+	}
+	produce := func(ctx context.Context, w io.Writer, _ *archive.CompressionMeter) error {
+		_, err := io.WriteString(w, "data")
+		return err
+	}
+	adapter := mock.New()
+	marker := newBucketMarker(cfg, "")
+	marker.LayoutVersion = layoutVersion + 1
+	data, _ := json.Marshal(marker)
+	adapter.SetObject("host1/"+markerName, &mock.Object{Data: data})
+
+	err := backupOnce(context.Background(), cfg, adapter, "host1/backup.sql.gz", nil, produce)
+	if !errors.Is(err, ErrBucketMarker) {
+		t.Fatalf("expected ErrBucketMarker, got %v", err)
+	}
+	if n := adapter.Calls(mock.OpInit); n != 0 {
+		t.Errorf("expected no upload, got %d InitMultipartUpload calls", n)
+	}
+
+	// 其他前缀不受影响
+	if err := backupOnce(context.Background(), cfg, adapter, "host2/backup.sql.gz", nil, produce); err != nil {
+		t.Fatalf("backupOnce() in another prefix error = %v", err)
+	}
+}
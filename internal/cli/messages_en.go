@@ -0,0 +1,698 @@
+package cli
+
+import (
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+)
+
+// 命令行输出、命令说明和参数说明的英文翻译，键为源代码中的中文消息
+func init() {
+	i18n.Register(i18n.En, map[string]string{
+		// backup
+		"执行备份": "Run a backup",
+		`将指定路径打包压缩并上传到 S3 兼容存储
+
+也可以使用 --only 选择配置文件 backup.paths 中命名的路径组，使用 --docker-volume 备份 Docker 卷，
+与命令行路径合并备份。`: `Archive, compress and upload the given paths to S3-compatible storage.
+
+Use --only to select named path groups from backup.paths in the config file and
+--docker-volume to back up Docker volumes; both are combined with paths given on the command line.`,
+		"备份文件名（默认：backup-{timestamp}.tar.gz.enc）": "backup object name (default: backup-{timestamp}.tar.gz.enc)",
+		"模拟运行，不实际上传":                              "dry run, do not upload",
+		"禁用进度条":                                   "disable the progress bar",
+		"状态文件目录（用于断点续传，默认 ~/.s3backup/state/<机器标识>）": "state directory for resumable uploads (default ~/.s3backup/state/<machine id>)",
+		"不保存断点续传状态": "do not save resume state",
+		"上传前估算存储和请求费用（可与 --dry-run 一起使用）":                            "estimate storage and request costs before uploading (can be combined with --dry-run)",
+		"数据库连接地址（postgres://、mysql://、mongodb://），备份数据库导出数据而不是文件":    "database URL (postgres://, mysql://, mongodb://); back up a database dump instead of files",
+		"备份 Docker 卷（可多次指定）":                                         "back up a Docker volume (repeatable)",
+		"只备份 backup.paths 中指定名称的路径组（逗号分隔）":                           "back up only the named path groups from backup.paths (comma separated)",
+		"待备份数据（压缩前）的大小上限（字节），超过时按 backup.max_total_size_action 处理":   "size limit in bytes for the data to back up (before compression); exceeding it is handled per backup.max_total_size_action",
+		"Ed25519 签名私钥（PEM），上传后对备份签名并上传 <备份名>.sig":                    "Ed25519 signing private key (PEM); sign the backup after upload and upload <backup>.sig",
+		"备份的保留时长（如 90d、12w），到期时间记录在对象元数据中，由 prune --honor-expiry 删除": "how long to keep the backup (e.g. 90d, 12w); the expiry time is recorded in object metadata and enforced by prune --honor-expiry",
+		"上传完成后下载首尾数据段核对 SHA-256 并回读备份报告，不一致时失败退出":                    "after uploading, download the first and last chunks to check their SHA-256 and read back the backup report; fail on mismatch",
+		"上传备份后同时上传 <备份名>.report.json 备份报告":                           "also upload a <backup>.report.json backup report",
+		"包含路径中的通配符没有匹配时跳过而不是报错":                                      "skip include patterns that match nothing instead of failing",
+		"从文件读取要备份的路径（- 为标准输入，按行或 NUL 分隔），不做通配符展开":                    "read paths to back up from a file (- for stdin, newline or NUL separated), without glob expansion",
+		"从文件读取排除模式（- 为标准输入，每行一个，# 开头为注释）":                            "read exclude patterns from a file (- for stdin, one per line, # starts a comment)",
+		"  包含路径: %d 个\n":            "  Include paths: %d\n",
+		"按存储类型规则拆分为 %d 个备份:\n":      "Split into %d backups by storage class rules:\n",
+		"[警告] 排除模式没有匹配任何路径: %s\n":   "[WARN] exclude pattern matched nothing: %s\n",
+		"备份配置:\n":                   "Backup configuration:\n",
+		"  存储提供商: %s\n":             "  Provider: %s\n",
+		"  存储桶: %s\n":               "  Bucket: %s\n",
+		"  存储类型: %s\n":              "  Storage class: %s\n",
+		"  加密: %v\n":                "  Encryption: %v\n",
+		"  并发数: 自动 (初始 %d，上限 %d)\n": "  Concurrency: auto (initial %d, max %d)\n",
+		"  并发数: %d\n":               "  Concurrency: %d\n",
+		"  分块大小: 自动 (%d ~ %d MB)\n": "  Chunk size: auto (%d ~ %d MB)\n",
+		"  分块大小: %d MB\n":           "  Chunk size: %d MB\n",
+		"  备份文件: %s\n":              "  Backup object: %s\n",
+		"  数据源: %s\n":               "  Source: %s\n",
+		"警告: 分块大小超出 %s 的限制，已调整为 %d 字节\n": "Warning: chunk size exceeds the %s limit, adjusted to %d bytes\n",
+		"\n提示: %s\n": "\nHint: %s\n",
+		"\n上传失败，状态已保存。使用以下命令恢复:\n":                      "\nUpload failed, state saved. Resume with:\n",
+		"上传统计: %d 个分块，限流 %d 次，重试 %d 次\n":                "Upload stats: %d parts, throttled %d times, %d retries\n",
+		"警告: %d 次分块 ETag 与本地 MD5 不一致（数据在传输中损坏），已重新上传\n": "Warning: %d part ETags did not match the local MD5 (data corrupted in transit); the parts were re-uploaded\n",
+		"签名: %s\n":   "Signature: %s\n",
+		"警告: %v\n":   "Warning: %v\n",
+		"备份报告: %s\n": "Backup report: %s\n",
+		"警告: 存储适配器不支持按范围下载，跳过上传后校验\n": "Warning: the storage adapter does not support ranged downloads, skipping upload verification\n",
+		"上传后校验通过: 已核对 %d 个数据段\n":      "Upload verified: checked %d chunks\n",
+		"模拟运行完成（未实际上传）\n":             "Dry run complete (nothing uploaded)\n",
+		"备份成功: %s\n": "Backup succeeded: %s\n",
+		"警告: 跳过了 %d 个无法访问或不支持的文件（使用 --report 记录完整列表）\n":                                                                       "Warning: skipped %d inaccessible or unsupported files (use --report to record the full list)\n",
+		"认证失败，请检查 access_key/secret_key 是否正确以及是否有该存储桶的写权限":                                                                    "authentication failed; check access_key/secret_key and write permission on the bucket",
+		"存储桶不存在，请检查 bucket 名称以及 region/endpoint 是否正确":                                                                         "bucket not found; check the bucket name and region/endpoint",
+		"分块小于存储提供商的最小分块大小，请增大 chunk_size":                                                                                     "part is smaller than the provider's minimum part size; increase chunk_size",
+		"请求被存储提供商限流，请降低 concurrency 或启用 --auto-concurrency":                                                                   "requests are throttled by the provider; lower concurrency or enable --auto-concurrency",
+		"当前只配置了只读凭证（storage.restore_access_key），只能执行 restore、rehearse、verify、thaw 和 freshness；备份需要配置 access_key 和 secret_key": "only read-only credentials (storage.restore_access_key) are configured, which allow restore, rehearse, verify, thaw and freshness only; backups need access_key and secret_key",
+		"已开启 storage.write_only，当前凭证只能上传备份；请在持有列出和删除权限的管理主机上运行 prune，或配置只读凭证 restore_access_key 用于还原和校验":                      "storage.write_only is enabled and the credentials can only upload backups; run prune on an admin host with list and delete permissions, or configure read-only restore_access_key credentials for restore and verify",
+		"只上传，不列出、读取或删除对象（凭证为只写凭证时使用；prune 等命令会直接报错）":                                                                          "upload only, never list, read or delete objects (for write-only credentials; prune and similar commands fail immediately)",
+		"网络错误，请检查网络连接和 endpoint 配置":                                                                                           "network error; check the network connection and endpoint",
+		"备份前缀中的 .s3backup.json 标记对象要求其他工具或更高版本，请升级 s3backup 或使用其他前缀（--name dir/...）":                                          "the .s3backup.json marker in the backup prefix requires another tool or a newer version; upgrade s3backup or use another prefix (--name dir/...)",
+		"存储中的备份与本地生成的数据不一致，可能在传输或存储中损坏，请检查后重新备份":                                                                              "the backup in storage does not match the data generated locally and may have been corrupted in transit or at rest; check the storage and back up again",
+		"重新生成的数据与已上传的分块不一致，说明源文件在中断后发生了变化，无法续传；使用 resume --force-restart 取消已上传的分块并重新开始":                                       "the re-generated data does not match the uploaded parts, so the source files changed after the interruption and the upload cannot be resumed; use resume --force-restart to discard the uploaded parts and start over",
+		"已取消未完成的上传: %s\n":                   "Aborted the unfinished upload: %s\n",
+		"流式备份无法从续传状态重新生成，请重新执行 backup 命令\n": "A streamed backup cannot be re-generated from the resume state, run the backup command again\n",
+		"取消未完成的分块上传并删除续传状态，从头重新上传":          "abort the unfinished multipart upload, delete the resume state and upload again from the beginning",
+		"存储请求统计:\n": "Storage requests:\n",
+		"在 tar 中记录扩展属性（如 setcap 设置的 capabilities、user.*），仅支持 Linux":        "record extended attributes (such as setcap capabilities and user.*) in the tar, Linux only",
+		"在 tar 中记录 SELinux 安全上下文（security.selinux），仅支持 Linux":              "record SELinux security contexts (security.selinux) in the tar, Linux only",
+		"归档字符设备、块设备和命名管道（记录设备号，不读取内容），而不是作为特殊文件跳过":                         "archive character devices, block devices and FIFOs (device numbers only, no content) instead of skipping them as special files",
+		"遇到无法读取的文件、特殊文件（设备、管道、套接字）或目标不存在的符号链接时失败退出，而不是跳过":                  "fail instead of skipping unreadable files, special files (devices, pipes, sockets) and symlinks whose target does not exist",
+		"存储中已存在同名备份，请使用 --name 指定其他名称（如在名称模板中加入主机名），或使用 --overwrite 覆盖":    "A backup with the same name already exists in storage; choose another name with --name (e.g. include the host name in the name template) or pass --overwrite to replace it",
+		"其他机器上的 backup 或 prune 正在使用该前缀，请等待其完成；持有者已退出时锁会在 30 分钟未刷新后过期":      "A backup or prune on another machine is using this prefix; wait for it to finish. Locks left by exited holders expire after 30 minutes without refresh",
+		"备份已上传，但存储中的存储类型与配置不一致，请检查存储桶是否支持该存储类型":                            "The backup was uploaded, but its storage class does not match the configuration; check that the bucket supports this storage class",
+		"--strict 模式下遇到无法完整备份的路径，请修复权限、将其加入排除模式或去掉 --strict":               "a path could not be fully backed up in --strict mode; fix its permissions, exclude it or drop --strict",
+		"提示: 使用 --max-warnings -1 显示全部警告，备份时使用 --report 在备份报告中记录完整的跳过列表\n": "Hint: use --max-warnings -1 to show every warning, or --report when backing up to record the full skipped list in the backup report\n",
+		"每类跳过警告逐条显示的数量，超出的只显示汇总（默认 20，-1 显示全部）":                            "warnings shown per skip type before only a summary is printed (default 20, -1 shows all)",
+		"警告: 初始化 OpenTelemetry 失败: %v\n":                          "Warning: failed to set up OpenTelemetry: %v\n",
+		"警告: 导出 OpenTelemetry span 失败: %v\n":                      "Warning: failed to export OpenTelemetry spans: %v\n",
+		"  %s: %d 次，失败 %d 次，重试 %d 次，p50 %s，p90 %s，p99 %s，最大 %s\n": "  %s: %d requests, %d failed, %d retried, p50 %s, p90 %s, p99 %s, max %s\n",
+		"在标准错误输出每个存储请求的操作、路径、状态码、耗时和重试次数，结束时汇总各操作的耗时分布":           "log the operation, path, status, duration and retry count of every storage request to stderr and summarize per-operation latency at the end",
+		"在指定地址提供 pprof 性能分析接口（如 localhost:6060），用于分析长时间运行的备份":     "serve pprof profiling endpoints on the given address (e.g. localhost:6060) to profile long-running backups",
+		"警告: 启动 pprof 服务失败: %v\n":                                 "Warning: failed to start the pprof server: %v\n",
+		"pprof 服务: http://%s/debug/pprof/\n":                      "pprof server: http://%s/debug/pprof/\n",
+		"警告: pprof 服务异常退出: %v\n":                                  "Warning: the pprof server stopped: %v\n",
+		"分块上传在服务端已不存在（可能已被取消或被生命周期规则清理），需要重新上传":                   "the multipart upload no longer exists on the server (aborted or cleaned up by a lifecycle rule); upload again",
+		"存储提供商 (aws/qiniu/aliyun)":                                "storage provider (aws/qiniu/aliyun)",
+		"存储桶名称":                                                   "bucket name",
+		"自定义端点":                                                   "custom endpoint",
+		"使用路径风格访问（MinIO 等自建 S3 兼容存储）":                             "use path-style addressing (self-hosted S3-compatible storage such as MinIO)",
+		"上传对象的预设 ACL（如写入其他账号的存储桶时使用 bucket-owner-full-control）":   "canned ACL for uploaded objects (e.g. bucket-owner-full-control when writing to a bucket owned by another account)",
+		"区域": "region",
+		"只读 Access Key（restore、verify 等只读命令优先使用）": "read-only access key (preferred by read-only commands such as restore and verify)",
+		"只读 Secret Key": "read-only secret key",
+		"存储类型 (standard/ia/archive/deep_archive 等，见 s3backup storage-classes)": "storage class (standard/ia/archive/deep_archive etc., see s3backup storage-classes)",
+		"启用加密": "enable encryption",
+		"加密密码": "encryption password",
+		"密钥文件": "key file",
+		"使用备份密钥加密本地续传状态文件":                  "encrypt the local resume state file with the backup key",
+		"加密前填充备份以隐藏准确大小 (none/pow2/bucket)": "pad backups before encryption to hide their exact size (none/pow2/bucket)",
+		"--padding bucket 时填充到该大小（字节）的整数倍":  "with --padding bucket, pad to a multiple of this size (bytes)",
+		"排除模式（可多次指定）":                       "exclude pattern (repeatable)",
+		"排除模式不区分大小写":                        "case-insensitive exclude patterns",
+		"并发上传数":                             "number of concurrent uploads",
+		"分块大小（字节）":                          "chunk size (bytes)",
+		"根据上传吞吐量自动调整分块大小":                   "adjust chunk size automatically based on upload throughput",
+		"自动调整分块大小的下限（字节）":                   "lower bound for automatic chunk size (bytes)",
+		"自动调整分块大小的上限（字节）":                   "upper bound for automatic chunk size (bytes)",
+		"根据吞吐量和限流响应自动调整并发数":                 "adjust concurrency automatically based on throughput and throttling",
+		"自动调整并发数的上限":                        "upper bound for automatic concurrency",
+		"预读等待上传的分块数（默认为并发数的 2 倍），内存不足时调小，链路延迟波动大时调大":         "number of chunks read ahead of the upload (default twice the concurrency); lower it on memory-constrained hosts, raise it to ride out latency spikes",
+		"分块 ETag 与本地 MD5 不一致时重新上传的次数（默认 3，-1 不校验）":           "times to re-upload a part whose ETag does not match the local MD5 (default 3, -1 to disable the check)",
+		"上传期间每隔指定时间输出心跳日志（如 5m），便于外部监控发现挂起的备份":               "print a heartbeat log line at this interval during uploads (e.g. 5m) so external monitors can detect a hung backup",
+		"[心跳] %s: 已上传 %d / %d MB，%d 个分块，已用时 %s，距上次完成分块 %s\n": "[heartbeat] %s: uploaded %d / %d MB, %d parts, elapsed %s, last part completed %s ago\n",
+		"[心跳] %s: 已上传 %d MB，%d 个分块，已用时 %s，距上次完成分块 %s\n":      "[heartbeat] %s: uploaded %d MB, %d parts, elapsed %s, last part completed %s ago\n",
+		"同时归档的包含路径数（多个包含路径位于不同磁盘时可加快归档）":                     "number of include paths to archive concurrently (speeds up archiving when they are on different disks)",
+		"不压缩已压缩的文件类型（jpg、mp4、zip、gz 等，按扩展名），节省 CPU":          "store already-compressed file types (jpg, mp4, zip, gz etc., by extension) without recompressing them to save CPU",
+		"压缩格式 (gzip/zstd)":                      "compression format (gzip/zstd)",
+		"zstd 字典文件（由 train-dict 生成）":            "zstd dictionary file (generated by train-dict)",
+		"压缩: 原始 %.1f MB，压缩后 %.1f MB，压缩比 %.2f\n": "Compression: %.1f MB raw, %.1f MB compressed, ratio %.2f\n",
+		"  各分块压缩比: %.2f ~ %.2f\n":               "  Per-part ratio: %.2f ~ %.2f\n",
+		"提示: 数据几乎无法压缩（如已压缩的媒体文件、压缩包），gzip 压缩只会消耗 CPU；可以使用 --smart-compression 不压缩这些文件类型\n": "Hint: the data is almost incompressible (e.g. already compressed media or archives); gzip only costs CPU. Use --smart-compression to store these file types uncompressed\n",
+		"先将备份完整写入该目录中的临时文件再上传，上传中断后可用 resume 从磁盘续传（需要与备份大小相同的磁盘空间）":                        "write the whole backup to a temporary file in this directory before uploading, so an interrupted upload can be resumed from disk with resume (needs disk space equal to the backup size)",
+		"写入本地临时文件: %s\n":                         "Writing local spool file: %s\n",
+		"临时文件已保留: %s\n":                          "Spool file kept: %s\n",
+		"警告: 删除临时文件失败: %v\n":                     "Warning: failed to remove spool file: %v\n",
+		"流水线: 等待数据 %s（%.0f%%），等待上传 %s（%.0f%%）\n": "Pipeline: waiting for data %s (%.0f%%), waiting for upload %s (%.0f%%)\n",
+		"提示: 上传器大部分时间在等待归档数据，瓶颈在读取文件和压缩，增加并发数不会加快备份；可以排除视频、压缩包等无法再压缩的大文件\n":    "Hint: the uploader spent most of the time waiting for archive data; reading and compressing files is the bottleneck, so more concurrency will not help. Consider excluding large incompressible files such as videos and archives\n",
+		"提示: 归档数据大部分时间在等待上传，瓶颈在网络；可以增加 --concurrency 或使用 --auto-concurrency\n": "Hint: archive data spent most of the time waiting for uploads; the network is the bottleneck. Consider raising --concurrency or using --auto-concurrency\n",
+
+		// backup_all
+		"执行所有备份配置": "Run all backup profiles",
+		`每个配置文件视为一个 profile，按其中的 backup.includes（或 source.url 数据库导出源）执行备份，最后汇总结果。
+任一 profile 失败时命令以错误退出。
+
+未指定配置文件时使用 --profiles-dir 目录（默认 ~/.config/s3backup/profiles）中的所有 .yaml/.yml 文件。
+备份文件名为 backup-{profile}-{timestamp}.tar.gz[.enc]（数据库导出为 .sql.gz 等），profile 为配置文件名（不含扩展名）。
+并行执行时自动禁用进度条。`: `Each config file is a profile; back up its backup.includes (or source.url database dump) and summarize the results.
+The command fails if any profile fails.
+
+Without arguments, all .yaml/.yml files in --profiles-dir (default ~/.config/s3backup/profiles) are used.
+Backups are named backup-{profile}-{timestamp}.tar.gz[.enc] (.sql.gz etc. for database dumps), where profile is the config file name without extension.
+Progress bars are disabled when running in parallel.`,
+		"profile 配置文件目录（默认 ~/.config/s3backup/profiles）": "profile config directory (default ~/.config/s3backup/profiles)",
+		"同时执行的 profile 数":                                "number of profiles to run at the same time",
+		"状态文件目录（用于断点续传，覆盖各 profile 的 state.dir）":         "state directory for resumable uploads (overrides each profile's state.dir)",
+		"\n备份汇总:\n":                  "\nBackup summary:\n",
+		"  [失败] %s (%s): %v\n":       "  [FAILED] %s (%s): %v\n",
+		"  [成功] %s -> %s (%s)\n":     "  [OK] %s -> %s (%s)\n",
+		"==> 开始备份 profile %s (%s)\n": "==> Backing up profile %s (%s)\n",
+
+		// completion
+		"生成 shell 自动补全脚本": "Generate shell completion scripts",
+		`生成指定 shell 的自动补全脚本。
+
+Bash:
+  source <(s3backup completion bash)
+  # 永久生效（Linux）:
+  s3backup completion bash > /etc/bash_completion.d/s3backup
+
+Zsh:
+  s3backup completion zsh > "${fpath[1]}/_s3backup"
+
+Fish:
+  s3backup completion fish > ~/.config/fish/completions/s3backup.fish
+
+PowerShell:
+  s3backup completion powershell | Out-String | Invoke-Expression`: `Generate the completion script for the given shell.
+
+Bash:
+  source <(s3backup completion bash)
+  # Load for every session (Linux):
+  s3backup completion bash > /etc/bash_completion.d/s3backup
+
+Zsh:
+  s3backup completion zsh > "${fpath[1]}/_s3backup"
+
+Fish:
+  s3backup completion fish > ~/.config/fish/completions/s3backup.fish
+
+PowerShell:
+  s3backup completion powershell | Out-String | Invoke-Expression`,
+
+		// config
+		"配置文件管理":     "Manage the config file",
+		"加密配置文件中的凭证": "Encrypt credentials in the config file",
+		"显示合并后实际生效的配置（敏感信息已屏蔽）": "Show the effective merged config (secrets masked)",
+		`显示合并配置文件、环境变量和命令行参数后实际生效的配置，
+用于排查配置优先级问题。凭证和密码会被屏蔽显示。`: `Show the effective config after merging the config file, environment variables and flags,
+to debug precedence issues. Credentials and passwords are masked.`,
+		"(未找到，使用默认值)":       "(not found, using defaults)",
+		"# 配置文件: %s\n":      "# Config file: %s\n",
+		"没有需要加密的凭证: %s\n":   "No credentials to encrypt: %s\n",
+		"已加密 %d 个凭证: %s\n":  "Encrypted %d credentials: %s\n",
+		"加载配置时请设置环境变量 %s\n": "Set the %s environment variable when loading the config\n",
+		"请输入口令: ":           "Enter passphrase: ",
+		"请再次输入口令: ":         "Enter passphrase again: ",
+		`使用口令加密配置文件中的 access_key、secret_key 和加密密码，
+加载配置时设置 ` + config.ConfigPassphraseEnv + ` 环境变量即可透明解密。
+适用于通过 dotfiles 仓库同步配置文件的场景。`: `Encrypt access_key, secret_key and the encryption password in the config file with a passphrase;
+set the ` + config.ConfigPassphraseEnv + ` environment variable when loading the config to decrypt them transparently.
+Useful when syncing config files through a dotfiles repository.`,
+
+		// decrypt
+		"离线解密本地的加密备份文件":                    "Decrypt a local encrypted backup offline",
+		"输出文件（默认去掉 .enc 后缀，- 表示标准输出）":      "output file (default: input without .enc, - for stdout)",
+		"覆盖已存在的输出文件":                       "overwrite an existing output file",
+		"解密密码":                             "decryption password",
+		"请输入解密密码: ":                        "Enter decryption password: ",
+		"解密成功: %s -> %s（%d 字节，加密格式 v%d）\n": "Decrypted: %s -> %s (%d bytes, format v%d)\n",
+		`使用密码或密钥文件解密本地的加密备份文件，不需要访问存储桶。
+
+输出默认为去掉 .enc 后缀的文件名，"-" 表示标准输出。输入为 "-" 时从标准输入读取。
+数据边读边解密，HMAC 在读到文件末尾时校验：写入文件时先写入临时文件，
+校验通过后才重命名为目标文件；输出到标准输出时，校验失败会以错误退出，
+此前输出的数据不可信。
+
+密码可以通过 --password、S3BACKUP_ENCRYPT_PASSWORD 环境变量或配置文件提供，
+都未提供时在终端中提示输入。`: `Decrypt a local encrypted backup with a password or key file, without accessing the bucket.
+
+The output defaults to the input name without the .enc suffix; "-" means stdout. An input of "-" reads from stdin.
+Data is decrypted while reading and the HMAC is verified at the end of the file: output files are written to a temporary file
+and renamed only after verification; when writing to stdout a verification failure exits with an error
+and the data written so far must not be trusted.
+
+The password can be given with --password, the S3BACKUP_ENCRYPT_PASSWORD environment variable or the config file;
+otherwise it is prompted for on the terminal.`,
+
+		// docker
+		"  Docker 卷: %s（无法直接读取 %s，使用辅助容器 %s 打包）\n": "  Docker volume: %s (cannot read %s directly, archiving with helper container %s)\n",
+
+		// estimate
+		"费用估算（%s 参考价格，按压缩前大小计算，仅供参考）:\n":                             "Cost estimate (%s reference prices, based on uncompressed size, for reference only):\n",
+		"  %s: %.2f GB，无价格数据\n":                                      "  %s: %.2f GB, no pricing data\n",
+		"  %s: %.2f GB，存储约 %.4f %s/月，上传请求 %d 次约 %.4f %s\n":           "  %s: %.2f GB, storage about %.4f %s/month, %d upload requests about %.4f %s\n",
+		"    最短存储 %d 天，提前删除仍按 %d 天计费\n":                              "    minimum storage %d days, early deletion is still billed for %d days\n",
+		"警告: 待备份数据 %.2f GB 超过上限 %.2f GB (backup.max_total_size)\n\n": "Warning: data to back up %.2f GB exceeds the limit of %.2f GB (backup.max_total_size)\n\n",
+
+		// k8s
+		"Kubernetes CronJob 模式：读取挂载的 Secret、输出 JSON 日志、写入终止消息并使用结构化退出码": "Kubernetes CronJob mode: read mounted Secrets, log JSON, write a termination message and use structured exit codes",
+		"--k8s 模式下读取凭证的 Secret 挂载目录":                                    "Secret mount directory to read credentials from in --k8s mode",
+		"--k8s 模式下写入终止消息的文件":                                            "termination message file in --k8s mode",
+
+		// migrate
+		"将旧格式的加密备份迁移到当前格式": "Migrate encrypted backups from an old format to the current format",
+		`下载加密备份，解密并校验后使用当前格式重新加密上传，数据全程流式处理，不写入本地磁盘。
+
+当前格式（v2）的 HMAC 覆盖文件头，使用密码加密时会在文件头中保存密钥派生盐值。
+旧格式（v1）中使用密钥文件加密的备份可以迁移；使用密码加密的 v1 备份没有保存盐值，
+无法解密，也无法迁移。
+
+默认覆盖原对象，上传完成前原对象保持不变；使用 --to 可以写入新的对象。`: `Download an encrypted backup, decrypt and verify it, then re-encrypt it with the current format and upload it.
+Data is streamed and never written to local disk.
+
+The current format (v2) covers the header with the HMAC and stores the key derivation salt in the header for password encryption.
+Old format (v1) backups encrypted with a key file can be migrated; v1 backups encrypted with a password did not store the salt
+and can neither be decrypted nor migrated.
+
+The original object is overwritten by default and stays unchanged until the upload completes; use --to to write a new object.`,
+		"迁移后的对象名（默认覆盖原对象）":              "object name after migration (default: overwrite the original)",
+		"只检查加密格式版本，不迁移":                 "only check the encryption format version, do not migrate",
+		"%s: 加密格式 v%d（%s）\n":            "%s: encryption format v%d (%s)\n",
+		"迁移加密格式:\n":                     "Migrating encryption format:\n",
+		"  源对象: %s\n":                   "  Source: %s\n",
+		"  目标对象: %s\n":                  "  Target: %s\n",
+		"%s 已是当前格式 v%d，无需迁移\n":          "%s is already in the current format v%d, nothing to migrate\n",
+		"迁移成功: v%d -> v%d，已写入 %s\n":     "Migrated: v%d -> v%d, written to %s\n",
+		"旧格式，文件头不受 HMAC 保护，使用密码加密时无法解密": "old format, header not covered by the HMAC, cannot be decrypted when password encrypted",
+		"密码加密，盐值保存在文件头":                 "password encrypted, salt stored in the header",
+		"密钥文件加密":                        "key file encrypted",
+
+		// pack
+		"在本地打包（并加密）备份，不上传": "Archive (and encrypt) a backup locally without uploading",
+		`将指定路径打包压缩（并加密）写入本地文件，生成的文件与 backup 上传的对象格式完全一致，
+可以先保存到移动存储，之后再用其他工具上传，或使用 s3backup decrypt 解密。
+
+输出默认为当前目录下的 backup-{timestamp}.tar.gz[.enc]（zstd 压缩时为 .tar.zst），"-" 表示标准输出。
+输出文件名应以 .tar.gz、.tar.zst 或再加 .enc 结尾。`: `Archive, compress (and encrypt) the given paths into a local file in exactly the same format as backup uploads,
+e.g. to save to removable media and upload later with other tools, or to decrypt with s3backup decrypt.
+
+The output defaults to backup-{timestamp}.tar.gz[.enc] in the current directory (.tar.zst with zstd compression); "-" means stdout.
+The output name should end with .tar.gz or .tar.zst, optionally followed by .enc.`,
+		"输出文件（默认：backup-{timestamp}.tar.gz[.enc]，- 表示标准输出）": "output file (default: backup-{timestamp}.tar.gz[.enc], - for stdout)",
+		"打包成功: %s（%d 字节，%d 个包含路径，加密: %v，耗时 %s）\n":           "Packed: %s (%d bytes, %d include paths, encryption: %v, took %s)\n",
+
+		// prune
+		"清理旧备份": "Delete old backups",
+		`按前缀列出存储桶中的备份，从最旧的开始删除，直到总大小不超过 --max-total-size。
+
+最新的备份始终保留，即使它本身已超过上限。归档等存储类型有最短存储时长，
+提前删除仍按最短时长计费。建议先使用 --dry-run 查看将要删除的备份。
+
+--honor-expiry 读取各备份对象元数据中由 backup --expire-after 记录的到期时间，只删除已到期的备份
+及其报告和签名，没有到期时间的备份不受影响。与 --max-total-size 同时使用时先删除到期的备份。`: `List backups in the bucket by prefix and delete them starting from the oldest until the total size is within --max-total-size.
+
+The newest backup is always kept, even if it alone exceeds the limit. Archive storage classes have a minimum storage duration;
+deleting earlier is still billed for the minimum duration. Use --dry-run first to see which backups would be deleted.
+
+--honor-expiry reads the expiry time recorded by backup --expire-after in each backup object's metadata and deletes only expired backups
+together with their reports and signatures; backups without an expiry time are not affected. Combined with --max-total-size, expired backups are deleted first.`,
+		"删除对象元数据中记录的到期时间已过的备份":                     "delete backups whose expiry time recorded in object metadata has passed",
+		"警告: %s 的到期时间 %q 无法解析，保留\n":                "Warning: cannot parse expiry time %[2]q of %[1]s, keeping it\n",
+		"  将删除: %s（%s 到期）\n":                       "  Would delete: %s (expired %s)\n",
+		"  已删除: %s（%s 到期）\n":                       "  Deleted: %s (expired %s)\n",
+		"没有到期的备份\n":                                "No expired backups\n",
+		"模拟运行：将删除 %d 个到期的备份\n":                     "Dry run: would delete %d expired backups\n",
+		"已删除 %d 个到期的备份\n":                          "Deleted %d expired backups\n",
+		"备份对象的 key 前缀":                             "key prefix of backup objects",
+		"前缀下备份的总大小上限（字节）":                          "total size limit for backups under the prefix (bytes)",
+		"只列出将要删除的备份，不实际删除":                         "only list backups that would be deleted, do not delete",
+		"前缀 %q 下共 %d 个备份，总大小 %.2f GB，上限 %.2f GB\n": "%d backups under prefix %q, total %.2f GB, limit %.2f GB\n",
+		"无需清理\n":                            "Nothing to prune\n",
+		"  将删除: %s (%.2f MB, %s)\n":         "  Would delete: %s (%.2f MB, %s)\n",
+		"  已删除: %s (%.2f MB)\n":             "  Deleted: %s (%.2f MB)\n",
+		"模拟运行：将删除 %d 个备份，释放 %.2f GB\n":      "Dry run: would delete %d backups, freeing %.2f GB\n",
+		"已删除 %d 个备份，释放 %.2f GB\n":           "Deleted %d backups, freed %.2f GB\n",
+		"警告: 最新的备份已超过上限，保留后总大小仍为 %.2f GB\n": "Warning: the newest backup exceeds the limit, %.2f GB remain after pruning\n",
+
+		// resume
+		"恢复未完成的上传": "Resume an interrupted upload",
+		"从上次中断的位置继续上传。\n\nupload 命令和 backup.spool_dir 的本地文件按偏移续传；流式备份按状态中保存的包含路径、排除模式和归档设置\n（压缩、扩展属性、填充等）重新归档，已上传的部分与记录的校验值比对后跳过。数据库导出和 Docker 辅助容器的备份无法续传。": "Continue uploading from where it was interrupted.\n\nLocal files from the upload command or backup.spool_dir resume by offset; streamed backups are re-archived with the include paths, exclude patterns and archive settings\n(compression, extended attributes, padding, etc.) saved in the state, with already uploaded parts compared against their recorded checksums and skipped. Database dump and Docker helper container backups cannot be resumed.",
+		"包含路径已保存在续传状态中，不再需要指定":                "include paths are saved in the resume state and no longer needed",
+		"排除模式已保存在续传状态中，不再需要指定":                "exclude patterns are saved in the resume state and no longer needed",
+		"状态文件目录（默认 ~/.s3backup/state/<机器标识>）": "state directory (default ~/.s3backup/state/<machine id>)",
+		"原始备份路径（可多次指定）":                       "original backup path (repeatable)",
+		"排除模式": "exclude pattern",
+		"加密密码（续传加密的上传或备份时使用）": "encryption password (for resuming encrypted uploads or backups)",
+		"密钥文件（续传加密的上传或备份时使用）": "key file (for resuming encrypted uploads or backups)",
+		"恢复上传:\n":             "Resuming upload:\n",
+		"  已完成分块: %d\n":       "  Completed parts: %d\n",
+		"  已上传: %d / %d MB\n": "  Uploaded: %d / %d MB\n",
+		"  已上传: %d MB\n":      "  Uploaded: %d MB\n",
+		"\n恢复失败，状态已保存。可以再次使用 resume 命令继续。\n": "\nResume failed, state saved. Run the resume command again to continue.\n",
+		"恢复成功: %s\n": "Resume succeeded: %s\n",
+		"[警告] %d 个已记录的分块在服务端缺失或不一致，已重新上传: %v\n": "[WARN] %d recorded parts were missing or inconsistent on the server and were uploaded again: %v\n",
+
+		// root
+		"流式备份工具，支持边打包边上传到 S3 兼容存储": "Streaming backup tool that archives and uploads to S3-compatible storage at the same time",
+		`S3Backup 是一个 Go 语言编写的命令行备份工具，
+支持边打包压缩边上传到 S3 兼容存储，并能适配各云存储服务的独特功能（如存储类型设置）。
+
+支持多云存储：
+  - AWS S3
+  - 七牛云 Kodo
+  - 阿里云 OSS
+  - 其他 S3 兼容存储
+
+特性：
+  - 流式处理，无需本地临时文件
+  - AES-256-CTR + HMAC-SHA512 加密
+  - 支持设置存储类型（低频、归档等）
+  - Multipart Upload 并发上传`: `S3Backup is a command-line backup tool written in Go.
+It archives and compresses while uploading to S3-compatible storage, and supports provider-specific features such as storage classes.
+
+Supported storage:
+  - AWS S3
+  - Qiniu Kodo
+  - Aliyun OSS
+  - Other S3-compatible storage
+
+Features:
+  - Streaming, no local temporary files
+  - AES-256-CTR + HMAC-SHA512 encryption
+  - Storage classes (infrequent access, archive, etc.)
+  - Concurrent multipart uploads`,
+		"配置文件路径 (默认 ~/.s3backup.yaml)":                  "config file path (default ~/.s3backup.yaml)",
+		"环境变量文件路径 (默认 .s3backup.env)":                   "env file path (default .s3backup.env)",
+		"只输出错误和警告汇总，不显示进度条、备份信息和逐个文件的警告":                "only print errors and a warning summary; no progress bar, backup info or per-file warnings",
+		"不输出进度条等终端控制字符（也可以设置 NO_COLOR 环境变量）":            "do not print progress bars or other terminal control sequences (or set NO_COLOR)",
+		"输出语言 (en/zh)，默认为 " + i18n.LangEnv + " 环境变量或中文": "output language (en/zh), defaults to the " + i18n.LangEnv + " environment variable or Chinese",
+
+		// schedule
+		"安装定时备份任务（systemd timer 或 crontab）": "Install a scheduled backup (systemd timer or crontab)",
+		`生成并安装每日执行的定时备份任务。
+
+默认写入 systemd service + timer（root 用户写入 /etc/systemd/system，
+普通用户写入 ~/.config/systemd/user）并立即启用；使用 --cron 则写入当前用户的 crontab。
+
+任务会以当前可执行文件的绝对路径调用 backup 命令，并固定使用当前找到的配置文件。
+使用 --verify 在每次备份上传后抽查首尾数据段和备份报告，发现损坏的上传时任务以失败状态结束，
+由 systemd（OnFailure=、journal）或 cron（MAILTO）发出通知。
+
+示例:
+  s3backup install-schedule --daily 03:00 /home/user/documents
+  s3backup install-schedule --daily 03:00 --cron /var/www
+  s3backup install-schedule --daily 03:00 --verify /srv/data
+  s3backup install-schedule --daily 03:00 --print /etc`: `Generate and install a daily scheduled backup.
+
+By default a systemd service + timer is written (/etc/systemd/system for root,
+~/.config/systemd/user otherwise) and enabled immediately; --cron writes to the current user's crontab instead.
+
+The job runs the backup command with the absolute path of the current executable and pins the config file found now.
+With --verify, the first and last chunks and the backup report are checked after every upload; a corrupt upload makes the job fail,
+so systemd (OnFailure=, journal) or cron (MAILTO) raises a notification.
+
+Examples:
+  s3backup install-schedule --daily 03:00 /home/user/documents
+  s3backup install-schedule --daily 03:00 --cron /var/www
+  s3backup install-schedule --daily 03:00 --verify /srv/data
+  s3backup install-schedule --daily 03:00 --print /etc`,
+		"每日执行时间 (HH:MM)":                              "daily run time (HH:MM)",
+		"任务名称（用于 unit 文件名和 crontab 标记）":               "job name (used for unit file names and the crontab marker)",
+		"写入 crontab 而不是 systemd timer":                "write to crontab instead of a systemd timer",
+		"systemd unit 文件目录（默认根据当前用户自动选择）":             "systemd unit directory (default chosen based on the current user)",
+		"只打印生成的内容，不安装":                                "only print the generated content, do not install",
+		"只写入 unit 文件，不执行 systemctl enable":            "only write unit files, do not run systemctl enable",
+		"每次备份上传后校验首尾数据段和备份报告（backup --verify-upload）": "verify the first and last chunks and the backup report after every upload (backup --verify-upload)",
+		"已写入: %s\n": "Written: %s\n",
+		"\n启用定时任务:\n  %s daemon-reload\n  %s enable --now %s.timer\n": "\nEnable the schedule:\n  %s daemon-reload\n  %s enable --now %s.timer\n",
+		"✓ 定时任务已启用，每日 %s 执行\n":                                        "✓ Schedule enabled, runs daily at %s\n",
+		"查看状态: %s list-timers %s.timer\n":                             "Check status: %s list-timers %s.timer\n",
+		"提示: 用户级 timer 需要 loginctl enable-linger %s 才能在未登录时运行\n":      "Hint: user timers need loginctl enable-linger %s to run while logged out\n",
+		"✓ 已写入 crontab，每日 %s 执行\n":                                    "✓ Written to crontab, runs daily at %s\n",
+
+		// storage_classes
+		"七牛云 Kodo": "Qiniu Kodo",
+		"阿里云 OSS":  "Aliyun OSS",
+		"标准存储":     "Standard",
+		"低频访问":     "Infrequent access",
+		"归档存储":     "Archive",
+		"冷归档":      "Cold archive",
+		"深度归档":     "Deep archive",
+		"归档直读":     "Archive instant retrieval",
+		"智能分层":     "Intelligent tiering",
+		"列出存储提供商支持的存储类型": "List storage classes supported by providers",
+		`列出各存储提供商支持的存储类型，以及 --storage-class 取值对应的提供商原生取值。
+
+示例:
+  s3backup storage-classes
+  s3backup storage-classes --provider qiniu`: `List the storage classes supported by each provider and the provider-native value for each --storage-class.
+
+Examples:
+  s3backup storage-classes
+  s3backup storage-classes --provider qiniu`,
+		"存储提供商 (aws/qiniu/aliyun)，默认列出全部": "storage provider (aws/qiniu/aliyun), lists all by default",
+		"  --storage-class\t提供商取值\t说明\n":  "  --storage-class\tprovider value\tdescription\n",
+
+		// tui
+		"显示交互式仪表盘（当前文件、各 worker 的分块进度、吞吐量曲线、重试次数和最近的日志）": "show an interactive dashboard (current file, per-worker part progress, throughput graph, retries and recent logs)",
+		"已中断\n": "Interrupted\n",
+
+		// upload
+		"上传已有的本地文件（不重新打包）": "Upload an existing local file (without re-archiving)",
+		`将已有的本地文件（如数据库导出或 pack 生成的备份）直接上传，可选加密。
+
+上传中断后再次执行相同的命令即可续传：分块按文件偏移读取，已上传的分块不会重新读取或上传。
+续传前会校验文件大小和修改时间，文件发生变化时需要删除状态文件后重新上传。
+启用加密时续传使用相同的文件头（IV），完成前需要顺序读取一遍整个文件计算 HMAC。`: `Upload an existing local file (such as a database dump or a pack output) directly, optionally encrypted.
+
+If the upload is interrupted, run the same command again to resume: parts are read by file offset and uploaded parts are neither re-read nor re-uploaded.
+The file size and modification time are checked before resuming; if the file changed, delete the state file and upload again.
+Encrypted resumes reuse the same header (IV) and read the whole file sequentially once to compute the HMAC before completing.`,
+		"对象名（默认：文件名，启用加密时追加 .enc）": "object name (default: file name, with .enc appended when encrypted)",
+		"上传文件:\n":            "Uploading file:\n",
+		"  源文件: %s\n":        "  Source: %s\n",
+		"  对象名: %s\n":        "  Object: %s\n",
+		"  大小: %d 字节\n":      "  Size: %d bytes\n",
+		"  续传: 已完成 %d 个分块\n": "  Resume: %d parts completed\n",
+		"\n上传失败，已删除失效的状态文件。再次执行相同的 upload 命令将重新上传。\n": "\nUpload failed, the stale state file was removed. Run the same upload command again to start over.\n",
+		"上传成功: %s\n": "Upload succeeded: %s\n",
+
+		// verify
+		"验证备份的 Ed25519 签名": "Verify a backup's Ed25519 signature",
+		`下载存储桶中的备份及其 <backup>.sig 签名，计算备份的 SHA-256 并使用 --pubkey 公钥验证签名。
+签名由 backup --sign-key 生成，即使存储凭证泄露，没有签名私钥也无法伪造有效的备份。
+
+使用 --local 验证本地文件，签名默认为同目录下的 <file>.sig，可通过 --sig 指定。
+
+使用 --sample 只下载随机抽取的一部分数据段（如 --sample 5%），与备份报告 <backup>.report.json
+中记录的各段 SHA-256 比较，不需要下载整个对象，适合定期检查大型备份。需要备份时启用 backup.report。
+
+发现不一致时逐行列出损坏的数据段（每段 16MB）及其字节范围，而不只是整体失败；
+签名验证失败时如果存在备份报告，同样按报告中的分段 SHA-256 定位损坏的数据段。`: `Download a backup and its <backup>.sig signature from the bucket, compute the backup's SHA-256 and verify the signature with the --pubkey public key.
+Signatures are created by backup --sign-key; even with leaked storage credentials, valid backups cannot be forged without the signing key.
+
+Use --local to verify a local file; the signature defaults to <file>.sig in the same directory and can be set with --sig.
+
+Use --sample to download only a random share of the chunks (e.g. --sample 5%) and compare them with the per-chunk SHA-256
+recorded in the backup report <backup>.report.json, without downloading the whole object. Useful for routine checks of large backups; requires backup.report when backing up.
+
+Mismatches are reported chunk by chunk (16MB each) with their byte ranges instead of a single failure;
+when signature verification fails and a backup report exists, corrupt chunks are located the same way.`,
+		"Ed25519 公钥（PEM）":               "Ed25519 public key (PEM)",
+		"验证本地文件而不是存储桶中的对象":              "verify a local file instead of an object in the bucket",
+		"签名文件（--local 时默认为 <file>.sig）": "signature file (default <file>.sig with --local)",
+		"签名验证通过: %s\n":                  "Signature verified: %s\n",
+		"  密钥: %s\n":                    "  Key: %s\n",
+		"抽样校验的数据比例（如 5%），按备份报告中的分段 SHA-256 校验，不验证签名": "share of the data to check (e.g. 5%) against the per-chunk SHA-256 in the backup report; does not verify the signature",
+		"抽样验证通过: %s\n":           "Sample verification passed: %s\n",
+		"  已校验 %d/%d 段，%d 字节\n":  "  Checked %d/%d chunks, %d bytes\n",
+		"损坏的数据段: %d（字节 %d-%d）\n": "Corrupt chunk: %d (bytes %d-%d)\n",
+
+		// restore
+		"下载备份并还原到本地目录": "Download a backup and restore it into a local directory",
+		`下载存储桶中的备份，解密（.enc 后缀）并解压到 <dir>。使用 --local 还原本地的备份文件。
+
+归档中的绝对路径去掉开头的 /，还原到 <dir> 下对应的位置，已存在的同名文件会被覆盖；
+包含 .. 或经过符号链接指向 <dir> 之外的路径会导致还原失败。
+
+备份时使用 --xattrs、--selinux 记录的扩展属性默认一并还原：
+  - POSIX ACL 和 user.* 扩展属性
+  - capabilities（security.capability）、SELinux 上下文和 trusted.* 等属性需要以 root 运行，否则跳过并输出警告
+可以分别用 --no-xattrs、--no-acls、--no-capabilities、--no-selinux 关闭。
+
+加密备份的 HMAC 在读完全部数据后才校验，校验失败时以错误退出，此前还原的文件不可信。
+
+默认以 4 个并发请求分段下载（每段 8MB），可以用 --download-concurrency、--download-chunk-size 调整；
+小文件很多时使用 --decompress-workers 让多个 worker 并行写入文件。`: `Download a backup from the bucket, decrypt it (.enc suffix) and extract it into <dir>. Use --local to restore a local backup file.
+
+Absolute paths in the archive lose their leading / and are restored under <dir>; existing files are overwritten.
+Paths containing .. or leading outside <dir> through a symlink make the restore fail.
+
+Extended attributes recorded with --xattrs and --selinux are restored by default:
+  - POSIX ACLs and user.* attributes
+  - capabilities (security.capability), SELinux contexts and trusted.* attributes require root and are skipped with a warning otherwise
+Disable them individually with --no-xattrs, --no-acls, --no-capabilities and --no-selinux.
+
+The HMAC of an encrypted backup is only checked after all data has been read; if it fails the command exits with an error and the restored files must not be trusted.
+
+By default the backup is downloaded in 8MB ranges over 4 concurrent requests; tune this with --download-concurrency and --download-chunk-size.
+With many small files, use --decompress-workers to write files with several workers in parallel.`,
+		"并发下载的分段数（默认 4），1 表示用单个请求顺序下载":            "number of ranges downloaded concurrently (default 4), 1 downloads sequentially in a single request",
+		"每个下载分段的大小（字节，默认 8MB，至少 1MB）":             "size of each download range in bytes (default 8MB, at least 1MB)",
+		"解压时并行写入文件的 worker 数（默认 1），小文件多、磁盘延迟高时调大": "number of workers writing extracted files in parallel (default 1); raise it for many small files or high-latency disks",
+		"还原本地的备份文件而不是存储桶中的对象":                     "restore a local backup file instead of an object in the bucket",
+		"不还原 user.*、trusted.* 等扩展属性":              "do not restore extended attributes such as user.* and trusted.*",
+		"不还原 POSIX ACL":                         "do not restore POSIX ACLs",
+		"不还原 capabilities（security.capability）": "do not restore capabilities (security.capability)",
+		"不还原 SELinux 上下文":                       "do not restore SELinux contexts",
+		"还原完成: %s -> %s（%d 个文件，%d 字节）\n":        "Restored: %s -> %s (%d files, %d bytes)\n",
+		"警告: 跳过了 %d 个无法还原的条目\n":                 "Warning: skipped %d entries that could not be restored\n",
+
+		// init-bucket
+		"在存储桶中写入说明备份布局和版本要求的标记对象": "Write a marker object describing the backup layout and version requirements to the bucket",
+		`在存储桶（或 --prefix 指定的前缀）下写入 .s3backup.json 标记对象，记录备份对象的命名方式、
+归档和加密格式版本，以及读写这些备份所需的最低 s3backup 版本。
+
+之后 backup 上传前会读取备份所在前缀的标记对象，属于其他工具、布局版本更新或要求更高版本的 s3backup 时拒绝上传，
+避免不兼容的工具或版本把备份混在同一个前缀下。没有标记对象的前缀不受影响。
+
+标记对象已存在时不会覆盖，使用 --force 重新写入。`: `Write a .s3backup.json marker object to the bucket (or the prefix given by --prefix) recording how backup objects are named,
+the archive and encryption format versions, and the minimum s3backup version required to read and write these backups.
+
+Before uploading, backup then reads the marker of the backup's prefix and refuses to upload if it belongs to another tool,
+uses a newer layout version or requires a newer s3backup, so incompatible tools or versions do not mix backups in one prefix. Prefixes without a marker are not affected.
+
+An existing marker is not overwritten unless --force is given.`,
+		"备份对象所在的前缀（如 host1/），默认为存储桶根目录":      "prefix holding the backup objects (e.g. host1/), default is the bucket root",
+		"要求的最低 s3backup 版本（默认为当前版本）":         "minimum required s3backup version (default: the current version)",
+		"覆盖已存在的标记对象":                         "overwrite an existing marker object",
+		"只打印标记对象，不上传":                        "only print the marker object, do not upload it",
+		"%s 已存在（%s，布局版本 %d），使用 --force 覆盖\n": "%s already exists (%s, layout version %d), use --force to overwrite\n",
+		"已写入标记对象: %s\n":                      "Marker object written: %s\n",
+
+		// overwrite
+		"允许覆盖存储中已存在的同名备份（默认已存在时失败）": "allow overwriting an existing backup with the same name (fails by default)",
+		"允许覆盖存储中已存在的同名对象（默认已存在时失败）": "allow overwriting an existing object with the same name (fails by default)",
+		"警告: 无法检查 %s 是否已存在: %v\n":   "Warning: could not check whether %s exists: %v\n",
+
+		// idempotent
+		"当天已有以相同 profile 成功完成的备份时跳过（用于重试的定时任务）": "skip if a backup of the same profile already succeeded today (for retried scheduled jobs)",
+		"幂等键 %s 的备份已于 %s 在 %s 完成: %s，跳过\n":      "Backup for idempotency key %s already completed at %s on %s: %s, skipping\n",
+		"警告: 写入运行记录 %s 失败: %v\n":                "Warning: failed to write run record %s: %v\n",
+
+		// lock
+		"警告: 删除 %s 之前未刷新的过期锁 %s\n": "Warning: deleting stale lock %[2]s, last refreshed %[1]s\n",
+		"警告: 刷新锁失败: %v\n":          "Warning: failed to refresh lock: %v\n",
+
+		// freshness
+		"检查最近一次成功的备份是否超过允许的时长（RPO）": "check that the latest successful backup is within the allowed age (RPO)",
+		`列出 --prefix 下的备份，最近一次完成上传的备份早于 --max-age（配置项 backup.max_age）
+或前缀下没有备份时以失败状态退出。
+
+s3backup 没有常驻进程，备份失败或定时任务没有运行时不会有任何输出。
+将 freshness 作为独立的定时任务或监控探针运行，可以发现这类静默的失败，
+由 systemd（OnFailure=）、cron（MAILTO）或监控系统发出通知。每个 profile 使用各自的配置文件和 max_age。
+
+示例:
+  s3backup freshness --max-age 26h
+  s3backup -c /etc/s3backup/db.yaml freshness --prefix db/ --max-age 2h`: `List the backups under --prefix and exit with a failure when the most recently uploaded backup is older than
+--max-age (config key backup.max_age) or there is no backup under the prefix.
+
+s3backup has no long-running process, so a failed backup or a schedule that never ran produces no output at all.
+Run freshness as a separate scheduled job or monitoring probe to catch these silent failures and let
+systemd (OnFailure=), cron (MAILTO) or your monitoring system raise a notification. Use a separate config file and max_age per profile.
+
+Examples:
+  s3backup freshness --max-age 26h
+  s3backup -c /etc/s3backup/db.yaml freshness --prefix db/ --max-age 2h`,
+		"最近一次成功的备份允许的最长时间（如 26h）": "maximum age of the latest successful backup (e.g. 26h)",
+		"最近一次备份: %s（%s，%s 前）\n":   "Latest backup: %s (%s, %s ago)\n",
+		"最近一次备份在允许的时长 %s 内\n":     "Latest backup is within the allowed age of %s\n",
+
+		// train-dict
+		"用样本文件训练 zstd 压缩字典": "Train a zstd compression dictionary from sample files",
+		`读取给定路径下的文件（如一批配置文件或以往的数据库导出）训练 zstd 字典，
+写入本地文件并上传到存储桶的 <prefix>/.s3backup-dicts/<字典 ID>.zdict。
+
+大量相似的小备份单独压缩时，每次都要从头学习数据中的重复内容；使用字典后这些内容可以直接引用，
+备份明显变小。在备份主机上设置 backup.compression: zstd 和 backup.zstd_dictionary 指向本地字典文件，
+restore 和 rehearse 按备份帧头中的字典 ID 从存储桶下载字典，不需要额外配置。
+
+数据的结构发生较大变化后重新训练即可：新字典使用新的 ID，旧备份仍使用各自的字典还原，
+因此不要删除存储桶中旧的字典。`: `Train a zstd dictionary from the files under the given paths (e.g. a set of config files or past database dumps),
+write it to a local file and upload it to <prefix>/.s3backup-dicts/<dictionary ID>.zdict in the bucket.
+
+When many similar small backups are compressed separately, each one has to learn the repeated content from scratch;
+with a dictionary that content can be referenced directly and backups get noticeably smaller. On backup hosts set
+backup.compression: zstd and point backup.zstd_dictionary at the local dictionary file; restore and rehearse download
+the dictionary from the bucket by the ID in the backup's frame header, no extra configuration needed.
+
+Retrain after the data's structure changes significantly: the new dictionary gets a new ID and older backups still restore
+with their own dictionaries, so do not delete old dictionaries from the bucket.`,
+		"字典文件（默认：s3backup-{字典 ID}.zdict）": "dictionary file (default: s3backup-{dictionary ID}.zdict)",
+		"上传字典的前缀，应与备份所在的前缀相同":             "prefix to upload the dictionary under; should match the backups' prefix",
+		"字典大小上限（字节）":                      "maximum dictionary size (bytes)",
+		"只写入本地文件，不上传到存储桶":                 "only write the local file, do not upload to the bucket",
+		"已生成字典: %s（ID %d，%d 字节）\n":        "Dictionary written: %s (ID %d, %d bytes)\n",
+		"已上传字典: %s\n":                     "Dictionary uploaded: %s\n",
+		"在配置文件中启用:\n":                     "Enable it in the config file:\n",
+
+		// thaw
+		"取回归档存储类型的备份，使其可以下载还原": "Restore archived backups so they can be downloaded",
+		`对归档类存储类型的备份发起取回（AWS S3 的 GLACIER、DEEP_ARCHIVE，阿里云 OSS 的 Archive、ColdArchive、DeepColdArchive），
+取回完成后的 --days 天内可以用 restore、rehearse、verify 等命令下载。
+
+--tier 选择取回速度：expedited、standard、bulk，越快费用越高。阿里云归档存储不区分档位，深度冷归档不支持 bulk。
+取回通常需要数分钟到数十小时：--status 只查询取回状态，--wait 发起取回后等待全部完成。`: `Request a restore of backups in archive storage classes (GLACIER and DEEP_ARCHIVE on AWS S3, Archive, ColdArchive and DeepColdArchive on Aliyun OSS).
+Once restored, they can be downloaded with restore, rehearse, verify and other commands for --days days.
+
+--tier selects the restore speed: expedited, standard or bulk; faster tiers cost more. Aliyun Archive has no tiers and DeepColdArchive does not support bulk.
+Restores usually take minutes to tens of hours: --status only shows the restore status, --wait waits for all restores to complete.`,
+		"取回的临时副本保留天数":                        "days to keep the restored temporary copy",
+		"取回速度: expedited, standard, bulk":    "restore speed: expedited, standard, bulk",
+		"只查询取回状态，不发起取回":                      "only show restore status, do not start a restore",
+		"等待取回完成后再退出":                         "wait until restores complete before exiting",
+		"%s: 存储类型 %s 可以直接读取，无需取回\n":          "%s: storage class %s is readable without restore\n",
+		"%s: 已取回，临时副本保留到 %s\n":               "%s: restored, temporary copy kept until %s\n",
+		"%s: 取回进行中\n":                        "%s: restore in progress\n",
+		"%s: 存储类型 %s，尚未取回\n":                 "%s: storage class %s, not restored\n",
+		"%s: 已发起取回（存储类型 %s，档位 %s，保留 %d 天）\n": "%s: restore requested (storage class %s, tier %s, kept for %d days)\n",
+		"等待 %d 个备份取回完成，每 %s 查询一次\n":          "Waiting for %d backups to be restored, checking every %s\n",
+
+		// rehearse
+		"将备份完整还原到临时目录并抽查文件，确认备份可以恢复": "Fully restore a backup into a temporary directory and spot-check files to prove it can be recovered",
+		`下载备份并完整还原到临时目录，与备份报告 <backup>.report.json 比较后删除还原结果。
+
+检查内容：
+  - 备份能完整下载、解密并解压，加密备份的 HMAC 校验通过
+  - 还原出的文件数与报告一致
+  - 报告中随机抽样记录的文件（备份时启用 backup.report）SHA-256 一致
+
+临时目录默认位于系统临时目录，可用 --dir 指定（如 /dev/shm 使用内存文件系统），
+--max-size 限制还原的数据量，超出时中止演练，避免占满磁盘或内存。
+演练时不还原扩展属性，也不需要 root 权限。`: `Download a backup, restore it completely into a temporary directory, compare it with the backup report <backup>.report.json and delete the result.
+
+Checks:
+  - the backup can be fully downloaded, decrypted and extracted, and the HMAC of an encrypted backup is valid
+  - the number of restored files matches the report
+  - the SHA-256 of the files randomly sampled in the report (backup.report enabled when backing up) matches
+
+The temporary directory is created in the system temp directory unless --dir is given (e.g. /dev/shm for a memory file system);
+--max-size caps the amount of restored data and aborts the rehearsal when exceeded, so it cannot fill the disk or memory.
+Extended attributes are not restored during a rehearsal and root is not required.`,
+		"演练本地的备份文件，报告为同目录下的 <file>.report.json": "rehearse a local backup file; the report is <file>.report.json in the same directory",
+		"在该目录下创建临时目录（默认为系统临时目录）":                "create the temporary directory here (default: system temp directory)",
+		"还原的文件内容总字节数上限，超出时中止演练（0 表示不限制）":        "maximum total bytes of restored file content; the rehearsal aborts when exceeded (0 means unlimited)",
+		"警告: 没有找到备份报告 %s，只检查备份能否完整还原\n":         "Warning: backup report %s not found, only checking that the backup can be fully restored\n",
+		"还原到临时目录: %s\n":               "Restoring into temporary directory: %s\n",
+		"还原演练通过: %s\n":                "Restore rehearsal passed: %s\n",
+		"  还原 %d 个文件，%d 字节，用时 %s\n":   "  Restored %d files, %d bytes in %s\n",
+		"  抽查 %d 个文件的 SHA-256 一致\n":   "  SHA-256 of %d sampled files matches\n",
+		"[失败] %s: %v\n":               "[FAIL] %s: %v\n",
+		"[失败] %s: SHA-256 与备份报告不一致\n": "[FAIL] %s: SHA-256 does not match the backup report\n",
+
+		// version
+		"显示版本信息":            "Show version information",
+		"检查 GitHub 上是否有新版本": "check GitHub for a newer release",
+		"版本:     %s\n":      "Version:    %s\n",
+		"提交:     %s\n":      "Commit:     %s\n",
+		"构建时间: %s\n":        "Build date: %s\n",
+		"Go 版本:  %s\n":      "Go version: %s\n",
+		"发现新版本: %s\n":       "New version available: %s\n",
+		"下载地址: https://github.com/lukelzlz/s3backup/releases/latest\n": "Download: https://github.com/lukelzlz/s3backup/releases/latest\n",
+		"已是最新版本（最新发布: %s）\n":                                           "Up to date (latest release: %s)\n",
+	})
+}
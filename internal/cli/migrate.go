@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/uploader"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateTarget string
+	migrateCheck  bool
+)
+
+// migrateFormatCmd 加密格式迁移命令
+var migrateFormatCmd = &cobra.Command{
+	Use:   "migrate-format <backup-name>",
+	Short: "将旧格式的加密备份迁移到当前格式",
+	Long: `下载加密备份，解密并校验后使用当前格式重新加密上传，数据全程流式处理，不写入本地磁盘。
+
+当前格式（v2）的 HMAC 覆盖文件头，使用密码加密时会在文件头中保存密钥派生盐值。
+旧格式（v1）中使用密钥文件加密的备份可以迁移；使用密码加密的 v1 备份没有保存盐值，
+无法解密，也无法迁移。
+
+默认覆盖原对象，上传完成前原对象保持不变；使用 --to 可以写入新的对象。`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMigrateFormat,
+
+	ValidArgsFunction: completeBackupName,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateFormatCmd)
+
+	addConfigFlags(migrateFormatCmd)
+	migrateFormatCmd.Flags().StringVar(&migrateTarget, "to", "", "迁移后的对象名（默认覆盖原对象）")
+	migrateFormatCmd.Flags().BoolVar(&migrateCheck, "check", false, "只检查加密格式版本，不迁移")
+	migrateFormatCmd.Flags().BoolVar(&noProgress, "no-progress", false, "禁用进度条")
+}
+
+func runMigrateFormat(cmd *cobra.Command, args []string) error {
+	source := args[0]
+	target := migrateTarget
+	if target == "" {
+		target = source
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
+	defer cancel()
+
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if err := requireReadAccess(cfg, "migrate-format"); err != nil {
+		return err
+	}
+	adapter, err := createStorageAdapter(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create storage adapter: %w", err)
+	}
+	reader, ok := adapter.(storage.ObjectReader)
+	if !ok {
+		return fmt.Errorf("provider %s does not support downloading objects", cfg.Storage.Provider)
+	}
+
+	if migrateCheck {
+		header, err := readObjectHeader(ctx, reader, source)
+		if err != nil {
+			return err
+		}
+		i18n.Printf("%s: 加密格式 v%d（%s）\n", source, header.Version, describeFormat(header))
+		return nil
+	}
+
+	keys, err := keySource(cfg)
+	if err != nil {
+		return err
+	}
+	encryptor, err := createEncryptor(cfg)
+	if err != nil {
+		return err
+	}
+
+	upl := uploader.NewUploader(adapter, cfg.Backup.ChunkSize, cfg.Backup.Concurrency)
+	reporter := newProgressReporter()
+	upl.SetProgressReporter(reporter)
+	upl.SetHeartbeat(heartbeatFor(cfg))
+	upl.SetReadAhead(cfg.Backup.ReadAhead)
+	upl.SetETagRetries(cfg.Backup.ETagRetries)
+	defer reporter.Close()
+
+	i18n.Printf("迁移加密格式:\n")
+	i18n.Printf("  源对象: %s\n", source)
+	i18n.Printf("  目标对象: %s\n", target)
+	fmt.Println()
+
+	opts := storage.UploadOptions{
+		StorageClass:       storage.ParseStorageClass(cfg.Storage.StorageClass),
+		ContentType:        "application/octet-stream",
+		ContentDisposition: storage.ContentDispositionFor(target),
+		ACL:                cfg.Storage.ACL,
+	}
+	header, migrated, err := migrateObject(ctx, reader, upl, keys, encryptor, source, target, opts)
+	if err != nil {
+		if hint := errorHint(err); hint != "" {
+			i18n.Printf("\n提示: %s\n", hint)
+		}
+		return err
+	}
+
+	if !migrated {
+		i18n.Printf("%s 已是当前格式 v%d，无需迁移\n", source, header.Version)
+		return nil
+	}
+	i18n.Printf("迁移成功: v%d -> v%d，已写入 %s\n", header.Version, crypto.FormatVersion, target)
+	return nil
+}
+
+// readObjectHeader 下载对象开头并解析加密文件头
+func readObjectHeader(ctx context.Context, reader storage.ObjectReader, key string) (*crypto.Header, error) {
+	body, err := reader.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	header, err := crypto.ReadHeader(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header of %s: %w", key, err)
+	}
+	return header, nil
+}
+
+// describeFormat 返回加密格式的简要说明
+func describeFormat(h *crypto.Header) string {
+	if h.Version == crypto.LegacyFormatVersion {
+		return i18n.T("旧格式，文件头不受 HMAC 保护，使用密码加密时无法解密")
+	}
+	if h.KDF == crypto.KDFArgon2id {
+		return i18n.T("密码加密，盐值保存在文件头")
+	}
+	return i18n.T("密钥文件加密")
+}
+
+// migrateObject 流式下载 source，解密并校验后使用 encryptor 重新加密上传到 target
+// source 已是当前格式且与 target 相同时不做任何修改，migrated 为 false。
+// 源数据 HMAC 校验失败时上传会被取消，不会产生目标对象。
+func migrateObject(ctx context.Context, reader storage.ObjectReader, upl *uploader.Uploader,
+	keys crypto.KeySource, encryptor *crypto.StreamEncryptor,
+	source, target string, opts storage.UploadOptions) (header *crypto.Header, migrated bool, err error) {
+
+	body, err := reader.GetObject(ctx, source)
+	if err != nil {
+		return nil, false, err
+	}
+	defer body.Close()
+
+	plaintext, header, err := crypto.OpenReader(body, keys)
+	if err != nil {
+		return header, false, fmt.Errorf("failed to open %s: %w", source, err)
+	}
+	if header.Version == crypto.FormatVersion && source == target {
+		return header, false, nil
+	}
+
+	sink := upl.NewWriter(ctx, target, opts)
+	w, err := encryptor.WrapWriter(sink)
+	if err != nil {
+		return header, false, sink.CloseWithError(fmt.Errorf("failed to create encrypt writer: %w", err))
+	}
+	if _, err := io.Copy(w, plaintext); err != nil {
+		return header, false, sink.CloseWithError(fmt.Errorf("failed to decrypt %s: %w", source, err))
+	}
+	if err := w.Close(); err != nil {
+		return header, false, sink.CloseWithError(fmt.Errorf("failed to close encryptor: %w", err))
+	}
+	if err := sink.Close(); err != nil {
+		return header, false, fmt.Errorf("failed to upload: %w", err)
+	}
+	return header, true, nil
+}
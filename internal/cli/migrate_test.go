@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+	"github.com/lukelzlz/s3backup/pkg/uploader"
+)
+
+// legacyBlob 按 v1 格式（HMAC 只覆盖密文）加密数据
+func legacyBlob(t *testing.T, keyFile, plaintext []byte) []byte {
+	t.Helper()
+	aesKey, hmacKey, err := crypto.DeriveKeyFromKeyFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iv := bytes.Repeat([]byte{7}, crypto.IVSize)
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, plaintext)
+	mac := hmac.New(sha512.New, hmacKey)
+	mac.Write(ciphertext)
+
+	var buf bytes.Buffer
+	buf.WriteString(crypto.Magic)
+	buf.Write(iv)
+	buf.Write(ciphertext)
+	binary.Write(&buf, binary.BigEndian, uint64(len(ciphertext)))
+	buf.Write(mac.Sum(nil))
+	return buf.Bytes()
+}
+
+// putObject 通过上传器写入对象
+func putObject(t *testing.T, adapter *mock.Adapter, key string, data []byte) {
+	t.Helper()
+	upl := uploader.NewUploader(adapter, 5*1024*1024, 1)
+	if err := upl.Upload(context.Background(), key, bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("failed to put %s: %v", key, err)
+	}
+}
+
+// TestMigrateObjectLegacyKeyFile 测试将 v1 密钥文件加密的备份迁移到当前格式
+func TestMigrateObjectLegacyKeyFile(t *testing.T) {
+	ctx := context.Background()
+	keyFile, _ := crypto.GenerateKeyFile()
+	aesKey, hmacKey, _ := crypto.DeriveKeyFromKeyFile(keyFile)
+	encryptor, _ := crypto.NewStreamEncryptor(aesKey, hmacKey)
+	keys := crypto.KeySource{KeyFile: keyFile}
+
+	plaintext := bytes.Repeat([]byte("backup data "), 50000)
+	adapter := mock.New()
+	putObject(t, adapter, "old.tar.gz.enc", legacyBlob(t, keyFile, plaintext))
+
+	upl := uploader.NewUploader(adapter, 5*1024*1024, 2)
+	header, migrated, err := migrateObject(ctx, adapter, upl, keys, encryptor, "old.tar.gz.enc", "old.tar.gz.enc", storage.UploadOptions{})
+	if err != nil {
+		t.Fatalf("migrateObject() error = %v", err)
+	}
+	if !migrated || header.Version != crypto.LegacyFormatVersion {
+		t.Fatalf("migrated = %v, source version = %d", migrated, header.Version)
+	}
+
+	obj, _ := adapter.Object("old.tar.gz.enc")
+	r, newHeader, err := crypto.OpenReader(bytes.NewReader(obj.Data), keys)
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	if newHeader.Version != crypto.FormatVersion {
+		t.Errorf("migrated version = %d, want %d", newHeader.Version, crypto.FormatVersion)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Error("migrated data does not match original plaintext")
+	}
+
+	// 再次迁移时不做修改
+	_, migrated, err = migrateObject(ctx, adapter, upl, keys, encryptor, "old.tar.gz.enc", "old.tar.gz.enc", storage.UploadOptions{})
+	if err != nil || migrated {
+		t.Errorf("second migration: migrated = %v, err = %v", migrated, err)
+	}
+}
+
+// TestMigrateObjectCorruptedSource 测试源数据损坏时不产生目标对象
+func TestMigrateObjectCorruptedSource(t *testing.T) {
+	ctx := context.Background()
+	keyFile, _ := crypto.GenerateKeyFile()
+	aesKey, hmacKey, _ := crypto.DeriveKeyFromKeyFile(keyFile)
+	encryptor, _ := crypto.NewStreamEncryptor(aesKey, hmacKey)
+
+	blob := legacyBlob(t, keyFile, bytes.Repeat([]byte("x"), 1024))
+	blob[crypto.LegacyHeaderSize+10] ^= 0xff
+
+	adapter := mock.New()
+	putObject(t, adapter, "corrupt.enc", blob)
+
+	upl := uploader.NewUploader(adapter, 5*1024*1024, 1)
+	_, _, err := migrateObject(ctx, adapter, upl, crypto.KeySource{KeyFile: keyFile}, encryptor, "corrupt.enc", "migrated.enc", storage.UploadOptions{})
+	if !errors.Is(err, crypto.ErrHMACMismatch) {
+		t.Fatalf("expected ErrHMACMismatch, got %v", err)
+	}
+	if _, ok := adapter.Object("migrated.enc"); ok {
+		t.Error("target object should not exist after failed migration")
+	}
+	if pending := adapter.PendingUploads(); len(pending) != 0 {
+		t.Errorf("upload should be aborted, pending: %v", pending)
+	}
+}
+
+// TestMigrateObjectLegacyPassword 测试 v1 密码加密的备份给出明确错误
+func TestMigrateObjectLegacyPassword(t *testing.T) {
+	keyFile, _ := crypto.GenerateKeyFile()
+	adapter := mock.New()
+	putObject(t, adapter, "old.enc", legacyBlob(t, keyFile, []byte("data")))
+
+	encryptor, _ := crypto.NewStreamEncryptor(make([]byte, crypto.AESKeySize), make([]byte, crypto.HMACKeySize))
+	upl := uploader.NewUploader(adapter, 5*1024*1024, 1)
+	_, _, err := migrateObject(context.Background(), adapter, upl, crypto.KeySource{Password: "pw"}, encryptor, "old.enc", "old.enc", storage.UploadOptions{})
+	if !errors.Is(err, crypto.ErrLegacyPassword) {
+		t.Errorf("expected ErrLegacyPassword, got %v", err)
+	}
+}
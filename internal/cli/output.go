@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/lukelzlz/s3backup/pkg/progress"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// stdout --quiet 模式下被替换前的标准输出，由 restoreOutput 恢复
+var stdout *os.File
+
+// setupOutput 处理 --quiet 和 --no-color，在所有命令执行前调用
+// NO_COLOR（任意非空值）或 TERM=dumb 等同于 --no-color；
+// --quiet 时丢弃标准输出，错误和警告汇总写入标准错误，--k8s 模式的 JSON 日志不受影响
+func setupOutput(cmd *cobra.Command, args []string) error {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		noColor = true
+	}
+	if !quiet || k8sMode || stdout != nil {
+		return nil
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+	}
+	stdout, os.Stdout = os.Stdout, devNull
+	return nil
+}
+
+// restoreOutput 恢复 --quiet 替换的标准输出
+func restoreOutput() {
+	if stdout == nil {
+		return
+	}
+	os.Stdout.Close()
+	os.Stdout, stdout = stdout, nil
+}
+
+// newProgressReporter 创建上传进度报告器
+// 标准错误不是终端（cron、CI 日志）或指定了 --no-progress、--quiet、--no-color 时不显示进度条，
+// 避免日志中充满进度条的刷新字符
+func newProgressReporter() progress.Reporter {
+	if noProgress || quiet || noColor || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return progress.NewSilent()
+	}
+	return progress.NewBar()
+}
+
+// writeOutput 调用 write 生成输出，output 为 "-" 时写入标准输出（--quiet 不影响数据输出）
+// 写入文件时先写入同目录下的临时文件，write 成功后再重命名为 output，失败时不留下输出文件；
+// force 为 false 时拒绝覆盖已存在的文件
+func writeOutput(output string, force bool, write func(w io.Writer) error) error {
+	if output == "-" {
+		if stdout != nil {
+			return write(stdout)
+		}
+		return write(os.Stdout)
+	}
+
+	if !force {
+		if _, err := os.Stat(output); err == nil {
+			return fmt.Errorf("output file already exists: %s (use --force to overwrite)", output)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(output), "."+filepath.Base(output)+".*.partial")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/progress"
+)
+
+// TestSetupOutputQuiet 测试 --quiet 丢弃标准输出，但 writeOutput("-") 仍写入原来的标准输出
+func TestSetupOutputQuiet(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	orig := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = orig }()
+
+	defer func(q bool) { quiet = q }(quiet)
+	quiet = true
+	if err := setupOutput(rootCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("备份配置:")
+	if err := writeOutput("-", false, func(w io.Writer) error {
+		_, err := io.WriteString(w, "data")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	restoreOutput()
+
+	if os.Stdout != f {
+		t.Error("stdout was not restored")
+	}
+	data, _ := os.ReadFile(f.Name())
+	if string(data) != "data" {
+		t.Errorf("stdout = %q, want only the data output", data)
+	}
+}
+
+// TestNewProgressReporter 测试 NO_COLOR、--quiet 和非终端输出时不显示进度条
+func TestNewProgressReporter(t *testing.T) {
+	defer func(q, c bool) { quiet, noColor = q, c }(quiet, noColor)
+	quiet = false
+	t.Setenv("NO_COLOR", "1")
+	if err := setupOutput(rootCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !noColor {
+		t.Error("NO_COLOR did not enable --no-color")
+	}
+	if _, ok := newProgressReporter().(*progress.Silent); !ok {
+		t.Error("expected silent reporter with NO_COLOR")
+	}
+
+	// 测试中标准错误不是终端
+	noColor = false
+	if _, ok := newProgressReporter().(*progress.Silent); !ok {
+		t.Error("expected silent reporter when stderr is not a terminal")
+	}
+}
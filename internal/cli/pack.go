@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+var (
+	packOutput string
+	packForce  bool
+)
+
+// packCmd 本地打包命令
+var packCmd = &cobra.Command{
+	Use:   "pack [paths...]",
+	Short: "在本地打包（并加密）备份，不上传",
+	Long: `将指定路径打包压缩（并加密）写入本地文件，生成的文件与 backup 上传的对象格式完全一致，
+可以先保存到移动存储，之后再用其他工具上传，或使用 s3backup decrypt 解密。
+
+输出默认为当前目录下的 backup-{timestamp}.tar.gz[.enc]（zstd 压缩时为 .tar.zst），"-" 表示标准输出。
+输出文件名应以 .tar.gz、.tar.zst 或再加 .enc 结尾。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPack,
+}
+
+func init() {
+	rootCmd.AddCommand(packCmd)
+
+	packCmd.Flags().StringVarP(&packOutput, "output", "o", "", "输出文件（默认：backup-{timestamp}.tar.gz[.enc]，- 表示标准输出）")
+	packCmd.Flags().BoolVarP(&packForce, "force", "f", false, "覆盖已存在的输出文件")
+	packCmd.Flags().BoolP("encrypt", "e", false, "启用加密")
+	packCmd.Flags().String("password", "", "加密密码")
+	packCmd.Flags().String("key-file", "", "密钥文件")
+	packCmd.Flags().String("padding", "", "加密前填充备份以隐藏准确大小 (none/pow2/bucket)")
+	packCmd.Flags().Int64("padding-bucket", 0, "--padding bucket 时填充到该大小（字节）的整数倍")
+	packCmd.Flags().StringSlice("exclude", []string{}, "排除模式（可多次指定）")
+	packCmd.Flags().Bool("ignore-case", false, "排除模式不区分大小写")
+	packCmd.Flags().Bool("smart-compression", false, "不压缩已压缩的文件类型（jpg、mp4、zip、gz 等，按扩展名），节省 CPU")
+	packCmd.Flags().String("compression", "", "压缩格式 (gzip/zstd)")
+	packCmd.Flags().String("zstd-dictionary", "", "zstd 字典文件（由 train-dict 生成）")
+	packCmd.Flags().Int("parallel-roots", 0, "同时归档的包含路径数（多个包含路径位于不同磁盘时可加快归档）")
+	packCmd.Flags().Bool("xattrs", false, "在 tar 中记录扩展属性（如 setcap 设置的 capabilities、user.*），仅支持 Linux")
+	packCmd.Flags().Bool("selinux", false, "在 tar 中记录 SELinux 安全上下文（security.selinux），仅支持 Linux")
+	packCmd.Flags().Bool("include-devices", false, "归档字符设备、块设备和命名管道（记录设备号，不读取内容），而不是作为特殊文件跳过")
+	packCmd.Flags().Bool("strict", false, "遇到无法读取的文件、特殊文件（设备、管道、套接字）或目标不存在的符号链接时失败退出，而不是跳过")
+	packCmd.Flags().Int("max-warnings", 0, "每类跳过警告逐条显示的数量，超出的只显示汇总（默认 20，-1 显示全部）")
+	packCmd.Flags().Bool("allow-no-match", false, "包含路径中的通配符没有匹配时跳过而不是报错")
+}
+
+func runPack(cmd *cobra.Command, args []string) error {
+	startTime := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
+	defer cancel()
+
+	// 只使用加密和排除配置，不要求存储配置完整
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	includes, err := resolveIncludes(cfg, args)
+	if err != nil {
+		return fmt.Errorf("failed to resolve includes: %w", err)
+	}
+
+	output := packOutput
+	if output == "" {
+		output = defaultBackupName(startTime, compressionSuffix(cfg), cfg.Encryption.Enabled)
+	}
+
+	var written int64
+	var unmatched []string
+	meter := archive.NewCompressionMeter(0)
+	err = writeOutput(output, packForce, func(w io.Writer) error {
+		cw := &countingWriter{w: w}
+		arc, err := writeArchive(ctx, cw, cfg, includes, meter)
+		written = cw.n
+		if err != nil {
+			return err
+		}
+		unmatched = arc.UnmatchedExcludes()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := cmd.OutOrStdout()
+	if output == "-" {
+		msg = cmd.ErrOrStderr()
+	}
+	printUnmatchedExcludes(msg, unmatched, func(string) bool { return true })
+	printCompressionStats(msg, meter.Stats())
+	i18n.Fprintf(msg, "打包成功: %s（%d 字节，%d 个包含路径，加密: %v，耗时 %s）\n",
+		output, written, len(includes), cfg.Encryption.Enabled, time.Since(startTime).Round(time.Millisecond))
+	return nil
+}
+
+// countingWriter 统计写入字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
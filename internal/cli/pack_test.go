@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+)
+
+// TestPackRoundTrip 测试本地打包的加密文件可以离线解密并解压
+func TestPackRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "data")
+	os.MkdirAll(src, 0755)
+	content := bytes.Repeat([]byte("staged backup "), 1000)
+	if err := os.WriteFile(filepath.Join(src, "file.txt"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	keyData, _ := crypto.GenerateKeyFile()
+	keyPath := filepath.Join(dir, "key")
+	os.WriteFile(keyPath, keyData, 0600)
+
+	cfg := &config.Config{Encryption: config.EncryptionConfig{Enabled: true, KeyFile: keyPath}}
+	packed := filepath.Join(dir, "backup.tar.gz.enc")
+	err := writeOutput(packed, false, func(w io.Writer) error {
+		_, err := writeArchive(context.Background(), w, cfg, []string{src}, nil)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("pack failed: %v", err)
+	}
+
+	unpacked := filepath.Join(dir, "backup.tar.gz")
+	if _, _, err := decryptFile(packed, unpacked, crypto.KeySource{KeyFile: keyData}, false); err != nil {
+		t.Fatalf("decryptFile() error = %v", err)
+	}
+
+	f, err := os.Open(unpacked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			t.Fatal("file.txt not found in archive")
+		}
+		if err != nil {
+			t.Fatalf("tar.Next() error = %v", err)
+		}
+		if filepath.Base(hdr.Name) != "file.txt" {
+			continue
+		}
+		got, _ := io.ReadAll(tr)
+		if !bytes.Equal(got, content) {
+			t.Error("archived file content mismatch")
+		}
+		return
+	}
+}
+
+// TestWriteOutputFailure 测试写入失败时不留下输出文件
+func TestWriteOutputFailure(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out")
+
+	err := writeOutput(output, false, func(w io.Writer) error {
+		w.Write([]byte("partial"))
+		return io.ErrUnexpectedEOF
+	})
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected write error, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected no files left, got %d", len(entries))
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+)
+
+var (
+	pprofAddr   string
+	pprofServer *http.Server // --pprof 启动的 HTTP 服务，命令结束时关闭
+)
+
+// pprofMux 返回只包含 /debug/pprof/ 的路由，不使用 http.DefaultServeMux，避免暴露其他包注册的处理器
+func pprofMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// startPprof 在 --pprof 指定的地址上提供 pprof 接口
+// 监听失败只输出警告，不影响备份；端点没有认证，只应监听本机地址
+func startPprof() {
+	if pprofAddr == "" || pprofServer != nil {
+		return
+	}
+	ln, err := net.Listen("tcp", pprofAddr)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "警告: 启动 pprof 服务失败: %v\n", err)
+		return
+	}
+	srv := &http.Server{Handler: pprofMux(), ReadHeaderTimeout: 10 * time.Second}
+	pprofServer = srv
+	i18n.Fprintf(os.Stderr, "pprof 服务: http://%s/debug/pprof/\n", ln.Addr())
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			i18n.Fprintf(os.Stderr, "警告: pprof 服务异常退出: %v\n", err)
+		}
+	}()
+}
+
+// stopPprof 命令结束时关闭 pprof 服务
+func stopPprof() {
+	if pprofServer == nil {
+		return
+	}
+	pprofServer.Close()
+	pprofServer = nil
+}
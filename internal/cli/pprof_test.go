@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestPprofMux 测试 pprof 路由只提供 /debug/pprof/ 下的接口
+func TestPprofMux(t *testing.T) {
+	srv := httptest.NewServer(pprofMux())
+	defer srv.Close()
+
+	for path, want := range map[string]int{
+		"/debug/pprof/":          http.StatusOK,
+		"/debug/pprof/heap":      http.StatusOK,
+		"/debug/pprof/goroutine": http.StatusOK,
+		"/":                      http.StatusNotFound,
+	} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
+
+// TestStartPprof 测试 --pprof 启动和关闭服务，监听失败时不影响命令
+func TestStartPprof(t *testing.T) {
+	defer func() { pprofAddr = "" }()
+
+	pprofAddr = "127.0.0.1:0"
+	startPprof()
+	if pprofServer == nil {
+		t.Fatal("startPprof() did not start the server")
+	}
+	stopPprof()
+	if pprofServer != nil {
+		t.Error("stopPprof() did not reset the server")
+	}
+
+	pprofAddr = "invalid-address"
+	startPprof()
+	if pprofServer != nil {
+		t.Error("startPprof() with an invalid address should not start the server")
+	}
+}
@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// expiresMetaKey 备份到期时间（RFC 3339）的对象元数据键，由 backup --expire-after 写入
+const expiresMetaKey = "s3backup-expires"
+
+var (
+	prunePrefix       string
+	pruneMaxTotalSize int64
+	pruneHonorExpiry  bool
+)
+
+// pruneCmd 清理旧备份命令
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "清理旧备份",
+	Long: `按前缀列出存储桶中的备份，从最旧的开始删除，直到总大小不超过 --max-total-size。
+
+最新的备份始终保留，即使它本身已超过上限。归档等存储类型有最短存储时长，
+提前删除仍按最短时长计费。建议先使用 --dry-run 查看将要删除的备份。
+
+--honor-expiry 读取各备份对象元数据中由 backup --expire-after 记录的到期时间，只删除已到期的备份
+及其报告和签名，没有到期时间的备份不受影响。与 --max-total-size 同时使用时先删除到期的备份。`,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	addConfigFlags(pruneCmd)
+	pruneCmd.Flags().StringVar(&prunePrefix, "prefix", "backup-", "备份对象的 key 前缀")
+	pruneCmd.Flags().Int64Var(&pruneMaxTotalSize, "max-total-size", 0, "前缀下备份的总大小上限（字节）")
+	pruneCmd.Flags().BoolVar(&pruneHonorExpiry, "honor-expiry", false, "删除对象元数据中记录的到期时间已过的备份")
+	pruneCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只列出将要删除的备份，不实际删除")
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	if pruneMaxTotalSize < 0 || (pruneMaxTotalSize == 0 && !pruneHonorExpiry) {
+		return fmt.Errorf("--max-total-size must be positive (or use --honor-expiry)")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if err := requireReadAccess(cfg, "prune"); err != nil {
+		return err
+	}
+	adapter, err := createStorageAdapter(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create storage adapter: %w", err)
+	}
+	lister, ok := adapter.(storage.ObjectLister)
+	if !ok {
+		return fmt.Errorf("provider %s does not support listing objects", cfg.Storage.Provider)
+	}
+	deleter, ok := adapter.(storage.ObjectDeleter)
+	if !ok {
+		return fmt.Errorf("provider %s does not support deleting objects", cfg.Storage.Provider)
+	}
+
+	// 持有独占锁，避免删除其他机器上正在进行的备份所依赖的对象
+	if !dryRun {
+		lock, err := acquireLock(ctx, os.Stdout, adapter, path.Dir(prunePrefix), "prune", true, cfg.Storage.ACL)
+		if err != nil {
+			return err
+		}
+		defer lock.release(os.Stdout)
+	}
+
+	if pruneHonorExpiry {
+		meta, ok := adapter.(storage.MetadataReader)
+		if !ok {
+			return fmt.Errorf("provider %s does not support reading object metadata", cfg.Storage.Provider)
+		}
+		if err := pruneExpired(ctx, os.Stdout, lister, deleter, meta, prunePrefix, time.Now(), dryRun); err != nil {
+			return err
+		}
+	}
+	if pruneMaxTotalSize == 0 {
+		return nil
+	}
+	return pruneBySize(ctx, os.Stdout, lister, deleter, prunePrefix, pruneMaxTotalSize, dryRun)
+}
+
+// pruneExpired 删除 prefix 下元数据中的到期时间早于 now 的备份，连同以备份名为前缀的报告和签名
+// 没有到期时间的对象保留；dryRun 时只输出将要删除的备份
+func pruneExpired(ctx context.Context, w io.Writer, lister storage.ObjectLister, deleter storage.ObjectDeleter,
+	meta storage.MetadataReader, prefix string, now time.Time, dryRun bool) error {
+	objects, err := listPrunable(ctx, lister, prefix)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		keys[obj.Key] = true
+	}
+
+	expired := 0
+	for _, obj := range objects {
+		if isCompanionKey(obj.Key) {
+			continue
+		}
+		m, err := meta.ObjectMetadata(ctx, obj.Key)
+		if err != nil {
+			return fmt.Errorf("failed to read metadata of %s: %w", obj.Key, err)
+		}
+		value, ok := m[expiresMetaKey]
+		if !ok {
+			continue
+		}
+		expires, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			i18n.Fprintf(w, "警告: %s 的到期时间 %q 无法解析，保留\n", obj.Key, value)
+			continue
+		}
+		if expires.After(now) {
+			continue
+		}
+
+		expired++
+		when := expires.Local().Format("2006-01-02 15:04:05")
+		if dryRun {
+			i18n.Fprintf(w, "  将删除: %s（%s 到期）\n", obj.Key, when)
+			continue
+		}
+		if err := deleter.DeleteObject(ctx, obj.Key); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", obj.Key, err)
+		}
+		i18n.Fprintf(w, "  已删除: %s（%s 到期）\n", obj.Key, when)
+		for _, companion := range []string{obj.Key + reportSuffix, obj.Key + crypto.SignatureSuffix} {
+			if !keys[companion] {
+				continue
+			}
+			if err := deleter.DeleteObject(ctx, companion); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", companion, err)
+			}
+		}
+	}
+
+	switch {
+	case expired == 0:
+		i18n.Fprintf(w, "没有到期的备份\n")
+	case dryRun:
+		i18n.Fprintf(w, "模拟运行：将删除 %d 个到期的备份\n", expired)
+	default:
+		i18n.Fprintf(w, "已删除 %d 个到期的备份\n", expired)
+	}
+	return nil
+}
+
+// isCompanionKey 判断对象是否为随备份存放的报告或签名
+func isCompanionKey(key string) bool {
+	return strings.HasSuffix(key, reportSuffix) || strings.HasSuffix(key, crypto.SignatureSuffix)
+}
+
+// listPrunable 列出 prefix 下的对象，跳过锁对象、运行记录和 zstd 字典
+func listPrunable(ctx context.Context, lister storage.ObjectLister, prefix string) ([]storage.ObjectInfo, error) {
+	objects, err := lister.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	kept := objects[:0]
+	for _, obj := range objects {
+		if !isLockKey(obj.Key) && !isRunRecordKey(obj.Key) && !isDictKey(obj.Key) {
+			kept = append(kept, obj)
+		}
+	}
+	return kept, nil
+}
+
+// pruneBySize 从最旧的备份开始删除，直到 prefix 下的总大小不超过 maxTotal
+// 最新的备份始终保留；dryRun 时只输出将要删除的备份
+func pruneBySize(ctx context.Context, w io.Writer, lister storage.ObjectLister, deleter storage.ObjectDeleter,
+	prefix string, maxTotal int64, dryRun bool) error {
+	objects, err := listPrunable(ctx, lister, prefix)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, obj := range objects {
+		total += obj.Size
+	}
+	i18n.Fprintf(w, "前缀 %q 下共 %d 个备份，总大小 %.2f GB，上限 %.2f GB\n",
+		prefix, len(objects), float64(total)/(1<<30), float64(maxTotal)/(1<<30))
+
+	remove := selectPruneBySize(objects, maxTotal)
+	if len(remove) == 0 {
+		i18n.Fprintf(w, "无需清理\n")
+		return nil
+	}
+
+	var freed int64
+	for _, obj := range remove {
+		if dryRun {
+			i18n.Fprintf(w, "  将删除: %s (%.2f MB, %s)\n", obj.Key, float64(obj.Size)/(1<<20), obj.LastModified.Local().Format("2006-01-02 15:04:05"))
+			freed += obj.Size
+			continue
+		}
+		if err := deleter.DeleteObject(ctx, obj.Key); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", obj.Key, err)
+		}
+		i18n.Fprintf(w, "  已删除: %s (%.2f MB)\n", obj.Key, float64(obj.Size)/(1<<20))
+		freed += obj.Size
+	}
+
+	if dryRun {
+		i18n.Fprintf(w, "模拟运行：将删除 %d 个备份，释放 %.2f GB\n", len(remove), float64(freed)/(1<<30))
+	} else {
+		i18n.Fprintf(w, "已删除 %d 个备份，释放 %.2f GB\n", len(remove), float64(freed)/(1<<30))
+	}
+	if total-freed > maxTotal {
+		i18n.Fprintf(w, "警告: 最新的备份已超过上限，保留后总大小仍为 %.2f GB\n", float64(total-freed)/(1<<30))
+	}
+	return nil
+}
+
+// selectPruneBySize 按修改时间从旧到新选择要删除的对象，直到剩余总大小不超过 maxTotal
+// 最新的对象不会被选中
+func selectPruneBySize(objects []storage.ObjectInfo, maxTotal int64) []storage.ObjectInfo {
+	sorted := append([]storage.ObjectInfo(nil), objects...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].LastModified.Equal(sorted[j].LastModified) {
+			return sorted[i].Key < sorted[j].Key
+		}
+		return sorted[i].LastModified.Before(sorted[j].LastModified)
+	})
+
+	var total int64
+	for _, obj := range sorted {
+		total += obj.Size
+	}
+
+	var remove []storage.ObjectInfo
+	for i := 0; i < len(sorted)-1 && total > maxTotal; i++ {
+		remove = append(remove, sorted[i])
+		total -= sorted[i].Size
+	}
+	return remove
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestPruneBySize 测试从最旧的备份开始删除，直到总大小不超过上限
+func TestPruneBySize(t *testing.T) {
+	adapter := mock.New()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, key := range []string{"backup-1", "backup-2", "backup-3", "backup-4"} {
+		adapter.SetObject(key, &mock.Object{Data: make([]byte, 100), LastModified: base.Add(time.Duration(i) * time.Hour)})
+	}
+	adapter.SetObject("other", &mock.Object{Data: make([]byte, 1000), LastModified: base.Add(-time.Hour)})
+
+	ctx := context.Background()
+	var buf bytes.Buffer
+	if err := pruneBySize(ctx, &buf, adapter, adapter, "backup-", 250, true); err != nil {
+		t.Fatalf("dry run error = %v", err)
+	}
+	if adapter.Calls(mock.OpDeleteObject) != 0 {
+		t.Error("dry run should not delete objects")
+	}
+
+	if err := pruneBySize(ctx, &buf, adapter, adapter, "backup-", 250, false); err != nil {
+		t.Fatalf("pruneBySize() error = %v", err)
+	}
+	for key, want := range map[string]bool{"backup-1": false, "backup-2": false, "backup-3": true, "backup-4": true, "other": true} {
+		if _, ok := adapter.Object(key); ok != want {
+			t.Errorf("%s exists = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+// TestPruneBySizeKeepsNewest 测试最新的备份超过上限时仍然保留
+func TestPruneBySizeKeepsNewest(t *testing.T) {
+	adapter := mock.New()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	adapter.SetObject("backup-old", &mock.Object{Data: make([]byte, 10), LastModified: base})
+	adapter.SetObject("backup-new", &mock.Object{Data: make([]byte, 500), LastModified: base.Add(time.Hour)})
+
+	var buf bytes.Buffer
+	if err := pruneBySize(context.Background(), &buf, adapter, adapter, "backup-", 100, false); err != nil {
+		t.Fatalf("pruneBySize() error = %v", err)
+	}
+	if _, ok := adapter.Object("backup-new"); !ok {
+		t.Error("newest backup should be kept")
+	}
+	if _, ok := adapter.Object("backup-old"); ok {
+		t.Error("old backup should be deleted")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("警告")) {
+		t.Errorf("expected warning, got %q", buf.String())
+	}
+}
+
+// TestPruneExpired 测试只删除元数据中已到期的备份及其报告和签名
+func TestPruneExpired(t *testing.T) {
+	adapter := mock.New()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	expires := func(t time.Time) map[string]string {
+		return map[string]string{expiresMetaKey: t.Format(time.RFC3339)}
+	}
+	adapter.SetObject("backup-expired", &mock.Object{Metadata: expires(now.Add(-time.Hour))})
+	adapter.SetObject("backup-expired"+reportSuffix, &mock.Object{})
+	adapter.SetObject("backup-expired"+crypto.SignatureSuffix, &mock.Object{})
+	adapter.SetObject("backup-valid", &mock.Object{Metadata: expires(now.Add(time.Hour))})
+	adapter.SetObject("backup-valid"+reportSuffix, &mock.Object{})
+	adapter.SetObject("backup-forever", &mock.Object{})
+	adapter.SetObject("backup-bad", &mock.Object{Metadata: map[string]string{expiresMetaKey: "soon"}})
+
+	ctx := context.Background()
+	var buf bytes.Buffer
+	if err := pruneExpired(ctx, &buf, adapter, adapter, adapter, "backup-", now, true); err != nil {
+		t.Fatalf("dry run error = %v", err)
+	}
+	if adapter.Calls(mock.OpDeleteObject) != 0 {
+		t.Error("dry run should not delete objects")
+	}
+
+	if err := pruneExpired(ctx, &buf, adapter, adapter, adapter, "backup-", now, false); err != nil {
+		t.Fatalf("pruneExpired() error = %v", err)
+	}
+	for key, want := range map[string]bool{
+		"backup-expired":                          false,
+		"backup-expired" + reportSuffix:           false,
+		"backup-expired" + crypto.SignatureSuffix: false,
+		"backup-valid":                            true,
+		"backup-valid" + reportSuffix:             true,
+		"backup-forever":                          true,
+		"backup-bad":                              true,
+	} {
+		if _, ok := adapter.Object(key); ok != want {
+			t.Errorf("%s exists = %v, want %v", key, ok, want)
+		}
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("backup-bad")) {
+		t.Errorf("expected a warning for the unparsable expiry, got %q", buf.String())
+	}
+}
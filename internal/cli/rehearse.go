@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rehearseLocal   bool
+	rehearseDir     string
+	rehearseMaxSize int64
+)
+
+// ErrRehearsal 还原演练得到的文件与备份报告不一致
+var ErrRehearsal = errors.New("restore rehearsal failed")
+
+// rehearseCmd 还原演练命令
+var rehearseCmd = &cobra.Command{
+	Use:   "rehearse <backup>",
+	Short: "将备份完整还原到临时目录并抽查文件，确认备份可以恢复",
+	Long: `下载备份并完整还原到临时目录，与备份报告 <backup>.report.json 比较后删除还原结果。
+
+检查内容：
+  - 备份能完整下载、解密并解压，加密备份的 HMAC 校验通过
+  - 还原出的文件数与报告一致
+  - 报告中随机抽样记录的文件（备份时启用 backup.report）SHA-256 一致
+
+临时目录默认位于系统临时目录，可用 --dir 指定（如 /dev/shm 使用内存文件系统），
+--max-size 限制还原的数据量，超出时中止演练，避免占满磁盘或内存。
+演练时不还原扩展属性，也不需要 root 权限。`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRehearse,
+}
+
+func init() {
+	rootCmd.AddCommand(rehearseCmd)
+
+	addConfigFlags(rehearseCmd)
+	addRestoreFlags(rehearseCmd)
+	rehearseCmd.Flags().BoolVar(&rehearseLocal, "local", false, "演练本地的备份文件，报告为同目录下的 <file>.report.json")
+	rehearseCmd.Flags().StringVar(&rehearseDir, "dir", "", "在该目录下创建临时目录（默认为系统临时目录）")
+	rehearseCmd.Flags().Int64Var(&rehearseMaxSize, "max-size", 0, "还原的文件内容总字节数上限，超出时中止演练（0 表示不限制）")
+}
+
+func runRehearse(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
+	defer cancel()
+
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	data, reader, err := openBackup(ctx, cfg, name, rehearseLocal)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	report, err := loadReport(ctx, reader, name)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		i18n.Printf("警告: 没有找到备份报告 %s，只检查备份能否完整还原\n", name+reportSuffix)
+	}
+
+	dest, err := os.MkdirTemp(rehearseDir, "s3backup-rehearse-*")
+	if err != nil {
+		return fmt.Errorf("failed to create rehearsal dir: %w", err)
+	}
+	defer os.RemoveAll(dest)
+	i18n.Printf("还原到临时目录: %s\n", dest)
+
+	started := time.Now()
+	stats, err := restoreArchive(ctx, data, name, dest, cfg, archive.ExtractOptions{
+		MaxBytes: rehearseMaxSize,
+		Workers:  cfg.Restore.DecompressWorkers,
+
+		Dictionary: dictionaryLoader(ctx, cfg, reader, name),
+	})
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(started)
+
+	checked, err := checkRehearsal(dest, stats, report)
+	if err != nil {
+		return err
+	}
+	i18n.Printf("还原演练通过: %s\n", name)
+	i18n.Printf("  还原 %d 个文件，%d 字节，用时 %s\n", stats.Files, stats.Bytes, elapsed.Round(time.Second))
+	if checked > 0 {
+		i18n.Printf("  抽查 %d 个文件的 SHA-256 一致\n", checked)
+	}
+	return nil
+}
+
+// loadReport 读取备份报告：本地模式（reader 为 nil）读取 <name>.report.json 文件，否则从存储桶下载
+// 报告不存在时返回 nil
+func loadReport(ctx context.Context, reader storage.ObjectReader, name string) (*backupReport, error) {
+	var data []byte
+	var err error
+	if reader == nil {
+		data, err = os.ReadFile(name + reportSuffix)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+	} else {
+		data, err = readObject(ctx, reader, name+reportSuffix)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup report: %w", err)
+	}
+
+	var report backupReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse backup report: %w", err)
+	}
+	return &report, nil
+}
+
+// checkRehearsal 比较还原结果与备份报告：文件数一致，抽样文件的 SHA-256 一致；返回抽查的文件数
+func checkRehearsal(dest string, stats *archive.ExtractStats, report *backupReport) (int, error) {
+	if report == nil {
+		return 0, nil
+	}
+	if stats.Files != report.Files {
+		return 0, fmt.Errorf("%w: restored %d files, backup report records %d", ErrRehearsal, stats.Files, report.Files)
+	}
+
+	failed := 0
+	for _, sample := range report.FileSamples {
+		path := filepath.Join(dest, filepath.FromSlash(strings.TrimLeft(sample.Path, "/")))
+		sum, err := fileSHA256(path)
+		switch {
+		case err != nil:
+			i18n.Printf("[失败] %s: %v\n", sample.Path, err)
+			failed++
+		case sum != sample.SHA256:
+			i18n.Printf("[失败] %s: SHA-256 与备份报告不一致\n", sample.Path)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return 0, fmt.Errorf("%w: %d of %d sampled files do not match the backup report", ErrRehearsal, failed, len(report.FileSamples))
+	}
+	return len(report.FileSamples), nil
+}
+
+// fileSHA256 返回文件内容的十六进制 SHA-256
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
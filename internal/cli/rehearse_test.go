@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestRehearsal 测试还原演练按备份报告检查文件数和抽样文件的 SHA-256，还原结果被修改时失败
+func TestRehearsal(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(src, 0755)
+	for i := range 5 {
+		os.WriteFile(filepath.Join(src, fmt.Sprintf("f%d.txt", i)), []byte(fmt.Sprintf("file %d", i)), 0644)
+	}
+
+	defer func(n bool) { noProgress = n }(noProgress)
+	noProgress = true
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{Provider: "aws", Bucket: "bucket"},
+		Backup:  config.BackupConfig{Report: true},
+		State:   config.StateConfig{Dir: filepath.Join(dir, "state")},
+	}
+	adapter := mock.New()
+	name := "backup-20260101-000000.tar.gz"
+	report := newBackupReport(cfg, name)
+	err := backupOnce(context.Background(), cfg, adapter, name, report, func(ctx context.Context, w io.Writer, meter *archive.CompressionMeter) error {
+		arc, err := writeArchive(ctx, w, cfg, []string{src}, meter)
+		report.setArchive([]string{src}, nil, arc)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("backupOnce() error = %v", err)
+	}
+
+	ctx := context.Background()
+	loaded, err := loadReport(ctx, adapter, name)
+	if err != nil || loaded == nil {
+		t.Fatalf("loadReport() = %v, %v", loaded, err)
+	}
+	if len(loaded.FileSamples) != 5 {
+		t.Fatalf("expected all 5 files to be sampled, got %+v", loaded.FileSamples)
+	}
+	if missing, err := loadReport(ctx, adapter, "missing.tar.gz"); missing != nil || err != nil {
+		t.Errorf("loadReport() for a missing report = %v, %v, want nil, nil", missing, err)
+	}
+
+	obj, _ := adapter.Object(name)
+	dest := t.TempDir()
+	stats, err := restoreArchive(ctx, bytes.NewReader(obj.Data), name, dest, cfg, archive.ExtractOptions{})
+	if err != nil {
+		t.Fatalf("restoreArchive() error = %v", err)
+	}
+	if checked, err := checkRehearsal(dest, stats, loaded); err != nil || checked != 5 {
+		t.Fatalf("checkRehearsal() = %d, %v", checked, err)
+	}
+
+	os.WriteFile(filepath.Join(dest, src, "f3.txt"), []byte("changed"), 0644)
+	if _, err := checkRehearsal(dest, stats, loaded); !errors.Is(err, ErrRehearsal) {
+		t.Errorf("expected ErrRehearsal for a modified file, got %v", err)
+	}
+
+	short := *stats
+	short.Files--
+	if _, err := checkRehearsal(dest, &short, loaded); !errors.Is(err, ErrRehearsal) {
+		t.Errorf("expected ErrRehearsal for a missing file, got %v", err)
+	}
+}
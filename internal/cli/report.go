@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/uploader"
+	"github.com/lukelzlz/s3backup/pkg/version"
+)
+
+// reportSuffix 备份报告对象的后缀，报告与备份同名存放
+const reportSuffix = ".report.json"
+
+// reportFileSamples 报告中记录 SHA-256 的随机抽样文件数，供 rehearse 还原演练抽查
+const reportFileSamples = 100
+
+// backupReport 随备份上传的 <backup>.report.json，记录备份是如何生成的，供恢复端和审计查看
+type backupReport struct {
+	Backup   string    `json:"backup"`
+	Tool     string    `json:"tool"`
+	Version  string    `json:"version"`
+	Host     string    `json:"host,omitempty"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Duration float64   `json:"duration_seconds"`
+
+	Provider     string `json:"provider"`
+	Bucket       string `json:"bucket"`
+	StorageClass string `json:"storage_class,omitempty"`
+	Encrypted    bool   `json:"encrypted"`
+	Compression  string `json:"compression"`
+
+	// 备份内容：文件备份记录 includes/excludes，数据库和 Docker 卷备份记录 source
+	Source   string   `json:"source,omitempty"`
+	Includes []string `json:"includes,omitempty"`
+	Excludes []string `json:"excludes,omitempty"`
+	Files    int      `json:"files"`
+	Bytes    int64    `json:"bytes"`
+
+	// 随机抽样的文件及其 SHA-256，还原演练时与还原出的文件比较
+	FileSamples []archive.FileHash `json:"file_samples,omitempty"`
+
+	// 压缩统计，数据由外部工具压缩（如 Docker 辅助容器）时为空
+	RawBytes          int64     `json:"raw_bytes,omitempty"`               // 压缩前的字节数
+	CompressionRatio  float64   `json:"compression_ratio,omitempty"`       // 压缩后与压缩前之比
+	PartCompressRatio []float64 `json:"part_compression_ratios,omitempty"` // 按分块大小划分的各段压缩比
+
+	// 上传的对象
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+
+	// 上传对象按 ChunkSize 划分的各段 SHA-256，供 verify --sample 抽样校验
+	ChunkSize   int64    `json:"chunk_size,omitempty"`
+	ChunkSHA256 []string `json:"chunk_sha256,omitempty"`
+
+	Signature string `json:"signature,omitempty"` // 分离签名对象，未签名时为空
+
+	Skipped  []archive.SkippedFile `json:"skipped"`
+	Warnings []string              `json:"warnings"`
+}
+
+// newBackupReport 创建备份报告，未启用 backup.report 时返回 nil
+func newBackupReport(cfg *config.Config, name string) *backupReport {
+	if !cfg.Backup.Report {
+		return nil
+	}
+	host, _ := os.Hostname()
+	return &backupReport{
+		Backup:       name,
+		Tool:         "s3backup",
+		Version:      version.Version,
+		Host:         host,
+		Provider:     cfg.Storage.Provider,
+		Bucket:       cfg.Storage.Bucket,
+		StorageClass: cfg.Storage.StorageClass,
+		Encrypted:    cfg.Encryption.Enabled,
+		Compression:  compressionName(cfg),
+		Skipped:      []archive.SkippedFile{},
+		Warnings:     []string{},
+	}
+}
+
+// setArchive 记录文件备份的路径、统计、跳过的文件和没有匹配的排除模式
+// excludes 为用户配置的排除模式，按存储类型规则自动添加的模式不计入
+func (r *backupReport) setArchive(includes, excludes []string, arc *archive.Archiver) {
+	if r == nil || arc == nil {
+		return
+	}
+	r.Includes = includes
+	r.Excludes = excludes
+	r.Files, r.Bytes = arc.Stats()
+	r.FileSamples = arc.FileSamples()
+	r.Skipped = append(r.Skipped, arc.Skipped()...)
+
+	user := make(map[string]bool, len(excludes))
+	for _, p := range excludes {
+		user[p] = true
+	}
+	for _, p := range arc.UnmatchedExcludes() {
+		if user[p] {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("exclude pattern matched nothing: %s", p))
+		}
+	}
+}
+
+// setCompression 记录压缩前后的字节数和各分块的压缩比
+func (r *backupReport) setCompression(stats archive.CompressionStats) {
+	if r == nil || stats.Raw == 0 {
+		return
+	}
+	r.RawBytes = stats.Raw
+	r.CompressionRatio = stats.Ratio()
+	r.PartCompressRatio = make([]float64, len(stats.Parts))
+	for i, p := range stats.Parts {
+		r.PartCompressRatio[i] = p.Ratio()
+	}
+}
+
+// setSource 记录数据库或 Docker 卷等非文件数据源
+func (r *backupReport) setSource(source string) {
+	if r == nil {
+		return
+	}
+	r.Source = source
+}
+
+// setSignature 记录签名对象
+func (r *backupReport) setSignature(key string) {
+	if r == nil {
+		return
+	}
+	r.Signature = key
+}
+
+// uploadSignature 对备份对象的 SHA-256 签名并上传为 <name>.sig，返回签名对象名
+func uploadSignature(ctx context.Context, adapter storage.StorageAdapter, name string, key ed25519.PrivateKey, digest []byte, acl string) (string, error) {
+	data, err := crypto.SignDigest(key, digest).Marshal()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode signature: %w", err)
+	}
+	sigKey := name + crypto.SignatureSuffix
+	if err := putSmallObject(ctx, adapter, sigKey, "application/json", data, acl); err != nil {
+		return "", fmt.Errorf("failed to upload signature: %w", err)
+	}
+	return sigKey, nil
+}
+
+// sampleChunkSize 报告中记录分段 SHA-256 的分段大小
+const sampleChunkSize = 16 << 20
+
+// hashingWriter 计算写入数据的 SHA-256、字节数，以及每 sampleChunkSize 字节一段的 SHA-256
+type hashingWriter struct {
+	w io.Writer
+	h hash.Hash
+	n int64
+
+	chunk  hash.Hash
+	chunks []string
+}
+
+func newHashingWriter(w io.Writer) *hashingWriter {
+	return &hashingWriter{w: w, h: sha256.New(), chunk: sha256.New()}
+}
+
+func (hw *hashingWriter) Write(p []byte) (int, error) {
+	n, err := hw.w.Write(p)
+	hw.h.Write(p[:n])
+	for b := p[:n]; len(b) > 0; {
+		k := min(int64(len(b)), sampleChunkSize-hw.n%sampleChunkSize)
+		hw.chunk.Write(b[:k])
+		hw.n += k
+		b = b[k:]
+		if hw.n%sampleChunkSize == 0 {
+			hw.chunks = append(hw.chunks, hex.EncodeToString(hw.chunk.Sum(nil)))
+			hw.chunk.Reset()
+		}
+	}
+	return n, err
+}
+
+// Chunks 返回各分段的十六进制 SHA-256，最后一段可能不足 sampleChunkSize
+func (hw *hashingWriter) Chunks() []string {
+	if hw.n%sampleChunkSize == 0 {
+		return hw.chunks
+	}
+	return append(hw.chunks[:len(hw.chunks):len(hw.chunks)], hex.EncodeToString(hw.chunk.Sum(nil)))
+}
+
+// Digest 返回 SHA-256 摘要
+func (hw *hashingWriter) Digest() []byte {
+	return hw.h.Sum(nil)
+}
+
+// Sum 返回十六进制的 SHA-256
+func (hw *hashingWriter) Sum() string {
+	return hex.EncodeToString(hw.Digest())
+}
+
+// uploadReport 将报告上传为 <backup>.report.json
+// 报告很小且需要随时可读，使用默认存储类型而不是备份的存储类型
+func uploadReport(ctx context.Context, adapter storage.StorageAdapter, report *backupReport, acl string) (string, error) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	key := report.Backup + reportSuffix
+	if err := putSmallObject(ctx, adapter, key, "application/json", data, acl); err != nil {
+		return "", fmt.Errorf("failed to upload report: %w", err)
+	}
+	return key, nil
+}
+
+// putSmallObject 上传报告、签名等随备份存放的小对象，使用默认存储类型
+func putSmallObject(ctx context.Context, adapter storage.StorageAdapter, key, contentType string, data []byte, acl string) error {
+	upl := uploader.NewUploader(adapter, 0, 1)
+	upl.SetTotalBytes(int64(len(data)))
+	opts := storage.UploadOptions{
+		ContentType:        contentType,
+		ContentDisposition: storage.ContentDispositionFor(key),
+		ACL:                acl,
+	}
+	return upl.Upload(ctx, key, bytes.NewReader(data), opts)
+}
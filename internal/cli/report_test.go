@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+	"github.com/lukelzlz/s3backup/pkg/version"
+)
+
+// TestBackupReport 测试备份报告随备份上传（使用相同的 ACL），并记录对象哈希、统计和警告
+func TestBackupReport(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(src, 0755)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("hello"), 0644)
+	os.WriteFile(filepath.Join(src, "b.log"), []byte("world!"), 0644)
+
+	defer func(n bool) { noProgress = n }(noProgress)
+	noProgress = true
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{Provider: "aws", Bucket: "bucket", ACL: "bucket-owner-full-control"},
+		Backup: config.BackupConfig{
+			Excludes: []string{"*.log", "node_module/**"},
+			Report:   true,
+		},
+		State: config.StateConfig{Dir: filepath.Join(dir, "state")},
+	}
+	adapter := mock.New()
+	name := "backup-20260101-000000.tar.gz"
+	includes := []string{src}
+
+	report := newBackupReport(cfg, name)
+	err := backupOnce(context.Background(), cfg, adapter, name, report, func(ctx context.Context, w io.Writer, meter *archive.CompressionMeter) error {
+		arc, err := writeArchive(ctx, w, cfg, includes, meter)
+		if err != nil {
+			return err
+		}
+		report.setArchive(includes, cfg.Backup.Excludes, arc)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("backupOnce() error = %v", err)
+	}
+
+	obj, ok := adapter.Object(name)
+	if !ok {
+		t.Fatal("backup object not uploaded")
+	}
+	reportObj, ok := adapter.Object(name + reportSuffix)
+	if !ok {
+		t.Fatal("report object not uploaded")
+	}
+	if obj.ACL != cfg.Storage.ACL || reportObj.ACL != cfg.Storage.ACL {
+		t.Errorf("ACL = %q/%q, want %q", obj.ACL, reportObj.ACL, cfg.Storage.ACL)
+	}
+
+	var got backupReport
+	if err := json.Unmarshal(reportObj.Data, &got); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	sum := sha256.Sum256(obj.Data)
+	if got.SHA256 != hex.EncodeToString(sum[:]) || got.Size != int64(len(obj.Data)) {
+		t.Errorf("report hash/size = %s/%d, want %x/%d", got.SHA256, got.Size, sum, len(obj.Data))
+	}
+	if got.Backup != name || got.Version != version.Version || got.Files != 1 || got.Bytes != 5 {
+		t.Errorf("unexpected report summary: %+v", got)
+	}
+	if len(got.Warnings) != 1 {
+		t.Errorf("expected 1 warning for node_module/**, got %q", got.Warnings)
+	}
+	// tar 流按 512 字节对齐，压缩后远小于原始大小
+	if got.RawBytes < 512 || got.CompressionRatio <= 0 || got.CompressionRatio >= 1 {
+		t.Errorf("unexpected compression stats: raw %d, ratio %v", got.RawBytes, got.CompressionRatio)
+	}
+}
+
+// TestNewBackupReportDisabled 测试未启用报告时不生成报告
+func TestNewBackupReportDisabled(t *testing.T) {
+	report := newBackupReport(&config.Config{}, "backup.tar.gz")
+	if report != nil {
+		t.Fatal("expected nil report when backup.report is disabled")
+	}
+	// nil 报告上的方法应为空操作
+	report.setSource("postgres://db")
+	report.setArchive(nil, nil, nil)
+}
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/downloader"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreLocal          bool
+	restoreNoXattrs       bool
+	restoreNoACLs         bool
+	restoreNoCapabilities bool
+	restoreNoSELinux      bool
+)
+
+// restoreCmd 还原备份命令
+var restoreCmd = &cobra.Command{
+	Use:   "restore <backup> <dir>",
+	Short: "下载备份并还原到本地目录",
+	Long: `下载存储桶中的备份，解密（.enc 后缀）并解压到 <dir>。使用 --local 还原本地的备份文件。
+
+归档中的绝对路径去掉开头的 /，还原到 <dir> 下对应的位置，已存在的同名文件会被覆盖；
+包含 .. 或经过符号链接指向 <dir> 之外的路径会导致还原失败。
+
+备份时使用 --xattrs、--selinux 记录的扩展属性默认一并还原：
+  - POSIX ACL 和 user.* 扩展属性
+  - capabilities（security.capability）、SELinux 上下文和 trusted.* 等属性需要以 root 运行，否则跳过并输出警告
+可以分别用 --no-xattrs、--no-acls、--no-capabilities、--no-selinux 关闭。
+
+加密备份的 HMAC 在读完全部数据后才校验，校验失败时以错误退出，此前还原的文件不可信。
+
+默认以 4 个并发请求分段下载（每段 8MB），可以用 --download-concurrency、--download-chunk-size 调整；
+小文件很多时使用 --decompress-workers 让多个 worker 并行写入文件。`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+
+	addConfigFlags(restoreCmd)
+	addRestoreFlags(restoreCmd)
+	restoreCmd.Flags().BoolVar(&restoreLocal, "local", false, "还原本地的备份文件而不是存储桶中的对象")
+	restoreCmd.Flags().BoolVar(&restoreNoXattrs, "no-xattrs", false, "不还原 user.*、trusted.* 等扩展属性")
+	restoreCmd.Flags().BoolVar(&restoreNoACLs, "no-acls", false, "不还原 POSIX ACL")
+	restoreCmd.Flags().BoolVar(&restoreNoCapabilities, "no-capabilities", false, "不还原 capabilities（security.capability）")
+	restoreCmd.Flags().BoolVar(&restoreNoSELinux, "no-selinux", false, "不还原 SELinux 上下文")
+}
+
+// addRestoreFlags 添加下载和解压的调优参数，restore 和 rehearse 共用
+func addRestoreFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("download-concurrency", 0, "并发下载的分段数（默认 4），1 表示用单个请求顺序下载")
+	cmd.Flags().Int64("download-chunk-size", 0, "每个下载分段的大小（字节，默认 8MB，至少 1MB）")
+	cmd.Flags().Int("decompress-workers", 0, "解压时并行写入文件的 worker 数（默认 1），小文件多、磁盘延迟高时调大")
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	name, dest := args[0], args[1]
+	ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
+	defer cancel()
+
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	data, reader, err := openBackup(ctx, cfg, name, restoreLocal)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	stats, err := restoreArchive(ctx, data, name, dest, cfg, archive.ExtractOptions{
+		Xattrs:       !restoreNoXattrs,
+		ACLs:         !restoreNoACLs,
+		Capabilities: !restoreNoCapabilities,
+		SELinux:      !restoreNoSELinux,
+		Workers:      cfg.Restore.DecompressWorkers,
+
+		Dictionary: dictionaryLoader(ctx, cfg, reader, name),
+	})
+	if err != nil {
+		return err
+	}
+	i18n.Printf("还原完成: %s -> %s（%d 个文件，%d 字节）\n", name, dest, stats.Files, stats.Bytes)
+	if n := len(stats.Skipped); n > 0 {
+		i18n.Printf("警告: 跳过了 %d 个无法还原的条目\n", n)
+	}
+	return nil
+}
+
+// openBackup 打开要还原的备份，local 为 true 时打开本地文件，否则从存储桶下载
+// 返回的 ObjectReader 用于下载备份报告等随备份存放的对象，本地模式下为 nil
+func openBackup(ctx context.Context, cfg *config.Config, name string, local bool) (io.ReadCloser, storage.ObjectReader, error) {
+	if local {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open backup: %w", err)
+		}
+		return f, nil, nil
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid config: %w", err)
+	}
+	adapter, err := createRestoreAdapter(ctx, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create storage adapter: %w", err)
+	}
+	reader, ok := adapter.(storage.ObjectReader)
+	if !ok {
+		return nil, nil, fmt.Errorf("provider %s does not support downloading objects", cfg.Storage.Provider)
+	}
+	data, err := downloadBackup(ctx, cfg, adapter, reader, name)
+	if errors.Is(err, storage.ErrObjectArchived) {
+		return nil, nil, fmt.Errorf("failed to download backup: %w (run \"s3backup thaw %s\" first)", err, name)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download backup: %w", err)
+	}
+	return data, reader, nil
+}
+
+// downloadBackup 下载备份，restore.download_concurrency 大于 1 时分段并发下载
+// 并发下载需要从对象列表中获得对象大小，存储不支持分段下载或列出对象（如只有读权限）时用单个请求下载
+func downloadBackup(ctx context.Context, cfg *config.Config, adapter storage.StorageAdapter, reader storage.ObjectReader, name string) (io.ReadCloser, error) {
+	ranges, ok := adapter.(storage.RangeReader)
+	if !ok || cfg.Restore.DownloadConcurrency <= 1 {
+		return reader.GetObject(ctx, name)
+	}
+	size, ok := objectSize(ctx, adapter, name)
+	if !ok {
+		return reader.GetObject(ctx, name)
+	}
+
+	// 先读到第一个分段，使对象已归档、没有权限等错误在开始解压前返回
+	dl := downloader.NewReader(ctx, ranges, name, size, cfg.Restore.DownloadChunkSize, cfg.Restore.DownloadConcurrency)
+	buffered := bufio.NewReader(dl)
+	if _, err := buffered.Peek(1); err != nil && err != io.EOF {
+		dl.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{buffered, dl}, nil
+}
+
+// objectSize 从对象列表中查找 name 的大小，无法列出对象或没有找到时返回 false
+func objectSize(ctx context.Context, adapter storage.StorageAdapter, name string) (int64, bool) {
+	lister, ok := adapter.(storage.ObjectLister)
+	if !ok {
+		return 0, false
+	}
+	objects, err := lister.ListObjects(ctx, name)
+	if err != nil {
+		return 0, false
+	}
+	for _, obj := range objects {
+		if obj.Key == name {
+			return obj.Size, true
+		}
+	}
+	return 0, false
+}
+
+// restoreArchive 将备份 r 解压到 dest，name 以 .enc 结尾时先解密
+// 解压完成后读完剩余的数据，使加密备份的 HMAC 得到校验
+func restoreArchive(ctx context.Context, r io.Reader, name, dest string, cfg *config.Config, opts archive.ExtractOptions) (*archive.ExtractStats, error) {
+	if strings.HasSuffix(name, ".enc") {
+		keys, err := decryptKeys(cfg)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, _, err := crypto.OpenReader(r, keys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open encrypted backup: %w", err)
+		}
+		r = plaintext
+	}
+
+	stats, err := archive.Extract(ctx, r, dest, opts)
+	if err != nil {
+		return stats, fmt.Errorf("failed to extract: %w", err)
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return stats, fmt.Errorf("failed to verify backup: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestRestoreArchiveEncrypted 测试加密备份解密还原后内容一致，数据被修改时还原失败
+func TestRestoreArchiveEncrypted(t *testing.T) {
+	src := t.TempDir()
+	os.WriteFile(filepath.Join(src, "data.txt"), bytes.Repeat([]byte("restore me\n"), 1000), 0644)
+
+	cfg := &config.Config{Encryption: config.EncryptionConfig{Enabled: true, Password: "secret"}}
+	var buf bytes.Buffer
+	if _, err := writeArchive(context.Background(), &buf, cfg, []string{src}, nil); err != nil {
+		t.Fatalf("writeArchive() error = %v", err)
+	}
+	encrypted := buf.Bytes()
+
+	dest := t.TempDir()
+	stats, err := restoreArchive(context.Background(), bytes.NewReader(encrypted), "backup.tar.gz.enc", dest, cfg, archive.ExtractOptions{})
+	if err != nil {
+		t.Fatalf("restoreArchive() error = %v", err)
+	}
+	if stats.Files != 1 {
+		t.Errorf("restored %d files, want 1", stats.Files)
+	}
+	data, err := os.ReadFile(filepath.Join(dest, src, "data.txt"))
+	if err != nil || !bytes.Equal(data, bytes.Repeat([]byte("restore me\n"), 1000)) {
+		t.Fatalf("restored content mismatch: %v", err)
+	}
+
+	tampered := append([]byte(nil), encrypted...)
+	tampered[len(tampered)-1] ^= 0xff
+	if _, err := restoreArchive(context.Background(), bytes.NewReader(tampered), "backup.tar.gz.enc", t.TempDir(), cfg, archive.ExtractOptions{}); err == nil {
+		t.Error("expected an error for a tampered backup")
+	}
+}
+
+// TestDownloadBackup 测试分段并发下载，无法列出对象时退回单个请求下载，对象已归档时在解压前返回错误
+func TestDownloadBackup(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 300*1024)
+	adapter := mock.New()
+	adapter.SetObject("backup.tar.gz", &mock.Object{Data: data})
+	cfg := &config.Config{Restore: config.RestoreConfig{DownloadConcurrency: 3, DownloadChunkSize: config.MinDownloadChunkSize}}
+
+	download := func() ([]byte, error) {
+		body, err := downloadBackup(context.Background(), cfg, adapter, adapter, "backup.tar.gz")
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	got, err := download()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("parallel download = %d bytes, %v", len(got), err)
+	}
+	if n := adapter.Calls(mock.OpGetObject); n != 3 {
+		t.Errorf("parallel download made %d requests, want 3", n)
+	}
+
+	adapter.AddFault(mock.Fault{Op: mock.OpListObjects, Err: storage.ErrAuth})
+	got, err = download()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("fallback download = %d bytes, %v", len(got), err)
+	}
+	if n := adapter.Calls(mock.OpGetObject); n != 4 {
+		t.Errorf("fallback download made %d requests in total, want 4", n)
+	}
+
+	adapter.ClearFaults()
+	adapter.AddFault(mock.Fault{Op: mock.OpGetObject, Err: storage.ErrObjectArchived})
+	if _, err := downloadBackup(context.Background(), cfg, adapter, adapter, "backup.tar.gz"); !errors.Is(err, storage.ErrObjectArchived) {
+		t.Errorf("downloadBackup() error = %v, want ErrObjectArchived", err)
+	}
+}
@@ -1,40 +1,52 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/lukelzlz/s3backup/pkg/config"
-	"github.com/lukelzlz/s3backup/pkg/progress"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
 	"github.com/lukelzlz/s3backup/pkg/state"
 	"github.com/lukelzlz/s3backup/pkg/storage"
 	"github.com/lukelzlz/s3backup/pkg/uploader"
 	"github.com/spf13/cobra"
 )
 
-var (
-	resumeDir     string
-	resumePaths   []string
-	resumeExclude []string
-)
+var resumeForceRestart bool
 
 // resumeCmd 恢复命令
 var resumeCmd = &cobra.Command{
 	Use:   "resume [backup-name]",
 	Short: "恢复未完成的上传",
-	Long:  `从上次中断的位置继续上传。需要重新提供原始路径。`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runResume,
+	Long: `从上次中断的位置继续上传。
+
+upload 命令和 backup.spool_dir 的本地文件按偏移续传；流式备份按状态中保存的包含路径、排除模式和归档设置
+（压缩、扩展属性、填充等）重新归档，已上传的部分与记录的校验值比对后跳过。数据库导出和 Docker 辅助容器的备份无法续传。`,
+	Args: cobra.ExactArgs(1),
+	RunE: runResume,
+
+	ValidArgsFunction: completeBackupName,
 }
 
 func init() {
 	rootCmd.AddCommand(resumeCmd)
-	resumeCmd.Flags().StringVar(&resumeDir, "state-dir", "", "状态文件目录")
-	resumeCmd.Flags().StringSliceVarP(&resumePaths, "path", "p", []string{}, "原始备份路径（可多次指定）")
-	resumeCmd.Flags().StringSliceVar(&resumeExclude, "exclude", []string{}, "排除模式")
+	resumeCmd.Flags().String("state-dir", "", "状态文件目录（默认 ~/.s3backup/state/<机器标识>）")
+	resumeCmd.Flags().StringSliceP("path", "p", []string{}, "原始备份路径（可多次指定）")
+	resumeCmd.Flags().StringSlice("exclude", []string{}, "排除模式")
+	// 包含路径和排除模式已保存在续传状态中，保留参数只为兼容已有的脚本
+	resumeCmd.Flags().MarkDeprecated("path", "包含路径已保存在续传状态中，不再需要指定")
+	resumeCmd.Flags().MarkDeprecated("exclude", "排除模式已保存在续传状态中，不再需要指定")
+	resumeCmd.Flags().String("password", "", "加密密码（续传加密的上传或备份时使用）")
+	resumeCmd.Flags().String("key-file", "", "密钥文件（续传加密的上传或备份时使用）")
+	resumeCmd.Flags().Duration("heartbeat-interval", 0, "上传期间每隔指定时间输出心跳日志（如 5m），便于外部监控发现挂起的备份")
+	resumeCmd.Flags().BoolVar(&resumeForceRestart, "force-restart", false, "取消未完成的分块上传并删除续传状态，从头重新上传")
 }
 
 func runResume(cmd *cobra.Command, args []string) error {
@@ -43,14 +55,14 @@ func runResume(cmd *cobra.Command, args []string) error {
 	defer cancel()
 
 	// 加载配置
-	cfg, err := config.LoadConfig(cfgFile, envFile)
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
 	// 加载状态
-	stateMgr := state.NewStateManager(resumeDir, backupName)
-	savedState, err := stateMgr.Load()
+	stateMgr := state.NewStateManager(cfg.State.Dir, backupName)
+	savedState, err := loadState(cfg, stateMgr)
 	if err != nil {
 		return fmt.Errorf("failed to load state: %w", err)
 	}
@@ -59,101 +71,194 @@ func runResume(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no saved state found for: %s", backupName)
 	}
 
-	// 检查是否提供了路径
-	if len(resumePaths) == 0 {
-		return fmt.Errorf("请使用 --path 参数提供原始备份路径")
+	if resumeForceRestart {
+		adapter, err := resumeAdapter(ctx, cfg, savedState)
+		if err != nil {
+			return fmt.Errorf("failed to create storage adapter: %w", err)
+		}
+		return restartUpload(ctx, cfg, adapter, stateMgr, savedState, backupName)
+	}
+
+	// upload 命令上传的本地文件按偏移续传，不需要原始路径
+	if savedState.Source != "" {
+		adapter, err := resumeAdapter(ctx, cfg, savedState)
+		if err != nil {
+			return fmt.Errorf("failed to create storage adapter: %w", err)
+		}
+		cfg.Encryption.Enabled = savedState.Encrypted
+		return uploadFile(ctx, cfg, adapter, stateMgr, savedState, savedState.Source, backupName, uploadFileOptions{})
 	}
 
-	uploadIDPreview := savedState.UploadID
-	if len(uploadIDPreview) > 20 {
-		uploadIDPreview = uploadIDPreview[:20] + "..."
+	cfg.Encryption.Enabled = savedState.Encrypted
+	if err := checkStateEncryption(cfg, savedState); err != nil {
+		return err
+	}
+
+	// 流式备份需要重新归档生成与已上传分块相同的数据流：数据库导出等无法重新生成，
+	// 旧版本没有保存加密文件头的状态也无法生成相同的密文
+	if savedState.Archive == nil || (savedState.Encrypted && len(savedState.Header) == 0) {
+		return fmt.Errorf("%w: %s was not archived from local paths or was saved by an older version, "+
+			"run backup again or use resume --force-restart to abort the upload", errNotRegenerable, backupName)
+	}
+	includes := applyArchiveOptions(cfg, savedState.Archive)
+	if savedState.Encrypted {
+		encryptor, h, err := savedEncryptor(cfg, savedState)
+		if err != nil {
+			return err
+		}
+		ctx = withEncryption(ctx, encryptor, h)
 	}
 
-	fmt.Printf("恢复上传:\n")
-	fmt.Printf("  备份文件: %s\n", backupName)
-	fmt.Printf("  Upload ID: %s\n", uploadIDPreview)
-	fmt.Printf("  已完成分块: %d\n", len(savedState.Completed))
-	fmt.Printf("  已上传: %d MB\n", savedState.UploadedBytes/1024/1024)
-	fmt.Printf("  并发数: %d\n", cfg.Backup.Concurrency)
+	i18n.Printf("恢复上传:\n")
+	i18n.Printf("  备份文件: %s\n", backupName)
+	fmt.Printf("  Upload ID: %s\n", state.RedactID(savedState.UploadID))
+	i18n.Printf("  已完成分块: %d\n", len(savedState.Completed))
+	if savedState.TotalBytes > 0 {
+		i18n.Printf("  已上传: %d / %d MB\n", savedState.UploadedBytes/1024/1024, savedState.TotalBytes/1024/1024)
+	} else {
+		i18n.Printf("  已上传: %d MB\n", savedState.UploadedBytes/1024/1024)
+	}
+	i18n.Printf("  并发数: %d\n", cfg.Backup.Concurrency)
 	fmt.Println()
 
 	// 创建存储适配器
-	adapter, err := createStorageAdapterFromState(ctx, cfg, savedState)
+	adapter, err := resumeAdapter(ctx, cfg, savedState)
 	if err != nil {
 		return fmt.Errorf("failed to create storage adapter: %w", err)
 	}
 
-	// 创建 io.Pipe
-	pr, pw := io.Pipe()
-
-	// 错误通道
-	errChan := make(chan error, 2)
-
-	// 启动数据读取 goroutine（简化版：只读取空数据用于测试）
-	// 实际使用时需要根据 resumePaths 重新归档
-	go func() {
-		defer pw.Close()
-
-		// TODO: 实现完整的归档恢复
-		// 需要根据 resumePaths 重新创建归档器
-		// 并跳过已上传的分块
-		_, _ = io.Copy(pw, strings.NewReader(""))
-	}()
-
 	// 创建可恢复上传器
 	upl := uploader.NewResumableUploader(adapter, cfg.Backup.ChunkSize, cfg.Backup.Concurrency, savedState)
 	upl.SetStateManager(stateMgr)
+	upl.SetHeartbeat(heartbeatFor(cfg))
+	upl.SetReadAhead(cfg.Backup.ReadAhead)
+	upl.SetETagRetries(cfg.Backup.ETagRetries)
 
 	// 设置进度报告器
-	reporter := progress.NewBar()
+	reporter := newProgressReporter()
 	upl.SetProgressReporter(reporter)
 	defer reporter.Close()
 
 	// 上传选项
-	contentType := "application/gzip"
-	if savedState.Encrypted {
-		contentType = "application/octet-stream"
-	}
 	opts := storage.UploadOptions{
-		StorageClass: storage.ParseStorageClass(savedState.StorageClass),
-		ContentType:  contentType,
+		StorageClass:       storage.ParseStorageClass(savedState.StorageClass),
+		ContentType:        backupContentType(backupName, savedState.Encrypted),
+		ContentDisposition: storage.ContentDispositionFor(backupName),
+		ACL:                cfg.Storage.ACL,
+		Metadata:           savedState.Metadata,
+		NoOverwrite:        savedState.NoOverwrite,
 	}
 
-	// 启动上传
+	// 按原来的配置重新归档，已上传的分块读出后与记录的偏移和校验值比对（数据源变化时返回 ErrStreamMismatch），
+	// 只上传未完成的分块；pr 不支持 Seek，已上传的部分同样需要重新生成
+	pr, pw := io.Pipe()
 	go func() {
-		if err := upl.Resume(ctx, backupName, savedState.UploadID, pr, opts); err != nil {
-			cancel()
-			errChan <- fmt.Errorf("failed to resume upload: %w", err)
-			return
-		}
-		errChan <- nil
+		_, err := writeArchive(ctx, pw, cfg, includes, nil)
+		pw.CloseWithError(err)
 	}()
-
-	// 等待完成
-	if err := <-errChan; err != nil {
-		fmt.Printf("\n恢复失败，状态已保存。可以再次使用 resume 命令继续。\n")
+	err = upl.Resume(ctx, backupName, savedState.UploadID, pr, opts)
+	// 上传提前结束时让归档停止写入
+	pr.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		err = fmt.Errorf("failed to resume upload: %w", err)
+		if hint := errorHint(err); hint != "" {
+			i18n.Printf("\n提示: %s\n", hint)
+		}
+		stateMgr.Flush()
+		// 数据源已变化时再次续传同样会失败，只能 --force-restart，不提示重试
+		if !errors.Is(err, uploader.ErrStreamMismatch) {
+			i18n.Printf("\n恢复失败，状态已保存。可以再次使用 resume 命令继续。\n")
+		}
 		return err
 	}
 
 	// 删除状态文件
 	stateMgr.Delete()
 
-	fmt.Printf("恢复成功: %s\n", backupName)
+	stats := upl.Stats()
+	i18n.Printf("上传统计: %d 个分块，限流 %d 次，重试 %d 次\n", stats.Parts, stats.Throttled, stats.Retries)
+	if stats.ETagMismatches > 0 {
+		i18n.Printf("警告: %d 次分块 ETag 与本地 MD5 不一致（数据在传输中损坏），已重新上传\n", stats.ETagMismatches)
+	}
+	printReuploaded(upl.Reuploaded())
+	i18n.Printf("恢复成功: %s\n", backupName)
 	return nil
 }
 
+// errNotRegenerable 中断的流式备份无法由 resume 重新生成
+var errNotRegenerable = errors.New("stream cannot be regenerated")
+
+// resumeAdapter 按状态中记录的存储信息创建存储适配器，测试时替换为内存适配器
+var resumeAdapter = createStorageAdapterFromState
+
+// savedEncryptor 按状态中保存的加密文件头创建加密器，确认当前密钥与中断的上传一致
+// 使用保存的文件头（IV 和盐值）才能重新生成与已上传分块相同的密文
+func savedEncryptor(cfg *config.Config, saved *state.UploadState) (*crypto.StreamEncryptor, *crypto.Header, error) {
+	h, err := crypto.ParseHeader(saved.Header)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse saved header: %w", err)
+	}
+	keys, err := keySource(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	encryptor, err := keys.EncryptorFor(h)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(encryptor.KeyCheck(h), saved.KeyCheck) {
+		return nil, nil, fmt.Errorf("encryption key differs from the interrupted upload")
+	}
+	return encryptor, h, nil
+}
+
+// restartUpload 取消旧的分块上传并删除续传状态，用于数据源已变化、无法续传的情况
+// 本地文件（upload 命令或 backup.spool_dir）随后从头重新上传；流式备份无法在 resume 中重新生成，提示重新执行 backup
+func restartUpload(ctx context.Context, cfg *config.Config, adapter storage.StorageAdapter,
+	stateMgr *state.StateManager, saved *state.UploadState, name string) error {
+	if err := adapter.AbortMultipartUpload(ctx, name, saved.UploadID); err != nil && !errors.Is(err, storage.ErrUploadNotFound) {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+	if err := stateMgr.Delete(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete state: %w", err)
+	}
+	i18n.Printf("已取消未完成的上传: %s\n", name)
+
+	if saved.Source == "" {
+		i18n.Printf("流式备份无法从续传状态重新生成，请重新执行 backup 命令\n")
+		return nil
+	}
+
+	// 重新上传到原来的存储桶
+	cfg.Storage.Provider, cfg.Storage.Bucket = saved.Provider, saved.Bucket
+	cfg.Storage.Endpoint, cfg.Storage.Region = saved.Endpoint, saved.Region
+	cfg.Storage.StorageClass = saved.StorageClass
+	cfg.Encryption.Enabled = saved.Encrypted
+	cfg.Backup.Overwrite = !saved.NoOverwrite
+	return uploadFile(ctx, cfg, adapter, stateMgr, nil, saved.Source, name, uploadFileOptions{Metadata: saved.Metadata, Spooled: saved.Spooled})
+}
+
+// printReuploaded 提示本地状态记录为已完成、但服务端缺失或不一致而重新上传的分块
+func printReuploaded(parts []int) {
+	if len(parts) > 0 {
+		i18n.Printf("[警告] %d 个已记录的分块在服务端缺失或不一致，已重新上传: %v\n", len(parts), parts)
+	}
+}
+
 // createStorageAdapterFromState 从状态创建存储适配器
 func createStorageAdapterFromState(ctx context.Context, cfg *config.Config, s *state.UploadState) (storage.StorageAdapter, error) {
 	accessKey := cfg.GetAccessKey()
 	secretKey := cfg.GetSecretKey()
 
+	opts := httpTraceOptions()
 	switch strings.ToLower(s.Provider) {
 	case "aws":
-		return storage.NewAWSAdapter(ctx, s.Region, s.Endpoint, s.Bucket, accessKey, secretKey)
+		return storage.NewAWSAdapter(ctx, s.Region, s.Endpoint, s.Bucket, accessKey, secretKey,
+			append(opts, storage.WithPathStyle(cfg.Storage.PathStyle))...)
 	case "qiniu":
-		return storage.NewQiniuAdapter(ctx, s.Endpoint, s.Bucket, accessKey, secretKey)
+		return storage.NewQiniuAdapter(ctx, s.Endpoint, s.Bucket, accessKey, secretKey, opts...)
 	case "aliyun":
-		return storage.NewAliyunAdapter(ctx, s.Region, s.Endpoint, s.Bucket, accessKey, secretKey)
+		return storage.NewAliyunAdapter(ctx, s.Region, s.Endpoint, s.Bucket, accessKey, secretKey, opts...)
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", s.Provider)
 	}
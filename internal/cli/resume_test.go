@@ -0,0 +1,340 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+	"github.com/lukelzlz/s3backup/pkg/uploader"
+)
+
+// interruptedBackup 中断的流式备份：归档 src 时第 4 个分块上传失败，保留已上传的分块和续传状态
+type interruptedBackup struct {
+	cfgPath  string
+	stateDir string
+	src      string
+	adapter  *mock.Adapter
+	name     string
+	saved    *state.UploadState
+}
+
+// newInterruptedBackup 按配置文件执行一次在第 4 个分块中断的备份，并让 runResume 使用同一个内存适配器
+// configure 不为 nil 时在备份前修改配置，模拟只在 backup 命令行上指定、配置文件中没有的设置
+func newInterruptedBackup(t *testing.T, encrypted bool, configure func(cfg *config.Config)) *interruptedBackup {
+	t.Helper()
+	defer func(n bool) { noProgress = n }(noProgress)
+	noProgress = true
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(src, 0755)
+	rng := rand.New(rand.NewSource(1))
+	for _, name := range []string{"a.bin", "b.bin"} {
+		data := make([]byte, 3000)
+		rng.Read(data)
+		if err := os.WriteFile(filepath.Join(src, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfgPath := filepath.Join(dir, "s3backup.yaml")
+	yaml := fmt.Sprintf(`storage:
+  provider: aws
+  bucket: bucket
+  region: us-east-1
+  access_key: key
+  secret_key: secret
+backup:
+  chunk_size: 1024
+  concurrency: 1
+state:
+  dir: %s
+encryption:
+  enabled: %v
+  password: secret
+`, filepath.Join(dir, "state"), encrypted)
+	if err := os.WriteFile(cfgPath, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(cfgPath, "")
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if configure != nil {
+		configure(cfg)
+	}
+
+	b := &interruptedBackup{cfgPath: cfgPath, stateDir: cfg.State.Dir, src: src, adapter: mock.New(), name: "backup-20260101-000000.tar.gz"}
+	if encrypted {
+		b.name += ".enc"
+	}
+	b.adapter.FailPart(4, 0, nil, 1)
+	err = backupStream(context.Background(), cfg, b.adapter, b.name, nil, archiveOptions(cfg, []string{src}), func(ctx context.Context, w io.Writer, meter *archive.CompressionMeter) error {
+		_, err := writeArchive(ctx, w, cfg, []string{src}, meter)
+		return err
+	})
+	if !errors.Is(err, mock.ErrInjected) {
+		t.Fatalf("backup error = %v, want ErrInjected", err)
+	}
+	if b.saved, err = state.NewStateManager(cfg.State.Dir, b.name).Load(); err != nil || b.saved == nil {
+		t.Fatalf("no resumable state: %+v, %v", b.saved, err)
+	}
+	if len(b.saved.Completed) == 0 {
+		t.Fatal("no parts completed before the failure")
+	}
+
+	orig, origAdapter := cfgFile, resumeAdapter
+	t.Cleanup(func() { cfgFile, resumeAdapter = orig, origAdapter })
+	cfgFile = cfgPath
+	resumeAdapter = func(context.Context, *config.Config, *state.UploadState) (storage.StorageAdapter, error) {
+		return b.adapter, nil
+	}
+	return b
+}
+
+// resume 执行 s3backup resume <name>，返回错误和标准输出
+func (b *interruptedBackup) resume(t *testing.T) (string, error) {
+	t.Helper()
+	defer func(n bool) { noProgress = n }(noProgress)
+	noProgress = true
+
+	out, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = out
+	err = runResume(resumeCmd, []string{b.name})
+	os.Stdout = stdout
+
+	data, _ := os.ReadFile(out.Name())
+	return string(data), err
+}
+
+// TestResumeStreamedBackup 测试中断的流式备份由 resume 按状态中保存的包含路径重新归档续传：
+// 已上传的分块不再上传，完成的对象解压后与源文件一致；加密的备份使用状态中保存的文件头生成相同的密文
+func TestResumeStreamedBackup(t *testing.T) {
+	for _, encrypted := range []bool{false, true} {
+		b := newInterruptedBackup(t, encrypted, nil)
+		if encrypted && len(b.saved.Header) == 0 {
+			t.Fatal("encryption header not saved in state")
+		}
+		before := b.adapter.Calls(mock.OpUploadPart)
+
+		if out, err := b.resume(t); err != nil {
+			t.Fatalf("encrypted=%v: resume error = %v\n%s", encrypted, err, out)
+		}
+		obj, ok := b.adapter.Object(b.name)
+		if !ok {
+			t.Fatalf("encrypted=%v: object not completed", encrypted)
+		}
+		parts := (len(obj.Data) + 1023) / 1024
+		if got, want := b.adapter.Calls(mock.OpUploadPart)-before, parts-len(b.saved.Completed); got != want {
+			t.Errorf("encrypted=%v: resume uploaded %d parts, want %d", encrypted, got, want)
+		}
+
+		var r io.Reader = bytes.NewReader(obj.Data)
+		if encrypted {
+			var err error
+			if r, _, err = crypto.OpenReader(r, crypto.KeySource{Password: "secret"}); err != nil {
+				t.Fatalf("OpenReader() error = %v", err)
+			}
+		}
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatalf("encrypted=%v: gzip error = %v", encrypted, err)
+		}
+		tr := tar.NewReader(zr)
+		files := 0
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("encrypted=%v: tar error = %v", encrypted, err)
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			got, _ := io.ReadAll(tr)
+			want, _ := os.ReadFile(filepath.Join(b.src, filepath.Base(hdr.Name)))
+			if !bytes.Equal(got, want) {
+				t.Errorf("encrypted=%v: %s differs from source", encrypted, hdr.Name)
+			}
+			files++
+		}
+		if files != 2 {
+			t.Errorf("encrypted=%v: archive has %d files, want 2", encrypted, files)
+		}
+
+		if s, _ := state.NewStateManager(b.stateDir, b.name).Load(); s != nil {
+			t.Error("state should be deleted after a successful resume")
+		}
+	}
+}
+
+// TestResumeArchiveOptions 测试 resume 使用状态中保存的归档设置重新归档：备份时在命令行上指定的
+// --compression zstd、排除模式和 --ignore-case 不在配置文件中，续传仍生成相同的 zstd 数据流，完成的对象只包含未排除的文件
+func TestResumeArchiveOptions(t *testing.T) {
+	b := newInterruptedBackup(t, false, func(cfg *config.Config) {
+		cfg.Backup.Compression = "zstd"
+		cfg.Backup.Excludes = []string{"**/B.BIN"}
+		cfg.Backup.IgnoreCase = true
+	})
+	if a := b.saved.Archive; a == nil || a.Compression != "zstd" || !a.IgnoreCase || len(a.Excludes) != 1 {
+		t.Fatalf("archive options not saved in state: %+v", a)
+	}
+	before := b.adapter.Calls(mock.OpUploadPart)
+
+	if out, err := b.resume(t); err != nil {
+		t.Fatalf("resume error = %v\n%s", err, out)
+	}
+	obj, ok := b.adapter.Object(b.name)
+	if !ok {
+		t.Fatal("object not completed")
+	}
+	parts := (len(obj.Data) + 1023) / 1024
+	if got, want := b.adapter.Calls(mock.OpUploadPart)-before, parts-len(b.saved.Completed); got != want {
+		t.Errorf("resume uploaded %d parts, want %d", got, want)
+	}
+
+	zr, err := zstd.NewReader(bytes.NewReader(obj.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	var files []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar error = %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		got, _ := io.ReadAll(tr)
+		want, _ := os.ReadFile(filepath.Join(b.src, filepath.Base(hdr.Name)))
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from source", hdr.Name)
+		}
+		files = append(files, filepath.Base(hdr.Name))
+	}
+	if len(files) != 1 || files[0] != "a.bin" {
+		t.Errorf("archive has %v, want [a.bin]", files)
+	}
+}
+
+// TestResumeReportsETagRetries 测试续传时分块在传输中损坏会重新上传，并在上传统计中报告
+func TestResumeReportsETagRetries(t *testing.T) {
+	b := newInterruptedBackup(t, false, nil)
+	b.adapter.CorruptPart(4, 1)
+
+	out, err := b.resume(t)
+	if err != nil {
+		t.Fatalf("resume error = %v\n%s", err, out)
+	}
+	if want := i18n.Sprintf("警告: %d 次分块 ETag 与本地 MD5 不一致（数据在传输中损坏），已重新上传\n", 1); !strings.Contains(out, want) {
+		t.Errorf("output does not report the ETag retry:\n%s", out)
+	}
+	if _, ok := b.adapter.Object(b.name); !ok {
+		t.Error("object not completed")
+	}
+}
+
+// TestResumeNotRegenerable 测试无法重新归档的流式备份（数据库导出、旧版本状态）在续传前拒绝，而不是上传空数据
+func TestResumeNotRegenerable(t *testing.T) {
+	b := newInterruptedBackup(t, false, nil)
+	b.saved.Archive = nil
+	if err := state.NewStateManager(b.stateDir, b.name).Save(b.saved); err != nil {
+		t.Fatal(err)
+	}
+	before := b.adapter.Calls(mock.OpUploadPart)
+
+	if _, err := b.resume(t); !errors.Is(err, errNotRegenerable) {
+		t.Fatalf("resume error = %v, want errNotRegenerable", err)
+	}
+	if b.adapter.Calls(mock.OpUploadPart) != before {
+		t.Error("parts uploaded for a stream that cannot be regenerated")
+	}
+	if _, ok := b.adapter.Object(b.name); ok {
+		t.Error("object completed for a stream that cannot be regenerated")
+	}
+}
+
+// TestResumeDetectsChangedSource 测试源文件在中断后被修改（大小不变）时，resume 重新归档后
+// 与已上传分块的校验值比对失败，拒绝续传并提示 --force-restart，不上传新的分块也不完成对象
+func TestResumeDetectsChangedSource(t *testing.T) {
+	b := newInterruptedBackup(t, false, nil)
+	path := filepath.Join(b.src, "a.bin")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range data {
+		data[i] ^= 0xff
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	before := b.adapter.Calls(mock.OpUploadPart)
+
+	out, err := b.resume(t)
+	if !errors.Is(err, uploader.ErrStreamMismatch) {
+		t.Fatalf("resume error = %v, want ErrStreamMismatch", err)
+	}
+	// 提示使用 --force-restart，而不是再次续传
+	if hint := errorHint(err); hint == "" || !strings.Contains(out, hint) || !strings.Contains(hint, "resume --force-restart") {
+		t.Errorf("output does not contain the --force-restart hint:\n%s", out)
+	}
+	if strings.Contains(out, i18n.T("\n恢复失败，状态已保存。可以再次使用 resume 命令继续。\n")) {
+		t.Errorf("output suggests resuming again after a mismatch:\n%s", out)
+	}
+	if b.adapter.Calls(mock.OpUploadPart) != before {
+		t.Error("parts uploaded after the source changed")
+	}
+	if _, ok := b.adapter.Object(b.name); ok {
+		t.Error("object completed from a changed source")
+	}
+}
+
+// TestResumeKeepsNoOverwrite 测试续传使用状态中保存的覆盖设置：中断期间其他机器上传了同名备份时，
+// resume 在另一个进程中完成上传同样拒绝覆盖
+func TestResumeKeepsNoOverwrite(t *testing.T) {
+	b := newInterruptedBackup(t, false, nil)
+	if !b.saved.NoOverwrite {
+		t.Fatal("no_overwrite not saved in state")
+	}
+	if err := b.adapter.PutObject(context.Background(), b.name, []byte("other"), storage.UploadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.resume(t); !errors.Is(err, storage.ErrObjectExists) {
+		t.Fatalf("resume error = %v, want ErrObjectExists", err)
+	}
+	if obj, _ := b.adapter.Object(b.name); string(obj.Data) != "other" {
+		t.Error("resume overwrote an existing backup")
+	}
+}
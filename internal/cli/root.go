@@ -1,15 +1,20 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/version"
 	"github.com/spf13/cobra"
 )
 
 var (
 	cfgFile string
 	envFile string
+	quiet   bool
+	noColor bool
 )
 
 // rootCmd 根命令
@@ -30,12 +35,35 @@ var rootCmd = &cobra.Command{
   - AES-256-CTR + HMAC-SHA512 加密
   - 支持设置存储类型（低频、归档等）
   - Multipart Upload 并发上传`,
+	Version: version.Version,
+
+	PersistentPreRunE: setup,
+}
+
+// setup 在所有命令执行前初始化输出、OpenTelemetry 和 pprof 服务
+func setup(cmd *cobra.Command, args []string) error {
+	setupTracing()
+	startPprof()
+	return setupOutput(cmd, args)
 }
 
 // Execute 执行根命令
 func Execute() {
+	if err := setupLang(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	if err := rootCmd.Execute(); err != nil {
+		// --k8s 模式下错误已输出为 JSON 日志，按结构化退出码退出
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		// 凭证模式的错误在连接存储之前返回，上传失败时输出的提示覆盖不到
+		if errors.Is(err, ErrReadOnlyCredentials) || errors.Is(err, ErrWriteOnly) {
+			i18n.Fprintf(os.Stderr, "\n提示: %s\n", errorHint(err))
+		}
 		os.Exit(1)
 	}
 }
@@ -46,6 +74,12 @@ func init() {
 	// 全局 flags
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "配置文件路径 (默认 ~/.s3backup.yaml)")
 	rootCmd.PersistentFlags().StringVar(&envFile, "env-file", "", "环境变量文件路径 (默认 .s3backup.env)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "只输出错误和警告汇总，不显示进度条、备份信息和逐个文件的警告")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "不输出进度条等终端控制字符（也可以设置 NO_COLOR 环境变量）")
+	rootCmd.PersistentFlags().StringVar(&lang, "lang", "", "输出语言 (en/zh)，默认为 "+i18n.LangEnv+" 环境变量或中文")
+	rootCmd.PersistentFlags().BoolVar(&debugHTTP, "debug-http", false, "在标准错误输出每个存储请求的操作、路径、状态码、耗时和重试次数，结束时汇总各操作的耗时分布")
+	rootCmd.PersistentFlags().StringVar(&pprofAddr, "pprof", "", "在指定地址提供 pprof 性能分析接口（如 localhost:6060），用于分析长时间运行的备份")
+	cobra.OnFinalize(printHTTPMetrics, shutdownTracing, stopPprof, restoreOutput)
 }
 
 func initConfig() {
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/schedule"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleDaily   string
+	scheduleJob     string
+	scheduleCron    bool
+	scheduleUnitDir string
+	schedulePrint   bool
+	scheduleNoStart bool
+	scheduleVerify  bool
+)
+
+// installScheduleCmd 安装定时备份命令
+var installScheduleCmd = &cobra.Command{
+	Use:   "install-schedule [paths...]",
+	Short: "安装定时备份任务（systemd timer 或 crontab）",
+	Long: `生成并安装每日执行的定时备份任务。
+
+默认写入 systemd service + timer（root 用户写入 /etc/systemd/system，
+普通用户写入 ~/.config/systemd/user）并立即启用；使用 --cron 则写入当前用户的 crontab。
+
+任务会以当前可执行文件的绝对路径调用 backup 命令，并固定使用当前找到的配置文件。
+使用 --verify 在每次备份上传后抽查首尾数据段和备份报告，发现损坏的上传时任务以失败状态结束，
+由 systemd（OnFailure=、journal）或 cron（MAILTO）发出通知。
+
+示例:
+  s3backup install-schedule --daily 03:00 /home/user/documents
+  s3backup install-schedule --daily 03:00 --cron /var/www
+  s3backup install-schedule --daily 03:00 --verify /srv/data
+  s3backup install-schedule --daily 03:00 --print /etc`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runInstallSchedule,
+}
+
+func init() {
+	rootCmd.AddCommand(installScheduleCmd)
+
+	installScheduleCmd.Flags().StringVar(&scheduleDaily, "daily", "", "每日执行时间 (HH:MM)")
+	installScheduleCmd.Flags().StringVar(&scheduleJob, "job-name", "s3backup", "任务名称（用于 unit 文件名和 crontab 标记）")
+	installScheduleCmd.Flags().BoolVar(&scheduleCron, "cron", false, "写入 crontab 而不是 systemd timer")
+	installScheduleCmd.Flags().StringVar(&scheduleUnitDir, "unit-dir", "", "systemd unit 文件目录（默认根据当前用户自动选择）")
+	installScheduleCmd.Flags().BoolVar(&schedulePrint, "print", false, "只打印生成的内容，不安装")
+	installScheduleCmd.Flags().BoolVar(&scheduleNoStart, "no-enable", false, "只写入 unit 文件，不执行 systemctl enable")
+	installScheduleCmd.Flags().BoolVar(&scheduleVerify, "verify", false, "每次备份上传后校验首尾数据段和备份报告（backup --verify-upload）")
+	installScheduleCmd.MarkFlagRequired("daily")
+}
+
+func runInstallSchedule(cmd *cobra.Command, args []string) error {
+	command, err := scheduledBackupCommand(args)
+	if err != nil {
+		return err
+	}
+
+	spec := schedule.Spec{
+		Name:    scheduleJob,
+		Command: command,
+		Daily:   scheduleDaily,
+	}
+
+	if scheduleCron {
+		return installCron(cmd, spec)
+	}
+	return installSystemd(cmd, spec)
+}
+
+// scheduledBackupCommand 构造定时任务执行的完整 backup 命令行
+func scheduledBackupCommand(paths []string) ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	// 定时任务的工作目录不确定，配置文件和路径都需要使用绝对路径
+	configPath, err := config.FindConfigFile(cfgFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find config file: %w", err)
+	}
+
+	command := []string{exe}
+	if configPath != "" {
+		abs, err := filepath.Abs(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve config path: %w", err)
+		}
+		command = append(command, "--config", abs)
+	}
+	if envFile != "" {
+		abs, err := filepath.Abs(envFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve env file path: %w", err)
+		}
+		command = append(command, "--env-file", abs)
+	}
+
+	command = append(command, "backup", "--no-progress")
+	if scheduleVerify {
+		command = append(command, "--verify-upload")
+	}
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path %s: %w", p, err)
+		}
+		command = append(command, abs)
+	}
+
+	return command, nil
+}
+
+// installSystemd 写入 systemd service 和 timer 并启用
+func installSystemd(cmd *cobra.Command, spec schedule.Spec) error {
+	service, timer, err := schedule.SystemdUnits(spec)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if schedulePrint {
+		fmt.Fprintf(out, "# %s.service\n%s\n# %s.timer\n%s", spec.Name, service, spec.Name, timer)
+		return nil
+	}
+
+	userMode := os.Geteuid() != 0
+	unitDir := scheduleUnitDir
+	if unitDir == "" {
+		if userMode {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("failed to get home directory: %w", err)
+			}
+			unitDir = filepath.Join(home, ".config", "systemd", "user")
+		} else {
+			unitDir = "/etc/systemd/system"
+		}
+	}
+
+	if err := os.MkdirAll(unitDir, 0755); err != nil {
+		return fmt.Errorf("failed to create unit directory: %w", err)
+	}
+
+	servicePath := filepath.Join(unitDir, spec.Name+".service")
+	timerPath := filepath.Join(unitDir, spec.Name+".timer")
+	if err := os.WriteFile(servicePath, []byte(service), 0644); err != nil {
+		return fmt.Errorf("failed to write service unit: %w", err)
+	}
+	if err := os.WriteFile(timerPath, []byte(timer), 0644); err != nil {
+		return fmt.Errorf("failed to write timer unit: %w", err)
+	}
+
+	i18n.Fprintf(out, "已写入: %s\n", servicePath)
+	i18n.Fprintf(out, "已写入: %s\n", timerPath)
+
+	systemctl := []string{"systemctl"}
+	if userMode {
+		systemctl = append(systemctl, "--user")
+	}
+	if scheduleNoStart {
+		i18n.Fprintf(out, "\n启用定时任务:\n  %s daemon-reload\n  %s enable --now %s.timer\n",
+			strings.Join(systemctl, " "), strings.Join(systemctl, " "), spec.Name)
+		return nil
+	}
+
+	if err := runCommand(nil, append(systemctl, "daemon-reload")...); err != nil {
+		return fmt.Errorf("failed to reload systemd: %w", err)
+	}
+	if err := runCommand(nil, append(systemctl, "enable", "--now", spec.Name+".timer")...); err != nil {
+		return fmt.Errorf("failed to enable timer: %w", err)
+	}
+
+	i18n.Fprintf(out, "✓ 定时任务已启用，每日 %s 执行\n", spec.Daily)
+	i18n.Fprintf(out, "查看状态: %s list-timers %s.timer\n", strings.Join(systemctl, " "), spec.Name)
+	if userMode {
+		i18n.Fprintf(out, "提示: 用户级 timer 需要 loginctl enable-linger %s 才能在未登录时运行\n", os.Getenv("USER"))
+	}
+	return nil
+}
+
+// installCron 将任务写入当前用户的 crontab，已存在的同名任务会被替换
+func installCron(cmd *cobra.Command, spec schedule.Spec) error {
+	entry, err := schedule.CronEntry(spec)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if schedulePrint {
+		fmt.Fprintln(out, entry)
+		return nil
+	}
+
+	// 没有 crontab 时 crontab -l 返回非零，视为空
+	var existing bytes.Buffer
+	list := exec.Command("crontab", "-l")
+	list.Stdout = &existing
+	_ = list.Run()
+
+	merged := schedule.MergeCrontab(existing.String(), spec.Name, entry)
+	if err := runCommand(strings.NewReader(merged), "crontab", "-"); err != nil {
+		return fmt.Errorf("failed to install crontab: %w", err)
+	}
+
+	i18n.Fprintf(out, "✓ 已写入 crontab，每日 %s 执行\n", spec.Daily)
+	fmt.Fprintf(out, "  %s\n", entry)
+	return nil
+}
+
+// runCommand 执行外部命令，失败时附带其输出
+func runCommand(stdin *strings.Reader, name ...string) error {
+	c := exec.Command(name[0], name[1:]...)
+	if stdin != nil {
+		c.Stdin = stdin
+	}
+	output, err := c.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", strings.Join(name, " "), err, msg)
+		}
+		return fmt.Errorf("%s: %w", strings.Join(name, " "), err)
+	}
+	return nil
+}
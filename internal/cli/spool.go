@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/pipeline"
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+)
+
+// spoolBackup 先将 produce 写出的数据（已压缩、加密）完整写入 backup.spool_dir 中的临时文件，再按偏移分块上传
+// 以磁盘空间换取可靠性：上传中断后保留临时文件和续传状态，s3backup resume <name> 直接从磁盘续传，不需要重新归档；
+// 上传完成后由 uploadFile 删除临时文件
+func spoolBackup(ctx context.Context, cfg *config.Config, adapter storage.StorageAdapter, name string,
+	stateMgr *state.StateManager, signKey ed25519.PrivateKey, report *backupReport, started time.Time,
+	produce producer) error {
+	if err := os.MkdirAll(cfg.Backup.SpoolDir, 0700); err != nil {
+		return fmt.Errorf("failed to create spool dir: %w", err)
+	}
+	// 续传时按状态文件中记录的路径读取，需要是绝对路径
+	path, err := filepath.Abs(filepath.Join(cfg.Backup.SpoolDir, spoolFileName(name)))
+	if err != nil {
+		return fmt.Errorf("failed to resolve spool path: %w", err)
+	}
+
+	i18n.Printf("写入本地临时文件: %s\n", path)
+	meter := archive.NewCompressionMeter(cfg.Backup.ChunkSize)
+	hw, err := writeSpoolFile(ctx, path, report != nil || signKey != nil || cfg.Backup.VerifyUpload, meter, produce)
+	if err != nil {
+		return err
+	}
+	printCompressionStats(os.Stdout, meter.Stats())
+
+	// 数据已在写入临时文件时加密，按原样上传
+	uploadCfg := *cfg
+	uploadCfg.Encryption.Enabled = false
+	extra := uploadFileOptions{Metadata: backupMetadata(cfg), Spooled: true}
+	if err := uploadFile(ctx, &uploadCfg, adapter, stateMgr, nil, path, name, extra); err != nil {
+		// 状态文件失效被删除时临时文件无法再续传
+		if stateMgr.GetState() == nil {
+			os.Remove(path)
+		} else {
+			i18n.Printf("临时文件已保留: %s\n", path)
+		}
+		return err
+	}
+
+	report.setCompression(meter.Stats())
+	if err := finishBackup(ctx, cfg, adapter, name, signKey, report, hw, started); err != nil {
+		return err
+	}
+	i18n.Printf("备份成功: %s\n", name)
+	return nil
+}
+
+// writeSpoolFile 将 produce 写出的数据写入 path 并同步到磁盘，失败时删除文件
+// hash 为 true 时返回统计大小和 SHA-256 的 hashingWriter
+func writeSpoolFile(ctx context.Context, path string, hash bool, meter *archive.CompressionMeter,
+	produce producer) (*hashingWriter, error) {
+	f, err := pipeline.CreateFile(path, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write spool file: %w", err)
+	}
+
+	var filters []pipeline.Filter
+	var hw *hashingWriter
+	if hash {
+		hw = newHashingWriter(io.Discard)
+		filters = append(filters, pipeline.Tap(hw))
+	}
+	if err := pipeline.Run(ctx, produce.source(meter), f, filters...); err != nil {
+		return nil, err
+	}
+	return hw, nil
+}
+
+// spoolFileName 返回对象 name 在 spool 目录中的文件名，对象名中的目录分隔符替换为下划线
+func spoolFileName(name string) string {
+	return strings.ReplaceAll(name, "/", "_")
+}
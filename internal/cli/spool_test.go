@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestSpoolBackupResumeFromDisk 测试 spool 模式上传中断后保留临时文件，续传从磁盘读取，完成后删除临时文件
+func TestSpoolBackupResumeFromDisk(t *testing.T) {
+	noProgress = true
+	dir := t.TempDir()
+
+	plaintext := make([]byte, 5*1024+77)
+	rand.New(rand.NewSource(1)).Read(plaintext)
+
+	cfg := &config.Config{
+		Encryption: config.EncryptionConfig{Enabled: true, Password: "secret"},
+		Backup:     config.BackupConfig{ChunkSize: 1024, Concurrency: 1, SpoolDir: filepath.Join(dir, "spool")},
+	}
+	produced := 0
+	produce := func(ctx context.Context, w io.Writer, _ *archive.CompressionMeter) error {
+		produced++
+		return writeEncrypted(ctx, w, cfg, func(w io.Writer) error {
+			_, err := w.Write(plaintext)
+			return err
+		})
+	}
+
+	adapter := mock.New()
+	adapter.FailPart(3, 0, mock.ErrInjected, 1)
+	name := "backups/data.bin.enc"
+	stateMgr := state.NewStateManager(filepath.Join(dir, "state"), name)
+
+	ctx := context.Background()
+	if err := spoolBackup(ctx, cfg, adapter, name, stateMgr, nil, nil, time.Now(), produce); err == nil {
+		t.Fatal("expected first upload to fail")
+	}
+	saved := stateMgr.GetState()
+	spoolPath := filepath.Join(cfg.Backup.SpoolDir, "backups_data.bin.enc")
+	if saved == nil || saved.Source != spoolPath || !saved.Spooled || saved.Encrypted {
+		t.Fatalf("unexpected state: %+v", saved)
+	}
+	if _, err := os.Stat(spoolPath); err != nil {
+		t.Fatalf("spool file should be kept after a failed upload: %v", err)
+	}
+
+	// 与 resume 命令相同：按状态中的加密设置从磁盘续传
+	resumeCfg := *cfg
+	resumeCfg.Encryption.Enabled = saved.Encrypted
+	if err := uploadFile(ctx, &resumeCfg, adapter, stateMgr, saved, saved.Source, name, uploadFileOptions{}); err != nil {
+		t.Fatalf("resume error = %v", err)
+	}
+	if produced != 1 {
+		t.Errorf("archive produced %d times, want 1", produced)
+	}
+	if _, err := os.Stat(spoolPath); !os.IsNotExist(err) {
+		t.Error("spool file should be removed after success")
+	}
+
+	obj, ok := adapter.Object(name)
+	if !ok {
+		t.Fatal("object not created")
+	}
+	r, _, err := crypto.OpenReader(bytes.NewReader(obj.Data), crypto.KeySource{Password: "secret"})
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decrypt error = %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Error("uploaded data does not match archive")
+	}
+	if obj.Metadata["s3backup-cipher"] != crypto.CipherName {
+		t.Errorf("metadata = %v, want cipher %s", obj.Metadata, crypto.CipherName)
+	}
+}
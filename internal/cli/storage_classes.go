@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var storageClassesProvider string
+
+// providerNames 存储提供商显示名称，使用时通过 i18n.T 翻译
+var providerNames = map[string]string{
+	"aws":    "AWS S3",
+	"qiniu":  i18n.N("七牛云 Kodo"),
+	"aliyun": i18n.N("阿里云 OSS"),
+}
+
+// storageClassDescriptions 存储类型说明，使用时通过 i18n.T 翻译
+var storageClassDescriptions = map[storage.StorageClass]string{
+	storage.StorageClassStandard:           i18n.N("标准存储"),
+	storage.StorageClassInfrequent:         i18n.N("低频访问"),
+	storage.StorageClassArchive:            i18n.N("归档存储"),
+	storage.StorageClassColdArchive:        i18n.N("冷归档"),
+	storage.StorageClassDeepArchive:        i18n.N("深度归档"),
+	storage.StorageClassGlacierIR:          i18n.N("归档直读"),
+	storage.StorageClassIntelligentTiering: i18n.N("智能分层"),
+}
+
+// storageClassesCmd 列出存储类型命令
+var storageClassesCmd = &cobra.Command{
+	Use:   "storage-classes",
+	Short: "列出存储提供商支持的存储类型",
+	Long: `列出各存储提供商支持的存储类型，以及 --storage-class 取值对应的提供商原生取值。
+
+示例:
+  s3backup storage-classes
+  s3backup storage-classes --provider qiniu`,
+	Args: cobra.NoArgs,
+	RunE: runStorageClasses,
+}
+
+func init() {
+	rootCmd.AddCommand(storageClassesCmd)
+
+	storageClassesCmd.Flags().StringVarP(&storageClassesProvider, "provider", "p", "", "存储提供商 (aws/qiniu/aliyun)，默认列出全部")
+	_ = storageClassesCmd.RegisterFlagCompletionFunc("provider", completeProvider)
+}
+
+func runStorageClasses(cmd *cobra.Command, args []string) error {
+	providers := []string{"aws", "qiniu", "aliyun"}
+	if storageClassesProvider != "" {
+		provider := strings.ToLower(storageClassesProvider)
+		if storage.SupportedStorageClassesFor(provider) == nil {
+			return fmt.Errorf("unsupported provider: %s", storageClassesProvider)
+		}
+		providers = []string{provider}
+	}
+
+	out := cmd.OutOrStdout()
+	for i, provider := range providers {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s (%s):\n", i18n.T(providerNames[provider]), provider)
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		i18n.Fprintf(w, "  --storage-class\t提供商取值\t说明\n")
+		for _, sc := range storage.SupportedStorageClassesFor(provider) {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", sc.Name(), storage.NativeStorageClass(provider, sc), i18n.T(storageClassDescriptions[sc]))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
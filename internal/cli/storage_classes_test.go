@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestStorageClassesCommand 测试按提供商列出存储类型及原生取值
+func TestStorageClassesCommand(t *testing.T) {
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"storage-classes", "--provider", "qiniu"})
+	defer func() {
+		rootCmd.SetArgs(nil)
+		storageClassesProvider = ""
+	}()
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("storage-classes failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "七牛云 Kodo (qiniu)") {
+		t.Errorf("output should contain provider header:\n%s", output)
+	}
+	if !strings.Contains(output, "LINE") {
+		t.Errorf("output should contain qiniu native value for ia:\n%s", output)
+	}
+	if strings.Contains(output, "aws") {
+		t.Errorf("output should only list qiniu:\n%s", output)
+	}
+}
+
+// TestStorageClassesUnknownProvider 测试未知提供商
+func TestStorageClassesUnknownProvider(t *testing.T) {
+	storageClassesProvider = "gcp"
+	defer func() { storageClassesProvider = "" }()
+
+	if err := runStorageClasses(storageClassesCmd, nil); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
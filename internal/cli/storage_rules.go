@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/lukelzlz/s3backup/pkg/config"
+)
+
+// backupGroup 使用同一存储类型上传的一组路径
+type backupGroup struct {
+	StorageClass string
+	Includes     []string
+	Excludes     []string // 被更深的规则拆分到其他组的子路径
+}
+
+// planBackupGroups 按 storage_class_rules 将包含路径分组，每组单独上传
+//
+// 路径使用最深的祖先规则（或自身规则）的存储类型，没有匹配规则时使用 defaultClass。
+// 规则路径位于某个包含路径内部且存储类型不同时，该子路径从所在组中排除，
+// 作为独立的包含路径加入对应存储类型的组。不存在的规则路径会被忽略。
+// 组按首次出现的顺序排列。
+func planBackupGroups(includes []string, rules []config.StorageClassRule, defaultClass string) []backupGroup {
+	type absRule struct {
+		path  string
+		class string
+	}
+	var absRules []absRule
+	for _, r := range rules {
+		p, err := filepath.Abs(r.Path)
+		if err != nil {
+			continue
+		}
+		absRules = append(absRules, absRule{path: p, class: r.StorageClass})
+	}
+
+	// classOf 返回 path 的存储类型：最深的祖先或自身规则
+	classOf := func(path string) string {
+		class, depth := defaultClass, -1
+		for _, r := range absRules {
+			if isWithin(path, r.path) && len(r.path) > depth {
+				class, depth = r.class, len(r.path)
+			}
+		}
+		return class
+	}
+
+	var groups []backupGroup
+	index := make(map[string]int)
+	group := func(class string) *backupGroup {
+		i, ok := index[class]
+		if !ok {
+			i = len(groups)
+			index[class] = i
+			groups = append(groups, backupGroup{StorageClass: class})
+		}
+		return &groups[i]
+	}
+
+	for _, include := range includes {
+		absInclude, err := filepath.Abs(include)
+		if err != nil {
+			absInclude = include
+		}
+		g := group(classOf(absInclude))
+		g.Includes = append(g.Includes, include)
+
+		for _, r := range absRules {
+			if r.path == absInclude || !isWithin(r.path, absInclude) {
+				continue
+			}
+			// 与父路径存储类型相同时不需要拆分
+			parentClass := classOf(filepath.Dir(r.path))
+			if r.class == parentClass {
+				continue
+			}
+			if _, err := os.Lstat(r.path); err != nil {
+				continue
+			}
+
+			// 子路径使用与归档器相同的形式（基于包含路径拼接）
+			rel, _ := filepath.Rel(absInclude, r.path)
+			sub := filepath.Join(include, rel)
+			parent := group(parentClass)
+			parent.Excludes = append(parent.Excludes, glob.QuoteMeta(filepath.ToSlash(sub)))
+			child := group(r.class)
+			child.Includes = append(child.Includes, sub)
+		}
+	}
+
+	planned := groups[:0]
+	for _, g := range groups {
+		if len(g.Includes) > 0 {
+			planned = append(planned, g)
+		}
+	}
+	return planned
+}
+
+// isWithin 判断 path 是否等于 dir 或位于 dir 之下
+func isWithin(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// groupBackupName 在备份文件名中插入存储类型，例如 backup-x.tar.gz.enc -> backup-x-archive.tar.gz.enc
+func groupBackupName(name, class string) string {
+	for _, suffix := range []string{".tar.gz.enc", ".tar.gz", ".tar.zst.enc", ".tar.zst"} {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix) + "-" + class + suffix
+		}
+	}
+	return name + "-" + class
+}
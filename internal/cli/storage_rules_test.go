@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+)
+
+// archivedFiles 归档并返回 tar 中的普通文件名（去掉 root 前缀）
+func archivedFiles(t *testing.T, root string, includes, excludes []string) []string {
+	t.Helper()
+	cfg := &config.Config{Backup: config.BackupConfig{Excludes: excludes}}
+	var buf bytes.Buffer
+	if _, err := writeArchive(context.Background(), &buf, cfg, includes, nil); err != nil {
+		t.Fatalf("writeArchive() error = %v", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			rel, _ := filepath.Rel(root, "/"+hdr.Name)
+			files = append(files, filepath.ToSlash(rel))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// TestPlanBackupGroups 测试按路径规则拆分备份，每个文件只出现在一个组中
+func TestPlanBackupGroups(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"srv/app.conf", "srv/logs/a.log", "srv/logs/keep/important.log", "etc/hosts"} {
+		p := filepath.Join(root, f)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		os.WriteFile(p, []byte(f), 0644)
+	}
+
+	srv := filepath.Join(root, "srv")
+	etc := filepath.Join(root, "etc")
+	rules := []config.StorageClassRule{
+		{Path: filepath.Join(srv, "logs"), StorageClass: "archive"},
+		{Path: filepath.Join(srv, "logs", "keep"), StorageClass: "standard"},
+		{Path: etc, StorageClass: "ia"},
+		{Path: filepath.Join(root, "missing"), StorageClass: "deep_archive"},
+	}
+
+	groups := planBackupGroups([]string{srv, etc}, rules, "standard")
+	got := make(map[string][]string)
+	for _, g := range groups {
+		got[g.StorageClass] = archivedFiles(t, root, g.Includes, g.Excludes)
+	}
+
+	want := map[string][]string{
+		"standard": {"srv/app.conf", "srv/logs/keep/important.log"},
+		"archive":  {"srv/logs/a.log"},
+		"ia":       {"etc/hosts"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+	if groups[0].StorageClass != "standard" {
+		t.Errorf("first group = %s, want standard", groups[0].StorageClass)
+	}
+}
+
+// TestPlanBackupGroupsNoRules 测试没有规则时只有一个组
+func TestPlanBackupGroupsNoRules(t *testing.T) {
+	groups := planBackupGroups([]string{"a", "b"}, nil, "ia")
+	if len(groups) != 1 || groups[0].StorageClass != "ia" || len(groups[0].Includes) != 2 {
+		t.Errorf("unexpected groups: %+v", groups)
+	}
+}
+
+// TestGroupBackupName 测试拆分后的备份文件名
+func TestGroupBackupName(t *testing.T) {
+	tests := map[string]string{
+		"backup-20240101.tar.gz":     "backup-20240101-archive.tar.gz",
+		"backup-20240101.tar.gz.enc": "backup-20240101-archive.tar.gz.enc",
+		"nightly":                    "nightly-archive",
+	}
+	for name, want := range tests {
+		if got := groupBackupName(name, "archive"); got != want {
+			t.Errorf("groupBackupName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	thawDays   int
+	thawTier   string
+	thawStatus bool
+	thawWait   bool
+)
+
+// thawPollInterval --wait 时查询取回状态的间隔
+var thawPollInterval = 5 * time.Minute
+
+// thawCmd 取回归档备份命令
+var thawCmd = &cobra.Command{
+	Use:   "thaw <backup>...",
+	Short: "取回归档存储类型的备份，使其可以下载还原",
+	Long: `对归档类存储类型的备份发起取回（AWS S3 的 GLACIER、DEEP_ARCHIVE，阿里云 OSS 的 Archive、ColdArchive、DeepColdArchive），
+取回完成后的 --days 天内可以用 restore、rehearse、verify 等命令下载。
+
+--tier 选择取回速度：expedited、standard、bulk，越快费用越高。阿里云归档存储不区分档位，深度冷归档不支持 bulk。
+取回通常需要数分钟到数十小时：--status 只查询取回状态，--wait 发起取回后等待全部完成。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runThaw,
+}
+
+func init() {
+	rootCmd.AddCommand(thawCmd)
+
+	addConfigFlags(thawCmd)
+	thawCmd.Flags().IntVar(&thawDays, "days", 1, "取回的临时副本保留天数")
+	thawCmd.Flags().StringVar(&thawTier, "tier", "standard", "取回速度: expedited, standard, bulk")
+	thawCmd.Flags().BoolVar(&thawStatus, "status", false, "只查询取回状态，不发起取回")
+	thawCmd.Flags().BoolVar(&thawWait, "wait", false, "等待取回完成后再退出")
+}
+
+func runThaw(cmd *cobra.Command, args []string) error {
+	if thawDays <= 0 {
+		return fmt.Errorf("--days must be positive")
+	}
+	tier, err := storage.ParseRestoreTier(thawTier)
+	if err != nil {
+		return err
+	}
+
+	// 深度归档的标准取回最长需要 48 小时
+	ctx, cancel := context.WithTimeout(context.Background(), 72*time.Hour)
+	defer cancel()
+
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	adapter, err := createRestoreAdapter(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create storage adapter: %w", err)
+	}
+	restorer, ok := adapter.(storage.Restorer)
+	if !ok {
+		return fmt.Errorf("provider %s does not support restoring archived objects", cfg.Storage.Provider)
+	}
+
+	pending, err := thawBackups(ctx, os.Stdout, restorer, args, thawDays, tier, thawStatus)
+	if err != nil {
+		return err
+	}
+	if !thawWait || len(pending) == 0 {
+		return nil
+	}
+	return waitThawed(ctx, os.Stdout, restorer, pending, thawPollInterval)
+}
+
+// thawBackups 查询各备份的取回状态，statusOnly 为 false 时对尚未取回的归档备份发起取回
+// 返回取回尚未完成的备份
+func thawBackups(ctx context.Context, w io.Writer, restorer storage.Restorer, names []string,
+	days int, tier storage.RestoreTier, statusOnly bool) ([]string, error) {
+	var pending []string
+	for _, name := range names {
+		status, err := restorer.RestoreStatus(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get restore status of %s: %w", name, err)
+		}
+		switch {
+		case !status.Archived:
+			i18n.Fprintf(w, "%s: 存储类型 %s 可以直接读取，无需取回\n", name, status.StorageClass)
+		case status.Readable():
+			i18n.Fprintf(w, "%s: 已取回，临时副本保留到 %s\n", name, status.Expiry.Local().Format("2006-01-02 15:04:05"))
+		case status.Ongoing:
+			i18n.Fprintf(w, "%s: 取回进行中\n", name)
+			pending = append(pending, name)
+		case statusOnly:
+			i18n.Fprintf(w, "%s: 存储类型 %s，尚未取回\n", name, status.StorageClass)
+			pending = append(pending, name)
+		default:
+			err := restorer.RestoreObject(ctx, name, days, tier)
+			if errors.Is(err, storage.ErrRestoreInProgress) {
+				i18n.Fprintf(w, "%s: 取回进行中\n", name)
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to restore %s: %w", name, err)
+			} else {
+				i18n.Fprintf(w, "%s: 已发起取回（存储类型 %s，档位 %s，保留 %d 天）\n", name, status.StorageClass, tier, days)
+			}
+			pending = append(pending, name)
+		}
+	}
+	return pending, nil
+}
+
+// waitThawed 每隔 interval 查询一次取回状态，直到 pending 中的备份全部取回
+func waitThawed(ctx context.Context, w io.Writer, restorer storage.Restorer, pending []string, interval time.Duration) error {
+	i18n.Fprintf(w, "等待 %d 个备份取回完成，每 %s 查询一次\n", len(pending), interval)
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for restore: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+
+		var still []string
+		for _, name := range pending {
+			status, err := restorer.RestoreStatus(ctx, name)
+			if err != nil {
+				return fmt.Errorf("failed to get restore status of %s: %w", name, err)
+			}
+			if !status.Readable() {
+				still = append(still, name)
+				continue
+			}
+			i18n.Fprintf(w, "%s: 已取回，临时副本保留到 %s\n", name, status.Expiry.Local().Format("2006-01-02 15:04:05"))
+		}
+		pending = still
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestThawBackups 测试只对尚未取回的归档备份发起取回
+func TestThawBackups(t *testing.T) {
+	adapter := mock.New()
+	adapter.SetObject("hot", &mock.Object{StorageClass: storage.StorageClassStandard})
+	adapter.SetObject("cold", &mock.Object{StorageClass: storage.StorageClassDeepArchive})
+	ctx := context.Background()
+	var buf bytes.Buffer
+
+	pending, err := thawBackups(ctx, &buf, adapter, []string{"hot", "cold"}, 1, storage.RestoreTierStandard, true)
+	if err != nil {
+		t.Fatalf("thawBackups(status) error = %v", err)
+	}
+	if adapter.Calls(mock.OpRestoreObject) != 0 {
+		t.Error("--status should not start a restore")
+	}
+	if len(pending) != 1 || pending[0] != "cold" {
+		t.Errorf("pending = %v, want [cold]", pending)
+	}
+
+	for i := 0; i < 2; i++ {
+		pending, err = thawBackups(ctx, &buf, adapter, []string{"hot", "cold"}, 3, storage.RestoreTierBulk, false)
+		if err != nil {
+			t.Fatalf("thawBackups() error = %v", err)
+		}
+		if len(pending) != 1 || pending[0] != "cold" {
+			t.Errorf("pending = %v, want [cold]", pending)
+		}
+	}
+	// 第二次调用时取回已在进行，不再重复发起
+	if n := adapter.Calls(mock.OpRestoreObject); n != 1 {
+		t.Errorf("RestoreObject calls = %d, want 1", n)
+	}
+}
+
+// TestWaitThawed 测试等待取回完成
+func TestWaitThawed(t *testing.T) {
+	adapter := mock.New()
+	adapter.SetObject("cold", &mock.Object{StorageClass: storage.StorageClassArchive, Restoring: true})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		adapter.FinishRestore("cold", time.Now().Add(24*time.Hour))
+	}()
+
+	var buf bytes.Buffer
+	if err := waitThawed(context.Background(), &buf, adapter, []string{"cold"}, 5*time.Millisecond); err != nil {
+		t.Fatalf("waitThawed() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "cold") {
+		t.Errorf("output = %q, want the restored backup", buf.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	adapter.SetObject("never", &mock.Object{StorageClass: storage.StorageClassArchive, Restoring: true})
+	if err := waitThawed(ctx, &buf, adapter, []string{"never"}, 5*time.Millisecond); err == nil {
+		t.Error("waitThawed() should time out")
+	}
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/tracing"
+	"github.com/lukelzlz/s3backup/pkg/version"
+)
+
+// tracingShutdown 导出尚未发送的 span，setupTracing 成功后设置
+var tracingShutdown func(context.Context) error
+
+// setupTracing 根据 OTEL_* 和 TRACEPARENT 环境变量初始化 OpenTelemetry
+// 初始化失败只输出警告，不影响备份
+func setupTracing() {
+	if tracingShutdown != nil {
+		return
+	}
+	shutdown, err := tracing.Setup(context.Background(), version.Version)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "警告: 初始化 OpenTelemetry 失败: %v\n", err)
+		return
+	}
+	tracingShutdown = shutdown
+}
+
+// shutdownTracing 命令结束时导出剩余的 span，最多等待 5 秒，避免收集器不可用时阻塞退出
+func shutdownTracing() {
+	if tracingShutdown == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracingShutdown(ctx); err != nil {
+		i18n.Fprintf(os.Stderr, "警告: 导出 OpenTelemetry span 失败: %v\n", err)
+	}
+	tracingShutdown = nil
+}
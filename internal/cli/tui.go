@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/tui"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// exitInterrupted 在仪表盘中按 Ctrl+C 中断时的退出码，与 shell 中进程被 SIGINT 终止时一致
+const exitInterrupted = 130
+
+var (
+	tuiMode bool
+
+	// dashboard --tui 模式下的仪表盘，backupOnce 用作进度报告器，归档器向其报告当前文件
+	dashboard *tui.Dashboard
+)
+
+// addTUIFlags 注册 --tui 参数
+func addTUIFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&tuiMode, "tui", false, "显示交互式仪表盘（当前文件、各 worker 的分块进度、吞吐量曲线、重试次数和最近的日志）")
+}
+
+// withTUI 包装命令的 RunE：启用 --tui 时在终端显示仪表盘，命令的输出显示在仪表盘的日志区域
+func withTUI(run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if !tuiMode {
+			return run(cmd, args)
+		}
+		if k8sMode || quiet || noColor {
+			return fmt.Errorf("--tui cannot be used with --k8s, --quiet or --no-color (NO_COLOR)")
+		}
+		out := os.Stdout
+		if !term.IsTerminal(int(out.Fd())) {
+			return fmt.Errorf("--tui requires an interactive terminal")
+		}
+		// 标准输入用于读取文件列表时不读取键盘，Ctrl+C 仍以信号的方式中断
+		var in io.Reader = os.Stdin
+		if filesFrom == "-" || excludeFrom == "-" {
+			in = nil
+		}
+
+		dashboard = tui.New("s3backup " + cmd.Name())
+		defer func() { dashboard = nil }()
+
+		restoreStdout, err := captureOutput(&os.Stdout, dashboard.Log)
+		if err != nil {
+			return err
+		}
+		restoreStderr, err := captureOutput(&os.Stderr, dashboard.Log)
+		if err != nil {
+			restoreStdout()
+			return err
+		}
+		restore := func() {
+			restoreStderr()
+			restoreStdout()
+		}
+
+		stop := dashboard.Start(out, in, func() {
+			restore()
+			i18n.Fprintf(os.Stderr, "已中断\n")
+			os.Exit(exitInterrupted)
+		})
+		runErr := run(cmd, args)
+		stop()
+		restore()
+		return runErr
+	}
+}
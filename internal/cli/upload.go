@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/uploader"
+	"github.com/spf13/cobra"
+)
+
+var uploadName string
+
+// uploadCmd 上传本地文件命令
+var uploadCmd = &cobra.Command{
+	Use:   "upload <file>",
+	Short: "上传已有的本地文件（不重新打包）",
+	Long: `将已有的本地文件（如数据库导出或 pack 生成的备份）直接上传，可选加密。
+
+上传中断后再次执行相同的命令即可续传：分块按文件偏移读取，已上传的分块不会重新读取或上传。
+续传前会校验文件大小和修改时间，文件发生变化时需要删除状态文件后重新上传。
+启用加密时续传使用相同的文件头（IV），完成前需要顺序读取一遍整个文件计算 HMAC。`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUpload,
+}
+
+func init() {
+	rootCmd.AddCommand(uploadCmd)
+
+	addConfigFlags(uploadCmd)
+	uploadCmd.Flags().StringVarP(&uploadName, "name", "n", "", "对象名（默认：文件名，启用加密时追加 .enc）")
+	uploadCmd.Flags().BoolVar(&noProgress, "no-progress", false, "禁用进度条")
+	uploadCmd.Flags().Bool("overwrite", false, "允许覆盖存储中已存在的同名对象（默认已存在时失败）")
+	uploadCmd.Flags().String("state-dir", "", "状态文件目录（用于断点续传，默认 ~/.s3backup/state/<机器标识>）")
+}
+
+func runUpload(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
+	defer cancel()
+
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	source, err := filepath.Abs(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	key := uploadName
+	if key == "" {
+		key = filepath.Base(source)
+		if cfg.Encryption.Enabled {
+			key += ".enc"
+		}
+	}
+
+	stateMgr, err := newStateManager(cfg, key)
+	if err != nil {
+		return err
+	}
+	saved, err := loadState(cfg, stateMgr)
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	if saved != nil && saved.Source == "" {
+		return fmt.Errorf("an unfinished backup named %s exists, use resume or remove %s", key, stateMgr.GetStateFile())
+	}
+
+	adapter, err := createStorageAdapter(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create storage adapter: %w", err)
+	}
+	if saved == nil && !cfg.Backup.Overwrite {
+		if err := checkNotExists(ctx, adapter, key); err != nil {
+			return err
+		}
+	}
+
+	return uploadFile(ctx, cfg, adapter, stateMgr, saved, source, key, uploadFileOptions{})
+}
+
+// uploadFileOptions 新建上传时的附加选项，续传时使用状态文件中记录的设置
+type uploadFileOptions struct {
+	Metadata map[string]string // 对象元数据
+	Spooled  bool              // 源文件是 backup.spool_dir 中的临时文件，上传完成后删除
+}
+
+// uploadFile 按偏移分块上传本地文件，saved 不为 nil 时从保存的状态续传
+func uploadFile(ctx context.Context, cfg *config.Config, adapter storage.StorageAdapter,
+	stateMgr *state.StateManager, saved *state.UploadState, source, key string, extra uploadFileOptions) error {
+
+	f, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file: %s", source)
+	}
+
+	if saved != nil {
+		if saved.Source != source || saved.SourceSize != info.Size() || !saved.SourceModTime.Equal(info.ModTime()) {
+			return fmt.Errorf("file %s has changed since the upload started, run `s3backup resume --force-restart %s` to start over", source, key)
+		}
+		if err := checkStateEncryption(cfg, saved); err != nil {
+			return err
+		}
+	}
+
+	body, size, header, keyCheck, err := openUploadBody(cfg, f, info.Size(), saved)
+	if err != nil {
+		return err
+	}
+
+	contentType := "application/octet-stream"
+	if !cfg.Encryption.Enabled {
+		contentType = storage.ContentTypeFor(key)
+	}
+	opts := storage.UploadOptions{
+		StorageClass:       storage.ParseStorageClass(cfg.Storage.StorageClass),
+		ContentType:        contentType,
+		ContentDisposition: storage.ContentDispositionFor(key),
+		Metadata:           extra.Metadata,
+		ACL:                cfg.Storage.ACL,
+		NoOverwrite:        !cfg.Backup.Overwrite,
+	}
+	// 续传时使用开始上传时的元数据和覆盖设置，而不是本次运行的配置
+	if saved != nil {
+		opts.Metadata, opts.NoOverwrite = saved.Metadata, saved.NoOverwrite
+	}
+
+	var upl *uploader.ResumableUploader
+	if saved == nil {
+		upl = uploader.NewResumableUploader(adapter, uploader.ChunkSizeFor(size, cfg.Backup.ChunkSize), cfg.Backup.Concurrency, nil)
+		uploadID, err := adapter.InitMultipartUpload(ctx, key, opts)
+		if err != nil {
+			return fmt.Errorf("failed to init multipart upload: %w", err)
+		}
+		saved = &state.UploadState{
+			Key:           key,
+			UploadID:      uploadID,
+			Bucket:        cfg.Storage.Bucket,
+			Provider:      cfg.Storage.Provider,
+			Endpoint:      cfg.Storage.Endpoint,
+			Region:        cfg.Storage.Region,
+			StorageClass:  cfg.Storage.StorageClass,
+			Completed:     []state.CompletedPart{},
+			TotalBytes:    size,
+			Source:        source,
+			Spooled:       extra.Spooled,
+			SourceSize:    info.Size(),
+			SourceModTime: info.ModTime(),
+			ChunkSize:     upl.ChunkSize(),
+			Header:        header,
+			KeyCheck:      keyCheck,
+			NoOverwrite:   opts.NoOverwrite,
+			Metadata:      opts.Metadata,
+		}
+		var h *crypto.Header
+		if header != nil {
+			if h, err = crypto.ParseHeader(header); err != nil {
+				return err
+			}
+		}
+		if err := setStateEncryption(cfg, saved, h); err != nil {
+			return err
+		}
+		if err := stateMgr.Save(saved); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+	} else {
+		upl = uploader.NewResumableUploader(adapter, saved.ChunkSize, cfg.Backup.Concurrency, saved)
+		if upl.ChunkSize() != saved.ChunkSize {
+			return fmt.Errorf("saved chunk size %d is not allowed by %s", saved.ChunkSize, saved.Provider)
+		}
+	}
+	upl.SetStateManager(stateMgr)
+	upl.SetHeartbeat(heartbeatFor(cfg))
+	upl.SetReadAhead(cfg.Backup.ReadAhead)
+	upl.SetETagRetries(cfg.Backup.ETagRetries)
+
+	reporter := newProgressReporter()
+	if dashboard != nil {
+		reporter = dashboard
+	}
+	upl.SetProgressReporter(reporter)
+	defer reporter.Close()
+
+	i18n.Printf("上传文件:\n")
+	i18n.Printf("  源文件: %s\n", source)
+	i18n.Printf("  对象名: %s\n", key)
+	i18n.Printf("  大小: %d 字节\n", size)
+	i18n.Printf("  加密: %v\n", cfg.Encryption.Enabled)
+	i18n.Printf("  分块大小: %d MB\n", saved.ChunkSize/1024/1024)
+	if len(saved.Completed) > 0 {
+		i18n.Printf("  续传: 已完成 %d 个分块\n", len(saved.Completed))
+	}
+	fmt.Println()
+
+	if err := upl.ResumeAt(ctx, key, saved.UploadID, body, size, opts); err != nil {
+		if hint := errorHint(err); hint != "" {
+			i18n.Printf("\n提示: %s\n", hint)
+		}
+		if errors.Is(err, storage.ErrUploadNotFound) {
+			stateMgr.Delete()
+			i18n.Printf("\n上传失败，已删除失效的状态文件。再次执行相同的 upload 命令将重新上传。\n")
+			return err
+		}
+		stateMgr.Flush()
+		i18n.Printf("\n上传失败，状态已保存。使用以下命令恢复:\n")
+		fmt.Printf("  s3backup resume %s\n", key)
+		return err
+	}
+
+	stateMgr.Delete()
+	if saved.Spooled {
+		if err := os.Remove(source); err != nil {
+			i18n.Printf("警告: 删除临时文件失败: %v\n", err)
+		}
+	}
+	printReuploaded(upl.Reuploaded())
+	i18n.Printf("上传成功: %s\n", key)
+	return nil
+}
+
+// openUploadBody 返回实际上传的数据及其大小；启用加密时返回加密后的数据、文件头和密钥校验值
+// 续传时使用保存的文件头，保证重新生成的密文与已上传的分块一致
+func openUploadBody(cfg *config.Config, f io.ReaderAt, fileSize int64, saved *state.UploadState) (body io.ReaderAt, size int64, header, keyCheck []byte, err error) {
+	if !cfg.Encryption.Enabled {
+		return f, fileSize, nil, nil, nil
+	}
+
+	var encryptor *crypto.StreamEncryptor
+	var h *crypto.Header
+	if saved == nil {
+		if encryptor, err = createEncryptor(cfg); err != nil {
+			return nil, 0, nil, nil, err
+		}
+		if h, err = encryptor.NewHeader(); err != nil {
+			return nil, 0, nil, nil, err
+		}
+	} else if encryptor, h, err = savedEncryptor(cfg, saved); err != nil {
+		return nil, 0, nil, nil, err
+	}
+
+	r, err := encryptor.NewReaderAt(f, fileSize, h)
+	if err != nil {
+		return nil, 0, nil, nil, fmt.Errorf("failed to create encrypted reader: %w", err)
+	}
+	return r, r.Size(), h.Bytes(), encryptor.KeyCheck(h), nil
+}
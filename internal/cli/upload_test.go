@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestUploadFileResumeEncrypted 测试加密上传中断后按偏移续传，已上传的分块不再上传
+func TestUploadFileResumeEncrypted(t *testing.T) {
+	noProgress = true
+	dir := t.TempDir()
+
+	plaintext := make([]byte, 10*1024+123)
+	rand.New(rand.NewSource(1)).Read(plaintext)
+	source := filepath.Join(dir, "dump.sql")
+	os.WriteFile(source, plaintext, 0644)
+
+	keyData, _ := crypto.GenerateKeyFile()
+	keyPath := filepath.Join(dir, "key")
+	os.WriteFile(keyPath, keyData, 0600)
+
+	cfg := &config.Config{
+		Encryption: config.EncryptionConfig{Enabled: true, KeyFile: keyPath},
+		Backup:     config.BackupConfig{ChunkSize: 1024, Concurrency: 1},
+	}
+
+	adapter := mock.New()
+	adapter.FailPart(4, 0, mock.ErrInjected, 1)
+	stateMgr := state.NewStateManager(filepath.Join(dir, "state"), "dump.sql.enc")
+
+	ctx := context.Background()
+	if err := uploadFile(ctx, cfg, adapter, stateMgr, nil, source, "dump.sql.enc", uploadFileOptions{}); err == nil {
+		t.Fatal("expected first upload to fail")
+	}
+	saved := stateMgr.GetState()
+	if saved == nil || len(saved.Completed) != 3 {
+		t.Fatalf("expected 3 completed parts in state, got %+v", saved)
+	}
+	uploadsBefore := adapter.Calls(mock.OpUploadPart)
+
+	if err := uploadFile(ctx, cfg, adapter, stateMgr, saved, source, "dump.sql.enc", uploadFileOptions{}); err != nil {
+		t.Fatalf("resume error = %v", err)
+	}
+
+	totalParts := int((int64(crypto.HeaderSize) + int64(len(plaintext)) + crypto.TrailerSize + 1023) / 1024)
+	if got := adapter.Calls(mock.OpUploadPart) - uploadsBefore; got != totalParts-3 {
+		t.Errorf("resume uploaded %d parts, want %d", got, totalParts-3)
+	}
+
+	obj, ok := adapter.Object("dump.sql.enc")
+	if !ok {
+		t.Fatal("object not created")
+	}
+	r, _, err := crypto.OpenReader(bytes.NewReader(obj.Data), crypto.KeySource{KeyFile: keyData})
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decrypt error = %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Error("uploaded data does not match source file")
+	}
+	if obj.ContentType != "application/octet-stream" || obj.ContentDisposition != "attachment; filename=dump.sql.enc" {
+		t.Errorf("content type = %q, disposition = %q", obj.ContentType, obj.ContentDisposition)
+	}
+	if _, err := os.Stat(stateMgr.GetStateFile()); !os.IsNotExist(err) {
+		t.Error("state file should be removed after success")
+	}
+}
+
+// TestUploadFileResumeRejectsChanges 测试源文件或密钥改变时拒绝续传
+func TestUploadFileResumeRejectsChanges(t *testing.T) {
+	noProgress = true
+	dir := t.TempDir()
+	source := filepath.Join(dir, "data.bin")
+	os.WriteFile(source, bytes.Repeat([]byte("d"), 4096), 0644)
+
+	cfg := &config.Config{
+		Encryption: config.EncryptionConfig{Enabled: true, Password: "first"},
+		Backup:     config.BackupConfig{ChunkSize: 1024, Concurrency: 1},
+	}
+	adapter := mock.New()
+	adapter.FailPart(2, 0, mock.ErrInjected, 1)
+	stateMgr := state.NewStateManager(filepath.Join(dir, "state"), "data.bin.enc")
+
+	ctx := context.Background()
+	if err := uploadFile(ctx, cfg, adapter, stateMgr, nil, source, "data.bin.enc", uploadFileOptions{}); err == nil {
+		t.Fatal("expected first upload to fail")
+	}
+	saved := stateMgr.GetState()
+
+	cfg.Encryption.Password = "second"
+	if err := uploadFile(ctx, cfg, adapter, stateMgr, saved, source, "data.bin.enc", uploadFileOptions{}); err == nil {
+		t.Error("expected error when resuming with a different password")
+	}
+
+	cfg.Encryption.Password = "first"
+	os.WriteFile(source, bytes.Repeat([]byte("d"), 5000), 0644)
+	if err := uploadFile(ctx, cfg, adapter, stateMgr, saved, source, "data.bin.enc", uploadFileOptions{}); err == nil {
+		t.Error("expected error when source file changed")
+	}
+}
+
+// TestRestartUpload 测试源文件变化后 --force-restart 取消旧的分块上传并从头重新上传
+func TestRestartUpload(t *testing.T) {
+	noProgress = true
+	dir := t.TempDir()
+	source := filepath.Join(dir, "data.bin")
+	os.WriteFile(source, bytes.Repeat([]byte("d"), 4096), 0644)
+
+	cfg := &config.Config{Backup: config.BackupConfig{ChunkSize: 1024, Concurrency: 1}}
+	adapter := mock.New()
+	adapter.FailPart(2, 0, mock.ErrInjected, 1)
+	stateMgr := state.NewStateManager(filepath.Join(dir, "state"), "data.bin")
+
+	ctx := context.Background()
+	if err := uploadFile(ctx, cfg, adapter, stateMgr, nil, source, "data.bin", uploadFileOptions{}); err == nil {
+		t.Fatal("expected first upload to fail")
+	}
+	saved := stateMgr.GetState()
+
+	changed := bytes.Repeat([]byte("e"), 5000)
+	os.WriteFile(source, changed, 0644)
+	if err := restartUpload(ctx, cfg, adapter, stateMgr, saved, "data.bin"); err != nil {
+		t.Fatalf("restartUpload() error = %v", err)
+	}
+
+	obj, ok := adapter.Object("data.bin")
+	if !ok || !bytes.Equal(obj.Data, changed) {
+		t.Error("object should contain the changed file")
+	}
+	if pending := adapter.PendingUploads(); len(pending) != 0 {
+		t.Errorf("old multipart upload not aborted: %v", pending)
+	}
+	if _, err := os.Stat(stateMgr.GetStateFile()); !os.IsNotExist(err) {
+		t.Error("state file should be removed after success")
+	}
+}
+
+// TestLoadStateEncrypted 测试启用 encrypt_state 时状态文件加密，续传时使用配置的密钥解密
+func TestLoadStateEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	keyData, _ := crypto.GenerateKeyFile()
+	keyPath := filepath.Join(dir, "key")
+	os.WriteFile(keyPath, keyData, 0600)
+
+	cfg := &config.Config{
+		Encryption: config.EncryptionConfig{KeyFile: keyPath, EncryptState: true},
+		State:      config.StateConfig{Dir: dir},
+	}
+	stateMgr, err := newStateManager(cfg, "backup.tar.gz")
+	if err != nil {
+		t.Fatalf("newStateManager() error = %v", err)
+	}
+	if err := stateMgr.Save(&state.UploadState{Key: "backup.tar.gz", UploadID: "upload-id"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadState(&config.Config{}, state.NewStateManager(dir, "backup.tar.gz")); !errors.Is(err, state.ErrEncrypted) {
+		t.Errorf("loadState() without key error = %v, want ErrEncrypted", err)
+	}
+
+	// resume 不需要 --encrypt-state，只需提供密钥
+	resumeCfg := &config.Config{Encryption: config.EncryptionConfig{KeyFile: keyPath}}
+	saved, err := loadState(resumeCfg, state.NewStateManager(dir, "backup.tar.gz"))
+	if err != nil {
+		t.Fatalf("loadState() error = %v", err)
+	}
+	if saved.UploadID != "upload-id" {
+		t.Errorf("unexpected state: %+v", saved)
+	}
+}
+
+// TestCheckStateEncryption 测试状态记录的加密参数与当前密钥不一致时拒绝续传
+func TestCheckStateEncryption(t *testing.T) {
+	dir := t.TempDir()
+	keyA := filepath.Join(dir, "a.key")
+	keyB := filepath.Join(dir, "b.key")
+	for _, p := range []string{keyA, keyB} {
+		keyData, _ := crypto.GenerateKeyFile()
+		os.WriteFile(p, keyData, 0600)
+	}
+
+	cfg := &config.Config{Encryption: config.EncryptionConfig{Enabled: true, KeyFile: keyA}}
+	saved := &state.UploadState{}
+	if err := setStateEncryption(cfg, saved, nil); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Cipher != crypto.CipherName || saved.FormatVersion != crypto.FormatVersion || saved.KDF != "key-file" || saved.KeyFingerprint == "" {
+		t.Fatalf("unexpected encryption state: %+v", saved)
+	}
+	if err := checkStateEncryption(cfg, saved); err != nil {
+		t.Errorf("same key file rejected: %v", err)
+	}
+
+	cfg.Encryption.KeyFile = keyB
+	if err := checkStateEncryption(cfg, saved); err == nil {
+		t.Error("expected error for a different key file")
+	}
+	cfg.Encryption = config.EncryptionConfig{Enabled: true, Password: "secret"}
+	if err := checkStateEncryption(cfg, saved); err == nil {
+		t.Error("expected error when a password is given for a key-file upload")
+	}
+	cfg.Encryption.Enabled = false
+	if err := checkStateEncryption(cfg, saved); err == nil {
+		t.Error("expected error when encryption is disabled")
+	}
+
+	// 旧版本状态文件没有记录加密参数
+	legacy := &state.UploadState{Encrypted: true}
+	cfg.Encryption.Enabled = true
+	if err := checkStateEncryption(cfg, legacy); err != nil {
+		t.Errorf("legacy state rejected: %v", err)
+	}
+}
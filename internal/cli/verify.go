@@ -0,0 +1,393 @@
+package cli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyPubKey string
+	verifyLocal  bool
+	verifySig    string
+	verifySample string
+)
+
+// verifyCmd 验证备份签名命令
+var verifyCmd = &cobra.Command{
+	Use:   "verify <backup>",
+	Short: "验证备份的 Ed25519 签名",
+	Long: `下载存储桶中的备份及其 <backup>.sig 签名，计算备份的 SHA-256 并使用 --pubkey 公钥验证签名。
+签名由 backup --sign-key 生成，即使存储凭证泄露，没有签名私钥也无法伪造有效的备份。
+
+使用 --local 验证本地文件，签名默认为同目录下的 <file>.sig，可通过 --sig 指定。
+
+使用 --sample 只下载随机抽取的一部分数据段（如 --sample 5%），与备份报告 <backup>.report.json
+中记录的各段 SHA-256 比较，不需要下载整个对象，适合定期检查大型备份。需要备份时启用 backup.report。
+
+发现不一致时逐行列出损坏的数据段（每段 16MB）及其字节范围，而不只是整体失败；
+签名验证失败时如果存在备份报告，同样按报告中的分段 SHA-256 定位损坏的数据段。`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	addConfigFlags(verifyCmd)
+	verifyCmd.Flags().StringVar(&verifyPubKey, "pubkey", "", "Ed25519 公钥（PEM）")
+	verifyCmd.Flags().BoolVar(&verifyLocal, "local", false, "验证本地文件而不是存储桶中的对象")
+	verifyCmd.Flags().StringVar(&verifySig, "sig", "", "签名文件（--local 时默认为 <file>.sig）")
+	verifyCmd.Flags().StringVar(&verifySample, "sample", "", "抽样校验的数据比例（如 5%），按备份报告中的分段 SHA-256 校验，不验证签名")
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if verifySample != "" {
+		return runVerifySample(cmd, name)
+	}
+	if verifyPubKey == "" {
+		return fmt.Errorf("--pubkey is required (or use --sample)")
+	}
+	pub, err := crypto.LoadVerifyKey(verifyPubKey)
+	if err != nil {
+		return err
+	}
+
+	var sigData, reportData []byte
+	var data io.ReadCloser
+	if verifyLocal {
+		sigPath := verifySig
+		if sigPath == "" {
+			sigPath = name + crypto.SignatureSuffix
+		}
+		if sigData, err = os.ReadFile(sigPath); err != nil {
+			return fmt.Errorf("failed to read signature: %w", err)
+		}
+		if data, err = os.Open(name); err != nil {
+			return fmt.Errorf("failed to open backup: %w", err)
+		}
+		reportData, _ = os.ReadFile(name + reportSuffix)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
+		defer cancel()
+
+		adapter, cfg, err := verifyAdapter(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		reader, ok := adapter.(storage.ObjectReader)
+		if !ok {
+			return fmt.Errorf("provider %s does not support downloading objects", cfg.Storage.Provider)
+		}
+
+		sigKey := name + crypto.SignatureSuffix
+		if verifySig != "" {
+			sigKey = verifySig
+		}
+		if sigData, err = readObject(ctx, reader, sigKey); err != nil {
+			return fmt.Errorf("failed to download signature: %w", err)
+		}
+		// 备份报告只用于签名验证失败时定位损坏的数据段，没有报告时照常验证
+		reportData, _ = readObject(ctx, reader, name+reportSuffix)
+		if data, err = reader.GetObject(ctx, name); err != nil {
+			return fmt.Errorf("failed to download backup: %w", err)
+		}
+	}
+	defer data.Close()
+
+	hw := newHashingWriter(io.Discard)
+	sig, err := verifySignature(sigData, io.TeeReader(data, hw), pub)
+	if err != nil {
+		if corrupt := corruptChunks(reportData, hw); len(corrupt) > 0 {
+			printCorruptChunks(corrupt)
+			return fmt.Errorf("%w (%d corrupt chunks)", err, len(corrupt))
+		}
+		return err
+	}
+	i18n.Printf("签名验证通过: %s\n", name)
+	fmt.Printf("  SHA-256: %s\n", sig.SHA256)
+	i18n.Printf("  密钥: %s\n", sig.KeyID)
+	return nil
+}
+
+// runVerifySample 按 --sample 比例抽取数据段，与备份报告中的分段 SHA-256 比较
+func runVerifySample(cmd *cobra.Command, name string) error {
+	if verifyLocal {
+		return fmt.Errorf("--sample cannot be used with --local")
+	}
+	ratio, err := parseSampleRatio(verifySample)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
+	defer cancel()
+	adapter, cfg, err := verifyAdapter(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	reader, ok := adapter.(storage.ObjectReader)
+	ranges, ok2 := adapter.(storage.RangeReader)
+	if !ok || !ok2 {
+		return fmt.Errorf("provider %s does not support downloading object ranges", cfg.Storage.Provider)
+	}
+
+	result, err := verifySampled(ctx, reader, ranges, name, ratio, rand.IntN)
+	if result != nil {
+		printCorruptChunks(result.Corrupt)
+	}
+	if err != nil {
+		return err
+	}
+	i18n.Printf("抽样验证通过: %s\n", name)
+	i18n.Printf("  已校验 %d/%d 段，%d 字节\n", result.Checked, result.Chunks, result.Bytes)
+	return nil
+}
+
+// verifyAdapter 读取配置并创建存储适配器
+func verifyAdapter(ctx context.Context, cmd *cobra.Command) (storage.StorageAdapter, *config.Config, error) {
+	cfg, err := config.LoadConfigWithFlags(cfgFile, envFile, cmd.Flags())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid config: %w", err)
+	}
+	adapter, err := createRestoreAdapter(ctx, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create storage adapter: %w", err)
+	}
+	return adapter, cfg, nil
+}
+
+// parseSampleRatio 解析 --sample，接受百分比（5%）或 (0, 1] 之间的小数（0.05）
+func parseSampleRatio(s string) (float64, error) {
+	v, percent := strings.CutSuffix(strings.TrimSpace(s), "%")
+	ratio, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --sample %q: %w", s, err)
+	}
+	if percent {
+		ratio /= 100
+	}
+	if !(ratio > 0 && ratio <= 1) {
+		return 0, fmt.Errorf("invalid --sample %q: must be between 0%% and 100%%", s)
+	}
+	return ratio, nil
+}
+
+// ErrSampleMismatch 抽样的数据段与备份报告中记录的 SHA-256 不一致
+var ErrSampleMismatch = errors.New("sampled chunk does not match backup report")
+
+// sampleResult 抽样校验的结果
+type sampleResult struct {
+	Chunks  int            // 报告中记录的总段数
+	Checked int            // 已校验的段数
+	Bytes   int64          // 下载的字节数
+	Corrupt []corruptChunk // 与报告不一致的数据段
+}
+
+// corruptChunk 与备份报告中记录的 SHA-256 不一致的数据段
+type corruptChunk struct {
+	Index  int
+	Offset int64
+	Length int64
+}
+
+// verifySampled 下载备份报告，随机抽取 ratio 比例的数据段（至少一段，且总是包含最后一段以发现截断）
+// 逐段下载并与报告中的 SHA-256 比较，校验完所有抽中的段后返回不一致的段；intn 用于抽样，便于测试
+func verifySampled(ctx context.Context, reader storage.ObjectReader, ranges storage.RangeReader,
+	name string, ratio float64, intn func(int) int) (*sampleResult, error) {
+	data, err := readObject(ctx, reader, name+reportSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download backup report: %w", err)
+	}
+	var report backupReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse backup report: %w", err)
+	}
+	if report.ChunkSize <= 0 || len(report.ChunkSHA256) == 0 {
+		return nil, fmt.Errorf("backup report of %s has no chunk hashes (created by an older version?)", name)
+	}
+
+	total := len(report.ChunkSHA256)
+	picked := sampleChunks(total, ratio, intn)
+	result := &sampleResult{Chunks: total}
+	for _, i := range picked {
+		offset := int64(i) * report.ChunkSize
+		length := min(report.ChunkSize, report.Size-offset)
+		n, sum, err := hashRange(ctx, ranges, name, offset, length)
+		if err != nil {
+			return result, err
+		}
+		result.Checked++
+		result.Bytes += n
+		if n != length || sum != report.ChunkSHA256[i] {
+			result.Corrupt = append(result.Corrupt, corruptChunk{Index: i, Offset: offset, Length: length})
+		}
+	}
+	if len(result.Corrupt) > 0 {
+		c := result.Corrupt[0]
+		return result, fmt.Errorf("%w: %d of %d checked chunks of %s, first is chunk %d (bytes %d-%d)",
+			ErrSampleMismatch, len(result.Corrupt), result.Checked, name, c.Index, c.Offset, c.Offset+c.Length-1)
+	}
+	return result, nil
+}
+
+// corruptChunks 比较完整读取的备份的分段 SHA-256 与备份报告 reportData，返回不一致的段
+// 报告无法解析或没有记录分段 SHA-256 时返回 nil；备份被截断或变长时，多出或缺少的段同样视为损坏
+func corruptChunks(reportData []byte, hw *hashingWriter) []corruptChunk {
+	var report backupReport
+	if len(reportData) == 0 || json.Unmarshal(reportData, &report) != nil {
+		return nil
+	}
+	if report.ChunkSize != sampleChunkSize || len(report.ChunkSHA256) == 0 {
+		return nil
+	}
+	got := hw.Chunks()
+	var corrupt []corruptChunk
+	for i := range max(len(got), len(report.ChunkSHA256)) {
+		if i < len(got) && i < len(report.ChunkSHA256) && got[i] == report.ChunkSHA256[i] {
+			continue
+		}
+		offset := int64(i) * report.ChunkSize
+		length := min(report.ChunkSize, max(report.Size, hw.n)-offset)
+		corrupt = append(corrupt, corruptChunk{Index: i, Offset: offset, Length: length})
+	}
+	return corrupt
+}
+
+// printCorruptChunks 逐行输出损坏的数据段及其字节范围
+func printCorruptChunks(corrupt []corruptChunk) {
+	for _, c := range corrupt {
+		i18n.Printf("损坏的数据段: %d（字节 %d-%d）\n", c.Index, c.Offset, c.Offset+c.Length-1)
+	}
+}
+
+// sampleChunks 返回按升序排列的抽样段号，最后一段总是被选中
+func sampleChunks(total int, ratio float64, intn func(int) int) []int {
+	n := min(max(int(math.Ceil(float64(total)*ratio)), 1), total)
+	picked := map[int]bool{total - 1: true}
+	for len(picked) < n {
+		picked[intn(total-1)] = true
+	}
+	chunks := make([]int, 0, n)
+	for i := range picked {
+		chunks = append(chunks, i)
+	}
+	sort.Ints(chunks)
+	return chunks
+}
+
+// hashRange 下载对象的一段并返回实际读到的字节数和十六进制 SHA-256
+func hashRange(ctx context.Context, ranges storage.RangeReader, key string, offset, length int64) (int64, string, error) {
+	rc, err := ranges.GetObjectRange(ctx, key, offset, length)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to download backup: %w", err)
+	}
+	defer rc.Close()
+	h := sha256.New()
+	// 多读一个字节，服务端返回的数据多于请求的长度时同样视为不一致
+	n, err := io.Copy(h, io.LimitReader(rc, length+1))
+	if err != nil {
+		return n, "", fmt.Errorf("failed to download backup: %w", err)
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ErrUploadCheck 上传后校验发现存储中的备份与本地生成的数据不一致
+var ErrUploadCheck = errors.New("uploaded backup failed verification")
+
+// checkUpload 上传后校验：下载备份的第一段和最后一段（包含加密文件头和 HMAC），与上传时计算的分段 SHA-256 比较，
+// 并回读 reportKey 备份报告确认其中记录的 SHA-256；只下载很少的数据，适合每次定时备份后执行
+// 适配器不支持按范围下载时只输出警告
+func checkUpload(ctx context.Context, adapter storage.StorageAdapter, name string, hw *hashingWriter, reportKey string) error {
+	ranges, ok := adapter.(storage.RangeReader)
+	reader, ok2 := adapter.(storage.ObjectReader)
+	if !ok || !ok2 {
+		i18n.Printf("警告: 存储适配器不支持按范围下载，跳过上传后校验\n")
+		return nil
+	}
+
+	chunks := hw.Chunks()
+	var picked []int
+	if len(chunks) > 0 {
+		picked = append(picked, 0)
+	}
+	if len(chunks) > 1 {
+		picked = append(picked, len(chunks)-1)
+	}
+	for _, i := range picked {
+		offset := int64(i) * sampleChunkSize
+		length := min(sampleChunkSize, hw.n-offset)
+		n, sum, err := hashRange(ctx, ranges, name, offset, length)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrUploadCheck, err)
+		}
+		if n != length || sum != chunks[i] {
+			return fmt.Errorf("%w: chunk %d (bytes %d-%d) of %s does not match the uploaded data", ErrUploadCheck, i, offset, offset+length-1, name)
+		}
+	}
+
+	if reportKey != "" {
+		data, err := readObject(ctx, reader, reportKey)
+		if err != nil {
+			return fmt.Errorf("%w: failed to download backup report: %w", ErrUploadCheck, err)
+		}
+		var report backupReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return fmt.Errorf("%w: failed to parse backup report: %w", ErrUploadCheck, err)
+		}
+		if report.SHA256 != hw.Sum() || report.Size != hw.n {
+			return fmt.Errorf("%w: backup report %s does not match the uploaded data", ErrUploadCheck, reportKey)
+		}
+	}
+
+	i18n.Printf("上传后校验通过: 已核对 %d 个数据段\n", len(picked))
+	return nil
+}
+
+// verifySignature 计算 r 的 SHA-256 并用 pub 验证 sigData 中的签名
+func verifySignature(sigData []byte, r io.Reader, pub ed25519.PublicKey) (*crypto.Signature, error) {
+	sig, err := crypto.ParseSignature(sigData)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	if err := sig.Verify(pub, h.Sum(nil)); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// readObject 读取整个对象
+func readObject(ctx context.Context, reader storage.ObjectReader, key string) ([]byte, error) {
+	rc, err := reader.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math"
+	mrand "math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/archive"
+	"github.com/lukelzlz/s3backup/pkg/config"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestSignedBackupVerify 测试备份签名上传后可以用公钥验证，数据被修改时验证失败
+func TestSignedBackupVerify(t *testing.T) {
+	dir := t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	keyPath := filepath.Join(dir, "sign.key")
+	os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+
+	defer func(n bool) { noProgress = n }(noProgress)
+	noProgress = true
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{Provider: "aws", Bucket: "bucket"},
+		Backup:  config.BackupConfig{SignKey: keyPath},
+		State:   config.StateConfig{Dir: filepath.Join(dir, "state")},
+	}
+	adapter := mock.New()
+	name := "backup-20260101-000000.sql.gz"
+	payload := strings.Repeat("dump data\n", 1000)
+	err = backupOnce(context.Background(), cfg, adapter, name, nil, func(ctx context.Context, w io.Writer, _ *archive.CompressionMeter) error {
+		_, err := io.WriteString(w, payload)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("backupOnce() error = %v", err)
+	}
+
+	sigObj, ok := adapter.Object(name + crypto.SignatureSuffix)
+	if !ok {
+		t.Fatal("signature not uploaded")
+	}
+	obj, _ := adapter.Object(name)
+
+	sig, err := verifySignature(sigObj.Data, bytes.NewReader(obj.Data), pub)
+	if err != nil {
+		t.Fatalf("verifySignature() error = %v", err)
+	}
+	if sig.KeyID != crypto.KeyID(pub) {
+		t.Errorf("KeyID = %s, want %s", sig.KeyID, crypto.KeyID(pub))
+	}
+
+	tampered := append([]byte(nil), obj.Data...)
+	tampered[0] ^= 0xff
+	if _, err := verifySignature(sigObj.Data, bytes.NewReader(tampered), pub); !errors.Is(err, crypto.ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for tampered backup, got %v", err)
+	}
+}
+
+// TestBackupOnceBadSignKey 测试签名密钥不可用时在上传前失败
+func TestBackupOnceBadSignKey(t *testing.T) {
+	cfg := &config.Config{Backup: config.BackupConfig{SignKey: filepath.Join(t.TempDir(), "missing.key")}}
+	adapter := mock.New()
+	err := backupOnce(context.Background(), cfg, adapter, "backup.tar.gz", nil, func(ctx context.Context, w io.Writer, _ *archive.CompressionMeter) error {
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected error for missing signing key")
+	}
+	if n := adapter.Calls(mock.OpInit); n != 0 {
+		t.Errorf("expected no upload, got %d InitMultipartUpload calls", n)
+	}
+}
+
+// TestVerifySampled 测试抽样校验按备份报告中的分段哈希检查随机数据段，数据被修改或截断时失败
+func TestVerifySampled(t *testing.T) {
+	defer func(n bool) { noProgress = n }(noProgress)
+	noProgress = true
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{Provider: "aws", Bucket: "bucket"},
+		Backup:  config.BackupConfig{Report: true},
+		State:   config.StateConfig{Dir: filepath.Join(t.TempDir(), "state")},
+	}
+	adapter := mock.New()
+	name := "backup-20260101-000000.sql.gz"
+	payload := bytes.Repeat([]byte("dump data\n"), (2*sampleChunkSize+1000)/10)
+	err := backupOnce(context.Background(), cfg, adapter, name, newBackupReport(cfg, name), func(ctx context.Context, w io.Writer, _ *archive.CompressionMeter) error {
+		_, err := w.Write(payload)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("backupOnce() error = %v", err)
+	}
+
+	ctx := context.Background()
+	first := func(int) int { return 0 }
+	result, err := verifySampled(ctx, adapter, adapter, name, 0.01, first)
+	if err != nil {
+		t.Fatalf("verifySampled() error = %v", err)
+	}
+	if result.Chunks != 3 || result.Checked != 1 || result.Bytes != int64(len(payload))-2*sampleChunkSize {
+		t.Errorf("1%% sample should check only the last chunk, got %+v", result)
+	}
+	if result, err = verifySampled(ctx, adapter, adapter, name, 1, mrand.IntN); err != nil || result.Checked != 3 || result.Bytes != int64(len(payload)) {
+		t.Fatalf("full sample = %+v, %v", result, err)
+	}
+
+	obj, _ := adapter.Object(name)
+	tampered := append([]byte(nil), obj.Data...)
+	tampered[sampleChunkSize+10] ^= 0xff
+	adapter.SetObject(name, &mock.Object{Data: tampered})
+	if _, err := verifySampled(ctx, adapter, adapter, name, 0.5, func(int) int { return 1 }); !errors.Is(err, ErrSampleMismatch) {
+		t.Errorf("expected ErrSampleMismatch for tampered chunk, got %v", err)
+	}
+	if _, err := verifySampled(ctx, adapter, adapter, name, 0.5, first); err != nil {
+		t.Errorf("untouched chunks should pass, got %v", err)
+	}
+
+	// 全部校验时列出每个损坏的段，而不是在第一个不一致处停止
+	tampered[10] ^= 0xff
+	adapter.SetObject(name, &mock.Object{Data: tampered})
+	result, err = verifySampled(ctx, adapter, adapter, name, 1, mrand.IntN)
+	if !errors.Is(err, ErrSampleMismatch) {
+		t.Fatalf("expected ErrSampleMismatch, got %v", err)
+	}
+	if result.Checked != 3 || len(result.Corrupt) != 2 || result.Corrupt[0].Index != 0 || result.Corrupt[1].Index != 1 {
+		t.Errorf("expected chunks 0 and 1 to be corrupt, got %+v", result)
+	}
+	if c := result.Corrupt[1]; c.Offset != sampleChunkSize || c.Length != sampleChunkSize {
+		t.Errorf("unexpected range of chunk 1: %+v", c)
+	}
+
+	adapter.SetObject(name, &mock.Object{Data: obj.Data[:len(obj.Data)-1]})
+	if _, err := verifySampled(ctx, adapter, adapter, name, 0.01, first); !errors.Is(err, ErrSampleMismatch) {
+		t.Errorf("expected ErrSampleMismatch for truncated backup, got %v", err)
+	}
+}
+
+// TestCorruptChunks 测试完整读取备份后按报告中的分段 SHA-256 定位损坏的段
+func TestCorruptChunks(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 2*sampleChunkSize+100)
+	orig := newHashingWriter(io.Discard)
+	orig.Write(payload)
+	reportData, _ := json.Marshal(backupReport{Size: orig.n, ChunkSize: sampleChunkSize, ChunkSHA256: orig.Chunks()})
+
+	read := func(data []byte) *hashingWriter {
+		hw := newHashingWriter(io.Discard)
+		hw.Write(data)
+		return hw
+	}
+	if corrupt := corruptChunks(reportData, read(payload)); len(corrupt) != 0 {
+		t.Errorf("intact backup reported corrupt chunks: %+v", corrupt)
+	}
+
+	tampered := append([]byte(nil), payload...)
+	tampered[sampleChunkSize+1] ^= 0xff
+	corrupt := corruptChunks(reportData, read(tampered))
+	if len(corrupt) != 1 || corrupt[0].Index != 1 || corrupt[0].Offset != sampleChunkSize {
+		t.Errorf("expected chunk 1 to be corrupt, got %+v", corrupt)
+	}
+
+	corrupt = corruptChunks(reportData, read(payload[:sampleChunkSize+10]))
+	if len(corrupt) != 2 || corrupt[0].Index != 1 || corrupt[1].Index != 2 || corrupt[1].Length != 100 {
+		t.Errorf("expected truncated chunks 1 and 2, got %+v", corrupt)
+	}
+
+	if corrupt := corruptChunks(nil, read(tampered)); corrupt != nil {
+		t.Errorf("expected nil without a report, got %+v", corrupt)
+	}
+}
+
+// TestParseSampleRatio 测试 --sample 接受百分比和小数
+func TestParseSampleRatio(t *testing.T) {
+	for in, want := range map[string]float64{"5%": 0.05, "100%": 1, "0.1": 0.1, " 2.5% ": 0.025} {
+		if got, err := parseSampleRatio(in); err != nil || math.Abs(got-want) > 1e-9 {
+			t.Errorf("parseSampleRatio(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0", "0%", "150%", "-1", "abc"} {
+		if _, err := parseSampleRatio(in); err == nil {
+			t.Errorf("parseSampleRatio(%q) should fail", in)
+		}
+	}
+}
+
+// TestCheckUpload 测试上传后校验核对首尾数据段和备份报告，存储中的数据损坏时返回 ErrUploadCheck
+func TestCheckUpload(t *testing.T) {
+	defer func(n bool) { noProgress = n }(noProgress)
+	noProgress = true
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{Provider: "aws", Bucket: "bucket"},
+		Backup:  config.BackupConfig{Report: true, VerifyUpload: true},
+		State:   config.StateConfig{Dir: filepath.Join(t.TempDir(), "state")},
+	}
+	adapter := mock.New()
+	name := "backup-20260101-000000.sql.gz"
+	payload := bytes.Repeat([]byte("dump data\n"), (sampleChunkSize+1000)/10)
+	err := backupOnce(context.Background(), cfg, adapter, name, newBackupReport(cfg, name), func(ctx context.Context, w io.Writer, _ *archive.CompressionMeter) error {
+		_, err := w.Write(payload)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("backupOnce() error = %v", err)
+	}
+	// 上传前读取标记对象，上传后两次范围下载和回读报告
+	if n := adapter.Calls(mock.OpGetObject); n != 4 {
+		t.Errorf("expected the marker, 2 ranged downloads and the report, got %d GetObject calls", n)
+	}
+
+	ctx := context.Background()
+	hw := newHashingWriter(io.Discard)
+	hw.Write(payload)
+	if err := checkUpload(ctx, adapter, name, hw, name+reportSuffix); err != nil {
+		t.Fatalf("checkUpload() error = %v", err)
+	}
+
+	obj, _ := adapter.Object(name)
+	corrupt := append([]byte(nil), obj.Data...)
+	corrupt[len(corrupt)-1] ^= 0xff
+	adapter.SetObject(name, &mock.Object{Data: corrupt})
+	if err := checkUpload(ctx, adapter, name, hw, ""); !errors.Is(err, ErrUploadCheck) {
+		t.Errorf("expected ErrUploadCheck for corrupt last chunk, got %v", err)
+	}
+
+	adapter.SetObject(name, obj)
+	adapter.SetObject(name+reportSuffix, &mock.Object{Data: []byte(`{"sha256":"0000","size":1}`)})
+	if err := checkUpload(ctx, adapter, name, hw, name+reportSuffix); !errors.Is(err, ErrUploadCheck) {
+		t.Errorf("expected ErrUploadCheck for mismatching report, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+	"github.com/lukelzlz/s3backup/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+var checkUpdate bool
+
+// versionCmd 版本命令
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "显示版本信息",
+	Args:  cobra.NoArgs,
+	RunE:  runVersion,
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().BoolVar(&checkUpdate, "check", false, "检查 GitHub 上是否有新版本")
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	i18n.Fprintf(out, "版本:     %s\n", version.Version)
+	i18n.Fprintf(out, "提交:     %s\n", version.Commit)
+	i18n.Fprintf(out, "构建时间: %s\n", version.BuildDate)
+	i18n.Fprintf(out, "Go 版本:  %s\n", version.GoVersion())
+
+	if !checkUpdate {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	latest, newer, err := version.CheckLatest(ctx, http.DefaultClient)
+	if err != nil {
+		return fmt.Errorf("failed to check for updates: %w", err)
+	}
+
+	fmt.Fprintln(out)
+	if newer {
+		i18n.Fprintf(out, "发现新版本: %s\n", latest)
+		i18n.Fprintf(out, "下载地址: https://github.com/lukelzlz/s3backup/releases/latest\n")
+	} else {
+		i18n.Fprintf(out, "已是最新版本（最新发布: %s）\n", latest)
+	}
+	return nil
+}
@@ -3,48 +3,208 @@ package archive
 import (
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/gobwas/glob"
+	"github.com/lukelzlz/s3backup/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// ErrStrict 严格模式下遇到无法完整归档的路径（无法读取的文件、特殊文件、目标不存在的符号链接）
+var ErrStrict = errors.New("path cannot be archived in strict mode")
+
 // Archiver 归档器
 type Archiver struct {
-	includes []string
-	excludes []glob.Glob
+	includes   []string
+	excludes   []glob.Glob
+	patterns   []string // 排除模式原文，与 excludes 一一对应
+	hits       []int    // 每个排除模式匹配的路径数
+	ignoreCase bool
+	onFile     func(path string)
+	meter      *CompressionMeter
+	store      storeSet
+	gz         *multiGzipWriter // 设置了 store 时用于在文件之间切换压缩级别
+	zstd       bool
+	dictionary []byte
+	parallel   int
+	tempDir    string
+	warnings   *warnLimiter
+	strict     bool
+	devices    bool
+	xattrs     bool
+	selinux    bool
+	sampler    *fileSampler
+
+	skipped []SkippedFile
+	files   int
+	bytes   int64
+}
+
+// SkippedFile 归档时因无法访问等原因跳过的路径
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"` // 跳过原因的类型，见 SkipInaccessible 等常量
+	Reason string `json:"reason"`
+}
+
+// Options 归档器选项
+type Options struct {
+	IgnoreCase bool // 排除模式不区分大小写，例如 *.log 同时排除 ERROR.LOG
+
+	// OnFile 开始归档每个普通文件时在归档 goroutine 中调用，用于显示当前文件
+	// 并行归档时会被多个 goroutine 同时调用
+	OnFile func(path string)
+
+	// Meter 统计压缩前后的字节数，为 nil 时不统计
+	Meter *CompressionMeter
+
+	// StoreExtensions 不压缩的文件扩展名（如 .jpg、mp4），这些文件在 gzip 流中以不压缩的成员存储，
+	// 节省对已压缩数据的 CPU 消耗；生成的仍是标准的 tar.gz
+	StoreExtensions []string
+
+	// Parallel 同时归档的包含路径数，<= 1 时按顺序归档
+	Parallel int
+
+	// TempDir 并行归档时暂存其余包含路径的目录，为空时使用系统临时目录
+	TempDir string
+
+	// MaxWarnings 每类跳过警告逐条输出的数量，超出的只在结束时汇总，完整列表见 Skipped
+	// 0 时使用 DefaultMaxWarnings，负数表示不限制
+	MaxWarnings int
+
+	// Strict 遇到原本会跳过的路径或目标不存在的符号链接时返回 ErrStrict，而不是输出警告后继续
+	Strict bool
+
+	// Devices 归档字符设备、块设备和命名管道（只记录类型和设备号），否则作为特殊文件跳过
+	// 套接字无法用 tar 表示，始终跳过
+	Devices bool
+
+	// Xattrs 将扩展属性（如 setcap 设置的 security.capability、user.*）写入 PAX 扩展头 SCHILY.xattr.*，
+	// 不包括 SELinux 上下文；仅支持 Linux
+	Xattrs bool
+
+	// SELinux 将 SELinux 安全上下文（security.selinux）写入 PAX 扩展头；仅支持 Linux
+	SELinux bool
+
+	// HashSample 随机抽取的普通文件数，归档时计算这些文件的 SHA-256（见 FileSamples），0 表示不抽样
+	HashSample int
+
+	// Zstd 使用 zstd 而不是 gzip 压缩，生成 tar.zst；StoreExtensions 只对 gzip 有效
+	Zstd bool
+
+	// ZstdDictionary zstd 压缩使用的字典（见 TrainDictionary），为空时不使用字典
+	ZstdDictionary []byte
 }
 
 // NewArchiver 创建归档器
 func NewArchiver(includes, excludes []string) (*Archiver, error) {
+	return NewArchiverWithOptions(includes, excludes, Options{})
+}
+
+// NewArchiverWithOptions 按 opts 创建归档器
+func NewArchiverWithOptions(includes, excludes []string, opts Options) (*Archiver, error) {
+	if (opts.Xattrs || opts.SELinux) && !xattrSupported {
+		return nil, fmt.Errorf("extended attributes are not supported on %s", runtime.GOOS)
+	}
+
 	excludePatterns := make([]glob.Glob, len(excludes))
 	for i, pattern := range excludes {
+		if opts.IgnoreCase {
+			pattern = strings.ToLower(pattern)
+		}
 		g, err := glob.Compile(pattern)
 		if err != nil {
-			return nil, fmt.Errorf("failed to compile exclude pattern %s: %w", pattern, err)
+			return nil, fmt.Errorf("failed to compile exclude pattern %s: %w", excludes[i], err)
 		}
 		excludePatterns[i] = g
 	}
 
 	return &Archiver{
-		includes: includes,
-		excludes: excludePatterns,
+		includes:   includes,
+		excludes:   excludePatterns,
+		patterns:   excludes,
+		hits:       make([]int, len(excludes)),
+		ignoreCase: opts.IgnoreCase,
+		onFile:     opts.OnFile,
+		meter:      opts.Meter,
+		store:      newStoreSet(opts.StoreExtensions),
+		parallel:   opts.Parallel,
+		tempDir:    opts.TempDir,
+		warnings:   newWarnLimiter(opts.MaxWarnings),
+		strict:     opts.Strict,
+		devices:    opts.Devices,
+		xattrs:     opts.Xattrs,
+		selinux:    opts.SELinux,
+		sampler:    newFileSampler(opts.HashSample),
+		zstd:       opts.Zstd,
+		dictionary: opts.ZstdDictionary,
 	}, nil
 }
 
 // Archive 将文件打包为 tar.gz 流写入到 writer
-func (a *Archiver) Archive(ctx context.Context, w io.Writer) error {
-	gzWriter := gzip.NewWriter(w)
+// 设置了 Parallel 且有多个包含路径时并行归档，输出内容与顺序归档相同
+func (a *Archiver) Archive(ctx context.Context, w io.Writer) (err error) {
+	ctx, span := tracing.Start(ctx, "archive", attribute.Int("s3backup.includes", len(a.includes)))
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("s3backup.files", a.files),
+			attribute.Int64("s3backup.bytes", a.bytes),
+			attribute.Int("s3backup.skipped", len(a.skipped)),
+		)
+		tracing.End(span, err)
+	}()
+	defer a.warnings.summary()
+
+	if a.parallel > 1 && len(a.includes) > 1 {
+		return a.archiveParallel(ctx, w)
+	}
+	return a.archiveRoots(ctx, w, a.includes, true)
+}
+
+// archiveRoots 将 roots 打包为 gzip 或 zstd 流写入 w
+// trailer 为 false 时不写 tar 结束标记，以便后续的流拼接在其后组成同一个 tar
+func (a *Archiver) archiveRoots(ctx context.Context, w io.Writer, roots []string, trailer bool) error {
+	if a.meter != nil {
+		w = a.meter.CompressedWriter(w)
+	}
+	var gzWriter io.WriteCloser
+	switch {
+	case a.zstd:
+		zw, err := NewZstdWriter(w, a.dictionary)
+		if err != nil {
+			return err
+		}
+		gzWriter = zw
+	case a.store != nil:
+		a.gz = newMultiGzipWriter(w)
+		gzWriter = a.gz
+	default:
+		gzWriter = gzip.NewWriter(w)
+	}
 	defer gzWriter.Close()
 
-	tarWriter := NewTarWriter(gzWriter)
-	defer tarWriter.Close()
+	var raw io.Writer = gzWriter
+	if a.meter != nil {
+		raw = a.meter.RawWriter(gzWriter)
+	}
+	tarWriter := NewTarWriter(raw)
+	if trailer {
+		defer tarWriter.Close()
+	} else {
+		defer tarWriter.Flush()
+	}
 
-	for _, include := range a.includes {
+	for _, include := range roots {
 		// 顶层路径不存在直接报错，不要静默跳过
 		if _, err := os.Lstat(include); err != nil {
 			return fmt.Errorf("failed to archive %s: %w", include, err)
@@ -73,8 +233,7 @@ func (a *Archiver) archivePath(ctx context.Context, tw *TarWriter, path, base st
 	info, err := os.Lstat(path)
 	if err != nil {
 		// 如果无法访问，记录警告并跳过
-		fmt.Printf("[警告] 跳过无法访问的文件: %s (%v)\n", path, err)
-		return nil
+		return a.skip(SkipInaccessible, path, err, err.Error())
 	}
 
 	// 计算归档内的路径
@@ -102,10 +261,12 @@ func (a *Archiver) archivePath(ctx context.Context, tw *TarWriter, path, base st
 	} else if mode.IsRegular() {
 		// 处理普通文件
 		return a.archiveFile(tw, path, archivePath, info)
+	} else if a.devices && mode&(os.ModeDevice|os.ModeNamedPipe) != 0 {
+		// 处理设备文件和命名管道
+		return a.archiveDevice(tw, path, archivePath, info)
 	} else {
 		// 跳过其他类型（设备文件、管道等）
-		fmt.Printf("[警告] 跳过特殊文件: %s (mode: %v)\n", path, mode)
-		return nil
+		return a.skip(SkipSpecial, path, mode, fmt.Sprintf("special file (mode: %v)", mode))
 	}
 }
 
@@ -119,6 +280,7 @@ func (a *Archiver) archiveDir(ctx context.Context, tw *TarWriter, path, archiveP
 		Typeflag:   TypeDir,
 		AccessTime: time.Now(),
 		ChangeTime: time.Now(),
+		PAXRecords: a.paxRecords(path),
 	}); err != nil {
 		return fmt.Errorf("failed to write dir header: %w", err)
 	}
@@ -126,8 +288,7 @@ func (a *Archiver) archiveDir(ctx context.Context, tw *TarWriter, path, archiveP
 	// 递归处理目录内容
 	entries, err := os.ReadDir(path)
 	if err != nil {
-		fmt.Printf("[警告] 无法读取目录: %s (%v)\n", path, err)
-		return nil
+		return a.skip(SkipDir, path, err, err.Error())
 	}
 
 	for _, entry := range entries {
@@ -145,8 +306,12 @@ func (a *Archiver) archiveSymlink(tw *TarWriter, path, archivePath string, info
 	// 读取符号链接目标
 	target, err := os.Readlink(path)
 	if err != nil {
-		fmt.Printf("[警告] 无法读取符号链接: %s (%v)\n", path, err)
-		return nil
+		return a.skip(SkipSymlink, path, err, err.Error())
+	}
+	if a.strict {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("%w: %s: unresolved symlink target %s (%v)", ErrStrict, path, target, err)
+		}
 	}
 
 	// 写入符号链接 header
@@ -154,10 +319,11 @@ func (a *Archiver) archiveSymlink(tw *TarWriter, path, archivePath string, info
 		Name:       archivePath,
 		Mode:       int64(info.Mode()),
 		ModTime:    info.ModTime(),
-		Typeflag:   TypeLink,
+		Typeflag:   TypeSymlink,
 		Linkname:   target,
 		AccessTime: time.Now(),
 		ChangeTime: time.Now(),
+		PAXRecords: a.paxRecords(path),
 	}); err != nil {
 		return fmt.Errorf("failed to write symlink header: %w", err)
 	}
@@ -180,11 +346,25 @@ func (a *Archiver) archiveFile(tw *TarWriter, path, archivePath string, info os.
 	// 打开文件
 	file, err := os.Open(path)
 	if err != nil {
-		fmt.Printf("[警告] 无法打开文件: %s (%v)\n", path, err)
-		return nil
+		return a.skip(SkipFile, path, err, err.Error())
 	}
 	defer file.Close()
 
+	if a.onFile != nil {
+		a.onFile(path)
+	}
+
+	// 按扩展名切换压缩级别，连续的同类文件共用一个 gzip 成员
+	if a.gz != nil {
+		level := gzip.DefaultCompression
+		if a.store.match(path) {
+			level = gzip.NoCompression
+		}
+		if err := a.gz.setLevel(level); err != nil {
+			return fmt.Errorf("failed to switch compression level: %w", err)
+		}
+	}
+
 	// 写入 header
 	header := &TarHeader{
 		Name:       archivePath,
@@ -194,31 +374,95 @@ func (a *Archiver) archiveFile(tw *TarWriter, path, archivePath string, info os.
 		Typeflag:   TypeReg,
 		AccessTime: time.Now(),
 		ChangeTime: time.Now(),
+		PAXRecords: a.paxRecords(path),
 	}
 
 	if err := tw.WriteHeader(header); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 
-	// 写入文件内容
-	if _, err := io.Copy(tw, file); err != nil {
+	// 写入文件内容，被抽样的文件同时计算 SHA-256
+	var src io.Reader = file
+	slot, ticket, sampled := a.sampler.offer()
+	var h hash.Hash
+	if sampled {
+		h = sha256.New()
+		src = io.TeeReader(file, h)
+	}
+	n, err := io.Copy(tw, src)
+	if err != nil {
 		return fmt.Errorf("failed to write file content: %w", err)
 	}
+	if sampled {
+		a.sampler.record(slot, ticket, FileHash{Path: archivePath, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))})
+	}
+	a.files++
+	a.bytes += n
+
+	return nil
+}
 
+// skip 记录跳过的路径并输出警告，同类警告超过上限后不再逐条输出
+// 严格模式下不跳过，返回 ErrStrict
+func (a *Archiver) skip(kind, path string, detail any, reason string) error {
+	if a.strict {
+		return fmt.Errorf("%w: %s: %s", ErrStrict, path, reason)
+	}
+	a.warnings.warn(kind, path, detail)
+	a.skipped = append(a.skipped, SkippedFile{Path: path, Kind: kind, Reason: reason})
 	return nil
 }
 
+// Skipped 返回 Archive 过程中跳过的路径
+func (a *Archiver) Skipped() []SkippedFile {
+	return a.skipped
+}
+
+// SuppressedWarnings 返回因超过 MaxWarnings 没有逐条输出的警告数
+func (a *Archiver) SuppressedWarnings() int {
+	return a.warnings.suppressed()
+}
+
+// FileSamples 返回按 HashSample 随机抽取的文件及其 SHA-256，按路径排序
+func (a *Archiver) FileSamples() []FileHash {
+	return a.sampler.samples()
+}
+
+// Stats 返回 Archive 已归档的普通文件数和文件内容字节数
+func (a *Archiver) Stats() (files int, bytes int64) {
+	return a.files, a.bytes
+}
+
 // isExcluded 检查路径是否被排除
+// 每个模式都会参与匹配，以便统计各模式的命中次数
 func (a *Archiver) isExcluded(path string) bool {
 	// 标准化路径（使用 / 作为分隔符）
 	normalizedPath := filepath.ToSlash(path)
+	if a.ignoreCase {
+		normalizedPath = strings.ToLower(normalizedPath)
+	}
 
-	for _, g := range a.excludes {
+	excluded := false
+	for i, g := range a.excludes {
 		if g.Match(normalizedPath) {
-			return true
+			a.hits[i]++
+			excluded = true
 		}
 	}
-	return false
+	return excluded
+}
+
+// UnmatchedExcludes 返回到目前为止没有匹配任何路径的排除模式，
+// 在 Archive 或 GetTotalSize 之后调用，用于发现 node_module/** 之类的拼写错误。
+// 被排除目录内的路径不再参与匹配，只能在其中匹配的模式也会被返回
+func (a *Archiver) UnmatchedExcludes() []string {
+	var unmatched []string
+	for i, n := range a.hits {
+		if n == 0 {
+			unmatched = append(unmatched, a.patterns[i])
+		}
+	}
+	return unmatched
 }
 
 // isPathSafe 检查路径是否安全，防止路径遍历攻击
@@ -299,27 +543,52 @@ func (a *Archiver) getPathSize(ctx context.Context, path string) (int64, error)
 }
 
 // ResolveIncludes 解析包含路径，展开通配符
+// 除 filepath.Glob 语法外还支持 {a,b} 选择和跨目录的 **（与排除模式语法一致），
+// 通配符没有匹配任何路径时报错
 func ResolveIncludes(includes []string) ([]string, error) {
+	return resolveIncludes(includes, false)
+}
+
+// ResolveIncludesAllowEmpty 与 ResolveIncludes 相同，但通配符没有匹配时跳过而不是报错
+func ResolveIncludesAllowEmpty(includes []string) ([]string, error) {
+	return resolveIncludes(includes, true)
+}
+
+func resolveIncludes(includes []string, allowEmpty bool) ([]string, error) {
 	var resolved []string
+	seen := make(map[string]bool)
+	add := func(paths ...string) {
+		for _, p := range paths {
+			if !seen[p] {
+				seen[p] = true
+				resolved = append(resolved, p)
+			}
+		}
+	}
 
 	for _, include := range includes {
 		// 检查是否包含通配符
-		if strings.ContainsAny(include, "*?[]") {
-			matches, err := filepath.Glob(include)
-			if err != nil {
-				return nil, fmt.Errorf("failed to glob %s: %w", include, err)
-			}
-			if len(matches) == 0 {
-				return nil, fmt.Errorf("no matches found for pattern: %s", include)
-			}
-			resolved = append(resolved, matches...)
-		} else {
+		if !hasMeta(include) {
 			// 检查路径是否存在
 			if _, err := os.Stat(include); err != nil {
 				return nil, fmt.Errorf("path not found: %s", include)
 			}
-			resolved = append(resolved, include)
+			add(include)
+			continue
+		}
+
+		var matches []string
+		for _, pattern := range expandBraces(include) {
+			m, err := globPath(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("failed to glob %s: %w", include, err)
+			}
+			matches = append(matches, m...)
+		}
+		if len(matches) == 0 && !allowEmpty {
+			return nil, fmt.Errorf("no matches found for pattern: %s", include)
 		}
+		add(matches...)
 	}
 
 	return resolved, nil
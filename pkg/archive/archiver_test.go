@@ -2,6 +2,11 @@ package archive
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -127,3 +132,152 @@ func TestArchiveWithUnsafePaths(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+// TestArchiveOnFile 测试归档每个普通文件时调用 OnFile，被排除的文件不会调用
+func TestArchiveOnFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.log"), []byte("b"), 0644)
+
+	var files []string
+	a, err := NewArchiverWithOptions([]string{dir}, []string{"*.log"}, Options{
+		OnFile: func(path string) { files = append(files, path) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Archive(context.Background(), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join(dir, "a.txt") {
+		t.Errorf("OnFile called with %q", files)
+	}
+}
+
+// TestIsExcludedIgnoreCase 测试不区分大小写的排除模式
+func TestIsExcludedIgnoreCase(t *testing.T) {
+	a, err := NewArchiverWithOptions(nil, []string{"*.log", "**/Cache/**"}, Options{IgnoreCase: true})
+	if err != nil {
+		t.Fatalf("failed to create archiver: %v", err)
+	}
+
+	for _, path := range []string{"test.log", "ERROR.LOG", "/home/user/cache/x", "/home/user/CACHE/x"} {
+		if !a.isExcluded(path) {
+			t.Errorf("%s should be excluded", path)
+		}
+	}
+	if a.isExcluded("logs.txt") {
+		t.Error("logs.txt should not be excluded")
+	}
+
+	// 默认区分大小写
+	a, err = NewArchiver(nil, []string{"*.log"})
+	if err != nil {
+		t.Fatalf("failed to create archiver: %v", err)
+	}
+	if a.isExcluded("ERROR.LOG") {
+		t.Error("ERROR.LOG should not be excluded without IgnoreCase")
+	}
+}
+
+// TestUnmatchedExcludes 测试统计没有匹配任何路径的排除模式
+func TestUnmatchedExcludes(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "node_modules", "pkg"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "app.log"), []byte("log"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "debug.log"), []byte("log"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main"), 0644)
+
+	excludes := []string{"*.log", "**/debug.log", "**/node_module", "**/node_modules"}
+	a, err := NewArchiver([]string{tmpDir}, excludes)
+	if err != nil {
+		t.Fatalf("failed to create archiver: %v", err)
+	}
+	if err := a.Archive(context.Background(), io.Discard); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+
+	// 已被其他模式排除的路径仍计入后续模式的命中
+	got := a.UnmatchedExcludes()
+	if len(got) != 1 || got[0] != "**/node_module" {
+		t.Errorf("UnmatchedExcludes() = %q, want [**/node_module]", got)
+	}
+}
+
+// TestArchiveStrict 测试严格模式下目标不存在的符号链接和特殊文件导致归档失败，非严格模式下正常归档
+func TestArchiveStrict(t *testing.T) {
+	t.Run("broken symlink", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "file.txt"), []byte("data"), 0644)
+		if err := os.Symlink("missing", filepath.Join(dir, "link")); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+		if err := os.Symlink("file.txt", filepath.Join(dir, "ok")); err != nil {
+			t.Fatal(err)
+		}
+
+		a, _ := NewArchiver([]string{dir}, nil)
+		if err := a.Archive(context.Background(), io.Discard); err != nil {
+			t.Fatalf("non-strict Archive() error = %v", err)
+		}
+
+		a, _ = NewArchiverWithOptions([]string{dir}, nil, Options{Strict: true})
+		err := a.Archive(context.Background(), io.Discard)
+		if !errors.Is(err, ErrStrict) || !strings.Contains(err.Error(), "link") {
+			t.Fatalf("strict Archive() error = %v, want ErrStrict for link", err)
+		}
+	})
+
+	t.Run("special file", func(t *testing.T) {
+		// unix 套接字路径长度有限，不使用 t.TempDir 的长路径
+		dir, err := os.MkdirTemp("", "s3b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		l, err := net.Listen("unix", filepath.Join(dir, "sock"))
+		if err != nil {
+			t.Skipf("unix sockets not supported: %v", err)
+		}
+		defer l.Close()
+
+		a, _ := NewArchiverWithOptions([]string{dir}, nil, Options{Strict: true})
+		if err := a.Archive(context.Background(), io.Discard); !errors.Is(err, ErrStrict) {
+			t.Fatalf("strict Archive() error = %v, want ErrStrict", err)
+		}
+		if len(a.Skipped()) != 0 {
+			t.Errorf("strict mode should not record skipped files, got %+v", a.Skipped())
+		}
+	})
+}
+
+// BenchmarkArchive 基准测试打包压缩吞吐量，数据一半可压缩一半随机
+func BenchmarkArchive(b *testing.B) {
+	dir := b.TempDir()
+	var total int64
+	for i := 0; i < 64; i++ {
+		data := make([]byte, 256*1024)
+		if i%2 == 0 {
+			rand.New(rand.NewSource(int64(i))).Read(data)
+		} else {
+			copy(data, strings.Repeat("s3backup benchmark line\n", len(data)/24))
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file-%02d", i)), data, 0644); err != nil {
+			b.Fatal(err)
+		}
+		total += int64(len(data))
+	}
+	a, err := NewArchiver([]string{dir}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(total)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := a.Archive(context.Background(), io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package archive
+
+import (
+	"io"
+	"sync"
+)
+
+// CompressionStats 压缩前后的字节数
+type CompressionStats struct {
+	Raw        int64             // 压缩前的字节数
+	Compressed int64             // 压缩后的字节数
+	Parts      []PartCompression // 压缩输出按分块大小划分的各段
+}
+
+// PartCompression 一段压缩输出及其对应的压缩前字节数
+// 压缩器内部有缓冲，对应的压缩前字节数是按写出时的进度估算的
+type PartCompression struct {
+	Raw        int64
+	Compressed int64
+}
+
+// Ratio 返回压缩后与压缩前的字节数之比，没有数据时返回 0
+func (s CompressionStats) Ratio() float64 {
+	return ratio(s.Compressed, s.Raw)
+}
+
+// PartRatioRange 返回各分块压缩比的最小值和最大值，没有分块时返回 0
+func (s CompressionStats) PartRatioRange() (min, max float64) {
+	for i, p := range s.Parts {
+		r := p.Ratio()
+		if i == 0 || r < min {
+			min = r
+		}
+		if i == 0 || r > max {
+			max = r
+		}
+	}
+	return min, max
+}
+
+// Ratio 返回该段的压缩比
+func (p PartCompression) Ratio() float64 {
+	return ratio(p.Compressed, p.Raw)
+}
+
+func ratio(compressed, raw int64) float64 {
+	if raw <= 0 {
+		return 0
+	}
+	return float64(compressed) / float64(raw)
+}
+
+// CompressionMeter 统计压缩器输入和输出的字节数，并按 partSize 划分压缩输出，
+// 记录每段对应的压缩前字节数，用于报告各分块的压缩比
+type CompressionMeter struct {
+	partSize int64
+
+	mu    sync.Mutex
+	stats CompressionStats
+	cur   PartCompression // 尚未写满的一段
+}
+
+// NewCompressionMeter 创建压缩统计，partSize 通常为上传的分块大小，<= 0 时不划分
+func NewCompressionMeter(partSize int64) *CompressionMeter {
+	return &CompressionMeter{partSize: partSize}
+}
+
+// RawWriter 包装压缩器的输入
+func (m *CompressionMeter) RawWriter(w io.Writer) io.Writer {
+	return &meterWriter{w: w, add: m.addRaw}
+}
+
+// CompressedWriter 包装压缩器的输出
+func (m *CompressionMeter) CompressedWriter(w io.Writer) io.Writer {
+	return &meterWriter{w: w, add: m.addCompressed}
+}
+
+func (m *CompressionMeter) addRaw(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.Raw += n
+	m.cur.Raw += n
+}
+
+func (m *CompressionMeter) addCompressed(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.Compressed += n
+	m.cur.Compressed += n
+	// 一次写出可能跨过多个分块边界，压缩前字节数按比例分配到各段
+	for m.partSize > 0 && m.cur.Compressed >= m.partSize {
+		raw := m.cur.Raw * m.partSize / m.cur.Compressed
+		m.stats.Parts = append(m.stats.Parts, PartCompression{Raw: raw, Compressed: m.partSize})
+		m.cur.Raw -= raw
+		m.cur.Compressed -= m.partSize
+	}
+}
+
+// Stats 返回目前的统计，最后一段未写满的分块也计入 Parts
+func (m *CompressionMeter) Stats() CompressionStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	stats.Parts = append([]PartCompression(nil), m.stats.Parts...)
+	if m.partSize > 0 && m.cur.Compressed > 0 {
+		stats.Parts = append(stats.Parts, m.cur)
+	}
+	return stats
+}
+
+// add 将另一份统计追加到末尾，用于合并并行归档的各部分
+func (m *CompressionMeter) add(s CompressionStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.Raw += s.Raw
+	m.stats.Compressed += s.Compressed
+	m.stats.Parts = append(m.stats.Parts, s.Parts...)
+}
+
+// meterWriter 将写入的字节数报告给 add
+type meterWriter struct {
+	w   io.Writer
+	add func(n int64)
+}
+
+func (w *meterWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.add(int64(n))
+	return n, err
+}
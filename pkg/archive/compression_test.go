@@ -0,0 +1,80 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCompressionMeterParts 测试压缩输出按分块大小划分，压缩前字节数按比例分配到各段
+func TestCompressionMeterParts(t *testing.T) {
+	m := NewCompressionMeter(100)
+	raw := m.RawWriter(io.Discard)
+	compressed := m.CompressedWriter(io.Discard)
+
+	raw.Write(make([]byte, 1000))
+	compressed.Write(make([]byte, 250)) // 跨过两个分块边界
+
+	stats := m.Stats()
+	if stats.Raw != 1000 || stats.Compressed != 250 {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+	want := []PartCompression{{400, 100}, {400, 100}, {200, 50}}
+	if len(stats.Parts) != len(want) {
+		t.Fatalf("Parts = %+v, want %+v", stats.Parts, want)
+	}
+	for i, p := range want {
+		if stats.Parts[i] != p {
+			t.Errorf("Parts[%d] = %+v, want %+v", i, stats.Parts[i], p)
+		}
+	}
+	if r := stats.Ratio(); r != 0.25 {
+		t.Errorf("Ratio() = %v, want 0.25", r)
+	}
+	if min, max := stats.PartRatioRange(); min != 0.25 || max != 0.25 {
+		t.Errorf("PartRatioRange() = %v, %v", min, max)
+	}
+}
+
+// TestArchiveCompressionStats 测试归档时统计 tar 流和 gzip 输出的字节数
+func TestArchiveCompressionStats(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "zeros"), make([]byte, 64*1024), 0644)
+	random := make([]byte, 64*1024)
+	rand.Read(random)
+	os.WriteFile(filepath.Join(dir, "random"), random, 0644)
+
+	meter := NewCompressionMeter(16 * 1024)
+	a, err := NewArchiverWithOptions([]string{dir}, nil, Options{Meter: meter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := a.Archive(context.Background(), &buf); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+
+	stats := meter.Stats()
+	if stats.Compressed != int64(buf.Len()) {
+		t.Errorf("Compressed = %d, want %d", stats.Compressed, buf.Len())
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarSize, _ := io.Copy(io.Discard, gz)
+	if stats.Raw != tarSize {
+		t.Errorf("Raw = %d, want tar size %d", stats.Raw, tarSize)
+	}
+	if r := stats.Ratio(); r < 0.4 || r > 0.6 {
+		t.Errorf("Ratio() = %v, want about 0.5 for half random data", r)
+	}
+	if len(stats.Parts) < 2 {
+		t.Errorf("expected several parts, got %+v", stats.Parts)
+	}
+}
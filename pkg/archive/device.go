@@ -0,0 +1,41 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// archiveDevice 归档字符设备、块设备和命名管道，只写入类型和设备号，不读取内容
+func (a *Archiver) archiveDevice(tw *TarWriter, path, archivePath string, info os.FileInfo) error {
+	mode := info.Mode()
+	header := &TarHeader{
+		Name:       archivePath,
+		Mode:       int64(mode.Perm()),
+		ModTime:    info.ModTime(),
+		AccessTime: time.Now(),
+		ChangeTime: time.Now(),
+		PAXRecords: a.paxRecords(path),
+	}
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		header.Typeflag = TypeFifo
+	case mode&os.ModeCharDevice != 0:
+		header.Typeflag = TypeChar
+	default:
+		header.Typeflag = TypeBlock
+	}
+
+	if header.Typeflag != TypeFifo {
+		major, minor, ok := deviceNumbers(info)
+		if !ok {
+			return a.skip(SkipSpecial, path, mode, fmt.Sprintf("device numbers unavailable (mode: %v)", mode))
+		}
+		header.Devmajor, header.Devminor = major, minor
+	}
+
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write device header: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package archive
+
+import "os"
+
+// deviceNumbers 非 Unix 系统上无法获取设备号
+func deviceNumbers(info os.FileInfo) (major, minor int64, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package archive
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// deviceNumbers 返回设备文件的主、次设备号
+func deviceNumbers(info os.FileInfo) (major, minor int64, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	dev := uint64(st.Rdev)
+	return int64(unix.Major(dev)), int64(unix.Minor(dev)), true
+}
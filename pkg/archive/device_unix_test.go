@@ -0,0 +1,86 @@
+//go:build unix
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestArchiveDevices 测试启用 Devices 时归档字符设备和命名管道，记录正确的类型和设备号
+func TestArchiveDevices(t *testing.T) {
+	dir := t.TempDir()
+	fifo := filepath.Join(dir, "fifo")
+	if err := unix.Mkfifo(fifo, 0600); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+	var st unix.Stat_t
+	if err := unix.Stat("/dev/null", &st); err != nil {
+		t.Skipf("/dev/null not available: %v", err)
+	}
+
+	var buf bytes.Buffer
+	a, _ := NewArchiverWithOptions([]string{dir, "/dev/null"}, nil, Options{Devices: true})
+	if err := a.Archive(context.Background(), &buf); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	if len(a.Skipped()) != 0 {
+		t.Errorf("expected no skipped files, got %+v", a.Skipped())
+	}
+
+	headers := make(map[string]*tar.Header)
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers[filepath.Base(hdr.Name)] = hdr
+	}
+
+	if hdr := headers["fifo"]; hdr == nil || hdr.Typeflag != tar.TypeFifo || hdr.Mode != 0600 {
+		t.Errorf("fifo header = %+v, want TypeFifo with mode 0600", hdr)
+	}
+	null := headers["null"]
+	if null == nil || null.Typeflag != tar.TypeChar {
+		t.Fatalf("/dev/null header = %+v, want TypeChar", null)
+	}
+	dev := uint64(st.Rdev)
+	if null.Devmajor != int64(unix.Major(dev)) || null.Devminor != int64(unix.Minor(dev)) {
+		t.Errorf("/dev/null device = %d,%d, want %d,%d", null.Devmajor, null.Devminor, unix.Major(dev), unix.Minor(dev))
+	}
+}
+
+// TestArchiveSkipsDevicesByDefault 测试默认仍跳过设备文件和命名管道
+func TestArchiveSkipsDevicesByDefault(t *testing.T) {
+	dir := t.TempDir()
+	if err := unix.Mkfifo(filepath.Join(dir, "fifo"), 0600); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+	if _, err := os.Stat("/dev/null"); err != nil {
+		t.Skipf("/dev/null not available: %v", err)
+	}
+
+	a, _ := NewArchiver([]string{dir, "/dev/null"}, nil)
+	if err := a.Archive(context.Background(), io.Discard); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	if got := len(a.Skipped()); got != 2 {
+		t.Errorf("expected 2 skipped files, got %+v", a.Skipped())
+	}
+}
@@ -0,0 +1,425 @@
+package archive
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+)
+
+// ErrUnsafePath 归档中的路径会写到目标目录之外（包含 ..，或经过目标目录中的符号链接）
+var ErrUnsafePath = errors.New("unsafe path in archive")
+
+// ErrExtractLimit 解压出的文件内容超过 ExtractOptions.MaxBytes
+var ErrExtractLimit = errors.New("extracted data exceeds the size limit")
+
+// ExtractOptions 解压选项，各项控制是否还原 PAX 扩展头 SCHILY.xattr.* 中对应类型的扩展属性
+// capabilities、SELinux 上下文和 trusted.* 等需要特权的属性只在以 root 运行时还原
+type ExtractOptions struct {
+	Xattrs       bool // user.*、trusted.* 等其他扩展属性
+	ACLs         bool // POSIX ACL（system.posix_acl_access、system.posix_acl_default）
+	Capabilities bool // setcap 设置的 capabilities（security.capability）
+	SELinux      bool // SELinux 安全上下文（security.selinux）
+
+	// OnFile 开始解压每个普通文件时调用，用于显示当前文件
+	OnFile func(path string)
+
+	// MaxBytes 普通文件内容的总字节数上限，超过时返回 ErrExtractLimit，0 表示不限制
+	MaxBytes int64
+
+	// Workers 并行写入普通文件的 worker 数，0 或 1 表示在读取归档的 goroutine 中按顺序写入
+	// 不超过 1MB 的文件读入内存后交给 worker，遇到符号链接、特殊文件等条目时先等待已提交的文件写完
+	Workers int
+
+	// Dictionary 返回 zstd 压缩的归档使用的字典，帧头中记录了字典 ID 时调用；gzip 归档不使用
+	Dictionary func(id uint32) ([]byte, error)
+}
+
+// ExtractStats 解压统计
+type ExtractStats struct {
+	Files   int   // 普通文件数
+	Bytes   int64 // 普通文件内容的字节数
+	Skipped []SkippedFile
+}
+
+// xattr 类型，与 ExtractOptions 的各项对应
+const (
+	xattrGeneral = iota
+	xattrACL
+	xattrCapability
+	xattrSELinux
+	xattrKinds
+)
+
+// xattrLabels 各类扩展属性在警告中的名称
+var xattrLabels = [xattrKinds]string{
+	i18n.N("扩展属性"),
+	i18n.N("POSIX ACL"),
+	i18n.N("capabilities"),
+	i18n.N("SELinux 上下文"),
+}
+
+// extractor 一次解压的状态
+type extractor struct {
+	dest string
+	opts ExtractOptions
+	root bool
+
+	pool *filePool // Workers > 1 时并行写入普通文件
+	read int64     // 已从归档中读出的普通文件字节数，用于检查 MaxBytes
+
+	mu    sync.Mutex // 启用 pool 时保护 stats 和扩展属性计数
+	stats ExtractStats
+	dirs  []dirTimes // 目录的修改时间在其内容解压完成后设置
+
+	unprivileged [xattrKinds]int   // 非 root 运行时跳过的文件数
+	failed       [xattrKinds]int   // 设置失败的文件数
+	failure      [xattrKinds]error // 每类的第一个错误
+}
+
+type dirTimes struct {
+	path    string
+	modTime time.Time
+}
+
+// Extract 将 tar.gz 或 tar.zst 流解压到 dest 目录，dest 不存在时创建
+// 归档中的绝对路径去掉开头的 /（与 GNU tar 相同），包含 .. 或经过符号链接的路径返回 ErrUnsafePath
+// 兼容旧版本以硬链接类型记录的符号链接
+func Extract(ctx context.Context, r io.Reader, dest string, opts ExtractOptions) (*ExtractStats, error) {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	gz, err := decompressReader(r, opts.Dictionary)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	e := &extractor{dest: dest, opts: opts, root: os.Geteuid() == 0}
+	if opts.Workers > 1 {
+		e.pool = newFilePool(e, opts.Workers)
+		defer e.pool.close()
+	}
+	tr := tar.NewReader(gz)
+	for {
+		select {
+		case <-ctx.Done():
+			return &e.stats, ctx.Err()
+		default:
+		}
+
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return &e.stats, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if err := e.extract(tr, hdr); err != nil {
+			return &e.stats, err
+		}
+	}
+	// 目录的修改时间在其中的文件写完后才能设置
+	if e.pool != nil {
+		if err := e.pool.close(); err != nil {
+			return &e.stats, err
+		}
+	}
+
+	for i := len(e.dirs) - 1; i >= 0; i-- {
+		d := e.dirs[i]
+		os.Chtimes(d.path, d.modTime, d.modTime)
+	}
+	e.summary()
+	return &e.stats, nil
+}
+
+// extract 解压一个条目
+func (e *extractor) extract(tr *tar.Reader, hdr *tar.Header) error {
+	path, err := e.target(hdr.Name)
+	if err != nil {
+		return err
+	}
+	if path == e.dest {
+		return nil
+	}
+	// 并行写入时，改变目录结构的条目和与正在写入的文件冲突的条目等待之前的文件写完
+	if e.pool != nil && (hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeDir || e.pool.busy(path)) {
+		if err := e.pool.wait(); err != nil {
+			return err
+		}
+	}
+	if err := e.checkParents(path); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	perm := os.FileMode(hdr.Mode).Perm()
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if info, err := os.Lstat(path); err == nil && !info.IsDir() {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to replace %s: %w", path, err)
+			}
+		}
+		if err := os.Mkdir(path, perm); err != nil && !os.IsExist(err) {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		os.Chmod(path, perm)
+		e.applyXattrs(path, hdr)
+		e.dirs = append(e.dirs, dirTimes{path, hdr.ModTime})
+		return nil
+
+	case tar.TypeReg:
+		if e.pool != nil && hdr.Size <= maxQueuedFileSize {
+			return e.queueFile(tr, path, hdr, perm)
+		}
+		if err := e.writeFile(tr, path, hdr, perm); err != nil {
+			return err
+		}
+		e.applyXattrs(path, hdr)
+
+	case tar.TypeSymlink, tar.TypeLink:
+		// 旧版本将符号链接记录为硬链接类型，归档器本身不生成硬链接
+		if err := removeExisting(path); err != nil {
+			return err
+		}
+		if err := os.Symlink(hdr.Linkname, path); err != nil {
+			return fmt.Errorf("failed to create symlink %s: %w", path, err)
+		}
+		e.applyXattrs(path, hdr)
+		return nil
+
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		if err := removeExisting(path); err != nil {
+			return err
+		}
+		if err := makeDevice(path, hdr.Typeflag, perm, hdr.Devmajor, hdr.Devminor); err != nil {
+			i18n.Printf("[警告] 无法创建特殊文件: %s (%v)\n", path, err)
+			e.stats.Skipped = append(e.stats.Skipped, SkippedFile{Path: path, Kind: SkipSpecial, Reason: err.Error()})
+			return nil
+		}
+		e.applyXattrs(path, hdr)
+
+	default:
+		i18n.Printf("[警告] 跳过不支持的条目类型: %s (%c)\n", hdr.Name, hdr.Typeflag)
+		e.stats.Skipped = append(e.stats.Skipped, SkippedFile{Path: path, Kind: SkipSpecial, Reason: fmt.Sprintf("unsupported type %q", hdr.Typeflag)})
+		return nil
+	}
+
+	os.Chtimes(path, hdr.ModTime, hdr.ModTime)
+	return nil
+}
+
+// target 返回条目在 dest 中的路径
+func (e *extractor) target(name string) (string, error) {
+	rel := filepath.Clean(filepath.FromSlash(strings.TrimLeft(name, "/")))
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) || filepath.VolumeName(rel) != "" {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	return filepath.Join(e.dest, rel), nil
+}
+
+// checkParents 检查 path 在 dest 中的各级父目录都不是符号链接，防止归档中的符号链接把后续条目引到 dest 之外
+func (e *extractor) checkParents(path string) error {
+	rel, err := filepath.Rel(e.dest, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return err
+	}
+	dir := e.dest
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", dir, err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %s is a symlink", ErrUnsafePath, dir)
+		}
+	}
+	return nil
+}
+
+// writeFile 写入普通文件，已存在的同名文件被替换
+func (e *extractor) writeFile(r io.Reader, path string, hdr *tar.Header, perm os.FileMode) error {
+	if e.opts.OnFile != nil {
+		e.opts.OnFile(path)
+	}
+	if limit := e.opts.MaxBytes; limit > 0 {
+		r = io.LimitReader(r, limit-e.read+1)
+	}
+	n, err := createFile(path, perm, r)
+	e.read += n
+	if err != nil {
+		return err
+	}
+	if limit := e.opts.MaxBytes; limit > 0 && e.read > limit {
+		return fmt.Errorf("%w: %d bytes", ErrExtractLimit, limit)
+	}
+	e.fileDone(n)
+	return nil
+}
+
+// queueFile 将普通文件的内容读入内存后交给 worker 写入
+func (e *extractor) queueFile(r io.Reader, path string, hdr *tar.Header, perm os.FileMode) error {
+	if limit := e.opts.MaxBytes; limit > 0 && e.read+hdr.Size > limit {
+		return fmt.Errorf("%w: %d bytes", ErrExtractLimit, limit)
+	}
+	data := make([]byte, hdr.Size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	e.read += hdr.Size
+	if e.opts.OnFile != nil {
+		e.opts.OnFile(path)
+	}
+	return e.pool.submit(fileJob{path: path, hdr: hdr, perm: perm, data: data})
+}
+
+// createFile 创建或替换 path 并写入 r 的内容，返回写入的字节数
+func createFile(path string, perm os.FileMode, r io.Reader) (int64, error) {
+	if info, err := os.Lstat(path); err == nil && !info.Mode().IsRegular() {
+		if err := removeExisting(path); err != nil {
+			return 0, err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	n, err := io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return n, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	// 已存在的文件保留原权限，按归档中的权限修正
+	if err := os.Chmod(path, perm); err != nil {
+		return n, fmt.Errorf("failed to chmod %s: %w", path, err)
+	}
+	return n, nil
+}
+
+// fileDone 记录写完的普通文件
+func (e *extractor) fileDone(n int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stats.Files++
+	e.stats.Bytes += n
+}
+
+// removeExisting 删除已存在的非目录文件，为创建符号链接、特殊文件让出位置
+func removeExisting(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err == nil && info.IsDir() {
+		return fmt.Errorf("failed to replace %s: is a directory", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyXattrs 按选项还原条目的扩展属性；失败只计数，在解压结束时汇总警告
+// capabilities 在写入内容和修改权限之后设置，否则会被内核清除
+func (e *extractor) applyXattrs(path string, hdr *tar.Header) {
+	var skipped, failed [xattrKinds]bool
+	for key, value := range hdr.PAXRecords {
+		name, ok := strings.CutPrefix(key, paxXattrPrefix)
+		if !ok {
+			continue
+		}
+		kind, privileged := classifyXattr(name)
+		if !e.enabled(kind) {
+			continue
+		}
+		if privileged && !e.root {
+			skipped[kind] = true
+			continue
+		}
+		if err := writeXattr(path, name, []byte(value)); err != nil {
+			e.mu.Lock()
+			if e.failure[kind] == nil {
+				e.failure[kind] = err
+			}
+			e.mu.Unlock()
+			failed[kind] = true
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for kind := range xattrKinds {
+		if skipped[kind] {
+			e.unprivileged[kind]++
+		}
+		if failed[kind] {
+			e.failed[kind]++
+		}
+	}
+}
+
+// enabled 返回是否还原 kind 类型的扩展属性
+func (e *extractor) enabled(kind int) bool {
+	switch kind {
+	case xattrACL:
+		return e.opts.ACLs
+	case xattrCapability:
+		return e.opts.Capabilities
+	case xattrSELinux:
+		return e.opts.SELinux
+	default:
+		return e.opts.Xattrs
+	}
+}
+
+// classifyXattr 返回扩展属性的类型，以及设置它是否需要 root 权限
+// 文件所有者可以设置 user.* 和 ACL，security.*、trusted.* 需要特权
+func classifyXattr(name string) (kind int, privileged bool) {
+	switch {
+	case name == "system.posix_acl_access" || name == "system.posix_acl_default":
+		return xattrACL, false
+	case name == "security.capability":
+		return xattrCapability, true
+	case name == selinuxXattr:
+		return xattrSELinux, true
+	case strings.HasPrefix(name, "security.") || strings.HasPrefix(name, "trusted."):
+		return xattrGeneral, true
+	default:
+		return xattrGeneral, false
+	}
+}
+
+// summary 每类扩展属性输出一行警告：因不是 root 跳过的文件数、设置失败的文件数和原因
+func (e *extractor) summary() {
+	for kind := range xattrKinds {
+		label := i18n.T(xattrLabels[kind])
+		if n := e.unprivileged[kind]; n > 0 {
+			i18n.Printf("[警告] 没有以 root 运行，跳过了 %d 个文件的%s\n", n, label)
+		}
+		if n := e.failed[kind]; n > 0 {
+			err := e.failure[kind]
+			if errors.Is(err, errors.ErrUnsupported) {
+				i18n.Printf("[警告] 当前系统或文件系统不支持%s，%d 个文件未还原\n", label, n)
+			} else {
+				i18n.Printf("[警告] %d 个文件的%s还原失败: %v\n", n, label, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxQueuedFileSize 交给 worker 写入的普通文件大小上限，更大的文件由读取归档的 goroutine 直接写入
+const maxQueuedFileSize = 1024 * 1024
+
+// fileJob 已读入内存、等待写入的普通文件
+type fileJob struct {
+	path string
+	hdr  *tar.Header
+	perm os.FileMode
+	data []byte
+}
+
+// filePool 并行写入普通文件的 worker 池
+// 读取归档（解压缩）和创建文件在不同的 goroutine 中进行，小文件多时文件系统的延迟不再阻塞解压
+type filePool struct {
+	e    *extractor
+	jobs chan fileJob
+
+	pending sync.WaitGroup // 已提交、尚未写完的文件
+	workers sync.WaitGroup
+
+	mu       sync.Mutex
+	inflight map[string]bool // 正在写入的路径
+	err      error           // 第一个写入错误
+	closed   bool
+}
+
+// newFilePool 启动 workers 个 worker，最多再排队 workers 个文件
+func newFilePool(e *extractor, workers int) *filePool {
+	p := &filePool{
+		e:        e,
+		jobs:     make(chan fileJob, workers),
+		inflight: make(map[string]bool),
+	}
+	p.workers.Add(workers)
+	for range workers {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *filePool) worker() {
+	defer p.workers.Done()
+	for job := range p.jobs {
+		err := p.e.writeQueued(job)
+		p.mu.Lock()
+		if err != nil && p.err == nil {
+			p.err = err
+		}
+		delete(p.inflight, job.path)
+		p.mu.Unlock()
+		p.pending.Done()
+	}
+}
+
+// submit 提交一个文件，之前的写入已经出错时返回该错误
+func (p *filePool) submit(job fileJob) error {
+	p.mu.Lock()
+	if err := p.err; err != nil {
+		p.mu.Unlock()
+		return err
+	}
+	p.inflight[job.path] = true
+	p.mu.Unlock()
+
+	p.pending.Add(1)
+	p.jobs <- job
+	return nil
+}
+
+// busy 判断 path 或它的某一级父目录是否正在被 worker 写入
+// 此时对 path 的操作必须等待写入完成，否则可能与 worker 的写入交错
+func (p *filePool) busy(path string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.inflight) == 0 {
+		return false
+	}
+	for dir := path; dir != p.e.dest && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if p.inflight[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// wait 等待已提交的文件全部写完，返回第一个写入错误
+// 创建符号链接、特殊文件等会改变目录结构的条目之前调用，保证 worker 不会写到被替换的路径上
+func (p *filePool) wait() error {
+	p.pending.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// close 等待写入完成并停止 worker，可以重复调用
+func (p *filePool) close() error {
+	err := p.wait()
+	p.mu.Lock()
+	closed := p.closed
+	p.closed = true
+	p.mu.Unlock()
+	if !closed {
+		close(p.jobs)
+		p.workers.Wait()
+	}
+	return err
+}
+
+// writeQueued 在 worker 中写入文件内容，并还原扩展属性和修改时间
+func (e *extractor) writeQueued(job fileJob) error {
+	n, err := createFile(job.path, job.perm, bytes.NewReader(job.data))
+	if err != nil {
+		return err
+	}
+	e.fileDone(n)
+	e.applyXattrs(job.path, job.hdr)
+	os.Chtimes(job.path, job.hdr.ModTime, job.hdr.ModTime)
+	return nil
+}
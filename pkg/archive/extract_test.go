@@ -0,0 +1,226 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tarGz 按 headers 生成 tar.gz，普通文件的内容为 contents 中同名的数据
+func tarGz(t *testing.T, headers []*tar.Header, contents map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, hdr := range headers {
+		data := contents[hdr.Name]
+		hdr.Size = int64(len(data))
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(data))
+	}
+	tw.Close()
+	gz.Close()
+	return &buf
+}
+
+// TestExtractRoundTrip 测试归档后解压得到相同的目录结构、内容、权限、修改时间和符号链接
+func TestExtractRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("hello"), 0600)
+	modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	os.Chtimes(filepath.Join(src, "sub", "a.txt"), modTime, modTime)
+	symlinks := os.Symlink("sub/a.txt", filepath.Join(src, "link")) == nil
+
+	var buf bytes.Buffer
+	a, _ := NewArchiver([]string{src}, nil)
+	if err := a.Archive(context.Background(), &buf); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+
+	dest := t.TempDir()
+	stats, err := Extract(context.Background(), &buf, dest, ExtractOptions{})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if stats.Files != 1 || stats.Bytes != 5 {
+		t.Errorf("stats = %+v, want 1 file of 5 bytes", stats)
+	}
+
+	restored := filepath.Join(dest, src)
+	path := filepath.Join(restored, "sub", "a.txt")
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("restored file = %q, %v", data, err)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 || !info.ModTime().Equal(modTime) {
+		t.Errorf("restored file mode = %v, mtime = %v", info.Mode(), info.ModTime())
+	}
+	if symlinks {
+		if target, err := os.Readlink(filepath.Join(restored, "link")); err != nil || target != "sub/a.txt" {
+			t.Errorf("restored symlink = %q, %v", target, err)
+		}
+	}
+}
+
+// TestExtractRejectsUnsafePaths 测试包含 .. 或经过符号链接写到目标目录之外的条目被拒绝
+func TestExtractRejectsUnsafePaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []*tar.Header
+	}{
+		{"parent reference", []*tar.Header{{Name: "../evil.txt", Typeflag: tar.TypeReg, Mode: 0644}}},
+		{"nested parent reference", []*tar.Header{{Name: "a/../../evil.txt", Typeflag: tar.TypeReg, Mode: 0644}}},
+		{"through symlink", []*tar.Header{
+			{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "{outside}"},
+			{Name: "link/evil.txt", Typeflag: tar.TypeReg, Mode: 0644},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outside := t.TempDir()
+			dest := filepath.Join(outside, "dest")
+			for _, hdr := range tt.headers {
+				if hdr.Linkname == "{outside}" {
+					hdr.Linkname = outside
+				}
+			}
+			_, err := Extract(context.Background(), tarGz(t, tt.headers, nil), dest, ExtractOptions{})
+			if !errors.Is(err, ErrUnsafePath) {
+				t.Fatalf("Extract() error = %v, want ErrUnsafePath", err)
+			}
+			if _, err := os.Stat(filepath.Join(outside, "evil.txt")); err == nil {
+				t.Error("file written outside the destination")
+			}
+		})
+	}
+}
+
+// TestExtractLegacySymlink 测试旧版本以硬链接类型记录的符号链接还原为符号链接
+func TestExtractLegacySymlink(t *testing.T) {
+	buf := tarGz(t, []*tar.Header{
+		{Name: "/data/file.txt", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "/data/link", Typeflag: tar.TypeLink, Linkname: "file.txt"},
+	}, map[string]string{"/data/file.txt": "content"})
+
+	dest := t.TempDir()
+	if _, err := Extract(context.Background(), buf, dest, ExtractOptions{}); err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	link := filepath.Join(dest, "data", "link")
+	if target, err := os.Readlink(link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	} else if target != "file.txt" {
+		t.Errorf("symlink target = %q, want file.txt", target)
+	}
+	if data, _ := os.ReadFile(link); string(data) != "content" {
+		t.Errorf("symlink content = %q", data)
+	}
+}
+
+// TestExtractMaxBytes 测试文件内容总量超过 MaxBytes 时解压失败
+func TestExtractMaxBytes(t *testing.T) {
+	headers := func() []*tar.Header {
+		return []*tar.Header{
+			{Name: "a", Typeflag: tar.TypeReg, Mode: 0644},
+			{Name: "b", Typeflag: tar.TypeReg, Mode: 0644},
+		}
+	}
+	contents := map[string]string{"a": "12345", "b": "67890"}
+
+	if _, err := Extract(context.Background(), tarGz(t, headers(), contents), t.TempDir(), ExtractOptions{MaxBytes: 10}); err != nil {
+		t.Fatalf("Extract() within the limit error = %v", err)
+	}
+	stats, err := Extract(context.Background(), tarGz(t, headers(), contents), t.TempDir(), ExtractOptions{MaxBytes: 9})
+	if !errors.Is(err, ErrExtractLimit) {
+		t.Fatalf("expected ErrExtractLimit, got %v", err)
+	}
+	if stats.Files != 1 {
+		t.Errorf("expected only the first file to be extracted, got %+v", stats)
+	}
+}
+
+// TestExtractWorkers 测试并行写入文件时内容、修改时间、重复条目和目录修改时间与顺序写入一致
+func TestExtractWorkers(t *testing.T) {
+	modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	headers := []*tar.Header{{Name: "dir", Typeflag: tar.TypeDir, Mode: 0755, ModTime: modTime}}
+	contents := map[string]string{}
+	for i := range 50 {
+		name := fmt.Sprintf("dir/f%02d", i)
+		headers = append(headers, &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0640, ModTime: modTime})
+		contents[name] = strings.Repeat(name, i+1)
+	}
+	// 超过 maxQueuedFileSize 的文件直接写入
+	headers = append(headers, &tar.Header{Name: "dir/large", Typeflag: tar.TypeReg, Mode: 0644, ModTime: modTime})
+	contents["dir/large"] = strings.Repeat("x", maxQueuedFileSize+1)
+	buf := tarGz(t, headers, contents)
+
+	dest := t.TempDir()
+	stats, err := Extract(context.Background(), buf, dest, ExtractOptions{Workers: 4})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if stats.Files != 51 {
+		t.Errorf("stats.Files = %d, want 51", stats.Files)
+	}
+	for name, want := range contents {
+		path := filepath.Join(dest, name)
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != want {
+			t.Fatalf("%s = %d bytes, %v", name, len(data), err)
+		}
+		if info, _ := os.Stat(path); !info.ModTime().Equal(modTime) {
+			t.Errorf("%s mtime = %v", name, info.ModTime())
+		}
+	}
+	if info, _ := os.Stat(filepath.Join(dest, "dir")); !info.ModTime().Equal(modTime) {
+		t.Errorf("dir mtime = %v, want %v", info.ModTime(), modTime)
+	}
+
+	// 同名条目以最后一个为准
+	var dup bytes.Buffer
+	gz := gzip.NewWriter(&dup)
+	tw := tar.NewWriter(gz)
+	for _, data := range []string{"first", "second"} {
+		tw.WriteHeader(&tar.Header{Name: "same", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(data))})
+		tw.Write([]byte(data))
+	}
+	tw.Close()
+	gz.Close()
+	if _, err := Extract(context.Background(), &dup, dest, ExtractOptions{Workers: 4}); err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "same")); string(data) != "second" {
+		t.Errorf("duplicate entry = %q, want second", data)
+	}
+}
+
+// TestExtractWorkersUnsafePath 测试并行写入时，替换正在写入的文件为符号链接后不能经过它写到目标目录之外
+func TestExtractWorkersUnsafePath(t *testing.T) {
+	outside := t.TempDir()
+	dest := filepath.Join(outside, "dest")
+	buf := tarGz(t, []*tar.Header{
+		{Name: "a", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "a", Typeflag: tar.TypeSymlink, Linkname: outside},
+		{Name: "a/evil.txt", Typeflag: tar.TypeReg, Mode: 0644},
+	}, map[string]string{"a": "content", "a/evil.txt": "evil"})
+
+	_, err := Extract(context.Background(), buf, dest, ExtractOptions{Workers: 4})
+	if !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("Extract() error = %v, want ErrUnsafePath", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil.txt")); err == nil {
+		t.Error("file written outside the destination")
+	}
+}
@@ -0,0 +1,35 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadFileList 读取文件列表，例如 find 的输出
+// 内容包含 NUL 字符时按 NUL 分隔（find -print0），否则按行分隔；空项被忽略
+func ReadFileList(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file list: %w", err)
+	}
+
+	sep := []byte("\n")
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = []byte{0}
+	}
+
+	var paths []string
+	for _, item := range bytes.Split(data, sep) {
+		path := string(item)
+		if sep[0] == '\n' {
+			path = strings.TrimSuffix(path, "\r")
+		}
+		if path == "" {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
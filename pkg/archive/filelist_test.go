@@ -0,0 +1,33 @@
+package archive
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestReadFileList 测试按行和按 NUL 分隔的文件列表
+func TestReadFileList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"newline", "a.txt\ndir/b.txt\n", []string{"a.txt", "dir/b.txt"}},
+		{"crlf and blank lines", "a.txt\r\n\r\n b.txt\r\n", []string{"a.txt", " b.txt"}},
+		{"nul", "a\nb.txt\x00c.txt\x00", []string{"a\nb.txt", "c.txt"}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadFileList(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ReadFileList() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadFileList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
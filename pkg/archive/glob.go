@@ -0,0 +1,156 @@
+package archive
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// hasMeta 判断路径是否包含通配符
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[]{")
+}
+
+// expandBraces 展开 {a,b} 选择（支持嵌套），没有逗号的 {x} 按字面处理
+func expandBraces(pattern string) []string {
+	open := strings.IndexByte(pattern, '{')
+	if open < 0 {
+		return []string{pattern}
+	}
+
+	depth := 0
+	start := open + 1
+	var alts []string
+	for i := open; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			depth++
+		case ',':
+			if depth == 1 {
+				alts = append(alts, pattern[start:i])
+				start = i + 1
+			}
+		case '}':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			var out []string
+			if alts == nil {
+				for _, rest := range expandBraces(pattern[i+1:]) {
+					out = append(out, pattern[:i+1]+rest)
+				}
+				return out
+			}
+			alts = append(alts, pattern[start:i])
+			for _, alt := range alts {
+				out = append(out, expandBraces(pattern[:open]+alt+pattern[i+1:])...)
+			}
+			return out
+		}
+	}
+
+	// 未闭合的 { 按字面处理
+	return []string{pattern}
+}
+
+// globPath 展开不含 {a,b} 的通配符，包含 ** 时递归匹配子目录
+func globPath(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
+	return globRecursive(pattern)
+}
+
+// globRecursive 从模式中不含通配符的前缀目录开始遍历，返回匹配的路径
+// ** 匹配任意层目录（包括零层）；目录匹配后不再进入，由归档器递归归档
+func globRecursive(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	var globs []glob.Glob
+	for _, p := range doublestarPatterns(pattern) {
+		g, err := glob.Compile(p, '/')
+		if err != nil {
+			return nil, err
+		}
+		globs = append(globs, g)
+	}
+
+	root := staticRoot(pattern)
+	if _, err := os.Stat(root); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 跳过无法访问的目录，与 filepath.Glob 忽略 I/O 错误的行为一致
+			return nil
+		}
+		if path == "." {
+			return nil
+		}
+		slashed := filepath.ToSlash(path)
+		for _, g := range globs {
+			if !g.Match(slashed) {
+				continue
+			}
+			matches = append(matches, path)
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// staticRoot 返回模式中第一个含通配符的路径组件之前的目录
+func staticRoot(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	i := 0
+	for i < len(parts) && !hasMeta(parts[i]) {
+		i++
+	}
+	root := strings.Join(parts[:i], "/")
+	if root == "" {
+		if strings.HasPrefix(pattern, "/") {
+			return "/"
+		}
+		return "."
+	}
+	return root
+}
+
+// doublestarPatterns 为作为完整路径组件的 ** 生成匹配零层目录的变体，
+// 例如 a/**/b 同时生成 a/b，a/** 同时生成 a
+func doublestarPatterns(pattern string) []string {
+	variants := [][]string{nil}
+	for _, part := range strings.Split(pattern, "/") {
+		var next [][]string
+		for _, v := range variants {
+			next = append(next, append(append([]string(nil), v...), part))
+			if part == "**" {
+				next = append(next, v)
+			}
+		}
+		variants = next
+	}
+
+	var patterns []string
+	for _, v := range variants {
+		if p := strings.Join(v, "/"); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,115 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestExpandBraces 测试 {a,b} 展开
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"a/b", []string{"a/b"}},
+		{"{a,b}/c", []string{"a/c", "b/c"}},
+		{"x/{a,b{c,d}}", []string{"x/a", "x/bc", "x/bd"}},
+		{"{a,b}/{c,d}", []string{"a/c", "a/d", "b/c", "b/d"}},
+		{"*.{yml,}", []string{"*.yml", "*."}},
+		{"literal{x}/{a,b}", []string{"literal{x}/a", "literal{x}/b"}},
+		{"unclosed{a,b", []string{"unclosed{a,b"}},
+	}
+	for _, tt := range tests {
+		if got := expandBraces(tt.pattern); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandBraces(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+// TestResolveIncludesRecursive 测试 ** 和 {a,b} 展开
+func TestResolveIncludesRecursive(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{
+		"main.go",
+		"pkg/a/a.go",
+		"pkg/a/a_test.go",
+		"pkg/b/deep/b.go",
+		"pkg/b/readme.md",
+		"etc/nginx/nginx.conf",
+		"etc/ssh/sshd_config",
+		"etc/other/x.conf",
+	} {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("content"), 0644)
+	}
+	rel := func(paths ...string) []string {
+		out := make([]string, len(paths))
+		for i, p := range paths {
+			out[i] = filepath.Join(tmpDir, p)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{"recursive files", "**/*.go", rel("main.go", "pkg/a/a.go", "pkg/a/a_test.go", "pkg/b/deep/b.go")},
+		{"zero directories", "pkg/**/a.go", rel("pkg/a/a.go")},
+		{"trailing doublestar matches directory", "pkg/**", rel("pkg")},
+		{"directory match is not descended", "**/deep", rel("pkg/b/deep")},
+		{"braces", "etc/{nginx,ssh}", rel("etc/nginx", "etc/ssh")},
+		{"braces with doublestar", "{etc,pkg}/**/*.{conf,md}", rel("etc/nginx/nginx.conf", "etc/other/x.conf", "pkg/b/readme.md")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveIncludes([]string{filepath.Join(tmpDir, tt.pattern)})
+			if err != nil {
+				t.Fatalf("ResolveIncludes() error = %v", err)
+			}
+			sort.Strings(got)
+			sort.Strings(tt.want)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveIncludes(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+
+	// 重叠的模式不会产生重复路径
+	got, err := ResolveIncludes([]string{filepath.Join(tmpDir, "etc/{nginx,nginx}"), filepath.Join(tmpDir, "etc", "nginx")})
+	if err != nil {
+		t.Fatalf("ResolveIncludes() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("expected duplicates to be removed, got %q", got)
+	}
+}
+
+// TestResolveIncludesAllowEmpty 测试允许通配符没有匹配
+func TestResolveIncludesAllowEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("content"), 0644)
+	patterns := []string{filepath.Join(tmpDir, "**/*.log"), filepath.Join(tmpDir, "*.txt")}
+
+	if _, err := ResolveIncludes(patterns); err == nil {
+		t.Error("expected error for pattern without matches")
+	}
+
+	got, err := ResolveIncludesAllowEmpty(patterns)
+	if err != nil {
+		t.Fatalf("ResolveIncludesAllowEmpty() error = %v", err)
+	}
+	if want := []string{filepath.Join(tmpDir, "file.txt")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveIncludesAllowEmpty() = %q, want %q", got, want)
+	}
+
+	// 不含通配符的路径仍然必须存在
+	if _, err := ResolveIncludesAllowEmpty([]string{filepath.Join(tmpDir, "missing")}); err == nil {
+		t.Error("expected error for missing literal path")
+	}
+}
@@ -0,0 +1,29 @@
+package archive
+
+import "github.com/lukelzlz/s3backup/pkg/i18n"
+
+// 归档警告的英文翻译
+func init() {
+	i18n.Register(i18n.En, map[string]string{
+		"[警告] 跳过无法访问的文件: %s (%v)\n":        "[WARN] skipping inaccessible file: %s (%v)\n",
+		"[警告] 跳过特殊文件: %s (mode: %v)\n":     "[WARN] skipping special file: %s (mode: %v)\n",
+		"[警告] 无法读取目录: %s (%v)\n":           "[WARN] cannot read directory: %s (%v)\n",
+		"[警告] 无法读取符号链接: %s (%v)\n":         "[WARN] cannot read symlink: %s (%v)\n",
+		"[警告] 无法打开文件: %s (%v)\n":           "[WARN] cannot open file: %s (%v)\n",
+		"[警告] 共跳过 %d 个%s，另外 %d 个未逐条显示\n":   "[WARN] skipped %d %s in total, %d more not shown\n",
+		"无法访问的文件":                          "inaccessible files",
+		"特殊文件":                             "special files",
+		"无法读取的目录":                          "unreadable directories",
+		"无法读取的符号链接":                        "unreadable symlinks",
+		"[警告] 无法读取扩展属性: %s (%v)\n":         "[WARN] cannot read extended attributes: %s (%v)\n",
+		"无法读取扩展属性的文件":                      "files with unreadable extended attributes",
+		"[警告] 无法创建特殊文件: %s (%v)\n":         "[WARN] cannot create special file: %s (%v)\n",
+		"[警告] 跳过不支持的条目类型: %s (%c)\n":       "[WARN] skipping unsupported entry type: %s (%c)\n",
+		"[警告] 没有以 root 运行，跳过了 %d 个文件的%s\n": "[WARN] not running as root, skipped %[2]s of %[1]d files\n",
+		"[警告] 当前系统或文件系统不支持%s，%d 个文件未还原\n":  "[WARN] %s are not supported on this system or filesystem, not restored for %d files\n",
+		"[警告] %d 个文件的%s还原失败: %v\n":         "[WARN] failed to restore %[2]s of %[1]d files: %[3]v\n",
+		"扩展属性":        "extended attributes",
+		"SELinux 上下文": "SELinux contexts",
+		"无法打开的文件":     "files that could not be opened",
+	})
+}
@@ -0,0 +1,21 @@
+package archive
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// makeDevice 创建设备文件或命名管道，创建设备文件需要 root 权限
+func makeDevice(path string, typeflag byte, perm os.FileMode, major, minor int64) error {
+	mode := uint32(perm)
+	switch typeflag {
+	case TypeFifo:
+		return unix.Mkfifo(path, mode)
+	case TypeChar:
+		mode |= unix.S_IFCHR
+	default:
+		mode |= unix.S_IFBLK
+	}
+	return unix.Mknod(path, mode, int(unix.Mkdev(uint32(major), uint32(minor))))
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package archive
+
+import (
+	"errors"
+	"os"
+)
+
+// makeDevice 目前只支持在 Linux 上创建设备文件和命名管道
+func makeDevice(path string, typeflag byte, perm os.FileMode, major, minor int64) error {
+	return errors.ErrUnsupported
+}
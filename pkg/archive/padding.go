@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// gzipPadMemberSize 不含附加字段数据的空 gzip 成员大小：文件头 10 + XLEN 2 + 子字段头 4 + 空压缩块 2 + CRC32/ISIZE 8
+	gzipPadMemberSize = 26
+	// gzipPadMaxData 单个 gzip 成员附加字段中子字段数据的上限（XLEN 最大 65535，减去子字段头）
+	gzipPadMaxData = 65535 - 4
+
+	// zstdPadFrameSize zstd 跳过帧的帧头大小（魔数 4 + 长度 4）
+	zstdPadFrameSize = 8
+	// zstdPadMaxData 单个跳过帧的数据上限，更长的填充写为多个帧
+	zstdPadMaxData = 1 << 20
+)
+
+// zstdSkippableMagic zstd 跳过帧的魔数（0x184D2A50，小端序），解压时忽略其内容
+var zstdSkippableMagic = []byte{0x50, 0x2a, 0x4d, 0x18}
+
+// PadTarget 返回 size 字节填充后的大小：bucket 为 0 时为不小于 size 的 2 的幂，否则为 bucket 的整数倍
+func PadTarget(size, bucket int64) int64 {
+	if bucket > 0 {
+		return (size + bucket - 1) / bucket * bucket
+	}
+	target := int64(1)
+	for target < size {
+		target <<= 1
+	}
+	return target
+}
+
+// PadLength 返回将 size 字节的输出填充到 PadTarget 需要追加的字节数
+// 差值小于最短的填充（一个空 gzip 成员或 zstd 跳过帧）时填充到下一档
+func PadLength(size, bucket int64, zstd bool) int64 {
+	minPad := int64(gzipPadMemberSize)
+	if zstd {
+		minPad = zstdPadFrameSize
+	}
+	pad := PadTarget(size, bucket) - size
+	if pad > 0 && pad < minPad {
+		pad = PadTarget(size+minPad, bucket) - size
+	}
+	return pad
+}
+
+// WritePadding 在压缩流之后写入 n 字节解压时被忽略的填充（n 为 0 或不小于最短填充，见 PadLength）
+// gzip 写为附加字段中携带填充的空成员，zstd 写为跳过帧，gzip -d、zstd -d 和还原都得到与填充前相同的数据
+// 填充内容为零，只应在加密层之下使用，由密文隐藏
+func WritePadding(w io.Writer, n int64, zstd bool) error {
+	header, maxData := int64(gzipPadMemberSize), int64(gzipPadMaxData)
+	if zstd {
+		header, maxData = zstdPadFrameSize, zstdPadMaxData
+	}
+	if n == 0 {
+		return nil
+	}
+	if n < header {
+		return fmt.Errorf("padding of %d bytes is shorter than the minimum of %d", n, header)
+	}
+
+	// 平均分配到 count 个成员（帧），每个都不超过上限且不短于帧头
+	count := (n + header + maxData - 1) / (header + maxData)
+	zeros := make([]byte, min(maxData, n/count+1))
+	for i := int64(0); i < count; i++ {
+		size := n / count
+		if i < n%count {
+			size++
+		}
+		data := size - header
+
+		var frame []byte
+		if zstd {
+			frame = binary.LittleEndian.AppendUint32(append([]byte{}, zstdSkippableMagic...), uint32(data))
+		} else {
+			// FLG=FEXTRA，MTIME=0，XFL=0，OS=255；附加字段为一个 "SP" 子字段
+			frame = []byte{0x1f, 0x8b, 8, 4, 0, 0, 0, 0, 0, 255}
+			frame = binary.LittleEndian.AppendUint16(frame, uint16(data+4))
+			frame = append(frame, 'S', 'P')
+			frame = binary.LittleEndian.AppendUint16(frame, uint16(data))
+		}
+		if _, err := w.Write(frame); err != nil {
+			return fmt.Errorf("failed to write padding: %w", err)
+		}
+		if _, err := w.Write(zeros[:data]); err != nil {
+			return fmt.Errorf("failed to write padding: %w", err)
+		}
+		if !zstd {
+			// 空的固定 Huffman 结束块，CRC32 和 ISIZE 均为 0
+			if _, err := w.Write([]byte{3, 0, 0, 0, 0, 0, 0, 0, 0, 0}); err != nil {
+				return fmt.Errorf("failed to write padding: %w", err)
+			}
+		}
+	}
+	return nil
+}
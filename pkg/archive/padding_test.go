@@ -0,0 +1,76 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func TestPadLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int64
+		bucket int64
+		zstd   bool
+		want   int64
+	}{
+		{"power of two", 990, 0, false, 34},
+		{"exact power of two", 1024, 0, false, 0},
+		{"gzip gap too short", 1020, 0, false, 1028},
+		{"zstd gap fits a frame", 1016, 0, true, 8},
+		{"zstd gap too short", 1020, 0, true, 1028},
+		{"bucket", 1000, 300, false, 200},
+		{"exact bucket", 900, 300, true, 0},
+		{"bucket gap too short", 890, 300, false, 310},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PadLength(tt.size, tt.bucket, tt.zstd); got != tt.want {
+				t.Errorf("PadLength(%d, %d) = %d, want %d", tt.size, tt.bucket, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWritePadding 测试填充后的 gzip 和 zstd 流长度正确，解压得到与填充前相同的数据
+func TestWritePadding(t *testing.T) {
+	payload := bytes.Repeat([]byte("s3backup padding "), 1000)
+	for _, zstd := range []bool{false, true} {
+		for _, n := range []int64{0, 26, 1000, 3 << 20} {
+			var buf bytes.Buffer
+			var w io.WriteCloser
+			if zstd {
+				w, _ = NewZstdWriter(&buf, nil)
+			} else {
+				w = gzip.NewWriter(&buf)
+			}
+			w.Write(payload)
+			w.Close()
+			compressed := int64(buf.Len())
+
+			if err := WritePadding(&buf, n, zstd); err != nil {
+				t.Fatalf("WritePadding(%d, zstd=%v) error = %v", n, zstd, err)
+			}
+			if got := int64(buf.Len()) - compressed; got != n {
+				t.Errorf("zstd=%v: wrote %d bytes of padding, want %d", zstd, got, n)
+			}
+
+			r, err := decompressReader(&buf, nil)
+			if err != nil {
+				t.Fatalf("zstd=%v: decompressReader() error = %v", zstd, err)
+			}
+			data, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("zstd=%v, padding %d: read error = %v", zstd, n, err)
+			}
+			if !bytes.Equal(data, payload) {
+				t.Errorf("zstd=%v, padding %d: decompressed %d bytes, want %d", zstd, n, len(data), len(payload))
+			}
+		}
+	}
+
+	if err := WritePadding(io.Discard, 10, false); err == nil {
+		t.Error("WritePadding() accepted padding shorter than a gzip member")
+	}
+}
@@ -0,0 +1,139 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/lukelzlz/s3backup/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// archiveParallel 并行归档各个包含路径
+// 每个路径单独打包为 gzip 成员（zstd 时为单独的帧）：第一个路径直接写入 w，其余路径先写入临时文件，
+// 完成后按配置中的顺序追加到 w。除最后一个路径外都不写 tar 结束标记，
+// 拼接结果是一个标准的多成员 tar.gz，条目顺序与顺序归档相同
+func (a *Archiver) archiveParallel(ctx context.Context, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	n := len(a.includes)
+	subs := make([]*Archiver, n)
+	outs := make([]io.Writer, n)
+	temps := make([]*os.File, n)
+	defer func() {
+		for _, f := range temps {
+			if f != nil {
+				f.Close()
+				os.Remove(f.Name())
+			}
+		}
+	}()
+
+	outs[0] = w
+	for i := range a.includes {
+		subs[i] = a.sub()
+		if i == 0 {
+			continue
+		}
+		f, err := os.CreateTemp(a.tempDir, "s3backup-root-*.tar.gz")
+		if err != nil {
+			return fmt.Errorf("failed to create temp file: %w", err)
+		}
+		temps[i], outs[i] = f, f
+	}
+
+	var (
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	sem := make(chan struct{}, a.parallel)
+	done := make([]chan struct{}, n)
+	for i := range a.includes {
+		done[i] = make(chan struct{})
+		go func(i int) {
+			defer close(done[i])
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			rctx, span := tracing.Start(ctx, "archive_root", attribute.String("s3backup.root", a.includes[i]))
+			err := subs[i].archiveRoots(rctx, outs[i], a.includes[i:i+1], i == n-1)
+			tracing.End(span, err)
+			if err != nil {
+				fail(err)
+			}
+		}(i)
+	}
+
+	// 按顺序等待各路径完成并追加暂存的输出；出错后仍等待所有 goroutine 退出再清理临时文件
+	for i := range a.includes {
+		<-done[i]
+		if i == 0 || ctx.Err() != nil {
+			continue
+		}
+		if _, err := temps[i].Seek(0, io.SeekStart); err != nil {
+			fail(fmt.Errorf("failed to read temp file: %w", err))
+			continue
+		}
+		if _, err := io.Copy(w, temps[i]); err != nil {
+			fail(fmt.Errorf("failed to append archive of %s: %w", a.includes[i], err))
+		}
+	}
+
+	a.merge(subs)
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// sub 返回归档单个包含路径的归档器，共享排除模式和选项，统计单独记录
+func (a *Archiver) sub() *Archiver {
+	s := &Archiver{
+		excludes:   a.excludes,
+		patterns:   a.patterns,
+		hits:       make([]int, len(a.hits)),
+		ignoreCase: a.ignoreCase,
+		onFile:     a.onFile,
+		store:      a.store,
+		warnings:   a.warnings,
+		strict:     a.strict,
+		devices:    a.devices,
+		xattrs:     a.xattrs,
+		selinux:    a.selinux,
+		sampler:    a.sampler,
+		zstd:       a.zstd,
+		dictionary: a.dictionary,
+	}
+	if a.meter != nil {
+		s.meter = NewCompressionMeter(a.meter.partSize)
+	}
+	return s
+}
+
+// merge 按包含路径的顺序合并各归档器的统计
+func (a *Archiver) merge(subs []*Archiver) {
+	for _, s := range subs {
+		a.skipped = append(a.skipped, s.skipped...)
+		a.files += s.files
+		a.bytes += s.bytes
+		for i, n := range s.hits {
+			a.hits[i] += n
+		}
+		if a.meter != nil {
+			a.meter.add(s.meter.Stats())
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestArchiveParallel 测试并行归档多个包含路径，解压后的 tar 与顺序归档完全相同，统计合并正确
+func TestArchiveParallel(t *testing.T) {
+	base := t.TempDir()
+	var roots []string
+	for i := 0; i < 4; i++ {
+		root := filepath.Join(base, fmt.Sprintf("root%d", i))
+		os.MkdirAll(filepath.Join(root, "sub"), 0755)
+		for j := 0; j < 3; j++ {
+			data := bytes.Repeat([]byte(fmt.Sprintf("root %d file %d\n", i, j)), 1000*(j+1))
+			os.WriteFile(filepath.Join(root, "sub", fmt.Sprintf("f%d.txt", j)), data, 0644)
+		}
+		os.WriteFile(filepath.Join(root, "skip.log"), []byte("log"), 0644)
+		roots = append(roots, root)
+	}
+
+	tempDir := t.TempDir()
+	archiveWith := func(parallel int) ([]byte, *Archiver, CompressionStats) {
+		meter := NewCompressionMeter(4096)
+		a, err := NewArchiverWithOptions(roots, []string{"*.log"}, Options{
+			Meter:    meter,
+			Parallel: parallel,
+			TempDir:  tempDir,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := a.Archive(context.Background(), &buf); err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		gz, err := gzip.NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(gz)
+		if err != nil {
+			t.Fatalf("gzip read error = %v", err)
+		}
+		return data, a, meter.Stats()
+	}
+
+	seqTar, seq, seqStats := archiveWith(0)
+	parTar, par, parStats := archiveWith(2)
+
+	if !bytes.Equal(seqTar, parTar) {
+		t.Error("parallel archive differs from sequential archive")
+	}
+	seqFiles, seqBytes := seq.Stats()
+	parFiles, parBytes := par.Stats()
+	if parFiles != seqFiles || parBytes != seqBytes || parFiles != 12 {
+		t.Errorf("stats = %d files, %d bytes, want %d files, %d bytes", parFiles, parBytes, seqFiles, seqBytes)
+	}
+	if len(par.UnmatchedExcludes()) != 0 {
+		t.Errorf("exclude hits not merged: %v", par.UnmatchedExcludes())
+	}
+	if parStats.Raw != seqStats.Raw || parStats.Raw != int64(len(parTar)) {
+		t.Errorf("raw bytes = %d, want %d", parStats.Raw, len(parTar))
+	}
+	if len(parStats.Parts) == 0 {
+		t.Error("expected per-part compression stats")
+	}
+
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("temp files left behind: %d", len(entries))
+	}
+}
+
+// TestArchiveParallelError 测试某个包含路径失败时返回错误并清理临时文件
+func TestArchiveParallelError(t *testing.T) {
+	base := t.TempDir()
+	good := filepath.Join(base, "good")
+	os.MkdirAll(good, 0755)
+	os.WriteFile(filepath.Join(good, "a.txt"), []byte("a"), 0644)
+	missing := filepath.Join(base, "missing")
+
+	tempDir := t.TempDir()
+	a, err := NewArchiverWithOptions([]string{good, missing, good}, nil, Options{Parallel: 3, TempDir: tempDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Archive(context.Background(), io.Discard); err == nil {
+		t.Fatal("expected error for missing include")
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("temp files left behind: %d", len(entries))
+	}
+}
@@ -0,0 +1,80 @@
+package archive
+
+import (
+	"math/rand/v2"
+	"sort"
+	"sync"
+)
+
+// FileHash 归档中一个普通文件的内容摘要，用于还原后抽查文件内容
+type FileHash struct {
+	Path   string `json:"path"` // 归档中的路径
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// fileSampler 以蓄水池抽样从所有普通文件中等概率选出最多 k 个文件并记录摘要
+// 只有被选中的文件才计算 SHA-256；并行归档的各路径共用同一个 fileSampler
+type fileSampler struct {
+	mu      sync.Mutex
+	k       int
+	seen    int
+	slots   []FileHash
+	tickets []int // 每个位置当前归属的文件序号，计算摘要期间被替换的文件不再写入
+}
+
+func newFileSampler(k int) *fileSampler {
+	if k <= 0 {
+		return nil
+	}
+	return &fileSampler{k: k}
+}
+
+// offer 登记一个文件，返回其被选中时的位置和序号
+func (s *fileSampler) offer() (slot, ticket int, ok bool) {
+	if s == nil {
+		return 0, 0, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seen++
+	if len(s.slots) < s.k {
+		s.slots = append(s.slots, FileHash{})
+		s.tickets = append(s.tickets, s.seen)
+		return len(s.slots) - 1, s.seen, true
+	}
+	if j := rand.IntN(s.seen); j < s.k {
+		s.slots[j] = FileHash{}
+		s.tickets[j] = s.seen
+		return j, s.seen, true
+	}
+	return 0, 0, false
+}
+
+// record 写入被选中文件的摘要，位置已被之后的文件替换时忽略
+func (s *fileSampler) record(slot, ticket int, h FileHash) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tickets[slot] == ticket {
+		s.slots[slot] = h
+	}
+}
+
+// samples 返回已记录的摘要，按路径排序
+func (s *fileSampler) samples() []FileHash {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	samples := make([]FileHash, 0, len(s.slots))
+	for _, h := range s.slots {
+		if h.SHA256 != "" {
+			samples = append(samples, h)
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Path < samples[j].Path })
+	return samples
+}
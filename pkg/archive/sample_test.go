@@ -0,0 +1,51 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFileSamples 测试 HashSample 记录被抽样文件的路径、大小和 SHA-256，抽样数不超过上限
+func TestFileSamples(t *testing.T) {
+	for _, parallel := range []int{0, 2} {
+		t.Run(fmt.Sprintf("parallel=%d", parallel), func(t *testing.T) {
+			roots := []string{t.TempDir(), t.TempDir()}
+			want := make(map[string]string)
+			for i := range 20 {
+				path := filepath.Join(roots[i%2], fmt.Sprintf("f%02d.txt", i))
+				data := []byte(fmt.Sprintf("content %d", i))
+				os.WriteFile(path, data, 0644)
+				sum := sha256.Sum256(data)
+				want[path] = hex.EncodeToString(sum[:])
+			}
+
+			a, err := NewArchiverWithOptions(roots, nil, Options{HashSample: 5, Parallel: parallel})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := a.Archive(context.Background(), &bytes.Buffer{}); err != nil {
+				t.Fatalf("Archive() error = %v", err)
+			}
+			samples := a.FileSamples()
+			if len(samples) != 5 {
+				t.Fatalf("expected 5 samples, got %+v", samples)
+			}
+			for _, s := range samples {
+				if want[s.Path] != s.SHA256 || s.Size != int64(len("content 0")) && s.Size != int64(len("content 10")) {
+					t.Errorf("unexpected sample %+v", s)
+				}
+			}
+		})
+	}
+
+	a, _ := NewArchiver([]string{t.TempDir()}, nil)
+	if samples := a.FileSamples(); samples != nil {
+		t.Errorf("expected no samples without HashSample, got %+v", samples)
+	}
+}
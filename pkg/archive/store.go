@@ -0,0 +1,80 @@
+package archive
+
+import (
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultStoreExtensions 智能压缩模式下默认不压缩的扩展名：已压缩的图片、音视频和压缩包，再次压缩几乎没有效果
+var DefaultStoreExtensions = []string{
+	".jpg", ".jpeg", ".png", ".gif", ".webp", ".heic", ".avif",
+	".mp3", ".aac", ".ogg", ".flac", ".m4a",
+	".mp4", ".m4v", ".mkv", ".mov", ".avi", ".webm",
+	".zip", ".gz", ".tgz", ".bz2", ".xz", ".zst", ".7z", ".rar", ".jar",
+}
+
+// storeSet 不压缩的扩展名集合（小写，带前导点）
+type storeSet map[string]bool
+
+// newStoreSet 规范化扩展名，接受 jpg、.JPG 等写法
+func newStoreSet(exts []string) storeSet {
+	if len(exts) == 0 {
+		return nil
+	}
+	set := make(storeSet, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		set[ext] = true
+	}
+	return set
+}
+
+// match 检查文件是否按扩展名（不区分大小写）不压缩
+func (s storeSet) match(path string) bool {
+	return s[strings.ToLower(filepath.Ext(path))]
+}
+
+// multiGzipWriter 由多个 gzip 成员组成的写入器，可以在文件之间切换压缩级别
+// 多成员 gzip 是标准格式，gzip -d、tar -z 和 Go 的 gzip.Reader 都会依次解压所有成员
+type multiGzipWriter struct {
+	w     io.Writer
+	level int
+	gz    *gzip.Writer
+}
+
+func newMultiGzipWriter(w io.Writer) *multiGzipWriter {
+	return &multiGzipWriter{w: w, level: gzip.DefaultCompression, gz: gzip.NewWriter(w)}
+}
+
+func (m *multiGzipWriter) Write(p []byte) (int, error) {
+	return m.gz.Write(p)
+}
+
+// setLevel 结束当前成员并以 level 开始新成员，级别没有变化时不做任何事
+func (m *multiGzipWriter) setLevel(level int) error {
+	if level == m.level {
+		return nil
+	}
+	if err := m.gz.Close(); err != nil {
+		return err
+	}
+	gz, err := gzip.NewWriterLevel(m.w, level)
+	if err != nil {
+		return err
+	}
+	m.gz, m.level = gz, level
+	return nil
+}
+
+// Close 结束最后一个成员
+func (m *multiGzipWriter) Close() error {
+	return m.gz.Close()
+}
@@ -0,0 +1,98 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestNewStoreSet 测试扩展名规范化和不区分大小写的匹配
+func TestNewStoreSet(t *testing.T) {
+	set := newStoreSet([]string{"jpg", ".MP4", " zip ", ""})
+	tests := map[string]bool{
+		"photo.jpg":       true,
+		"PHOTO.JPG":       true,
+		"movie.mp4":       true,
+		"dir/archive.zip": true,
+		"notes.txt":       false,
+		"jpg":             false,
+	}
+	for path, want := range tests {
+		if got := set.match(path); got != want {
+			t.Errorf("match(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if newStoreSet(nil) != nil {
+		t.Error("expected nil set for no extensions")
+	}
+}
+
+// TestArchiveStoreExtensions 测试匹配扩展名的文件不压缩存储，输出仍是可以正常解压的 tar.gz
+func TestArchiveStoreExtensions(t *testing.T) {
+	dir := t.TempDir()
+	photo := make([]byte, 100*1024)
+	rand.Read(photo)
+	text := bytes.Repeat([]byte("compressible text\n"), 10000)
+	files := map[string][]byte{"a.txt": text, "b.jpg": photo, "c.txt": text}
+	for name, data := range files {
+		os.WriteFile(filepath.Join(dir, name), data, 0644)
+	}
+
+	archiveWith := func(exts []string) []byte {
+		a, err := NewArchiverWithOptions([]string{dir}, nil, Options{StoreExtensions: exts})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := a.Archive(context.Background(), &buf); err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		return buf.Bytes()
+	}
+	stored := archiveWith([]string{"jpg"})
+
+	// 不压缩的成员中保留原始字节（deflate 存储块每 64KB 插入块头，只比较开头部分）
+	if !bytes.Contains(stored, photo[:32*1024]) {
+		t.Error("jpg content should be stored uncompressed")
+	}
+	if bytes.Contains(archiveWith(nil), photo[:32*1024]) {
+		t.Error("jpg content should be compressed without StoreExtensions")
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(stored))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	found := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar read error = %v", err)
+		}
+		want, ok := files[filepath.Base(hdr.Name)]
+		if !ok || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		got, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s content mismatch", hdr.Name)
+		}
+		found++
+	}
+	if found != len(files) {
+		t.Errorf("extracted %d files, want %d", found, len(files))
+	}
+}
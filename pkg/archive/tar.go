@@ -33,6 +33,7 @@ type TarHeader struct {
 	AccessTime time.Time
 	ChangeTime time.Time
 	Xattrs     map[string]string
+	PAXRecords map[string]string
 }
 
 // WriteHeader 写入 tar 头部
@@ -53,12 +54,17 @@ func (tw *TarWriter) WriteHeader(hdr *TarHeader) error {
 		AccessTime: hdr.AccessTime,
 		ChangeTime: hdr.ChangeTime,
 		Xattrs:     hdr.Xattrs,
+		PAXRecords: hdr.PAXRecords,
 	})
 }
 
 // 文件类型常量
 const (
-	TypeReg  = tar.TypeReg  // 普通文件
-	TypeLink = tar.TypeLink // 硬链接
-	TypeDir  = tar.TypeDir  // 目录
+	TypeReg     = tar.TypeReg     // 普通文件
+	TypeLink    = tar.TypeLink    // 硬链接
+	TypeSymlink = tar.TypeSymlink // 符号链接
+	TypeDir     = tar.TypeDir     // 目录
+	TypeChar    = tar.TypeChar    // 字符设备
+	TypeBlock   = tar.TypeBlock   // 块设备
+	TypeFifo    = tar.TypeFifo    // 命名管道
 )
@@ -0,0 +1,94 @@
+package archive
+
+import (
+	"sync"
+
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+)
+
+// DefaultMaxWarnings 每类警告默认逐条输出的数量
+const DefaultMaxWarnings = 20
+
+// 跳过路径的原因类型，同类警告超过上限后合并为一行汇总
+const (
+	SkipInaccessible = "inaccessible" // 无法获取文件信息
+	SkipSpecial      = "special"      // 设备文件、管道、套接字等特殊文件
+	SkipDir          = "dir"          // 无法读取的目录
+	SkipSymlink      = "symlink"      // 无法读取的符号链接
+	SkipFile         = "file"         // 无法打开的文件
+
+	// warnXattr 无法读取扩展属性，文件仍正常归档，不计入 Skipped
+	warnXattr = "xattr"
+)
+
+// skipKinds 按输出汇总的顺序排列的跳过类型、逐条警告和汇总时的名称
+var skipKinds = []struct {
+	kind, message, label string
+}{
+	{SkipInaccessible, i18n.N("[警告] 跳过无法访问的文件: %s (%v)\n"), i18n.N("无法访问的文件")},
+	{SkipSpecial, i18n.N("[警告] 跳过特殊文件: %s (mode: %v)\n"), i18n.N("特殊文件")},
+	{SkipDir, i18n.N("[警告] 无法读取目录: %s (%v)\n"), i18n.N("无法读取的目录")},
+	{SkipSymlink, i18n.N("[警告] 无法读取符号链接: %s (%v)\n"), i18n.N("无法读取的符号链接")},
+	{SkipFile, i18n.N("[警告] 无法打开文件: %s (%v)\n"), i18n.N("无法打开的文件")},
+	{warnXattr, i18n.N("[警告] 无法读取扩展属性: %s (%v)\n"), i18n.N("无法读取扩展属性的文件")},
+}
+
+// warnLimiter 按类型限制逐条输出的警告数，超出的只计数
+// 并行归档时由各路径的归档器共享
+type warnLimiter struct {
+	mu     sync.Mutex
+	limit  int // 每类警告逐条输出的数量，负数表示不限制
+	counts map[string]int
+}
+
+func newWarnLimiter(limit int) *warnLimiter {
+	if limit == 0 {
+		limit = DefaultMaxWarnings
+	}
+	return &warnLimiter{limit: limit, counts: make(map[string]int)}
+}
+
+// warn 输出一条 kind 类型的警告，该类型已达到上限时不输出
+func (l *warnLimiter) warn(kind, path string, detail any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.counts[kind]++
+	if l.limit >= 0 && l.counts[kind] > l.limit {
+		return
+	}
+	for _, k := range skipKinds {
+		if k.kind == kind {
+			i18n.Printf(k.message, path, detail)
+		}
+	}
+}
+
+// suppressed 返回因超过上限没有输出的警告数
+func (l *warnLimiter) suppressed() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := 0
+	for _, count := range l.counts {
+		if l.limit >= 0 && count > l.limit {
+			n += count - l.limit
+		}
+	}
+	return n
+}
+
+// summary 为超过上限的每类警告输出一行汇总
+func (l *warnLimiter) summary() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit < 0 {
+		return
+	}
+	for _, k := range skipKinds {
+		if n := l.counts[k.kind]; n > l.limit {
+			i18n.Printf("[警告] 共跳过 %d 个%s，另外 %d 个未逐条显示\n", n, i18n.T(k.label), n-l.limit)
+		}
+	}
+}
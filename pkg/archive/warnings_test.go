@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// TestWarnLimiter 测试每类警告超过上限后只计数，各类型分别计算
+func TestWarnLimiter(t *testing.T) {
+	l := newWarnLimiter(2)
+	for i := 0; i < 5; i++ {
+		l.warn(SkipFile, "f"+strconv.Itoa(i), os.ErrPermission)
+	}
+	l.warn(SkipDir, "d", os.ErrPermission)
+	if got := l.suppressed(); got != 3 {
+		t.Errorf("suppressed() = %d, want 3", got)
+	}
+
+	unlimited := newWarnLimiter(-1)
+	for i := 0; i < 5; i++ {
+		unlimited.warn(SkipFile, "f"+strconv.Itoa(i), os.ErrPermission)
+	}
+	if got := unlimited.suppressed(); got != 0 {
+		t.Errorf("unlimited suppressed() = %d, want 0", got)
+	}
+
+	if got := newWarnLimiter(0).limit; got != DefaultMaxWarnings {
+		t.Errorf("default limit = %d, want %d", got, DefaultMaxWarnings)
+	}
+}
+
+// TestArchiveLimitsWarnings 测试跳过大量特殊文件时警告被限制，但 Skipped 仍包含完整列表
+func TestArchiveLimitsWarnings(t *testing.T) {
+	// unix 套接字路径长度有限，不使用 t.TempDir 的长路径
+	dir, err := os.MkdirTemp("", "s3b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 5; i++ {
+		l, err := net.Listen("unix", filepath.Join(dir, "s"+strconv.Itoa(i)))
+		if err != nil {
+			t.Skipf("unix sockets not supported: %v", err)
+		}
+		defer l.Close()
+	}
+	os.WriteFile(filepath.Join(dir, "file.txt"), []byte("data"), 0644)
+
+	a, err := NewArchiverWithOptions([]string{dir}, nil, Options{MaxWarnings: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Archive(context.Background(), io.Discard); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+
+	skipped := a.Skipped()
+	if len(skipped) != 5 {
+		t.Fatalf("expected 5 skipped files, got %d", len(skipped))
+	}
+	for _, s := range skipped {
+		if s.Kind != SkipSpecial {
+			t.Errorf("skipped %s kind = %q, want %q", s.Path, s.Kind, SkipSpecial)
+		}
+	}
+	if got := a.SuppressedWarnings(); got != 3 {
+		t.Errorf("SuppressedWarnings() = %d, want 3", got)
+	}
+}
@@ -0,0 +1,33 @@
+package archive
+
+const (
+	// paxXattrPrefix 扩展属性在 PAX 扩展头中的前缀，与 GNU tar、bsdtar 兼容
+	paxXattrPrefix = "SCHILY.xattr."
+	// selinuxXattr SELinux 安全上下文对应的扩展属性
+	selinuxXattr = "security.selinux"
+)
+
+// paxRecords 读取 path 的扩展属性，返回需要写入 PAX 扩展头的记录
+// 未启用 Xattrs 和 SELinux 时返回 nil；读取失败时输出警告，文件仍正常归档
+func (a *Archiver) paxRecords(path string) map[string]string {
+	if !a.xattrs && !a.selinux {
+		return nil
+	}
+	attrs, err := readXattrs(path)
+	if err != nil {
+		a.warnings.warn(warnXattr, path, err)
+		return nil
+	}
+
+	var records map[string]string
+	for name, value := range attrs {
+		if name == selinuxXattr && !a.selinux || name != selinuxXattr && !a.xattrs {
+			continue
+		}
+		if records == nil {
+			records = make(map[string]string)
+		}
+		records[paxXattrPrefix+name] = value
+	}
+	return records
+}
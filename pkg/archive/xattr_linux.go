@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// xattrSupported 当前系统是否支持读取扩展属性
+const xattrSupported = true
+
+// readXattrs 读取 path 的全部扩展属性，不跟随符号链接；文件系统不支持扩展属性时返回空
+func readXattrs(path string) (map[string]string, error) {
+	names, err := xattrGet(func(buf []byte) (int, error) { return unix.Llistxattr(path, buf) })
+	if errors.Is(err, unix.ENOTSUP) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]string)
+	for _, name := range bytes.Split(names, []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		value, err := xattrGet(func(buf []byte) (int, error) { return unix.Lgetxattr(path, string(name), buf) })
+		if errors.Is(err, unix.ENODATA) {
+			// 列出后被删除
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		attrs[string(name)] = string(value)
+	}
+	return attrs, nil
+}
+
+// xattrGet 先查询大小再读取，读取期间属性变大（ERANGE）时重试
+func xattrGet(get func(buf []byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := get(nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size)
+		n, err := get(buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+// writeXattr 设置 path 的扩展属性，不跟随符号链接
+func writeXattr(path, name string, value []byte) error {
+	return unix.Lsetxattr(path, name, value, 0)
+}
@@ -0,0 +1,115 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestArchiveXattrs 测试 Xattrs 将扩展属性写入 SCHILY.xattr.* PAX 记录，只启用 SELinux 时不写入其他属性
+func TestArchiveXattrs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	os.WriteFile(path, []byte("data"), 0644)
+	value := "v\x00binary"
+	if err := unix.Lsetxattr(path, "user.s3backup", []byte(value), 0); err != nil {
+		t.Skipf("user xattrs not supported: %v", err)
+	}
+
+	records := func(opts Options) map[string]string {
+		t.Helper()
+		var buf bytes.Buffer
+		a, err := NewArchiverWithOptions([]string{path}, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Archive(context.Background(), &buf); err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		gz, err := gzip.NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gz)
+		hdr, err := tr.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			t.Fatal(err)
+		}
+		return hdr.PAXRecords
+	}
+
+	if got := records(Options{Xattrs: true})["SCHILY.xattr.user.s3backup"]; got != value {
+		t.Errorf("xattr record = %q, want %q", got, value)
+	}
+	if _, ok := records(Options{SELinux: true})["SCHILY.xattr.user.s3backup"]; ok {
+		t.Error("SELinux-only archive should not record user xattrs")
+	}
+	if recs := records(Options{}); len(recs) != 0 {
+		t.Errorf("expected no PAX records by default, got %v", recs)
+	}
+}
+
+// TestExtractXattrs 测试解压时还原扩展属性，关闭 Xattrs 时不还原
+func TestExtractXattrs(t *testing.T) {
+	src := t.TempDir()
+	path := filepath.Join(src, "file.txt")
+	os.WriteFile(path, []byte("data"), 0644)
+	if err := unix.Lsetxattr(path, "user.s3backup", []byte("value"), 0); err != nil {
+		t.Skipf("user xattrs not supported: %v", err)
+	}
+
+	var buf bytes.Buffer
+	a, _ := NewArchiverWithOptions([]string{path}, nil, Options{Xattrs: true})
+	if err := a.Archive(context.Background(), &buf); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	archived := buf.Bytes()
+
+	for _, restore := range []bool{true, false} {
+		dest := t.TempDir()
+		if _, err := Extract(context.Background(), bytes.NewReader(archived), dest, ExtractOptions{Xattrs: restore}); err != nil {
+			t.Fatalf("Extract() error = %v", err)
+		}
+		value := make([]byte, 16)
+		n, err := unix.Lgetxattr(filepath.Join(dest, path), "user.s3backup", value)
+		if restore && (err != nil || string(value[:n]) != "value") {
+			t.Errorf("restored xattr = %q, %v", value[:n], err)
+		}
+		if !restore && err == nil {
+			t.Error("xattr restored with Xattrs disabled")
+		}
+	}
+}
+
+// TestClassifyXattr 测试扩展属性的分类和是否需要特权
+func TestClassifyXattr(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       int
+		privileged bool
+	}{
+		{"user.comment", xattrGeneral, false},
+		{"trusted.overlay", xattrGeneral, true},
+		{"security.ima", xattrGeneral, true},
+		{"system.posix_acl_access", xattrACL, false},
+		{"system.posix_acl_default", xattrACL, false},
+		{"security.capability", xattrCapability, true},
+		{"security.selinux", xattrSELinux, true},
+	}
+	for _, tt := range tests {
+		kind, privileged := classifyXattr(tt.name)
+		if kind != tt.kind || privileged != tt.privileged {
+			t.Errorf("classifyXattr(%s) = %d, %v, want %d, %v", tt.name, kind, privileged, tt.kind, tt.privileged)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package archive
+
+import "errors"
+
+// xattrSupported 当前系统是否支持读取扩展属性
+const xattrSupported = false
+
+func readXattrs(path string) (map[string]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func writeXattr(path, name string, value []byte) error {
+	return errors.ErrUnsupported
+}
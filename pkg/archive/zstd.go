@@ -0,0 +1,155 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// DefaultDictionarySize 训练的 zstd 字典大小上限，与 zstd --train 的默认值相同
+	DefaultDictionarySize = 112 << 10
+
+	// dictSampleSize 训练字典时每个样本的大小，较大的文件按此大小切分为多个样本
+	// 字典只对每个帧开头的数据有明显作用，更长的样本没有意义
+	dictSampleSize = 64 << 10
+
+	// maxDictTrainBytes 训练字典时读取的样本总量上限
+	maxDictTrainBytes = 128 << 20
+)
+
+// zstdMagic zstd 帧开头的魔数
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// ErrNoSamples 训练字典的路径下没有可读取的普通文件
+var ErrNoSamples = errors.New("no samples to train the dictionary")
+
+// NewZstdWriter 返回压缩写入 w 的 zstd 编码器，dictionary 不为空时使用该字典（帧头中记录字典 ID）
+func NewZstdWriter(w io.Writer, dictionary []byte) (*zstd.Encoder, error) {
+	opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+	if len(dictionary) > 0 {
+		opts = append(opts, zstd.WithEncoderDict(dictionary))
+	}
+	enc, err := zstd.NewWriter(w, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+	}
+	return enc, nil
+}
+
+// DictionaryID 解析 zstd 字典并返回其 ID
+func DictionaryID(dictionary []byte) (uint32, error) {
+	d, err := zstd.InspectDictionary(dictionary)
+	if err != nil {
+		return 0, fmt.Errorf("invalid zstd dictionary: %w", err)
+	}
+	return d.ID(), nil
+}
+
+// TrainDictionary 读取 paths 下的普通文件作为样本训练 zstd 字典，字典大小不超过 maxSize
+// 适合大量相似的小备份（配置文件、数据库导出等）：每次备份单独压缩时重复的内容可以从字典中引用
+func TrainDictionary(paths []string, maxSize int) ([]byte, error) {
+	var samples [][]byte
+	var total int64
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() || total >= maxDictTrainBytes {
+				return nil
+			}
+			data, err := readSamples(path, maxDictTrainBytes-total)
+			if err != nil {
+				return err
+			}
+			for _, s := range data {
+				total += int64(len(s))
+			}
+			samples = append(samples, data...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read samples from %s: %w", root, err)
+		}
+	}
+	if len(samples) == 0 {
+		return nil, ErrNoSamples
+	}
+
+	dictionary, err := dict.BuildZstdDict(samples, dict.Options{MaxDictSize: maxSize, HashBytes: 6})
+	if err != nil {
+		return nil, fmt.Errorf("failed to train dictionary: %w", err)
+	}
+	return dictionary, nil
+}
+
+// readSamples 将文件按 dictSampleSize 切分为样本，最多读取 limit 字节
+func readSamples(path string, limit int64) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples [][]byte
+	r := io.LimitReader(f, limit)
+	for {
+		buf := make([]byte, dictSampleSize)
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			samples = append(samples, buf[:n])
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// decompressReader 按魔数识别 gzip 或 zstd 压缩的归档流，返回解压后的 reader
+// zstd 帧头中记录了字典 ID 时通过 dictionary 获取字典
+func decompressReader(r io.Reader, dictionary func(id uint32) ([]byte, error)) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	if !bytes.Equal(magic, zstdMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		return gz, nil
+	}
+
+	var header zstd.Header
+	peek, _ := br.Peek(zstd.HeaderMaxSize)
+	if err := header.Decode(peek); err != nil {
+		return nil, fmt.Errorf("failed to open zstd stream: %w", err)
+	}
+	opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	if header.DictionaryID != 0 {
+		if dictionary == nil {
+			return nil, fmt.Errorf("zstd stream requires dictionary %d", header.DictionaryID)
+		}
+		d, err := dictionary(header.DictionaryID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load zstd dictionary %d: %w", header.DictionaryID, err)
+		}
+		opts = append(opts, zstd.WithDecoderDicts(d))
+	}
+	dec, err := zstd.NewReader(br, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zstd stream: %w", err)
+	}
+	return dec.IOReadCloser(), nil
+}
@@ -0,0 +1,114 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSimilarFiles 写入 n 个结构相同、取值不同的配置文件，作为训练字典的样本
+func writeSimilarFiles(t *testing.T, dir string, n int) {
+	t.Helper()
+	for i := range n {
+		var b strings.Builder
+		for j := range 40 {
+			fmt.Fprintf(&b, "server.instance_%d.listen_address = 10.0.%d.%d:%d\n", j, i%256, j, 8000+i*j)
+			fmt.Fprintf(&b, "server.instance_%d.max_connections = %d\n", j, 100+i+j)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("app-%03d.conf", i)), []byte(b.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestZstdDictionaryRoundTrip 测试使用训练的字典归档为 tar.zst 后按帧头中的字典 ID 解压
+func TestZstdDictionaryRoundTrip(t *testing.T) {
+	samples := t.TempDir()
+	writeSimilarFiles(t, samples, 200)
+	dictionary, err := TrainDictionary([]string{samples}, 16<<10)
+	if err != nil {
+		t.Fatalf("TrainDictionary() error = %v", err)
+	}
+	id, err := DictionaryID(dictionary)
+	if err != nil || id == 0 {
+		t.Fatalf("DictionaryID() = %d, %v", id, err)
+	}
+
+	src := t.TempDir()
+	writeSimilarFiles(t, src, 1)
+	a, err := NewArchiverWithOptions([]string{src}, nil, Options{Zstd: true, ZstdDictionary: dictionary})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := a.Archive(context.Background(), &buf); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), zstdMagic) {
+		t.Fatal("archive does not start with the zstd magic")
+	}
+	archived := buf.Bytes()
+
+	var requested uint32
+	dest := t.TempDir()
+	_, err = Extract(context.Background(), bytes.NewReader(archived), dest, ExtractOptions{
+		Dictionary: func(got uint32) ([]byte, error) {
+			requested = got
+			return dictionary, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if requested != id {
+		t.Errorf("requested dictionary %d, want %d", requested, id)
+	}
+	want, _ := os.ReadFile(filepath.Join(src, "app-000.conf"))
+	got, err := os.ReadFile(filepath.Join(dest, src, "app-000.conf"))
+	if err != nil || !bytes.Equal(got, want) {
+		t.Errorf("restored file differs: %v", err)
+	}
+
+	// 没有字典时无法解压
+	if _, err := Extract(context.Background(), bytes.NewReader(archived), t.TempDir(), ExtractOptions{}); err == nil {
+		t.Error("Extract() without dictionary succeeded")
+	}
+	missing := errors.New("missing")
+	_, err = Extract(context.Background(), bytes.NewReader(archived), t.TempDir(), ExtractOptions{
+		Dictionary: func(uint32) ([]byte, error) { return nil, missing },
+	})
+	if !errors.Is(err, missing) {
+		t.Errorf("Extract() error = %v, want %v", err, missing)
+	}
+}
+
+// TestZstdWithoutDictionary 测试不使用字典的 tar.zst 不需要字典即可解压
+func TestZstdWithoutDictionary(t *testing.T) {
+	src := t.TempDir()
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("hello"), 0600)
+	a, _ := NewArchiverWithOptions([]string{src}, nil, Options{Zstd: true})
+	var buf bytes.Buffer
+	if err := a.Archive(context.Background(), &buf); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	dest := t.TempDir()
+	stats, err := Extract(context.Background(), &buf, dest, ExtractOptions{})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if stats.Files != 1 || stats.Bytes != 5 {
+		t.Errorf("stats = %+v, want 1 file of 5 bytes", stats)
+	}
+}
+
+// TestTrainDictionaryNoSamples 测试路径下没有普通文件时返回 ErrNoSamples
+func TestTrainDictionaryNoSamples(t *testing.T) {
+	if _, err := TrainDictionary([]string{t.TempDir()}, DefaultDictionarySize); !errors.Is(err, ErrNoSamples) {
+		t.Errorf("TrainDictionary() error = %v, want ErrNoSamples", err)
+	}
+}
@@ -4,17 +4,34 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/joho/godotenv"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
+const (
+	// MinChunkSize S3 Multipart Upload 最小分块大小
+	MinChunkSize = 5 * 1024 * 1024
+	// MaxChunkSize S3 Multipart Upload 最大分块大小
+	MaxChunkSize = 5 * 1024 * 1024 * 1024
+	// MinDownloadChunkSize 还原时每个下载分段的最小大小，更小的分段请求开销过大
+	MinDownloadChunkSize = 1024 * 1024
+)
+
 // Config 配置结构
 type Config struct {
 	Storage    StorageConfig    `yaml:"storage"`
 	Encryption EncryptionConfig `yaml:"encryption"`
 	Backup     BackupConfig     `yaml:"backup"`
+	Source     SourceConfig     `yaml:"source"`
+	State      StateConfig      `yaml:"state"`
+	Restore    RestoreConfig    `yaml:"restore"`
 }
 
 // StorageConfig 存储配置
@@ -26,6 +43,24 @@ type StorageConfig struct {
 	AccessKey    string `yaml:"access_key"`
 	SecretKey    string `yaml:"secret_key"`
 	StorageClass string `yaml:"storage_class"` // 存储类型
+	PathStyle    bool   `yaml:"path_style"`    // 使用路径风格访问（MinIO 等自建存储）
+	ACL          string `yaml:"acl"`           // 上传对象的预设 ACL，如写入其他账号的存储桶时使用 bucket-owner-full-control
+
+	// RestoreAccessKey、RestoreSecretKey 只读凭证，restore、rehearse、verify、thaw 和 freshness 优先使用
+	// 只配置只读凭证时拒绝执行写入存储的命令，还原主机上不需要保存可以写入或删除备份的凭证
+	RestoreAccessKey string `yaml:"restore_access_key"`
+	RestoreSecretKey string `yaml:"restore_secret_key"`
+
+	// WriteOnly 声明 access_key/secret_key 是只写凭证（只追加的备份，防止勒索软件删除备份）
+	// 开启后不再发起列出、读取和删除请求，prune 等需要这些权限的命令直接报错
+	WriteOnly bool `yaml:"write_only"`
+}
+
+// cannedACLs 各提供商 S3 兼容接口支持的预设 ACL，七牛云不支持对象 ACL
+var cannedACLs = map[string][]string{
+	"aws": {"private", "public-read", "public-read-write", "authenticated-read",
+		"aws-exec-read", "bucket-owner-read", "bucket-owner-full-control"},
+	"aliyun": {"private", "public-read", "public-read-write"},
 }
 
 // EncryptionConfig 加密配置
@@ -33,25 +68,179 @@ type EncryptionConfig struct {
 	Enabled  bool   `yaml:"enabled"`
 	Password string `yaml:"password"` // 用于派生密钥
 	KeyFile  string `yaml:"key_file"` // 或直接使用密钥文件
+
+	// EncryptState 使用备份密钥加密本地的续传状态文件（其中包含存储桶、端点和 UploadID）
+	EncryptState bool `yaml:"encrypt_state"`
+
+	// Padding 在加密前填充备份，使存储桶中看到的大小只反映所在的档位：pow2 填充到 2 的幂，bucket 填充到 padding_bucket 的整数倍
+	Padding       string `yaml:"padding"`        // none, pow2, bucket
+	PaddingBucket int64  `yaml:"padding_bucket"` // padding 为 bucket 时的档位大小（字节）
 }
 
 // BackupConfig 备份配置
 type BackupConfig struct {
 	Includes    []string `yaml:"includes"`    // 包含路径
 	Excludes    []string `yaml:"excludes"`    // 排除模式
-	Compression string   `yaml:"compression"` // gzip, none
+	Compression string   `yaml:"compression"` // gzip, zstd
 	ChunkSize   int64    `yaml:"chunk_size"`  // 分块大小，默认 5MB
 	Concurrency int      `yaml:"concurrency"` // 并发上传数
+
+	// SmartCompression 已压缩的文件类型（按扩展名）在 gzip 流中不压缩存储，其余文件正常压缩
+	SmartCompression bool     `yaml:"smart_compression"`
+	StoreExtensions  []string `yaml:"store_extensions"` // 智能压缩时不压缩的扩展名，为空时使用内置列表
+
+	// ZstdDictionary compression 为 zstd 时使用的字典文件（由 train-dict 生成），大量相似的小备份可以明显变小
+	ZstdDictionary string `yaml:"zstd_dictionary"`
+
+	// ParallelRoots 同时归档的包含路径数，各路径的输出按配置顺序拼接，0 或 1 表示按顺序归档
+	ParallelRoots int `yaml:"parallel_roots"`
+
+	// MaxWarnings 每类跳过警告（无法访问、特殊文件等）逐条输出的数量，超出的只汇总，0 时为 20，-1 表示不限制
+	MaxWarnings int `yaml:"max_warnings"`
+
+	// Strict 遇到无法读取的文件、特殊文件或目标不存在的符号链接时备份失败，而不是跳过
+	Strict bool `yaml:"strict"`
+
+	// IncludeDevices 归档字符设备、块设备和命名管道（记录设备号，不读取内容），用于整机备份
+	IncludeDevices bool `yaml:"include_devices"`
+
+	// Xattrs 记录扩展属性（如 security.capability），SELinux 记录 SELinux 安全上下文，仅支持 Linux
+	Xattrs  bool `yaml:"xattrs"`
+	SELinux bool `yaml:"selinux"`
+
+	AutoChunkSize bool  `yaml:"auto_chunk_size"` // 根据吞吐量自动调整分块大小
+	ChunkSizeMin  int64 `yaml:"chunk_size_min"`  // 自动调整的下限，默认 5MB
+	ChunkSizeMax  int64 `yaml:"chunk_size_max"`  // 自动调整的上限，默认 64MB
+
+	AutoConcurrency bool `yaml:"auto_concurrency"` // 根据吞吐量和限流自动调整并发数
+	ConcurrencyMax  int  `yaml:"concurrency_max"`  // 自动调整的并发上限，默认 16
+
+	// ReadAhead 已读入内存、等待上传的分块数上限，0 表示 2 × concurrency
+	// 内存占用约为 (concurrency + read_ahead) × chunk_size
+	ReadAhead int `yaml:"read_ahead"`
+
+	// ETagRetries 分块 ETag 与本地 MD5 不一致时重新上传的次数，0 时为 3，-1 表示不校验（SSE-KMS 等服务端加密时 ETag 不是 MD5）
+	ETagRetries int `yaml:"etag_retries"`
+
+	StorageClassRules []StorageClassRule `yaml:"storage_class_rules"` // 按路径指定存储类型，不同类型的路径分别上传
+
+	MaxTotalSize       int64  `yaml:"max_total_size"`        // 待备份数据（压缩前）的大小上限（字节），0 表示不限制
+	MaxTotalSizeAction string `yaml:"max_total_size_action"` // 超过上限时的处理方式: abort, warn
+
+	Paths map[string][]string `yaml:"paths"` // 命名的包含路径组，通过 backup --only 选择
+
+	AllowNoMatch bool `yaml:"allow_no_match"` // 包含路径中的通配符没有匹配时跳过而不是报错
+	IgnoreCase   bool `yaml:"ignore_case"`    // 排除模式不区分大小写
+	Report       bool `yaml:"report"`         // 上传备份后同时上传 <backup>.report.json
+
+	// VerifyUpload 上传完成后下载备份的首尾数据段与本地计算的 SHA-256 比较，并回读备份报告，不一致时备份失败
+	VerifyUpload bool `yaml:"verify_upload"`
+
+	SignKey string `yaml:"sign_key"` // Ed25519 签名私钥（PEM），上传后对备份签名并上传 <backup>.sig
+
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"` // 上传期间输出心跳日志的间隔（如 5m），0 表示不输出
+
+	// MaxAge 最近一次成功的备份允许的最长时间（RPO，如每日备份设为 26h），由 freshness 命令检查
+	MaxAge time.Duration `yaml:"max_age"`
+
+	// Idempotent 按 profile 和日期生成幂等键，当天已有成功的备份时跳过，避免重试的定时任务重复上传
+	Idempotent bool `yaml:"idempotent"`
+
+	// Overwrite 允许覆盖存储中已存在的同名备份，默认上传前检查并在完成上传时条件写入，已存在时失败
+	Overwrite bool `yaml:"overwrite"`
+
+	// ExpireAfter 备份的保留时长（如 90d、12w、720h），到期时间记录在对象元数据中，由 prune --honor-expiry 删除
+	ExpireAfter string `yaml:"expire_after"`
+
+	// SpoolDir 设置后先将备份完整写入该目录中的临时文件再上传，上传中断时可从磁盘续传，不需要重新归档
+	SpoolDir string `yaml:"spool_dir"`
+}
+
+// StorageClassRule 路径存储类型规则，Path 及其下的文件使用 StorageClass
+// 多条规则嵌套时以最深的路径为准
+type StorageClassRule struct {
+	Path         string `yaml:"path"`
+	StorageClass string `yaml:"storage_class"`
+}
+
+// SourceConfig 数据库导出源配置，设置后 backup 备份数据库导出数据而不是文件
+type SourceConfig struct {
+	URL      string `yaml:"url"`      // postgres://、mysql://、mongodb:// 连接地址
+	Password string `yaml:"password"` // 数据库密码（优先于地址中的密码）
+}
+
+// StateConfig 断点续传状态配置
+type StateConfig struct {
+	Dir      string `yaml:"dir"`       // 状态文件目录，默认 ~/.s3backup/state/<机器标识>
+	NoResume bool   `yaml:"no_resume"` // backup 不保存续传状态，中断后只能重新备份
+}
+
+// RestoreConfig 还原配置（restore、rehearse）
+type RestoreConfig struct {
+	// DownloadConcurrency 并发下载的分段数，1 表示用单个请求顺序下载
+	// 内存占用约为 (download_concurrency + 1) × download_chunk_size
+	DownloadConcurrency int   `yaml:"download_concurrency"`
+	DownloadChunkSize   int64 `yaml:"download_chunk_size"` // 每个下载分段的大小，默认 8MB
+
+	// DecompressWorkers 解压时并行写入普通文件的 worker 数，1 表示按顺序写入
+	DecompressWorkers int `yaml:"decompress_workers"`
 }
 
 // LoadConfig 加载配置
 func LoadConfig(configPath, envPath string) (*Config, error) {
+	return LoadConfigWithFlags(configPath, envPath, nil)
+}
+
+// LoadConfigWithFlags 加载配置，并使用已设置的命令行 flag 覆盖
+// 优先级：flag > 环境变量 > .env 文件 > 配置文件 > 默认值
+func LoadConfigWithFlags(configPath, envPath string, flags *pflag.FlagSet) (*Config, error) {
 	// 加载 .env 文件
 	if err := loadEnvFile(envPath); err != nil {
 		return nil, fmt.Errorf("failed to load env file: %w", err)
 	}
 
-	// 设置 viper
+	// 读取配置文件
+	v, err := readConfigFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查未知键（拼写错误等），避免被静默忽略
+	if err := validateKeys(v.AllKeys()); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", v.ConfigFileUsed(), err)
+	}
+
+	// 展开配置文件中的 ${VAR} 环境变量引用
+	if err := expandConfigFile(v); err != nil {
+		return nil, err
+	}
+
+	// 绑定环境变量和命令行 flag
+	if err := bindSources(v, flags); err != nil {
+		return nil, err
+	}
+
+	// 解析配置（严格模式，使用 yaml tag 作为键名）
+	var cfg Config
+	if err := v.UnmarshalExact(&cfg, func(dc *mapstructure.DecoderConfig) {
+		dc.TagName = "yaml"
+	}); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	// 解密加密存储的凭证
+	if err := decryptSecrets(&cfg); err != nil {
+		return nil, err
+	}
+
+	// 填充默认值
+	setDefaults(&cfg)
+
+	return &cfg, nil
+}
+
+// readConfigFile 按查找顺序读取配置文件，配置文件不存在时返回空配置
+func readConfigFile(configPath string) (*viper.Viper, error) {
 	v := viper.New()
 	v.SetConfigType("yaml")
 
@@ -77,21 +266,16 @@ func LoadConfig(configPath, envPath string) (*Config, error) {
 		}
 	}
 
-	// 绑定环境变量
-	v.SetEnvPrefix("S3BACKUP")
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	v.AutomaticEnv()
+	return v, nil
+}
 
-	// 解析配置
-	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+// FindConfigFile 返回实际使用的配置文件路径，未找到时返回空字符串
+func FindConfigFile(configPath string) (string, error) {
+	v, err := readConfigFile(configPath)
+	if err != nil {
+		return "", err
 	}
-
-	// 填充默认值
-	setDefaults(&cfg)
-
-	return &cfg, nil
+	return v.ConfigFileUsed(), nil
 }
 
 // loadEnvFile 加载 .env 文件
@@ -142,6 +326,29 @@ func setDefaults(cfg *Config) {
 	if cfg.Backup.Concurrency == 0 {
 		cfg.Backup.Concurrency = 4
 	}
+	if cfg.Backup.ChunkSizeMin == 0 {
+		cfg.Backup.ChunkSizeMin = MinChunkSize
+	}
+	if cfg.Backup.ChunkSizeMax == 0 {
+		cfg.Backup.ChunkSizeMax = 64 * 1024 * 1024 // 64MB
+	}
+	if cfg.Backup.ConcurrencyMax == 0 {
+		cfg.Backup.ConcurrencyMax = 16
+	}
+	if cfg.Backup.MaxTotalSizeAction == "" {
+		cfg.Backup.MaxTotalSizeAction = "abort"
+	}
+
+	// 还原配置默认值
+	if cfg.Restore.DownloadConcurrency == 0 {
+		cfg.Restore.DownloadConcurrency = 4
+	}
+	if cfg.Restore.DownloadChunkSize == 0 {
+		cfg.Restore.DownloadChunkSize = 8 * 1024 * 1024 // 8MB
+	}
+	if cfg.Restore.DecompressWorkers == 0 {
+		cfg.Restore.DecompressWorkers = 1
+	}
 }
 
 // GetAccessKey 获取 Access Key（优先级：配置 > 环境变量）
@@ -160,6 +367,27 @@ func (c *Config) GetSecretKey() string {
 	return os.Getenv("S3BACKUP_SECRET_KEY")
 }
 
+// GetRestoreAccessKey 获取只读 Access Key（优先级：配置 > 环境变量）
+func (c *Config) GetRestoreAccessKey() string {
+	if c.Storage.RestoreAccessKey != "" {
+		return c.Storage.RestoreAccessKey
+	}
+	return os.Getenv("S3BACKUP_RESTORE_ACCESS_KEY")
+}
+
+// GetRestoreSecretKey 获取只读 Secret Key（优先级：配置 > 环境变量）
+func (c *Config) GetRestoreSecretKey() string {
+	if c.Storage.RestoreSecretKey != "" {
+		return c.Storage.RestoreSecretKey
+	}
+	return os.Getenv("S3BACKUP_RESTORE_SECRET_KEY")
+}
+
+// ReadOnly 返回是否只配置了只读凭证，此时不能执行写入存储的命令
+func (c *Config) ReadOnly() bool {
+	return c.GetAccessKey() == "" && c.GetSecretKey() == "" && c.GetRestoreAccessKey() != ""
+}
+
 // GetPassword 获取加密密码（优先级：配置 > 环境变量）
 func (c *Config) GetPassword() string {
 	if c.Encryption.Password != "" {
@@ -168,6 +396,31 @@ func (c *Config) GetPassword() string {
 	return os.Getenv("S3BACKUP_ENCRYPT_PASSWORD")
 }
 
+// GetSourcePassword 获取数据库密码（优先级：配置 > 环境变量 > 连接地址）
+// 返回空字符串时由 dbdump 使用连接地址中的密码
+func (c *Config) GetSourcePassword() string {
+	if c.Source.Password != "" {
+		return c.Source.Password
+	}
+	return os.Getenv("S3BACKUP_SOURCE_PASSWORD")
+}
+
+// ParseDuration 解析时长，在 time.ParseDuration 的基础上支持 d（天）和 w（周）后缀，如 90d、2w
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if n := len(s); n > 1 {
+		if unit, ok := units[s[n-1]]; ok {
+			count, err := strconv.Atoi(s[:n-1])
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
 // Validate 验证配置
 func (c *Config) Validate() error {
 	// 验证存储提供商
@@ -180,20 +433,122 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("storage bucket is required")
 	}
 
-	accessKey := c.GetAccessKey()
-	if accessKey == "" {
-		return fmt.Errorf("storage access_key is required")
+	if c.Storage.ACL != "" && !slices.Contains(cannedACLs[provider], c.Storage.ACL) {
+		if len(cannedACLs[provider]) == 0 {
+			return fmt.Errorf("storage acl is not supported by %s", provider)
+		}
+		return fmt.Errorf("storage acl must be one of: %s (got: %s)", strings.Join(cannedACLs[provider], ", "), c.Storage.ACL)
 	}
 
+	// 只配置只读凭证时可以还原，写入存储的命令在创建适配器时拒绝执行
+	accessKey := c.GetAccessKey()
 	secretKey := c.GetSecretKey()
-	if secretKey == "" {
+	restoreAccessKey := c.GetRestoreAccessKey()
+	if (restoreAccessKey == "") != (c.GetRestoreSecretKey() == "") {
+		return fmt.Errorf("storage restore_access_key and restore_secret_key must be set together")
+	}
+	if accessKey == "" && (secretKey != "" || restoreAccessKey == "") {
+		return fmt.Errorf("storage access_key is required")
+	}
+	if secretKey == "" && (accessKey != "" || restoreAccessKey == "") {
 		return fmt.Errorf("storage secret_key is required")
 	}
 
-	// 验证分块大小（至少 5MB）
-	if c.Backup.ChunkSize < 5*1024*1024 {
+	// 验证分块大小（5MB ~ 5GB）
+	if c.Backup.ChunkSize < MinChunkSize {
 		return fmt.Errorf("backup chunk_size must be at least 5MB (got: %d bytes)", c.Backup.ChunkSize)
 	}
+	if c.Backup.ChunkSize > MaxChunkSize {
+		return fmt.Errorf("backup chunk_size must be at most 5GB (got: %d bytes)", c.Backup.ChunkSize)
+	}
+
+	// 自动调整分块大小的范围
+	if c.Backup.AutoChunkSize {
+		if c.Backup.ChunkSizeMin < MinChunkSize {
+			return fmt.Errorf("backup chunk_size_min must be at least 5MB (got: %d bytes)", c.Backup.ChunkSizeMin)
+		}
+		if c.Backup.ChunkSizeMax > MaxChunkSize {
+			return fmt.Errorf("backup chunk_size_max must be at most 5GB (got: %d bytes)", c.Backup.ChunkSizeMax)
+		}
+		if c.Backup.ChunkSizeMin > c.Backup.ChunkSizeMax {
+			return fmt.Errorf("backup chunk_size_min (%d) must not exceed chunk_size_max (%d)", c.Backup.ChunkSizeMin, c.Backup.ChunkSizeMax)
+		}
+	}
+
+	if c.Backup.Concurrency < 0 {
+		return fmt.Errorf("backup concurrency must not be negative (got: %d)", c.Backup.Concurrency)
+	}
+	if c.Backup.MaxAge < 0 {
+		return fmt.Errorf("backup max_age must not be negative (got: %s)", c.Backup.MaxAge)
+	}
+	if c.Backup.ReadAhead < 0 {
+		return fmt.Errorf("backup read_ahead must not be negative (got: %d)", c.Backup.ReadAhead)
+	}
+	if c.Backup.ETagRetries < -1 {
+		return fmt.Errorf("backup etag_retries must be -1 or greater (got: %d)", c.Backup.ETagRetries)
+	}
+	if c.Restore.DownloadConcurrency < 0 {
+		return fmt.Errorf("restore download_concurrency must not be negative (got: %d)", c.Restore.DownloadConcurrency)
+	}
+	if c.Restore.DownloadChunkSize != 0 && c.Restore.DownloadChunkSize < MinDownloadChunkSize {
+		return fmt.Errorf("restore download_chunk_size must be at least 1MB (got: %d bytes)", c.Restore.DownloadChunkSize)
+	}
+	if c.Restore.DecompressWorkers < 0 {
+		return fmt.Errorf("restore decompress_workers must not be negative (got: %d)", c.Restore.DecompressWorkers)
+	}
+	if c.Backup.ParallelRoots < 0 {
+		return fmt.Errorf("backup parallel_roots must not be negative (got: %d)", c.Backup.ParallelRoots)
+	}
+	if c.Backup.AutoConcurrency && c.Backup.ConcurrencyMax < 1 {
+		return fmt.Errorf("backup concurrency_max must be at least 1 (got: %d)", c.Backup.ConcurrencyMax)
+	}
+
+	for name, paths := range c.Backup.Paths {
+		if len(paths) == 0 {
+			return fmt.Errorf("backup paths.%s must list at least one path", name)
+		}
+	}
+
+	for i, rule := range c.Backup.StorageClassRules {
+		if rule.Path == "" || rule.StorageClass == "" {
+			return fmt.Errorf("backup storage_class_rules[%d] requires both path and storage_class", i)
+		}
+	}
+
+	if c.Backup.ExpireAfter != "" {
+		if d, err := ParseDuration(c.Backup.ExpireAfter); err != nil || d <= 0 {
+			return fmt.Errorf("backup expire_after must be a positive duration such as 90d, 12w or 720h (got: %s)", c.Backup.ExpireAfter)
+		}
+	}
+
+	if c.Backup.MaxTotalSize < 0 {
+		return fmt.Errorf("backup max_total_size must not be negative (got: %d)", c.Backup.MaxTotalSize)
+	}
+	switch c.Backup.MaxTotalSizeAction {
+	case "", "abort", "warn":
+	default:
+		return fmt.Errorf("backup max_total_size_action must be one of: abort, warn (got: %s)", c.Backup.MaxTotalSizeAction)
+	}
+
+	switch c.Backup.Compression {
+	case "", "gzip", "zstd":
+	case "none":
+		// 不压缩的 .tar 备份没有实现，已压缩的文件类型可以用 smart_compression 跳过压缩
+		return fmt.Errorf("backup compression none is not supported (did you mean smart_compression: true?)")
+	default:
+		if suggestion := suggestKey(c.Backup.Compression, []string{"gzip", "zstd"}); suggestion != "" {
+			return fmt.Errorf("backup compression must be one of: gzip, zstd (got: %s, did you mean %q?)", c.Backup.Compression, suggestion)
+		}
+		return fmt.Errorf("backup compression must be one of: gzip, zstd (got: %s)", c.Backup.Compression)
+	}
+	if c.Backup.ZstdDictionary != "" && c.Backup.Compression != "zstd" {
+		return fmt.Errorf("backup zstd_dictionary requires compression zstd")
+	}
+
+	// 从磁盘续传依赖续传状态
+	if c.Backup.SpoolDir != "" && c.State.NoResume {
+		return fmt.Errorf("backup spool_dir cannot be used with state no_resume")
+	}
 
 	if c.Encryption.Enabled {
 		password := c.GetPassword()
@@ -201,6 +556,26 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("encryption password or key_file is required when encryption is enabled")
 		}
 	}
+	if c.Encryption.EncryptState && c.GetPassword() == "" && c.Encryption.KeyFile == "" {
+		return fmt.Errorf("encryption password or key_file is required when encrypt_state is enabled")
+	}
+
+	switch c.Encryption.Padding {
+	case "", "none":
+	case "pow2", "bucket":
+		// 未加密时大小本来就可见，填充没有意义
+		if !c.Encryption.Enabled {
+			return fmt.Errorf("encryption padding requires encryption to be enabled")
+		}
+	default:
+		return fmt.Errorf("encryption padding must be one of: none, pow2, bucket (got: %s)", c.Encryption.Padding)
+	}
+	if c.Encryption.Padding == "bucket" && c.Encryption.PaddingBucket <= 0 {
+		return fmt.Errorf("encryption padding_bucket must be positive when padding is bucket")
+	}
+	if c.Encryption.PaddingBucket < 0 {
+		return fmt.Errorf("encryption padding_bucket cannot be negative")
+	}
 
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestSetDefaults 测试默认值设置
@@ -164,6 +165,52 @@ func TestValidateAccessKey(t *testing.T) {
 	}
 }
 
+// TestValidateRestoreKeys 测试只读凭证：只配置只读凭证时通过验证，但 ReadOnly 返回 true
+func TestValidateRestoreKeys(t *testing.T) {
+	tests := []struct {
+		name                 string
+		accessKey, secretKey string
+		restoreAccess        string
+		restoreSecret        string
+		wantErr              string
+		wantReadOnly         bool
+	}{
+		{"restore keys only", "", "", "ro-key", "ro-secret", "", true},
+		{"both key pairs", "rw-key", "rw-secret", "ro-key", "ro-secret", "", false},
+		{"restore access key without secret", "", "", "ro-key", "", "restore_access_key", false},
+		{"restore keys and half of the write pair", "rw-key", "", "ro-key", "ro-secret", "secret_key", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Storage: StorageConfig{
+					Provider:         "aws",
+					Bucket:           "test-bucket",
+					AccessKey:        tt.accessKey,
+					SecretKey:        tt.secretKey,
+					RestoreAccessKey: tt.restoreAccess,
+					RestoreSecretKey: tt.restoreSecret,
+				},
+				Backup: BackupConfig{
+					ChunkSize: 5 * 1024 * 1024,
+				},
+			}
+
+			err := cfg.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+			if err == nil && cfg.ReadOnly() != tt.wantReadOnly {
+				t.Errorf("ReadOnly() = %v, want %v", cfg.ReadOnly(), tt.wantReadOnly)
+			}
+		})
+	}
+}
+
 // TestValidateSecretKey 测试 secret key 验证
 func TestValidateSecretKey(t *testing.T) {
 	tests := []struct {
@@ -497,6 +544,75 @@ func TestValidateStorageClass(t *testing.T) {
 	}
 }
 
+// TestValidateACL 测试各提供商支持的预设 ACL
+func TestValidateACL(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		acl      string
+		wantErr  bool
+	}{
+		{"empty acl", "qiniu", "", false},
+		{"AWS bucket-owner-full-control", "aws", "bucket-owner-full-control", false},
+		{"AWS invalid", "aws", "owner-only", true},
+		{"Aliyun public-read", "aliyun", "public-read", false},
+		{"Aliyun bucket-owner-full-control", "aliyun", "bucket-owner-full-control", true},
+		{"Qiniu private", "qiniu", "private", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Storage: StorageConfig{
+					Provider:  tt.provider,
+					Bucket:    "test-bucket",
+					AccessKey: "test-key",
+					SecretKey: "test-secret",
+					ACL:       tt.acl,
+				},
+				Backup: BackupConfig{
+					ChunkSize: 5 * 1024 * 1024,
+				},
+			}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestParseDuration 测试带天和周后缀的时长解析
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90d", 90 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"36h", 36 * time.Hour, false},
+		{"1.5d", 0, true},
+		{"d", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	cfg := &Config{
+		Storage: StorageConfig{Provider: "aws", Bucket: "b", AccessKey: "k", SecretKey: "s"},
+		Backup:  BackupConfig{ChunkSize: MinChunkSize, ExpireAfter: "0d"},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject a zero expire_after")
+	}
+}
+
 // TestValidateConcurrency 测试并发数验证
 func TestValidateConcurrency(t *testing.T) {
 	tests := []struct {
@@ -506,8 +622,8 @@ func TestValidateConcurrency(t *testing.T) {
 	}{
 		{"valid concurrency", 4, false},
 		{"high concurrency", 100, false},
-		{"zero concurrency", 0, false},      // 默认值会生效
-		{"negative concurrency", -1, false}, // 不会验证负数
+		{"zero concurrency", 0, false}, // 默认值会生效
+		{"negative concurrency", -1, true},
 	}
 
 	for _, tt := range tests {
@@ -553,7 +669,197 @@ func TestValidateEdgeCases(t *testing.T) {
 			modify: func(c *Config) {
 				c.Backup.ChunkSize = 10 * 1024 * 1024 * 1024 // 10GB
 			},
-			wantErr: false, // 当前不限制最大值
+			wantErr: true, // 超过 S3 单个分块 5GB 上限
+			errMsg:  "chunk_size",
+		},
+		{
+			name: "exact maximum chunk size",
+			modify: func(c *Config) {
+				c.Backup.ChunkSize = 5 * 1024 * 1024 * 1024 // 精确 5GB
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown compression",
+			modify: func(c *Config) {
+				c.Backup.Compression = "zip"
+			},
+			wantErr: true,
+			errMsg:  `did you mean "gzip"?`,
+		},
+		{
+			name: "compression none not supported",
+			modify: func(c *Config) {
+				c.Backup.Compression = "none"
+			},
+			wantErr: true,
+			errMsg:  "smart_compression",
+		},
+		{
+			name: "zstd compression with dictionary",
+			modify: func(c *Config) {
+				c.Backup.Compression = "zstd"
+				c.Backup.ZstdDictionary = "/etc/s3backup/configs.zdict"
+			},
+			wantErr: false,
+		},
+		{
+			name: "dictionary without zstd",
+			modify: func(c *Config) {
+				c.Backup.ZstdDictionary = "/etc/s3backup/configs.zdict"
+			},
+			wantErr: true,
+			errMsg:  "zstd_dictionary",
+		},
+		{
+			name: "pow2 padding",
+			modify: func(c *Config) {
+				c.Encryption = EncryptionConfig{Enabled: true, Password: "secret", Padding: "pow2"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "padding without encryption",
+			modify: func(c *Config) {
+				c.Encryption.Padding = "pow2"
+			},
+			wantErr: true,
+			errMsg:  "padding",
+		},
+		{
+			name: "bucket padding without size",
+			modify: func(c *Config) {
+				c.Encryption = EncryptionConfig{Enabled: true, Password: "secret", Padding: "bucket"}
+			},
+			wantErr: true,
+			errMsg:  "padding_bucket",
+		},
+		{
+			name: "unknown padding",
+			modify: func(c *Config) {
+				c.Encryption = EncryptionConfig{Enabled: true, Password: "secret", Padding: "random"}
+			},
+			wantErr: true,
+			errMsg:  "padding",
+		},
+		{
+			name: "spool dir without resume state",
+			modify: func(c *Config) {
+				c.Backup.SpoolDir = "/var/spool/s3backup"
+				c.State.NoResume = true
+			},
+			wantErr: true,
+			errMsg:  "spool_dir",
+		},
+		{
+			name: "negative parallel roots",
+			modify: func(c *Config) {
+				c.Backup.ParallelRoots = -1
+			},
+			wantErr: true,
+			errMsg:  "parallel_roots",
+		},
+		{
+			name: "negative read ahead",
+			modify: func(c *Config) {
+				c.Backup.ReadAhead = -1
+			},
+			wantErr: true,
+			errMsg:  "read_ahead",
+		},
+		{
+			name: "etag retries disabled",
+			modify: func(c *Config) {
+				c.Backup.ETagRetries = -1
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid etag retries",
+			modify: func(c *Config) {
+				c.Backup.ETagRetries = -2
+			},
+			wantErr: true,
+			errMsg:  "etag_retries",
+		},
+		{
+			name: "negative download concurrency",
+			modify: func(c *Config) {
+				c.Restore.DownloadConcurrency = -1
+			},
+			wantErr: true,
+			errMsg:  "download_concurrency",
+		},
+		{
+			name: "download chunk size below minimum",
+			modify: func(c *Config) {
+				c.Restore.DownloadChunkSize = 64 * 1024
+			},
+			wantErr: true,
+			errMsg:  "download_chunk_size",
+		},
+		{
+			name: "negative decompress workers",
+			modify: func(c *Config) {
+				c.Restore.DecompressWorkers = -2
+			},
+			wantErr: true,
+			errMsg:  "decompress_workers",
+		},
+		{
+			name: "auto chunk size min above max",
+			modify: func(c *Config) {
+				c.Backup.AutoChunkSize = true
+				c.Backup.ChunkSizeMin = 64 * 1024 * 1024
+				c.Backup.ChunkSizeMax = 16 * 1024 * 1024
+			},
+			wantErr: true,
+			errMsg:  "chunk_size_min",
+		},
+		{
+			name: "auto chunk size min below 5MB",
+			modify: func(c *Config) {
+				c.Backup.AutoChunkSize = true
+				c.Backup.ChunkSizeMin = 1024 * 1024
+				c.Backup.ChunkSizeMax = 16 * 1024 * 1024
+			},
+			wantErr: true,
+			errMsg:  "chunk_size_min",
+		},
+		{
+			name: "auto chunk size valid range",
+			modify: func(c *Config) {
+				c.Backup.AutoChunkSize = true
+				c.Backup.ChunkSizeMin = 5 * 1024 * 1024
+				c.Backup.ChunkSizeMax = 128 * 1024 * 1024
+			},
+			wantErr: false,
+		},
+		{
+			name: "auto concurrency without max",
+			modify: func(c *Config) {
+				c.Backup.AutoConcurrency = true
+				c.Backup.ConcurrencyMax = 0
+			},
+			wantErr: true,
+			errMsg:  "concurrency_max",
+		},
+		{
+			name: "negative max total size",
+			modify: func(c *Config) {
+				c.Backup.MaxTotalSize = -1
+			},
+			wantErr: true,
+			errMsg:  "max_total_size",
+		},
+		{
+			name: "unknown max total size action",
+			modify: func(c *Config) {
+				c.Backup.MaxTotalSize = 1024
+				c.Backup.MaxTotalSizeAction = "ignore"
+			},
+			wantErr: true,
+			errMsg:  "max_total_size_action",
 		},
 		{
 			name: "exact minimum chunk size",
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// envVarPattern 匹配 ${NAME} 形式的环境变量引用，以及转义的 $${NAME}
+var envVarPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnvString 展开字符串中的 ${NAME} 引用，未设置的变量展开为空字符串；$${NAME} 保留为字面的 ${NAME}
+// 只处理花括号形式，避免误伤密码等值中出现的普通 $ 字符
+func expandEnvString(s string) string {
+	return envVarPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if strings.HasPrefix(m, "$$") {
+			return m[1:]
+		}
+		name := envVarPattern.FindStringSubmatch(m)[1]
+		return os.Getenv(name)
+	})
+}
+
+// hasEnvRef 判断 s 是否包含未转义的 ${NAME} 引用
+func hasEnvRef(s string) bool {
+	for _, m := range envVarPattern.FindAllString(s, -1) {
+		if !strings.HasPrefix(m, "$$") {
+			return true
+		}
+	}
+	return false
+}
+
+// expandConfigFile 展开配置文件中所有字符串值（包括列表和映射中的值）的环境变量引用
+// 在绑定环境变量和命令行 flag 之前执行，只处理配置文件中的值：来自环境变量、.env 文件和 flag 的值
+// 本身已经是最终的值（如包含 ${ 的密码），原样使用，不再展开
+func expandConfigFile(v *viper.Viper) error {
+	expanded := make(map[string]any)
+	for _, key := range v.AllKeys() {
+		parts := strings.Split(key, ".")
+		m := expanded
+		for _, p := range parts[:len(parts)-1] {
+			next, ok := m[p].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				m[p] = next
+			}
+			m = next
+		}
+		m[parts[len(parts)-1]] = expandAny(v.Get(key))
+	}
+	if err := v.MergeConfigMap(expanded); err != nil {
+		return fmt.Errorf("failed to expand env references: %w", err)
+	}
+	return nil
+}
+
+// expandAny 递归展开 YAML 解析结果中的字符串
+func expandAny(x any) any {
+	switch x := x.(type) {
+	case string:
+		return expandEnvString(x)
+	case []any:
+		out := make([]any, len(x))
+		for i, e := range x {
+			out[i] = expandAny(e)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, e := range x {
+			out[k] = expandAny(e)
+		}
+		return out
+	default:
+		return x
+	}
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestExpandEnvString 测试 ${VAR} 展开
+func TestExpandEnvString(t *testing.T) {
+	t.Setenv("S3BACKUP_TEST_BUCKET", "prod-bucket")
+	t.Setenv("S3BACKUP_TEST_REGION", "cn-east-1")
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"single variable", "${S3BACKUP_TEST_BUCKET}", "prod-bucket"},
+		{"embedded variable", "s3.${S3BACKUP_TEST_REGION}.qiniucs.com", "s3.cn-east-1.qiniucs.com"},
+		{"multiple variables", "${S3BACKUP_TEST_BUCKET}-${S3BACKUP_TEST_REGION}", "prod-bucket-cn-east-1"},
+		{"unset variable", "${S3BACKUP_TEST_UNSET}", ""},
+		{"plain dollar kept", "pa$$word$HOME", "pa$$word$HOME"},
+		{"escaped reference", "$${S3BACKUP_TEST_BUCKET}", "${S3BACKUP_TEST_BUCKET}"},
+		{"no variable", "plain", "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandEnvString(tt.input); got != tt.expected {
+				t.Errorf("expandEnvString(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestExpandEnvConfig 测试配置文件中字符串、列表和映射中的值都会展开
+func TestExpandEnvConfig(t *testing.T) {
+	t.Setenv("S3BACKUP_TEST_HOME", "/home/test")
+	t.Setenv("S3BACKUP_TEST_SECRET", "secret-value")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `storage:
+  secret_key: ${S3BACKUP_TEST_SECRET}
+encryption:
+  key_file: ${S3BACKUP_TEST_HOME}/.s3backup.key
+backup:
+  includes: ["${S3BACKUP_TEST_HOME}/docs"]
+  excludes: ["*.log"]
+  paths:
+    home: ["${S3BACKUP_TEST_HOME}"]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(configPath, filepath.Join(t.TempDir(), "nonexistent.env"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.Storage.SecretKey != "secret-value" {
+		t.Errorf("expected secret key to be expanded, got %q", cfg.Storage.SecretKey)
+	}
+	if cfg.Encryption.KeyFile != "/home/test/.s3backup.key" {
+		t.Errorf("expected key file to be expanded, got %q", cfg.Encryption.KeyFile)
+	}
+	if len(cfg.Backup.Includes) != 1 || cfg.Backup.Includes[0] != "/home/test/docs" {
+		t.Errorf("expected include to be expanded, got %q", cfg.Backup.Includes)
+	}
+	if len(cfg.Backup.Excludes) != 1 || cfg.Backup.Excludes[0] != "*.log" {
+		t.Errorf("expected exclude to be unchanged, got %q", cfg.Backup.Excludes)
+	}
+	if got := cfg.Backup.Paths["home"]; len(got) != 1 || got[0] != "/home/test" {
+		t.Errorf("expected path group to be expanded, got %q", got)
+	}
+}
+
+// TestExpandEnvEscapeAndEnvValues 测试 $${VAR} 转义为字面的 ${VAR}，来自环境变量的值不展开
+func TestExpandEnvEscapeAndEnvValues(t *testing.T) {
+	t.Setenv("S3BACKUP_TEST_VAR", "expanded")
+	t.Setenv("S3BACKUP_SECRET_KEY", "env${S3BACKUP_TEST_VAR}secret")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `storage:
+  access_key: pa$${S3BACKUP_TEST_VAR}ss
+  bucket: ${S3BACKUP_TEST_VAR}
+encryption:
+  password: $${S3BACKUP_TEST_VAR}-${S3BACKUP_TEST_VAR}
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(configPath, filepath.Join(t.TempDir(), "nonexistent.env"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.Storage.AccessKey != "pa${S3BACKUP_TEST_VAR}ss" {
+		t.Errorf("escaped access key = %q", cfg.Storage.AccessKey)
+	}
+	if cfg.Storage.Bucket != "expanded" {
+		t.Errorf("bucket = %q, want expanded", cfg.Storage.Bucket)
+	}
+	if cfg.Encryption.Password != "${S3BACKUP_TEST_VAR}-expanded" {
+		t.Errorf("password = %q", cfg.Encryption.Password)
+	}
+	if got := cfg.GetSecretKey(); got != "env${S3BACKUP_TEST_VAR}secret" {
+		t.Errorf("secret key from env = %q, want it unexpanded", got)
+	}
+}
+
+// TestLoadConfigExpandsEnv 测试 LoadConfig 展开环境变量
+func TestLoadConfigExpandsEnv(t *testing.T) {
+	t.Setenv("S3BACKUP_TEST_BUCKET_NAME", "env-bucket")
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := "storage:\n  bucket: ${S3BACKUP_TEST_BUCKET_NAME}\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath, filepath.Join(tmpDir, "nonexistent.env"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Storage.Bucket != "env-bucket" {
+		t.Errorf("expected bucket 'env-bucket', got %q", cfg.Storage.Bucket)
+	}
+}
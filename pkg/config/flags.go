@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// flagKeys 配置键与命令行 flag 名称的对应关系
+var flagKeys = map[string]string{
+	"storage.provider":             "provider",
+	"storage.endpoint":             "endpoint",
+	"storage.region":               "region",
+	"storage.bucket":               "bucket",
+	"storage.access_key":           "access-key",
+	"storage.secret_key":           "secret-key",
+	"storage.restore_access_key":   "restore-access-key",
+	"storage.restore_secret_key":   "restore-secret-key",
+	"storage.storage_class":        "storage-class",
+	"storage.path_style":           "path-style",
+	"storage.write_only":           "write-only",
+	"storage.acl":                  "acl",
+	"encryption.enabled":           "encrypt",
+	"encryption.password":          "password",
+	"encryption.key_file":          "key-file",
+	"encryption.encrypt_state":     "encrypt-state",
+	"encryption.padding":           "padding",
+	"encryption.padding_bucket":    "padding-bucket",
+	"backup.excludes":              "exclude",
+	"backup.chunk_size":            "chunk-size",
+	"backup.concurrency":           "concurrency",
+	"backup.auto_chunk_size":       "auto-chunk-size",
+	"backup.chunk_size_min":        "chunk-size-min",
+	"backup.chunk_size_max":        "chunk-size-max",
+	"backup.auto_concurrency":      "auto-concurrency",
+	"backup.concurrency_max":       "concurrency-max",
+	"backup.read_ahead":            "read-ahead",
+	"backup.etag_retries":          "etag-retries",
+	"backup.max_total_size":        "max-total-size",
+	"backup.allow_no_match":        "allow-no-match",
+	"backup.ignore_case":           "ignore-case",
+	"backup.report":                "report",
+	"backup.verify_upload":         "verify-upload",
+	"backup.sign_key":              "sign-key",
+	"backup.heartbeat_interval":    "heartbeat-interval",
+	"backup.max_age":               "max-age",
+	"backup.expire_after":          "expire-after",
+	"backup.overwrite":             "overwrite",
+	"backup.idempotent":            "idempotent",
+	"backup.spool_dir":             "spool-dir",
+	"backup.compression":           "compression",
+	"backup.zstd_dictionary":       "zstd-dictionary",
+	"backup.smart_compression":     "smart-compression",
+	"backup.parallel_roots":        "parallel-roots",
+	"backup.max_warnings":          "max-warnings",
+	"backup.strict":                "strict",
+	"backup.include_devices":       "include-devices",
+	"backup.xattrs":                "xattrs",
+	"backup.selinux":               "selinux",
+	"source.url":                   "source",
+	"state.dir":                    "state-dir",
+	"state.no_resume":              "no-resume-state",
+	"restore.download_concurrency": "download-concurrency",
+	"restore.download_chunk_size":  "download-chunk-size",
+	"restore.decompress_workers":   "decompress-workers",
+}
+
+// envAliases 常用配置键的简短环境变量名（优先于 S3BACKUP_<SECTION>_<KEY> 形式）
+var envAliases = map[string]string{
+	"storage.provider":           "S3BACKUP_PROVIDER",
+	"storage.endpoint":           "S3BACKUP_ENDPOINT",
+	"storage.region":             "S3BACKUP_REGION",
+	"storage.bucket":             "S3BACKUP_BUCKET",
+	"storage.access_key":         "S3BACKUP_ACCESS_KEY",
+	"storage.secret_key":         "S3BACKUP_SECRET_KEY",
+	"storage.restore_access_key": "S3BACKUP_RESTORE_ACCESS_KEY",
+	"storage.restore_secret_key": "S3BACKUP_RESTORE_SECRET_KEY",
+	"storage.storage_class":      "S3BACKUP_STORAGE_CLASS",
+	"encryption.password":        "S3BACKUP_ENCRYPT_PASSWORD",
+	"backup.chunk_size":          "S3BACKUP_CHUNK_SIZE",
+	"backup.concurrency":         "S3BACKUP_CONCURRENCY",
+}
+
+// envName 返回配置键对应的完整环境变量名，例如 storage.bucket -> S3BACKUP_STORAGE_BUCKET
+func envName(key string) string {
+	return "S3BACKUP_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// bindSources 将环境变量和命令行 flag 绑定到 viper，
+// 使优先级统一为：flag > 环境变量 > 配置文件 > 默认值
+func bindSources(v *viper.Viper, flags *pflag.FlagSet) error {
+	// 每个配置键都可以通过 S3BACKUP_<SECTION>_<KEY> 环境变量覆盖，
+	// 例如 storage.bucket 对应 S3BACKUP_STORAGE_BUCKET；常用键另有简短别名
+	for _, key := range knownKeys() {
+		names := []string{}
+		if alias, ok := envAliases[key]; ok {
+			names = append(names, alias)
+		}
+		names = append(names, envName(key))
+		if err := v.BindEnv(append([]string{key}, names...)...); err != nil {
+			return fmt.Errorf("failed to bind env for %s: %w", key, err)
+		}
+	}
+
+	if flags == nil {
+		return nil
+	}
+
+	// 只有显式设置（Changed）的 flag 才会覆盖其他来源
+	for key, name := range flagKeys {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			continue
+		}
+		if err := v.BindPFlag(key, flag); err != nil {
+			return fmt.Errorf("failed to bind flag --%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
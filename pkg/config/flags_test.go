@@ -0,0 +1,213 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// newTestFlags 创建与 CLI 相同名称的 flag 集合
+func newTestFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("bucket", "", "")
+	flags.String("provider", "", "")
+	flags.Bool("encrypt", false, "")
+	flags.StringSlice("exclude", []string{}, "")
+	flags.Int("concurrency", 0, "")
+	flags.Int64("chunk-size", 0, "")
+	return flags
+}
+
+// writeTestConfig 写入测试配置文件
+func writeTestConfig(t *testing.T, content string) (configPath, envPath string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	configPath = filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return configPath, filepath.Join(tmpDir, "nonexistent.env")
+}
+
+// TestPrecedenceConflictingSources 测试 flag > 环境变量 > 配置文件 > 默认值
+func TestPrecedenceConflictingSources(t *testing.T) {
+	content := `storage:
+  bucket: file-bucket
+backup:
+  concurrency: 2
+`
+	tests := []struct {
+		name     string
+		env      string
+		flag     string
+		expected string
+	}{
+		{"file only", "", "", "file-bucket"},
+		{"env overrides file", "env-bucket", "", "env-bucket"},
+		{"flag overrides env and file", "env-bucket", "flag-bucket", "flag-bucket"},
+		{"flag overrides file", "", "flag-bucket", "flag-bucket"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath, envPath := writeTestConfig(t, content)
+			if tt.env != "" {
+				t.Setenv("S3BACKUP_STORAGE_BUCKET", tt.env)
+			}
+
+			flags := newTestFlags()
+			if tt.flag != "" {
+				if err := flags.Parse([]string{"--bucket", tt.flag}); err != nil {
+					t.Fatalf("failed to parse flags: %v", err)
+				}
+			}
+
+			cfg, err := LoadConfigWithFlags(configPath, envPath, flags)
+			if err != nil {
+				t.Fatalf("LoadConfigWithFlags() error = %v", err)
+			}
+			if cfg.Storage.Bucket != tt.expected {
+				t.Errorf("expected bucket %q, got %q", tt.expected, cfg.Storage.Bucket)
+			}
+		})
+	}
+}
+
+// TestPrecedenceUnsetFlagDoesNotOverride 测试未设置的 flag 不覆盖配置文件
+func TestPrecedenceUnsetFlagDoesNotOverride(t *testing.T) {
+	configPath, envPath := writeTestConfig(t, `encryption:
+  enabled: true
+backup:
+  concurrency: 2
+  excludes:
+    - "*.log"
+`)
+
+	cfg, err := LoadConfigWithFlags(configPath, envPath, newTestFlags())
+	if err != nil {
+		t.Fatalf("LoadConfigWithFlags() error = %v", err)
+	}
+
+	if !cfg.Encryption.Enabled {
+		t.Error("unset --encrypt flag should not override config file")
+	}
+	if cfg.Backup.Concurrency != 2 {
+		t.Errorf("expected concurrency 2 from file, got %d", cfg.Backup.Concurrency)
+	}
+	if len(cfg.Backup.Excludes) != 1 || cfg.Backup.Excludes[0] != "*.log" {
+		t.Errorf("expected excludes from file, got %v", cfg.Backup.Excludes)
+	}
+}
+
+// TestPrecedenceTypedFlags 测试非字符串类型的 flag 绑定
+func TestPrecedenceTypedFlags(t *testing.T) {
+	configPath, envPath := writeTestConfig(t, "backup:\n  concurrency: 2\n")
+	t.Setenv("S3BACKUP_BACKUP_CONCURRENCY", "6")
+
+	flags := newTestFlags()
+	if err := flags.Parse([]string{
+		"--concurrency", "8",
+		"--chunk-size", "10485760",
+		"--encrypt",
+		"--exclude", "*.tmp", "--exclude", "*.log",
+	}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+
+	cfg, err := LoadConfigWithFlags(configPath, envPath, flags)
+	if err != nil {
+		t.Fatalf("LoadConfigWithFlags() error = %v", err)
+	}
+
+	if cfg.Backup.Concurrency != 8 {
+		t.Errorf("expected concurrency 8 from flag, got %d", cfg.Backup.Concurrency)
+	}
+	if cfg.Backup.ChunkSize != 10485760 {
+		t.Errorf("expected chunk size from flag, got %d", cfg.Backup.ChunkSize)
+	}
+	if !cfg.Encryption.Enabled {
+		t.Error("expected encryption enabled from flag")
+	}
+	if len(cfg.Backup.Excludes) != 2 {
+		t.Errorf("expected 2 excludes from flag, got %v", cfg.Backup.Excludes)
+	}
+}
+
+// TestPrecedenceEnvWithoutFileKey 测试配置文件中没有的键也能由环境变量设置
+func TestPrecedenceEnvWithoutFileKey(t *testing.T) {
+	configPath, envPath := writeTestConfig(t, "storage:\n  bucket: file-bucket\n")
+	t.Setenv("S3BACKUP_STORAGE_PROVIDER", "qiniu")
+
+	cfg, err := LoadConfigWithFlags(configPath, envPath, nil)
+	if err != nil {
+		t.Fatalf("LoadConfigWithFlags() error = %v", err)
+	}
+	if cfg.Storage.Provider != "qiniu" {
+		t.Errorf("expected provider from env, got %q", cfg.Storage.Provider)
+	}
+}
+
+// TestEnvAliases 测试简短环境变量名
+func TestEnvAliases(t *testing.T) {
+	configPath, envPath := writeTestConfig(t, "storage:\n  bucket: file-bucket\n")
+
+	t.Setenv("S3BACKUP_PROVIDER", "aliyun")
+	t.Setenv("S3BACKUP_BUCKET", "alias-bucket")
+	t.Setenv("S3BACKUP_ENDPOINT", "oss-cn-hangzhou.aliyuncs.com")
+	t.Setenv("S3BACKUP_REGION", "cn-hangzhou")
+	t.Setenv("S3BACKUP_STORAGE_CLASS", "archive")
+	t.Setenv("S3BACKUP_CHUNK_SIZE", "10485760")
+	t.Setenv("S3BACKUP_CONCURRENCY", "8")
+
+	cfg, err := LoadConfigWithFlags(configPath, envPath, nil)
+	if err != nil {
+		t.Fatalf("LoadConfigWithFlags() error = %v", err)
+	}
+
+	if cfg.Storage.Provider != "aliyun" {
+		t.Errorf("expected provider from S3BACKUP_PROVIDER, got %q", cfg.Storage.Provider)
+	}
+	if cfg.Storage.Bucket != "alias-bucket" {
+		t.Errorf("expected bucket from S3BACKUP_BUCKET, got %q", cfg.Storage.Bucket)
+	}
+	if cfg.Storage.Endpoint != "oss-cn-hangzhou.aliyuncs.com" {
+		t.Errorf("expected endpoint from S3BACKUP_ENDPOINT, got %q", cfg.Storage.Endpoint)
+	}
+	if cfg.Storage.Region != "cn-hangzhou" {
+		t.Errorf("expected region from S3BACKUP_REGION, got %q", cfg.Storage.Region)
+	}
+	if cfg.Storage.StorageClass != "archive" {
+		t.Errorf("expected storage class from S3BACKUP_STORAGE_CLASS, got %q", cfg.Storage.StorageClass)
+	}
+	if cfg.Backup.ChunkSize != 10485760 {
+		t.Errorf("expected chunk size from S3BACKUP_CHUNK_SIZE, got %d", cfg.Backup.ChunkSize)
+	}
+	if cfg.Backup.Concurrency != 8 {
+		t.Errorf("expected concurrency from S3BACKUP_CONCURRENCY, got %d", cfg.Backup.Concurrency)
+	}
+}
+
+// TestEnvAliasPrecedence 测试简短别名优先于完整环境变量名
+func TestEnvAliasPrecedence(t *testing.T) {
+	configPath, envPath := writeTestConfig(t, "")
+
+	t.Setenv("S3BACKUP_BUCKET", "alias-bucket")
+	t.Setenv("S3BACKUP_STORAGE_BUCKET", "full-bucket")
+
+	cfg, err := LoadConfigWithFlags(configPath, envPath, nil)
+	if err != nil {
+		t.Fatalf("LoadConfigWithFlags() error = %v", err)
+	}
+	if cfg.Storage.Bucket != "alias-bucket" {
+		t.Errorf("expected alias to take precedence, got %q", cfg.Storage.Bucket)
+	}
+}
+
+// TestEnvName 测试完整环境变量名生成
+func TestEnvName(t *testing.T) {
+	if got := envName("storage.storage_class"); got != "S3BACKUP_STORAGE_STORAGE_CLASS" {
+		t.Errorf("envName() = %q", got)
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LoadSecretDir 将挂载的密钥目录（如 Kubernetes Secret 卷）中的文件导出为环境变量
+// 文件名对应环境变量名：access_key -> S3BACKUP_ACCESS_KEY，storage_bucket -> S3BACKUP_STORAGE_BUCKET，
+// 已带 S3BACKUP_ 前缀的文件名原样使用。文件内容去掉首尾空白后作为值。
+// 已设置的环境变量不会被覆盖；以 . 开头的文件（Secret 卷内部的 ..data 等）和子目录被忽略。
+// 返回本次设置的环境变量名（已排序）
+func LoadSecretDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets directory: %w", err)
+	}
+
+	var set []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		// Secret 卷中的文件是指向 ..data 的符号链接，需要跟随链接判断类型
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat secret %s: %w", e.Name(), err)
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		name := secretEnvName(e.Name())
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %s: %w", e.Name(), err)
+		}
+		if err := os.Setenv(name, strings.TrimSpace(string(data))); err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", name, err)
+		}
+		set = append(set, name)
+	}
+	sort.Strings(set)
+	return set, nil
+}
+
+// secretEnvName 返回密钥文件对应的环境变量名
+func secretEnvName(file string) string {
+	name := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(file))
+	if strings.HasPrefix(name, "S3BACKUP_") {
+		return name
+	}
+	return "S3BACKUP_" + name
+}
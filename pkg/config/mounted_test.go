@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestLoadSecretDir 测试从挂载的密钥目录导出环境变量
+func TestLoadSecretDir(t *testing.T) {
+	// 注册清理后再取消设置，测试结束时恢复原值
+	for _, name := range []string{"S3BACKUP_ACCESS_KEY", "S3BACKUP_SECRET_KEY", "S3BACKUP_STORAGE_BUCKET", "S3BACKUP_CONFIG_PASSPHRASE"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	t.Setenv("S3BACKUP_ENCRYPT_PASSWORD", "from-env")
+
+	dir := t.TempDir()
+	data := filepath.Join(dir, "..data")
+	if err := os.Mkdir(data, 0700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"access_key":                 "AKIAEXAMPLE\n",
+		"secret-key":                 "  secret-example  ",
+		"storage_bucket":             "my-bucket",
+		"S3BACKUP_CONFIG_PASSPHRASE": "passphrase",
+		"encrypt_password":           "from-secret",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(data, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		// 与 Kubernetes Secret 卷相同，文件为指向 ..data 的符号链接
+		if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set, err := LoadSecretDir(dir)
+	if err != nil {
+		t.Fatalf("LoadSecretDir() error = %v", err)
+	}
+
+	want := []string{"S3BACKUP_ACCESS_KEY", "S3BACKUP_CONFIG_PASSPHRASE", "S3BACKUP_SECRET_KEY", "S3BACKUP_STORAGE_BUCKET"}
+	if !reflect.DeepEqual(set, want) {
+		t.Errorf("set = %v, want %v", set, want)
+	}
+
+	expected := map[string]string{
+		"S3BACKUP_ACCESS_KEY":        "AKIAEXAMPLE",
+		"S3BACKUP_SECRET_KEY":        "secret-example",
+		"S3BACKUP_STORAGE_BUCKET":    "my-bucket",
+		"S3BACKUP_CONFIG_PASSPHRASE": "passphrase",
+		// 已设置的环境变量优先
+		"S3BACKUP_ENCRYPT_PASSWORD": "from-env",
+	}
+	for name, value := range expected {
+		if got := os.Getenv(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+
+	if _, err := LoadSecretDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
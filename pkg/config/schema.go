@@ -0,0 +1,118 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// knownKeys 通过 yaml tag 反射出所有合法的配置键（形如 backup.chunk_size）
+func knownKeys() []string {
+	var keys []string
+	collectKeys(reflect.TypeOf(Config{}), "", &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+// collectKeys 递归收集结构体的 yaml 键
+func collectKeys(t reflect.Type, prefix string, keys *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		if field.Type.Kind() == reflect.Struct {
+			collectKeys(field.Type, key, keys)
+			continue
+		}
+		*keys = append(*keys, key)
+	}
+}
+
+// validateKeys 检查配置文件中的键是否都是已知键
+// 对于未知键，给出最相近的合法键作为建议
+func validateKeys(keys []string) error {
+	known := knownKeys()
+	knownSet := make(map[string]bool, len(known))
+	for _, k := range known {
+		knownSet[k] = true
+	}
+
+	var errs []error
+	for _, key := range keys {
+		if knownSet[key] || underKnownKey(key, known) {
+			continue
+		}
+		if suggestion := suggestKey(key, known); suggestion != "" {
+			errs = append(errs, fmt.Errorf("unknown config key %q (did you mean %q?)", key, suggestion))
+		} else {
+			errs = append(errs, fmt.Errorf("unknown config key %q", key))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// underKnownKey 判断 key 是否位于某个合法叶子键之下
+// 映射类型的配置（如 backup.paths）由用户自定义子键，viper 会将其展开为 backup.paths.<name>
+func underKnownKey(key string, known []string) bool {
+	for _, k := range known {
+		if strings.HasPrefix(key, k+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestKey 返回与 key 编辑距离最小的合法键，距离过大时返回空字符串
+func suggestKey(key string, known []string) string {
+	// 忽略分隔符差异，例如 chunksize 与 chunk_size
+	normalize := func(s string) string {
+		return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(s))
+	}
+
+	best := ""
+	bestDist := -1
+	for _, k := range known {
+		d := levenshtein(normalize(key), normalize(k))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+
+	// 超过键长度的三分之一则认为不是拼写错误
+	if bestDist < 0 || bestDist > len(key)/3 {
+		return ""
+	}
+	return best
+}
+
+// levenshtein 计算两个字符串的编辑距离
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
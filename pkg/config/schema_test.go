@@ -0,0 +1,192 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestKnownKeys 测试从 yaml tag 推导的合法键
+func TestKnownKeys(t *testing.T) {
+	keys := knownKeys()
+
+	expected := []string{
+		"storage.provider",
+		"storage.access_key",
+		"storage.storage_class",
+		"encryption.key_file",
+		"backup.chunk_size",
+		"backup.concurrency",
+	}
+
+	set := make(map[string]bool)
+	for _, k := range keys {
+		set[k] = true
+	}
+	for _, k := range expected {
+		if !set[k] {
+			t.Errorf("expected known key %q", k)
+		}
+	}
+}
+
+// TestValidateKeysSuggestion 测试未知键的纠错建议
+func TestValidateKeysSuggestion(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		suggestion string
+	}{
+		{"missing underscore", "backup.chunksize", "backup.chunk_size"},
+		{"typo", "storage.bukcet", "storage.bucket"},
+		{"hyphen instead of underscore", "storage.access-key", "storage.access_key"},
+		{"unrelated key", "foo.bar.baz.qux", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKeys([]string{tt.key})
+			if err == nil {
+				t.Fatalf("expected error for unknown key %q", tt.key)
+			}
+			if !strings.Contains(err.Error(), tt.key) {
+				t.Errorf("error should mention offending key %q, got: %v", tt.key, err)
+			}
+			if tt.suggestion != "" && !strings.Contains(err.Error(), "did you mean \""+tt.suggestion+"\"") {
+				t.Errorf("expected suggestion %q, got: %v", tt.suggestion, err)
+			}
+			if tt.suggestion == "" && strings.Contains(err.Error(), "did you mean") {
+				t.Errorf("expected no suggestion, got: %v", err)
+			}
+		})
+	}
+}
+
+// TestValidateKeysKnown 测试合法键不报错
+func TestValidateKeysKnown(t *testing.T) {
+	if err := validateKeys(knownKeys()); err != nil {
+		t.Errorf("known keys should pass validation, got: %v", err)
+	}
+}
+
+// TestLoadConfigUnknownKey 测试配置文件中的拼写错误会被报告
+func TestLoadConfigUnknownKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := "backup:\n  chunksize: 10485760\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	_, err := LoadConfig(configPath, filepath.Join(tmpDir, "nonexistent.env"))
+	if err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+	if !strings.Contains(err.Error(), "backup.chunk_size") {
+		t.Errorf("expected suggestion for backup.chunk_size, got: %v", err)
+	}
+}
+
+// TestLoadConfigSnakeCaseKeys 测试下划线键名能正确解析
+func TestLoadConfigSnakeCaseKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := `storage:
+  provider: qiniu
+  storage_class: archive
+encryption:
+  key_file: /tmp/key
+backup:
+  chunk_size: 10485760
+  concurrency: 8
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath, filepath.Join(tmpDir, "nonexistent.env"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.Storage.StorageClass != "archive" {
+		t.Errorf("expected storage_class 'archive', got '%s'", cfg.Storage.StorageClass)
+	}
+	if cfg.Encryption.KeyFile != "/tmp/key" {
+		t.Errorf("expected key_file '/tmp/key', got '%s'", cfg.Encryption.KeyFile)
+	}
+	if cfg.Backup.ChunkSize != 10485760 {
+		t.Errorf("expected chunk_size 10485760, got %d", cfg.Backup.ChunkSize)
+	}
+	if cfg.Backup.Concurrency != 8 {
+		t.Errorf("expected concurrency 8, got %d", cfg.Backup.Concurrency)
+	}
+}
+
+// TestLoadConfigStorageClassRules 测试加载路径存储类型规则
+func TestLoadConfigStorageClassRules(t *testing.T) {
+	t.Setenv("S3BACKUP_TEST_LOG_DIR", "/var/log")
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := `backup:
+  storage_class_rules:
+    - path: ${S3BACKUP_TEST_LOG_DIR}
+      storage_class: archive
+    - path: /etc
+      storage_class: standard
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath, filepath.Join(tmpDir, "nonexistent.env"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	want := []StorageClassRule{{Path: "/var/log", StorageClass: "archive"}, {Path: "/etc", StorageClass: "standard"}}
+	if !reflect.DeepEqual(cfg.Backup.StorageClassRules, want) {
+		t.Errorf("rules = %+v, want %+v", cfg.Backup.StorageClassRules, want)
+	}
+
+	cfg.Storage.Bucket, cfg.Storage.AccessKey, cfg.Storage.SecretKey = "b", "ak", "sk"
+	cfg.Backup.StorageClassRules = append(cfg.Backup.StorageClassRules, StorageClassRule{Path: "/tmp"})
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for rule without storage_class")
+	}
+}
+
+// TestLoadConfigPaths 测试命名的包含路径组不会被当作未知键
+func TestLoadConfigPaths(t *testing.T) {
+	t.Setenv("S3BACKUP_TEST_HOME", "/home/user")
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := `backup:
+  paths:
+    etc: [/etc]
+    home:
+      - ${S3BACKUP_TEST_HOME}
+      - /root
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath, filepath.Join(tmpDir, "nonexistent.env"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	want := map[string][]string{"etc": {"/etc"}, "home": {"/home/user", "/root"}}
+	if !reflect.DeepEqual(cfg.Backup.Paths, want) {
+		t.Errorf("paths = %v, want %v", cfg.Backup.Paths, want)
+	}
+
+	cfg.Storage.Bucket, cfg.Storage.AccessKey, cfg.Storage.SecretKey = "b", "ak", "sk"
+	cfg.Backup.Paths["empty"] = nil
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for empty path group")
+	}
+}
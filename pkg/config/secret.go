@@ -0,0 +1,173 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"go.yaml.in/yaml/v3"
+)
+
+// ConfigPassphraseEnv 用于解密配置文件中加密凭证的口令环境变量
+const ConfigPassphraseEnv = "S3BACKUP_CONFIG_PASSPHRASE"
+
+// secretKeys 需要加密存储的配置键
+var secretKeys = []string{
+	"storage.access_key",
+	"storage.secret_key",
+	"storage.restore_access_key",
+	"storage.restore_secret_key",
+	"encryption.password",
+	"source.password",
+}
+
+// secretFields 返回配置中敏感字段的指针，与 secretKeys 一一对应
+func (c *Config) secretFields() []*string {
+	return []*string{
+		&c.Storage.AccessKey,
+		&c.Storage.SecretKey,
+		&c.Storage.RestoreAccessKey,
+		&c.Storage.RestoreSecretKey,
+		&c.Encryption.Password,
+		&c.Source.Password,
+	}
+}
+
+// decryptSecrets 解密配置中以 enc:v1: 开头的敏感字段
+func decryptSecrets(cfg *Config) error {
+	passphrase := os.Getenv(ConfigPassphraseEnv)
+
+	for i, field := range cfg.secretFields() {
+		if !crypto.IsSealed(*field) {
+			continue
+		}
+		if passphrase == "" {
+			return fmt.Errorf("config value %s is encrypted, set %s to decrypt it", secretKeys[i], ConfigPassphraseEnv)
+		}
+		plain, err := crypto.OpenString(passphrase, *field)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt config value %s: %w", secretKeys[i], err)
+		}
+		*field = plain
+	}
+
+	return nil
+}
+
+// EncryptConfigFile 加密配置文件中的敏感字段并原地写回
+// 使用 yaml.Node 修改，以保留注释和键顺序。返回本次新加密的字段数
+func EncryptConfigFile(path, passphrase string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return 0, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	count := 0
+	for _, key := range secretKeys {
+		node := findNode(&root, strings.Split(key, "."))
+		if node == nil || node.Kind != yaml.ScalarNode || node.Value == "" {
+			continue
+		}
+		// 已加密或引用环境变量的值无需处理
+		if crypto.IsSealed(node.Value) || hasEnvRef(node.Value) {
+			continue
+		}
+
+		// 解密后的值不再展开，转义的 $${NAME} 按字面的 ${NAME} 加密
+		sealed, err := crypto.SealString(passphrase, expandEnvString(node.Value))
+		if err != nil {
+			return 0, fmt.Errorf("failed to encrypt %s: %w", key, err)
+		}
+		node.Value = sealed
+		node.Style = 0
+		node.Tag = "!!str"
+		count++
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return 0, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return 0, fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat config: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), info.Mode().Perm()); err != nil {
+		return 0, fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return count, nil
+}
+
+// findNode 按路径查找 YAML 映射中的值节点
+func findNode(node *yaml.Node, path []string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return findNode(node.Content[0], path)
+	}
+	if len(path) == 0 {
+		return node
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == path[0] {
+			return findNode(node.Content[i+1], path[1:])
+		}
+	}
+	return nil
+}
+
+// Redacted 返回屏蔽敏感字段后的配置副本（凭证取实际生效的值，包括环境变量回退）
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.Backup.Includes = append([]string(nil), c.Backup.Includes...)
+	redacted.Backup.Excludes = append([]string(nil), c.Backup.Excludes...)
+
+	redacted.Storage.AccessKey = c.GetAccessKey()
+	redacted.Storage.SecretKey = c.GetSecretKey()
+	redacted.Storage.RestoreAccessKey = c.GetRestoreAccessKey()
+	redacted.Storage.RestoreSecretKey = c.GetRestoreSecretKey()
+	redacted.Encryption.Password = c.GetPassword()
+	redacted.Source.Password = c.GetSourcePassword()
+	if u, err := url.Parse(c.Source.URL); err == nil {
+		redacted.Source.URL = u.Redacted()
+	}
+
+	for _, field := range redacted.secretFields() {
+		*field = MaskSecret(*field)
+	}
+	return &redacted
+}
+
+// MaskSecret 屏蔽敏感值，较长的值保留末尾 4 位便于辨认
+func MaskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	if len(s) < 12 {
+		return "****"
+	}
+	return "****" + s[len(s)-4:]
+}
@@ -0,0 +1,355 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// 加密文件格式
+//
+// v1（旧格式，无版本字段）:
+//
+//	[4 bytes magic][16 bytes IV][encrypted data...][8 bytes data length][64 bytes HMAC]
+//	HMAC 只覆盖密文数据；密码派生密钥使用的盐值未保存
+//
+// v2（当前格式）:
+//
+//	[4 bytes magic][3 bytes "FMT"][1 byte version][1 byte KDF][32 bytes salt][16 bytes IV]
+//	[encrypted data...][8 bytes data length][64 bytes HMAC]
+//	HMAC 覆盖完整文件头、密文数据和数据长度；使用密码时盐值保存在文件头中
+//
+// v1 文件的 IV 以 "FMT" 开头的概率为 2^-24，其后的版本号或 KDF 无效时仍按 v1 解析；只有恰好是版本号 2
+// 和有效的 KDF（概率约 2^-39）时才会被识别为 v2 并在 HMAC 校验时失败
+const (
+	// Magic 加密文件魔数
+	Magic = "S3BE"
+	// formatTag 版本化文件头标记，紧跟在魔数之后
+	formatTag = "FMT"
+
+	// LegacyFormatVersion 无版本字段的旧格式
+	LegacyFormatVersion = 1
+	// FormatVersion 当前加密格式版本
+	FormatVersion = 2
+	// CipherName 加密算法名称，记录在对象元数据和续传状态中
+	CipherName = "aes-256-ctr+hmac-sha512"
+
+	// LegacyHeaderSize v1 文件头大小（魔数 + IV）
+	LegacyHeaderSize = len(Magic) + IVSize
+	// HeaderSize 当前格式文件头大小，密文数据从此偏移开始
+	HeaderSize = len(Magic) + len(formatTag) + 1 + 1 + SaltSize + IVSize
+	// TrailerSize 加密文件尾大小（数据长度 + HMAC）
+	TrailerSize = 8 + 64
+)
+
+var (
+	// ErrHMACMismatch HMAC 校验失败，数据被损坏或篡改，或者密钥不正确
+	ErrHMACMismatch = errors.New("HMAC verification failed: data may be corrupted or tampered")
+	// ErrLegacyPassword v1 格式使用密码加密时没有保存盐值，无法重新派生密钥
+	ErrLegacyPassword = errors.New("legacy format encrypted with a password cannot be decrypted: the key derivation salt was not stored")
+)
+
+// KDF 密钥派生方式
+type KDF byte
+
+const (
+	// KDFNone 直接使用密钥文件，无需派生
+	KDFNone KDF = 0
+	// KDFArgon2id 使用 Argon2id 从密码派生（time=3, memory=64MB, threads=4）
+	KDFArgon2id KDF = 1
+)
+
+// String 返回密钥派生方式的名称
+func (k KDF) String() string {
+	switch k {
+	case KDFNone:
+		return "key-file"
+	case KDFArgon2id:
+		return "argon2id"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(k))
+	}
+}
+
+// Header 加密文件头
+type Header struct {
+	Version int
+	KDF     KDF
+	Salt    []byte // KDFArgon2id 使用的盐值，KDFNone 时为全零
+	IV      []byte
+}
+
+// Size 返回文件头的字节数
+func (h *Header) Size() int {
+	if h.Version == LegacyFormatVersion {
+		return LegacyHeaderSize
+	}
+	return HeaderSize
+}
+
+// Bytes 返回文件头的二进制表示
+func (h *Header) Bytes() []byte {
+	buf := make([]byte, 0, h.Size())
+	buf = append(buf, Magic...)
+	if h.Version != LegacyFormatVersion {
+		salt := make([]byte, SaltSize)
+		copy(salt, h.Salt)
+		buf = append(buf, formatTag...)
+		buf = append(buf, byte(h.Version), byte(h.KDF))
+		buf = append(buf, salt...)
+	}
+	return append(buf, h.IV...)
+}
+
+// ReadHeader 从 r 读取并解析文件头，支持 v1 和 v2 格式
+func ReadHeader(r io.Reader) (*Header, error) {
+	// 魔数、版本标记、版本号和 KDF，均不超过 v1 文件头的长度
+	prefix := make([]byte, len(Magic)+len(formatTag)+2)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(prefix[:len(Magic)]) != Magic {
+		return nil, fmt.Errorf("invalid magic: %s", string(prefix[:len(Magic)]))
+	}
+
+	// 没有版本标记，或版本号、KDF 无效（v1 文件的 IV 恰好以 "FMT" 开头）的是 v1 文件，已读取的部分属于 IV
+	tag := string(prefix[len(Magic) : len(Magic)+len(formatTag)])
+	version, kdf := int(prefix[len(prefix)-2]), KDF(prefix[len(prefix)-1])
+	if tag != formatTag || version != FormatVersion || (kdf != KDFNone && kdf != KDFArgon2id) {
+		iv := make([]byte, IVSize)
+		n := copy(iv, prefix[len(Magic):])
+		if _, err := io.ReadFull(r, iv[n:]); err != nil {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		return &Header{Version: LegacyFormatVersion, KDF: KDFNone, IV: iv}, nil
+	}
+
+	rest := make([]byte, HeaderSize-len(prefix))
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	return &Header{
+		Version: version,
+		KDF:     kdf,
+		Salt:    rest[:SaltSize],
+		IV:      rest[SaltSize:],
+	}, nil
+}
+
+// ParseHeader 解析加密文件开头的字节
+func ParseHeader(data []byte) (*Header, error) {
+	h, err := ReadHeader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	return h, nil
+}
+
+// NewPasswordEncryptor 使用随机盐值从密码派生密钥，盐值会写入加密文件头以便解密时重新派生
+func NewPasswordEncryptor(password string) (*StreamEncryptor, error) {
+	if password == "" {
+		return nil, fmt.Errorf("password cannot be empty")
+	}
+
+	salt, err := GenerateSalt()
+	if err != nil {
+		return nil, err
+	}
+	aesKey, hmacKey, err := DeriveKey(password, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := NewStreamEncryptor(aesKey, hmacKey)
+	if err != nil {
+		return nil, err
+	}
+	e.kdf = KDFArgon2id
+	e.salt = salt
+	return e, nil
+}
+
+// KeySource 解密时使用的密钥来源，Password 和 KeyFile 二选一
+type KeySource struct {
+	Password string
+	KeyFile  []byte // 密钥文件内容
+}
+
+// KDF 返回加密时使用的密钥派生方式，同时提供时优先使用密钥文件
+func (k KeySource) KDF() KDF {
+	if len(k.KeyFile) == 0 {
+		return KDFArgon2id
+	}
+	return KDFNone
+}
+
+// Fingerprint 返回密钥文件的短指纹（SHA-256 的前 8 字节），用于确认续传使用同一个密钥文件
+// 使用密码时返回空字符串，不保存可用于离线猜测密码的摘要
+func (k KeySource) Fingerprint() string {
+	if len(k.KeyFile) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(append([]byte("s3backup-key-fingerprint-v1:"), k.KeyFile...))
+	return hex.EncodeToString(sum[:8])
+}
+
+// EncryptorFor 根据文件头记录的密钥派生方式创建解密用的加密器
+func (k KeySource) EncryptorFor(h *Header) (*StreamEncryptor, error) {
+	if h.KDF == KDFArgon2id {
+		if k.Password == "" {
+			return nil, fmt.Errorf("file is encrypted with a password, but no password was provided")
+		}
+		aesKey, hmacKey, err := DeriveKey(k.Password, h.Salt)
+		if err != nil {
+			return nil, err
+		}
+		e, err := NewStreamEncryptor(aesKey, hmacKey)
+		if err != nil {
+			return nil, err
+		}
+		e.kdf = KDFArgon2id
+		e.salt = h.Salt
+		return e, nil
+	}
+
+	if len(k.KeyFile) == 0 {
+		if h.Version == LegacyFormatVersion && k.Password != "" {
+			return nil, ErrLegacyPassword
+		}
+		return nil, fmt.Errorf("file is encrypted with a key file, but no key file was provided")
+	}
+	aesKey, hmacKey, err := DeriveKeyFromKeyFile(k.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewStreamEncryptor(aesKey, hmacKey)
+}
+
+// OpenReader 读取文件头并返回流式解密读取器，密钥按文件头记录的方式从 keys 获取
+//
+// 与 WrapReaderWithHMAC 不同，数据边读边解密而不缓存在内存中，
+// HMAC 在读到末尾时校验，失败时最后一次 Read 返回 ErrHMACMismatch 而不是 io.EOF。
+// 调用方必须读到 io.EOF 才能确认数据完整，在此之前不应信任已读取的数据。
+func OpenReader(r io.Reader, keys KeySource) (io.Reader, *Header, error) {
+	h, err := ReadHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e, err := keys.EncryptorFor(h)
+	if err != nil {
+		return nil, h, err
+	}
+
+	dr, err := e.newVerifyingReader(r, h)
+	if err != nil {
+		return nil, h, err
+	}
+	return dr, h, nil
+}
+
+// verifyingReader 流式解密并在末尾校验 HMAC 的读取器
+// 始终保留最后 TrailerSize 字节不解密，直到确认它们是 trailer
+// buf[start:end] 为已读取、尚未解密的数据，读取和解密都在 buf 中进行，不随读取次数分配内存
+type verifyingReader struct {
+	r          io.Reader
+	stream     cipher.Stream
+	mac        hash.Hash
+	legacy     bool
+	buf        []byte
+	start, end int
+	length     int64
+	eof        bool
+	err        error
+}
+
+// newVerifyingReader 创建流式校验读取器，r 应位于文件头之后
+func (e *StreamEncryptor) newVerifyingReader(r io.Reader, h *Header) (*verifyingReader, error) {
+	block, err := aes.NewCipher(e.aesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	mac := hmac.New(sha512.New, e.hmacKey)
+	legacy := h.Version == LegacyFormatVersion
+	if !legacy {
+		mac.Write(h.Bytes())
+	}
+
+	return &verifyingReader{
+		r:      r,
+		stream: cipher.NewCTR(block, h.IV),
+		mac:    mac,
+		legacy: legacy,
+		buf:    make([]byte, 32*1024+TrailerSize),
+	}, nil
+}
+
+// Read 读取并解密数据
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for {
+		if v.err != nil {
+			return 0, v.err
+		}
+
+		// 超出 trailer 长度的部分一定是密文，直接解密到 p
+		if avail := v.end - v.start - TrailerSize; avail > 0 {
+			n := min(avail, len(p))
+			data := v.buf[v.start : v.start+n]
+			v.mac.Write(data)
+			v.stream.XORKeyStream(p[:n], data)
+			v.start += n
+			v.length += int64(n)
+			return n, nil
+		}
+
+		if v.eof {
+			v.err = v.verify()
+			continue
+		}
+
+		// 剩余不超过 TrailerSize 字节，移到缓冲区开头后继续读取
+		v.end = copy(v.buf, v.buf[v.start:v.end])
+		v.start = 0
+		n, err := v.r.Read(v.buf[v.end:])
+		v.end += n
+		if err == io.EOF {
+			v.eof = true
+		} else if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// verify 校验 trailer 中的数据长度和 HMAC，成功时返回 io.EOF
+func (v *verifyingReader) verify() error {
+	pending := v.buf[v.start:v.end]
+	if len(pending) < TrailerSize {
+		return fmt.Errorf("invalid encrypted data: too short (got %d trailer bytes, need %d)", len(pending), TrailerSize)
+	}
+
+	lengthBytes := pending[:8]
+	if dataLength := int64(binary.BigEndian.Uint64(lengthBytes)); dataLength != v.length {
+		return fmt.Errorf("data length mismatch: header says %d, but got %d bytes", dataLength, v.length)
+	}
+	if !v.legacy {
+		v.mac.Write(lengthBytes)
+	}
+	if !hmac.Equal(v.mac.Sum(nil), pending[8:]) {
+		return ErrHMACMismatch
+	}
+	return io.EOF
+}
@@ -0,0 +1,261 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// legacyEncrypt 按 v1 格式加密（HMAC 只覆盖密文），用于测试旧格式兼容性
+func legacyEncrypt(t *testing.T, aesKey, hmacKey, plaintext []byte) []byte {
+	t.Helper()
+	return legacyEncryptIV(t, bytes.Repeat([]byte{0x42}, IVSize), aesKey, hmacKey, plaintext)
+}
+
+// legacyEncryptIV 使用指定的 IV 按 v1 格式加密
+func legacyEncryptIV(t *testing.T, iv, aesKey, hmacKey, plaintext []byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, plaintext)
+
+	mac := hmac.New(sha512.New, hmacKey)
+	mac.Write(ciphertext)
+
+	var buf bytes.Buffer
+	buf.WriteString(Magic)
+	buf.Write(iv)
+	buf.Write(ciphertext)
+	binary.Write(&buf, binary.BigEndian, uint64(len(ciphertext)))
+	buf.Write(mac.Sum(nil))
+	return buf.Bytes()
+}
+
+// encryptWith 使用加密器加密数据
+func encryptWith(t *testing.T, e *StreamEncryptor, plaintext []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := e.WrapWriter(&buf)
+	if err != nil {
+		t.Fatalf("WrapWriter() error = %v", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestPasswordEncryptorStoresSalt 测试密码加密的数据可以只凭密码解密
+func TestPasswordEncryptorStoresSalt(t *testing.T) {
+	e, err := NewPasswordEncryptor("correct horse")
+	if err != nil {
+		t.Fatalf("NewPasswordEncryptor() error = %v", err)
+	}
+
+	plaintext := []byte("recoverable with just the password")
+	encrypted := encryptWith(t, e, plaintext)
+
+	header, err := ParseHeader(encrypted)
+	if err != nil {
+		t.Fatalf("ParseHeader() error = %v", err)
+	}
+	if header.Version != FormatVersion || header.KDF != KDFArgon2id {
+		t.Fatalf("header = version %d, kdf %s", header.Version, header.KDF)
+	}
+
+	r, _, err := OpenReader(bytes.NewReader(encrypted), KeySource{Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("decrypted = %q, want %q", got, plaintext)
+	}
+
+	r, _, err = OpenReader(bytes.NewReader(encrypted), KeySource{Password: "wrong"})
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	if _, err := io.ReadAll(r); !errors.Is(err, ErrHMACMismatch) {
+		t.Errorf("wrong password: expected ErrHMACMismatch, got %v", err)
+	}
+}
+
+// TestOpenReaderLegacyFormat 测试读取 v1 格式
+func TestOpenReaderLegacyFormat(t *testing.T) {
+	keyFile, _ := GenerateKeyFile()
+	aesKey, hmacKey, _ := DeriveKeyFromKeyFile(keyFile)
+	plaintext := bytes.Repeat([]byte("legacy "), 10000)
+	encrypted := legacyEncrypt(t, aesKey, hmacKey, plaintext)
+
+	r, header, err := OpenReader(bytes.NewReader(encrypted), KeySource{KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	if header.Version != LegacyFormatVersion {
+		t.Errorf("version = %d, want %d", header.Version, LegacyFormatVersion)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Error("legacy decryption mismatch")
+	}
+
+	// WrapReaderWithHMAC 同样兼容旧格式
+	e, _ := NewStreamEncryptor(aesKey, hmacKey)
+	rc, err := e.WrapReaderWithHMAC(bytes.NewReader(encrypted))
+	if err != nil {
+		t.Fatalf("WrapReaderWithHMAC() error = %v", err)
+	}
+	if got, _ := io.ReadAll(rc); !bytes.Equal(got, plaintext) {
+		t.Error("WrapReaderWithHMAC legacy decryption mismatch")
+	}
+
+	// 旧格式使用密码加密时无法恢复
+	if _, _, err := OpenReader(bytes.NewReader(encrypted), KeySource{Password: "pw"}); !errors.Is(err, ErrLegacyPassword) {
+		t.Errorf("expected ErrLegacyPassword, got %v", err)
+	}
+}
+
+// TestOpenReaderSmallReads 测试逐字节读取时 trailer 不会被当作数据
+func TestOpenReaderSmallReads(t *testing.T) {
+	keyFile, _ := GenerateKeyFile()
+	aesKey, hmacKey, _ := DeriveKeyFromKeyFile(keyFile)
+	e, _ := NewStreamEncryptor(aesKey, hmacKey)
+
+	for _, size := range []int{0, 1, TrailerSize - 1, TrailerSize, TrailerSize + 1, 100000} {
+		plaintext := bytes.Repeat([]byte{0xab}, size)
+		encrypted := encryptWith(t, e, plaintext)
+
+		r, _, err := OpenReader(iotest.OneByteReader(bytes.NewReader(encrypted)), KeySource{KeyFile: keyFile})
+		if err != nil {
+			t.Fatalf("size %d: OpenReader() error = %v", size, err)
+		}
+		got, err := io.ReadAll(iotest.OneByteReader(r))
+		if err != nil {
+			t.Fatalf("size %d: ReadAll() error = %v", size, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("size %d: decrypted %d bytes", size, len(got))
+		}
+	}
+}
+
+// TestOpenReaderDetectsTampering 测试修改文件头、密文和 trailer 都会被检测到
+func TestOpenReaderDetectsTampering(t *testing.T) {
+	keyFile, _ := GenerateKeyFile()
+	aesKey, hmacKey, _ := DeriveKeyFromKeyFile(keyFile)
+	e, _ := NewStreamEncryptor(aesKey, hmacKey)
+	encrypted := encryptWith(t, e, []byte("integrity matters"))
+
+	positions := map[string]int{
+		"salt":    len(Magic) + len(formatTag) + 2,
+		"iv":      HeaderSize - 1,
+		"data":    HeaderSize,
+		"length":  len(encrypted) - TrailerSize,
+		"hmac":    len(encrypted) - 1,
+		"version": len(Magic) + len(formatTag),
+	}
+	for name, pos := range positions {
+		t.Run(name, func(t *testing.T) {
+			data := append([]byte(nil), encrypted...)
+			data[pos] ^= 0x01
+
+			r, _, err := OpenReader(bytes.NewReader(data), KeySource{KeyFile: keyFile})
+			if err == nil {
+				_, err = io.ReadAll(r)
+			}
+			if err == nil {
+				t.Error("tampering was not detected")
+			}
+		})
+	}
+
+	// 截断的数据
+	r, _, err := OpenReader(bytes.NewReader(encrypted[:len(encrypted)-10]), KeySource{KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Error("truncated data was not detected")
+	}
+}
+
+// TestEncryptorForKeyMismatch 测试密钥来源与文件头不符时给出明确错误
+func TestEncryptorForKeyMismatch(t *testing.T) {
+	passwordHeader := &Header{Version: FormatVersion, KDF: KDFArgon2id, Salt: make([]byte, SaltSize), IV: make([]byte, IVSize)}
+	if _, err := (KeySource{KeyFile: make([]byte, AESKeySize+HMACKeySize)}).EncryptorFor(passwordHeader); err == nil {
+		t.Error("expected error when password file is opened with a key file")
+	}
+
+	keyHeader := &Header{Version: FormatVersion, KDF: KDFNone, IV: make([]byte, IVSize)}
+	if _, err := (KeySource{Password: "pw"}).EncryptorFor(keyHeader); err == nil {
+		t.Error("expected error when key file is opened with a password")
+	}
+}
+
+// TestKeySourceFingerprint 测试密钥文件指纹和密钥派生方式
+func TestKeySourceFingerprint(t *testing.T) {
+	a := KeySource{KeyFile: []byte("key-a")}
+	b := KeySource{KeyFile: []byte("key-b")}
+	if a.Fingerprint() == "" || a.Fingerprint() != (KeySource{KeyFile: []byte("key-a")}).Fingerprint() {
+		t.Errorf("fingerprint is not stable: %q", a.Fingerprint())
+	}
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Error("different key files have the same fingerprint")
+	}
+	if a.KDF() != KDFNone {
+		t.Errorf("key file KDF = %s", a.KDF())
+	}
+
+	pw := KeySource{Password: "pw"}
+	if pw.Fingerprint() != "" || pw.KDF() != KDFArgon2id {
+		t.Errorf("password source: fingerprint %q, kdf %s", pw.Fingerprint(), pw.KDF())
+	}
+}
+
+// TestReadHeaderLegacyFMTPrefix 测试 IV 恰好以 "FMT" 开头、其后的版本号或 KDF 无效的 v1 文件仍按 v1 解析和解密
+func TestReadHeaderLegacyFMTPrefix(t *testing.T) {
+	keyFile, _ := GenerateKeyFile()
+	aesKey, hmacKey, _ := DeriveKeyFromKeyFile(keyFile)
+	plaintext := bytes.Repeat([]byte("legacy "), 1000)
+
+	for _, prefix := range []string{formatTag + "\x09", formatTag + "\x02\x07"} {
+		iv := append([]byte(prefix), bytes.Repeat([]byte{0x42}, IVSize-len(prefix))...)
+		encrypted := legacyEncryptIV(t, iv, aesKey, hmacKey, plaintext)
+
+		header, err := ParseHeader(encrypted)
+		if err != nil {
+			t.Fatalf("%q: ParseHeader() error = %v", prefix, err)
+		}
+		if header.Version != LegacyFormatVersion || !bytes.Equal(header.IV, iv) {
+			t.Fatalf("%q: header = version %d, iv %x", prefix, header.Version, header.IV)
+		}
+
+		r, _, err := OpenReader(bytes.NewReader(encrypted), KeySource{KeyFile: keyFile})
+		if err != nil {
+			t.Fatalf("%q: OpenReader() error = %v", prefix, err)
+		}
+		if got, err := io.ReadAll(r); err != nil || !bytes.Equal(got, plaintext) {
+			t.Errorf("%q: legacy decryption failed: %v", prefix, err)
+		}
+	}
+}
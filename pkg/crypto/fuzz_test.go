@@ -0,0 +1,171 @@
+package crypto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// fuzzEncryptor 创建模糊测试使用的加密器（密钥派生较慢，只在 Fuzz 函数开始时调用一次）
+func fuzzEncryptor(f *testing.F) *StreamEncryptor {
+	f.Helper()
+	aesKey, hmacKey, err := DeriveKeyFromPasswordFile("fuzz-password")
+	if err != nil {
+		f.Fatalf("failed to derive keys: %v", err)
+	}
+	encryptor, err := NewStreamEncryptor(aesKey, hmacKey)
+	if err != nil {
+		f.Fatalf("failed to create encryptor: %v", err)
+	}
+	return encryptor
+}
+
+// fuzzEncrypt 加密数据，用于生成合法的种子输入
+func fuzzEncrypt(f *testing.F, e *StreamEncryptor, plaintext []byte) []byte {
+	f.Helper()
+	var buf bytes.Buffer
+	w, err := e.WrapWriter(&buf)
+	if err != nil {
+		f.Fatalf("failed to wrap writer: %v", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		f.Fatalf("failed to write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		f.Fatalf("failed to close writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// FuzzWrapReaderWithHMAC 对恢复时读取的远端加密数据进行模糊测试
+// 任意输入都不能导致 panic；解密成功时输出长度必须等于密文数据长度
+func FuzzWrapReaderWithHMAC(f *testing.F) {
+	e := fuzzEncryptor(f)
+
+	valid := fuzzEncrypt(f, e, []byte("hello, fuzz"))
+	f.Add(valid)
+	f.Add(fuzzEncrypt(f, e, nil))
+	f.Add([]byte(Magic))
+	f.Add(valid[:HeaderSize])
+	f.Add(valid[:len(valid)-1])
+	f.Add(append([]byte("XXXX"), valid[4:]...))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r, err := e.WrapReaderWithHMAC(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		defer r.Close()
+
+		plaintext, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("read after successful verification failed: %v", err)
+		}
+		header, err := ParseHeader(data)
+		if err != nil {
+			t.Fatalf("ParseHeader() failed after successful verification: %v", err)
+		}
+		if want := len(data) - header.Size() - TrailerSize; len(plaintext) != want {
+			t.Fatalf("plaintext length = %d, want %d", len(plaintext), want)
+		}
+	})
+}
+
+// FuzzTamperedCiphertext 修改合法密文的任意一个字节（包括文件头中的 IV 和盐值）后必须校验失败
+func FuzzTamperedCiphertext(f *testing.F) {
+	e := fuzzEncryptor(f)
+
+	f.Add([]byte("some plaintext"), uint(0), byte(1))
+	f.Add([]byte("some plaintext"), uint(HeaderSize+3), byte(0x80))
+	f.Add([]byte{}, uint(HeaderSize), byte(0xff))
+
+	f.Fuzz(func(t *testing.T, plaintext []byte, pos uint, mask byte) {
+		if mask == 0 {
+			return
+		}
+
+		var buf bytes.Buffer
+		w, err := e.WrapWriter(&buf)
+		if err != nil {
+			t.Fatalf("failed to wrap writer: %v", err)
+		}
+		w.Write(plaintext)
+		w.Close()
+
+		data := buf.Bytes()
+		i := int(pos % uint(len(data)))
+		data[i] ^= mask
+
+		if _, err := e.WrapReaderWithHMAC(bytes.NewReader(data)); err == nil {
+			t.Fatalf("tampered byte %d (mask %#x) was not detected", i, mask)
+		}
+	})
+}
+
+// FuzzParseHeader 对加密文件头解析进行模糊测试，解析成功的文件头重新序列化后必须与输入一致
+func FuzzParseHeader(f *testing.F) {
+	f.Add([]byte(Magic + "0123456789abcdef"))
+	f.Add((&Header{Version: FormatVersion, KDF: KDFArgon2id, Salt: make([]byte, SaltSize), IV: make([]byte, IVSize)}).Bytes())
+	f.Add([]byte(Magic + formatTag + "\x02"))
+	f.Add([]byte(Magic))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		header, err := ParseHeader(data)
+		if err != nil {
+			return
+		}
+		if len(header.IV) != IVSize {
+			t.Fatalf("IV length = %d, want %d", len(header.IV), IVSize)
+		}
+		raw := header.Bytes()
+		if len(raw) != header.Size() {
+			t.Fatalf("Bytes() length = %d, Size() = %d", len(raw), header.Size())
+		}
+		if !bytes.Equal(raw, data[:len(raw)]) {
+			t.Fatal("header does not round-trip")
+		}
+	})
+}
+
+// FuzzDecryptAt 区间解密结果必须与完整解密的对应区间一致
+func FuzzDecryptAt(f *testing.F) {
+	e := fuzzEncryptor(f)
+
+	f.Add(bytes.Repeat([]byte("range"), 20), uint(0), uint(16))
+	f.Add(bytes.Repeat([]byte("range"), 20), uint(7), uint(33))
+	f.Add([]byte("x"), uint(0), uint(1))
+
+	f.Fuzz(func(t *testing.T, plaintext []byte, start, length uint) {
+		if len(plaintext) == 0 {
+			return
+		}
+
+		var buf bytes.Buffer
+		w, err := e.WrapWriter(&buf)
+		if err != nil {
+			t.Fatalf("failed to wrap writer: %v", err)
+		}
+		w.Write(plaintext)
+		w.Close()
+
+		data := buf.Bytes()
+		header, err := ParseHeader(data)
+		if err != nil {
+			t.Fatalf("ParseHeader() error = %v", err)
+		}
+		iv := header.IV
+		ciphertext := data[header.Size() : len(data)-TrailerSize]
+
+		from := int(start % uint(len(plaintext)))
+		to := from + int(length%uint(len(plaintext)-from+1))
+
+		dst := make([]byte, to-from)
+		if err := e.DecryptAt(iv, dst, ciphertext[from:to], int64(from)); err != nil {
+			t.Fatalf("DecryptAt() error = %v", err)
+		}
+		if !bytes.Equal(dst, plaintext[from:to]) {
+			t.Fatalf("DecryptAt(%d..%d) mismatch", from, to)
+		}
+	})
+}
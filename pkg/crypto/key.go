@@ -42,6 +42,9 @@ func DeriveKey(password string, salt []byte) (aesKey, hmacKey []byte, err error)
 }
 
 // DeriveKeyFromPasswordFile 从密码派生密钥并生成新的盐值
+//
+// Deprecated: 生成的盐值不会返回给调用方，之后无法重新派生相同的密钥。
+// 加密数据请使用 NewPasswordEncryptor，它会把盐值写入加密文件头。
 func DeriveKeyFromPasswordFile(password string) (aesKey, hmacKey []byte, err error) {
 	if password == "" {
 		return nil, nil, fmt.Errorf("password cannot be empty")
@@ -51,6 +54,15 @@ func DeriveKeyFromPasswordFile(password string) (aesKey, hmacKey []byte, err err
 	return k1, k2, err
 }
 
+// GenerateSalt 生成随机盐值
+func GenerateSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return salt, nil
+}
+
 // GenerateRandomIV 生成随机初始化向量
 func GenerateRandomIV() ([]byte, error) {
 	iv := make([]byte, IVSize)
@@ -0,0 +1,168 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// NewHeader 生成当前格式的文件头，IV 随机生成
+func (e *StreamEncryptor) NewHeader() (*Header, error) {
+	iv, err := GenerateRandomIV()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate IV: %w", err)
+	}
+	return &Header{Version: FormatVersion, KDF: e.kdf, Salt: e.salt, IV: iv}, nil
+}
+
+// KeyCheck 返回用于确认密钥的校验值，可与文件头一起保存，续传前比对以免用错误的密钥生成密文
+// 校验值是对文件头的 HMAC，不泄露密钥
+func (e *StreamEncryptor) KeyCheck(h *Header) []byte {
+	mac := hmac.New(sha512.New, e.hmacKey)
+	mac.Write([]byte("s3backup key check"))
+	mac.Write(h.Bytes())
+	return mac.Sum(nil)[:16]
+}
+
+// EncryptedReaderAt 以随机访问方式读取 src 加密后的完整文件（文件头 + 密文 + trailer）
+//
+// 密文按 CTR 计数器直接定位，读取任意区间都不需要处理之前的数据；
+// 只有第一次读取 trailer 时需要顺序读取整个 src 计算 HMAC。
+// 相同的密钥、文件头和源数据总是得到相同的输出，因此可以按偏移续传任意分块。
+//
+// 注意：同一文件头（IV）只能用于同一份源数据，源数据改变后必须生成新的文件头。
+type EncryptedReaderAt struct {
+	e      *StreamEncryptor
+	src    io.ReaderAt
+	size   int64
+	header []byte
+	iv     []byte
+
+	once       sync.Once
+	trailer    []byte
+	trailerErr error
+}
+
+// NewReaderAt 创建随机访问的加密读取器，size 为 src 的字节数
+func (e *StreamEncryptor) NewReaderAt(src io.ReaderAt, size int64, h *Header) (*EncryptedReaderAt, error) {
+	if h.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported format version: %d", h.Version)
+	}
+	if h.KDF != e.kdf {
+		return nil, fmt.Errorf("header key derivation %s does not match encryptor %s", h.KDF, e.kdf)
+	}
+	if len(h.IV) != IVSize {
+		return nil, fmt.Errorf("invalid IV size: expected %d, got %d", IVSize, len(h.IV))
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid size: %d", size)
+	}
+
+	return &EncryptedReaderAt{
+		e:      e,
+		src:    src,
+		size:   size,
+		header: h.Bytes(),
+		iv:     h.IV,
+	}, nil
+}
+
+// Size 返回加密后的总字节数
+func (r *EncryptedReaderAt) Size() int64 {
+	return int64(len(r.header)) + r.size + TrailerSize
+}
+
+// ReadAt 读取加密文件中从 off 开始的数据
+func (r *EncryptedReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("invalid offset: %d", off)
+	}
+
+	headerSize := int64(len(r.header))
+	dataEnd := headerSize + r.size
+	total := r.Size()
+
+	n := 0
+	for n < len(p) && off < total {
+		var c int
+		switch {
+		case off < headerSize:
+			c = copy(p[n:], r.header[off:])
+
+		case off < dataEnd:
+			dataOff := off - headerSize
+			c = int(min(int64(len(p)-n), r.size-dataOff))
+			buf := p[n : n+c]
+			m, err := r.src.ReadAt(buf, dataOff)
+			if m < c {
+				if err == nil || err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return n, fmt.Errorf("failed to read source at offset %d: %w", dataOff, err)
+			}
+			if err := r.e.DecryptAt(r.iv, buf, buf, dataOff); err != nil {
+				return n, err
+			}
+
+		default:
+			trailer, err := r.trailerBytes()
+			if err != nil {
+				return n, err
+			}
+			c = copy(p[n:], trailer[off-dataEnd:])
+		}
+		n += c
+		off += int64(c)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// trailerBytes 顺序读取整个源数据计算 trailer，结果会被缓存
+func (r *EncryptedReaderAt) trailerBytes() ([]byte, error) {
+	r.once.Do(func() {
+		block, err := aes.NewCipher(r.e.aesKey)
+		if err != nil {
+			r.trailerErr = fmt.Errorf("failed to create AES cipher: %w", err)
+			return
+		}
+		stream := cipher.NewCTR(block, r.iv)
+		mac := hmac.New(sha512.New, r.e.hmacKey)
+		mac.Write(r.header)
+
+		buf := make([]byte, 32*1024)
+		section := io.NewSectionReader(r.src, 0, r.size)
+		var read int64
+		for {
+			n, err := section.Read(buf)
+			stream.XORKeyStream(buf[:n], buf[:n])
+			mac.Write(buf[:n])
+			read += int64(n)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				r.trailerErr = fmt.Errorf("failed to read source: %w", err)
+				return
+			}
+		}
+		if read != r.size {
+			r.trailerErr = fmt.Errorf("source size changed: expected %d bytes, got %d", r.size, read)
+			return
+		}
+
+		trailer := make([]byte, 8, TrailerSize)
+		binary.BigEndian.PutUint64(trailer, uint64(r.size))
+		mac.Write(trailer)
+		r.trailer = mac.Sum(trailer)
+	})
+	return r.trailer, r.trailerErr
+}
@@ -0,0 +1,122 @@
+package crypto
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// TestEncryptedReaderAt 测试随机访问读取的结果可以正常解密，且分段读取与整体读取一致
+func TestEncryptedReaderAt(t *testing.T) {
+	e, err := NewPasswordEncryptor("pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := e.NewHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{0, 1, 15, 16, 17, 100000} {
+		plaintext := make([]byte, size)
+		rand.New(rand.NewSource(int64(size))).Read(plaintext)
+
+		r, err := e.NewReaderAt(bytes.NewReader(plaintext), int64(size), h)
+		if err != nil {
+			t.Fatalf("NewReaderAt() error = %v", err)
+		}
+		full, err := io.ReadAll(io.NewSectionReader(r, 0, r.Size()))
+		if err != nil {
+			t.Fatalf("size %d: read error = %v", size, err)
+		}
+		if int64(len(full)) != r.Size() {
+			t.Fatalf("size %d: read %d bytes, want %d", size, len(full), r.Size())
+		}
+
+		dr, _, err := OpenReader(bytes.NewReader(full), KeySource{Password: "pw"})
+		if err != nil {
+			t.Fatalf("size %d: OpenReader() error = %v", size, err)
+		}
+		got, err := io.ReadAll(dr)
+		if err != nil {
+			t.Fatalf("size %d: decrypt error = %v", size, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("size %d: decrypted data mismatch", size)
+		}
+
+		// 以新的读取器按任意区间读取，结果一致
+		r, _ = e.NewReaderAt(bytes.NewReader(plaintext), int64(size), h)
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 50; i++ {
+			off := rng.Int63n(r.Size())
+			buf := make([]byte, rng.Intn(int(r.Size()-off))+1)
+			n, err := r.ReadAt(buf, off)
+			if err != nil && err != io.EOF {
+				t.Fatalf("size %d: ReadAt(%d) error = %v", size, off, err)
+			}
+			if !bytes.Equal(buf[:n], full[off:off+int64(n)]) {
+				t.Fatalf("size %d: ReadAt(%d, %d) mismatch", size, off, len(buf))
+			}
+		}
+	}
+}
+
+// TestEncryptedReaderAtSourceChanged 测试源数据变短时计算 trailer 失败
+func TestEncryptedReaderAtSourceChanged(t *testing.T) {
+	keyFile, _ := GenerateKeyFile()
+	aesKey, hmacKey, _ := DeriveKeyFromKeyFile(keyFile)
+	e, _ := NewStreamEncryptor(aesKey, hmacKey)
+	h, _ := e.NewHeader()
+
+	r, _ := e.NewReaderAt(bytes.NewReader(make([]byte, 100)), 200, h)
+	if _, err := io.ReadAll(io.NewSectionReader(r, 0, r.Size())); err == nil {
+		t.Error("expected error when source is shorter than declared size")
+	}
+
+	// 密码文件头不能用于密钥文件加密器
+	pe, _ := NewPasswordEncryptor("pw")
+	ph, _ := pe.NewHeader()
+	if _, err := e.NewReaderAt(bytes.NewReader(nil), 0, ph); err == nil {
+		t.Error("expected error for mismatched key derivation")
+	}
+}
+
+// TestWrapWriterWithHeader 测试使用同一文件头流式加密的输出与随机访问读取器一致，可以重新生成
+func TestWrapWriterWithHeader(t *testing.T) {
+	e, err := NewPasswordEncryptor("pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := e.NewHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(plaintext)
+
+	var streamed bytes.Buffer
+	w, err := e.WrapWriterWithHeader(&streamed, h)
+	if err != nil {
+		t.Fatalf("WrapWriterWithHeader() error = %v", err)
+	}
+	w.Write(plaintext)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	r, err := e.NewReaderAt(bytes.NewReader(plaintext), int64(len(plaintext)), h)
+	if err != nil {
+		t.Fatalf("NewReaderAt() error = %v", err)
+	}
+	full, _ := io.ReadAll(io.NewSectionReader(r, 0, r.Size()))
+	if !bytes.Equal(streamed.Bytes(), full) {
+		t.Error("streamed output differs from NewReaderAt output with the same header")
+	}
+
+	other, _ := NewPasswordEncryptor("pw")
+	if _, err := other.WrapWriterWithHeader(io.Discard, &Header{Version: FormatVersion, KDF: KDFNone, IV: h.IV}); err == nil {
+		t.Error("WrapWriterWithHeader() accepted a header with a different key derivation")
+	}
+}
@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// localKeyContext 派生本地文件加密密钥时使用的前缀，使其与加密备份的密钥互相独立
+const localKeyContext = "s3backup-local-v1"
+
+// SealedPrefix 加密字符串的前缀，用于识别配置文件中的加密值
+const SealedPrefix = "enc:v1:"
+
+// SealString 使用口令加密短字符串（如配置文件中的凭证）
+// 格式: enc:v1:base64([32 bytes salt][12 bytes nonce][AES-256-GCM ciphertext])
+func SealString(passphrase, plaintext string) (string, error) {
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := newSealGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nil, nonce, []byte(plaintext), nil)
+
+	buf := make([]byte, 0, len(salt)+len(nonce)+len(sealed))
+	buf = append(buf, salt...)
+	buf = append(buf, nonce...)
+	buf = append(buf, sealed...)
+
+	return SealedPrefix + base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// OpenString 解密 SealString 生成的字符串
+func OpenString(passphrase, sealed string) (string, error) {
+	if !IsSealed(sealed) {
+		return "", fmt.Errorf("value is not sealed")
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, SealedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode sealed value: %w", err)
+	}
+
+	if len(data) < SaltSize {
+		return "", fmt.Errorf("invalid sealed value: too short")
+	}
+	salt := data[:SaltSize]
+
+	gcm, err := newSealGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < SaltSize+gcm.NonceSize()+gcm.Overhead() {
+		return "", fmt.Errorf("invalid sealed value: too short")
+	}
+	nonce := data[SaltSize : SaltSize+gcm.NonceSize()]
+	ciphertext := data[SaltSize+gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt sealed value: wrong passphrase or corrupted data")
+	}
+
+	return string(plaintext), nil
+}
+
+// IsSealed 判断字符串是否为 SealString 生成的加密值
+func IsSealed(s string) bool {
+	return strings.HasPrefix(s, SealedPrefix)
+}
+
+// newSealGCM 从口令和盐值派生 AES-GCM
+func newSealGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	aesKey, _, err := DeriveKeyWithCustomSalt(passphrase, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// NewLocalGCM 从备份密钥和盐值派生 AES-GCM，用于加密续传状态等本地文件
+// 使用密钥文件时由其中的 AES 密钥派生，使用密码时先经 Argon2id 派生，调用方应缓存结果
+func NewLocalGCM(keys KeySource, salt []byte) (cipher.AEAD, error) {
+	var secret []byte
+	switch {
+	case keys.KeyFile != nil:
+		aesKey, _, err := DeriveKeyFromKeyFile(keys.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		secret = aesKey
+	case keys.Password != "":
+		aesKey, _, err := DeriveKey(keys.Password, salt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+		secret = aesKey
+	default:
+		return nil, fmt.Errorf("encryption password or key_file is required")
+	}
+
+	h := sha256.New()
+	h.Write([]byte(localKeyContext))
+	h.Write(secret)
+	h.Write(salt)
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
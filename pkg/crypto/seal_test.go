@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSealOpenRoundtrip 测试字符串加密解密往返
+func TestSealOpenRoundtrip(t *testing.T) {
+	sealed, err := SealString("passphrase", "my-secret-key")
+	if err != nil {
+		t.Fatalf("SealString() error = %v", err)
+	}
+
+	if !IsSealed(sealed) {
+		t.Errorf("sealed value should have prefix %q, got %q", SealedPrefix, sealed)
+	}
+	if strings.Contains(sealed, "my-secret-key") {
+		t.Error("sealed value should not contain plaintext")
+	}
+
+	opened, err := OpenString("passphrase", sealed)
+	if err != nil {
+		t.Fatalf("OpenString() error = %v", err)
+	}
+	if opened != "my-secret-key" {
+		t.Errorf("expected 'my-secret-key', got %q", opened)
+	}
+}
+
+// TestOpenStringWrongPassphrase 测试错误口令
+func TestOpenStringWrongPassphrase(t *testing.T) {
+	sealed, err := SealString("correct", "secret")
+	if err != nil {
+		t.Fatalf("SealString() error = %v", err)
+	}
+
+	if _, err := OpenString("wrong", sealed); err == nil {
+		t.Error("expected error with wrong passphrase")
+	}
+}
+
+// TestOpenStringInvalid 测试无效输入
+func TestOpenStringInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not sealed", "plain-value"},
+		{"invalid base64", SealedPrefix + "!!!"},
+		{"too short", SealedPrefix + "AAAA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := OpenString("passphrase", tt.value); err == nil {
+				t.Errorf("expected error for %q", tt.value)
+			}
+		})
+	}
+}
+
+// TestSealStringEmptyPassphrase 测试空口令
+func TestSealStringEmptyPassphrase(t *testing.T) {
+	if _, err := SealString("", "secret"); err == nil {
+		t.Error("expected error with empty passphrase")
+	}
+}
@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+)
+
+// CounterIV 从基础 IV 派生第 blockIndex 个 AES 块的计数器值
+// 与 crypto/cipher 的 CTR 实现一致：整个 16 字节按大端序整数递增并回绕
+func CounterIV(iv []byte, blockIndex uint64) []byte {
+	counter := make([]byte, len(iv))
+	copy(counter, iv)
+
+	carry := blockIndex
+	for i := len(counter) - 1; i >= 0 && carry > 0; i-- {
+		sum := uint64(counter[i]) + carry&0xff
+		counter[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+	return counter
+}
+
+// DecryptAt 解密从密文数据偏移 offset 处开始的一段数据
+// offset 相对于密文数据起点（即文件偏移减去 HeaderSize），无需从头读取整个流，
+// 可用于基于 Range GET 的部分恢复。
+//
+// 注意：HMAC 覆盖整个密文流，区间解密无法单独校验完整性。
+func (e *StreamEncryptor) DecryptAt(iv, dst, src []byte, offset int64) error {
+	if len(iv) != IVSize {
+		return fmt.Errorf("invalid IV size: expected %d, got %d", IVSize, len(iv))
+	}
+	if offset < 0 {
+		return fmt.Errorf("invalid offset: %d", offset)
+	}
+	if len(dst) < len(src) {
+		return fmt.Errorf("destination buffer too small: need %d, got %d", len(src), len(dst))
+	}
+
+	block, err := aes.NewCipher(e.aesKey)
+	if err != nil {
+		return fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	stream := cipher.NewCTR(block, CounterIV(iv, uint64(offset)/aes.BlockSize))
+
+	// 偏移未按块对齐时，丢弃块内前 skip 字节的密钥流
+	if skip := int(offset % aes.BlockSize); skip > 0 {
+		var discard [aes.BlockSize]byte
+		stream.XORKeyStream(discard[:skip], discard[:skip])
+	}
+
+	stream.XORKeyStream(dst, src)
+	return nil
+}
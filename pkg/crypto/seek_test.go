@@ -0,0 +1,91 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+// TestDecryptAt 测试任意偏移的区间解密与流式解密结果一致
+func TestDecryptAt(t *testing.T) {
+	aesKey, hmacKey, err := DeriveKey("range-password", nil)
+	if err != nil {
+		t.Fatalf("failed to derive key: %v", err)
+	}
+	encryptor, err := NewStreamEncryptor(aesKey, hmacKey)
+	if err != nil {
+		t.Fatalf("failed to create encryptor: %v", err)
+	}
+
+	plaintext := make([]byte, 10000)
+	rand.Read(plaintext)
+
+	var buf bytes.Buffer
+	w, err := encryptor.WrapWriter(&buf)
+	if err != nil {
+		t.Fatalf("failed to wrap writer: %v", err)
+	}
+	w.Write(plaintext)
+	w.Close()
+
+	encrypted := buf.Bytes()
+	header, err := ParseHeader(encrypted)
+	if err != nil {
+		t.Fatalf("ParseHeader() error = %v", err)
+	}
+	iv := header.IV
+	ciphertext := encrypted[header.Size() : len(encrypted)-TrailerSize]
+
+	ranges := []struct{ start, end int }{
+		{0, 16},
+		{0, len(plaintext)},
+		{16, 48},
+		{5, 37},
+		{4095, 4200},
+		{len(plaintext) - 3, len(plaintext)},
+	}
+
+	for _, r := range ranges {
+		dst := make([]byte, r.end-r.start)
+		if err := encryptor.DecryptAt(iv, dst, ciphertext[r.start:r.end], int64(r.start)); err != nil {
+			t.Fatalf("DecryptAt(%d, %d) error = %v", r.start, r.end, err)
+		}
+		if !bytes.Equal(dst, plaintext[r.start:r.end]) {
+			t.Errorf("DecryptAt(%d, %d) mismatch", r.start, r.end)
+		}
+	}
+}
+
+// TestCounterIVCarry 测试计数器进位和回绕
+func TestCounterIVCarry(t *testing.T) {
+	iv := bytes.Repeat([]byte{0xff}, IVSize)
+	iv[0] = 0x00
+
+	got := CounterIV(iv, 1)
+	want := make([]byte, IVSize)
+	want[0] = 0x01
+	if !bytes.Equal(got, want) {
+		t.Errorf("CounterIV carry = %x, want %x", got, want)
+	}
+
+	// 全 0xff 时回绕为 0
+	wrapped := CounterIV(bytes.Repeat([]byte{0xff}, IVSize), 1)
+	if !bytes.Equal(wrapped, make([]byte, IVSize)) {
+		t.Errorf("CounterIV wrap = %x, want zero", wrapped)
+	}
+
+	// 原 IV 不应被修改
+	if iv[0] != 0x00 {
+		t.Error("CounterIV should not modify input IV")
+	}
+}
+
+// TestParseHeaderInvalid 测试无效文件头
+func TestParseHeaderInvalid(t *testing.T) {
+	if _, err := ParseHeader([]byte("S3BE")); err == nil {
+		t.Error("expected error for short header")
+	}
+	if _, err := ParseHeader(append([]byte("XXXX"), make([]byte, IVSize)...)); err == nil {
+		t.Error("expected error for invalid magic")
+	}
+}
@@ -0,0 +1,125 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SignatureSuffix 分离签名对象的后缀，签名与备份同名存放
+const SignatureSuffix = ".sig"
+
+// signatureContext 签名消息的前缀，避免签名被用于其他用途
+const signatureContext = "s3backup-signature-v1:sha256:"
+
+// ErrBadSignature 签名与数据或公钥不匹配
+var ErrBadSignature = errors.New("signature verification failed: backup may be tampered or signed by another key")
+
+// Signature 备份的分离签名，对备份对象的 SHA-256 进行 Ed25519 签名
+type Signature struct {
+	Version   int    `json:"version"`
+	Algorithm string `json:"algorithm"`
+	SHA256    string `json:"sha256"`
+	KeyID     string `json:"key_id"`
+	Signature []byte `json:"signature"`
+}
+
+// LoadSigningKey 读取 PEM 编码（PKCS#8）的 Ed25519 私钥
+// 可使用 openssl genpkey -algorithm ed25519 -out sign.key 生成
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an Ed25519 key", path)
+	}
+	return priv, nil
+}
+
+// LoadVerifyKey 读取 PEM 编码（PKIX）的 Ed25519 公钥
+// 可使用 openssl pkey -in sign.key -pubout -out sign.pub 从私钥导出
+func LoadVerifyKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an Ed25519 key", path)
+	}
+	return pub, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key file %s is not PEM encoded", path)
+	}
+	return block, nil
+}
+
+// KeyID 返回公钥的短标识（公钥 SHA-256 的前 8 字节），用于区分签名使用的密钥
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SignDigest 使用 priv 对 SHA-256 摘要签名
+func SignDigest(priv ed25519.PrivateKey, digest []byte) *Signature {
+	sum := hex.EncodeToString(digest)
+	return &Signature{
+		Version:   1,
+		Algorithm: "ed25519",
+		SHA256:    sum,
+		KeyID:     KeyID(priv.Public().(ed25519.PublicKey)),
+		Signature: ed25519.Sign(priv, []byte(signatureContext+sum)),
+	}
+}
+
+// Verify 使用 pub 验证签名，并检查签名的摘要与 digest 一致
+func (s *Signature) Verify(pub ed25519.PublicKey, digest []byte) error {
+	if s.Version != 1 || s.Algorithm != "ed25519" {
+		return fmt.Errorf("unsupported signature version %d (%s)", s.Version, s.Algorithm)
+	}
+	if !ed25519.Verify(pub, []byte(signatureContext+s.SHA256), s.Signature) {
+		return ErrBadSignature
+	}
+	if s.SHA256 != hex.EncodeToString(digest) {
+		return fmt.Errorf("%w: sha256 mismatch", ErrBadSignature)
+	}
+	return nil
+}
+
+// Marshal 将签名编码为 JSON
+func (s *Signature) Marshal() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// ParseSignature 解析 Marshal 生成的签名
+func ParseSignature(data []byte) (*Signature, error) {
+	var s Signature
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,95 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSigningKeys 生成 Ed25519 密钥对并以 PEM 格式写入 dir，返回私钥和公钥路径
+func writeSigningKeys(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	privPath := filepath.Join(dir, name+".key")
+	pubPath := filepath.Join(dir, name+".pub")
+	os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600)
+	os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644)
+	return privPath, pubPath
+}
+
+// TestSignVerify 测试签名、编码和验证往返
+func TestSignVerify(t *testing.T) {
+	dir := t.TempDir()
+	privPath, pubPath := writeSigningKeys(t, dir, "sign")
+	_, otherPub := writeSigningKeys(t, dir, "other")
+
+	priv, err := LoadSigningKey(privPath)
+	if err != nil {
+		t.Fatalf("LoadSigningKey() error = %v", err)
+	}
+	pub, err := LoadVerifyKey(pubPath)
+	if err != nil {
+		t.Fatalf("LoadVerifyKey() error = %v", err)
+	}
+
+	digest := sha256.Sum256([]byte("backup data"))
+	data, err := SignDigest(priv, digest[:]).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := ParseSignature(data)
+	if err != nil {
+		t.Fatalf("ParseSignature() error = %v", err)
+	}
+	if sig.KeyID != KeyID(pub) {
+		t.Errorf("KeyID = %s, want %s", sig.KeyID, KeyID(pub))
+	}
+	if err := sig.Verify(pub, digest[:]); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	// 数据被修改
+	tampered := sha256.Sum256([]byte("tampered data"))
+	if err := sig.Verify(pub, tampered[:]); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for tampered data, got %v", err)
+	}
+
+	// 签名中的摘要被替换
+	forged := *sig
+	forged.SHA256 = "00" + sig.SHA256[2:]
+	if err := forged.Verify(pub, tampered[:]); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for forged digest, got %v", err)
+	}
+
+	// 其他密钥
+	other, _ := LoadVerifyKey(otherPub)
+	if err := sig.Verify(other, digest[:]); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for another key, got %v", err)
+	}
+}
+
+// TestLoadSigningKeyErrors 测试读取格式错误的密钥
+func TestLoadSigningKeyErrors(t *testing.T) {
+	dir := t.TempDir()
+	_, pubPath := writeSigningKeys(t, dir, "sign")
+
+	if _, err := LoadSigningKey(pubPath); err == nil {
+		t.Error("expected error when loading a public key as signing key")
+	}
+	notPEM := filepath.Join(dir, "plain.key")
+	os.WriteFile(notPEM, []byte("not a key"), 0600)
+	if _, err := LoadVerifyKey(notPEM); err == nil {
+		t.Error("expected error for non-PEM key file")
+	}
+}
@@ -16,9 +16,12 @@ import (
 type StreamEncryptor struct {
 	aesKey  []byte
 	hmacKey []byte
+	kdf     KDF    // 写入文件头的密钥派生方式
+	salt    []byte // KDFArgon2id 使用的盐值
 }
 
-// NewStreamEncryptor 创建流式加密器
+// NewStreamEncryptor 使用已有密钥（如密钥文件）创建流式加密器
+// 从密码加密时请使用 NewPasswordEncryptor，以便把盐值写入文件头
 func NewStreamEncryptor(aesKey, hmacKey []byte) (*StreamEncryptor, error) {
 	if len(aesKey) != AESKeySize {
 		return nil, fmt.Errorf("invalid AES key size: expected %d, got %d", AESKeySize, len(aesKey))
@@ -33,6 +36,9 @@ func NewStreamEncryptor(aesKey, hmacKey []byte) (*StreamEncryptor, error) {
 	}, nil
 }
 
+// scratchSize EncryptWriter 加密缓冲区的大小，更大的写入分段加密
+const scratchSize = 64 * 1024
+
 // EncryptWriter 加密写入器
 type EncryptWriter struct {
 	iv       []byte
@@ -41,22 +47,39 @@ type EncryptWriter struct {
 	hmac     hash.Hash
 	writer   io.Writer
 	position int64
+	scratch  []byte // 复用的密文缓冲区，调用方的 p 不能原地加密
 }
 
-// WrapWriter 包装一个 writer 为加密写入器
-// 文件格式: [4 bytes magic][16 bytes IV][encrypted data...][8 bytes data length][64 bytes HMAC]
+// WrapWriter 包装一个 writer 为加密写入器，输出当前格式（见 format.go）
 func (e *StreamEncryptor) WrapWriter(w io.Writer) (io.WriteCloser, error) {
-	// 创建 AES 块
-	block, err := aes.NewCipher(e.aesKey)
+	// 生成带随机 IV 的文件头
+	h, err := e.NewHeader()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+		return nil, err
+	}
+	return e.WrapWriterWithHeader(w, h)
+}
+
+// WrapWriterWithHeader 使用指定的文件头（由 NewHeader 生成并保存）包装加密写入器
+// 相同的密钥、文件头和明文总是得到相同的输出，流式备份续传时据此重新生成已上传的密文；
+// 同一文件头（IV）只能用于同一份明文
+func (e *StreamEncryptor) WrapWriterWithHeader(w io.Writer, h *Header) (io.WriteCloser, error) {
+	if h.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported format version: %d", h.Version)
+	}
+	if h.KDF != e.kdf {
+		return nil, fmt.Errorf("header key derivation %s does not match encryptor %s", h.KDF, e.kdf)
+	}
+	if len(h.IV) != IVSize {
+		return nil, fmt.Errorf("invalid IV size: expected %d, got %d", IVSize, len(h.IV))
 	}
 
-	// 生成随机 IV
-	iv, err := GenerateRandomIV()
+	// 创建 AES 块
+	block, err := aes.NewCipher(e.aesKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate IV: %w", err)
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
 	}
+	iv := h.IV
 
 	// 创建 CTR 流
 	stream := cipher.NewCTR(block, iv)
@@ -64,14 +87,12 @@ func (e *StreamEncryptor) WrapWriter(w io.Writer) (io.WriteCloser, error) {
 	// 创建 HMAC
 	hmac := hmac.New(sha512.New, e.hmacKey)
 
-	// 写入魔数和 IV
-	magic := []byte("S3BE") // S3Backup Encryption
-	if _, err := w.Write(magic); err != nil {
-		return nil, fmt.Errorf("failed to write magic: %w", err)
-	}
-	if _, err := w.Write(iv); err != nil {
-		return nil, fmt.Errorf("failed to write IV: %w", err)
+	// 写入文件头，文件头同样受 HMAC 保护
+	header := h.Bytes()
+	if _, err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
 	}
+	hmac.Write(header)
 
 	return &EncryptWriter{
 		iv:       iv,
@@ -84,36 +105,37 @@ func (e *StreamEncryptor) WrapWriter(w io.Writer) (io.WriteCloser, error) {
 }
 
 // Write 写入数据并加密
+// 密文写入复用的缓冲区，超过 scratchSize 的数据分段处理，不随写入次数分配内存
 func (ew *EncryptWriter) Write(p []byte) (int, error) {
-	if len(p) == 0 {
-		return 0, nil
+	written := 0
+	for len(p) > 0 {
+		size := min(len(p), scratchSize)
+		if len(ew.scratch) < size {
+			ew.scratch = make([]byte, size)
+		}
+		encrypted := ew.scratch[:size]
+		ew.stream.XORKeyStream(encrypted, p[:len(encrypted)])
+		ew.hmac.Write(encrypted)
+
+		n, err := ew.writer.Write(encrypted)
+		written += n
+		ew.position += int64(n)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
 	}
-
-	// 加密数据
-	encrypted := make([]byte, len(p))
-	ew.stream.XORKeyStream(encrypted, p)
-
-	// 更新 HMAC
-	ew.hmac.Write(encrypted)
-
-	// 写入加密数据
-	n, err := ew.writer.Write(encrypted)
-	if err != nil {
-		return n, err
-	}
-
-	ew.position += int64(n)
-	return n, nil
+	return written, nil
 }
 
 // Close 关闭写入器并写入 HMAC
 func (ew *EncryptWriter) Close() error {
 	// 写入数据长度（8字节，大端序）
-	lengthBytes := make([]byte, 8)
-	binary.BigEndian.PutUint64(lengthBytes, uint64(ew.position))
+	lengthBytes := binary.BigEndian.AppendUint64(nil, uint64(ew.position))
 	if _, err := ew.writer.Write(lengthBytes); err != nil {
 		return fmt.Errorf("failed to write data length: %w", err)
 	}
+	ew.hmac.Write(lengthBytes)
 
 	// 写入 HMAC
 	hmac := ew.hmac.Sum(nil)
@@ -133,25 +155,16 @@ type DecryptReader struct {
 	reader   io.Reader
 	position int64
 	total    int64
-	buffer   []byte
 }
 
 // WrapReader 包装一个 reader 为解密读取器
 func (e *StreamEncryptor) WrapReader(r io.Reader) (io.Reader, error) {
-	// 读取魔数和 IV
-	header := make([]byte, 4+IVSize)
-	if _, err := io.ReadFull(r, header); err != nil {
-		return nil, fmt.Errorf("failed to read header: %w", err)
-	}
-
-	// 验证魔数
-	magic := header[:4]
-	if string(magic) != "S3BE" {
-		return nil, fmt.Errorf("invalid magic: %s", string(magic))
+	// 读取文件头
+	header, err := ReadHeader(r)
+	if err != nil {
+		return nil, err
 	}
-
-	// 读取 IV
-	iv := header[4:]
+	iv := header.IV
 
 	// 创建 AES 块
 	block, err := aes.NewCipher(e.aesKey)
@@ -173,7 +186,6 @@ func (e *StreamEncryptor) WrapReader(r io.Reader) (io.Reader, error) {
 		reader:   r,
 		position: 0,
 		total:    0,
-		buffer:   make([]byte, 32*1024), // 32KB 缓冲区
 	}, nil
 }
 
@@ -183,8 +195,8 @@ func (dr *DecryptReader) Read(p []byte) (int, error) {
 		return 0, nil
 	}
 
-	// 从底层 reader 读取数据
-	n, err := dr.reader.Read(dr.buffer)
+	// 密文直接读入 p，先更新 HMAC 再原地解密
+	n, err := dr.reader.Read(p)
 	if err != nil && err != io.EOF {
 		return 0, err
 	}
@@ -193,15 +205,8 @@ func (dr *DecryptReader) Read(p []byte) (int, error) {
 		return 0, io.EOF
 	}
 
-	// 解密数据
-	decrypted := make([]byte, n)
-	dr.stream.XORKeyStream(decrypted, dr.buffer[:n])
-
-	// 更新 HMAC
-	dr.hmac.Write(dr.buffer[:n])
-
-	// 复制到输出
-	copy(p, decrypted)
+	dr.hmac.Write(p[:n])
+	dr.stream.XORKeyStream(p[:n], p[:n])
 	dr.position += int64(n)
 
 	return n, err
@@ -216,27 +221,17 @@ func (e *StreamEncryptor) VerifyHMAC(r io.Reader, expectedHMAC []byte) error {
 	return fmt.Errorf("VerifyHMAC is deprecated, use WrapReaderWithHMAC instead")
 }
 
-// WrapReaderWithHMAC 包装 reader 并验证 HMAC
-// 文件格式: [4 bytes magic][16 bytes IV][encrypted data...][8 bytes data length][64 bytes HMAC]
+// WrapReaderWithHMAC 包装 reader 并验证 HMAC，支持 v1 和 v2 格式（见 format.go）
 //
 // 注意：此实现将所有加密数据读入内存进行解析和验证。
 // 对于非常大的文件（GB级别），这会消耗大量内存。
 func (e *StreamEncryptor) WrapReaderWithHMAC(r io.Reader) (io.ReadCloser, error) {
-	// 读取魔数和 IV
-	header := make([]byte, 4+IVSize)
-	if _, err := io.ReadFull(r, header); err != nil {
-		return nil, fmt.Errorf("failed to read header: %w", err)
-	}
-
-	// 验证魔数
-	magic := header[:4]
-	if string(magic) != "S3BE" {
-		return nil, fmt.Errorf("invalid magic: %s", string(magic))
+	// 读取文件头
+	header, err := ReadHeader(r)
+	if err != nil {
+		return nil, err
 	}
 
-	// 读取 IV
-	iv := header[4:]
-
 	// 创建 AES 块
 	block, err := aes.NewCipher(e.aesKey)
 	if err != nil {
@@ -244,7 +239,7 @@ func (e *StreamEncryptor) WrapReaderWithHMAC(r io.Reader) (io.ReadCloser, error)
 	}
 
 	// 创建 CTR 流
-	stream := cipher.NewCTR(block, iv)
+	stream := cipher.NewCTR(block, header.IV)
 
 	// 读取所有剩余数据（加密数据 + trailer）
 	encryptedData, err := io.ReadAll(r)
@@ -252,14 +247,13 @@ func (e *StreamEncryptor) WrapReaderWithHMAC(r io.Reader) (io.ReadCloser, error)
 		return nil, fmt.Errorf("failed to read encrypted data: %w", err)
 	}
 
-	// 检查最小长度（至少需要 trailerSize 字节）
-	const trailerSize = 8 + 64 // data length + HMAC
-	if len(encryptedData) < trailerSize {
-		return nil, fmt.Errorf("invalid encrypted data: too short (got %d bytes, need at least %d)", len(encryptedData), trailerSize)
+	// 检查最小长度（至少需要 TrailerSize 字节）
+	if len(encryptedData) < TrailerSize {
+		return nil, fmt.Errorf("invalid encrypted data: too short (got %d bytes, need at least %d)", len(encryptedData), TrailerSize)
 	}
 
 	// 从末尾解析 trailer
-	trailerOffset := len(encryptedData) - trailerSize
+	trailerOffset := len(encryptedData) - TrailerSize
 	dataLength := int64(binary.BigEndian.Uint64(encryptedData[trailerOffset : trailerOffset+8]))
 	expectedHMAC := encryptedData[trailerOffset+8:]
 	actualEncryptedData := encryptedData[:trailerOffset]
@@ -269,13 +263,19 @@ func (e *StreamEncryptor) WrapReaderWithHMAC(r io.Reader) (io.ReadCloser, error)
 		return nil, fmt.Errorf("data length mismatch: header says %d, but got %d bytes", dataLength, len(actualEncryptedData))
 	}
 
-	// 计算并验证 HMAC
+	// 计算并验证 HMAC（v1 只覆盖密文数据，v2 还覆盖文件头和数据长度）
 	hmacCalc := hmac.New(sha512.New, e.hmacKey)
+	if header.Version != LegacyFormatVersion {
+		hmacCalc.Write(header.Bytes())
+	}
 	hmacCalc.Write(actualEncryptedData)
+	if header.Version != LegacyFormatVersion {
+		hmacCalc.Write(encryptedData[trailerOffset : trailerOffset+8])
+	}
 	actualHMAC := hmacCalc.Sum(nil)
 
 	if !hmac.Equal(actualHMAC, expectedHMAC) {
-		return nil, fmt.Errorf("HMAC verification failed: data may be corrupted or tampered")
+		return nil, ErrHMACMismatch
 	}
 
 	// 解密数据
@@ -400,3 +400,162 @@ func TestVerifyHMACDeprecated(t *testing.T) {
 		t.Errorf("expected 'deprecated' error, got: %v", err)
 	}
 }
+
+// zeroReader 无限返回零字节的 reader
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// newTestEncryptor 使用固定密钥创建加密器，避免每个测试都执行密钥派生
+func newTestEncryptor(t testing.TB) *StreamEncryptor {
+	t.Helper()
+	e, err := NewStreamEncryptor(bytes.Repeat([]byte{1}, AESKeySize), bytes.Repeat([]byte{2}, HMACKeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// TestStreamAllocs 测试加密写入和解密读取不随 Write/Read 次数分配内存
+func TestStreamAllocs(t *testing.T) {
+	e := newTestEncryptor(t)
+	p := make([]byte, 32*1024)
+
+	w, err := e.WrapWriter(io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write(p)
+	if allocs := testing.AllocsPerRun(100, func() { _, _ = w.Write(p) }); allocs != 0 {
+		t.Errorf("EncryptWriter.Write allocs = %v, want 0", allocs)
+	}
+
+	h, err := e.NewHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := e.WrapReader(io.MultiReader(bytes.NewReader(h.Bytes()), zeroReader{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { _, _ = r.Read(p) }); allocs != 0 {
+		t.Errorf("DecryptReader.Read allocs = %v, want 0", allocs)
+	}
+
+	v, err := e.newVerifyingReader(zeroReader{}, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { _, _ = v.Read(p) }); allocs != 0 {
+		t.Errorf("verifyingReader.Read allocs = %v, want 0", allocs)
+	}
+}
+
+// TestStreamChunking 测试超过加密缓冲区的单次写入和小缓冲区读取
+func TestStreamChunking(t *testing.T) {
+	e := newTestEncryptor(t)
+	data := make([]byte, 3*scratchSize+123)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	var buf bytes.Buffer
+	w, err := e.WrapWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := w.Write(data); err != nil || n != len(data) {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	encrypted := buf.Bytes()
+
+	// 流式校验读取器每次只读 100 字节
+	h, err := ReadHeader(bytes.NewReader(encrypted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := e.newVerifyingReader(bytes.NewReader(encrypted[h.Size():]), h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	small := make([]byte, 100)
+	for {
+		n, err := v.Read(small)
+		got.Write(small[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+	}
+	if !bytes.Equal(got.Bytes(), data) {
+		t.Error("verifying reader output differs from the input")
+	}
+
+	// 不校验 HMAC 的解密读取器同样不丢弃超出 p 的数据
+	dr, err := e.WrapReader(bytes.NewReader(encrypted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Reset()
+	for got.Len() < len(data) {
+		n, err := dr.Read(small)
+		got.Write(small[:n])
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+	}
+	if !bytes.Equal(got.Bytes()[:len(data)], data) {
+		t.Error("decrypt reader output differs from the input")
+	}
+}
+
+// BenchmarkEncryptWriter 基准测试加密写入吞吐量
+func BenchmarkEncryptWriter(b *testing.B) {
+	e := newTestEncryptor(b)
+	w, err := e.WrapWriter(io.Discard)
+	if err != nil {
+		b.Fatal(err)
+	}
+	p := make([]byte, 32*1024)
+
+	b.SetBytes(int64(len(p)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := w.Write(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkVerifyingReader 基准测试解密并校验 HMAC 的读取吞吐量
+func BenchmarkVerifyingReader(b *testing.B) {
+	e := newTestEncryptor(b)
+	h, err := e.NewHeader()
+	if err != nil {
+		b.Fatal(err)
+	}
+	v, err := e.newVerifyingReader(zeroReader{}, h)
+	if err != nil {
+		b.Fatal(err)
+	}
+	p := make([]byte, 32*1024)
+
+	b.SetBytes(int64(len(p)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := io.ReadFull(v, p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
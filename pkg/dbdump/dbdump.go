@@ -0,0 +1,170 @@
+package dbdump
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// maxStderr 错误信息中保留的导出命令 stderr 字节数
+const maxStderr = 4096
+
+// Dumper 数据库导出源，调用 pg_dump/mysqldump/mongodump 并将导出数据写到标准输出
+type Dumper struct {
+	kind     string // postgres, mysql, mongodb
+	u        *url.URL
+	password string
+}
+
+// New 解析数据库连接地址，支持 postgres://、postgresql://、mysql://、mongodb:// 和 mongodb+srv://
+// password 非空时覆盖地址中的密码。密码通过环境变量或临时配置文件传给导出命令，不出现在命令行参数中
+func New(rawURL, password string) (*Dumper, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source url: %w", err)
+	}
+
+	d := &Dumper{u: u, password: password}
+	switch u.Scheme {
+	case "postgres", "postgresql":
+		d.kind = "postgres"
+	case "mysql":
+		d.kind = "mysql"
+	case "mongodb", "mongodb+srv":
+		d.kind = "mongodb"
+	default:
+		return nil, fmt.Errorf("unsupported source scheme %q: expected postgres, mysql or mongodb", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid source url: host is required")
+	}
+
+	if d.password == "" && u.User != nil {
+		d.password, _ = u.User.Password()
+	}
+	// 去掉地址中的密码
+	if u.User != nil {
+		d.u.User = url.User(u.User.Username())
+	}
+	return d, nil
+}
+
+// Kind 返回数据库类型：postgres, mysql, mongodb
+func (d *Dumper) Kind() string {
+	return d.kind
+}
+
+// Ext 返回导出数据的文件扩展名（压缩前）
+func (d *Dumper) Ext() string {
+	if d.kind == "mongodb" {
+		return ".archive"
+	}
+	return ".sql"
+}
+
+// String 返回不含密码的连接地址
+func (d *Dumper) String() string {
+	return d.u.String()
+}
+
+// Dump 执行导出命令，将导出数据写入 w
+// 命令失败时错误信息包含 stderr 的末尾部分
+func (d *Dumper) Dump(ctx context.Context, w io.Writer) error {
+	cmd, cleanup, err := d.command(ctx)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	stderr := &tailBuffer{max: maxStderr}
+	cmd.Stdout = w
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s failed: %w: %s", cmd.Args[0], err, msg)
+		}
+		return fmt.Errorf("%s failed: %w", cmd.Args[0], err)
+	}
+	return nil
+}
+
+// command 构造导出命令，cleanup 用于删除临时文件
+func (d *Dumper) command(ctx context.Context) (*exec.Cmd, func(), error) {
+	cleanup := func() {}
+
+	switch d.kind {
+	case "postgres":
+		cmd := exec.CommandContext(ctx, "pg_dump", "--no-password", "--dbname="+d.u.String())
+		cmd.Env = os.Environ()
+		if d.password != "" {
+			cmd.Env = append(cmd.Env, "PGPASSWORD="+d.password)
+		}
+		return cmd, cleanup, nil
+
+	case "mysql":
+		args := []string{"--host=" + d.u.Hostname(), "--single-transaction", "--quick", "--routines", "--triggers"}
+		if port := d.u.Port(); port != "" {
+			args = append(args, "--port="+port)
+		}
+		if d.u.User != nil && d.u.User.Username() != "" {
+			args = append(args, "--user="+d.u.User.Username())
+		}
+		if db := strings.Trim(d.u.Path, "/"); db != "" {
+			args = append(args, "--databases", db)
+		} else {
+			args = append(args, "--all-databases")
+		}
+		cmd := exec.CommandContext(ctx, "mysqldump", args...)
+		cmd.Env = os.Environ()
+		if d.password != "" {
+			cmd.Env = append(cmd.Env, "MYSQL_PWD="+d.password)
+		}
+		return cmd, cleanup, nil
+
+	default:
+		args := []string{"--uri=" + d.u.String(), "--archive"}
+		if d.password != "" {
+			// mongodump 只能通过命令行或配置文件接收密码，使用仅当前用户可读的临时配置文件
+			f, err := os.CreateTemp("", "s3backup-mongodump-*.yaml")
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to create mongodump config: %w", err)
+			}
+			cleanup = func() { os.Remove(f.Name()) }
+			_, err = fmt.Fprintf(f, "password: %s\n", strconv.Quote(d.password))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				cleanup()
+				return nil, nil, fmt.Errorf("failed to write mongodump config: %w", err)
+			}
+			args = append(args, "--config="+f.Name())
+		}
+		return exec.CommandContext(ctx, "mongodump", args...), cleanup, nil
+	}
+}
+
+// tailBuffer 只保留最后 max 字节的缓冲区
+type tailBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf.Write(p)
+	if extra := t.buf.Len() - t.max; extra > 0 {
+		t.buf.Next(extra)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	return t.buf.String()
+}
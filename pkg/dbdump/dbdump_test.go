@@ -0,0 +1,115 @@
+package dbdump
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestNew 测试解析连接地址，地址中的密码被移除
+func TestNew(t *testing.T) {
+	tests := []struct {
+		url      string
+		password string
+		kind     string
+		ext      string
+		wantPass string
+		wantURL  string
+	}{
+		{"postgres://app:secret@db:5432/shop?sslmode=disable", "", "postgres", ".sql", "secret", "postgres://app@db:5432/shop?sslmode=disable"},
+		{"mysql://root@127.0.0.1:3306/shop", "fromconfig", "mysql", ".sql", "fromconfig", "mysql://root@127.0.0.1:3306/shop"},
+		{"mongodb+srv://u:p@cluster.example.com/app", "", "mongodb", ".archive", "p", "mongodb+srv://u@cluster.example.com/app"},
+	}
+	for _, tt := range tests {
+		d, err := New(tt.url, tt.password)
+		if err != nil {
+			t.Fatalf("New(%q) error = %v", tt.url, err)
+		}
+		if d.Kind() != tt.kind || d.Ext() != tt.ext || d.password != tt.wantPass || d.String() != tt.wantURL {
+			t.Errorf("New(%q) = %s %s %q %s", tt.url, d.Kind(), d.Ext(), d.password, d.String())
+		}
+	}
+
+	for _, bad := range []string{"redis://localhost", "postgres:///db", "::"} {
+		if _, err := New(bad, ""); err == nil {
+			t.Errorf("New(%q) expected error", bad)
+		}
+	}
+}
+
+// TestCommandKeepsPasswordOffArgs 测试密码不出现在命令行参数中
+func TestCommandKeepsPasswordOffArgs(t *testing.T) {
+	for _, raw := range []string{"postgres://u:hunter2@db/app", "mysql://u:hunter2@db:3307/app", "mongodb://u:hunter2@db/app"} {
+		d, _ := New(raw, "")
+		cmd, cleanup, err := d.command(context.Background())
+		if err != nil {
+			t.Fatalf("command() error = %v", err)
+		}
+		if args := strings.Join(cmd.Args, " "); strings.Contains(args, "hunter2") {
+			t.Errorf("%s: password in args: %s", d.Kind(), args)
+		}
+
+		switch d.Kind() {
+		case "postgres":
+			if !containsEnv(cmd.Env, "PGPASSWORD=hunter2") {
+				t.Error("postgres: PGPASSWORD not set")
+			}
+		case "mysql":
+			want := []string{"mysqldump", "--host=db", "--single-transaction", "--quick", "--routines", "--triggers", "--port=3307", "--user=u", "--databases", "app"}
+			if strings.Join(cmd.Args, " ") != strings.Join(want, " ") {
+				t.Errorf("mysql args = %v", cmd.Args)
+			}
+			if !containsEnv(cmd.Env, "MYSQL_PWD=hunter2") {
+				t.Error("mysql: MYSQL_PWD not set")
+			}
+		case "mongodb":
+			configPath := strings.TrimPrefix(cmd.Args[len(cmd.Args)-1], "--config=")
+			data, err := os.ReadFile(configPath)
+			if err != nil || string(data) != "password: \"hunter2\"\n" {
+				t.Errorf("mongodump config = %q, %v", data, err)
+			}
+			cleanup()
+			if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+				t.Error("mongodump config should be removed by cleanup")
+			}
+		}
+		cleanup()
+	}
+}
+
+// TestDump 测试导出数据写入 w，失败时错误包含 stderr
+func TestDump(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$PGPASSWORD\" != \"pw\" ]; then echo 'password authentication failed' >&2; exit 1; fi\necho \"-- dump $2\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "pg_dump"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	d, _ := New("postgres://app:pw@db/shop", "")
+	var buf bytes.Buffer
+	if err := d.Dump(context.Background(), &buf); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	if buf.String() != "-- dump --dbname=postgres://app@db/shop\n" {
+		t.Errorf("dump output = %q", buf.String())
+	}
+
+	d, _ = New("postgres://app:wrong@db/shop", "")
+	err := d.Dump(context.Background(), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "password authentication failed") {
+		t.Errorf("expected error with stderr, got %v", err)
+	}
+}
+
+func containsEnv(env []string, kv string) bool {
+	for _, e := range env {
+		if e == kv {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// DefaultHost Docker Engine API 默认地址
+const DefaultHost = "unix:///var/run/docker.sock"
+
+// HelperImage 无法直接读取卷目录时用于打包卷内容的辅助容器镜像
+const HelperImage = "alpine:3"
+
+// Volume Docker 卷信息
+type Volume struct {
+	Name       string `json:"Name"`
+	Driver     string `json:"Driver"`
+	Mountpoint string `json:"Mountpoint"`
+}
+
+// Host 返回 Docker Engine API 地址：DOCKER_HOST 环境变量，未设置时为 DefaultHost
+func Host() string {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+	return DefaultHost
+}
+
+// InspectVolume 通过 Docker Engine API 查询卷信息，目前只支持 unix:// 地址
+func InspectVolume(ctx context.Context, host, name string) (*Volume, error) {
+	client, err := newClient(host)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker/volumes/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to docker at %s: %w", host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("docker volume %s not found", name)
+		}
+		return nil, fmt.Errorf("failed to inspect docker volume %s: %s %s", name, resp.Status, apiErr.Message)
+	}
+
+	var vol Volume
+	if err := json.NewDecoder(resp.Body).Decode(&vol); err != nil {
+		return nil, fmt.Errorf("failed to decode docker volume %s: %w", name, err)
+	}
+	return &vol, nil
+}
+
+// newClient 创建通过 unix socket 访问 Docker Engine API 的 HTTP 客户端
+func newClient(host string) (*http.Client, error) {
+	path, ok := strings.CutPrefix(host, "unix://")
+	if !ok {
+		return nil, fmt.Errorf("unsupported docker host %q: only unix:// is supported", host)
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}, nil
+}
+
+// Readable 判断当前用户能否直接读取卷目录
+// rootless Docker、Docker Desktop 或非 root 用户通常无法访问宿主机上的卷目录
+func (v *Volume) Readable() bool {
+	f, err := os.Open(v.Mountpoint)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	_, err = f.Readdirnames(1)
+	return err == nil || err == io.EOF
+}
+
+// ArchiveVolume 使用辅助容器以只读方式挂载卷，将卷内容打包为 tar.gz 写入 w
+// 需要 docker 命令可用，镜像不存在时由 docker 自动拉取
+func ArchiveVolume(ctx context.Context, name, image string, w io.Writer) error {
+	cmd := exec.CommandContext(ctx, "docker", helperArgs(name, image)...)
+	var stderr bytes.Buffer
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("helper container failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("helper container failed: %w", err)
+	}
+	return nil
+}
+
+// helperArgs 返回辅助容器的 docker 命令参数
+func helperArgs(name, image string) []string {
+	return []string{
+		"run", "--rm", "--network", "none",
+		"-v", name + ":/volume:ro",
+		image,
+		"tar", "-czf", "-", "-C", "/volume", ".",
+	}
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// serveDocker 在临时 unix socket 上启动模拟的 Docker Engine API
+func serveDocker(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: handler}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return "unix://" + sock
+}
+
+// TestInspectVolume 测试通过 API 查询卷挂载点
+func TestInspectVolume(t *testing.T) {
+	host := serveDocker(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/volumes/pgdata":
+			w.Write([]byte(`{"Name":"pgdata","Driver":"local","Mountpoint":"/var/lib/docker/volumes/pgdata/_data"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"get missing: no such volume"}`))
+		}
+	})
+
+	vol, err := InspectVolume(context.Background(), host, "pgdata")
+	if err != nil {
+		t.Fatalf("InspectVolume() error = %v", err)
+	}
+	if vol.Mountpoint != "/var/lib/docker/volumes/pgdata/_data" || vol.Driver != "local" {
+		t.Errorf("unexpected volume: %+v", vol)
+	}
+
+	if _, err := InspectVolume(context.Background(), host, "missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if _, err := InspectVolume(context.Background(), "tcp://127.0.0.1:2375", "pgdata"); err == nil {
+		t.Error("expected error for tcp host")
+	}
+}
+
+// TestVolumeReadable 测试卷目录可读性检查
+func TestVolumeReadable(t *testing.T) {
+	if !(&Volume{Mountpoint: t.TempDir()}).Readable() {
+		t.Error("empty temp dir should be readable")
+	}
+	if (&Volume{Mountpoint: filepath.Join(t.TempDir(), "missing")}).Readable() {
+		t.Error("missing dir should not be readable")
+	}
+}
+
+// TestHelperArgs 测试辅助容器以只读方式挂载卷且不联网
+func TestHelperArgs(t *testing.T) {
+	got := strings.Join(helperArgs("pgdata", HelperImage), " ")
+	want := "run --rm --network none -v pgdata:/volume:ro alpine:3 tar -czf - -C /volume ."
+	if got != want {
+		t.Errorf("helperArgs() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,177 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/storage"
+)
+
+// maxRangeRetries 单个分段因限流或网络错误重试的最大次数
+const maxRangeRetries = 5
+
+// networkRetryDelay 网络错误重试的初始等待时间，每次重试翻倍
+var networkRetryDelay = 1 * time.Second
+
+// segment 一个下载分段的结果
+type segment struct {
+	data []byte
+	err  error
+}
+
+// Reader 将对象按 chunkSize 分段，用 concurrency 个 worker 并发下载，按顺序输出
+// 正在下载和已下载等待读取的分段合计不超过 concurrency 个，内存占用约为 (concurrency + 1) × chunkSize
+type Reader struct {
+	ranges    storage.RangeReader
+	key       string
+	size      int64
+	chunkSize int64
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pending chan chan segment // 按对象顺序排列的分段，读取方依次等待
+	done    chan struct{}     // 调度 goroutine 退出后关闭
+
+	current *bytes.Reader
+	err     error
+}
+
+// NewReader 开始并发下载对象 key 的 size 个字节，调用方读完或出错后必须调用 Close
+func NewReader(ctx context.Context, ranges storage.RangeReader, key string, size, chunkSize int64, concurrency int) *Reader {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r := &Reader{
+		ranges:    ranges,
+		key:       key,
+		size:      size,
+		chunkSize: chunkSize,
+		ctx:       ctx,
+		cancel:    cancel,
+		pending:   make(chan chan segment, concurrency),
+		done:      make(chan struct{}),
+		current:   bytes.NewReader(nil),
+	}
+	go r.schedule(concurrency)
+	return r
+}
+
+// schedule 按顺序为每个分段启动下载，正在下载的分段不超过 concurrency 个
+// pending 已满（读取方落后）时暂停，避免下载速度超过解压速度时占用过多内存
+func (r *Reader) schedule(concurrency int) {
+	defer close(r.done)
+	defer close(r.pending)
+
+	sem := make(chan struct{}, concurrency)
+	for offset := int64(0); offset < r.size; offset += r.chunkSize {
+		length := min(r.chunkSize, r.size-offset)
+		ch := make(chan segment, 1)
+		select {
+		case r.pending <- ch:
+		case <-r.ctx.Done():
+			return
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-r.ctx.Done():
+			ch <- segment{err: r.ctx.Err()}
+			return
+		}
+		go func() {
+			defer func() { <-sem }()
+			data, err := r.fetch(offset, length)
+			ch <- segment{data: data, err: err}
+		}()
+	}
+}
+
+// fetch 下载一个分段，限流和网络错误（包括返回的数据不完整）等待后重试
+func (r *Reader) fetch(offset, length int64) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		data, err := r.fetchOnce(offset, length)
+		if err == nil {
+			return data, nil
+		}
+
+		var throttled *storage.ThrottledError
+		var delay time.Duration
+		switch {
+		case errors.As(err, &throttled):
+			delay = throttled.Backoff(attempt)
+		case errors.Is(err, storage.ErrNetwork), errors.Is(err, io.ErrUnexpectedEOF):
+			delay = networkRetryDelay << attempt
+		default:
+			return nil, err
+		}
+		if attempt >= maxRangeRetries {
+			return nil, err
+		}
+		if err := sleepContext(r.ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fetchOnce 下载一个分段并读完响应
+func (r *Reader) fetchOnce(offset, length int64) ([]byte, error) {
+	body, err := r.ranges.GetObjectRange(r.ctx, r.key, offset, length)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download bytes %d-%d: %w", offset, offset+length-1, err)
+	}
+	defer body.Close()
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(body, data); err != nil {
+		return nil, fmt.Errorf("failed to read bytes %d-%d: %w", offset, offset+length-1, err)
+	}
+	return data, nil
+}
+
+// Read 按顺序读取下载的数据，当前分段读完时等待下一个分段
+func (r *Reader) Read(p []byte) (int, error) {
+	for r.current.Len() == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		ch, ok := <-r.pending
+		if !ok {
+			r.err = io.EOF
+			if err := r.ctx.Err(); err != nil {
+				r.err = err
+			}
+			continue
+		}
+		seg := <-ch
+		if seg.err != nil {
+			r.err = seg.err
+			r.cancel()
+			continue
+		}
+		r.current.Reset(seg.data)
+	}
+	return r.current.Read(p)
+}
+
+// Close 取消尚未完成的下载并等待调度 goroutine 退出
+func (r *Reader) Close() error {
+	r.cancel()
+	<-r.done
+	return nil
+}
+
+// sleepContext 等待 d 时间，上下文取消时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+func init() {
+	networkRetryDelay = time.Millisecond
+}
+
+// newObject 在 mock 存储中写入 size 字节的对象
+func newObject(t *testing.T, size int) (*mock.Adapter, []byte) {
+	t.Helper()
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 31)
+	}
+	adapter := mock.New()
+	adapter.SetObject("backup.tar.gz", &mock.Object{Data: data})
+	return adapter, data
+}
+
+// TestReaderOrder 测试分段并发下载后按顺序输出，包括最后一个不完整的分段
+func TestReaderOrder(t *testing.T) {
+	adapter, data := newObject(t, 10*1024+17)
+	adapter.SetLatency(0, 2*time.Millisecond, 1)
+
+	for _, concurrency := range []int{1, 3, 16} {
+		r := NewReader(context.Background(), adapter, "backup.tar.gz", int64(len(data)), 1024, concurrency)
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("concurrency %d: ReadAll() error = %v", concurrency, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("concurrency %d: downloaded data differs", concurrency)
+		}
+	}
+}
+
+// TestReaderRetry 测试网络错误重试后继续下载，其他错误立即返回
+func TestReaderRetry(t *testing.T) {
+	adapter, data := newObject(t, 4096)
+	adapter.AddFault(mock.Fault{Op: mock.OpGetObject, Err: mock.NetworkError(), Times: 2})
+
+	r := NewReader(context.Background(), adapter, "backup.tar.gz", int64(len(data)), 1024, 2)
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("downloaded data differs after retries")
+	}
+
+	adapter.ClearFaults()
+	adapter.AddFault(mock.Fault{Op: mock.OpGetObject, Err: storage.ErrObjectArchived})
+	r = NewReader(context.Background(), adapter, "backup.tar.gz", int64(len(data)), 1024, 2)
+	_, err = io.ReadAll(r)
+	r.Close()
+	if !errors.Is(err, storage.ErrObjectArchived) {
+		t.Errorf("ReadAll() error = %v, want ErrObjectArchived", err)
+	}
+}
+
+// TestReaderClose 测试未读完时关闭会取消下载
+func TestReaderClose(t *testing.T) {
+	adapter, data := newObject(t, 64*1024)
+	r := NewReader(context.Background(), adapter, "backup.tar.gz", int64(len(data)), 1024, 4)
+	if _, err := io.ReadFull(r, make([]byte, 100)); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() did not return")
+	}
+}
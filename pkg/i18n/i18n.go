@@ -0,0 +1,102 @@
+package i18n
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Lang 输出语言
+type Lang string
+
+const (
+	// Zh 中文，源代码中的消息使用中文，不需要翻译
+	Zh Lang = "zh"
+	// En 英文
+	En Lang = "en"
+)
+
+// LangEnv 选择输出语言的环境变量，--lang 优先
+const LangEnv = "S3BACKUP_LANG"
+
+// 消息目录以源代码中的中文消息为键（与 gettext 的 msgid 相同），各包在 init 中注册自己的翻译；
+// 没有翻译的消息原样输出
+var (
+	mu       sync.RWMutex
+	current  = Zh
+	catalogs = map[Lang]map[string]string{}
+)
+
+// ParseLang 解析语言名称，接受 en、zh 以及 en_US.UTF-8、zh-CN 等区域设置写法
+func ParseLang(s string) (Lang, error) {
+	name := strings.ToLower(s)
+	if i := strings.IndexAny(name, "_-."); i >= 0 {
+		name = name[:i]
+	}
+	switch Lang(name) {
+	case Zh:
+		return Zh, nil
+	case En:
+		return En, nil
+	}
+	return "", fmt.Errorf("unsupported language %q (supported: en, zh)", s)
+}
+
+// SetLang 设置输出语言
+func SetLang(lang Lang) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = lang
+}
+
+// Current 返回当前输出语言
+func Current() Lang {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Register 注册 lang 的翻译，msgs 的键为中文消息，重复注册的键以后注册的为准
+func Register(lang Lang, msgs map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	catalog := catalogs[lang]
+	if catalog == nil {
+		catalog = make(map[string]string, len(msgs))
+		catalogs[lang] = catalog
+	}
+	for k, v := range msgs {
+		catalog[k] = v
+	}
+}
+
+// T 返回 msg 在当前语言下的翻译，没有翻译时返回 msg
+func T(msg string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if s, ok := catalogs[current][msg]; ok {
+		return s
+	}
+	return msg
+}
+
+// Sprintf 翻译 format 后格式化
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Printf 翻译 format 后输出到标准输出
+func Printf(format string, args ...any) {
+	fmt.Printf(T(format), args...)
+}
+
+// Fprintf 翻译 format 后输出到 w
+func Fprintf(w io.Writer, format string, args ...any) {
+	fmt.Fprintf(w, T(format), args...)
+}
+
+// N 原样返回 msg，用于标记在包初始化时定义、使用时才通过 T 翻译的消息（如映射表中的说明）
+func N(msg string) string {
+	return msg
+}
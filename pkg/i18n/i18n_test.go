@@ -0,0 +1,55 @@
+package i18n
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseLang(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Lang
+		wantErr bool
+	}{
+		{"en", En, false},
+		{"zh", Zh, false},
+		{"EN", En, false},
+		{"en_US.UTF-8", En, false},
+		{"zh-CN", Zh, false},
+		{"zh_TW.UTF-8", Zh, false},
+		{"fr", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLang(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLang(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// TestTranslate 测试按当前语言翻译，没有翻译的消息原样输出
+func TestTranslate(t *testing.T) {
+	defer SetLang(Current())
+	Register(En, map[string]string{
+		"备份成功: %s\n": "Backup succeeded: %s\n",
+	})
+
+	SetLang(Zh)
+	if got := Sprintf("备份成功: %s\n", "a.tar.gz"); got != "备份成功: a.tar.gz\n" {
+		t.Errorf("zh Sprintf = %q", got)
+	}
+
+	SetLang(En)
+	if got := Sprintf("备份成功: %s\n", "a.tar.gz"); got != "Backup succeeded: a.tar.gz\n" {
+		t.Errorf("en Sprintf = %q", got)
+	}
+	var buf bytes.Buffer
+	Fprintf(&buf, "备份成功: %s\n", "b")
+	if buf.String() != "Backup succeeded: b\n" {
+		t.Errorf("en Fprintf = %q", buf.String())
+	}
+	if got := T("没有翻译"); got != "没有翻译" {
+		t.Errorf("untranslated T = %q, want original", got)
+	}
+}
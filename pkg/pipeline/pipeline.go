@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Source 数据源，将全部数据写入 w 后返回
+type Source func(ctx context.Context, w io.Writer) error
+
+// Filter 处理阶段（压缩、填充、加密等）：包装下游 w，写入返回的 WriteCloser 的数据经处理后写入 w
+// Close 写出尾部数据（压缩流结尾、HMAC 等），不关闭 w
+type Filter func(w io.Writer) (io.WriteCloser, error)
+
+// Sink 管道的终点（上传、本地文件等）
+// Close 在全部数据写入后提交；CloseWithError 在数据源或处理阶段出错时放弃已写入的数据，返回应报告的错误
+type Sink interface {
+	io.Writer
+	Close() error
+	CloseWithError(cause error) error
+}
+
+// Run 将 src 写出的数据依次经过 filters（第一个最靠近数据源）写入 sink
+// 全部成功时从数据源一侧开始依次关闭各处理阶段，最后关闭 sink；
+// 任一环节出错时以该错误调用 sink.CloseWithError，返回其结果
+func Run(ctx context.Context, src Source, sink Sink, filters ...Filter) error {
+	var w io.Writer = sink
+	stages := make([]io.WriteCloser, len(filters))
+	for i := len(filters) - 1; i >= 0; i-- {
+		stage, err := filters[i](w)
+		if err != nil {
+			return sink.CloseWithError(err)
+		}
+		stages[i], w = stage, stage
+	}
+
+	if err := src(ctx, w); err != nil {
+		return sink.CloseWithError(err)
+	}
+	for _, stage := range stages {
+		if err := stage.Close(); err != nil {
+			return sink.CloseWithError(err)
+		}
+	}
+	return sink.Close()
+}
+
+// Tap 不改变数据的处理阶段，同时将经过的数据写入 observer（统计大小、计算摘要等）
+// observer 的写入错误会中止管道
+func Tap(observer io.Writer) Filter {
+	return func(w io.Writer) (io.WriteCloser, error) {
+		return nopCloser{io.MultiWriter(w, observer)}, nil
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// ToWriter 将 w 包装为 Sink，Close 不做任何事，CloseWithError 返回 cause
+// 用于写入调用方管理的 writer（如外层管道的处理阶段）
+func ToWriter(w io.Writer) Sink {
+	return writerSink{w}
+}
+
+type writerSink struct {
+	io.Writer
+}
+
+func (writerSink) Close() error { return nil }
+
+func (writerSink) CloseWithError(cause error) error { return cause }
+
+// Discard 丢弃全部数据的 Sink，用于模拟运行
+var Discard Sink = writerSink{io.Discard}
+
+// Tee 将数据同时写入所有 sinks 的 Sink，任一 sink 写入失败时停止写入
+// Close 依次关闭所有 sinks，返回第一个错误；CloseWithError 同样传给所有 sinks
+func Tee(sinks ...Sink) Sink {
+	return teeSink(sinks)
+}
+
+type teeSink []Sink
+
+func (t teeSink) Write(p []byte) (int, error) {
+	for _, s := range t {
+		if n, err := s.Write(p); err != nil {
+			return n, err
+		} else if n != len(p) {
+			return n, io.ErrShortWrite
+		}
+	}
+	return len(p), nil
+}
+
+func (t teeSink) Close() error {
+	var errs []error
+	for _, s := range t {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeSink) CloseWithError(cause error) error {
+	err := cause
+	for _, s := range t {
+		// 其他 sink 自身的错误（如上传失败）比 cause 更接近根因时优先返回
+		if serr := s.CloseWithError(cause); serr != nil && !errors.Is(serr, cause) && err == cause {
+			err = serr
+		}
+	}
+	return err
+}
+
+// File 写入本地文件的 Sink：Close 同步到磁盘后关闭，CloseWithError 删除文件
+type File struct {
+	f *os.File
+}
+
+// CreateFile 创建（或截断）path 作为 Sink，权限为 perm
+func CreateFile(path string, perm os.FileMode) (*File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	return &File{f: f}, nil
+}
+
+// Write 写入文件
+func (f *File) Write(p []byte) (int, error) {
+	return f.f.Write(p)
+}
+
+// Close 同步并关闭文件，失败时删除文件
+func (f *File) Close() error {
+	err := f.f.Sync()
+	if err != nil {
+		err = fmt.Errorf("failed to sync file: %w", err)
+	}
+	if closeErr := f.f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close file: %w", closeErr)
+	}
+	if err != nil {
+		os.Remove(f.f.Name())
+	}
+	return err
+}
+
+// CloseWithError 关闭并删除文件，返回 cause
+func (f *File) CloseWithError(cause error) error {
+	f.f.Close()
+	os.Remove(f.f.Name())
+	return cause
+}
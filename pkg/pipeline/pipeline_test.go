@@ -0,0 +1,158 @@
+package pipeline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// recordSink 记录写入的数据和关闭方式
+type recordSink struct {
+	bytes.Buffer
+	closed bool
+	cause  error
+}
+
+func (s *recordSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *recordSink) CloseWithError(cause error) error {
+	s.cause = cause
+	return cause
+}
+
+func gzipFilter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+// TestRun 测试数据依次经过处理阶段写入 sink，处理阶段在 sink 之前关闭
+func TestRun(t *testing.T) {
+	sink := &recordSink{}
+	var observed bytes.Buffer
+	src := func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "hello pipeline")
+		return err
+	}
+
+	// Tap 在 gzip 之后，观察到的是压缩后的数据
+	if err := Run(context.Background(), src, sink, gzipFilter, Tap(&observed)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !sink.closed || sink.cause != nil {
+		t.Fatalf("sink closed = %v, cause = %v", sink.closed, sink.cause)
+	}
+	if !bytes.Equal(observed.Bytes(), sink.Bytes()) {
+		t.Error("tap observed different data than the sink received")
+	}
+	zr, err := gzip.NewReader(&sink.Buffer)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	if got, _ := io.ReadAll(zr); string(got) != "hello pipeline" {
+		t.Errorf("decompressed %q", got)
+	}
+}
+
+// TestRunSourceError 测试数据源出错时以该错误关闭 sink，不关闭处理阶段
+func TestRunSourceError(t *testing.T) {
+	sink := &recordSink{}
+	cause := errors.New("archive failed")
+	src := func(ctx context.Context, w io.Writer) error { return cause }
+
+	if err := Run(context.Background(), src, sink, gzipFilter); err != cause {
+		t.Fatalf("Run() error = %v, want %v", err, cause)
+	}
+	if sink.closed || sink.cause != cause {
+		t.Errorf("sink closed = %v, cause = %v", sink.closed, sink.cause)
+	}
+	if sink.Len() != 0 {
+		t.Errorf("gzip trailer written after source error: %d bytes", sink.Len())
+	}
+}
+
+// TestRunFilterError 测试创建处理阶段失败时不运行数据源
+func TestRunFilterError(t *testing.T) {
+	sink := &recordSink{}
+	cause := errors.New("no key")
+	failing := func(w io.Writer) (io.WriteCloser, error) { return nil, cause }
+	ran := false
+	src := func(ctx context.Context, w io.Writer) error {
+		ran = true
+		return nil
+	}
+
+	if err := Run(context.Background(), src, sink, gzipFilter, failing); err != cause {
+		t.Fatalf("Run() error = %v, want %v", err, cause)
+	}
+	if ran {
+		t.Error("source ran although a filter failed")
+	}
+}
+
+// TestTee 测试数据写入所有 sink，出错时所有 sink 都被放弃
+func TestTee(t *testing.T) {
+	a, b := &recordSink{}, &recordSink{}
+	src := func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "tee")
+		return err
+	}
+	if err := Run(context.Background(), src, Tee(a, b)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if a.String() != "tee" || b.String() != "tee" || !a.closed || !b.closed {
+		t.Errorf("a = %q (closed %v), b = %q (closed %v)", a.String(), a.closed, b.String(), b.closed)
+	}
+
+	a, b = &recordSink{}, &recordSink{}
+	cause := errors.New("failed")
+	if err := Tee(a, b).CloseWithError(cause); err != cause {
+		t.Errorf("CloseWithError() = %v, want %v", err, cause)
+	}
+	if a.cause != cause || b.cause != cause {
+		t.Error("CloseWithError() not passed to every sink")
+	}
+}
+
+// TestFile 测试文件 sink 成功时保留文件，出错时删除文件
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	src := func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "spool")
+		return err
+	}
+
+	path := filepath.Join(dir, "ok")
+	f, err := CreateFile(path, 0600)
+	if err != nil {
+		t.Fatalf("CreateFile() error = %v", err)
+	}
+	if err := Run(context.Background(), src, f); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "spool" {
+		t.Errorf("file = %q, %v", data, err)
+	}
+
+	path = filepath.Join(dir, "failed")
+	if f, err = CreateFile(path, 0600); err != nil {
+		t.Fatalf("CreateFile() error = %v", err)
+	}
+	cause := errors.New("archive failed")
+	failing := func(ctx context.Context, w io.Writer) error {
+		io.WriteString(w, "partial")
+		return cause
+	}
+	if err := Run(context.Background(), failing, f); err != cause {
+		t.Fatalf("Run() error = %v, want %v", err, cause)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("file should be removed after a failed run")
+	}
+}
@@ -19,6 +19,8 @@ type Bar struct {
 	lastSize int64
 	mu       sync.Mutex
 	speed    float64
+	total    int64  // 运行中通过 SetTotal 确定的总数
+	phase    string // 当前阶段，作为进度条的描述
 }
 
 // NewBar 创建新的进度条
@@ -38,14 +40,21 @@ func (b *Bar) Init(total int64) {
 	b.lastSize = 0
 	b.speed = 0
 
-	// 未知总数时使用不确定模式
+	// 未知总数时使用 SetTotal 提前设置的总数，仍未知时使用不确定模式
+	if total > 0 {
+		b.total = total
+	}
+	total = b.total
 	if total <= 0 {
 		total = -1
 	}
+	if b.phase == "" {
+		b.phase = "Uploading"
+	}
 
 	b.bar = progressbar.NewOptions64(
 		total,
-		progressbar.OptionSetDescription("Uploading"),
+		progressbar.OptionSetDescription(b.phase),
 		progressbar.OptionSetWriter(os.Stderr),
 		progressbar.OptionShowCount(),
 		progressbar.OptionShowIts(),
@@ -76,6 +85,28 @@ func (b *Bar) Add(n int64) {
 	b.bar.Add64(n)
 }
 
+// SetTotal 更新总字节数，进度条从不确定模式切换为显示百分比
+func (b *Bar) SetTotal(total int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.total = total
+	if b.bar != nil && total > 0 {
+		b.bar.ChangeMax64(total)
+	}
+}
+
+// SetPhase 设置进度条的描述，Init 之前设置时在初始化时使用
+func (b *Bar) SetPhase(phase string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.phase = phase
+	if b.bar != nil {
+		b.bar.Describe(phase)
+	}
+}
+
 // Complete 标记完成
 func (b *Bar) Complete() {
 	if b.bar == nil {
@@ -43,6 +43,29 @@ func TestBarUnknownTotal(t *testing.T) {
 	bar.Close()
 }
 
+// TestBarSetTotalAndPhase 测试 Init 前设置的总数和阶段在初始化时生效，之后的更新不影响已统计的字节数
+func TestBarSetTotalAndPhase(t *testing.T) {
+	bar := NewBar()
+	bar.SetPhase("Archiving")
+	bar.SetTotal(500)
+	bar.Init(0)
+	if bar.total != 500 || bar.phase != "Archiving" {
+		t.Errorf("total = %d, phase = %q after Init(0)", bar.total, bar.phase)
+	}
+
+	bar.Add(100)
+	bar.SetPhase("Uploading")
+	bar.SetTotal(800)
+	bar.Add(100)
+	if bar.GetBytes() != 200 {
+		t.Errorf("expected 200 bytes, got %d", bar.GetBytes())
+	}
+	if bar.total != 800 || bar.phase != "Uploading" {
+		t.Errorf("total = %d, phase = %q", bar.total, bar.phase)
+	}
+	bar.Close()
+}
+
 func TestSilent(t *testing.T) {
 	silent := NewSilent()
 
@@ -50,6 +73,8 @@ func TestSilent(t *testing.T) {
 	silent.Init(100)
 	silent.Add(10)
 	silent.Add(20)
+	silent.SetTotal(200)
+	silent.SetPhase("Uploading")
 	silent.Complete()
 
 	if err := silent.Close(); err != nil {
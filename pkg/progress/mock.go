@@ -1,6 +1,7 @@
 package progress
 
 import (
+	"sync"
 	"sync/atomic"
 )
 
@@ -11,6 +12,10 @@ type MockReporter struct {
 	CompleteCalled atomic.Int64
 	CloseCalled    atomic.Int64
 	AddTotal       atomic.Int64
+	Total          atomic.Int64 // 最近一次 Init 或 SetTotal 设置的总数
+
+	mu     sync.Mutex
+	phases []string
 }
 
 // NewMockReporter 创建新的模拟报告器
@@ -21,6 +26,26 @@ func NewMockReporter() *MockReporter {
 // Init 初始化进度报告
 func (m *MockReporter) Init(total int64) {
 	m.InitCalled.Add(1)
+	m.Total.Store(total)
+}
+
+// SetTotal 更新总数
+func (m *MockReporter) SetTotal(total int64) {
+	m.Total.Store(total)
+}
+
+// SetPhase 记录阶段
+func (m *MockReporter) SetPhase(phase string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.phases = append(m.phases, phase)
+}
+
+// Phases 返回按顺序设置过的阶段
+func (m *MockReporter) Phases() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.phases...)
 }
 
 // Add 增加已处理的数量
@@ -47,4 +72,8 @@ func (m *MockReporter) Reset() {
 	m.CompleteCalled.Store(0)
 	m.CloseCalled.Store(0)
 	m.AddTotal.Store(0)
+	m.Total.Store(0)
+	m.mu.Lock()
+	m.phases = nil
+	m.mu.Unlock()
 }
@@ -8,9 +8,31 @@ type Reporter interface {
 	// Add 增加已处理的字节数
 	Add(n int64)
 
+	// SetTotal 更新总字节数，用于运行中才确定的总数（例如压缩后的大小、续传时记录的大小）
+	SetTotal(total int64)
+
+	// SetPhase 设置当前阶段的描述，例如 "Archiving"、"Encrypting"、"Uploading"
+	SetPhase(phase string)
+
 	// Complete 标记完成
 	Complete()
 
 	// Close 关闭报告器
 	Close() error
 }
+
+// PartObserver 可选接口，Reporter 同时实现时上传器报告每个 worker 正在上传的分块
+// worker 为从 0 开始的 worker 编号
+type PartObserver interface {
+	// PartStarted worker 开始（或重试）上传分块
+	PartStarted(worker, partNumber int, size int64)
+
+	// PartProgress worker 当前分块已发送 n 字节
+	PartProgress(worker int, n int64)
+
+	// PartRetry 分块上传失败，等待后重试
+	PartRetry(worker, partNumber int, err error)
+
+	// PartDone worker 完成当前分块
+	PartDone(worker, partNumber int)
+}
@@ -14,6 +14,12 @@ func (s *Silent) Init(total int64) {}
 // Add 增加字节数（无操作）
 func (s *Silent) Add(n int64) {}
 
+// SetTotal 更新总字节数（无操作）
+func (s *Silent) SetTotal(total int64) {}
+
+// SetPhase 设置阶段（无操作）
+func (s *Silent) SetPhase(phase string) {}
+
 // Complete 标记完成（无操作）
 func (s *Silent) Complete() {}
 
@@ -0,0 +1,143 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Spec 定时备份任务描述
+type Spec struct {
+	Name    string   // 任务名称，用于 unit 文件名和 crontab 标记
+	Command []string // 完整命令行（第一个元素为可执行文件绝对路径）
+	Daily   string   // 每日执行时间 HH:MM
+}
+
+// ParseDaily 解析 HH:MM 格式的每日执行时间
+func ParseDaily(s string) (hour, minute int, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid daily time %q: expected HH:MM", s)
+	}
+
+	hour, err = strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("invalid daily time %q: hour must be 0-23", s)
+	}
+	minute, err = strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid daily time %q: minute must be 0-59", s)
+	}
+
+	return hour, minute, nil
+}
+
+// Validate 验证任务描述
+func (s *Spec) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("schedule name is required")
+	}
+	for _, c := range s.Name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return fmt.Errorf("invalid schedule name %q: only letters, digits, '-', '_' and '.' are allowed", s.Name)
+		}
+	}
+	if len(s.Command) == 0 {
+		return fmt.Errorf("schedule command is required")
+	}
+	_, _, err := ParseDaily(s.Daily)
+	return err
+}
+
+// SystemdUnits 生成 systemd service 和 timer unit 文件内容
+func SystemdUnits(spec Spec) (service, timer string, err error) {
+	if err := spec.Validate(); err != nil {
+		return "", "", err
+	}
+	hour, minute, _ := ParseDaily(spec.Daily)
+
+	service = fmt.Sprintf(`[Unit]
+Description=s3backup scheduled backup (%s)
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=oneshot
+ExecStart=%s
+`, spec.Name, systemdCommand(spec.Command))
+
+	timer = fmt.Sprintf(`[Unit]
+Description=Run s3backup scheduled backup (%s) daily at %02d:%02d
+
+[Timer]
+OnCalendar=*-*-* %02d:%02d:00
+Persistent=true
+RandomizedDelaySec=60
+
+[Install]
+WantedBy=timers.target
+`, spec.Name, hour, minute, hour, minute)
+
+	return service, timer, nil
+}
+
+// CronMarker 返回 crontab 条目的标记注释，用于识别和替换已安装的条目
+func CronMarker(name string) string {
+	return "# s3backup:" + name
+}
+
+// CronEntry 生成 crontab 条目（包含标记注释）
+func CronEntry(spec Spec) (string, error) {
+	if err := spec.Validate(); err != nil {
+		return "", err
+	}
+	hour, minute, _ := ParseDaily(spec.Daily)
+
+	return fmt.Sprintf("%d %d * * * %s %s", minute, hour, shellCommand(spec.Command), CronMarker(spec.Name)), nil
+}
+
+// MergeCrontab 将条目合并到现有 crontab 内容中，替换同名的旧条目
+func MergeCrontab(existing, name, entry string) string {
+	marker := CronMarker(name)
+	var lines []string
+	for _, line := range strings.Split(existing, "\n") {
+		if line == "" || strings.HasSuffix(line, marker) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, entry)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// systemdCommand 按 systemd ExecStart 规则引用参数
+func systemdCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if a != "" && !strings.ContainsAny(a, " \t\"'\\$%;") {
+			quoted[i] = a
+			continue
+		}
+		a = strings.ReplaceAll(a, `\`, `\\`)
+		a = strings.ReplaceAll(a, `"`, `\"`)
+		a = strings.ReplaceAll(a, `$`, `$$`)
+		a = strings.ReplaceAll(a, `%`, `%%`)
+		quoted[i] = `"` + a + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellCommand 按 POSIX shell 规则引用参数（cron 通过 /bin/sh 执行）
+func shellCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if a != "" && !strings.ContainsAny(a, " \t\"'\\$%;&|<>*?()`#~") {
+			quoted[i] = a
+			continue
+		}
+		// cron 中 % 有特殊含义，需要转义
+		a = strings.ReplaceAll(a, `%`, `\%`)
+		quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,114 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseDaily 测试每日时间解析
+func TestParseDaily(t *testing.T) {
+	tests := []struct {
+		input   string
+		hour    int
+		minute  int
+		wantErr bool
+	}{
+		{"03:00", 3, 0, false},
+		{"23:59", 23, 59, false},
+		{"0:5", 0, 5, false},
+		{"24:00", 0, 0, true},
+		{"12:60", 0, 0, true},
+		{"3", 0, 0, true},
+		{"ab:cd", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		hour, minute, err := ParseDaily(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDaily(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (hour != tt.hour || minute != tt.minute) {
+			t.Errorf("ParseDaily(%q) = %d:%d, want %d:%d", tt.input, hour, minute, tt.hour, tt.minute)
+		}
+	}
+}
+
+// TestSystemdUnits 测试生成 systemd unit
+func TestSystemdUnits(t *testing.T) {
+	spec := Spec{
+		Name:    "s3backup-home",
+		Command: []string{"/usr/local/bin/s3backup", "backup", "--config", "/etc/s3backup.yaml", "/home/my docs"},
+		Daily:   "03:00",
+	}
+
+	service, timer, err := SystemdUnits(spec)
+	if err != nil {
+		t.Fatalf("SystemdUnits() error = %v", err)
+	}
+
+	if !strings.Contains(service, `ExecStart=/usr/local/bin/s3backup backup --config /etc/s3backup.yaml "/home/my docs"`) {
+		t.Errorf("unexpected service:\n%s", service)
+	}
+	if !strings.Contains(timer, "OnCalendar=*-*-* 03:00:00") {
+		t.Errorf("unexpected timer:\n%s", timer)
+	}
+	if !strings.Contains(timer, "WantedBy=timers.target") {
+		t.Errorf("timer should be installable:\n%s", timer)
+	}
+}
+
+// TestCronEntry 测试生成 crontab 条目
+func TestCronEntry(t *testing.T) {
+	spec := Spec{
+		Name:    "s3backup-home",
+		Command: []string{"/usr/local/bin/s3backup", "backup", "--name", "backup-100%.tar.gz", "/home/it's"},
+		Daily:   "03:30",
+	}
+
+	entry, err := CronEntry(spec)
+	if err != nil {
+		t.Fatalf("CronEntry() error = %v", err)
+	}
+
+	want := `30 3 * * * /usr/local/bin/s3backup backup --name 'backup-100\%.tar.gz' '/home/it'\''s' # s3backup:s3backup-home`
+	if entry != want {
+		t.Errorf("CronEntry() =\n%s\nwant\n%s", entry, want)
+	}
+}
+
+// TestMergeCrontab 测试 crontab 合并替换旧条目
+func TestMergeCrontab(t *testing.T) {
+	existing := "0 1 * * * /usr/bin/other\n0 2 * * * /old/s3backup backup /home # s3backup:home\n"
+
+	merged := MergeCrontab(existing, "home", "0 3 * * * /new/s3backup backup /home # s3backup:home")
+
+	if strings.Contains(merged, "/old/s3backup") {
+		t.Error("old entry should be replaced")
+	}
+	if !strings.Contains(merged, "/usr/bin/other") {
+		t.Error("unrelated entries should be kept")
+	}
+	if strings.Count(merged, "# s3backup:home") != 1 {
+		t.Errorf("expected exactly one entry, got:\n%s", merged)
+	}
+}
+
+// TestSpecValidate 测试任务验证
+func TestSpecValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec Spec
+	}{
+		{"empty name", Spec{Command: []string{"s3backup"}, Daily: "03:00"}},
+		{"invalid name", Spec{Name: "a/b", Command: []string{"s3backup"}, Daily: "03:00"}},
+		{"empty command", Spec{Name: "x", Daily: "03:00"}},
+		{"invalid time", Spec{Name: "x", Command: []string{"s3backup"}, Daily: "25:00"}},
+	}
+
+	for _, tt := range tests {
+		if err := tt.spec.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+}
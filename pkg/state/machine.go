@@ -0,0 +1,33 @@
+package state
+
+import (
+	"os"
+	"strings"
+)
+
+// machineIDFiles systemd 和 dbus 保存机器 ID 的文件
+var machineIDFiles = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+// MachineID 返回当前机器的标识，用于区分共享家目录（NFS、同步的 dotfiles）中不同主机的状态
+// 优先使用 S3BACKUP_MACHINE_ID 环境变量，否则为 <主机名>-<machine-id 前 12 位>，没有 machine-id 时只使用主机名
+func MachineID() string {
+	if id := strings.TrimSpace(os.Getenv("S3BACKUP_MACHINE_ID")); id != "" {
+		return safeFilename(id)
+	}
+
+	id, _ := os.Hostname()
+	if id == "" {
+		id = "unknown"
+	}
+	for _, path := range machineIDFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if mid := strings.TrimSpace(string(data)); mid != "" {
+			id += "-" + mid[:min(len(mid), 12)]
+			break
+		}
+	}
+	return safeFilename(id)
+}
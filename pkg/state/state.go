@@ -1,27 +1,95 @@
 package state
 
 import (
+	"crypto/cipher"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+)
+
+var (
+	// ErrEncrypted 状态文件已加密，但没有通过 SetKeySource 提供密钥
+	ErrEncrypted = errors.New("state file is encrypted, provide the backup password or key file")
+	// ErrOtherMachine 状态文件由其他机器创建（共享的状态目录），不能在本机续传
+	ErrOtherMachine = errors.New("state file was created on another machine")
 )
 
+// sealedState 使用备份密钥加密的状态文件
+// 对象名保留明文，便于列出未完成的上传（状态文件名同样由对象名生成）
+type sealedState struct {
+	Key    string `json:"key"`
+	Salt   []byte `json:"salt"`
+	Sealed []byte `json:"sealed"` // [nonce][AES-256-GCM 密文]
+}
+
 // UploadState 上传状态
 type UploadState struct {
-	Key           string          `json:"key"`
-	UploadID      string          `json:"upload_id"`
-	Bucket        string          `json:"bucket"`
-	Provider      string          `json:"provider"`
-	Endpoint      string          `json:"endpoint"`
-	Region        string          `json:"region"`
-	StorageClass  string          `json:"storage_class"`
-	Encrypted     bool            `json:"encrypted"`
-	Completed     []CompletedPart `json:"completed"`
-	LastUpdated   time.Time       `json:"last_updated"`
-	TotalBytes    int64           `json:"total_bytes"`
-	UploadedBytes int64           `json:"uploaded_bytes"`
+	Key            string          `json:"key"`
+	UploadID       string          `json:"upload_id"`
+	Bucket         string          `json:"bucket"`
+	Provider       string          `json:"provider"`
+	Endpoint       string          `json:"endpoint"`
+	Region         string          `json:"region"`
+	StorageClass   string          `json:"storage_class"`
+	Encrypted      bool            `json:"encrypted"`
+	Cipher         string          `json:"cipher,omitempty"`          // 加密算法，见 crypto.CipherName
+	FormatVersion  int             `json:"format_version,omitempty"`  // 加密格式版本
+	KDF            string          `json:"kdf,omitempty"`             // 密钥派生方式（argon2id 或 key-file）
+	Salt           []byte          `json:"salt,omitempty"`            // 密码派生使用的盐值（已知时）
+	KeyFingerprint string          `json:"key_fingerprint,omitempty"` // 密钥文件指纹，使用密码时为空
+	Completed      []CompletedPart `json:"completed"`
+	LastUpdated    time.Time       `json:"last_updated"`
+	Machine        string          `json:"machine,omitempty"` // 创建状态的机器，见 MachineID
+	TotalBytes     int64           `json:"total_bytes"`
+	UploadedBytes  int64           `json:"uploaded_bytes"`
+	ElapsedSeconds float64         `json:"elapsed_seconds,omitempty"` // 累计上传用时（包含之前中断的上传），由 Checkpoint 定期更新
+
+	// 上传队列：已从数据流读出的分块（Completed 与 Pending 按分块号依次拼接即为数据流的前 ProducedBytes 字节），
+	// 续传时按记录的分块边界只重建未完成的分块，分块大小不要求与上传开始时一致
+	Pending       []PendingPart   `json:"pending,omitempty"`        // 已读出但尚未上传完成的分块
+	ProducedParts int             `json:"produced_parts,omitempty"` // 已读出的分块数
+	ProducedBytes int64           `json:"produced_bytes,omitempty"` // 已读出的字节数
+	Archive       *ArchiveOptions `json:"archive,omitempty"`        // 生成数据流的归档设置，resume 按其重新归档（数据库导出等无法重新生成，为 nil）
+
+	// 开始上传时的对象选项，续传（可能在另一个进程中）完成上传或重新开始时使用相同的设置
+	NoOverwrite bool              `json:"no_overwrite,omitempty"` // 完成上传时对象已存在则失败
+	Metadata    map[string]string `json:"metadata,omitempty"`     // 对象元数据（过期时间等）
+
+	// 以下字段仅在上传本地文件（upload 命令或 backup.spool_dir）时使用，续传时按偏移读取分块
+	Source        string    `json:"source,omitempty"`          // 源文件绝对路径
+	Spooled       bool      `json:"spooled,omitempty"`         // 源文件是 backup.spool_dir 中的临时文件，上传完成后删除
+	SourceSize    int64     `json:"source_size,omitempty"`     // 源文件大小，续传前校验
+	SourceModTime time.Time `json:"source_mod_time,omitempty"` // 源文件修改时间，续传前校验
+	ChunkSize     int64     `json:"chunk_size,omitempty"`      // 分块大小，续传时必须一致
+	Header        []byte    `json:"header,omitempty"`          // 加密文件头（IV 和盐值，不含密钥）
+	KeyCheck      []byte    `json:"key_check,omitempty"`       // 密钥校验值，续传前确认密钥一致
+}
+
+// ArchiveOptions 流式备份中影响归档数据流内容的设置（已解析的包含路径和排除模式、压缩和填充等），
+// 续传时按原样重新归档，不受当前配置和命令行参数的影响
+type ArchiveOptions struct {
+	Includes         []string `json:"includes"`
+	Excludes         []string `json:"excludes,omitempty"`
+	IgnoreCase       bool     `json:"ignore_case,omitempty"`
+	Compression      string   `json:"compression,omitempty"`
+	ZstdDictionary   string   `json:"zstd_dictionary,omitempty"` // 字典文件路径，内容变化时续传的校验值比对失败
+	SmartCompression bool     `json:"smart_compression,omitempty"`
+	StoreExtensions  []string `json:"store_extensions,omitempty"`
+	ParallelRoots    int      `json:"parallel_roots,omitempty"`
+	IncludeDevices   bool     `json:"include_devices,omitempty"`
+	Xattrs           bool     `json:"xattrs,omitempty"`
+	SELinux          bool     `json:"selinux,omitempty"`
+	Padding          string   `json:"padding,omitempty"`
+	PaddingBucket    int64    `json:"padding_bucket,omitempty"`
 }
 
 // CompletedPart 已完成的分块
@@ -29,6 +97,25 @@ type CompletedPart struct {
 	PartNumber int    `json:"part_number"`
 	ETag       string `json:"etag"`
 	Size       int64  `json:"size"`
+
+	// 分块在数据流中的偏移和内容校验值（见 PartChecksum），续传时用于确认重新生成的数据流
+	// 与已上传的分块对齐；旧版本的状态文件没有记录
+	Offset   int64  `json:"offset,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// PartChecksum 返回分块内容的校验值（CRC-32C），只用于续传时比对本地重新生成的数据，不是安全哈希
+func PartChecksum(data []byte) string {
+	return fmt.Sprintf("crc32c:%08x", crc32.Checksum(data, castagnoli))
+}
+
+// PendingPart 已从数据流读出、尚未上传完成的分块
+type PendingPart struct {
+	PartNumber int   `json:"part_number"`
+	Offset     int64 `json:"offset"` // 在数据流中的偏移
+	Size       int64 `json:"size"`
 }
 
 // StateManager 状态管理器
@@ -36,26 +123,115 @@ type StateManager struct {
 	stateFile string
 	state     *UploadState
 	mu        sync.RWMutex
+
+	// 设置 keys 后状态文件加密保存；gcm 按 salt 派生后缓存，避免每次保存都重新派生
+	keys *crypto.KeySource
+	salt []byte
+	gcm  cipher.AEAD
+
+	// 分块记录后的保存由一个后台 goroutine 合并执行：dirty 表示有未写入的修改，saving 表示已有 goroutine 等待写入
+	dirty  bool
+	saving bool
+	saved  sync.WaitGroup
+}
+
+// DefaultStateDir 返回默认状态文件目录 ~/.s3backup/state/<机器标识>
+// 按机器区分，避免共享家目录的多台主机互相使用对方的续传状态
+func DefaultStateDir() string {
+	return filepath.Join(legacyStateDir(), MachineID())
+}
+
+// legacyStateDir 旧版本不区分机器的状态目录 ~/.s3backup/state
+func legacyStateDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".s3backup", "state")
 }
 
 // NewStateManager 创建状态管理器
+// stateDir 为空时使用 DefaultStateDir，旧版本保存在 ~/.s3backup/state 的状态文件仍可继续使用
 func NewStateManager(stateDir string, key string) *StateManager {
+	name := safeFilename(key) + ".json"
 	if stateDir == "" {
-		home, _ := os.UserHomeDir()
-		stateDir = filepath.Join(home, ".s3backup", "state")
+		stateDir = DefaultStateDir()
+		legacy := filepath.Join(legacyStateDir(), name)
+		if !fileExists(filepath.Join(stateDir, name)) && fileExists(legacy) {
+			return &StateManager{stateFile: legacy}
+		}
 	}
 
-	// 创建状态目录
-	os.MkdirAll(stateDir, 0755)
+	// 创建状态目录，状态文件包含存储桶、端点等信息，只允许当前用户访问
+	os.MkdirAll(stateDir, 0700)
 
 	// 生成状态文件名（使用 key 的 hash）
-	stateFile := filepath.Join(stateDir, safeFilename(key)+".json")
+	stateFile := filepath.Join(stateDir, name)
 
 	return &StateManager{
 		stateFile: stateFile,
 	}
 }
 
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// ListStates 列出状态目录中本机未完成的上传状态，无法解析的文件和其他机器的状态会被忽略
+// stateDir 为空时同时列出旧版本 ~/.s3backup/state 中的状态
+func ListStates(stateDir string) ([]*UploadState, error) {
+	if stateDir != "" {
+		return listStates(stateDir)
+	}
+	states, err := listStates(DefaultStateDir())
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := listStates(legacyStateDir())
+	if err != nil {
+		return nil, err
+	}
+	return append(states, legacy...), nil
+}
+
+func listStates(stateDir string) ([]*UploadState, error) {
+	entries, err := os.ReadDir(stateDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var states []*UploadState
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(stateDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		// 加密的状态文件只能得到对象名
+		var state UploadState
+		if err := json.Unmarshal(data, &state); err != nil || state.Key == "" {
+			continue
+		}
+		if state.Machine != "" && state.Machine != MachineID() {
+			continue
+		}
+		states = append(states, &state)
+	}
+
+	return states, nil
+}
+
+// RedactID 返回用于输出的 UploadID 缩写，完整的 UploadID 只保存在状态文件中
+func RedactID(id string) string {
+	if len(id) <= 8 {
+		return id
+	}
+	return id[:8] + "..."
+}
+
 // safeFilename 生成安全的文件名
 func safeFilename(key string) string {
 	// 简单替换不安全字符
@@ -83,29 +259,131 @@ func (sm *StateManager) Load() (*UploadState, error) {
 		return nil, err
 	}
 
+	var sealed sealedState
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return nil, err
+	}
+	if sealed.Sealed != nil {
+		if data, err = sm.open(&sealed); err != nil {
+			return nil, err
+		}
+	}
+
 	var state UploadState
 	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, err
 	}
+	if machine := MachineID(); state.Machine != "" && state.Machine != machine {
+		return nil, fmt.Errorf("%w: %s (this machine is %s), remove %s or set S3BACKUP_MACHINE_ID",
+			ErrOtherMachine, state.Machine, machine, sm.stateFile)
+	}
 
 	sm.state = &state
 	return &state, nil
 }
 
+// SetKeySource 设置备份密钥，之后状态文件加密保存，加载加密的状态文件时也需要先设置
+func (sm *StateManager) SetKeySource(keys crypto.KeySource) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.keys = &keys
+	sm.salt, sm.gcm = nil, nil
+}
+
+// open 解密状态文件，并缓存密钥用于之后的保存，调用方需持有锁
+func (sm *StateManager) open(sealed *sealedState) ([]byte, error) {
+	if sm.keys == nil {
+		return nil, ErrEncrypted
+	}
+	gcm, err := crypto.NewLocalGCM(*sm.keys, sealed.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed.Sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid encrypted state file: too short")
+	}
+	nonce, ciphertext := sealed.Sealed[:gcm.NonceSize()], sealed.Sealed[gcm.NonceSize():]
+	data, err := gcm.Open(nil, nonce, ciphertext, []byte(sealed.Key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt state file: wrong key or corrupted file")
+	}
+	sm.salt, sm.gcm = sealed.Salt, gcm
+	return data, nil
+}
+
+// encode 编码状态，设置了密钥时加密，调用方需持有锁
+func (sm *StateManager) encode(state *UploadState) ([]byte, error) {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil || sm.keys == nil {
+		return data, err
+	}
+
+	if sm.gcm == nil {
+		salt, err := crypto.GenerateSalt()
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := crypto.NewLocalGCM(*sm.keys, salt)
+		if err != nil {
+			return nil, err
+		}
+		sm.salt, sm.gcm = salt, gcm
+	}
+	nonce := make([]byte, sm.gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := sealedState{
+		Key:    state.Key,
+		Salt:   sm.salt,
+		Sealed: sm.gcm.Seal(nonce, nonce, data, []byte(state.Key)),
+	}
+	return json.MarshalIndent(sealed, "", "  ")
+}
+
+// writeFile 写入状态文件，权限为 0600（同时修正旧版本以 0644 创建的文件）
+// 先写入同一目录中的临时文件再重命名，写入中途崩溃不会留下不完整的状态文件
+func (sm *StateManager) writeFile(data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(sm.stateFile), filepath.Base(sm.stateFile)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0600)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), sm.stateFile)
+}
+
 // Save 保存状态
 func (sm *StateManager) Save(state *UploadState) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
 	state.LastUpdated = time.Now()
+	if state.Machine == "" {
+		state.Machine = MachineID()
+	}
 	sm.state = state
+	sm.dirty = false
 
-	data, err := json.MarshalIndent(state, "", "  ")
+	data, err := sm.encode(state)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(sm.stateFile, data, 0644)
+	return sm.writeFile(data)
 }
 
 // SaveWithUploadID 保存带 UploadID 的状态
@@ -120,6 +398,7 @@ func (sm *StateManager) Delete() error {
 	defer sm.mu.Unlock()
 
 	sm.state = nil
+	sm.dirty = false
 	return os.Remove(sm.stateFile)
 }
 
@@ -131,13 +410,14 @@ func (sm *StateManager) AddCompletedPart(part CompletedPart) error {
 	if sm.state == nil {
 		return nil
 	}
+	sm.state.Pending = removePending(sm.state.Pending, part.PartNumber)
 
 	// 检查是否已存在
 	for i, p := range sm.state.Completed {
 		if p.PartNumber == part.PartNumber {
 			sm.state.Completed[i] = part
 			sm.state.LastUpdated = time.Now()
-			go sm.saveAsync(sm.state)
+			sm.scheduleSave()
 			return nil
 		}
 	}
@@ -147,18 +427,98 @@ func (sm *StateManager) AddCompletedPart(part CompletedPart) error {
 	sm.state.LastUpdated = time.Now()
 
 	// 异步保存
-	go sm.saveAsync(sm.state)
+	sm.scheduleSave()
 
 	return nil
 }
 
-// saveAsync 异步保存
-func (sm *StateManager) saveAsync(state *UploadState) {
-	data, err := json.MarshalIndent(state, "", "  ")
+// AddPendingPart 记录从数据流读出、即将上传的分块，分块上传完成后由 AddCompletedPart 移出队列
+func (sm *StateManager) AddPendingPart(part PendingPart) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if sm.state == nil {
+		return nil
+	}
+
+	// 续传时重新读出的分块已在队列中
+	sm.state.Pending = append(removePending(sm.state.Pending, part.PartNumber), part)
+	sort.Slice(sm.state.Pending, func(i, j int) bool {
+		return sm.state.Pending[i].PartNumber < sm.state.Pending[j].PartNumber
+	})
+	if part.PartNumber > sm.state.ProducedParts {
+		sm.state.ProducedParts = part.PartNumber
+		sm.state.ProducedBytes = part.Offset + part.Size
+	}
+	sm.state.LastUpdated = time.Now()
+
+	sm.scheduleSave()
+
+	return nil
+}
+
+// removePending 从上传队列中移除分块
+func removePending(pending []PendingPart, partNumber int) []PendingPart {
+	for i, p := range pending {
+		if p.PartNumber == partNumber {
+			return append(pending[:i], pending[i+1:]...)
+		}
+	}
+	return pending
+}
+
+// Checkpoint 记录累计上传用时并立即保存，长时间上传期间定期调用，
+// 使状态文件的 last_updated 持续更新，外部监控可据此判断上传是否仍在进行
+// 状态已被删除（上传完成）时不再写入
+func (sm *StateManager) Checkpoint(elapsed time.Duration) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.state == nil {
+		return nil
+	}
+	sm.state.ElapsedSeconds = elapsed.Seconds()
+	sm.state.LastUpdated = time.Now()
+	sm.dirty = false
+	data, err := sm.encode(sm.state)
+	if err != nil {
+		return err
+	}
+	return sm.writeFile(data)
+}
+
+// scheduleSave 标记状态已修改，没有等待写入的 goroutine 时启动一个，调用方需持有锁
+// 分块完成得比写入快时多次修改合并为一次写入，同一时间只有一个 goroutine 写入状态文件
+func (sm *StateManager) scheduleSave() {
+	sm.dirty = true
+	if sm.saving {
+		return
+	}
+	sm.saving = true
+	sm.saved.Add(1)
+	go sm.saveAsync()
+}
+
+// saveAsync 写入最新的状态
+// 状态已被删除（上传完成）或已由 Save 写入时不再写入，避免上传完成后重新生成状态文件
+func (sm *StateManager) saveAsync() {
+	defer sm.saved.Done()
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.saving = false
+	if !sm.dirty || sm.state == nil {
+		return
+	}
+	sm.dirty = false
+	data, err := sm.encode(sm.state)
 	if err != nil {
 		return
 	}
-	os.WriteFile(sm.stateFile, data, 0644)
+	sm.writeFile(data)
+}
+
+// Flush 等待后台的状态保存完成，上传失败退出前调用，确保最后完成的分块已记录
+func (sm *StateManager) Flush() {
+	sm.saved.Wait()
 }
 
 // GetCompletedParts 获取已完成的分块
@@ -0,0 +1,318 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+)
+
+// TestSaveLoad 测试状态保存和加载
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	sm := NewStateManager(dir, "backup-test.tar.gz")
+
+	if err := sm.Save(&UploadState{
+		Key:      "backup-test.tar.gz",
+		UploadID: "upload-id",
+		Bucket:   "bucket",
+	}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := NewStateManager(dir, "backup-test.tar.gz").Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded == nil || loaded.UploadID != "upload-id" {
+		t.Errorf("unexpected loaded state: %+v", loaded)
+	}
+}
+
+// TestLoadMissing 测试状态文件不存在时返回 nil
+func TestLoadMissing(t *testing.T) {
+	sm := NewStateManager(t.TempDir(), "missing")
+
+	loaded, err := sm.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded != nil {
+		t.Errorf("expected nil state, got %+v", loaded)
+	}
+}
+
+// TestCheckpoint 测试检查点写入累计用时，状态删除后不再重新生成状态文件
+func TestCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	sm := NewStateManager(dir, "backup-test.tar.gz")
+	if err := sm.Save(&UploadState{Key: "backup-test.tar.gz"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if err := sm.Checkpoint(90 * time.Second); err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+	loaded, err := NewStateManager(dir, "backup-test.tar.gz").Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded == nil || loaded.ElapsedSeconds != 90 {
+		t.Errorf("unexpected loaded state: %+v", loaded)
+	}
+
+	if err := sm.Delete(); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := sm.Checkpoint(time.Minute); err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+	if _, err := os.Stat(sm.GetStateFile()); !os.IsNotExist(err) {
+		t.Errorf("state file recreated after Delete(): %v", err)
+	}
+}
+
+// TestPendingParts 测试上传队列：读出的分块记入队列并更新已读出的位置，上传完成后移出队列
+func TestPendingParts(t *testing.T) {
+	sm := NewStateManager(t.TempDir(), "backup-test.tar.gz")
+	if err := sm.Save(&UploadState{Key: "backup-test.tar.gz"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	sm.AddPendingPart(PendingPart{PartNumber: 2, Offset: 100, Size: 200})
+	sm.AddPendingPart(PendingPart{PartNumber: 1, Offset: 0, Size: 100})
+	sm.AddPendingPart(PendingPart{PartNumber: 2, Offset: 100, Size: 200})
+	sm.AddCompletedPart(CompletedPart{PartNumber: 1, ETag: "etag", Size: 100})
+
+	s := sm.GetState()
+	if len(s.Pending) != 1 || s.Pending[0].PartNumber != 2 {
+		t.Errorf("pending = %+v, want only part 2", s.Pending)
+	}
+	if s.ProducedParts != 2 || s.ProducedBytes != 300 {
+		t.Errorf("produced = %d parts, %d bytes, want 2 parts, 300 bytes", s.ProducedParts, s.ProducedBytes)
+	}
+}
+
+// TestConcurrentParts 测试并发记录分块后状态文件包含全部分块，且不留下临时文件；Delete 之后不再写入
+func TestConcurrentParts(t *testing.T) {
+	dir := t.TempDir()
+	sm := NewStateManager(dir, "backup-test.tar.gz")
+	if err := sm.Save(&UploadState{Key: "backup-test.tar.gz"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 50; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			sm.AddPendingPart(PendingPart{PartNumber: n, Offset: int64(n-1) * 10, Size: 10})
+			sm.AddCompletedPart(CompletedPart{PartNumber: n, ETag: "etag", Size: 10})
+		}(i)
+	}
+	wg.Wait()
+	sm.Flush()
+
+	loaded, err := NewStateManager(dir, "backup-test.tar.gz").Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded.Completed) != 50 || len(loaded.Pending) != 0 || loaded.UploadedBytes != 500 {
+		t.Errorf("loaded %d completed, %d pending, %d bytes", len(loaded.Completed), len(loaded.Pending), loaded.UploadedBytes)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("state dir contains %d files, want only the state file", len(entries))
+	}
+
+	sm.AddCompletedPart(CompletedPart{PartNumber: 51, ETag: "etag", Size: 10})
+	sm.Delete()
+	sm.Flush()
+	if _, err := os.Stat(sm.GetStateFile()); !os.IsNotExist(err) {
+		t.Errorf("state file recreated after Delete(): %v", err)
+	}
+}
+
+// TestListStates 测试列出状态目录中的上传状态
+func TestListStates(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, key := range []string{"backup-a.tar.gz", "backup-b.tar.gz.enc"} {
+		if err := NewStateManager(dir, key).Save(&UploadState{Key: key}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	// 无法解析的文件和非 JSON 文件应被忽略
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644)
+
+	states, err := ListStates(dir)
+	if err != nil {
+		t.Fatalf("ListStates() error = %v", err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("expected 2 states, got %d", len(states))
+	}
+
+	keys := map[string]bool{}
+	for _, s := range states {
+		keys[s.Key] = true
+	}
+	if !keys["backup-a.tar.gz"] || !keys["backup-b.tar.gz.enc"] {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+}
+
+// TestListStatesMissingDir 测试状态目录不存在
+func TestListStatesMissingDir(t *testing.T) {
+	states, err := ListStates(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("ListStates() error = %v", err)
+	}
+	if len(states) != 0 {
+		t.Errorf("expected no states, got %d", len(states))
+	}
+}
+
+// TestSaveFileMode 测试状态文件只允许当前用户读写，旧版本创建的 0644 文件也会被修正
+func TestSaveFileMode(t *testing.T) {
+	dir := t.TempDir()
+	sm := NewStateManager(dir, "backup-mode")
+	if err := os.WriteFile(sm.GetStateFile(), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Save(&UploadState{Key: "backup-mode"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(sm.GetStateFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("state file mode = %o, want 600", mode)
+	}
+}
+
+// TestSaveLoadEncrypted 测试使用备份密钥加密状态文件
+func TestSaveLoadEncrypted(t *testing.T) {
+	keyFile, err := crypto.GenerateKeyFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	sm := NewStateManager(dir, "backup-enc")
+	sm.SetKeySource(crypto.KeySource{KeyFile: keyFile})
+	if err := sm.Save(&UploadState{Key: "backup-enc", UploadID: "secret-upload-id", Bucket: "private-bucket"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(sm.GetStateFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-upload-id") || strings.Contains(string(data), "private-bucket") {
+		t.Errorf("state file is not encrypted: %s", data)
+	}
+
+	// 没有密钥时无法加载
+	if _, err := NewStateManager(dir, "backup-enc").Load(); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("Load() without key error = %v, want ErrEncrypted", err)
+	}
+
+	// 密钥错误时无法解密
+	other, _ := crypto.GenerateKeyFile()
+	wrong := NewStateManager(dir, "backup-enc")
+	wrong.SetKeySource(crypto.KeySource{KeyFile: other})
+	if _, err := wrong.Load(); err == nil {
+		t.Error("Load() with wrong key should fail")
+	}
+
+	loader := NewStateManager(dir, "backup-enc")
+	loader.SetKeySource(crypto.KeySource{KeyFile: keyFile})
+	loaded, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.UploadID != "secret-upload-id" || loaded.Bucket != "private-bucket" {
+		t.Errorf("unexpected loaded state: %+v", loaded)
+	}
+
+	// 列出未完成的上传时仍能得到对象名
+	states, err := ListStates(dir)
+	if err != nil || len(states) != 1 || states[0].Key != "backup-enc" {
+		t.Errorf("ListStates() = %+v, %v", states, err)
+	}
+}
+
+// TestRedactID 测试 UploadID 缩写
+func TestRedactID(t *testing.T) {
+	if got := RedactID("2~abcdefghijklmnopqrstuvwxyz"); got != "2~abcdef..." {
+		t.Errorf("RedactID() = %q", got)
+	}
+	if got := RedactID("short"); got != "short" {
+		t.Errorf("RedactID() = %q", got)
+	}
+}
+
+// TestMachineIDOverride 测试通过环境变量指定机器标识
+func TestMachineIDOverride(t *testing.T) {
+	t.Setenv("S3BACKUP_MACHINE_ID", "nas/backup 1")
+	if got := MachineID(); got != "nas-backup-1" {
+		t.Errorf("MachineID() = %q, want nas-backup-1", got)
+	}
+}
+
+// TestLoadOtherMachine 测试共享状态目录中其他机器的状态不会被使用
+func TestLoadOtherMachine(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("S3BACKUP_MACHINE_ID", "host-a")
+	if err := NewStateManager(dir, "backup.tar.gz").Save(&UploadState{Key: "backup.tar.gz", UploadID: "id"}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("S3BACKUP_MACHINE_ID", "host-b")
+	if _, err := NewStateManager(dir, "backup.tar.gz").Load(); !errors.Is(err, ErrOtherMachine) {
+		t.Errorf("Load() error = %v, want ErrOtherMachine", err)
+	}
+	if states, _ := ListStates(dir); len(states) != 0 {
+		t.Errorf("ListStates() should skip other machines, got %+v", states)
+	}
+
+	t.Setenv("S3BACKUP_MACHINE_ID", "host-a")
+	if loaded, err := NewStateManager(dir, "backup.tar.gz").Load(); err != nil || loaded.Machine != "host-a" {
+		t.Errorf("Load() = %+v, %v", loaded, err)
+	}
+}
+
+// TestDefaultStateDirLegacy 测试默认状态目录按机器区分，并兼容旧版本的状态文件
+func TestDefaultStateDirLegacy(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("S3BACKUP_MACHINE_ID", "host-a")
+
+	legacyDir := filepath.Join(home, ".s3backup", "state")
+	os.MkdirAll(legacyDir, 0700)
+	legacyFile := filepath.Join(legacyDir, "old-tar-gz.json")
+	os.WriteFile(legacyFile, []byte(`{"key":"old.tar.gz","upload_id":"id"}`), 0600)
+
+	if got := NewStateManager("", "old.tar.gz").GetStateFile(); got != legacyFile {
+		t.Errorf("legacy state file = %s, want %s", got, legacyFile)
+	}
+	sm := NewStateManager("", "new.tar.gz")
+	if want := filepath.Join(legacyDir, "host-a", "new-tar-gz.json"); sm.GetStateFile() != want {
+		t.Errorf("state file = %s, want %s", sm.GetStateFile(), want)
+	}
+	sm.Save(&UploadState{Key: "new.tar.gz"})
+
+	states, err := ListStates("")
+	if err != nil || len(states) != 2 {
+		t.Errorf("ListStates() = %+v, %v, want legacy and new state", states, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"strings"
+	"time"
 )
 
 // Mock 错误类型，用于测试
@@ -22,12 +23,16 @@ type StorageAdapter interface {
 	// 上传分块
 	UploadPart(ctx context.Context, key, uploadID string, partNum int, data io.Reader, size int64) (etag string, err error)
 
-	// 完成上传
-	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error
+	// 完成上传，opts 与初始化时相同（续传时由调用方从状态文件恢复），适配器不在内存中按上传 ID 记录选项
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart, opts UploadOptions) error
 
 	// 取消上传
 	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
 
+	// 列出已上传的分块（自动翻页，按分块号排序），续传前用于核对本地状态
+	// 上传已完成、取消或被生命周期规则清理时返回 ErrUploadNotFound
+	ListParts(ctx context.Context, key, uploadID string) ([]PartInfo, error)
+
 	// 获取支持的存储类型
 	SupportedStorageClasses() []StorageClass
 
@@ -35,11 +40,84 @@ type StorageAdapter interface {
 	SetStorageClass(ctx context.Context, key string, class StorageClass) error
 }
 
+// PartSizeLimiter 可选接口，适配器通过它声明提供商的分块大小限制
+type PartSizeLimiter interface {
+	// PartSizeLimits 返回非最后一个分块的最小和最大大小（字节）
+	PartSizeLimits() (min, max int64)
+}
+
+// PartSizeLimits 返回适配器的分块大小限制，适配器未声明时 ok 为 false
+func PartSizeLimits(adapter StorageAdapter) (min, max int64, ok bool) {
+	limiter, ok := adapter.(PartSizeLimiter)
+	if !ok {
+		return 0, 0, false
+	}
+	min, max = limiter.PartSizeLimits()
+	return min, max, true
+}
+
+// ObjectReader 可选接口，适配器通过它支持下载对象
+type ObjectReader interface {
+	// GetObject 返回对象内容，调用方负责关闭
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// RangeReader 可选接口，适配器通过它支持下载对象的一段字节
+type RangeReader interface {
+	// GetObjectRange 返回对象从 offset 开始的 length 个字节，调用方负责关闭
+	GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+}
+
+// ObjectLister 可选接口，适配器通过它支持列出对象
+type ObjectLister interface {
+	// ListObjects 返回 key 以 prefix 开头的所有对象（自动翻页）
+	ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// ObjectDeleter 可选接口，适配器通过它支持删除对象
+type ObjectDeleter interface {
+	// DeleteObject 删除对象
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// ObjectPutter 可选接口，适配器通过它支持一次请求写入小对象，以及只在对象不存在时写入
+type ObjectPutter interface {
+	// PutObject 写入或覆盖对象
+	PutObject(ctx context.Context, key string, data []byte, opts UploadOptions) error
+	// PutObjectIfAbsent 写入对象，对象已存在时不覆盖并返回 ErrObjectExists
+	PutObjectIfAbsent(ctx context.Context, key string, data []byte, opts UploadOptions) error
+}
+
+// MetadataReader 可选接口，适配器通过它支持读取对象的用户元数据
+type MetadataReader interface {
+	// ObjectMetadata 返回上传时通过 UploadOptions.Metadata 设置的元数据，键为小写
+	ObjectMetadata(ctx context.Context, key string) (map[string]string, error)
+}
+
+// PartInfo 服务端已上传的分块信息
+type PartInfo struct {
+	PartNumber int
+	ETag       string
+	Size       int64
+}
+
+// ObjectInfo 对象列表中的对象信息
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
 // UploadOptions 上传选项
 type UploadOptions struct {
-	StorageClass StorageClass
-	ContentType  string
-	Metadata     map[string]string
+	StorageClass       StorageClass
+	ContentType        string
+	ContentDisposition string // 通过控制台或浏览器下载时使用的文件名，见 ContentDispositionFor
+	Metadata           map[string]string
+	ACL                string // 预设 ACL（x-amz-acl），为空时使用存储桶的默认设置
+	// NoOverwrite 完成上传时对象已存在则失败并返回 ErrObjectExists
+	// AWS S3 和阿里云 OSS 在服务端条件完成，七牛云不支持，由调用方在上传前检查
+	NoOverwrite bool
 }
 
 // CompletedPart 已完成的分块信息
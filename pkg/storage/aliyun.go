@@ -11,6 +11,15 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// aliyunStorageClasses 阿里云 OSS支持的存储类型
+var aliyunStorageClasses = []StorageClass{
+	StorageClassStandard,
+	StorageClassInfrequent,
+	StorageClassArchive,
+	StorageClassColdArchive,
+	StorageClassDeepArchive,
+}
+
 // AliyunAdapter 阿里云 OSS 适配器
 // 阿里云 OSS 支持 S3 协议，但存储类型映射不同
 type AliyunAdapter struct {
@@ -19,7 +28,7 @@ type AliyunAdapter struct {
 }
 
 // NewAliyunAdapter 创建阿里云 OSS 适配器
-func NewAliyunAdapter(ctx context.Context, region, endpoint, bucket, accessKey, secretKey string) (*AliyunAdapter, error) {
+func NewAliyunAdapter(ctx context.Context, region, endpoint, bucket, accessKey, secretKey string, opts ...AWSOption) (*AliyunAdapter, error) {
 	cfg, err := config.LoadDefaultConfig(ctx,
 		config.WithRegion(region),
 		config.WithCredentialsProvider(aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
@@ -37,6 +46,9 @@ func NewAliyunAdapter(ctx context.Context, region, endpoint, bucket, accessKey,
 		if endpoint != "" {
 			o.BaseEndpoint = aws.String(normalizeEndpoint(endpoint))
 		}
+		for _, opt := range opts {
+			opt(o)
+		}
 	})
 
 	return &AliyunAdapter{
@@ -62,6 +74,12 @@ func (a *AliyunAdapter) InitMultipartUpload(ctx context.Context, key string, opt
 	if opts.ContentType != "" {
 		input.ContentType = aws.String(opts.ContentType)
 	}
+	if opts.ContentDisposition != "" {
+		input.ContentDisposition = aws.String(opts.ContentDisposition)
+	}
+	if opts.ACL != "" {
+		input.ACL = types.ObjectCannedACL(opts.ACL)
+	}
 	if len(opts.Metadata) > 0 {
 		if input.Metadata == nil {
 			input.Metadata = make(map[string]string)
@@ -73,7 +91,7 @@ func (a *AliyunAdapter) InitMultipartUpload(ctx context.Context, key string, opt
 
 	result, err := a.client.CreateMultipartUpload(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to create multipart upload: %w", err)
+		return "", fmt.Errorf("failed to create multipart upload: %w", classifyError("aliyun", err))
 	}
 
 	return *result.UploadId, nil
@@ -95,14 +113,14 @@ func (a *AliyunAdapter) UploadPart(ctx context.Context, key, uploadID string, pa
 
 	result, err := a.client.UploadPart(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload part %d: %w", partNum, err)
+		return "", fmt.Errorf("failed to upload part %d: %w", partNum, classifyError("aliyun", err))
 	}
 
 	return *result.ETag, nil
 }
 
-// CompleteMultipartUpload 完成上传
-func (a *AliyunAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+// CompleteMultipartUpload 完成上传，opts.NoOverwrite 时条件写入
+func (a *AliyunAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart, opts UploadOptions) error {
 	completedParts := make([]types.CompletedPart, len(parts))
 	for i, p := range parts {
 		completedParts[i] = types.CompletedPart{
@@ -118,9 +136,13 @@ func (a *AliyunAdapter) CompleteMultipartUpload(ctx context.Context, key, upload
 		MultipartUpload: &types.CompletedMultipartUpload{Parts: completedParts},
 	}
 
-	_, err := a.client.CompleteMultipartUpload(ctx, input)
+	var optFns []func(*s3.Options)
+	if opts.NoOverwrite {
+		optFns = append(optFns, withHeader("x-oss-forbid-overwrite", "true"))
+	}
+	_, err := a.client.CompleteMultipartUpload(ctx, input, optFns...)
 	if err != nil {
-		return fmt.Errorf("failed to complete multipart upload: %w", err)
+		return fmt.Errorf("failed to complete multipart upload: %w", classifyError("aliyun", err))
 	}
 
 	return nil
@@ -136,20 +158,69 @@ func (a *AliyunAdapter) AbortMultipartUpload(ctx context.Context, key, uploadID
 
 	_, err := a.client.AbortMultipartUpload(ctx, input)
 	if err != nil {
-		return fmt.Errorf("failed to abort multipart upload: %w", err)
+		return fmt.Errorf("failed to abort multipart upload: %w", classifyError("aliyun", err))
 	}
 
 	return nil
 }
 
+// GetObject 下载对象
+func (a *AliyunAdapter) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	result, err := a.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(a.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", classifyError("aliyun", err))
+	}
+
+	return result.Body, nil
+}
+
+// GetObjectRange 下载对象从 offset 开始的 length 个字节
+func (a *AliyunAdapter) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	return getObjectRange(ctx, a.client, "aliyun", a.bucket, key, offset, length)
+}
+
+// ListObjects 列出 prefix 下的所有对象
+func (a *AliyunAdapter) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	return listObjects(ctx, a.client, "aliyun", a.bucket, prefix)
+}
+
+// ListParts 列出分块上传中已上传的分块
+func (a *AliyunAdapter) ListParts(ctx context.Context, key, uploadID string) ([]PartInfo, error) {
+	return listParts(ctx, a.client, "aliyun", a.bucket, key, uploadID)
+}
+
+// PutObject 一次请求写入小对象
+func (a *AliyunAdapter) PutObject(ctx context.Context, key string, data []byte, opts UploadOptions) error {
+	return putObject(ctx, a.client, "aliyun", a.bucket, key, data, opts)
+}
+
+// PutObjectIfAbsent 通过 x-oss-forbid-overwrite 条件写入对象，对象已存在时返回 ErrObjectExists
+func (a *AliyunAdapter) PutObjectIfAbsent(ctx context.Context, key string, data []byte, opts UploadOptions) error {
+	return putObject(ctx, a.client, "aliyun", a.bucket, key, data, opts, withHeader("x-oss-forbid-overwrite", "true"))
+}
+
+// ObjectMetadata 返回对象的用户元数据
+func (a *AliyunAdapter) ObjectMetadata(ctx context.Context, key string) (map[string]string, error) {
+	return objectMetadata(ctx, a.client, "aliyun", a.bucket, key)
+}
+
+// DeleteObject 删除对象
+func (a *AliyunAdapter) DeleteObject(ctx context.Context, key string) error {
+	return deleteObject(ctx, a.client, "aliyun", a.bucket, key)
+}
+
+// PartSizeLimits 返回分块大小限制
+// 阿里云 OSS 分块大小为 100KB ~ 5GB（最后一个分块不受最小值限制）
+func (a *AliyunAdapter) PartSizeLimits() (min, max int64) {
+	return 100 * 1024, 5 * 1024 * 1024 * 1024
+}
+
 // SupportedStorageClasses 返回支持的存储类型
 func (a *AliyunAdapter) SupportedStorageClasses() []StorageClass {
-	return []StorageClass{
-		StorageClassStandard,
-		StorageClassInfrequent,
-		StorageClassArchive,
-		StorageClassDeepArchive,
-	}
+	return aliyunStorageClasses
 }
 
 // SetStorageClass 设置存储类型
@@ -167,12 +238,35 @@ func (a *AliyunAdapter) SetStorageClass(ctx context.Context, key string, class S
 
 	_, err := a.client.CopyObject(ctx, input)
 	if err != nil {
-		return fmt.Errorf("failed to set storage class: %w", err)
+		return fmt.Errorf("failed to set storage class: %w", classifyError("aliyun", err))
 	}
 
 	return nil
 }
 
+// aliyunArchivedClasses 需要取回才能读取的阿里云存储类型
+var aliyunArchivedClasses = map[string]bool{"Archive": true, "ColdArchive": true, "DeepColdArchive": true}
+
+// RestoreObject 发起归档、冷归档或深度冷归档对象的取回
+// 归档存储不接受取回档位；冷归档和深度冷归档的档位在 OSS 中为 JobParameters，
+// 而 S3 SDK 只能发送 GlacierJobParameters，签名前改写请求体
+func (a *AliyunAdapter) RestoreObject(ctx context.Context, key string, days int, tier RestoreTier) error {
+	status, err := a.RestoreStatus(ctx, key)
+	if err != nil {
+		return err
+	}
+	if status.StorageClass == "Archive" {
+		return restoreObject(ctx, a.client, "aliyun", a.bucket, key, days, "")
+	}
+	return restoreObject(ctx, a.client, "aliyun", a.bucket, key, days, tier,
+		withRequestBodyRewrite("GlacierJobParameters", "JobParameters"))
+}
+
+// RestoreStatus 返回对象的归档和取回状态
+func (a *AliyunAdapter) RestoreStatus(ctx context.Context, key string) (RestoreStatus, error) {
+	return restoreStatus(ctx, a.client, "aliyun", a.bucket, key, aliyunArchivedClasses)
+}
+
 // mapStorageClass 将通用存储类型映射到阿里云 OSS 的存储类型值
 // 阿里云 OSS 存储类型: Standard, IA, Archive, ColdArchive, DeepColdArchive
 func (a *AliyunAdapter) mapStorageClass(sc StorageClass) string {
@@ -183,8 +277,10 @@ func (a *AliyunAdapter) mapStorageClass(sc StorageClass) string {
 		return "IA"
 	case StorageClassArchive:
 		return "Archive"
-	case StorageClassDeepArchive:
+	case StorageClassColdArchive:
 		return "ColdArchive"
+	case StorageClassDeepArchive:
+		return "DeepColdArchive"
 	default:
 		return "Standard"
 	}
@@ -11,14 +11,34 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// awsStorageClasses AWS S3支持的存储类型
+var awsStorageClasses = []StorageClass{
+	StorageClassStandard,
+	StorageClassInfrequent,
+	StorageClassArchive,
+	StorageClassDeepArchive,
+	StorageClassGlacierIR,
+	StorageClassIntelligentTiering,
+}
+
 // AWSAdapter AWS S3 适配器
 type AWSAdapter struct {
 	client *s3.Client
 	bucket string
 }
 
+// AWSOption AWS 适配器可选配置（阿里云、七牛云适配器同样基于 S3 客户端，也接受这些选项）
+type AWSOption func(o *s3.Options)
+
+// WithPathStyle 使用路径风格访问（endpoint/bucket/key），MinIO 等自建 S3 兼容存储通常需要
+func WithPathStyle(enabled bool) AWSOption {
+	return func(o *s3.Options) {
+		o.UsePathStyle = enabled
+	}
+}
+
 // NewAWSAdapter 创建 AWS S3 适配器
-func NewAWSAdapter(ctx context.Context, region, endpoint, bucket, accessKey, secretKey string) (*AWSAdapter, error) {
+func NewAWSAdapter(ctx context.Context, region, endpoint, bucket, accessKey, secretKey string, opts ...AWSOption) (*AWSAdapter, error) {
 	cfg, err := config.LoadDefaultConfig(ctx,
 		config.WithRegion(region),
 		config.WithCredentialsProvider(aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
@@ -36,6 +56,9 @@ func NewAWSAdapter(ctx context.Context, region, endpoint, bucket, accessKey, sec
 		if endpoint != "" {
 			o.BaseEndpoint = aws.String(normalizeEndpoint(endpoint))
 		}
+		for _, opt := range opts {
+			opt(o)
+		}
 	})
 
 	return &AWSAdapter{
@@ -52,18 +75,24 @@ func (a *AWSAdapter) InitMultipartUpload(ctx context.Context, key string, opts U
 	}
 
 	if opts.StorageClass.IsValid() {
-		input.StorageClass = types.StorageClass(opts.StorageClass.String())
+		input.StorageClass = types.StorageClass(a.mapStorageClass(opts.StorageClass))
 	}
 	if opts.ContentType != "" {
 		input.ContentType = aws.String(opts.ContentType)
 	}
+	if opts.ContentDisposition != "" {
+		input.ContentDisposition = aws.String(opts.ContentDisposition)
+	}
+	if opts.ACL != "" {
+		input.ACL = types.ObjectCannedACL(opts.ACL)
+	}
 	if len(opts.Metadata) > 0 {
 		input.Metadata = opts.Metadata
 	}
 
 	result, err := a.client.CreateMultipartUpload(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to create multipart upload: %w", err)
+		return "", fmt.Errorf("failed to create multipart upload: %w", classifyError("aws", err))
 	}
 
 	return *result.UploadId, nil
@@ -85,14 +114,14 @@ func (a *AWSAdapter) UploadPart(ctx context.Context, key, uploadID string, partN
 
 	result, err := a.client.UploadPart(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload part %d: %w", partNum, err)
+		return "", fmt.Errorf("failed to upload part %d: %w", partNum, classifyError("aws", err))
 	}
 
 	return *result.ETag, nil
 }
 
-// CompleteMultipartUpload 完成上传
-func (a *AWSAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+// CompleteMultipartUpload 完成上传，opts.NoOverwrite 时条件写入
+func (a *AWSAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart, opts UploadOptions) error {
 	completedParts := make([]types.CompletedPart, len(parts))
 	for i, p := range parts {
 		completedParts[i] = types.CompletedPart{
@@ -108,9 +137,13 @@ func (a *AWSAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID
 		MultipartUpload: &types.CompletedMultipartUpload{Parts: completedParts},
 	}
 
-	_, err := a.client.CompleteMultipartUpload(ctx, input)
+	var optFns []func(*s3.Options)
+	if opts.NoOverwrite {
+		optFns = append(optFns, withHeader("If-None-Match", "*"))
+	}
+	_, err := a.client.CompleteMultipartUpload(ctx, input, optFns...)
 	if err != nil {
-		return fmt.Errorf("failed to complete multipart upload: %w", err)
+		return fmt.Errorf("failed to complete multipart upload: %w", classifyError("aws", err))
 	}
 
 	return nil
@@ -126,22 +159,70 @@ func (a *AWSAdapter) AbortMultipartUpload(ctx context.Context, key, uploadID str
 
 	_, err := a.client.AbortMultipartUpload(ctx, input)
 	if err != nil {
-		return fmt.Errorf("failed to abort multipart upload: %w", err)
+		return fmt.Errorf("failed to abort multipart upload: %w", classifyError("aws", err))
 	}
 
 	return nil
 }
 
+// GetObject 下载对象
+func (a *AWSAdapter) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	result, err := a.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(a.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", classifyError("aws", err))
+	}
+
+	return result.Body, nil
+}
+
+// GetObjectRange 下载对象从 offset 开始的 length 个字节
+func (a *AWSAdapter) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	return getObjectRange(ctx, a.client, "aws", a.bucket, key, offset, length)
+}
+
+// ListObjects 列出 prefix 下的所有对象
+func (a *AWSAdapter) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	return listObjects(ctx, a.client, "aws", a.bucket, prefix)
+}
+
+// ListParts 列出分块上传中已上传的分块
+func (a *AWSAdapter) ListParts(ctx context.Context, key, uploadID string) ([]PartInfo, error) {
+	return listParts(ctx, a.client, "aws", a.bucket, key, uploadID)
+}
+
+// PutObject 一次请求写入小对象
+func (a *AWSAdapter) PutObject(ctx context.Context, key string, data []byte, opts UploadOptions) error {
+	return putObject(ctx, a.client, "aws", a.bucket, key, data, opts)
+}
+
+// PutObjectIfAbsent 通过 If-None-Match: * 条件写入对象，对象已存在时返回 ErrObjectExists
+// 不支持条件写入的 S3 兼容存储（部分旧版本 MinIO 等）会忽略该请求头
+func (a *AWSAdapter) PutObjectIfAbsent(ctx context.Context, key string, data []byte, opts UploadOptions) error {
+	return putObject(ctx, a.client, "aws", a.bucket, key, data, opts, withHeader("If-None-Match", "*"))
+}
+
+// ObjectMetadata 返回对象的用户元数据
+func (a *AWSAdapter) ObjectMetadata(ctx context.Context, key string) (map[string]string, error) {
+	return objectMetadata(ctx, a.client, "aws", a.bucket, key)
+}
+
+// DeleteObject 删除对象
+func (a *AWSAdapter) DeleteObject(ctx context.Context, key string) error {
+	return deleteObject(ctx, a.client, "aws", a.bucket, key)
+}
+
+// PartSizeLimits 返回分块大小限制
+// AWS S3 分块大小为 5MB ~ 5GB（最后一个分块不受最小值限制）
+func (a *AWSAdapter) PartSizeLimits() (min, max int64) {
+	return 5 * 1024 * 1024, 5 * 1024 * 1024 * 1024
+}
+
 // SupportedStorageClasses 返回支持的存储类型
 func (a *AWSAdapter) SupportedStorageClasses() []StorageClass {
-	return []StorageClass{
-		StorageClassStandard,
-		StorageClassInfrequent,
-		StorageClassArchive,
-		StorageClassDeepArchive,
-		StorageClassGlacierIR,
-		StorageClassIntelligentTiering,
-	}
+	return awsStorageClasses
 }
 
 // SetStorageClass 设置存储类型（AWS S3 支持在上传时指定，此方法用于后续修改）
@@ -153,14 +234,45 @@ func (a *AWSAdapter) SetStorageClass(ctx context.Context, key string, class Stor
 		Bucket:            aws.String(a.bucket),
 		CopySource:        aws.String(copySource),
 		Key:               aws.String(key),
-		StorageClass:      types.StorageClass(class.String()),
+		StorageClass:      types.StorageClass(a.mapStorageClass(class)),
 		MetadataDirective: types.MetadataDirectiveReplace,
 	}
 
 	_, err := a.client.CopyObject(ctx, input)
 	if err != nil {
-		return fmt.Errorf("failed to set storage class: %w", err)
+		return fmt.Errorf("failed to set storage class: %w", classifyError("aws", err))
 	}
 
 	return nil
 }
+
+// RestoreObject 发起 Glacier/Deep Archive 对象的取回
+func (a *AWSAdapter) RestoreObject(ctx context.Context, key string, days int, tier RestoreTier) error {
+	return restoreObject(ctx, a.client, "aws", a.bucket, key, days, tier)
+}
+
+// RestoreStatus 返回对象的归档和取回状态（Glacier Instant Retrieval 可直接读取，不算归档）
+func (a *AWSAdapter) RestoreStatus(ctx context.Context, key string) (RestoreStatus, error) {
+	return restoreStatus(ctx, a.client, "aws", a.bucket, key, map[string]bool{"GLACIER": true, "DEEP_ARCHIVE": true})
+}
+
+// mapStorageClass 将通用存储类型映射到 AWS S3 的存储类型值
+// AWS S3 存储类型: STANDARD, STANDARD_IA, GLACIER, DEEP_ARCHIVE, GLACIER_IR, INTELLIGENT_TIERING
+func (a *AWSAdapter) mapStorageClass(sc StorageClass) string {
+	switch sc {
+	case StorageClassStandard:
+		return "STANDARD"
+	case StorageClassInfrequent:
+		return "STANDARD_IA"
+	case StorageClassArchive:
+		return "GLACIER"
+	case StorageClassDeepArchive:
+		return "DEEP_ARCHIVE"
+	case StorageClassGlacierIR:
+		return "GLACIER_IR"
+	case StorageClassIntelligentTiering:
+		return "INTELLIGENT_TIERING"
+	default:
+		return "STANDARD"
+	}
+}
@@ -160,6 +160,7 @@ func TestStorageClassParsing(t *testing.T) {
 		{"INFREQUENT_ACCESS", StorageClassInfrequent},
 		{"archive", StorageClassArchive},
 		{"ARCHIVE", StorageClassArchive},
+		{"cold_archive", StorageClassColdArchive},
 		{"deep_archive", StorageClassDeepArchive},
 		{"DEEP_ARCHIVE", StorageClassDeepArchive},
 		{"glacier_ir", StorageClassGlacierIR},
@@ -466,3 +467,119 @@ func mustCreateAliyunAdapter(ctx context.Context, t *testing.T) StorageAdapter {
 	}
 	return adapter
 }
+
+// TestStorageClassName 测试存储类型通用名称与解析互逆
+func TestStorageClassName(t *testing.T) {
+	classes := []StorageClass{
+		StorageClassStandard,
+		StorageClassInfrequent,
+		StorageClassArchive,
+		StorageClassDeepArchive,
+		StorageClassGlacierIR,
+		StorageClassIntelligentTiering,
+	}
+
+	for _, sc := range classes {
+		name := sc.Name()
+		if name == "" {
+			t.Errorf("%s should have a name", sc)
+			continue
+		}
+		if got := ParseStorageClass(name); got != sc {
+			t.Errorf("ParseStorageClass(%q) = %s, want %s", name, got, sc)
+		}
+	}
+}
+
+// TestSupportedStorageClassesFor 测试按提供商查询支持的存储类型
+func TestSupportedStorageClassesFor(t *testing.T) {
+	ctx := context.Background()
+	adapters := map[string]StorageAdapter{
+		"aws":    mustCreateAWSAdapter(ctx, t),
+		"qiniu":  mustCreateQiniuAdapter(ctx, t),
+		"aliyun": mustCreateAliyunAdapter(ctx, t),
+	}
+
+	for provider, adapter := range adapters {
+		got := SupportedStorageClassesFor(provider)
+		want := adapter.SupportedStorageClasses()
+		if len(got) != len(want) {
+			t.Errorf("%s: expected %d classes, got %d", provider, len(want), len(got))
+		}
+	}
+
+	if SupportedStorageClassesFor("unknown") != nil {
+		t.Error("unknown provider should return nil")
+	}
+	if SupportedStorageClassesFor("QINIU") == nil {
+		t.Error("provider name should be case-insensitive")
+	}
+}
+
+// TestPartSizeLimits 测试各提供商的分块大小限制
+func TestPartSizeLimits(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		adapter StorageAdapter
+		min     int64
+		max     int64
+	}{
+		{"AWS", mustCreateAWSAdapter(ctx, t), 5 * 1024 * 1024, 5 * 1024 * 1024 * 1024},
+		{"Qiniu", mustCreateQiniuAdapter(ctx, t), 1024 * 1024, 1024 * 1024 * 1024},
+		{"Aliyun", mustCreateAliyunAdapter(ctx, t), 100 * 1024, 5 * 1024 * 1024 * 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			min, max, ok := PartSizeLimits(tt.adapter)
+			if !ok {
+				t.Fatal("adapter should declare part size limits")
+			}
+			if min != tt.min || max != tt.max {
+				t.Errorf("PartSizeLimits() = (%d, %d), want (%d, %d)", min, max, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+// TestNativeStorageClass 测试通用存储类型到各提供商原生取值的映射
+func TestNativeStorageClass(t *testing.T) {
+	tests := []struct {
+		provider string
+		class    StorageClass
+		want     string
+	}{
+		{"aws", StorageClassInfrequent, "STANDARD_IA"},
+		{"aws", StorageClassArchive, "GLACIER"},
+		{"AWS", StorageClassIntelligentTiering, "INTELLIGENT_TIERING"},
+		{"qiniu", StorageClassInfrequent, "LINE"},
+		{"aliyun", StorageClassColdArchive, "ColdArchive"},
+		{"aliyun", StorageClassDeepArchive, "DeepColdArchive"},
+		{"qiniu", StorageClassGlacierIR, "GLACIER_IR"},
+		{"unknown", StorageClassStandard, ""},
+	}
+
+	for _, tt := range tests {
+		if got := NativeStorageClass(tt.provider, tt.class); got != tt.want {
+			t.Errorf("NativeStorageClass(%q, %q) = %q, want %q", tt.provider, tt.class, got, tt.want)
+		}
+	}
+}
+
+// TestSupportedClassesHaveNativeMapping 测试每个提供商声明支持的存储类型都有专门的原生取值
+// （映射到 STANDARD 兜底值的只能是标准存储本身）
+func TestSupportedClassesHaveNativeMapping(t *testing.T) {
+	for _, provider := range []string{"aws", "qiniu", "aliyun"} {
+		standard := NativeStorageClass(provider, StorageClassStandard)
+		for _, sc := range SupportedStorageClassesFor(provider) {
+			if sc == StorageClassStandard {
+				continue
+			}
+			if NativeStorageClass(provider, sc) == standard {
+				t.Errorf("%s: %s falls back to %s", provider, sc, standard)
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"mime"
+	"path"
+	"strings"
+)
+
+// contentTypes 按后缀推断的 Content-Type，按顺序匹配，较长的后缀在前
+var contentTypes = []struct {
+	suffix      string
+	contentType string
+}{
+	// 加密文件无法直接打开，不论内层格式
+	{".enc", "application/octet-stream"},
+	{".tar.gz", "application/gzip"},
+	{".tgz", "application/gzip"},
+	{".gz", "application/gzip"},
+	{".tar.zst", "application/zstd"},
+	{".zst", "application/zstd"},
+	{".tar.xz", "application/x-xz"},
+	{".xz", "application/x-xz"},
+	{".tar.bz2", "application/x-bzip2"},
+	{".bz2", "application/x-bzip2"},
+	{".tar", "application/x-tar"},
+	{".zip", "application/zip"},
+	{".7z", "application/x-7z-compressed"},
+}
+
+// ContentTypeFor 根据对象名的后缀推断 Content-Type，无法识别时返回 application/octet-stream
+func ContentTypeFor(key string) string {
+	name := strings.ToLower(path.Base(key))
+	for _, ct := range contentTypes {
+		if strings.HasSuffix(name, ct.suffix) {
+			return ct.contentType
+		}
+	}
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// ContentDispositionFor 返回以对象名最后一段作为下载文件名的 Content-Disposition
+// 非 ASCII 文件名按 RFC 2231 编码
+func ContentDispositionFor(key string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)})
+}
@@ -0,0 +1,37 @@
+package storage
+
+import "testing"
+
+// TestContentTypeFor 测试按对象名后缀推断 Content-Type
+func TestContentTypeFor(t *testing.T) {
+	tests := map[string]string{
+		"backup-20240101-120000.tar.gz":     "application/gzip",
+		"backup-20240101-120000.tar.gz.enc": "application/octet-stream",
+		"db/dump.TGZ":                       "application/gzip",
+		"backup.tar.zst":                    "application/zstd",
+		"backup.tar":                        "application/x-tar",
+		"photos.zip":                        "application/zip",
+		"data.json":                         "application/json",
+		"no-extension":                      "application/octet-stream",
+	}
+	for key, want := range tests {
+		if got := ContentTypeFor(key); got != want {
+			t.Errorf("ContentTypeFor(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+// TestContentDispositionFor 测试下载文件名取对象名最后一段，并正确转义
+func TestContentDispositionFor(t *testing.T) {
+	tests := map[string]string{
+		"backup.tar.gz.enc":      "attachment; filename=backup.tar.gz.enc",
+		"prefix/2024/backup.zip": "attachment; filename=backup.zip",
+		"my backup.tar.gz":       `attachment; filename="my backup.tar.gz"`,
+		"备份.tar.gz":              "attachment; filename*=utf-8''%E5%A4%87%E4%BB%BD.tar.gz",
+	}
+	for key, want := range tests {
+		if got := ContentDispositionFor(key); got != want {
+			t.Errorf("ContentDispositionFor(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// 存储错误分类，适配器返回的错误可通过 errors.Is 判断
+var (
+	// ErrThrottled 服务端限流（SlowDown/503 等），调用方应降低请求频率后重试
+	ErrThrottled = errors.New("request throttled by storage provider")
+	// ErrAuth 凭证无效或权限不足
+	ErrAuth = errors.New("storage authentication failed")
+	// ErrBucketNotFound 存储桶不存在
+	ErrBucketNotFound = errors.New("bucket not found")
+	// ErrEntityTooSmall 非最后一个分块小于提供商最小分块大小
+	ErrEntityTooSmall = errors.New("part smaller than provider minimum")
+	// ErrNetwork 网络错误（连接失败、超时、连接重置等），通常可以重试
+	ErrNetwork = errors.New("network error")
+	// ErrUploadNotFound 分块上传不存在（已完成、已取消或被生命周期规则清理）
+	ErrUploadNotFound = errors.New("multipart upload not found")
+	// ErrObjectNotFound 对象不存在
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrObjectArchived 对象为归档类型，需要先取回才能读取
+	ErrObjectArchived = errors.New("object is archived and must be restored first")
+	// ErrObjectExists 条件写入的对象已存在
+	ErrObjectExists = errors.New("object already exists")
+	// ErrRestoreInProgress 对象的取回已在进行
+	ErrRestoreInProgress = errors.New("restore already in progress")
+)
+
+// errorCodes SDK 错误码与错误分类的对应关系（各提供商的 S3 兼容接口基本一致）
+var errorCodes = map[string]error{
+	"AccessDenied":             ErrAuth,
+	"InvalidAccessKeyId":       ErrAuth,
+	"SignatureDoesNotMatch":    ErrAuth,
+	"ExpiredToken":             ErrAuth,
+	"InvalidToken":             ErrAuth,
+	"AllAccessDisabled":        ErrAuth,
+	"AccountProblem":           ErrAuth,
+	"NoSuchBucket":             ErrBucketNotFound,
+	"EntityTooSmall":           ErrEntityTooSmall,
+	"NoSuchUpload":             ErrUploadNotFound,
+	"NoSuchKey":                ErrObjectNotFound,
+	"NotFound":                 ErrObjectNotFound, // HeadObject 的 404 没有响应体
+	"InvalidObjectState":       ErrObjectArchived,
+	"RestoreAlreadyInProgress": ErrRestoreInProgress,
+	"PreconditionFailed":       ErrObjectExists,
+	"FileAlreadyExists":        ErrObjectExists, // 阿里云 x-oss-forbid-overwrite
+}
+
+// ProviderError 已分类的存储错误，errors.Is(err, Kind) 为 true
+type ProviderError struct {
+	Kind     error  // 错误分类，如 ErrAuth
+	Provider string // 存储提供商
+	Code     string // 提供商返回的错误码或 HTTP 状态
+	Err      error  // 原始错误
+}
+
+// Error 实现 error 接口
+func (e *ProviderError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%s: %v: %v", e.Provider, e.Kind, e.Err)
+	}
+	return fmt.Sprintf("%s: %v (%s): %v", e.Provider, e.Kind, e.Code, e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Is 使 errors.Is(err, e.Kind) 成立
+func (e *ProviderError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// maxThrottleBackoff 限流退避的最大等待时间
+const maxThrottleBackoff = 30 * time.Second
+
+// ThrottledError 携带提供商退避建议的限流错误，errors.Is(err, ErrThrottled) 为 true
+type ThrottledError struct {
+	Provider   string        // 存储提供商
+	Code       string        // 提供商返回的错误码或 HTTP 状态
+	RetryAfter time.Duration // 服务端通过 Retry-After 建议的等待时间，未提供时为 0
+	Err        error         // 原始错误
+	baseDelay  time.Duration
+}
+
+// Error 实现 error 接口
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s throttled (%s): %v", e.Provider, e.Code, e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e *ThrottledError) Unwrap() error {
+	return e.Err
+}
+
+// Is 使 errors.Is(err, ErrThrottled) 成立
+func (e *ThrottledError) Is(target error) bool {
+	return target == ErrThrottled
+}
+
+// Backoff 返回第 attempt 次重试（从 0 开始）前应等待的时间
+// 取服务端 Retry-After 与提供商基础退避指数增长值中的较大者，最多 30 秒
+func (e *ThrottledError) Backoff(attempt int) time.Duration {
+	delay := e.baseDelay
+	for i := 0; i < attempt && delay < maxThrottleBackoff; i++ {
+		delay *= 2
+	}
+	if e.RetryAfter > delay {
+		delay = e.RetryAfter
+	}
+	if delay > maxThrottleBackoff {
+		delay = maxThrottleBackoff
+	}
+	return delay
+}
+
+// throttlePolicy 提供商的限流识别规则和基础退避时间
+type throttlePolicy struct {
+	codes     map[string]bool
+	statuses  map[int]bool
+	baseDelay time.Duration
+}
+
+// throttlePolicies 各提供商的限流规则
+var throttlePolicies = map[string]throttlePolicy{
+	"aws": {
+		codes: map[string]bool{
+			"SlowDown":             true,
+			"RequestLimitExceeded": true,
+			"Throttling":           true,
+			"ThrottlingException":  true,
+			"TooManyRequests":      true,
+			"RequestThrottled":     true,
+			"ServiceUnavailable":   true,
+		},
+		statuses:  map[int]bool{http.StatusServiceUnavailable: true, http.StatusTooManyRequests: true},
+		baseDelay: 500 * time.Millisecond,
+	},
+	"aliyun": {
+		codes: map[string]bool{
+			"SlowDown":           true,
+			"QpsLimitExceeded":   true,
+			"ServiceUnavailable": true,
+		},
+		statuses:  map[int]bool{http.StatusServiceUnavailable: true, http.StatusTooManyRequests: true},
+		baseDelay: 1 * time.Second,
+	},
+	"qiniu": {
+		codes: map[string]bool{
+			"SlowDown":           true,
+			"ServiceUnavailable": true,
+		},
+		// 573: 七牛单个资源访问频率过高
+		statuses:  map[int]bool{http.StatusServiceUnavailable: true, http.StatusTooManyRequests: true, 573: true},
+		baseDelay: 2 * time.Second,
+	},
+}
+
+// IsThrottled 判断错误是否为服务端限流
+func IsThrottled(err error) bool {
+	return errors.Is(err, ErrThrottled)
+}
+
+// IsRetryable 判断错误是否可以重试（限流或网络错误）
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrThrottled) || errors.Is(err, ErrNetwork)
+}
+
+// classifyError 将 SDK 错误转换为带类型的存储错误，无法识别时原样返回
+func classifyError(provider string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if throttled := classifyThrottle(provider, err); throttled != nil {
+		return throttled
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		if kind, ok := errorCodes[apiErr.ErrorCode()]; ok {
+			return &ProviderError{Kind: kind, Provider: provider, Code: apiErr.ErrorCode(), Err: err}
+		}
+	}
+
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) && respErr.Response != nil && respErr.Response.Response != nil {
+		switch status := respErr.HTTPStatusCode(); status {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return &ProviderError{Kind: ErrAuth, Provider: provider, Code: strconv.Itoa(status), Err: err}
+		case http.StatusPreconditionFailed:
+			return &ProviderError{Kind: ErrObjectExists, Provider: provider, Code: strconv.Itoa(status), Err: err}
+		}
+	}
+
+	// 请求未能发送或响应读取中断
+	var sendErr *smithyhttp.RequestSendError
+	var netErr net.Error
+	if errors.As(err, &sendErr) || errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &ProviderError{Kind: ErrNetwork, Provider: provider, Err: err}
+	}
+
+	return err
+}
+
+// classifyThrottle 识别提供商的限流错误，不是限流时返回 nil
+func classifyThrottle(provider string, err error) *ThrottledError {
+	policy, ok := throttlePolicies[provider]
+	if !ok {
+		policy = throttlePolicies["aws"]
+	}
+
+	var code string
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && policy.codes[apiErr.ErrorCode()] {
+		code = apiErr.ErrorCode()
+	}
+
+	var retryAfter time.Duration
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) && respErr.Response != nil && respErr.Response.Response != nil {
+		status := respErr.HTTPStatusCode()
+		if code == "" && policy.statuses[status] {
+			code = strconv.Itoa(status)
+		}
+		if secs, err := strconv.Atoi(respErr.Response.Header.Get("Retry-After")); err == nil && secs > 0 {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+	}
+
+	if code == "" {
+		return nil
+	}
+
+	return &ThrottledError{
+		Provider:   provider,
+		Code:       code,
+		RetryAfter: retryAfter,
+		Err:        err,
+		baseDelay:  policy.baseDelay,
+	}
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// httpError 构造带 HTTP 状态码和响应头的 SDK 错误
+func httpError(status int, header http.Header) error {
+	return &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status, Header: header}},
+		Err:      errors.New("http error"),
+	}
+}
+
+// TestClassifyErrorThrottled 测试各提供商限流错误识别
+func TestClassifyErrorThrottled(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		err      error
+		want     bool
+	}{
+		{"plain error", "aws", errors.New("boom"), false},
+		{"aws SlowDown", "aws", &smithy.GenericAPIError{Code: "SlowDown"}, true},
+		{"aws RequestLimitExceeded", "aws", &smithy.GenericAPIError{Code: "RequestLimitExceeded"}, true},
+		{"aws AccessDenied", "aws", &smithy.GenericAPIError{Code: "AccessDenied"}, false},
+		{"aws HTTP 503", "aws", httpError(503, http.Header{}), true},
+		{"aws HTTP 403", "aws", httpError(403, http.Header{}), false},
+		{"aliyun QpsLimitExceeded", "aliyun", &smithy.GenericAPIError{Code: "QpsLimitExceeded"}, true},
+		{"qiniu HTTP 573", "qiniu", httpError(573, http.Header{}), true},
+		{"aws HTTP 573", "aws", httpError(573, http.Header{}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyError(tt.provider, tt.err)
+			if got := IsThrottled(err); got != tt.want {
+				t.Errorf("IsThrottled(classifyError()) = %v, want %v", got, tt.want)
+			}
+			// 无论是否识别，原始错误都应保留在错误链中
+			if !errors.Is(err, tt.err) {
+				t.Error("classified error should wrap the original error")
+			}
+		})
+	}
+}
+
+// TestIsThrottledWrapped 测试经过 fmt.Errorf 包装后仍可识别
+func TestIsThrottledWrapped(t *testing.T) {
+	err := fmt.Errorf("failed to upload part 1: %w", classifyError("aws", &smithy.GenericAPIError{Code: "SlowDown"}))
+	if !IsThrottled(err) {
+		t.Error("wrapped throttled error should be detected")
+	}
+	if IsThrottled(nil) {
+		t.Error("nil should not be throttled")
+	}
+}
+
+// TestThrottledErrorBackoff 测试退避时间计算
+func TestThrottledErrorBackoff(t *testing.T) {
+	err := classifyError("qiniu", &smithy.GenericAPIError{Code: "SlowDown"})
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("expected ThrottledError, got %T", err)
+	}
+
+	if got := throttled.Backoff(0); got != 2*time.Second {
+		t.Errorf("Backoff(0) = %v, want 2s", got)
+	}
+	if got := throttled.Backoff(2); got != 8*time.Second {
+		t.Errorf("Backoff(2) = %v, want 8s", got)
+	}
+	if got := throttled.Backoff(10); got != maxThrottleBackoff {
+		t.Errorf("Backoff(10) = %v, want %v", got, maxThrottleBackoff)
+	}
+}
+
+// TestThrottledErrorRetryAfter 测试优先使用服务端 Retry-After
+func TestThrottledErrorRetryAfter(t *testing.T) {
+	err := classifyError("aws", httpError(503, http.Header{"Retry-After": []string{"7"}}))
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("expected ThrottledError, got %T", err)
+	}
+
+	if throttled.RetryAfter != 7*time.Second {
+		t.Errorf("RetryAfter = %v, want 7s", throttled.RetryAfter)
+	}
+	if got := throttled.Backoff(0); got != 7*time.Second {
+		t.Errorf("Backoff(0) = %v, want 7s", got)
+	}
+}
+
+// TestClassifyErrorKinds 测试错误分类
+func TestClassifyErrorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"AccessDenied", &smithy.GenericAPIError{Code: "AccessDenied"}, ErrAuth},
+		{"InvalidAccessKeyId", &smithy.GenericAPIError{Code: "InvalidAccessKeyId"}, ErrAuth},
+		{"SignatureDoesNotMatch", &smithy.GenericAPIError{Code: "SignatureDoesNotMatch"}, ErrAuth},
+		{"HTTP 403", httpError(403, http.Header{}), ErrAuth},
+		{"NoSuchBucket", &smithy.GenericAPIError{Code: "NoSuchBucket"}, ErrBucketNotFound},
+		{"EntityTooSmall", &smithy.GenericAPIError{Code: "EntityTooSmall"}, ErrEntityTooSmall},
+		{"NoSuchKey", &smithy.GenericAPIError{Code: "NoSuchKey"}, ErrObjectNotFound},
+		{"SlowDown", &smithy.GenericAPIError{Code: "SlowDown"}, ErrThrottled},
+		{"send error", &smithyhttp.RequestSendError{Err: errors.New("connection refused")}, ErrNetwork},
+		{"net error", &net.OpError{Op: "dial", Err: errors.New("connection reset")}, ErrNetwork},
+		{"unexpected EOF", fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), ErrNetwork},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyError("aws", tt.err)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("classifyError() = %v, want kind %v", err, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("classified error should wrap the original error")
+			}
+		})
+	}
+}
+
+// TestClassifyErrorUnknown 测试无法识别的错误原样返回
+func TestClassifyErrorUnknown(t *testing.T) {
+	orig := &smithy.GenericAPIError{Code: "InternalError"}
+	if err := classifyError("aws", orig); err != orig {
+		t.Errorf("classifyError() = %v, want original error", err)
+	}
+	if classifyError("aws", nil) != nil {
+		t.Error("classifyError(nil) should be nil")
+	}
+}
+
+// TestIsRetryable 测试可重试判断
+func TestIsRetryable(t *testing.T) {
+	if !IsRetryable(classifyError("aws", &smithy.GenericAPIError{Code: "SlowDown"})) {
+		t.Error("throttled error should be retryable")
+	}
+	if !IsRetryable(classifyError("aws", &smithyhttp.RequestSendError{Err: errors.New("timeout")})) {
+		t.Error("network error should be retryable")
+	}
+	if IsRetryable(classifyError("aws", &smithy.GenericAPIError{Code: "AccessDenied"})) {
+		t.Error("auth error should not be retryable")
+	}
+}
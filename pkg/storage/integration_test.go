@@ -0,0 +1,326 @@
+//go:build integration
+
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/lukelzlz/s3backup/pkg/crypto"
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/uploader"
+	"github.com/testcontainers/testcontainers-go/modules/minio"
+)
+
+// 集成测试需要 Docker，运行方式：
+//
+//	go test -tags integration ./pkg/storage/...
+
+const (
+	minioImage   = "minio/minio:RELEASE.2024-01-16T16-07-38Z"
+	testBucket   = "s3backup-integration"
+	testPartSize = 5 * 1024 * 1024
+)
+
+var (
+	minioEndpoint string
+	minioUser     string
+	minioPassword string
+)
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	container, err := minio.Run(ctx, minioImage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start MinIO container: %v\n", err)
+		os.Exit(1)
+	}
+
+	endpoint, err := container.ConnectionString(ctx)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		fmt.Fprintf(os.Stderr, "failed to get MinIO endpoint: %v\n", err)
+		os.Exit(1)
+	}
+	minioEndpoint = "http://" + endpoint
+	minioUser = container.Username
+	minioPassword = container.Password
+
+	if _, err := rawClient().CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(testBucket)}); err != nil {
+		_ = container.Terminate(ctx)
+		fmt.Fprintf(os.Stderr, "failed to create bucket: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	_ = container.Terminate(ctx)
+	os.Exit(code)
+}
+
+// rawClient 返回直接访问 MinIO 的 S3 客户端，用于准备数据和校验结果
+func rawClient() *s3.Client {
+	return s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(minioEndpoint),
+		UsePathStyle: true,
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: minioUser, SecretAccessKey: minioPassword}, nil
+		}),
+	})
+}
+
+// newAdapter 创建指向 MinIO 的 AWS 适配器
+func newAdapter(t *testing.T, bucket string) *storage.AWSAdapter {
+	t.Helper()
+	adapter, err := storage.NewAWSAdapter(context.Background(), "us-east-1", minioEndpoint, bucket,
+		minioUser, minioPassword, storage.WithPathStyle(true))
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+	return adapter
+}
+
+// randomData 生成随机测试数据
+func randomData(t *testing.T, size int) []byte {
+	t.Helper()
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatalf("failed to generate data: %v", err)
+	}
+	return data
+}
+
+// download 下载对象内容
+func download(t *testing.T, key string) []byte {
+	t.Helper()
+	out, err := rawClient().GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(testBucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		t.Fatalf("failed to get object %s: %v", key, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		t.Fatalf("failed to read object %s: %v", key, err)
+	}
+	return data
+}
+
+// TestIntegrationMultipartUpload 测试真实的多分块上传和下载
+func TestIntegrationMultipartUpload(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	data := randomData(t, 2*testPartSize+1234)
+	upl := uploader.NewUploader(newAdapter(t, testBucket), testPartSize, 2)
+
+	err := upl.Upload(ctx, "multipart.bin", bytes.NewReader(data), storage.UploadOptions{
+		StorageClass: storage.StorageClassStandard,
+		ContentType:  "application/octet-stream",
+	})
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	if stats := upl.Stats(); stats.Parts != 3 {
+		t.Errorf("expected 3 parts, got %d", stats.Parts)
+	}
+	if !bytes.Equal(download(t, "multipart.bin"), data) {
+		t.Error("downloaded data does not match uploaded data")
+	}
+}
+
+// TestIntegrationAbort 测试取消上传后分块被清理
+func TestIntegrationAbort(t *testing.T) {
+	ctx := context.Background()
+	adapter := newAdapter(t, testBucket)
+
+	uploadID, err := adapter.InitMultipartUpload(ctx, "aborted.bin", storage.UploadOptions{})
+	if err != nil {
+		t.Fatalf("InitMultipartUpload() error = %v", err)
+	}
+	etag, err := adapter.UploadPart(ctx, "aborted.bin", uploadID, 1, bytes.NewReader(randomData(t, 1024)), 1024)
+	if err != nil {
+		t.Fatalf("UploadPart() error = %v", err)
+	}
+
+	parts, err := adapter.ListParts(ctx, "aborted.bin", uploadID)
+	if err != nil {
+		t.Fatalf("ListParts() error = %v", err)
+	}
+	if len(parts) != 1 || parts[0].PartNumber != 1 || parts[0].ETag != etag || parts[0].Size != 1024 {
+		t.Errorf("ListParts() = %+v, want part 1 with ETag %s", parts, etag)
+	}
+
+	if err := adapter.AbortMultipartUpload(ctx, "aborted.bin", uploadID); err != nil {
+		t.Fatalf("AbortMultipartUpload() error = %v", err)
+	}
+
+	_, err = adapter.ListParts(ctx, "aborted.bin", uploadID)
+	if !errors.Is(err, storage.ErrUploadNotFound) {
+		t.Errorf("ListParts after abort error = %v, want ErrUploadNotFound", err)
+	}
+}
+
+// TestIntegrationResume 测试从已上传的分块恢复
+func TestIntegrationResume(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	adapter := newAdapter(t, testBucket)
+	data := randomData(t, 2*testPartSize+100)
+
+	// 模拟中断前只上传了第一个分块
+	uploadID, err := adapter.InitMultipartUpload(ctx, "resumed.bin", storage.UploadOptions{})
+	if err != nil {
+		t.Fatalf("InitMultipartUpload() error = %v", err)
+	}
+	etag, err := adapter.UploadPart(ctx, "resumed.bin", uploadID, 1, bytes.NewReader(data[:testPartSize]), testPartSize)
+	if err != nil {
+		t.Fatalf("UploadPart() error = %v", err)
+	}
+
+	saved := &state.UploadState{
+		Key:      "resumed.bin",
+		UploadID: uploadID,
+		Completed: []state.CompletedPart{
+			{PartNumber: 1, ETag: etag, Size: testPartSize},
+		},
+	}
+
+	upl := uploader.NewResumableUploader(adapter, testPartSize, 2, saved)
+	if err := upl.Resume(ctx, "resumed.bin", uploadID, bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	if !bytes.Equal(download(t, "resumed.bin"), data) {
+		t.Error("resumed object does not match original data")
+	}
+}
+
+// TestIntegrationSetStorageClass 测试上传后修改存储类型
+func TestIntegrationSetStorageClass(t *testing.T) {
+	ctx := context.Background()
+	adapter := newAdapter(t, testBucket)
+	data := randomData(t, 4096)
+
+	upl := uploader.NewUploader(adapter, testPartSize, 1)
+	if err := upl.Upload(ctx, "class.bin", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	// MinIO 只支持 STANDARD 和 REDUCED_REDUNDANCY
+	if err := adapter.SetStorageClass(ctx, "class.bin", storage.StorageClassStandard); err != nil {
+		t.Fatalf("SetStorageClass() error = %v", err)
+	}
+	if !bytes.Equal(download(t, "class.bin"), data) {
+		t.Error("object content changed after SetStorageClass")
+	}
+}
+
+// TestIntegrationListAndDelete 测试按前缀列出对象和删除对象
+func TestIntegrationListAndDelete(t *testing.T) {
+	ctx := context.Background()
+	adapter := newAdapter(t, testBucket)
+
+	upl := uploader.NewUploader(adapter, testPartSize, 1)
+	for _, key := range []string{"list/a.bin", "list/b.bin"} {
+		if err := upl.Upload(ctx, key, bytes.NewReader(randomData(t, 1000)), storage.UploadOptions{}); err != nil {
+			t.Fatalf("Upload(%s) error = %v", key, err)
+		}
+	}
+
+	objects, err := adapter.ListObjects(ctx, "list/")
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+	if len(objects) != 2 || objects[0].Size != 1000 || objects[0].LastModified.IsZero() {
+		t.Fatalf("unexpected objects: %+v", objects)
+	}
+
+	if err := adapter.DeleteObject(ctx, "list/a.bin"); err != nil {
+		t.Fatalf("DeleteObject() error = %v", err)
+	}
+	objects, err = adapter.ListObjects(ctx, "list/")
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "list/b.bin" {
+		t.Errorf("unexpected objects after delete: %+v", objects)
+	}
+}
+
+// TestIntegrationEncryptedRoundTrip 测试加密上传后下载解密
+func TestIntegrationEncryptedRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	aesKey, hmacKey, err := crypto.DeriveKey("integration-password", nil)
+	if err != nil {
+		t.Fatalf("DeriveKey() error = %v", err)
+	}
+	encryptor, err := crypto.NewStreamEncryptor(aesKey, hmacKey)
+	if err != nil {
+		t.Fatalf("NewStreamEncryptor() error = %v", err)
+	}
+
+	plaintext := randomData(t, testPartSize+4096)
+	var encrypted bytes.Buffer
+	w, err := encryptor.WrapWriter(&encrypted)
+	if err != nil {
+		t.Fatalf("WrapWriter() error = %v", err)
+	}
+	w.Write(plaintext)
+	w.Close()
+
+	upl := uploader.NewUploader(newAdapter(t, testBucket), testPartSize, 2)
+	if err := upl.Upload(ctx, "encrypted.bin", &encrypted, storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	r, err := encryptor.WrapReaderWithHMAC(bytes.NewReader(download(t, "encrypted.bin")))
+	if err != nil {
+		t.Fatalf("WrapReaderWithHMAC() error = %v", err)
+	}
+	decrypted, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read decrypted data: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Error("decrypted data does not match plaintext")
+	}
+}
+
+// TestIntegrationErrorClassification 测试真实服务端错误的分类
+func TestIntegrationErrorClassification(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := newAdapter(t, "no-such-bucket").InitMultipartUpload(ctx, "x", storage.UploadOptions{})
+	if !errors.Is(err, storage.ErrBucketNotFound) {
+		t.Errorf("expected ErrBucketNotFound, got %v", err)
+	}
+
+	bad, err := storage.NewAWSAdapter(ctx, "us-east-1", minioEndpoint, testBucket,
+		minioUser, "wrong-password", storage.WithPathStyle(true))
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+	_, err = bad.InitMultipartUpload(ctx, "x", storage.UploadOptions{})
+	if !errors.Is(err, storage.ErrAuth) {
+		t.Errorf("expected ErrAuth, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// listObjects 通过 S3 ListObjectsV2 列出 prefix 下的所有对象
+// 七牛云和阿里云的 S3 兼容接口同样支持 ListObjectsV2
+func listObjects(ctx context.Context, client *s3.Client, provider, bucket, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", classifyError(provider, err))
+		}
+		for _, obj := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          aws.ToString(obj.Key),
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: aws.ToTime(obj.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// getObjectRange 通过带 Range 头的 S3 GetObject 下载对象的一段字节
+func getObjectRange(ctx context.Context, client *s3.Client, provider, bucket, key string, offset, length int64) (io.ReadCloser, error) {
+	result, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object range: %w", classifyError(provider, err))
+	}
+	return result.Body, nil
+}
+
+// objectMetadata 通过 S3 HeadObject 读取对象的用户元数据（x-amz-meta-*，阿里云另含 x-oss-meta-*）
+func objectMetadata(ctx context.Context, client *s3.Client, provider, bucket, key string) (map[string]string, error) {
+	out, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object: %w", classifyError(provider, err))
+	}
+	meta := make(map[string]string, len(out.Metadata))
+	for k, v := range out.Metadata {
+		meta[strings.ToLower(k)] = v
+	}
+	if raw, ok := awsmiddleware.GetRawResponse(out.ResultMetadata).(*smithyhttp.Response); ok {
+		for name, values := range raw.Header {
+			if k, ok := strings.CutPrefix(strings.ToLower(name), "x-oss-meta-"); ok && len(values) > 0 {
+				meta[k] = values[0]
+			}
+		}
+	}
+	return meta, nil
+}
+
+// deleteObject 通过 S3 DeleteObject 删除对象
+func deleteObject(ctx context.Context, client *s3.Client, provider, bucket, key string) error {
+	_, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %w", classifyError(provider, err))
+	}
+	return nil
+}
+
+// listParts 通过 S3 ListParts 列出分块上传中已上传的分块
+func listParts(ctx context.Context, client *s3.Client, provider, bucket, key, uploadID string) ([]PartInfo, error) {
+	var parts []PartInfo
+	paginator := s3.NewListPartsPaginator(client, &s3.ListPartsInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list parts: %w", classifyError(provider, err))
+		}
+		for _, p := range page.Parts {
+			parts = append(parts, PartInfo{
+				PartNumber: int(aws.ToInt32(p.PartNumber)),
+				ETag:       aws.ToString(p.ETag),
+				Size:       aws.ToInt64(p.Size),
+			})
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	return parts, nil
+}
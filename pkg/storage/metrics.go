@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// RequestMetrics 按 S3 操作汇总请求耗时，用于定位慢速的存储服务，并发安全
+type RequestMetrics struct {
+	mu  sync.Mutex
+	ops map[string]*opMetrics
+}
+
+type opMetrics struct {
+	durations []time.Duration
+	errors    int
+	retries   int
+}
+
+// OperationStats 一种操作的请求统计
+type OperationStats struct {
+	Operation string
+	Count     int // 请求数（包括 SDK 内部的重试）
+	Errors    int // 没有收到响应或状态码 >= 400 的请求数
+	Retries   int // SDK 内部重试的请求数
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+	Max       time.Duration
+}
+
+// NewRequestMetrics 创建请求统计
+func NewRequestMetrics() *RequestMetrics {
+	return &RequestMetrics{ops: make(map[string]*opMetrics)}
+}
+
+// Record 记录一次请求，可直接作为 WithRequestTrace 的回调
+func (m *RequestMetrics) Record(t RequestTrace) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	op := m.ops[t.Operation]
+	if op == nil {
+		op = &opMetrics{}
+		m.ops[t.Operation] = op
+	}
+	op.durations = append(op.durations, t.Duration)
+	if t.Err != nil || t.Status >= 400 {
+		op.errors++
+	}
+	if t.Retry > 0 {
+		op.retries++
+	}
+}
+
+// Summary 返回各操作的统计，按操作名排序
+func (m *RequestMetrics) Summary() []OperationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]OperationStats, 0, len(m.ops))
+	for name, op := range m.ops {
+		d := append([]time.Duration(nil), op.durations...)
+		sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+		stats = append(stats, OperationStats{
+			Operation: name,
+			Count:     len(d),
+			Errors:    op.errors,
+			Retries:   op.retries,
+			P50:       percentile(d, 50),
+			P90:       percentile(d, 90),
+			P99:       percentile(d, 99),
+			Max:       d[len(d)-1],
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Operation < stats[j].Operation })
+	return stats
+}
+
+// percentile 按最近秩法返回已排序数据的第 p 百分位数
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,187 @@
+package mock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/storage"
+)
+
+// TestAdapterRoundTrip 测试正常的分块上传流程
+func TestAdapterRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	a := New()
+
+	id, err := a.InitMultipartUpload(ctx, "key", storage.UploadOptions{StorageClass: storage.StorageClassArchive})
+	if err != nil {
+		t.Fatalf("InitMultipartUpload() error = %v", err)
+	}
+
+	var parts []storage.CompletedPart
+	for i, s := range []string{"hello ", "world"} {
+		etag, err := a.UploadPart(ctx, "key", id, i+1, strings.NewReader(s), int64(len(s)))
+		if err != nil {
+			t.Fatalf("UploadPart(%d) error = %v", i+1, err)
+		}
+		parts = append(parts, storage.CompletedPart{PartNumber: i + 1, ETag: etag})
+	}
+
+	if got, err := a.ListParts(ctx, "key", id); err != nil || len(got) != 2 || got[1].ETag != parts[1].ETag || got[1].Size != 5 {
+		t.Errorf("ListParts() = %v, %v, want %v", got, err, parts)
+	}
+	if err := a.CompleteMultipartUpload(ctx, "key", id, parts, storage.UploadOptions{}); err != nil {
+		t.Fatalf("CompleteMultipartUpload() error = %v", err)
+	}
+
+	obj, ok := a.Object("key")
+	if !ok {
+		t.Fatal("object not found after complete")
+	}
+	if string(obj.Data) != "hello world" {
+		t.Errorf("object data = %q", obj.Data)
+	}
+	if obj.StorageClass != storage.StorageClassArchive {
+		t.Errorf("storage class = %s", obj.StorageClass)
+	}
+	if len(a.PendingUploads()) != 0 {
+		t.Errorf("pending uploads = %v", a.PendingUploads())
+	}
+}
+
+// TestAdapterCompleteRejectsBadETag 测试完成上传时校验 ETag
+func TestAdapterCompleteRejectsBadETag(t *testing.T) {
+	ctx := context.Background()
+	a := New()
+
+	id, _ := a.InitMultipartUpload(ctx, "key", storage.UploadOptions{})
+	if _, err := a.UploadPart(ctx, "key", id, 1, strings.NewReader("data"), 4); err != nil {
+		t.Fatalf("UploadPart() error = %v", err)
+	}
+
+	err := a.CompleteMultipartUpload(ctx, "key", id, []storage.CompletedPart{{PartNumber: 1, ETag: "wrong"}}, storage.UploadOptions{})
+	if !errors.Is(err, ErrInvalidPart) {
+		t.Errorf("expected ErrInvalidPart, got %v", err)
+	}
+}
+
+// TestAdapterAbort 测试取消上传
+func TestAdapterAbort(t *testing.T) {
+	ctx := context.Background()
+	a := New()
+
+	id, _ := a.InitMultipartUpload(ctx, "key", storage.UploadOptions{})
+	if err := a.AbortMultipartUpload(ctx, "key", id); err != nil {
+		t.Fatalf("AbortMultipartUpload() error = %v", err)
+	}
+	if _, err := a.UploadPart(ctx, "key", id, 1, strings.NewReader("x"), 1); !errors.Is(err, ErrNoSuchUpload) {
+		t.Errorf("expected ErrNoSuchUpload after abort, got %v", err)
+	}
+}
+
+// TestFailPartAfterBytes 测试分块在读取指定字节后失败，且只失败指定次数
+func TestFailPartAfterBytes(t *testing.T) {
+	ctx := context.Background()
+	a := New()
+	a.FailPart(2, 3, NetworkError(), 1)
+
+	id, _ := a.InitMultipartUpload(ctx, "key", storage.UploadOptions{})
+
+	if _, err := a.UploadPart(ctx, "key", id, 1, strings.NewReader("part1"), 5); err != nil {
+		t.Fatalf("part 1 should not fail: %v", err)
+	}
+
+	r := bytes.NewReader([]byte("part2"))
+	_, err := a.UploadPart(ctx, "key", id, 2, r, 5)
+	if !errors.Is(err, storage.ErrNetwork) {
+		t.Fatalf("expected ErrNetwork, got %v", err)
+	}
+	if r.Len() != 2 {
+		t.Errorf("expected 3 bytes consumed before failure, %d remaining", r.Len())
+	}
+
+	if _, err := a.UploadPart(ctx, "key", id, 2, strings.NewReader("part2"), 5); err != nil {
+		t.Errorf("second attempt should succeed: %v", err)
+	}
+	if got := a.Calls(OpUploadPart); got != 3 {
+		t.Errorf("Calls(OpUploadPart) = %d, want 3", got)
+	}
+}
+
+// TestThrottlePart 测试限流错误可被识别并携带建议等待时间
+func TestThrottlePart(t *testing.T) {
+	ctx := context.Background()
+	a := New()
+	a.ThrottlePart(0, 2, time.Second)
+
+	id, _ := a.InitMultipartUpload(ctx, "key", storage.UploadOptions{})
+	for i := 0; i < 2; i++ {
+		_, err := a.UploadPart(ctx, "key", id, i+1, strings.NewReader("x"), 1)
+		if !storage.IsThrottled(err) {
+			t.Fatalf("attempt %d: expected throttled error, got %v", i, err)
+		}
+		var throttled *storage.ThrottledError
+		if !errors.As(err, &throttled) || throttled.Backoff(0) != time.Second {
+			t.Errorf("expected 1s backoff, got %v", err)
+		}
+	}
+	if _, err := a.UploadPart(ctx, "key", id, 1, strings.NewReader("x"), 1); err != nil {
+		t.Errorf("third attempt should succeed: %v", err)
+	}
+}
+
+// TestFaultOnOtherOps 测试非分块操作的故障注入
+func TestFaultOnOtherOps(t *testing.T) {
+	ctx := context.Background()
+	a := New()
+	a.AddFault(Fault{Op: OpInit, Err: storage.ErrAuth})
+
+	if _, err := a.InitMultipartUpload(ctx, "key", storage.UploadOptions{}); !errors.Is(err, storage.ErrAuth) {
+		t.Errorf("expected ErrAuth, got %v", err)
+	}
+
+	a.ClearFaults()
+	if _, err := a.InitMultipartUpload(ctx, "key", storage.UploadOptions{}); err != nil {
+		t.Errorf("InitMultipartUpload() after ClearFaults error = %v", err)
+	}
+}
+
+// TestListAndDeleteObjects 测试按前缀列出对象和删除对象
+func TestListAndDeleteObjects(t *testing.T) {
+	ctx := context.Background()
+	a := New()
+	a.SetObject("backup-b", &Object{Data: []byte("bb")})
+	a.SetObject("backup-a", &Object{Data: []byte("a")})
+	a.SetObject("other", &Object{Data: []byte("o")})
+
+	objects, err := a.ListObjects(ctx, "backup-")
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+	if len(objects) != 2 || objects[0].Key != "backup-a" || objects[1].Size != 2 {
+		t.Errorf("unexpected objects: %+v", objects)
+	}
+
+	if err := a.DeleteObject(ctx, "backup-a"); err != nil {
+		t.Fatalf("DeleteObject() error = %v", err)
+	}
+	if _, ok := a.Object("backup-a"); ok {
+		t.Error("object should be deleted")
+	}
+}
+
+// TestLatencyRespectsContext 测试延迟等待可被上下文取消
+func TestLatencyRespectsContext(t *testing.T) {
+	a := New()
+	a.SetLatency(time.Hour, time.Hour, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := a.InitMultipartUpload(ctx, "key", storage.UploadOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
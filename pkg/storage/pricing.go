@@ -0,0 +1,78 @@
+package storage
+
+import "strings"
+
+// Pricing 存储类型的参考价格
+//
+// 价格取自各提供商公开的标准价格（AWS us-east-1、七牛华东、阿里云华东 1），
+// 不含流量、取回、阶梯优惠和资源包，仅用于上传前的粗略估算。
+type Pricing struct {
+	Currency       string  // 货币单位：USD 或 CNY
+	StoragePerGB   float64 // 每 GB 每月存储费用
+	PutPer1000     float64 // 每千次 PUT 类请求费用
+	MinStorageDays int     // 最短存储时长（天），提前删除仍按此计费
+}
+
+// pricingTables 各提供商的参考价格
+var pricingTables = map[string]map[StorageClass]Pricing{
+	"aws": {
+		StorageClassStandard:           {"USD", 0.023, 0.005, 0},
+		StorageClassInfrequent:         {"USD", 0.0125, 0.01, 30},
+		StorageClassGlacierIR:          {"USD", 0.004, 0.02, 90},
+		StorageClassArchive:            {"USD", 0.0036, 0.03, 90},
+		StorageClassDeepArchive:        {"USD", 0.00099, 0.05, 180},
+		StorageClassIntelligentTiering: {"USD", 0.023, 0.005, 0},
+	},
+	"qiniu": {
+		StorageClassStandard:           {"CNY", 0.148, 0.001, 0},
+		StorageClassInfrequent:         {"CNY", 0.1, 0.01, 30},
+		StorageClassGlacierIR:          {"CNY", 0.045, 0.01, 90},
+		StorageClassArchive:            {"CNY", 0.028, 0.01, 60},
+		StorageClassDeepArchive:        {"CNY", 0.012, 0.01, 180},
+		StorageClassIntelligentTiering: {"CNY", 0.148, 0.001, 30},
+	},
+	"aliyun": {
+		StorageClassStandard:    {"CNY", 0.12, 0.001, 0},
+		StorageClassInfrequent:  {"CNY", 0.08, 0.01, 30},
+		StorageClassArchive:     {"CNY", 0.033, 0.01, 60},
+		StorageClassColdArchive: {"CNY", 0.015, 0.01, 180},
+		StorageClassDeepArchive: {"CNY", 0.0075, 0.01, 180},
+	},
+}
+
+// PricingFor 返回提供商存储类型的参考价格，没有价格数据时 ok 为 false
+func PricingFor(provider string, sc StorageClass) (p Pricing, ok bool) {
+	p, ok = pricingTables[strings.ToLower(provider)][sc]
+	return p, ok
+}
+
+// CostEstimate 上传一个对象的费用估算
+type CostEstimate struct {
+	Pricing
+	Size           int64   // 对象大小（字节）
+	Requests       int64   // PUT 类请求数（初始化 + 分块 + 完成）
+	MonthlyStorage float64 // 每月存储费用
+	UploadRequests float64 // 上传请求费用（一次性）
+}
+
+// EstimateCost 估算以 partSize 分块上传 size 字节对象的费用，没有价格数据时 ok 为 false
+func EstimateCost(provider string, sc StorageClass, size, partSize int64) (CostEstimate, bool) {
+	p, ok := PricingFor(provider, sc)
+	if !ok {
+		return CostEstimate{}, false
+	}
+
+	parts := int64(1)
+	if partSize > 0 && size > partSize {
+		parts = (size + partSize - 1) / partSize
+	}
+	requests := parts + 2
+
+	return CostEstimate{
+		Pricing:        p,
+		Size:           size,
+		Requests:       requests,
+		MonthlyStorage: float64(size) / (1 << 30) * p.StoragePerGB,
+		UploadRequests: float64(requests) / 1000 * p.PutPer1000,
+	}, true
+}
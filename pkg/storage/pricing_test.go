@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"math"
+	"testing"
+)
+
+// TestPricingCoversSupportedClasses 测试每个提供商支持的存储类型都有参考价格
+func TestPricingCoversSupportedClasses(t *testing.T) {
+	for _, provider := range []string{"aws", "qiniu", "aliyun"} {
+		for _, sc := range SupportedStorageClassesFor(provider) {
+			if _, ok := PricingFor(provider, sc); !ok {
+				t.Errorf("missing pricing for %s %s", provider, sc)
+			}
+		}
+	}
+}
+
+// TestEstimateCost 测试费用估算
+func TestEstimateCost(t *testing.T) {
+	est, ok := EstimateCost("AWS", StorageClassStandard, 10<<30, 5<<20)
+	if !ok {
+		t.Fatal("expected pricing for aws standard")
+	}
+	if est.Requests != 2048+2 {
+		t.Errorf("requests = %d, want %d", est.Requests, 2048+2)
+	}
+	if math.Abs(est.MonthlyStorage-0.23) > 1e-9 {
+		t.Errorf("monthly storage = %f, want 0.23", est.MonthlyStorage)
+	}
+	if math.Abs(est.UploadRequests-2.050*0.005) > 1e-9 {
+		t.Errorf("upload requests = %f", est.UploadRequests)
+	}
+
+	if est, _ := EstimateCost("aliyun", StorageClassStandard, 0, 5<<20); est.Requests != 3 {
+		t.Errorf("empty object requests = %d, want 3", est.Requests)
+	}
+	if _, ok := EstimateCost("aliyun", StorageClassGlacierIR, 1, 1); ok {
+		t.Error("expected no pricing for unsupported class")
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// putObject 通过 S3 PutObject 一次写入小对象
+func putObject(ctx context.Context, client *s3.Client, provider, bucket, key string, data []byte, opts UploadOptions, optFns ...func(*s3.Options)) error {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+		Metadata:      opts.Metadata,
+	}
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+	if opts.ACL != "" {
+		input.ACL = types.ObjectCannedACL(opts.ACL)
+	}
+	if _, err := client.PutObject(ctx, input, optFns...); err != nil {
+		return fmt.Errorf("failed to put object: %w", classifyError(provider, err))
+	}
+	return nil
+}
+
+// withHeader 在签名前为请求添加请求头，用于发送 S3 SDK 不支持的条件头或提供商私有头
+func withHeader(name, value string) func(*s3.Options) {
+	add := middleware.BuildMiddlewareFunc("AddHeader", func(
+		ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler,
+	) (middleware.BuildOutput, middleware.Metadata, error) {
+		if req, ok := in.Request.(*smithyhttp.Request); ok {
+			req.Header.Set(name, value)
+		}
+		return next.HandleBuild(ctx, in)
+	})
+	return func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Build.Add(add, middleware.After)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeConditionalPut 模拟支持 If-None-Match 和 x-oss-forbid-overwrite 的 PutObject 和 CompleteMultipartUpload
+type fakeConditionalPut struct {
+	mu      sync.Mutex
+	objects map[string]bool
+	uploads int
+}
+
+func (f *fakeConditionalPut) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && r.URL.Query().Has("uploads"):
+		f.uploads++
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><UploadId>up-%d</UploadId></InitiateMultipartUploadResult>`, r.URL.Path, f.uploads)
+		return
+	case r.Method == http.MethodPost && r.URL.Query().Has("uploadId"):
+		// 完成上传与 PutObject 使用相同的条件判断
+	case r.Method != http.MethodPut:
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+	if f.objects[r.URL.Path] {
+		switch {
+		case r.Header.Get("If-None-Match") == "*":
+			w.WriteHeader(http.StatusPreconditionFailed)
+			fmt.Fprint(w, `<Error><Code>PreconditionFailed</Code><Message>exists</Message></Error>`)
+			return
+		case r.Header.Get("X-Oss-Forbid-Overwrite") == "true":
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `<Error><Code>FileAlreadyExists</Code><Message>exists</Message></Error>`)
+			return
+		}
+	}
+	f.objects[r.URL.Path] = true
+	if r.Method == http.MethodPost {
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><ETag>"x"</ETag></CompleteMultipartUploadResult>`, r.URL.Path)
+	}
+}
+
+// TestPutObjectIfAbsent 测试条件写入在对象已存在时返回 ErrObjectExists，普通写入可以覆盖
+func TestPutObjectIfAbsent(t *testing.T) {
+	srv := httptest.NewServer(&fakeConditionalPut{objects: map[string]bool{}})
+	defer srv.Close()
+	ctx := context.Background()
+
+	awsAdapter, err := NewAWSAdapter(ctx, "us-east-1", srv.URL, "bucket", "ak", "sk", WithPathStyle(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliyunAdapter, err := NewAliyunAdapter(ctx, "oss-cn-hangzhou", srv.URL, "bucket", "ak", "sk", WithPathStyle(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, putter := range map[string]ObjectPutter{"aws": awsAdapter, "aliyun": aliyunAdapter} {
+		key := name + "/lock.json"
+		if err := putter.PutObjectIfAbsent(ctx, key, []byte("{}"), UploadOptions{}); err != nil {
+			t.Fatalf("%s: first PutObjectIfAbsent() error = %v", name, err)
+		}
+		if err := putter.PutObjectIfAbsent(ctx, key, []byte("{}"), UploadOptions{}); !errors.Is(err, ErrObjectExists) {
+			t.Errorf("%s: second PutObjectIfAbsent() error = %v, want ErrObjectExists", name, err)
+		}
+		if err := putter.PutObject(ctx, key, []byte("{}"), UploadOptions{}); err != nil {
+			t.Errorf("%s: PutObject() error = %v", name, err)
+		}
+	}
+}
+
+// TestCompleteMultipartUploadNoOverwrite 测试 NoOverwrite 的上传在完成时对象已存在返回 ErrObjectExists
+func TestCompleteMultipartUploadNoOverwrite(t *testing.T) {
+	srv := httptest.NewServer(&fakeConditionalPut{objects: map[string]bool{"/bucket/backup.tar.gz": true}})
+	defer srv.Close()
+	ctx := context.Background()
+
+	awsAdapter, err := NewAWSAdapter(ctx, "us-east-1", srv.URL, "bucket", "ak", "sk", WithPathStyle(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliyunAdapter, err := NewAliyunAdapter(ctx, "oss-cn-hangzhou", srv.URL, "bucket", "ak", "sk", WithPathStyle(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := []CompletedPart{{PartNumber: 1, ETag: `"x"`}}
+	for name, adapter := range map[string]StorageAdapter{"aws": awsAdapter, "aliyun": aliyunAdapter} {
+		// 条件写入只取决于完成时传入的选项，续传时由其他进程完成的上传同样生效
+		id, err := adapter.InitMultipartUpload(ctx, "backup.tar.gz", UploadOptions{})
+		if err != nil {
+			t.Fatalf("%s: InitMultipartUpload() error = %v", name, err)
+		}
+		if err := adapter.CompleteMultipartUpload(ctx, "backup.tar.gz", id, parts, UploadOptions{NoOverwrite: true}); !errors.Is(err, ErrObjectExists) {
+			t.Errorf("%s: CompleteMultipartUpload() error = %v, want ErrObjectExists", name, err)
+		}
+
+		id, err = adapter.InitMultipartUpload(ctx, "backup.tar.gz", UploadOptions{})
+		if err != nil {
+			t.Fatalf("%s: InitMultipartUpload() error = %v", name, err)
+		}
+		if err := adapter.CompleteMultipartUpload(ctx, "backup.tar.gz", id, parts, UploadOptions{}); err != nil {
+			t.Errorf("%s: CompleteMultipartUpload() without NoOverwrite error = %v", name, err)
+		}
+	}
+}
@@ -11,15 +11,27 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// qiniuStorageClasses 七牛云支持的存储类型
+var qiniuStorageClasses = []StorageClass{
+	StorageClassStandard,
+	StorageClassInfrequent,
+	StorageClassArchive,
+	StorageClassDeepArchive,
+	StorageClassGlacierIR,
+	StorageClassIntelligentTiering,
+}
+
 // QiniuAdapter 七牛云适配器
 // 七牛云 Kodo 支持 S3 协议，但存储类型映射不同
+// S3 兼容接口可能忽略 x-amz-storage-class，存储类型通过原生 chtype/stat 接口设置并读回确认
 type QiniuAdapter struct {
 	client *s3.Client
 	bucket string
+	kodo   *kodoClient
 }
 
 // NewQiniuAdapter 创建七牛云适配器
-func NewQiniuAdapter(ctx context.Context, endpoint, bucket, accessKey, secretKey string) (*QiniuAdapter, error) {
+func NewQiniuAdapter(ctx context.Context, endpoint, bucket, accessKey, secretKey string, opts ...AWSOption) (*QiniuAdapter, error) {
 	// 七牛云 S3 协议端点格式: s3.<region>.qiniucs.com
 	cfg, err := config.LoadDefaultConfig(ctx,
 		config.WithRegion("qiniu"), // 七牛云使用自定义 region
@@ -38,11 +50,20 @@ func NewQiniuAdapter(ctx context.Context, endpoint, bucket, accessKey, secretKey
 		if endpoint != "" {
 			o.BaseEndpoint = aws.String(normalizeEndpoint(endpoint))
 		}
+		for _, opt := range opts {
+			opt(o)
+		}
 	})
 
 	return &QiniuAdapter{
 		client: client,
 		bucket: bucket,
+		kodo: &kodoClient{
+			httpClient: client.Options().HTTPClient,
+			endpoint:   kodoRSHost(endpoint),
+			accessKey:  accessKey,
+			secretKey:  secretKey,
+		},
 	}, nil
 }
 
@@ -62,6 +83,9 @@ func (q *QiniuAdapter) InitMultipartUpload(ctx context.Context, key string, opts
 	if opts.ContentType != "" {
 		input.ContentType = aws.String(opts.ContentType)
 	}
+	if opts.ContentDisposition != "" {
+		input.ContentDisposition = aws.String(opts.ContentDisposition)
+	}
 	if len(opts.Metadata) > 0 {
 		if input.Metadata == nil {
 			input.Metadata = make(map[string]string)
@@ -73,7 +97,7 @@ func (q *QiniuAdapter) InitMultipartUpload(ctx context.Context, key string, opts
 
 	result, err := q.client.CreateMultipartUpload(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to create multipart upload: %w", err)
+		return "", fmt.Errorf("failed to create multipart upload: %w", classifyError("qiniu", err))
 	}
 
 	return *result.UploadId, nil
@@ -95,14 +119,15 @@ func (q *QiniuAdapter) UploadPart(ctx context.Context, key, uploadID string, par
 
 	result, err := q.client.UploadPart(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload part %d: %w", partNum, err)
+		return "", fmt.Errorf("failed to upload part %d: %w", partNum, classifyError("qiniu", err))
 	}
 
 	return *result.ETag, nil
 }
 
 // CompleteMultipartUpload 完成上传
-func (q *QiniuAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+// opts 指定了存储类型时，完成后读回对象的存储类型，不一致时通过原生接口修改
+func (q *QiniuAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart, opts UploadOptions) error {
 	completedParts := make([]types.CompletedPart, len(parts))
 	for i, p := range parts {
 		completedParts[i] = types.CompletedPart{
@@ -120,9 +145,14 @@ func (q *QiniuAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadI
 
 	_, err := q.client.CompleteMultipartUpload(ctx, input)
 	if err != nil {
-		return fmt.Errorf("failed to complete multipart upload: %w", err)
+		return fmt.Errorf("failed to complete multipart upload: %w", classifyError("qiniu", err))
 	}
 
+	if opts.StorageClass.IsValid() {
+		if err := q.kodo.ensureType(ctx, q.bucket, key, opts.StorageClass); err != nil {
+			return fmt.Errorf("upload completed but failed to apply storage class: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -136,41 +166,67 @@ func (q *QiniuAdapter) AbortMultipartUpload(ctx context.Context, key, uploadID s
 
 	_, err := q.client.AbortMultipartUpload(ctx, input)
 	if err != nil {
-		return fmt.Errorf("failed to abort multipart upload: %w", err)
+		return fmt.Errorf("failed to abort multipart upload: %w", classifyError("qiniu", err))
 	}
 
 	return nil
 }
 
+// GetObject 下载对象
+func (q *QiniuAdapter) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	result, err := q.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(q.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", classifyError("qiniu", err))
+	}
+
+	return result.Body, nil
+}
+
+// GetObjectRange 下载对象从 offset 开始的 length 个字节
+func (q *QiniuAdapter) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	return getObjectRange(ctx, q.client, "qiniu", q.bucket, key, offset, length)
+}
+
+// ListObjects 列出 prefix 下的所有对象
+func (q *QiniuAdapter) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	return listObjects(ctx, q.client, "qiniu", q.bucket, prefix)
+}
+
+// ListParts 列出分块上传中已上传的分块
+func (q *QiniuAdapter) ListParts(ctx context.Context, key, uploadID string) ([]PartInfo, error) {
+	return listParts(ctx, q.client, "qiniu", q.bucket, key, uploadID)
+}
+
+// ObjectMetadata 返回对象的用户元数据
+func (q *QiniuAdapter) ObjectMetadata(ctx context.Context, key string) (map[string]string, error) {
+	return objectMetadata(ctx, q.client, "qiniu", q.bucket, key)
+}
+
+// DeleteObject 删除对象
+func (q *QiniuAdapter) DeleteObject(ctx context.Context, key string) error {
+	return deleteObject(ctx, q.client, "qiniu", q.bucket, key)
+}
+
+// PartSizeLimits 返回分块大小限制
+// 七牛云 S3 兼容接口分块大小为 1MB ~ 1GB（最后一个分块不受最小值限制）
+func (q *QiniuAdapter) PartSizeLimits() (min, max int64) {
+	return 1024 * 1024, 1024 * 1024 * 1024
+}
+
 // SupportedStorageClasses 返回支持的存储类型
 func (q *QiniuAdapter) SupportedStorageClasses() []StorageClass {
-	return []StorageClass{
-		StorageClassStandard,
-		StorageClassInfrequent,
-		StorageClassArchive,
-		StorageClassDeepArchive,
-	}
+	return qiniuStorageClasses
 }
 
 // SetStorageClass 设置存储类型
-// 七牛 S3 兼容接口通过 CopyObject + x-amz-storage-class 修改存储类型
+// 通过七牛原生 chtype 接口修改存储类型并读回确认，已是目标类型时不修改
 func (q *QiniuAdapter) SetStorageClass(ctx context.Context, key string, class StorageClass) error {
-	copySource := fmt.Sprintf("%s/%s", q.bucket, key)
-	qiniuStorageClass := types.StorageClass(q.mapStorageClass(class))
-
-	input := &s3.CopyObjectInput{
-		Bucket:            aws.String(q.bucket),
-		CopySource:        aws.String(copySource),
-		Key:               aws.String(key),
-		StorageClass:      qiniuStorageClass,
-		MetadataDirective: types.MetadataDirectiveReplace,
-	}
-
-	_, err := q.client.CopyObject(ctx, input)
-	if err != nil {
+	if err := q.kodo.ensureType(ctx, q.bucket, key, class); err != nil {
 		return fmt.Errorf("failed to set storage class: %w", err)
 	}
-
 	return nil
 }
 
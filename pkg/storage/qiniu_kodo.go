@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrStorageClassNotApplied 设置存储类型后读回的存储类型与期望不一致
+var ErrStorageClassNotApplied = errors.New("storage class not applied")
+
+// kodoDefaultRSHost 无法从 endpoint 推断区域时使用的七牛资源管理域名
+const kodoDefaultRSHost = "https://rs.qiniu.com"
+
+// kodoRegionZones S3 兼容接口区域与七牛资源管理接口区域的对应关系
+var kodoRegionZones = map[string]string{
+	"cn-east-1":      "z0",
+	"cn-east-2":      "cn-east-2",
+	"cn-north-1":     "z1",
+	"cn-south-1":     "z2",
+	"us-north-1":     "na0",
+	"ap-southeast-1": "as0",
+}
+
+// kodoTypes 通用存储类型与七牛原生存储类型编号的对应关系
+var kodoTypes = map[StorageClass]int{
+	StorageClassStandard:           0,
+	StorageClassInfrequent:         1,
+	StorageClassArchive:            2,
+	StorageClassDeepArchive:        3,
+	StorageClassGlacierIR:          4,
+	StorageClassIntelligentTiering: 5,
+}
+
+// kodoClient 七牛原生资源管理接口（stat/chtype）客户端
+// S3 兼容接口可能忽略 x-amz-storage-class，存储类型通过原生接口设置和读回
+type kodoClient struct {
+	httpClient interface {
+		Do(*http.Request) (*http.Response, error)
+	}
+	endpoint  string
+	accessKey string
+	secretKey string
+}
+
+// kodoRSHost 根据 S3 兼容端点（s3.<region>.qiniucs.com 或 s3-<region>.qiniucs.com）推断资源管理域名
+func kodoRSHost(endpoint string) string {
+	host := endpoint
+	if u, err := url.Parse(normalizeEndpoint(endpoint)); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	region, ok := strings.CutSuffix(host, ".qiniucs.com")
+	if !ok {
+		return kodoDefaultRSHost
+	}
+	region = strings.TrimPrefix(strings.TrimPrefix(region, "s3."), "s3-")
+	if zone, ok := kodoRegionZones[region]; ok {
+		return "https://rs-" + zone + ".qiniuapi.com"
+	}
+	return kodoDefaultRSHost
+}
+
+// encodedEntry 返回七牛接口路径中的 EncodedEntryURI
+func encodedEntry(bucket, key string) string {
+	return base64.URLEncoding.EncodeToString([]byte(bucket + ":" + key))
+}
+
+// sign 按七牛管理凭证（QBox）规则签名：对 path[?query] 加换行做 HMAC-SHA1
+// 请求没有表单体，签名内容不包含 body
+func (k *kodoClient) sign(req *http.Request) {
+	data := req.URL.Path
+	if req.URL.RawQuery != "" {
+		data += "?" + req.URL.RawQuery
+	}
+	mac := hmac.New(sha1.New, []byte(k.secretKey))
+	mac.Write([]byte(data + "\n"))
+	req.Header.Set("Authorization", "QBox "+k.accessKey+":"+base64.URLEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// do 发送资源管理请求，返回响应体；非 200 响应按七牛错误码分类
+func (k *kodoClient) do(ctx context.Context, method, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, k.endpoint+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	k.sign(req)
+
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return nil, classifyError("qiniu", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, classifyError("qiniu", err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return body, nil
+	}
+
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(body, &apiErr)
+	if apiErr.Error == "" {
+		apiErr.Error = http.StatusText(resp.StatusCode)
+	}
+	cause := fmt.Errorf("%s %s: %s", method, path, apiErr.Error)
+	code := strconv.Itoa(resp.StatusCode)
+	if policy := throttlePolicies["qiniu"]; policy.statuses[resp.StatusCode] {
+		return nil, &ThrottledError{Provider: "qiniu", Code: code, Err: cause, baseDelay: policy.baseDelay}
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, &ProviderError{Kind: ErrAuth, Provider: "qiniu", Code: code, Err: cause}
+	case 612: // 资源不存在
+		return nil, &ProviderError{Kind: ErrObjectNotFound, Provider: "qiniu", Code: code, Err: cause}
+	case 631: // 存储桶不存在
+		return nil, &ProviderError{Kind: ErrBucketNotFound, Provider: "qiniu", Code: code, Err: cause}
+	}
+	return nil, fmt.Errorf("qiniu: %s (%s)", cause, code)
+}
+
+// stat 读取对象的原生存储类型编号
+func (k *kodoClient) stat(ctx context.Context, bucket, key string) (int, error) {
+	body, err := k.do(ctx, http.MethodGet, "/stat/"+encodedEntry(bucket, key))
+	if err != nil {
+		return 0, err
+	}
+	var info struct {
+		Type int `json:"type"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return 0, fmt.Errorf("failed to parse stat response: %w", err)
+	}
+	return info.Type, nil
+}
+
+// chtype 修改对象的原生存储类型
+func (k *kodoClient) chtype(ctx context.Context, bucket, key string, t int) error {
+	_, err := k.do(ctx, http.MethodPost, "/chtype/"+encodedEntry(bucket, key)+"/type/"+strconv.Itoa(t))
+	return err
+}
+
+// ensureType 读回对象的存储类型，不一致时通过 chtype 修改并再次读回确认
+func (k *kodoClient) ensureType(ctx context.Context, bucket, key string, class StorageClass) error {
+	want, ok := kodoTypes[class]
+	if !ok {
+		return fmt.Errorf("unsupported Qiniu storage class: %s", class)
+	}
+	got, err := k.stat(ctx, bucket, key)
+	if err != nil {
+		return fmt.Errorf("failed to stat object: %w", err)
+	}
+	if got == want {
+		return nil
+	}
+	if err := k.chtype(ctx, bucket, key, want); err != nil {
+		return fmt.Errorf("failed to change storage class: %w", err)
+	}
+	got, err = k.stat(ctx, bucket, key)
+	if err != nil {
+		return fmt.Errorf("failed to stat object: %w", err)
+	}
+	if got != want {
+		return fmt.Errorf("%w: %s is type %d after chtype, want %d (%s)", ErrStorageClassNotApplied, key, got, want, class)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeKodo 模拟七牛资源管理接口的 stat/chtype，记录对象的存储类型
+type fakeKodo struct {
+	mu     sync.Mutex
+	types  map[string]int // EncodedEntryURI -> 存储类型
+	ignore bool           // chtype 返回成功但不修改存储类型
+	calls  []string
+}
+
+func (f *fakeKodo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, r.Method+" "+strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0])
+
+	mac := hmac.New(sha1.New, []byte("sk"))
+	mac.Write([]byte(r.URL.Path + "\n"))
+	if r.Header.Get("Authorization") != "QBox ak:"+base64.URLEncoding.EncodeToString(mac.Sum(nil)) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":"bad token"}`)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	t, ok := f.types[parts[1]]
+	if !ok {
+		w.WriteHeader(612)
+		fmt.Fprint(w, `{"error":"no such file or directory"}`)
+		return
+	}
+	switch parts[0] {
+	case "stat":
+		fmt.Fprintf(w, `{"fsize":1,"hash":"x","type":%d}`, t)
+	case "chtype":
+		n, _ := strconv.Atoi(parts[3])
+		if !f.ignore {
+			f.types[parts[1]] = n
+		}
+	}
+}
+
+func newFakeKodo(t *testing.T, types map[string]int) (*fakeKodo, *kodoClient) {
+	f := &fakeKodo{types: types}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, &kodoClient{httpClient: srv.Client(), endpoint: srv.URL, accessKey: "ak", secretKey: "sk"}
+}
+
+// TestQiniuSetStorageClass 测试通过原生接口修改存储类型并读回确认
+func TestQiniuSetStorageClass(t *testing.T) {
+	entry := encodedEntry("bucket", "backup.tar.gz")
+	f, kodo := newFakeKodo(t, map[string]int{entry: 0})
+	q := &QiniuAdapter{bucket: "bucket", kodo: kodo}
+	ctx := context.Background()
+
+	if err := q.SetStorageClass(ctx, "backup.tar.gz", StorageClassArchive); err != nil {
+		t.Fatalf("SetStorageClass() error = %v", err)
+	}
+	if f.types[entry] != 2 {
+		t.Errorf("type = %d, want 2", f.types[entry])
+	}
+	if want := "GET stat,POST chtype,GET stat"; strings.Join(f.calls, ",") != want {
+		t.Errorf("calls = %v, want %s", f.calls, want)
+	}
+
+	// 已是目标类型时只读回，不修改
+	f.calls = nil
+	if err := q.SetStorageClass(ctx, "backup.tar.gz", StorageClassArchive); err != nil {
+		t.Fatalf("SetStorageClass() error = %v", err)
+	}
+	if len(f.calls) != 1 {
+		t.Errorf("calls = %v, want a single stat", f.calls)
+	}
+}
+
+// TestQiniuSetStorageClassNotApplied 测试读回的存储类型不一致时返回 ErrStorageClassNotApplied
+func TestQiniuSetStorageClassNotApplied(t *testing.T) {
+	f, kodo := newFakeKodo(t, map[string]int{encodedEntry("bucket", "a"): 0})
+	f.ignore = true
+	q := &QiniuAdapter{bucket: "bucket", kodo: kodo}
+
+	err := q.SetStorageClass(context.Background(), "a", StorageClassInfrequent)
+	if !errors.Is(err, ErrStorageClassNotApplied) {
+		t.Errorf("SetStorageClass() error = %v, want ErrStorageClassNotApplied", err)
+	}
+}
+
+// TestQiniuKodoErrors 测试原生接口错误码分类
+func TestQiniuKodoErrors(t *testing.T) {
+	_, kodo := newFakeKodo(t, map[string]int{})
+	ctx := context.Background()
+
+	if _, err := kodo.stat(ctx, "bucket", "missing"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("stat(missing) error = %v, want ErrObjectNotFound", err)
+	}
+	kodo.secretKey = "wrong"
+	if _, err := kodo.stat(ctx, "bucket", "missing"); !errors.Is(err, ErrAuth) {
+		t.Errorf("stat(bad key) error = %v, want ErrAuth", err)
+	}
+	q := &QiniuAdapter{bucket: "bucket", kodo: kodo}
+	if err := q.SetStorageClass(ctx, "a", StorageClassColdArchive); err == nil {
+		t.Error("SetStorageClass(cold_archive) should fail on Qiniu")
+	}
+}
+
+// TestKodoRSHost 测试从 S3 兼容端点推断资源管理域名
+func TestKodoRSHost(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"https://s3.cn-east-1.qiniucs.com", "https://rs-z0.qiniuapi.com"},
+		{"s3-cn-north-1.qiniucs.com", "https://rs-z1.qiniuapi.com"},
+		{"https://s3.cn-east-2.qiniucs.com", "https://rs-cn-east-2.qiniuapi.com"},
+		{"https://s3.unknown.qiniucs.com", kodoDefaultRSHost},
+		{"", kodoDefaultRSHost},
+		{"https://example.com", kodoDefaultRSHost},
+	}
+	for _, tt := range tests {
+		if got := kodoRSHost(tt.endpoint); got != tt.want {
+			t.Errorf("kodoRSHost(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// RestoreTier 取回归档对象的速度档位，越快费用越高
+type RestoreTier string
+
+const (
+	RestoreTierExpedited RestoreTier = "Expedited"
+	RestoreTierStandard  RestoreTier = "Standard"
+	RestoreTierBulk      RestoreTier = "Bulk"
+)
+
+// ParseRestoreTier 解析取回档位（expedited、standard、bulk，不区分大小写）
+func ParseRestoreTier(s string) (RestoreTier, error) {
+	for _, tier := range []RestoreTier{RestoreTierExpedited, RestoreTierStandard, RestoreTierBulk} {
+		if strings.EqualFold(s, string(tier)) {
+			return tier, nil
+		}
+	}
+	return "", fmt.Errorf("invalid restore tier %q (expedited, standard or bulk)", s)
+}
+
+// RestoreStatus 对象的归档和取回状态
+type RestoreStatus struct {
+	StorageClass string    // 提供商返回的原生存储类型
+	Archived     bool      // 是否为需要取回才能读取的归档类型
+	Ongoing      bool      // 取回正在进行
+	Expiry       time.Time // 已取回的临时副本的过期时间，未取回时为零值
+}
+
+// Readable 对象当前能否直接下载：不是归档类型，或已完成取回
+func (s RestoreStatus) Readable() bool {
+	return !s.Archived || (!s.Ongoing && !s.Expiry.IsZero())
+}
+
+// Restorer 可选接口，适配器通过它支持取回归档对象
+type Restorer interface {
+	// RestoreObject 发起取回，临时副本保留 days 天；取回已在进行时返回 ErrRestoreInProgress
+	RestoreObject(ctx context.Context, key string, days int, tier RestoreTier) error
+	// RestoreStatus 返回对象的归档和取回状态
+	RestoreStatus(ctx context.Context, key string) (RestoreStatus, error)
+}
+
+// restoreStatus 通过 HeadObject 读取对象的存储类型和取回状态
+// 同时识别 x-amz-* 和阿里云原生的 x-oss-* 响应头；archived 为需要取回的原生存储类型
+func restoreStatus(ctx context.Context, client *s3.Client, provider, bucket, key string, archived map[string]bool) (RestoreStatus, error) {
+	out, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return RestoreStatus{}, fmt.Errorf("failed to head object: %w", classifyError(provider, err))
+	}
+
+	var header http.Header
+	if raw, ok := awsmiddleware.GetRawResponse(out.ResultMetadata).(*smithyhttp.Response); ok {
+		header = raw.Header
+	}
+	first := func(values ...string) string {
+		for _, v := range values {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	status := RestoreStatus{
+		StorageClass: first(string(out.StorageClass), header.Get("X-Oss-Storage-Class"), "STANDARD"),
+	}
+	status.Archived = archived[status.StorageClass]
+	status.Ongoing, status.Expiry = parseRestoreHeader(first(aws.ToString(out.Restore), header.Get("X-Oss-Restore")))
+	return status, nil
+}
+
+// parseRestoreHeader 解析 x-amz-restore/x-oss-restore 响应头，如
+// ongoing-request="false", expiry-date="Fri, 23 Dec 2012 00:00:00 GMT"
+func parseRestoreHeader(v string) (ongoing bool, expiry time.Time) {
+	ongoing = strings.Contains(v, `ongoing-request="true"`)
+	// expiry-date 的值本身包含逗号，不能按逗号拆分字段
+	if _, rest, ok := strings.Cut(v, `expiry-date="`); ok {
+		if date, _, ok := strings.Cut(rest, `"`); ok {
+			expiry, _ = http.ParseTime(date)
+		}
+	}
+	return ongoing, expiry
+}
+
+// restoreObject 通过 S3 RestoreObject 发起取回，tier 为空时使用提供商默认档位
+func restoreObject(ctx context.Context, client *s3.Client, provider, bucket, key string, days int, tier RestoreTier, optFns ...func(*s3.Options)) error {
+	request := &types.RestoreRequest{Days: aws.Int32(int32(days))}
+	if tier != "" {
+		request.GlacierJobParameters = &types.GlacierJobParameters{Tier: types.Tier(tier)}
+	}
+	_, err := client.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket:         aws.String(bucket),
+		Key:            aws.String(key),
+		RestoreRequest: request,
+	}, optFns...)
+	if err != nil {
+		return fmt.Errorf("failed to restore object: %w", classifyError(provider, err))
+	}
+	return nil
+}
+
+// withRequestBodyRewrite 在签名前替换请求体中的 old 为 new
+// 用于发送 S3 SDK 不支持的提供商私有字段
+func withRequestBodyRewrite(old, new string) func(*s3.Options) {
+	rewrite := middleware.SerializeMiddlewareFunc("RewriteRequestBody", func(
+		ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler,
+	) (middleware.SerializeOutput, middleware.Metadata, error) {
+		req, ok := in.Request.(*smithyhttp.Request)
+		if !ok || req.GetStream() == nil {
+			return next.HandleSerialize(ctx, in)
+		}
+		body, err := io.ReadAll(req.GetStream())
+		if err != nil {
+			return middleware.SerializeOutput{}, middleware.Metadata{}, err
+		}
+		body = bytes.ReplaceAll(body, []byte(old), []byte(new))
+		req.ContentLength = int64(len(body))
+		if in.Request, err = req.SetStream(bytes.NewReader(body)); err != nil {
+			return middleware.SerializeOutput{}, middleware.Metadata{}, err
+		}
+		return next.HandleSerialize(ctx, in)
+	})
+	return func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Serialize.Add(rewrite, middleware.After)
+		})
+	}
+}
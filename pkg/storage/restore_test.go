@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestParseRestoreHeader 测试解析 x-amz-restore/x-oss-restore 响应头
+func TestParseRestoreHeader(t *testing.T) {
+	ongoing, expiry := parseRestoreHeader(`ongoing-request="true"`)
+	if !ongoing || !expiry.IsZero() {
+		t.Errorf("ongoing restore = %v, %v", ongoing, expiry)
+	}
+
+	ongoing, expiry = parseRestoreHeader(`ongoing-request="false", expiry-date="Fri, 23 Dec 2012 00:00:00 GMT"`)
+	if ongoing || !expiry.Equal(time.Date(2012, 12, 23, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("finished restore = %v, %v", ongoing, expiry)
+	}
+
+	if ongoing, expiry = parseRestoreHeader(""); ongoing || !expiry.IsZero() {
+		t.Errorf("no restore = %v, %v", ongoing, expiry)
+	}
+}
+
+// TestRestoreStatusReadable 测试对象能否直接读取的判断
+func TestRestoreStatusReadable(t *testing.T) {
+	tests := []struct {
+		status RestoreStatus
+		want   bool
+	}{
+		{RestoreStatus{StorageClass: "STANDARD"}, true},
+		{RestoreStatus{StorageClass: "GLACIER", Archived: true}, false},
+		{RestoreStatus{StorageClass: "GLACIER", Archived: true, Ongoing: true}, false},
+		{RestoreStatus{StorageClass: "GLACIER", Archived: true, Expiry: time.Now()}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.status.Readable(); got != tt.want {
+			t.Errorf("%+v.Readable() = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+// TestParseRestoreTier 测试解析取回档位
+func TestParseRestoreTier(t *testing.T) {
+	if tier, err := ParseRestoreTier("bulk"); err != nil || tier != RestoreTierBulk {
+		t.Errorf("ParseRestoreTier(bulk) = %q, %v", tier, err)
+	}
+	if _, err := ParseRestoreTier("fast"); err == nil {
+		t.Error("ParseRestoreTier(fast) should fail")
+	}
+}
+
+// fakeOSS 模拟阿里云 OSS 的 HeadObject 和 RestoreObject，记录取回请求体
+type fakeOSS struct {
+	mu      sync.Mutex
+	class   string
+	restore string
+	bodies  []string
+}
+
+func (f *fakeOSS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodHead:
+		w.Header().Set("X-Oss-Storage-Class", f.class)
+		if f.restore != "" {
+			w.Header().Set("X-Oss-Restore", f.restore)
+		}
+	case r.Method == http.MethodPost && r.URL.Query().Has("restore"):
+		body, _ := io.ReadAll(r.Body)
+		f.bodies = append(f.bodies, string(body))
+		if f.restore != "" {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `<Error><Code>RestoreAlreadyInProgress</Code><Message>in progress</Message></Error>`)
+			return
+		}
+		f.restore = `ongoing-request="true"`
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// TestAliyunRestoreObject 测试阿里云冷归档取回使用 OSS 的 JobParameters，归档存储不带档位
+func TestAliyunRestoreObject(t *testing.T) {
+	oss := &fakeOSS{class: "ColdArchive"}
+	srv := httptest.NewServer(oss)
+	defer srv.Close()
+	ctx := context.Background()
+
+	adapter, err := NewAliyunAdapter(ctx, "oss-cn-hangzhou", srv.URL, "bucket", "ak", "sk", WithPathStyle(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := adapter.RestoreStatus(ctx, "backup.tar.gz")
+	if err != nil {
+		t.Fatalf("RestoreStatus() error = %v", err)
+	}
+	if status.StorageClass != "ColdArchive" || !status.Archived || status.Readable() {
+		t.Errorf("status = %+v, want unrestored ColdArchive", status)
+	}
+
+	if err := adapter.RestoreObject(ctx, "backup.tar.gz", 3, RestoreTierBulk); err != nil {
+		t.Fatalf("RestoreObject() error = %v", err)
+	}
+	body := oss.bodies[0]
+	if !strings.Contains(body, "<JobParameters><Tier>Bulk</Tier></JobParameters>") || strings.Contains(body, "Glacier") {
+		t.Errorf("restore body = %s, want OSS JobParameters", body)
+	}
+	if !strings.Contains(body, "<Days>3</Days>") {
+		t.Errorf("restore body = %s, want 3 days", body)
+	}
+
+	err = adapter.RestoreObject(ctx, "backup.tar.gz", 3, RestoreTierBulk)
+	if !errors.Is(err, ErrRestoreInProgress) {
+		t.Errorf("second RestoreObject() error = %v, want ErrRestoreInProgress", err)
+	}
+	if status, _ := adapter.RestoreStatus(ctx, "backup.tar.gz"); !status.Ongoing {
+		t.Errorf("status = %+v, want ongoing", status)
+	}
+
+	oss.class, oss.restore = "Archive", ""
+	if err := adapter.RestoreObject(ctx, "backup.tar.gz", 1, RestoreTierStandard); err != nil {
+		t.Fatalf("RestoreObject(Archive) error = %v", err)
+	}
+	if body := oss.bodies[2]; strings.Contains(body, "Tier") {
+		t.Errorf("Archive restore body = %s, want no tier", body)
+	}
+}
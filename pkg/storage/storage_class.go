@@ -1,5 +1,7 @@
 package storage
 
+import "strings"
+
 // StorageClass 存储类型
 type StorageClass string
 
@@ -7,6 +9,7 @@ const (
 	StorageClassStandard           StorageClass = "STANDARD"
 	StorageClassInfrequent         StorageClass = "INFREQUENT_ACCESS"
 	StorageClassArchive            StorageClass = "ARCHIVE"
+	StorageClassColdArchive        StorageClass = "COLD_ARCHIVE"
 	StorageClassDeepArchive        StorageClass = "DEEP_ARCHIVE"
 	StorageClassGlacierIR          StorageClass = "GLACIER_IR"
 	StorageClassIntelligentTiering StorageClass = "INTELLIGENT_TIERING"
@@ -21,6 +24,8 @@ func ParseStorageClass(s string) StorageClass {
 		return StorageClassInfrequent
 	case "archive", "ARCHIVE":
 		return StorageClassArchive
+	case "cold_archive", "COLD_ARCHIVE":
+		return StorageClassColdArchive
 	case "deep_archive", "DEEP_ARCHIVE":
 		return StorageClassDeepArchive
 	case "glacier_ir", "GLACIER_IR":
@@ -43,6 +48,7 @@ func (sc StorageClass) IsValid() bool {
 	case StorageClassStandard,
 		StorageClassInfrequent,
 		StorageClassArchive,
+		StorageClassColdArchive,
 		StorageClassDeepArchive,
 		StorageClassGlacierIR,
 		StorageClassIntelligentTiering:
@@ -51,3 +57,56 @@ func (sc StorageClass) IsValid() bool {
 		return false
 	}
 }
+
+// Name 返回存储类型在命令行和配置文件中使用的通用名称（ParseStorageClass 的逆操作）
+func (sc StorageClass) Name() string {
+	switch sc {
+	case StorageClassStandard:
+		return "standard"
+	case StorageClassInfrequent:
+		return "ia"
+	case StorageClassArchive:
+		return "archive"
+	case StorageClassColdArchive:
+		return "cold_archive"
+	case StorageClassDeepArchive:
+		return "deep_archive"
+	case StorageClassGlacierIR:
+		return "glacier_ir"
+	case StorageClassIntelligentTiering:
+		return "intelligent"
+	default:
+		return ""
+	}
+}
+
+// SupportedStorageClassesFor 返回指定存储提供商支持的存储类型，无需创建适配器
+// 未知提供商返回 nil
+func SupportedStorageClassesFor(provider string) []StorageClass {
+	var classes []StorageClass
+	switch strings.ToLower(provider) {
+	case "aws":
+		classes = awsStorageClasses
+	case "qiniu":
+		classes = qiniuStorageClasses
+	case "aliyun":
+		classes = aliyunStorageClasses
+	default:
+		return nil
+	}
+	return append([]StorageClass(nil), classes...)
+}
+
+// NativeStorageClass 返回存储类型在指定提供商接口中的实际取值，未知提供商返回空字符串
+func NativeStorageClass(provider string, sc StorageClass) string {
+	switch strings.ToLower(provider) {
+	case "aws":
+		return (&AWSAdapter{}).mapStorageClass(sc)
+	case "qiniu":
+		return (&QiniuAdapter{}).mapStorageClass(sc)
+	case "aliyun":
+		return (&AliyunAdapter{}).mapStorageClass(sc)
+	default:
+		return ""
+	}
+}
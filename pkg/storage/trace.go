@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// RequestTrace 一次发往存储服务的 HTTP 请求
+// 只记录路径和分块号，不包含查询参数中的 UploadID 和请求头中的签名
+type RequestTrace struct {
+	Operation string // S3 操作名，如 UploadPart
+	Method    string
+	Path      string // 请求路径，UploadPart 附带 ?partNumber=N
+	Status    int    // HTTP 状态码，没有收到响应时为 0
+	Duration  time.Duration
+	Retry     int   // SDK 内部的重试次数，首次请求为 0
+	Err       error // 没有收到响应时的错误
+}
+
+// WithRequestTrace 每个 HTTP 请求（包括 SDK 内部的重试）完成后调用 fn，用于调试慢速或不稳定的存储服务
+// 同样适用于阿里云和七牛云适配器；fn 会被多个上传 worker 并发调用
+func WithRequestTrace(fn func(RequestTrace)) AWSOption {
+	return func(o *s3.Options) {
+		next := o.HTTPClient
+		if next == nil {
+			next = awshttp.NewBuildableClient()
+		}
+		o.HTTPClient = &tracingClient{next: next, fn: fn}
+	}
+}
+
+// tracingClient 记录每个请求的 HTTP 客户端
+type tracingClient struct {
+	next s3.HTTPClient
+	fn   func(RequestTrace)
+}
+
+func (c *tracingClient) Do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.next.Do(req)
+
+	t := RequestTrace{
+		Operation: awsmiddleware.GetOperationName(req.Context()),
+		Method:    req.Method,
+		Path:      tracePath(req.URL),
+		Duration:  time.Since(start),
+		Retry:     sdkAttempt(req.Header.Get("amz-sdk-request")) - 1,
+	}
+	if t.Operation == "" {
+		t.Operation = req.Method
+	}
+	if resp != nil {
+		t.Status = resp.StatusCode
+	}
+	if err != nil {
+		// url.Error 的文本包含完整的 URL（含 UploadID），只保留底层错误
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			t.Err = urlErr.Err
+		} else {
+			t.Err = err
+		}
+	}
+	c.fn(t)
+	return resp, err
+}
+
+// tracePath 返回请求路径，查询参数中只保留分块号
+func tracePath(u *url.URL) string {
+	if n := u.Query().Get("partNumber"); n != "" {
+		return u.EscapedPath() + "?partNumber=" + n
+	}
+	return u.EscapedPath()
+}
+
+// sdkAttempt 解析 SDK 添加的 amz-sdk-request 请求头（attempt=2; max=3），返回第几次尝试，无法解析时返回 1
+func sdkAttempt(header string) int {
+	for _, field := range strings.Split(header, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if ok && k == "attempt" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 1
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// TestRequestTrace 测试记录每个请求的操作名、路径、状态码和 SDK 重试次数，不记录 UploadID
+func TestRequestTrace(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method == http.MethodPut {
+			w.Header().Set("ETag", `"etag"`)
+			return
+		}
+		w.Write([]byte(`<InitiateMultipartUploadResult><UploadId>secret-upload-id</UploadId></InitiateMultipartUploadResult>`))
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var traces []RequestTrace
+	noBackoff := func(o *s3.Options) {
+		o.Retryer = retry.NewStandard(func(so *retry.StandardOptions) {
+			so.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		})
+	}
+	adapter, err := NewAWSAdapter(context.Background(), "us-east-1", srv.URL, "bucket", "ak", "sk",
+		WithPathStyle(true), noBackoff, WithRequestTrace(func(rt RequestTrace) {
+			mu.Lock()
+			defer mu.Unlock()
+			traces = append(traces, rt)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	uploadID, err := adapter.InitMultipartUpload(ctx, "dir/key", UploadOptions{})
+	if err != nil {
+		t.Fatalf("InitMultipartUpload() error = %v", err)
+	}
+	if _, err := adapter.UploadPart(ctx, "dir/key", uploadID, 3, strings.NewReader("data"), 4); err != nil {
+		t.Fatalf("UploadPart() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(traces) != 3 {
+		t.Fatalf("expected 3 traces, got %+v", traces)
+	}
+	want := []RequestTrace{
+		{Operation: "CreateMultipartUpload", Method: "POST", Path: "/bucket/dir/key", Status: 503, Retry: 0},
+		{Operation: "CreateMultipartUpload", Method: "POST", Path: "/bucket/dir/key", Status: 200, Retry: 1},
+		{Operation: "UploadPart", Method: "PUT", Path: "/bucket/dir/key?partNumber=3", Status: 200, Retry: 0},
+	}
+	for i, w := range want {
+		got := traces[i]
+		if got.Operation != w.Operation || got.Method != w.Method || got.Path != w.Path || got.Status != w.Status || got.Retry != w.Retry {
+			t.Errorf("trace %d = %+v, want %+v", i, got, w)
+		}
+		if strings.Contains(got.Path, uploadID) {
+			t.Errorf("trace %d leaks the upload ID: %s", i, got.Path)
+		}
+	}
+}
+
+// TestRequestMetricsSummary 测试按操作汇总请求数、失败数、重试数和耗时百分位数
+func TestRequestMetricsSummary(t *testing.T) {
+	m := NewRequestMetrics()
+	for i := 1; i <= 100; i++ {
+		m.Record(RequestTrace{Operation: "UploadPart", Status: 200, Duration: time.Duration(i) * time.Millisecond})
+	}
+	m.Record(RequestTrace{Operation: "CompleteMultipartUpload", Status: 503, Duration: time.Second})
+	m.Record(RequestTrace{Operation: "CompleteMultipartUpload", Status: 200, Retry: 1, Duration: 2 * time.Second})
+
+	stats := m.Summary()
+	if len(stats) != 2 || stats[0].Operation != "CompleteMultipartUpload" || stats[1].Operation != "UploadPart" {
+		t.Fatalf("unexpected summary: %+v", stats)
+	}
+	complete, part := stats[0], stats[1]
+	if complete.Count != 2 || complete.Errors != 1 || complete.Retries != 1 || complete.Max != 2*time.Second {
+		t.Errorf("CompleteMultipartUpload stats = %+v", complete)
+	}
+	if part.P50 != 50*time.Millisecond || part.P90 != 90*time.Millisecond || part.P99 != 99*time.Millisecond || part.Max != 100*time.Millisecond {
+		t.Errorf("UploadPart percentiles = %+v", part)
+	}
+}
@@ -0,0 +1,27 @@
+package storage
+
+// writeOnlyAdapter 只暴露上传相关的方法，隐藏列出、读取和删除等可选接口
+type writeOnlyAdapter struct {
+	StorageAdapter
+}
+
+// writeOnlyLimitedAdapter 保留被包装适配器声明的分块大小限制
+type writeOnlyLimitedAdapter struct {
+	writeOnlyAdapter
+	limiter PartSizeLimiter
+}
+
+func (a writeOnlyLimitedAdapter) PartSizeLimits() (min, max int64) {
+	return a.limiter.PartSizeLimits()
+}
+
+// WriteOnly 包装 adapter，返回的适配器不实现 ObjectReader、RangeReader、ObjectLister、ObjectDeleter、
+// ObjectPutter、MetadataReader 和 Restorer，调用方按适配器不支持这些操作处理（跳过锁、覆盖检查和上传后校验等）。
+// 用于只写凭证：即使凭证实际拥有更多权限，也不会发起列出、读取或删除请求
+func WriteOnly(adapter StorageAdapter) StorageAdapter {
+	base := writeOnlyAdapter{StorageAdapter: adapter}
+	if limiter, ok := adapter.(PartSizeLimiter); ok {
+		return writeOnlyLimitedAdapter{writeOnlyAdapter: base, limiter: limiter}
+	}
+	return base
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+// TestWriteOnly 测试只写包装隐藏读取、列出和删除接口，保留分块大小限制
+func TestWriteOnly(t *testing.T) {
+	adapter, err := NewAWSAdapter(context.Background(), "us-east-1", "", "test-bucket", "test-key", "test-secret")
+	if err != nil {
+		t.Fatalf("NewAWSAdapter() error = %v", err)
+	}
+	wrapped := WriteOnly(adapter)
+
+	if _, ok := wrapped.(ObjectReader); ok {
+		t.Error("write-only adapter should not implement ObjectReader")
+	}
+	if _, ok := wrapped.(RangeReader); ok {
+		t.Error("write-only adapter should not implement RangeReader")
+	}
+	if _, ok := wrapped.(ObjectLister); ok {
+		t.Error("write-only adapter should not implement ObjectLister")
+	}
+	if _, ok := wrapped.(ObjectDeleter); ok {
+		t.Error("write-only adapter should not implement ObjectDeleter")
+	}
+	if _, ok := wrapped.(MetadataReader); ok {
+		t.Error("write-only adapter should not implement MetadataReader")
+	}
+	if _, ok := wrapped.(Restorer); ok {
+		t.Error("write-only adapter should not implement Restorer")
+	}
+
+	wantMin, wantMax := adapter.PartSizeLimits()
+	min, max, ok := PartSizeLimits(wrapped)
+	if !ok || min != wantMin || max != wantMax {
+		t.Errorf("PartSizeLimits() = %d, %d, %v, want %d, %d, true", min, max, ok, wantMin, wantMax)
+	}
+}
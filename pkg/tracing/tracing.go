@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 本工具的 instrumentation scope
+const tracerName = "github.com/lukelzlz/s3backup"
+
+// parent 从 TRACEPARENT 环境变量继承的上游 span，没有上游 trace 时无效
+var parent trace.SpanContext
+
+// Start 开始一个 span；ctx 中没有 span 时以上游的 TRACEPARENT 为父 span
+// 未调用 Setup 或没有配置导出时使用全局的空操作 TracerProvider，开销可以忽略
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if parent.IsValid() && !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, parent)
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束 span，err 不为 nil 时记录错误并将状态设为 Error
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Setup 读取 TRACEPARENT/TRACESTATE 环境变量继承上游 trace；设置了 OTEL_EXPORTER_OTLP_ENDPOINT
+// 或 OTEL_EXPORTER_OTLP_TRACES_ENDPOINT 时通过 OTLP/HTTP 导出 span（其余 OTEL_EXPORTER_OTLP_* 变量同样生效）
+// 返回的 shutdown 在退出前调用，导出尚未发送的 span
+func Setup(ctx context.Context, version string) (shutdown func(context.Context) error, err error) {
+	carrier := propagation.MapCarrier{"traceparent": os.Getenv("TRACEPARENT"), "tracestate": os.Getenv("TRACESTATE")}
+	parent = trace.SpanContextFromContext(propagation.TraceContext{}.Extract(ctx, carrier))
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// OTEL_SERVICE_NAME、OTEL_RESOURCE_ATTRIBUTES 覆盖默认的服务名
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", "s3backup"),
+			attribute.String("service.version", version),
+		),
+		resource.WithFromEnv(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// record 将全局 TracerProvider 替换为记录 span 的 provider，测试结束后恢复
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		parent = trace.SpanContext{}
+	})
+	return recorder
+}
+
+// TestStartEnd 测试子 span 继承父 span，出错的 span 状态为 Error
+func TestStartEnd(t *testing.T) {
+	recorder := record(t)
+
+	ctx, root := Start(context.Background(), "backup")
+	_, child := Start(ctx, "upload")
+	End(child, errors.New("boom"))
+	End(root, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	upload, backup := spans[0], spans[1]
+	if upload.Parent().SpanID() != backup.SpanContext().SpanID() {
+		t.Errorf("upload span is not a child of backup span")
+	}
+	if upload.Status().Code != codes.Error || upload.Status().Description != "boom" {
+		t.Errorf("upload status = %+v, want Error", upload.Status())
+	}
+	if backup.Status().Code != codes.Unset {
+		t.Errorf("backup status = %+v, want Unset", backup.Status())
+	}
+}
+
+// TestSetupTraceParent 测试根 span 继承 TRACEPARENT 环境变量中的上游 trace
+func TestSetupTraceParent(t *testing.T) {
+	t.Setenv("TRACEPARENT", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	shutdown, err := Setup(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	defer shutdown(context.Background())
+	recorder := record(t)
+
+	_, span := Start(context.Background(), "backup")
+	End(span, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the TRACEPARENT trace", got)
+	}
+	if got := spans[0].Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("parent span ID = %s, want the TRACEPARENT span", got)
+	}
+}
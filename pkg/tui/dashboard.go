@@ -0,0 +1,382 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lukelzlz/s3backup/pkg/i18n"
+)
+
+const (
+	// tickInterval 刷新界面和采样吞吐量的间隔
+	tickInterval = 500 * time.Millisecond
+	// maxSamples 保留的吞吐量采样数，用于绘制吞吐量曲线
+	maxSamples = 120
+	// logLines 显示的日志行数
+	logLines = 8
+)
+
+// sparkChars 吞吐量曲线使用的字符，从低到高
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// worker 一个上传 worker 当前的分块
+type worker struct {
+	part  int
+	size  int64
+	sent  int64
+	retry bool // 当前分块正在重试
+}
+
+// Dashboard 交互式终端仪表盘，显示当前归档的文件、各 worker 的分块进度、吞吐量曲线、重试次数和最近的日志
+//
+// Dashboard 实现 progress.Reporter 和 progress.PartObserver，可直接设置给上传器；
+// 所有方法都是并发安全的，界面由 Start 启动的 bubbletea 程序定期刷新
+type Dashboard struct {
+	mu sync.Mutex
+
+	title   string
+	phase   string
+	start   time.Time
+	total   int64
+	bytes   int64
+	file    string
+	files   int
+	workers map[int]*worker
+	retries int
+	lastErr string
+	logs    []string
+	done    bool
+
+	// 吞吐量采样
+	samples   []float64
+	lastBytes int64
+	lastTick  time.Time
+
+	program  *tea.Program
+	stopping bool
+}
+
+// New 创建仪表盘，title 显示在第一行
+func New(title string) *Dashboard {
+	now := time.Now()
+	return &Dashboard{
+		title:    title,
+		start:    now,
+		lastTick: now,
+		workers:  make(map[int]*worker),
+	}
+}
+
+// Start 在 out 上启动仪表盘，in 为 nil 时不读取键盘输入（例如标准输入已用于读取文件列表）
+// 用户按 Ctrl+C 或 q、或进程收到 SIGINT/SIGTERM 时仪表盘退出并恢复终端，然后调用 interrupt
+// 返回的 stop 渲染最后一帧后退出仪表盘，最后一帧保留在终端上
+func (d *Dashboard) Start(out io.Writer, in io.Reader, interrupt func()) (stop func()) {
+	d.mu.Lock()
+	d.program = tea.NewProgram(model{d: d}, tea.WithOutput(out), tea.WithInput(in))
+	p := d.program
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = p.Run()
+
+		d.mu.Lock()
+		interrupted := !d.stopping
+		d.mu.Unlock()
+		if interrupted && interrupt != nil {
+			interrupt()
+		}
+	}()
+
+	return func() {
+		d.mu.Lock()
+		d.stopping = true
+		d.mu.Unlock()
+		p.Quit()
+		<-done
+	}
+}
+
+// Init 初始化进度，total 为 0 表示总数未知
+func (d *Dashboard) Init(total int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if total > 0 {
+		d.total = total
+	}
+}
+
+// Add 增加已上传的字节数
+func (d *Dashboard) Add(n int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.bytes += n
+}
+
+// SetTotal 更新总字节数
+func (d *Dashboard) SetTotal(total int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.total = total
+}
+
+// SetPhase 设置当前阶段
+func (d *Dashboard) SetPhase(phase string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.phase = phase
+}
+
+// Complete 标记完成
+func (d *Dashboard) Complete() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.done = true
+	d.file = ""
+}
+
+// Close 由上传器在结束时调用，仪表盘由 Start 返回的 stop 关闭
+func (d *Dashboard) Close() error {
+	return nil
+}
+
+// PartStarted worker 开始（或重试）上传分块
+func (d *Dashboard) PartStarted(id, partNumber int, size int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w, ok := d.workers[id]
+	if !ok || w.part != partNumber {
+		w = &worker{}
+		d.workers[id] = w
+	}
+	w.part, w.size, w.sent = partNumber, size, 0
+}
+
+// PartProgress worker 当前分块已发送 n 字节
+func (d *Dashboard) PartProgress(id int, n int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if w, ok := d.workers[id]; ok {
+		w.sent = n
+	}
+}
+
+// PartRetry 分块上传失败，等待后重试
+func (d *Dashboard) PartRetry(id, partNumber int, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.retries++
+	d.lastErr = i18n.Sprintf("分块 %d: %v", partNumber, err)
+	if w, ok := d.workers[id]; ok {
+		w.retry = true
+	}
+}
+
+// PartDone worker 完成当前分块
+func (d *Dashboard) PartDone(id, partNumber int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.workers, id)
+}
+
+// SetFile 设置当前归档的文件
+func (d *Dashboard) SetFile(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.file = path
+	d.files++
+}
+
+// Log 追加一行日志，只保留最近的 logLines 行
+func (d *Dashboard) Log(line string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.logs = append(d.logs, line)
+	if len(d.logs) > logLines {
+		d.logs = d.logs[len(d.logs)-logLines:]
+	}
+}
+
+// sample 记录自上次采样以来的吞吐量（字节/秒）
+func (d *Dashboard) sample(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	elapsed := now.Sub(d.lastTick).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	d.samples = append(d.samples, float64(d.bytes-d.lastBytes)/elapsed)
+	if len(d.samples) > maxSamples {
+		d.samples = d.samples[len(d.samples)-maxSamples:]
+	}
+	d.lastBytes = d.bytes
+	d.lastTick = now
+}
+
+// render 按终端宽度 width 渲染界面
+func (d *Dashboard) render(width int) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if width <= 0 {
+		width = 80
+	}
+	barWidth := min(max(width-40, 10), 50)
+
+	var b strings.Builder
+	elapsed := time.Since(d.start).Round(time.Second)
+	fmt.Fprintf(&b, "%s  %s  %s\n\n", d.title, d.phase, elapsed)
+
+	file := d.file
+	if d.done {
+		file = i18n.T("（归档完成）")
+	}
+	i18n.Fprintf(&b, "文件: %s\n", truncateLeft(file, width-6))
+	i18n.Fprintf(&b, "      已归档 %d 个文件\n", d.files)
+
+	if d.total > 0 {
+		i18n.Fprintf(&b, "进度: %s %5.1f%%  %s / %s\n", bar(d.bytes, d.total, barWidth),
+			float64(d.bytes)*100/float64(d.total), formatBytes(d.bytes), formatBytes(d.total))
+	} else {
+		i18n.Fprintf(&b, "进度: %s（总大小未知）\n", formatBytes(d.bytes))
+	}
+
+	var speed float64
+	if n := len(d.samples); n > 0 {
+		speed = d.samples[n-1]
+	}
+	i18n.Fprintf(&b, "吞吐: %s/s  %s\n", formatBytes(int64(speed)), sparkline(d.samples, barWidth))
+
+	i18n.Fprintf(&b, "重试: %d", d.retries)
+	if d.lastErr != "" {
+		i18n.Fprintf(&b, "（最近: %s）", truncateRight(d.lastErr, width-20))
+	}
+	b.WriteString("\n\n")
+
+	ids := make([]int, 0, len(d.workers))
+	for id := range d.workers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	if len(ids) == 0 {
+		b.WriteString(i18n.T("Worker: 空闲\n"))
+	}
+	for _, id := range ids {
+		w := d.workers[id]
+		status := ""
+		if w.retry {
+			status = i18n.T("  重试中")
+		}
+		i18n.Fprintf(&b, "Worker %-2d 分块 %-5d %s %s / %s%s\n", id+1, w.part, bar(w.sent, w.size, barWidth/2),
+			formatBytes(w.sent), formatBytes(w.size), status)
+	}
+
+	b.WriteString(i18n.T("\n日志:\n"))
+	for _, line := range d.logs {
+		fmt.Fprintf(&b, "  %s\n", truncateRight(line, width-2))
+	}
+	return b.String()
+}
+
+// bar 绘制宽度为 width 的进度条
+func bar(n, total int64, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = int(min(n, total) * int64(width) / total)
+	}
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("─", width-filled) + "]"
+}
+
+// sparkline 将最近的 width 个采样绘制为曲线，按其中的最大值缩放
+func sparkline(samples []float64, width int) string {
+	if len(samples) > width {
+		samples = samples[len(samples)-width:]
+	}
+	var peak float64
+	for _, s := range samples {
+		peak = max(peak, s)
+	}
+	runes := make([]rune, len(samples))
+	for i, s := range samples {
+		idx := 0
+		if peak > 0 {
+			idx = int(s / peak * float64(len(sparkChars)-1))
+		}
+		runes[i] = sparkChars[idx]
+	}
+	return string(runes)
+}
+
+// formatBytes 将字节数格式化为 KB/MB/GB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// truncateLeft 保留字符串末尾的 width 个字符，路径的末尾比开头更有用
+func truncateLeft(s string, width int) string {
+	r := []rune(s)
+	if width <= 3 || len(r) <= width {
+		return s
+	}
+	return "..." + string(r[len(r)-width+3:])
+}
+
+// truncateRight 保留字符串开头的 width 个字符
+func truncateRight(s string, width int) string {
+	r := []rune(s)
+	if width <= 3 || len(r) <= width {
+		return s
+	}
+	return string(r[:width-3]) + "..."
+}
+
+// tickMsg 定期刷新
+type tickMsg time.Time
+
+// model bubbletea 模型，状态保存在 Dashboard 中
+type model struct {
+	d     *Dashboard
+	width int
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(tickInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m model) Init() tea.Cmd {
+	return tick()
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tickMsg:
+		m.d.sample(time.Time(msg))
+		return m, tick()
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	return m.d.render(m.width)
+}
@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDashboardRender 测试界面显示当前文件、进度、worker 分块、重试和日志
+func TestDashboardRender(t *testing.T) {
+	d := New("s3backup backup")
+	d.SetPhase("Archiving → Uploading")
+	d.SetFile("/data/a.txt")
+	d.SetFile("/data/b.txt")
+	d.Init(0)
+	d.SetTotal(4096)
+	d.Add(1024)
+	d.PartStarted(0, 3, 2048)
+	d.PartProgress(0, 512)
+	d.PartRetry(1, 2, errors.New("connection reset"))
+	d.Log("[警告] 无法打开文件: /data/c.txt")
+
+	out := d.render(100)
+	for _, want := range []string{
+		"Archiving → Uploading",
+		"文件: /data/b.txt",
+		"已归档 2 个文件",
+		" 25.0%",
+		"1.0 KB / 4.0 KB",
+		"Worker 1  分块 3",
+		"512 B / 2.0 KB",
+		"重试: 1（最近: 分块 2: connection reset）",
+		"[警告] 无法打开文件: /data/c.txt",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("render() missing %q:\n%s", want, out)
+		}
+	}
+
+	d.PartDone(0, 3)
+	d.Complete()
+	out = d.render(100)
+	if !strings.Contains(out, "Worker: 空闲") || !strings.Contains(out, "（归档完成）") {
+		t.Errorf("unexpected render after completion:\n%s", out)
+	}
+}
+
+// TestDashboardLogTail 测试只保留最近的日志
+func TestDashboardLogTail(t *testing.T) {
+	d := New("test")
+	for i := 0; i < logLines+5; i++ {
+		d.Log(fmt.Sprintf("line %d", i))
+	}
+	if len(d.logs) != logLines || d.logs[0] != "line 5" {
+		t.Errorf("logs = %q", d.logs)
+	}
+}
+
+// TestDashboardSample 测试吞吐量采样和曲线
+func TestDashboardSample(t *testing.T) {
+	d := New("test")
+	now := d.lastTick
+	for i := 1; i <= 4; i++ {
+		d.Add(int64(i) * 1024)
+		d.sample(now.Add(time.Duration(i) * time.Second))
+	}
+	if len(d.samples) != 4 || d.samples[3] != 4096 {
+		t.Fatalf("samples = %v", d.samples)
+	}
+	if got := sparkline(d.samples, 3); got != "▄▆█" {
+		t.Errorf("sparkline = %q", got)
+	}
+	if got := sparkline([]float64{0, 0}, 10); got != "▁▁" {
+		t.Errorf("sparkline of zeros = %q", got)
+	}
+}
+
+// TestFormatBytes 测试字节数格式化
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1536:            "1.5 KB",
+		5 * 1024 * 1024: "5.0 MB",
+		3 << 30:         "3.0 GB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+// TestTruncate 测试按字符截断
+func TestTruncate(t *testing.T) {
+	if got := truncateLeft("/very/long/path/file.txt", 12); got != ".../file.txt" {
+		t.Errorf("truncateLeft = %q", got)
+	}
+	if got := truncateRight("连接被重置，正在重试", 8); got != "连接被重置..." {
+		t.Errorf("truncateRight = %q", got)
+	}
+}
@@ -0,0 +1,22 @@
+package tui
+
+import "github.com/lukelzlz/s3backup/pkg/i18n"
+
+// 仪表盘的英文翻译，各字段名补齐到相同宽度以便对齐
+func init() {
+	i18n.Register(i18n.En, map[string]string{
+		"分块 %d: %v":                          "part %d: %v",
+		"（归档完成）":                             "(archive complete)",
+		"文件: %s\n":                           "File:     %s\n",
+		"      已归档 %d 个文件\n":                 "          %d files archived\n",
+		"进度: %s %5.1f%%  %s / %s\n":          "Progress: %s %5.1f%%  %s / %s\n",
+		"进度: %s（总大小未知）\n":                    "Progress: %s (total size unknown)\n",
+		"吞吐: %s/s  %s\n":                     "Speed:    %s/s  %s\n",
+		"重试: %d":                             "Retries:  %d",
+		"（最近: %s）":                           " (last: %s)",
+		"Worker: 空闲\n":                       "Worker: idle\n",
+		"  重试中":                              "  retrying",
+		"Worker %-2d 分块 %-5d %s %s / %s%s\n": "Worker %-2d part %-5d %s %s / %s%s\n",
+		"\n日志:\n":                            "\nLog:\n",
+	})
+}
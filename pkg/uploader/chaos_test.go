@@ -0,0 +1,342 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/progress"
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// chaosData 生成 n 个分块大小的测试数据，最后一个分块不满
+func chaosData(chunkSize, n int) []byte {
+	data := make([]byte, chunkSize*n-chunkSize/2)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+// TestChaosNetworkFailureMidPart 测试分块传输中断后重试，最终对象内容完整
+func TestChaosNetworkFailureMidPart(t *testing.T) {
+	defer func(d time.Duration) { networkRetryDelay = d }(networkRetryDelay)
+	networkRetryDelay = time.Millisecond
+
+	adapter := mock.New()
+	adapter.FailPart(2, 512, mock.NetworkError(), 2)
+
+	data := chaosData(1024, 4)
+	upl := NewUploader(adapter, 1024, 2)
+	if err := upl.Upload(context.Background(), "chaos", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	obj, ok := adapter.Object("chaos")
+	if !ok || !bytes.Equal(obj.Data, data) {
+		t.Fatal("uploaded object does not match source data")
+	}
+	if got := upl.Stats().Retries; got != 2 {
+		t.Errorf("retries = %d, want 2", got)
+	}
+}
+
+// partRecorder 记录 PartObserver 收到的事件
+type partRecorder struct {
+	*progress.MockReporter
+	mu       sync.Mutex
+	started  map[int]int   // 分块号 -> 开始（含重试）次数
+	maxSent  map[int]int64 // worker -> 报告的最大发送位置
+	retried  []int
+	finished []int
+}
+
+func newPartRecorder() *partRecorder {
+	return &partRecorder{MockReporter: progress.NewMockReporter(), started: map[int]int{}, maxSent: map[int]int64{}}
+}
+
+func (r *partRecorder) PartStarted(worker, partNumber int, size int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.started[partNumber]++
+}
+
+func (r *partRecorder) PartProgress(worker int, n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxSent[worker] = max(r.maxSent[worker], n)
+}
+
+func (r *partRecorder) PartRetry(worker, partNumber int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retried = append(r.retried, partNumber)
+}
+
+func (r *partRecorder) PartDone(worker, partNumber int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finished = append(r.finished, partNumber)
+}
+
+// TestChaosPartObserver 测试进度报告器实现 PartObserver 时收到每个分块的开始、进度、重试和完成事件
+func TestChaosPartObserver(t *testing.T) {
+	defer func(d time.Duration) { networkRetryDelay = d }(networkRetryDelay)
+	networkRetryDelay = time.Millisecond
+
+	adapter := mock.New()
+	adapter.FailPart(2, 512, mock.NetworkError(), 1)
+
+	rec := newPartRecorder()
+	data := chaosData(1024, 4)
+	upl := NewUploader(adapter, 1024, 2)
+	upl.SetProgressReporter(rec)
+	if err := upl.Upload(context.Background(), "chaos", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	if rec.started[2] != 2 || rec.started[1] != 1 || len(rec.started) != 4 {
+		t.Errorf("started = %v, want part 2 started twice", rec.started)
+	}
+	if len(rec.retried) != 1 || rec.retried[0] != 2 {
+		t.Errorf("retried = %v", rec.retried)
+	}
+	if len(rec.finished) != 4 {
+		t.Errorf("finished = %v", rec.finished)
+	}
+	for worker, sent := range rec.maxSent {
+		if sent <= 0 || sent > 1024 {
+			t.Errorf("worker %d reported position %d", worker, sent)
+		}
+	}
+	if len(rec.maxSent) == 0 {
+		t.Error("no part progress reported")
+	}
+}
+
+// TestChaosThrottleWithLatency 测试随机延迟和限流同时存在时上传仍然完整
+func TestChaosThrottleWithLatency(t *testing.T) {
+	adapter := mock.New()
+	adapter.SetLatency(0, 2*time.Millisecond, 42)
+	adapter.ThrottlePart(0, 3, 0)
+
+	data := chaosData(1024, 8)
+	upl := NewUploader(adapter, 1024, 4)
+	if err := upl.Upload(context.Background(), "chaos", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	obj, ok := adapter.Object("chaos")
+	if !ok || !bytes.Equal(obj.Data, data) {
+		t.Fatal("uploaded object does not match source data")
+	}
+	if got := upl.Stats().Throttled; got != 3 {
+		t.Errorf("throttled = %d, want 3", got)
+	}
+}
+
+// TestChaosPermanentFailureAborts 测试不可重试的错误会取消上传
+func TestChaosPermanentFailureAborts(t *testing.T) {
+	adapter := mock.New()
+	adapter.FailPart(3, 100, nil, 0)
+
+	upl := NewUploader(adapter, 1024, 2)
+	err := upl.Upload(context.Background(), "chaos", bytes.NewReader(chaosData(1024, 4)), storage.UploadOptions{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if len(adapter.PendingUploads()) != 0 {
+		t.Errorf("upload should be aborted, pending: %v", adapter.PendingUploads())
+	}
+	if _, ok := adapter.Object("chaos"); ok {
+		t.Error("object should not exist after failed upload")
+	}
+}
+
+// TestChaosCorruptPart 测试分块在传输中损坏（ETag 与本地 MD5 不一致）时重新上传，超过次数后失败
+func TestChaosCorruptPart(t *testing.T) {
+	adapter := mock.New()
+	adapter.CorruptPart(2, 2)
+
+	data := chaosData(1024, 4)
+	upl := NewUploader(adapter, 1024, 2)
+	if err := upl.Upload(context.Background(), "chaos", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	obj, ok := adapter.Object("chaos")
+	if !ok || !bytes.Equal(obj.Data, data) {
+		t.Fatal("uploaded object does not match source data")
+	}
+	if got := upl.Stats().ETagMismatches; got != 2 {
+		t.Errorf("ETag mismatches = %d, want 2", got)
+	}
+
+	// 一直损坏时重新上传 1 次后失败
+	adapter = mock.New()
+	adapter.CorruptPart(2, 0)
+	upl = NewUploader(adapter, 1024, 2)
+	upl.SetETagRetries(1)
+	err := upl.Upload(context.Background(), "chaos", bytes.NewReader(data), storage.UploadOptions{})
+	if !errors.Is(err, ErrETagMismatch) {
+		t.Fatalf("Upload() error = %v, want ErrETagMismatch", err)
+	}
+	if got := upl.Stats().ETagMismatches; got != 2 {
+		t.Errorf("ETag mismatches = %d, want 2", got)
+	}
+
+	// 关闭校验时不检查 ETag
+	adapter = mock.New()
+	adapter.CorruptPart(2, 0)
+	upl = NewUploader(adapter, 1024, 2)
+	upl.SetETagRetries(-1)
+	if err := upl.Upload(context.Background(), "chaos", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if adapter.Calls(mock.OpUploadPart) != 4 {
+		t.Errorf("upload_part calls = %d, want 4", adapter.Calls(mock.OpUploadPart))
+	}
+}
+
+// TestChaosResumeCorruptPart 测试续传时损坏的分块同样重新上传
+func TestChaosResumeCorruptPart(t *testing.T) {
+	ctx := context.Background()
+	adapter := mock.New()
+	data := chaosData(1024, 4)
+	uploadID, err := adapter.InitMultipartUpload(ctx, "chaos", storage.UploadOptions{})
+	if err != nil {
+		t.Fatalf("InitMultipartUpload() error = %v", err)
+	}
+
+	adapter.CorruptPart(3, 1)
+	upl := NewResumableUploader(adapter, 1024, 2, &state.UploadState{UploadID: uploadID})
+	if err := upl.Resume(ctx, "chaos", uploadID, bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if got := adapter.Calls(mock.OpUploadPart); got != 5 {
+		t.Errorf("upload_part calls = %d, want 5", got)
+	}
+	obj, ok := adapter.Object("chaos")
+	if !ok || !bytes.Equal(obj.Data, data) {
+		t.Fatal("resumed object does not match source data")
+	}
+}
+
+// TestChaosResumeAfterFailure 测试中断后从已上传的分块恢复
+func TestChaosResumeAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	adapter := mock.New()
+	data := chaosData(1024, 4)
+
+	uploadID, err := adapter.InitMultipartUpload(ctx, "chaos", storage.UploadOptions{})
+	if err != nil {
+		t.Fatalf("InitMultipartUpload() error = %v", err)
+	}
+
+	// 第一次上传在分块 3 中途失败
+	adapter.FailPart(3, 100, nil, 0)
+	first := NewResumableUploader(adapter, 1024, 1, &state.UploadState{UploadID: uploadID})
+	if err := first.Resume(ctx, "chaos", uploadID, bytes.NewReader(data), storage.UploadOptions{}); err == nil {
+		t.Fatal("expected first attempt to fail")
+	}
+
+	uploaded, err := adapter.ListParts(ctx, "chaos", uploadID)
+	if err != nil || len(uploaded) != 2 {
+		t.Fatalf("expected parts 1-2 uploaded, got %v", uploaded)
+	}
+
+	// 使用已上传分块的 ETag 恢复
+	saved := &state.UploadState{Key: "chaos", UploadID: uploadID}
+	for _, p := range uploaded {
+		saved.Completed = append(saved.Completed, state.CompletedPart{PartNumber: p.PartNumber, ETag: p.ETag, Size: 1024})
+	}
+
+	adapter.ClearFaults()
+	before := adapter.Calls(mock.OpUploadPart)
+	second := NewResumableUploader(adapter, 1024, 2, saved)
+	if err := second.Resume(ctx, "chaos", uploadID, bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	if got := adapter.Calls(mock.OpUploadPart) - before; got != 2 {
+		t.Errorf("expected 2 parts uploaded on resume, got %d", got)
+	}
+	obj, ok := adapter.Object("chaos")
+	if !ok || !bytes.Equal(obj.Data, data) {
+		t.Fatal("resumed object does not match source data")
+	}
+}
+
+// TestChaosResumeReuploadsMissingParts 测试续传时服务端缺失或 ETag 不一致的分块会重新上传
+func TestChaosResumeReuploadsMissingParts(t *testing.T) {
+	ctx := context.Background()
+	adapter := mock.New()
+	data := chaosData(1024, 4)
+
+	uploadID, err := adapter.InitMultipartUpload(ctx, "chaos", storage.UploadOptions{})
+	if err != nil {
+		t.Fatalf("InitMultipartUpload() error = %v", err)
+	}
+	adapter.FailPart(4, 0, nil, 0)
+	first := NewResumableUploader(adapter, 1024, 1, &state.UploadState{UploadID: uploadID})
+	if err := first.Resume(ctx, "chaos", uploadID, bytes.NewReader(data), storage.UploadOptions{}); err == nil {
+		t.Fatal("expected first attempt to fail")
+	}
+
+	uploaded, err := adapter.ListParts(ctx, "chaos", uploadID)
+	if err != nil || len(uploaded) != 3 {
+		t.Fatalf("expected parts 1-3 uploaded, got %v", uploaded)
+	}
+	saved := &state.UploadState{Key: "chaos", UploadID: uploadID, UploadedBytes: 3 * 1024}
+	for _, p := range uploaded {
+		saved.Completed = append(saved.Completed, state.CompletedPart{PartNumber: p.PartNumber, ETag: p.ETag, Size: 1024})
+	}
+	// 分块 1 被服务端清理，分块 2 的本地记录与服务端不一致
+	adapter.DropPart(uploadID, 1)
+	saved.Completed[1].ETag = "stale"
+
+	adapter.ClearFaults()
+	before := adapter.Calls(mock.OpUploadPart)
+	second := NewResumableUploader(adapter, 1024, 2, saved)
+	if err := second.Resume(ctx, "chaos", uploadID, bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	if got := adapter.Calls(mock.OpUploadPart) - before; got != 3 {
+		t.Errorf("expected parts 1, 2 and 4 uploaded on resume, got %d uploads", got)
+	}
+	if got := second.Reuploaded(); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("Reuploaded() = %v, want [1 2]", got)
+	}
+	obj, ok := adapter.Object("chaos")
+	if !ok || !bytes.Equal(obj.Data, data) {
+		t.Fatal("resumed object does not match source data")
+	}
+}
+
+// TestChaosResumeUploadGone 测试服务端上传已被清理时续传直接失败
+func TestChaosResumeUploadGone(t *testing.T) {
+	ctx := context.Background()
+	adapter := mock.New()
+	data := chaosData(1024, 2)
+
+	uploadID, _ := adapter.InitMultipartUpload(ctx, "chaos", storage.UploadOptions{})
+	etag, _ := adapter.UploadPart(ctx, "chaos", uploadID, 1, bytes.NewReader(data[:1024]), 1024)
+	adapter.AbortMultipartUpload(ctx, "chaos", uploadID)
+
+	saved := &state.UploadState{Key: "chaos", UploadID: uploadID,
+		Completed: []state.CompletedPart{{PartNumber: 1, ETag: etag, Size: 1024}}}
+	upl := NewResumableUploader(adapter, 1024, 1, saved)
+	err := upl.Resume(ctx, "chaos", uploadID, bytes.NewReader(data), storage.UploadOptions{})
+	if !errors.Is(err, storage.ErrUploadNotFound) {
+		t.Fatalf("expected ErrUploadNotFound, got %v", err)
+	}
+	if got := adapter.Calls(mock.OpUploadPart); got != 1 {
+		t.Errorf("expected no parts uploaded after upload is gone, got %d calls", got)
+	}
+}
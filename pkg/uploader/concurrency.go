@@ -0,0 +1,157 @@
+package uploader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// throughputGain 吞吐量提升超过该比例才继续增加并发
+	throughputGain = 1.05
+)
+
+// ConcurrencyController AIMD 并发控制器
+// 吞吐量持续提升时每个观测窗口并发数加一（加性增），
+// 遇到限流（SlowDown/503）时并发数减半（乘性减）
+type ConcurrencyController struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	min      int
+	max      int
+	limit    int
+	active   int
+	decrease time.Time // 上次减半时间，用于合并同一时段内的多次限流
+
+	// 当前观测窗口
+	windowStart time.Time
+	windowBytes int64
+	windowParts int
+	lastRate    float64
+}
+
+// NewConcurrencyController 创建并发控制器，并发数在 [min, max] 范围内调整
+func NewConcurrencyController(initial, min, max int) *ConcurrencyController {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	if initial < min {
+		initial = min
+	}
+	if initial > max {
+		initial = max
+	}
+
+	c := &ConcurrencyController{
+		min:   min,
+		max:   max,
+		limit: initial,
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Max 返回并发上限，上传器按此数量启动 worker
+func (c *ConcurrencyController) Max() int {
+	return c.max
+}
+
+// Limit 返回当前允许的并发数
+func (c *ConcurrencyController) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit
+}
+
+// Acquire 等待获取一个上传槽位
+func (c *ConcurrencyController) Acquire(ctx context.Context) error {
+	// 上下文取消时唤醒等待者
+	stop := context.AfterFunc(ctx, func() {
+		c.mu.Lock()
+		c.cond.Broadcast()
+		c.mu.Unlock()
+	})
+	defer stop()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for c.active >= c.limit {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c.cond.Wait()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.active++
+	if c.windowStart.IsZero() {
+		c.windowStart = time.Now()
+	}
+	return nil
+}
+
+// Release 释放上传槽位
+func (c *ConcurrencyController) Release() {
+	c.mu.Lock()
+	c.active--
+	c.cond.Broadcast()
+	c.mu.Unlock()
+}
+
+// OnSuccess 记录一次成功上传，每完成 limit 个分块评估一次吞吐量
+func (c *ConcurrencyController) OnSuccess(size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.windowBytes += size
+	c.windowParts++
+	if c.windowParts < c.limit {
+		return
+	}
+
+	elapsed := time.Since(c.windowStart).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	rate := float64(c.windowBytes) / elapsed
+
+	// 吞吐量仍在提升，尝试增加并发
+	if (c.lastRate == 0 || rate > c.lastRate*throughputGain) && c.limit < c.max {
+		c.limit++
+		c.cond.Broadcast()
+	}
+
+	c.lastRate = rate
+	c.windowStart = time.Now()
+	c.windowBytes = 0
+	c.windowParts = 0
+}
+
+// OnThrottle 记录一次限流，并发数减半
+// 多个 worker 同时遇到限流时，1 秒内只减半一次
+func (c *ConcurrencyController) OnThrottle() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.decrease.IsZero() && time.Since(c.decrease) < time.Second {
+		return
+	}
+
+	c.limit /= 2
+	if c.limit < c.min {
+		c.limit = c.min
+	}
+	c.decrease = time.Now()
+
+	// 重置观测窗口，避免以限流前的吞吐量为基准
+	c.windowStart = time.Now()
+	c.windowBytes = 0
+	c.windowParts = 0
+	c.lastRate = 0
+}
@@ -0,0 +1,232 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+)
+
+// TestConcurrencyControllerBounds 测试初始并发数被限制在范围内
+func TestConcurrencyControllerBounds(t *testing.T) {
+	if got := NewConcurrencyController(0, 2, 8).Limit(); got != 2 {
+		t.Errorf("initial below min: got %d, want 2", got)
+	}
+	if got := NewConcurrencyController(20, 2, 8).Limit(); got != 8 {
+		t.Errorf("initial above max: got %d, want 8", got)
+	}
+	if got := NewConcurrencyController(4, 0, 0).Max(); got != 1 {
+		t.Errorf("max should be at least 1, got %d", got)
+	}
+}
+
+// TestConcurrencyControllerAdditiveIncrease 测试吞吐量提升时并发数加一
+func TestConcurrencyControllerAdditiveIncrease(t *testing.T) {
+	c := NewConcurrencyController(2, 1, 4)
+
+	for i := 0; i < 2; i++ {
+		if err := c.Acquire(context.Background()); err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		c.Release()
+		c.OnSuccess(1024)
+	}
+
+	if got := c.Limit(); got != 3 {
+		t.Errorf("limit after first window = %d, want 3", got)
+	}
+}
+
+// TestConcurrencyControllerMultiplicativeDecrease 测试限流时并发数减半且短时间内只减一次
+func TestConcurrencyControllerMultiplicativeDecrease(t *testing.T) {
+	c := NewConcurrencyController(8, 1, 16)
+
+	c.OnThrottle()
+	if got := c.Limit(); got != 4 {
+		t.Errorf("limit after throttle = %d, want 4", got)
+	}
+
+	// 同时到达的限流只减半一次
+	c.OnThrottle()
+	if got := c.Limit(); got != 4 {
+		t.Errorf("limit after burst throttle = %d, want 4", got)
+	}
+}
+
+// TestConcurrencyControllerMinLimit 测试减半不会低于下限
+func TestConcurrencyControllerMinLimit(t *testing.T) {
+	c := NewConcurrencyController(2, 2, 8)
+	c.OnThrottle()
+	if got := c.Limit(); got != 2 {
+		t.Errorf("limit = %d, want min 2", got)
+	}
+}
+
+// TestConcurrencyControllerAcquireBlocks 测试达到上限时 Acquire 阻塞直到取消
+func TestConcurrencyControllerAcquireBlocks(t *testing.T) {
+	c := NewConcurrencyController(1, 1, 1)
+	if err := c.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Acquire(ctx); err == nil {
+		t.Error("second Acquire() should block until context is done")
+	}
+
+	c.Release()
+	if err := c.Acquire(context.Background()); err != nil {
+		t.Errorf("Acquire() after Release error = %v", err)
+	}
+}
+
+// throttlingAdapter 前若干次 UploadPart 返回 SlowDown 的模拟适配器
+type throttlingAdapter struct {
+	mockAdapter
+	throttles atomic.Int64
+	remaining atomic.Int64
+}
+
+func (a *throttlingAdapter) UploadPart(ctx context.Context, key, uploadID string, partNumber int, r io.Reader, size int64) (string, error) {
+	if a.remaining.Add(-1) >= 0 {
+		a.throttles.Add(1)
+		return "", &storage.ThrottledError{
+			Provider: "aws",
+			Code:     "SlowDown",
+			Err:      &smithy.GenericAPIError{Code: "SlowDown", Message: "Please reduce your request rate."},
+		}
+	}
+	return a.mockAdapter.UploadPart(ctx, key, uploadID, partNumber, r, size)
+}
+
+// TestUploadRetriesThrottledParts 测试限流的分块会退避重试并降低并发
+func TestUploadRetriesThrottledParts(t *testing.T) {
+	adapter := &throttlingAdapter{}
+	adapter.remaining.Store(2)
+
+	controller := NewConcurrencyController(4, 1, 4)
+	upl := NewUploader(adapter, 1024, 4)
+	upl.SetConcurrencyController(controller)
+
+	data := bytes.Repeat([]byte("x"), 8*1024)
+	if err := upl.Upload(context.Background(), "throttled", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	if adapter.throttles.Load() != 2 {
+		t.Errorf("expected 2 throttled attempts, got %d", adapter.throttles.Load())
+	}
+	if adapter.completeCalled.Load() != 1 {
+		t.Error("expected upload to complete")
+	}
+	if adapter.abortCalled.Load() != 0 {
+		t.Error("throttled upload should not be aborted")
+	}
+}
+
+// TestUploadRetriesThrottledPartsWithoutController 测试未启用并发控制时限流分块同样退避重试
+func TestUploadRetriesThrottledPartsWithoutController(t *testing.T) {
+	adapter := &throttlingAdapter{}
+	adapter.remaining.Store(1)
+
+	upl := NewUploader(adapter, 1024, 1)
+	if err := upl.Upload(context.Background(), "throttled", bytes.NewReader(make([]byte, 2048)), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	stats := upl.Stats()
+	if stats.Parts != 2 || stats.Throttled != 1 || stats.Retries != 1 {
+		t.Errorf("Stats() = %+v, want 2 parts, 1 throttled, 1 retry", stats)
+	}
+}
+
+// TestUploadFailsAfterThrottleRetries 测试限流重试次数耗尽后上传失败
+func TestUploadFailsAfterThrottleRetries(t *testing.T) {
+	adapter := &throttlingAdapter{}
+	adapter.remaining.Store(100)
+
+	upl := NewUploader(adapter, 1024, 1)
+	err := upl.Upload(context.Background(), "throttled", bytes.NewReader(make([]byte, 1024)), storage.UploadOptions{})
+	if !storage.IsThrottled(err) {
+		t.Fatalf("expected throttled error, got %v", err)
+	}
+
+	if got := upl.Stats().Retries; got != maxPartRetries {
+		t.Errorf("retries = %d, want %d", got, maxPartRetries)
+	}
+	if adapter.abortCalled.Load() != 1 {
+		t.Error("expected upload to be aborted")
+	}
+}
+
+// TestResumeRetriesThrottledParts 测试续传与 Uploader 相同地退避重试限流的分块，并计入统计
+func TestResumeRetriesThrottledParts(t *testing.T) {
+	adapter := &throttlingAdapter{}
+	adapter.remaining.Store(2)
+
+	saved := &state.UploadState{Key: "throttled", UploadID: "test-upload-id",
+		Completed: []state.CompletedPart{{PartNumber: 1, ETag: "etag-1024", Size: 1024}}}
+	adapter.serverParts = []storage.PartInfo{{PartNumber: 1, ETag: "etag-1024", Size: 1024}}
+	upl := NewResumableUploader(adapter, 1024, 1, saved)
+	err := upl.Resume(context.Background(), "throttled", saved.UploadID, bytes.NewReader(make([]byte, 3*1024)), storage.UploadOptions{})
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	stats := upl.Stats()
+	if stats.Parts != 2 || stats.Throttled != 2 || stats.Retries != 2 {
+		t.Errorf("Stats() = %+v, want 2 parts, 2 throttled, 2 retries", stats)
+	}
+	if adapter.completeCalled.Load() != 1 {
+		t.Error("expected upload to complete")
+	}
+}
+
+// networkFailingAdapter 前若干次 UploadPart 返回网络错误的模拟适配器
+type networkFailingAdapter struct {
+	mockAdapter
+	remaining atomic.Int64
+	kind      error
+}
+
+func (a *networkFailingAdapter) UploadPart(ctx context.Context, key, uploadID string, partNumber int, r io.Reader, size int64) (string, error) {
+	if a.remaining.Add(-1) >= 0 {
+		return "", &storage.ProviderError{Kind: a.kind, Provider: "aws", Err: errors.New("connection reset by peer")}
+	}
+	return a.mockAdapter.UploadPart(ctx, key, uploadID, partNumber, r, size)
+}
+
+// TestUploadRetriesNetworkErrors 测试网络错误重试，认证错误不重试
+func TestUploadRetriesNetworkErrors(t *testing.T) {
+	defer func(d time.Duration) { networkRetryDelay = d }(networkRetryDelay)
+	networkRetryDelay = time.Millisecond
+
+	adapter := &networkFailingAdapter{kind: storage.ErrNetwork}
+	adapter.remaining.Store(2)
+	upl := NewUploader(adapter, 1024, 1)
+	if err := upl.Upload(context.Background(), "network", bytes.NewReader(make([]byte, 1024)), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if got := upl.Stats().Retries; got != 2 {
+		t.Errorf("retries = %d, want 2", got)
+	}
+
+	authAdapter := &networkFailingAdapter{kind: storage.ErrAuth}
+	authAdapter.remaining.Store(1)
+	upl = NewUploader(authAdapter, 1024, 1)
+	err := upl.Upload(context.Background(), "auth", bytes.NewReader(make([]byte, 1024)), storage.UploadOptions{})
+	if !errors.Is(err, storage.ErrAuth) {
+		t.Fatalf("expected ErrAuth, got %v", err)
+	}
+	if got := upl.Stats().Retries; got != 0 {
+		t.Errorf("auth errors should not be retried, got %d retries", got)
+	}
+}
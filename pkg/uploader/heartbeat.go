@@ -0,0 +1,95 @@
+package uploader
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/state"
+)
+
+// Heartbeat 长时间上传期间定期报告的进度
+type Heartbeat struct {
+	Key      string
+	Uploaded int64         // 已完成分块的字节数，续传时包含之前上传的部分
+	Total    int64         // 总字节数，未知时为 0
+	Parts    int64         // 本次上传完成的分块数
+	Elapsed  time.Duration // 累计上传用时，包含之前中断的上传
+	Idle     time.Duration // 距最近一次完成分块的时间，持续增长说明上传可能已挂起
+}
+
+// heartbeat 上传期间每隔 interval 将进度写入状态文件（检查点）并调用 fn
+type heartbeat struct {
+	interval time.Duration
+	fn       func(Heartbeat)
+
+	uploaded atomic.Int64
+	parts    atomic.Int64
+	lastPart atomic.Int64 // 最近一次完成分块的时间（UnixNano）
+}
+
+// partDone 记录完成的分块
+func (h *heartbeat) partDone(size int64) {
+	h.uploaded.Add(size)
+	h.parts.Add(1)
+	h.lastPart.Store(time.Now().UnixNano())
+}
+
+// start 开始心跳，resumed 为续传前已上传的字节数
+// 返回的 stop 停止心跳并写入最后一次检查点，需在删除状态文件之前调用
+func (h *heartbeat) start(key string, total, resumed int64, sm *state.StateManager) (stop func()) {
+	if h.interval <= 0 {
+		return func() {}
+	}
+
+	begin := time.Now()
+	var base time.Duration
+	if sm != nil {
+		if s := sm.GetState(); s != nil {
+			base = time.Duration(s.ElapsedSeconds * float64(time.Second))
+		}
+	}
+	h.uploaded.Store(resumed)
+	h.parts.Store(0)
+	h.lastPart.Store(begin.UnixNano())
+
+	beat := func(now time.Time) {
+		elapsed := base + now.Sub(begin)
+		if sm != nil {
+			_ = sm.Checkpoint(elapsed)
+		}
+		if h.fn != nil {
+			h.fn(Heartbeat{
+				Key:      key,
+				Uploaded: h.uploaded.Load(),
+				Total:    total,
+				Parts:    h.parts.Load(),
+				Elapsed:  elapsed,
+				Idle:     now.Sub(time.Unix(0, h.lastPart.Load())),
+			})
+		}
+	}
+
+	ticker := time.NewTicker(h.interval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case now := <-ticker.C:
+				beat(now)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+		<-stopped
+		if sm != nil {
+			_ = sm.Checkpoint(base + time.Since(begin))
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestUploadHeartbeat 测试上传期间定期调用心跳回调，并将累计用时写入状态文件
+func TestUploadHeartbeat(t *testing.T) {
+	adapter := mock.New()
+	adapter.SetLatency(20*time.Millisecond, 20*time.Millisecond, 1)
+
+	dir := t.TempDir()
+	sm := state.NewStateManager(dir, "heartbeat")
+	if err := sm.Save(&state.UploadState{Key: "heartbeat", ElapsedSeconds: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var beats []Heartbeat
+	data := chaosData(1024, 4)
+	upl := NewUploader(adapter, 1024, 1)
+	upl.SetStateManager(sm)
+	upl.SetTotalBytes(int64(len(data)))
+	upl.SetHeartbeat(5*time.Millisecond, func(hb Heartbeat) {
+		mu.Lock()
+		defer mu.Unlock()
+		beats = append(beats, hb)
+	})
+	if err := upl.Upload(context.Background(), "heartbeat", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(beats) == 0 {
+		t.Fatal("expected heartbeats during upload")
+	}
+	last := beats[len(beats)-1]
+	if last.Key != "heartbeat" || last.Total != int64(len(data)) || last.Uploaded > last.Total {
+		t.Errorf("unexpected heartbeat: %+v", last)
+	}
+	if last.Elapsed < time.Minute {
+		t.Errorf("elapsed %s should include the 60s from the saved state", last.Elapsed)
+	}
+
+	saved, err := state.NewStateManager(dir, "heartbeat").Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.ElapsedSeconds <= 60 {
+		t.Errorf("elapsed_seconds = %v, want > 60", saved.ElapsedSeconds)
+	}
+	if saved.UploadedBytes != int64(len(data)) {
+		t.Errorf("uploaded_bytes = %d, want %d", saved.UploadedBytes, len(data))
+	}
+}
+
+// TestHeartbeatDisabled 测试未设置心跳时不写入检查点
+func TestHeartbeatDisabled(t *testing.T) {
+	var h heartbeat
+	stop := h.start("key", 0, 0, nil)
+	stop()
+}
@@ -312,10 +312,14 @@ func BenchmarkUpload(b *testing.B) {
 	testData := make([]byte, 20*1024*1024)
 	ctx := context.Background()
 
+	b.SetBytes(int64(len(testData)))
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		adapter.reset()
-		u.Upload(ctx, "test-key", bytes.NewReader(testData), storage.UploadOptions{})
+		if err := u.Upload(ctx, "test-key", bytes.NewReader(testData), storage.UploadOptions{}); err != nil {
+			b.Fatal(err)
+		}
 	}
 }
 
@@ -0,0 +1,35 @@
+package uploader
+
+import "time"
+
+// Bottleneck 流水线瓶颈
+type Bottleneck string
+
+const (
+	// BottleneckNone 数据源与上传速度基本匹配
+	BottleneckNone Bottleneck = ""
+	// BottleneckSource 上传器大部分时间在等待数据源（归档、压缩、加密），增加并发不会加快上传
+	BottleneckSource Bottleneck = "source"
+	// BottleneckNetwork 分块通道大部分时间已满，数据源在等待上传 worker
+	BottleneckNetwork Bottleneck = "network"
+)
+
+// stallRatio 等待时间占读取总时间的比例超过该值时认为对应的一端是瓶颈
+const stallRatio = 0.5
+
+// minStallElapsed 读取总时间短于该值时不判断瓶颈，小备份的等待时间主要是启动开销
+var minStallElapsed = 10 * time.Second
+
+// Bottleneck 根据读取分块时的等待时间判断流水线瓶颈
+func (s Stats) Bottleneck() Bottleneck {
+	if s.Elapsed < minStallElapsed {
+		return BottleneckNone
+	}
+	switch {
+	case s.ReadWait.Seconds() >= s.Elapsed.Seconds()*stallRatio:
+		return BottleneckSource
+	case s.SendWait.Seconds() >= s.Elapsed.Seconds()*stallRatio:
+		return BottleneckNetwork
+	}
+	return BottleneckNone
+}
@@ -0,0 +1,64 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestStatsBottleneck 测试根据等待时间判断瓶颈
+func TestStatsBottleneck(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats Stats
+		want  Bottleneck
+	}{
+		{"too short", Stats{Elapsed: time.Second, ReadWait: time.Second}, BottleneckNone},
+		{"source", Stats{Elapsed: time.Minute, ReadWait: 40 * time.Second, SendWait: time.Second}, BottleneckSource},
+		{"network", Stats{Elapsed: time.Minute, ReadWait: time.Second, SendWait: 50 * time.Second}, BottleneckNetwork},
+		{"balanced", Stats{Elapsed: time.Minute, ReadWait: 20 * time.Second, SendWait: 20 * time.Second}, BottleneckNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.Bottleneck(); got != tt.want {
+				t.Errorf("Bottleneck() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPipelineStats 测试上传器统计等待数据源和等待 worker 的时间
+func TestPipelineStats(t *testing.T) {
+	defer func(d time.Duration) { minStallElapsed = d }(minStallElapsed)
+	minStallElapsed = 0
+
+	data := chaosData(1024, 8)
+
+	t.Run("source", func(t *testing.T) {
+		upl := NewUploader(mock.New(), 1024, 2)
+		r := &slowReader{data: data, delay: 10 * time.Millisecond}
+		if err := upl.Upload(context.Background(), "source", r, storage.UploadOptions{}); err != nil {
+			t.Fatalf("Upload() error = %v", err)
+		}
+		if got := upl.Stats().Bottleneck(); got != BottleneckSource {
+			t.Errorf("Bottleneck() = %q, want %q (stats %+v)", got, BottleneckSource, upl.Stats())
+		}
+	})
+
+	t.Run("network", func(t *testing.T) {
+		adapter := mock.New()
+		adapter.SetLatency(20*time.Millisecond, 20*time.Millisecond, 1)
+		// 分块数超过通道容量 (concurrency*2) 与 worker 数之和，读取端会阻塞在发送上
+		upl := NewUploader(adapter, 1024, 1)
+		if err := upl.Upload(context.Background(), "network", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+			t.Fatalf("Upload() error = %v", err)
+		}
+		if got := upl.Stats().Bottleneck(); got != BottleneckNetwork {
+			t.Errorf("Bottleneck() = %q, want %q (stats %+v)", got, BottleneckNetwork, upl.Stats())
+		}
+	})
+}
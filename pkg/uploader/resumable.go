@@ -1,28 +1,35 @@
 package uploader
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/lukelzlz/s3backup/pkg/progress"
 	"github.com/lukelzlz/s3backup/pkg/state"
 	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // ResumableUploader 支持断点续传的上传器
 type ResumableUploader struct {
-	adapter     storage.StorageAdapter
+	partSender
 	chunkSize   int64
 	concurrency int
 	reporter    progress.Reporter
 	uploaded    atomic.Int64
 	savedState  *state.UploadState
 	stateMgr    *state.StateManager
+	reuploaded  []int
+	readAhead   int
+	hb          heartbeat
 }
 
 // NewResumableUploader 创建支持断点续传的上传器
@@ -34,8 +41,13 @@ func NewResumableUploader(adapter storage.StorageAdapter, chunkSize int64, concu
 		concurrency = 4
 	}
 
+	// 与 Uploader 一致，分块大小超出提供商限制时自动调整
+	if min, max, ok := storage.PartSizeLimits(adapter); ok {
+		chunkSize = clampSize(chunkSize, min, max)
+	}
+
 	return &ResumableUploader{
-		adapter:     adapter,
+		partSender:  partSender{adapter: adapter},
 		chunkSize:   chunkSize,
 		concurrency: concurrency,
 		reporter:    progress.NewSilent(),
@@ -48,11 +60,36 @@ func (u *ResumableUploader) SetProgressReporter(r progress.Reporter) {
 	u.reporter = r
 }
 
+// ChunkSize 返回实际使用的分块大小（可能已按提供商限制调整）
+func (u *ResumableUploader) ChunkSize() int64 {
+	return u.chunkSize
+}
+
 // SetStateManager 设置状态管理器
 func (u *ResumableUploader) SetStateManager(sm *state.StateManager) {
 	u.stateMgr = sm
 }
 
+// SetHeartbeat 设置心跳，见 Uploader.SetHeartbeat
+func (u *ResumableUploader) SetHeartbeat(interval time.Duration, fn func(Heartbeat)) {
+	u.hb.interval, u.hb.fn = interval, fn
+}
+
+// SetReadAhead 设置预读的分块数，见 Uploader.SetReadAhead
+func (u *ResumableUploader) SetReadAhead(n int) {
+	u.readAhead = n
+}
+
+// SetETagRetries 设置 ETag 与本地 MD5 不一致时重新上传的次数，见 Uploader.SetETagRetries
+func (u *ResumableUploader) SetETagRetries(n int) {
+	u.etagRetries = n
+}
+
+// Stats 返回续传期间的上传统计（分块数、限流和重试次数）
+func (u *ResumableUploader) Stats() Stats {
+	return u.stats()
+}
+
 // Upload 从 reader 读取数据并上传（支持断点续传）
 func (u *ResumableUploader) Upload(ctx context.Context, key string, r io.Reader, opts storage.UploadOptions) (err error) {
 	// 检查是否有已保存的状态
@@ -63,13 +100,50 @@ func (u *ResumableUploader) Upload(ctx context.Context, key string, r io.Reader,
 	// 新上传，使用普通上传器
 	upl := NewUploader(u.adapter, u.chunkSize, u.concurrency)
 	upl.SetProgressReporter(u.reporter)
+	upl.SetHeartbeat(u.hb.interval, u.hb.fn)
+	upl.SetETagRetries(u.etagRetries)
 	return upl.Upload(ctx, key, r, opts)
 }
 
 // Resume 从断点恢复上传
+// r 需要从头提供完整数据，已完成的分块不再上传。状态中记录了上传队列时按记录的分块边界
+// 只重建未完成的分块，r 实现 io.Seeker 时直接跳过已完成的分块而不读取数据
 func (u *ResumableUploader) Resume(ctx context.Context, key string, uploadID string, r io.Reader, opts storage.UploadOptions) (err error) {
+	return u.resume(ctx, key, uploadID, 0, opts, func(chunkChan chan<- *chunk, errorChan chan<- error, completed map[int]state.CompletedPart) {
+		if layout := u.queueLayout(); layout != nil {
+			u.readQueue(ctx, r, layout, completed, chunkChan, errorChan)
+			return
+		}
+		u.readChunks(ctx, r, completed, chunkChan, errorChan)
+	})
+}
+
+// ResumeAt 按偏移从 r 读取 size 字节并恢复上传，第 n 个分块对应偏移 (n-1)*chunkSize
+// 已完成的分块直接跳过，不读取数据；分块大小必须与上传开始时一致
+func (u *ResumableUploader) ResumeAt(ctx context.Context, key string, uploadID string, r io.ReaderAt, size int64, opts storage.UploadOptions) (err error) {
+	return u.resume(ctx, key, uploadID, size, opts, func(chunkChan chan<- *chunk, errorChan chan<- error, completed map[int]state.CompletedPart) {
+		u.readChunksAt(ctx, r, size, completed, chunkChan, errorChan)
+	})
+}
+
+// resume 上传 read 产生的分块并以 opts 完成上传，total 为总字节数（未知时为 0）
+func (u *ResumableUploader) resume(ctx context.Context, key string, uploadID string, total int64, opts storage.UploadOptions,
+	read func(chunkChan chan<- *chunk, errorChan chan<- error, completed map[int]state.CompletedPart)) (err error) {
+	ctx, span := tracing.Start(ctx, "upload",
+		attribute.String("s3backup.key", key),
+		attribute.Int64("s3backup.chunk_size", u.chunkSize),
+		attribute.Int("s3backup.concurrency", u.concurrency),
+		attribute.Bool("s3backup.resumed", true),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	// 数据流的总大小未知时使用上传开始时记录的大小
+	if total == 0 && u.savedState != nil {
+		total = u.savedState.TotalBytes
+	}
+
 	// 初始化进度报告
-	u.reporter.Init(0)
+	u.reporter.Init(total)
 
 	// 确保在出错时清理资源
 	defer func() {
@@ -78,18 +152,18 @@ func (u *ResumableUploader) Resume(ctx context.Context, key string, uploadID str
 		}
 	}()
 
-	// 获取已完成的分块
-	completedParts := make(map[int]state.CompletedPart)
-	if u.savedState != nil {
-		for _, p := range u.savedState.Completed {
-			completedParts[p.PartNumber] = p
-		}
-		// 更新进度
-		u.reporter.Add(u.savedState.UploadedBytes)
+	// 获取已完成的分块（已与服务端核对）
+	u.reporter.SetPhase("Verifying parts")
+	completedParts, resumedBytes, err := u.verifiedParts(ctx, key, uploadID)
+	if err != nil {
+		return err
 	}
+	u.reporter.SetPhase("Uploading")
+	u.reporter.Add(resumedBytes)
+	defer u.hb.start(key, total, resumedBytes, u.stateMgr)()
 
 	// 创建分块通道
-	chunkChan := make(chan *chunk, u.concurrency*2)
+	chunkChan := make(chan *chunk, readAheadDepth(u.readAhead, u.concurrency))
 	resultChan := make(chan *partResult, u.concurrency)
 	errorChan := make(chan error, 1)
 
@@ -100,25 +174,19 @@ func (u *ResumableUploader) Resume(ctx context.Context, key string, uploadID str
 	var wg sync.WaitGroup
 	for i := 0; i < u.concurrency; i++ {
 		wg.Add(1)
-		go u.worker(ctx, &wg, key, uploadID, chunkChan, resultChan, errorChan, completedParts)
+		go u.worker(ctx, i, &wg, key, uploadID, chunkChan, resultChan, errorChan, completedParts)
 	}
 
 	// 读取数据并发送分块
 	go func() {
-		u.readChunks(ctx, r, chunkChan, errorChan)
+		read(chunkChan, errorChan, completedParts)
 		close(readDone)
 	}()
 
-	// 收集结果
+	// 收集结果（已完成的分块由 worker 跳过上传后直接返回，不需要预先加入）
 	var parts []storage.CompletedPart
-
-	// 添加已完成的分块
-	for _, p := range completedParts {
-		parts = append(parts, storage.CompletedPart{
-			PartNumber: p.PartNumber,
-			ETag:       p.ETag,
-		})
-	}
+	var uploaded int64
+	sizeKnown := true
 
 	// 等待所有 worker 完成和结果收集
 	go func() {
@@ -136,12 +204,24 @@ func (u *ResumableUploader) Resume(ctx context.Context, key string, uploadID str
 		case result, ok := <-resultChan:
 			if !ok {
 				// resultChan 已关闭，所有 worker 完成
+				// worker 或读取出错时也会关闭 resultChan，需先检查是否有未处理的错误
+				select {
+				case uploadErr := <-errorChan:
+					err = uploadErr
+					return uploadErr
+				default:
+				}
 				goto complete
 			}
 			parts = append(parts, storage.CompletedPart{
 				PartNumber: result.partNumber,
 				ETag:       result.etag,
 			})
+			// 旧版本的状态文件可能没有记录分块大小，此时无法校验总大小
+			if result.size == 0 {
+				sizeKnown = false
+			}
+			uploaded += result.size
 
 		case uploadErr := <-errorChan:
 			// 有错误发生
@@ -154,11 +234,18 @@ func (u *ResumableUploader) Resume(ctx context.Context, key string, uploadID str
 	}
 
 complete:
+	// 实际上传的数据比开始时多或少，说明数据源已变化，不能与已上传的分块拼接
+	if sizeKnown {
+		if err = checkTotalBytes(total, uploaded); err != nil {
+			return err
+		}
+	}
+
 	// 按分块号排序
 	u.sortParts(parts)
 
 	// 完成上传
-	if completeErr := u.adapter.CompleteMultipartUpload(ctx, key, uploadID, parts); completeErr != nil {
+	if completeErr := u.adapter.CompleteMultipartUpload(ctx, key, uploadID, parts, opts); completeErr != nil {
 		err = fmt.Errorf("failed to complete multipart upload: %w", completeErr)
 		return err
 	}
@@ -169,8 +256,58 @@ complete:
 	return nil
 }
 
-// worker 处理分块上传（支持跳过已完成的分块）
-func (u *ResumableUploader) worker(ctx context.Context, wg *sync.WaitGroup, key, uploadID string,
+// Reuploaded 返回本地状态记录为已完成、但服务端缺失或不一致而重新上传的分块号
+func (u *ResumableUploader) Reuploaded() []int {
+	return u.reuploaded
+}
+
+// verifiedParts 返回可以跳过的已完成分块及其字节数
+// 先通过 ListParts 与服务端核对分块号、ETag 和大小：服务端缺失（如被生命周期规则清理）
+// 或不一致的分块不再跳过而是重新上传，否则完成上传时会引用不存在的分块或拼出损坏的对象
+func (u *ResumableUploader) verifiedParts(ctx context.Context, key, uploadID string) (map[int]state.CompletedPart, int64, error) {
+	completed := make(map[int]state.CompletedPart)
+	u.reuploaded = nil
+	if u.savedState == nil {
+		return completed, 0, nil
+	}
+
+	if len(u.savedState.Completed) == 0 {
+		return completed, 0, nil
+	}
+
+	parts, err := u.adapter.ListParts(ctx, key, uploadID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUploadNotFound) {
+			return nil, 0, fmt.Errorf("upload %s no longer exists on the server, start a new upload: %w", state.RedactID(uploadID), err)
+		}
+		return nil, 0, fmt.Errorf("failed to verify uploaded parts: %w", err)
+	}
+	server := make(map[int]storage.PartInfo, len(parts))
+	for _, p := range parts {
+		server[p.PartNumber] = p
+	}
+
+	uploadedBytes := u.savedState.UploadedBytes
+	for _, p := range u.savedState.Completed {
+		sp, ok := server[p.PartNumber]
+		if !ok || trimETag(sp.ETag) != trimETag(p.ETag) || (p.Size > 0 && sp.Size != p.Size) {
+			u.reuploaded = append(u.reuploaded, p.PartNumber)
+			uploadedBytes -= p.Size
+			continue
+		}
+		completed[p.PartNumber] = p
+	}
+	sort.Ints(u.reuploaded)
+	return completed, max(uploadedBytes, 0), nil
+}
+
+// trimETag 去掉 ETag 两端的引号，ListParts 与 UploadPart 返回的格式可能不同
+func trimETag(etag string) string {
+	return strings.Trim(etag, `"`)
+}
+
+// worker 处理分块上传（支持跳过已完成的分块），id 为 worker 编号
+func (u *ResumableUploader) worker(ctx context.Context, id int, wg *sync.WaitGroup, key, uploadID string,
 	chunkChan <-chan *chunk, resultChan chan<- *partResult, errorChan chan<- error,
 	completedParts map[int]state.CompletedPart) {
 
@@ -189,13 +326,14 @@ func (u *ResumableUploader) worker(ctx context.Context, wg *sync.WaitGroup, key,
 			resultChan <- &partResult{
 				partNumber: completed.PartNumber,
 				etag:       completed.ETag,
+				size:       completed.Size,
 			}
 			putBuffer(chunk.data)
 			continue
 		}
 
-		// 上传分块
-		etag, err := u.adapter.UploadPart(ctx, key, uploadID, chunk.partNumber, bytes.NewReader(chunk.data), chunk.size)
+		// 上传分块，与 Uploader 相同地处理限流、网络错误和 ETag 不一致
+		etag, err := u.uploadPart(ctx, id, key, uploadID, chunk)
 		if err != nil {
 			errorChan <- fmt.Errorf("failed to upload part %d: %w", chunk.partNumber, err)
 			return
@@ -203,10 +341,12 @@ func (u *ResumableUploader) worker(ctx context.Context, wg *sync.WaitGroup, key,
 
 		// 更新进度
 		u.reporter.Add(chunk.size)
+		u.hb.partDone(chunk.size)
 
 		resultChan <- &partResult{
 			partNumber: chunk.partNumber,
 			etag:       etag,
+			size:       chunk.size,
 		}
 
 		// 保存状态
@@ -215,6 +355,8 @@ func (u *ResumableUploader) worker(ctx context.Context, wg *sync.WaitGroup, key,
 				PartNumber: chunk.partNumber,
 				ETag:       etag,
 				Size:       chunk.size,
+				Offset:     chunk.offset,
+				Checksum:   state.PartChecksum(chunk.data),
 			})
 		}
 
@@ -223,12 +365,112 @@ func (u *ResumableUploader) worker(ctx context.Context, wg *sync.WaitGroup, key,
 	}
 }
 
-// readChunks 读取数据并发送分块
-func (u *ResumableUploader) readChunks(ctx context.Context, r io.Reader, chunkChan chan<- *chunk, errorChan chan<- error) {
+// readChunks 从头按分块大小读取数据并发送分块（状态中没有上传队列时使用）
+func (u *ResumableUploader) readChunks(ctx context.Context, r io.Reader, completed map[int]state.CompletedPart,
+	chunkChan chan<- *chunk, errorChan chan<- error) {
 	defer close(chunkChan)
+	u.readFrom(ctx, r, 1, 0, completed, chunkChan, errorChan)
+}
 
-	partNumber := 1
+// queueLayout 由已完成的分块和上传队列重建数据流前 ProducedBytes 字节的分块边界
+// 旧版本的状态文件没有上传队列，或记录不完整（如缺少分块大小）时返回 nil
+func (u *ResumableUploader) queueLayout() []state.PendingPart {
+	s := u.savedState
+	if s == nil || s.ProducedParts == 0 {
+		return nil
+	}
 
+	sizes := make(map[int]int64, s.ProducedParts)
+	for _, p := range s.Completed {
+		sizes[p.PartNumber] = p.Size
+	}
+	for _, p := range s.Pending {
+		sizes[p.PartNumber] = p.Size
+	}
+
+	layout := make([]state.PendingPart, 0, s.ProducedParts)
+	var off int64
+	for n := 1; n <= s.ProducedParts; n++ {
+		size := sizes[n]
+		if size <= 0 {
+			return nil
+		}
+		layout = append(layout, state.PendingPart{PartNumber: n, Offset: off, Size: size})
+		off += size
+	}
+	if off != s.ProducedBytes {
+		return nil
+	}
+	return layout
+}
+
+// readQueue 按 layout 读取数据流：已完成的分块跳过，未完成的分块按记录的边界重新读出，
+// 之后的数据按分块大小继续分块
+func (u *ResumableUploader) readQueue(ctx context.Context, r io.Reader, layout []state.PendingPart,
+	completed map[int]state.CompletedPart, chunkChan chan<- *chunk, errorChan chan<- error) {
+	defer close(chunkChan)
+
+	for _, p := range layout {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		if done, ok := completed[p.PartNumber]; ok {
+			if err := skipPart(r, done, p.Offset); err != nil {
+				errorChan <- err
+				return
+			}
+			chunkChan <- &chunk{partNumber: p.PartNumber, offset: p.Offset, size: p.Size}
+			continue
+		}
+
+		buf := getBuffer(p.Size)[:p.Size]
+		if _, err := io.ReadFull(r, buf); err != nil {
+			putBuffer(buf)
+			errorChan <- fmt.Errorf("failed to read part %d at offset %d: %w", p.PartNumber, p.Offset, err)
+			return
+		}
+		chunkChan <- &chunk{partNumber: p.PartNumber, offset: p.Offset, data: buf, size: p.Size}
+	}
+
+	last := layout[len(layout)-1]
+	u.readFrom(ctx, r, last.PartNumber+1, last.Offset+last.Size, completed, chunkChan, errorChan)
+}
+
+// skipPart 跳过已完成的分块 p
+// r 实现 io.Seeker 时直接定位；否则 r 是重新生成的数据流，读出后与记录的偏移、大小和校验值比对
+func skipPart(r io.Reader, p state.CompletedPart, offset int64) error {
+	if s, ok := r.(io.Seeker); ok {
+		if _, err := s.Seek(p.Size, io.SeekCurrent); err != nil {
+			return fmt.Errorf("failed to skip part %d at offset %d: %w", p.PartNumber, offset, err)
+		}
+		return nil
+	}
+
+	buf := getBuffer(p.Size)[:p.Size]
+	defer putBuffer(buf)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return fmt.Errorf("failed to read part %d at offset %d: %w", p.PartNumber, offset, err)
+	}
+	return checkPart(p, offset, buf)
+}
+
+// checkPart 校验重新生成的数据与已完成分块记录的偏移、大小和校验值是否一致，
+// 旧版本状态文件没有记录的项不校验
+func checkPart(p state.CompletedPart, offset int64, data []byte) error {
+	if (p.Offset > 0 && p.Offset != offset) || (p.Size > 0 && p.Size != int64(len(data))) ||
+		(p.Checksum != "" && p.Checksum != state.PartChecksum(data)) {
+		return fmt.Errorf("%w: part %d at offset %d", ErrStreamMismatch, p.PartNumber, offset)
+	}
+	return nil
+}
+
+// readFrom 从数据流偏移 offset 处按分块大小读取数据，分块号从 partNumber 开始
+// 未完成的分块先记入上传队列再交给 worker，再次中断时同样可以按记录的边界续传
+func (u *ResumableUploader) readFrom(ctx context.Context, r io.Reader, partNumber int, offset int64,
+	completed map[int]state.CompletedPart, chunkChan chan<- *chunk, errorChan chan<- error) {
 	for {
 		select {
 		case <-ctx.Done():
@@ -237,7 +479,7 @@ func (u *ResumableUploader) readChunks(ctx context.Context, r io.Reader, chunkCh
 		}
 
 		// 获取缓冲区
-		buf := getBuffer(u.chunkSize)
+		buf := getBuffer(u.chunkSize)[:u.chunkSize]
 
 		// 读取数据
 		n, err := io.ReadFull(r, buf)
@@ -252,17 +494,66 @@ func (u *ResumableUploader) readChunks(ctx context.Context, r io.Reader, chunkCh
 			return
 		}
 
+		if done, ok := completed[partNumber]; ok {
+			if err := checkPart(done, offset, buf[:n]); err != nil {
+				putBuffer(buf)
+				errorChan <- err
+				return
+			}
+		} else if u.stateMgr != nil {
+			u.stateMgr.AddPendingPart(state.PendingPart{PartNumber: partNumber, Offset: offset, Size: int64(n)})
+		}
+
 		// 发送分块
 		chunkChan <- &chunk{
 			partNumber: partNumber,
+			offset:     offset,
 			data:       buf[:n],
 			size:       int64(n),
 		}
 
+		offset += int64(n)
 		partNumber++
 	}
 }
 
+// readChunksAt 按偏移读取分块，已完成的分块只发送分块号
+func (u *ResumableUploader) readChunksAt(ctx context.Context, r io.ReaderAt, size int64,
+	completed map[int]state.CompletedPart, chunkChan chan<- *chunk, errorChan chan<- error) {
+	defer close(chunkChan)
+
+	for partNumber, off := 1, int64(0); off < size; partNumber, off = partNumber+1, off+u.chunkSize {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		n := min(u.chunkSize, size-off)
+		if _, ok := completed[partNumber]; ok {
+			chunkChan <- &chunk{partNumber: partNumber, offset: off, size: n}
+			continue
+		}
+
+		buf := getBuffer(u.chunkSize)[:n]
+		if _, err := r.ReadAt(buf, off); err != nil && !(err == io.EOF && off+n == size) {
+			putBuffer(buf)
+			errorChan <- fmt.Errorf("failed to read part %d at offset %d: %w", partNumber, off, err)
+			return
+		}
+		chunkChan <- &chunk{partNumber: partNumber, offset: off, data: buf, size: n}
+	}
+}
+
+// ChunkSizeFor 返回上传 size 字节时使用的分块大小
+// 在 chunkSize 基础上按需增大，保证分块数不超过 MaxParts
+func ChunkSizeFor(size, chunkSize int64) int64 {
+	if minSize := (size + MaxParts - 1) / MaxParts; chunkSize < minSize {
+		return minSize
+	}
+	return chunkSize
+}
+
 // sortParts 按分块号排序
 func (u *ResumableUploader) sortParts(parts []storage.CompletedPart) {
 	sort.Slice(parts, func(i, j int) bool {
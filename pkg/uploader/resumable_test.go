@@ -0,0 +1,342 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/progress"
+	"github.com/lukelzlz/s3backup/pkg/state"
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// recordingAdapter 记录 CompleteMultipartUpload 收到的分块列表
+type recordingAdapter struct {
+	mockAdapter
+	completed []storage.CompletedPart
+}
+
+func (a *recordingAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []storage.CompletedPart, opts storage.UploadOptions) error {
+	a.completed = append([]storage.CompletedPart(nil), parts...)
+	return a.mockAdapter.CompleteMultipartUpload(ctx, key, uploadID, parts, opts)
+}
+
+// TestResumeSkipsCompletedPartsWithoutDuplicates 测试恢复上传时已完成分块只出现一次
+func TestResumeSkipsCompletedPartsWithoutDuplicates(t *testing.T) {
+	adapter := &recordingAdapter{}
+	saved := &state.UploadState{
+		Key:      "resume",
+		UploadID: "mock-upload-id",
+		Completed: []state.CompletedPart{
+			{PartNumber: 1, ETag: "etag-saved", Size: 1024},
+		},
+	}
+	adapter.serverParts = []storage.PartInfo{{PartNumber: 1, ETag: "etag-saved", Size: 1024}}
+
+	upl := NewResumableUploader(adapter, 1024, 2, saved)
+	data := bytes.Repeat([]byte("r"), 3*1024)
+	if err := upl.Resume(context.Background(), "resume", saved.UploadID, bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	if got := adapter.uploadPartCalled.Load(); got != 2 {
+		t.Errorf("expected 2 parts uploaded, got %d", got)
+	}
+	if len(adapter.completed) != 3 {
+		t.Fatalf("expected 3 completed parts, got %d: %+v", len(adapter.completed), adapter.completed)
+	}
+	for i, p := range adapter.completed {
+		if p.PartNumber != i+1 {
+			t.Errorf("part %d has number %d", i, p.PartNumber)
+		}
+	}
+	if adapter.completed[0].ETag != "etag-saved" {
+		t.Errorf("part 1 should reuse saved ETag, got %q", adapter.completed[0].ETag)
+	}
+}
+
+// TestResumeReportsPhases 测试续传时进度使用记录的总大小，并报告核对分块和上传阶段
+func TestResumeReportsPhases(t *testing.T) {
+	adapter := &recordingAdapter{}
+	saved := &state.UploadState{
+		Key:           "resume",
+		UploadID:      "mock-upload-id",
+		TotalBytes:    3 * 1024,
+		UploadedBytes: 1024,
+		Completed:     []state.CompletedPart{{PartNumber: 1, ETag: "etag-saved", Size: 1024}},
+	}
+	adapter.serverParts = []storage.PartInfo{{PartNumber: 1, ETag: "etag-saved", Size: 1024}}
+
+	reporter := progress.NewMockReporter()
+	upl := NewResumableUploader(adapter, 1024, 1, saved)
+	upl.SetProgressReporter(reporter)
+	data := bytes.Repeat([]byte("r"), 3*1024)
+	if err := upl.Resume(context.Background(), "resume", saved.UploadID, bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	if got := reporter.Total.Load(); got != 3*1024 {
+		t.Errorf("reporter total = %d, want %d", got, 3*1024)
+	}
+	if got := reporter.AddTotal.Load(); got != 3*1024 {
+		t.Errorf("reported %d bytes, want %d", got, 3*1024)
+	}
+	phases := reporter.Phases()
+	if len(phases) != 2 || phases[0] != "Verifying parts" || phases[1] != "Uploading" {
+		t.Errorf("phases = %q", phases)
+	}
+}
+
+// TestResumeDetectsTruncation 测试续传的数据与记录的总大小不一致时拒绝完成上传
+func TestResumeDetectsTruncation(t *testing.T) {
+	adapter := &recordingAdapter{}
+	saved := &state.UploadState{
+		Key:        "resume",
+		UploadID:   "mock-upload-id",
+		TotalBytes: 3 * 1024,
+		Completed: []state.CompletedPart{
+			{PartNumber: 1, ETag: "etag-saved", Size: 1024},
+		},
+	}
+	adapter.serverParts = []storage.PartInfo{{PartNumber: 1, ETag: "etag-saved", Size: 1024}}
+
+	upl := NewResumableUploader(adapter, 1024, 2, saved)
+	data := bytes.Repeat([]byte("r"), 2*1024+10)
+	err := upl.Resume(context.Background(), "resume", saved.UploadID, bytes.NewReader(data), storage.UploadOptions{})
+	if !errors.Is(err, ErrSizeMismatch) {
+		t.Fatalf("expected ErrSizeMismatch, got %v", err)
+	}
+	if adapter.completed != nil {
+		t.Errorf("upload should not be completed, got parts %+v", adapter.completed)
+	}
+}
+
+// offsetRecorder 记录 ReadAt 读取过的偏移
+type offsetRecorder struct {
+	*bytes.Reader
+	offsets []int64
+}
+
+func (r *offsetRecorder) ReadAt(p []byte, off int64) (int, error) {
+	r.offsets = append(r.offsets, off)
+	return r.Reader.ReadAt(p, off)
+}
+
+// TestResumeAtSkipsCompletedPartsWithoutReading 测试按偏移恢复时不读取已完成的分块
+func TestResumeAtSkipsCompletedPartsWithoutReading(t *testing.T) {
+	adapter := &recordingAdapter{}
+	saved := &state.UploadState{
+		Key:      "resume-at",
+		UploadID: "mock-upload-id",
+		Completed: []state.CompletedPart{
+			{PartNumber: 2, ETag: "etag-2", Size: 1024},
+		},
+	}
+	adapter.serverParts = []storage.PartInfo{{PartNumber: 2, ETag: "etag-2", Size: 1024}}
+
+	data := bytes.Repeat([]byte("f"), 3*1024+100)
+	r := &offsetRecorder{Reader: bytes.NewReader(data)}
+	upl := NewResumableUploader(adapter, 1024, 1, saved)
+	if err := upl.ResumeAt(context.Background(), "resume-at", saved.UploadID, r, int64(len(data)), storage.UploadOptions{}); err != nil {
+		t.Fatalf("ResumeAt() error = %v", err)
+	}
+
+	for _, off := range r.offsets {
+		if off == 1024 {
+			t.Error("completed part 2 should not be read")
+		}
+	}
+	if len(r.offsets) != 3 {
+		t.Errorf("expected 3 reads, got offsets %v", r.offsets)
+	}
+	if len(adapter.completed) != 4 || adapter.completed[1].ETag != "etag-2" {
+		t.Errorf("unexpected completed parts: %+v", adapter.completed)
+	}
+}
+
+// TestChunkSizeFor 测试分块大小保证分块数不超过上限
+func TestChunkSizeFor(t *testing.T) {
+	if got := ChunkSizeFor(100, 10); got != 10 {
+		t.Errorf("ChunkSizeFor(100, 10) = %d, want 10", got)
+	}
+	size := int64(MaxParts)*10 + 1
+	if got := ChunkSizeFor(size, 10); got != 11 {
+		t.Errorf("ChunkSizeFor(%d, 10) = %d, want 11", size, got)
+	}
+}
+
+// readCounter 统计通过 Read 读取的字节数
+type readCounter struct {
+	io.Reader
+	n int64
+}
+
+func (r *readCounter) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// seekCounter 同时实现 io.Seeker 的 readCounter
+type seekCounter struct {
+	*readCounter
+	io.Seeker
+}
+
+// TestResumeFromQueue 测试按上传队列续传：分块大小与上传开始时不同也能按记录的边界
+// 只重新上传未完成的分块，数据源可定位时跳过已完成的分块而不读取
+func TestResumeFromQueue(t *testing.T) {
+	data := chaosData(1000, 7)
+	for _, seekable := range []bool{false, true} {
+		ctx := context.Background()
+		adapter := mock.New()
+		uploadID, err := adapter.InitMultipartUpload(ctx, "queue", storage.UploadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 中断前：分块 1、3 已完成，分块 2 已读出但未上传完成，分块大小各不相同
+		layout := []state.PendingPart{
+			{PartNumber: 1, Offset: 0, Size: 1000},
+			{PartNumber: 2, Offset: 1000, Size: 2000},
+			{PartNumber: 3, Offset: 3000, Size: 1500},
+		}
+		saved := &state.UploadState{Key: "queue", UploadID: uploadID, TotalBytes: int64(len(data)),
+			ProducedParts: 3, ProducedBytes: 4500, Pending: layout[1:2]}
+		for _, p := range []state.PendingPart{layout[0], layout[2]} {
+			part := data[p.Offset : p.Offset+p.Size]
+			etag, err := adapter.UploadPart(ctx, "queue", uploadID, p.PartNumber, bytes.NewReader(part), p.Size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			saved.Completed = append(saved.Completed, state.CompletedPart{PartNumber: p.PartNumber, ETag: etag, Size: p.Size})
+			saved.UploadedBytes += p.Size
+		}
+		sm := state.NewStateManager(t.TempDir(), "queue")
+		if err := sm.Save(saved); err != nil {
+			t.Fatal(err)
+		}
+
+		counter := &readCounter{Reader: bytes.NewReader(data)}
+		var r io.Reader = counter
+		if seekable {
+			r = seekCounter{counter, counter.Reader.(io.Seeker)}
+		}
+		upl := NewResumableUploader(adapter, 1024, 2, saved)
+		upl.SetStateManager(sm)
+		if err := upl.Resume(ctx, "queue", uploadID, r, storage.UploadOptions{}); err != nil {
+			t.Fatalf("Resume(seekable=%v) error = %v", seekable, err)
+		}
+
+		obj, ok := adapter.Object("queue")
+		if !ok || !bytes.Equal(obj.Data, data) {
+			t.Fatalf("seekable=%v: uploaded object does not match source", seekable)
+		}
+		// 中断前的 2 次，续传时的分块 2 以及 4500 之后的 2000 字节（1024 + 976）
+		if got := adapter.Calls(mock.OpUploadPart); got != 2+3 {
+			t.Errorf("seekable=%v: %d UploadPart calls, want 5", seekable, got)
+		}
+		if want := int64(len(data)) - 2500; seekable && counter.n != want {
+			t.Errorf("read %d bytes, want %d (completed parts skipped)", counter.n, want)
+		}
+		if s := sm.GetState(); len(s.Pending) != 0 || s.ProducedParts != 5 || s.ProducedBytes != int64(len(data)) {
+			t.Errorf("seekable=%v: unexpected queue after resume: %+v", seekable, s)
+		}
+	}
+}
+
+// TestUploadRecordsQueue 测试上传时记录读出的分块，全部完成后上传队列为空
+func TestUploadRecordsQueue(t *testing.T) {
+	data := chaosData(1024, 4)
+	sm := state.NewStateManager(t.TempDir(), "queue")
+	upl := NewUploader(mock.New(), 1024, 2)
+	upl.SetStateManager(sm)
+	if err := upl.Upload(context.Background(), "queue", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	s := sm.GetState()
+	if len(s.Pending) != 0 || s.ProducedParts != 4 || s.ProducedBytes != int64(len(data)) {
+		t.Errorf("unexpected queue: pending %+v, %d parts, %d bytes", s.Pending, s.ProducedParts, s.ProducedBytes)
+	}
+	for _, p := range s.Completed {
+		off := int64(p.PartNumber-1) * 1024
+		if p.Offset != off || p.Checksum != state.PartChecksum(data[off:off+p.Size]) {
+			t.Errorf("part %d: offset %d checksum %s, want offset %d", p.PartNumber, p.Offset, p.Checksum, off)
+		}
+	}
+}
+
+// TestResumeDetectsStreamMismatch 测试重新生成的数据流与已上传分块的校验值不一致时拒绝续传
+func TestResumeDetectsStreamMismatch(t *testing.T) {
+	data := chaosData(1024, 4)
+	for _, queued := range []bool{false, true} {
+		ctx := context.Background()
+		adapter := mock.New()
+		uploadID, err := adapter.InitMultipartUpload(ctx, "mismatch", storage.UploadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved := &state.UploadState{Key: "mismatch", UploadID: uploadID}
+		for n := 1; n <= 2; n++ {
+			part := data[(n-1)*1024 : n*1024]
+			etag, err := adapter.UploadPart(ctx, "mismatch", uploadID, n, bytes.NewReader(part), 1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			saved.Completed = append(saved.Completed, state.CompletedPart{PartNumber: n, ETag: etag, Size: 1024,
+				Offset: int64(n-1) * 1024, Checksum: state.PartChecksum(part)})
+		}
+		if queued {
+			saved.ProducedParts, saved.ProducedBytes = 2, 2048
+		}
+
+		// 第 2 个分块的内容发生变化，大小不变
+		changed := append([]byte(nil), data...)
+		changed[1500] ^= 0xff
+		upl := NewResumableUploader(adapter, 1024, 1, saved)
+		err = upl.Resume(ctx, "mismatch", uploadID, &readCounter{Reader: bytes.NewReader(changed)}, storage.UploadOptions{})
+		if !errors.Is(err, ErrStreamMismatch) {
+			t.Errorf("queued=%v: expected ErrStreamMismatch, got %v", queued, err)
+		}
+		if _, ok := adapter.Object("mismatch"); ok {
+			t.Errorf("queued=%v: upload should not be completed", queued)
+		}
+	}
+}
+
+// TestResumeRetriesThrottledAndCorruptedParts 测试续传与 Uploader 相同地重试限流和 ETag 不一致的分块，并计入统计
+func TestResumeRetriesThrottledAndCorruptedParts(t *testing.T) {
+	ctx := context.Background()
+	data := chaosData(1024, 4)
+	adapter := mock.New()
+	uploadID, err := adapter.InitMultipartUpload(ctx, "retry", storage.UploadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := adapter.UploadPart(ctx, "retry", uploadID, 1, bytes.NewReader(data[:1024]), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := &state.UploadState{Key: "retry", UploadID: uploadID,
+		Completed: []state.CompletedPart{{PartNumber: 1, ETag: etag, Size: 1024}}}
+
+	adapter.ThrottlePart(2, 2, 0)
+	adapter.CorruptPart(3, 1)
+	upl := NewResumableUploader(adapter, 1024, 2, saved)
+	if err := upl.Resume(ctx, "retry", uploadID, bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	obj, ok := adapter.Object("retry")
+	if !ok || !bytes.Equal(obj.Data, data) {
+		t.Fatal("resumed object does not match source data")
+	}
+	stats := upl.Stats()
+	if stats.Parts != 3 || stats.Throttled != 2 || stats.ETagMismatches != 1 || stats.Retries != 3 {
+		t.Errorf("stats = %+v, want 3 parts, 2 throttled, 1 ETag mismatch, 3 retries", stats)
+	}
+}
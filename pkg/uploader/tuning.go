@@ -0,0 +1,125 @@
+package uploader
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// targetPartDuration 自动调优时单个分块的目标上传耗时
+	// 分块越大请求开销越小，但失败重试的代价越高；以耗时为目标可同时兼顾慢速和高速链路
+	targetPartDuration = 10 * time.Second
+
+	// tuningAlignment 调优后的分块大小按 1MB 对齐
+	tuningAlignment = 1024 * 1024
+
+	// throughputSmoothing 吞吐量指数移动平均的平滑系数
+	throughputSmoothing = 0.3
+)
+
+// PartSizeTuner 根据观测到的上传吞吐量动态调整分块大小
+// 慢速链路使用小分块以降低重试代价，高速链路使用大分块以减少请求次数
+type PartSizeTuner struct {
+	mu         sync.Mutex
+	min        int64
+	max        int64
+	limit      int64 // 提供商的分块大小上限，未知时为 0，见 PartSize
+	current    int64
+	throughput float64 // 字节/秒，指数移动平均
+}
+
+// NewPartSizeTuner 创建分块大小调优器，initial 会被限制在 [min, max] 范围内
+func NewPartSizeTuner(initial, min, max int64) *PartSizeTuner {
+	if max < min {
+		max = min
+	}
+
+	return &PartSizeTuner{
+		min:     min,
+		max:     max,
+		current: clampSize(initial, min, max),
+	}
+}
+
+// restrict 将调优范围收窄到 [min, max] 内（用于满足提供商的分块大小限制）
+func (t *PartSizeTuner) restrict(min, max int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.min = clampSize(t.min, min, max)
+	t.max = clampSize(t.max, t.min, max)
+	t.current = clampSize(t.current, t.min, t.max)
+	t.limit = max
+}
+
+// Observe 记录一次分块上传的大小和耗时（包含请求延迟）
+func (t *PartSizeTuner) Observe(size int64, elapsed time.Duration) {
+	if size <= 0 || elapsed <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sample := float64(size) / elapsed.Seconds()
+	if t.throughput == 0 {
+		t.throughput = sample
+	} else {
+		t.throughput = throughputSmoothing*sample + (1-throughputSmoothing)*t.throughput
+	}
+
+	// 每次最多翻倍或减半，避免单个异常样本造成剧烈波动
+	target := int64(t.throughput * targetPartDuration.Seconds())
+	target = clampSize(target, t.current/2, t.current*2)
+	target = target / tuningAlignment * tuningAlignment
+	t.current = clampSize(target, t.min, t.max)
+}
+
+// Next 返回下一个分块应使用的大小
+func (t *PartSizeTuner) Next() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current
+}
+
+// PartSize 返回第 partNumber 个分块应使用的大小，produced 为之前的分块已读出的字节数
+// 数据流大小未知时，一直使用较小的分块会在超过 MaxParts 个分块后失败：分块大小至少为
+// produced 按剩余分块数平均的大小（假设剩余数据不少于已读出的数据），后半段随分块号增长，
+// 必要时超过调优上限，但不超过提供商的分块大小上限
+func (t *PartSizeTuner) PartSize(partNumber int, produced int64) int64 {
+	size := t.Next()
+	remaining := int64(MaxParts - partNumber + 1)
+	if remaining <= 0 {
+		return size
+	}
+	floor := (produced + remaining - 1) / remaining
+	floor = (floor + tuningAlignment - 1) / tuningAlignment * tuningAlignment
+	if floor <= size {
+		return size
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limit > 0 && floor > t.limit {
+		return t.limit
+	}
+	return floor
+}
+
+// Throughput 返回当前估计的吞吐量（字节/秒），尚无观测时为 0
+func (t *PartSizeTuner) Throughput() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.throughput
+}
+
+// clampSize 将 size 限制在 [min, max] 范围内
+func clampSize(size, min, max int64) int64 {
+	if size < min {
+		return min
+	}
+	if size > max {
+		return max
+	}
+	return size
+}
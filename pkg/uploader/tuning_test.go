@@ -0,0 +1,194 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lukelzlz/s3backup/pkg/storage"
+)
+
+const mb = 1024 * 1024
+
+// TestPartSizeTunerInitialClamp 测试初始分块大小被限制在范围内
+func TestPartSizeTunerInitialClamp(t *testing.T) {
+	if got := NewPartSizeTuner(1*mb, 5*mb, 64*mb).Next(); got != 5*mb {
+		t.Errorf("initial below min: got %d, want %d", got, 5*mb)
+	}
+	if got := NewPartSizeTuner(128*mb, 5*mb, 64*mb).Next(); got != 64*mb {
+		t.Errorf("initial above max: got %d, want %d", got, 64*mb)
+	}
+}
+
+// TestPartSizeTunerFastLink 测试高速链路逐步增大分块
+func TestPartSizeTunerFastLink(t *testing.T) {
+	tuner := NewPartSizeTuner(5*mb, 5*mb, 64*mb)
+
+	// 5MB 用时 0.1s，约 50MB/s
+	tuner.Observe(5*mb, 100*time.Millisecond)
+	if got := tuner.Next(); got != 10*mb {
+		t.Errorf("after first sample: got %d MB, want 10 MB (at most doubled)", got/mb)
+	}
+
+	for i := 0; i < 10; i++ {
+		tuner.Observe(tuner.Next(), time.Duration(float64(tuner.Next())/(50*mb)*float64(time.Second)))
+	}
+	if got := tuner.Next(); got != 64*mb {
+		t.Errorf("fast link should reach max: got %d MB", got/mb)
+	}
+}
+
+// TestPartSizeTunerSlowLink 测试慢速链路逐步减小分块
+func TestPartSizeTunerSlowLink(t *testing.T) {
+	tuner := NewPartSizeTuner(64*mb, 5*mb, 64*mb)
+
+	// 约 100KB/s
+	for i := 0; i < 10; i++ {
+		size := tuner.Next()
+		tuner.Observe(size, time.Duration(float64(size)/(100*1024)*float64(time.Second)))
+	}
+	if got := tuner.Next(); got != 5*mb {
+		t.Errorf("slow link should reach min: got %d MB", got/mb)
+	}
+}
+
+// TestPartSizeTunerAlignment 测试调优结果按 1MB 对齐
+func TestPartSizeTunerAlignment(t *testing.T) {
+	tuner := NewPartSizeTuner(8*mb, 5*mb, 64*mb)
+
+	// 约 1.25MB/s，目标约 12.5MB
+	tuner.Observe(8*mb, time.Duration(float64(8*mb)/(1.25*mb)*float64(time.Second)))
+	if got := tuner.Next(); got%mb != 0 {
+		t.Errorf("part size %d is not aligned to 1MB", got)
+	}
+}
+
+// TestPartSizeTunerIgnoresInvalidSamples 测试忽略无效样本
+func TestPartSizeTunerIgnoresInvalidSamples(t *testing.T) {
+	tuner := NewPartSizeTuner(8*mb, 5*mb, 64*mb)
+	tuner.Observe(0, time.Second)
+	tuner.Observe(8*mb, 0)
+
+	if tuner.Throughput() != 0 {
+		t.Errorf("throughput should remain 0, got %f", tuner.Throughput())
+	}
+	if got := tuner.Next(); got != 8*mb {
+		t.Errorf("part size should be unchanged, got %d", got)
+	}
+}
+
+// TestPartSizeTunerStaysWithinMaxParts 测试慢速链路上分块降到下限后，未知大小的数据流超过
+// MaxParts × 下限时分块大小随分块号增长，不超过 MaxParts 个分块
+func TestPartSizeTunerStaysWithinMaxParts(t *testing.T) {
+	tuner := NewPartSizeTuner(5*mb, 5*mb, 64*mb)
+	tuner.Observe(5*mb, time.Minute)
+
+	const total = 3 * MaxParts * 5 * mb // 150000 MB
+	var produced int64
+	parts := 0
+	for produced < total {
+		parts++
+		size := tuner.PartSize(parts, produced)
+		if size < 5*mb {
+			t.Fatalf("part %d: size %d below min", parts, size)
+		}
+		produced += size
+	}
+	if parts > MaxParts {
+		t.Errorf("stream of %d MB used %d parts, more than %d", total/mb, parts, MaxParts)
+	}
+	// 数据流不大时保持调优结果
+	if got := tuner.PartSize(100, 100*5*mb); got != 5*mb {
+		t.Errorf("early part size = %d MB, want 5 MB", got/mb)
+	}
+}
+
+// TestPartSizeTunerFloorRespectsProviderLimit 测试按分块数增大的分块不超过提供商的分块大小上限
+func TestPartSizeTunerFloorRespectsProviderLimit(t *testing.T) {
+	upl := NewUploader(&limitedAdapter{}, 8*mb, 1)
+	tuner := NewPartSizeTuner(5*mb, 5*mb, 16*mb)
+	upl.SetPartSizeTuner(tuner)
+
+	if got := tuner.PartSize(MaxParts-1, int64(MaxParts)*16*mb); got != 16*mb {
+		t.Errorf("part size = %d MB, want provider max 16 MB", got/mb)
+	}
+}
+
+// TestUploadWithPartSizeTuner 测试启用调优器后上传数据完整
+func TestUploadWithPartSizeTuner(t *testing.T) {
+	adapter := &mockAdapter{}
+	upl := NewUploader(adapter, 5*mb, 2)
+	upl.SetPartSizeTuner(NewPartSizeTuner(5*mb, 5*mb, 16*mb))
+
+	data := bytes.Repeat([]byte("x"), 23*mb)
+	if err := upl.Upload(context.Background(), "tuned", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	if adapter.completeCalled.Load() != 1 {
+		t.Errorf("expected complete to be called once")
+	}
+
+	var total int64
+	adapter.mu.Lock()
+	for _, p := range adapter.uploadedParts {
+		var n int64
+		_, _ = fmt.Sscanf(p.ETag, "etag-%d", &n)
+		total += n
+	}
+	adapter.mu.Unlock()
+	if total != int64(len(data)) {
+		t.Errorf("uploaded %d bytes, want %d", total, len(data))
+	}
+}
+
+// limitedAdapter 声明分块大小限制的模拟适配器
+type limitedAdapter struct {
+	mockAdapter
+}
+
+func (a *limitedAdapter) PartSizeLimits() (min, max int64) {
+	return 5 * mb, 16 * mb
+}
+
+// TestNewUploaderEnforcesPartSizeLimits 测试分块大小按提供商限制自动调整
+func TestNewUploaderEnforcesPartSizeLimits(t *testing.T) {
+	adapter := &limitedAdapter{}
+
+	if got := NewUploader(adapter, 1*mb, 1).ChunkSize(); got != 5*mb {
+		t.Errorf("chunk size below min: got %d, want %d", got, 5*mb)
+	}
+	if got := NewUploader(adapter, 64*mb, 1).ChunkSize(); got != 16*mb {
+		t.Errorf("chunk size above max: got %d, want %d", got, 16*mb)
+	}
+	if got := NewUploader(adapter, 8*mb, 1).ChunkSize(); got != 8*mb {
+		t.Errorf("chunk size within limits: got %d, want %d", got, 8*mb)
+	}
+
+	// 未声明限制的适配器不做调整
+	if got := NewUploader(&mockAdapter{}, 1024, 1).ChunkSize(); got != 1024 {
+		t.Errorf("chunk size without limits: got %d, want 1024", got)
+	}
+}
+
+// TestSetPartSizeTunerRestrictsBounds 测试调优范围被收窄到提供商限制内
+func TestSetPartSizeTunerRestrictsBounds(t *testing.T) {
+	upl := NewUploader(&limitedAdapter{}, 8*mb, 1)
+	tuner := NewPartSizeTuner(64*mb, 1*mb, 128*mb)
+	upl.SetPartSizeTuner(tuner)
+
+	if got := tuner.Next(); got != 16*mb {
+		t.Errorf("tuner current = %d MB, want 16 MB", got/mb)
+	}
+
+	// 慢速链路最多降到提供商最小值
+	for i := 0; i < 10; i++ {
+		size := tuner.Next()
+		tuner.Observe(size, time.Duration(float64(size)/(10*1024)*float64(time.Second)))
+	}
+	if got := tuner.Next(); got != 5*mb {
+		t.Errorf("tuner min = %d MB, want 5 MB", got/mb)
+	}
+}
@@ -3,25 +3,123 @@ package uploader
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/lukelzlz/s3backup/pkg/progress"
 	"github.com/lukelzlz/s3backup/pkg/state"
 	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// maxPartRetries 单个分块因限流或网络错误重试的最大次数
+const maxPartRetries = 5
+
+// defaultETagRetries 分块的 ETag 与本地 MD5 不一致时重新上传的默认次数
+const defaultETagRetries = 3
+
+// MaxParts S3 Multipart Upload 允许的最大分块数
+const MaxParts = 10000
+
+// networkRetryDelay 网络错误重试的初始等待时间，每次重试翻倍
+var networkRetryDelay = 1 * time.Second
+
+// ErrSizeMismatch 上传的总字节数与预期大小不一致（数据源被截断或发生变化）
+var ErrSizeMismatch = errors.New("uploaded size does not match expected size")
+
+// ErrETagMismatch 服务端返回的分块 ETag 与本地计算的 MD5 不一致，分块在传输中被损坏
+var ErrETagMismatch = errors.New("part ETag does not match local MD5")
+
+// ErrStreamMismatch 续传时重新生成的数据流与已上传的分块不一致（数据源发生变化或归档不是确定性的）
+var ErrStreamMismatch = errors.New("re-generated stream does not match uploaded part")
+
+// partFailure 分块上传失败（重试用尽后），与数据源的读取错误区分
+type partFailure struct {
+	partNumber int
+	err        error
+}
+
+func (e *partFailure) Error() string {
+	return fmt.Sprintf("failed to upload part %d: %v", e.partNumber, e.err)
+}
+
+func (e *partFailure) Unwrap() error { return e.err }
+
+// Stats 上传统计
+type Stats struct {
+	Parts     int64 // 成功上传的分块数
+	Throttled int64 // 遇到限流的次数
+	Retries   int64 // 分块重试次数
+
+	ETagMismatches int64 // 分块 ETag 与本地 MD5 不一致而重新上传的次数
+
+	// 流水线等待时间，用于判断瓶颈（见 Stats.Bottleneck）
+	Elapsed  time.Duration // 读取全部分块的总用时
+	ReadWait time.Duration // 等待数据源的时间，占比高说明上传器处于饥饿状态
+	SendWait time.Duration // 分块通道已满、等待 worker 的时间，占比高说明网络是瓶颈
+}
+
 // Uploader 上传管理器
 type Uploader struct {
-	adapter     storage.StorageAdapter
+	partSender
 	chunkSize   int64
 	concurrency int
 	reporter    progress.Reporter
 	uploaded    atomic.Int64
 	stateMgr    *state.StateManager
+	totalBytes  int64
+	readAhead   int
+	hb          heartbeat
+
+	elapsed  atomic.Int64 // 以下为纳秒
+	readWait atomic.Int64
+	sendWait atomic.Int64
+}
+
+// partSender 上传单个分块并处理重试（见 uploadPart），Uploader 和 ResumableUploader 共用
+type partSender struct {
+	adapter     storage.StorageAdapter
+	etagRetries int
+	observer    progress.PartObserver // reporter 实现 PartObserver 时报告每个 worker 的分块
+	tuner       *PartSizeTuner
+	controller  *ConcurrencyController
+
+	// 全局退避：任一 worker 遇到限流时，所有 worker 暂停到该时间点
+	pauseMu     sync.Mutex
+	pausedUntil time.Time
+
+	parts     atomic.Int64
+	throttled atomic.Int64
+	retries   atomic.Int64
+	etagFails atomic.Int64
+}
+
+// stats 返回分块上传和重试的统计
+func (s *partSender) stats() Stats {
+	return Stats{
+		Parts:          s.parts.Load(),
+		Throttled:      s.throttled.Load(),
+		Retries:        s.retries.Load(),
+		ETagMismatches: s.etagFails.Load(),
+	}
+}
+
+// readAheadDepth 返回分块通道的容量，readAhead 为 0 时为 2 × concurrency
+func readAheadDepth(readAhead, concurrency int) int {
+	if readAhead > 0 {
+		return readAhead
+	}
+	return concurrency * 2
 }
 
 // NewUploader 创建上传管理器
@@ -33,8 +131,13 @@ func NewUploader(adapter storage.StorageAdapter, chunkSize int64, concurrency in
 		concurrency = 4 // 默认并发数
 	}
 
+	// 分块大小超出提供商限制时自动调整，否则要到 CompleteMultipartUpload 才会失败
+	if min, max, ok := storage.PartSizeLimits(adapter); ok {
+		chunkSize = clampSize(chunkSize, min, max)
+	}
+
 	return &Uploader{
-		adapter:     adapter,
+		partSender:  partSender{adapter: adapter},
 		chunkSize:   chunkSize,
 		concurrency: concurrency,
 		reporter:    progress.NewSilent(),
@@ -44,6 +147,7 @@ func NewUploader(adapter storage.StorageAdapter, chunkSize int64, concurrency in
 // SetProgressReporter 设置进度报告器
 func (u *Uploader) SetProgressReporter(r progress.Reporter) {
 	u.reporter = r
+	u.observer, _ = r.(progress.PartObserver)
 }
 
 // SetStateManager 设置状态管理器
@@ -51,10 +155,75 @@ func (u *Uploader) SetStateManager(sm *state.StateManager) {
 	u.stateMgr = sm
 }
 
+// SetPartSizeTuner 设置分块大小调优器，设置后分块大小根据吞吐量动态调整
+func (u *Uploader) SetPartSizeTuner(t *PartSizeTuner) {
+	if min, max, ok := storage.PartSizeLimits(u.adapter); ok {
+		t.restrict(min, max)
+	}
+	u.tuner = t
+}
+
+// ChunkSize 返回实际使用的分块大小（可能已按提供商限制调整）
+func (u *Uploader) ChunkSize() int64 {
+	return u.chunkSize
+}
+
+// SetTotalBytes 设置预期上传的总字节数（已知时），用于初始化进度条、写入状态文件，
+// 并在完成上传前校验实际上传的字节数
+func (u *Uploader) SetTotalBytes(n int64) {
+	u.totalBytes = n
+}
+
+// SetConcurrencyController 设置并发控制器，设置后并发数在控制器范围内自动调整
+func (u *Uploader) SetConcurrencyController(c *ConcurrencyController) {
+	u.controller = c
+}
+
+// SetHeartbeat 设置心跳：上传期间每隔 interval 将累计用时写入状态文件并调用 fn（可为 nil），
+// 便于外部监控发现挂起的上传；interval 为 0 时不启用
+func (u *Uploader) SetHeartbeat(interval time.Duration, fn func(Heartbeat)) {
+	u.hb.interval, u.hb.fn = interval, fn
+}
+
+// SetReadAhead 设置已读入内存、等待上传的分块数上限，n 为 0 时为 2 × 并发数
+// 内存占用约为 (并发数 + n) × 分块大小
+func (u *Uploader) SetReadAhead(n int) {
+	u.readAhead = n
+}
+
+// SetETagRetries 设置分块 ETag 与本地 MD5 不一致时重新上传的次数，n 为 0 时为 3，负数表示不校验
+// 启用 SSE-KMS 等服务端加密时 ETag 不是分块的 MD5，需要关闭校验
+func (u *Uploader) SetETagRetries(n int) {
+	u.etagRetries = n
+}
+
+// Stats 返回上传统计
+func (u *Uploader) Stats() Stats {
+	stats := u.stats()
+	stats.Elapsed = time.Duration(u.elapsed.Load())
+	stats.ReadWait = time.Duration(u.readWait.Load())
+	stats.SendWait = time.Duration(u.sendWait.Load())
+	return stats
+}
+
 // Upload 从 reader 读取数据并上传
 func (u *Uploader) Upload(ctx context.Context, key string, r io.Reader, opts storage.UploadOptions) (err error) {
+	ctx, span := tracing.Start(ctx, "upload",
+		attribute.String("s3backup.key", key),
+		attribute.Int64("s3backup.chunk_size", u.chunkSize),
+		attribute.Int("s3backup.concurrency", u.concurrency),
+	)
+	defer func() {
+		span.SetAttributes(
+			attribute.Int64("s3backup.parts", u.parts.Load()),
+			attribute.Int64("s3backup.retries", u.retries.Load()),
+			attribute.Int64("s3backup.throttled", u.throttled.Load()),
+		)
+		tracing.End(span, err)
+	}()
+
 	// 初始化进度报告
-	u.reporter.Init(0)
+	u.reporter.Init(u.totalBytes)
 
 	// 确保在出错时清理资源（包括进度报告器）
 	defer func() {
@@ -69,28 +238,46 @@ func (u *Uploader) Upload(ctx context.Context, key string, r io.Reader, opts sto
 		return fmt.Errorf("failed to init multipart upload: %w", initErr)
 	}
 
-	// 保存 UploadID 到状态文件
+	// 保存 UploadID 到状态文件，保留调用者预先保存的存储桶、加密等信息
 	if u.stateMgr != nil {
-		initialState := &state.UploadState{
-			Key:          key,
-			UploadID:     uploadID,
-			StorageClass: string(opts.StorageClass),
-			Encrypted:    false, // 由调用者设置
-			Completed:    []state.CompletedPart{},
+		initialState := u.stateMgr.GetState()
+		if initialState == nil {
+			initialState = &state.UploadState{
+				Key:          key,
+				StorageClass: string(opts.StorageClass),
+				Encrypted:    false, // 由调用者设置
+				Completed:    []state.CompletedPart{},
+				NoOverwrite:  opts.NoOverwrite,
+				Metadata:     opts.Metadata,
+			}
+		}
+		if u.totalBytes > 0 {
+			initialState.TotalBytes = u.totalBytes
 		}
-		u.stateMgr.Save(initialState)
+		u.stateMgr.SaveWithUploadID(uploadID, initialState)
 	}
+	defer u.hb.start(key, u.totalBytes, 0, u.stateMgr)()
 
 	// 确保在出错时取消上传
 	// 使用命名返回值 err，确保任何返回路径都会触发清理
+	// 保存了续传状态时，分块上传失败（网络中断等）保留已上传的分块，由 resume 续传；
+	// 数据源出错、大小不一致或完成上传失败时取消上传并删除状态
 	defer func() {
-		if err != nil {
-			_ = u.adapter.AbortMultipartUpload(ctx, key, uploadID)
+		if err == nil {
+			return
+		}
+		var pf *partFailure
+		if u.stateMgr != nil && errors.As(err, &pf) {
+			return
+		}
+		_ = u.adapter.AbortMultipartUpload(ctx, key, uploadID)
+		if u.stateMgr != nil {
+			_ = u.stateMgr.Delete()
 		}
 	}()
 
 	// 创建分块通道
-	chunkChan := make(chan *chunk, u.concurrency*2)
+	chunkChan := make(chan *chunk, readAheadDepth(u.readAhead, u.concurrency))
 	resultChan := make(chan *partResult, u.concurrency)
 	errorChan := make(chan error, 1)
 
@@ -98,10 +285,15 @@ func (u *Uploader) Upload(ctx context.Context, key string, r io.Reader, opts sto
 	readDone := make(chan struct{})
 
 	// 启动 worker goroutines
+	// 启用并发控制时按上限启动 worker，实际并发由控制器限制
+	workers := u.concurrency
+	if u.controller != nil {
+		workers = u.controller.Max()
+	}
 	var wg sync.WaitGroup
-	for i := 0; i < u.concurrency; i++ {
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go u.worker(ctx, &wg, key, uploadID, chunkChan, resultChan, errorChan)
+		go u.worker(ctx, i, &wg, key, uploadID, chunkChan, resultChan, errorChan)
 	}
 
 	// 读取数据并发送分块
@@ -112,6 +304,7 @@ func (u *Uploader) Upload(ctx context.Context, key string, r io.Reader, opts sto
 
 	// 收集结果
 	var parts []storage.CompletedPart
+	var uploaded int64
 
 	// 等待所有 worker 完成和结果收集
 	go func() {
@@ -129,12 +322,20 @@ func (u *Uploader) Upload(ctx context.Context, key string, r io.Reader, opts sto
 		case result, ok := <-resultChan:
 			if !ok {
 				// resultChan 已关闭，所有 worker 完成
+				// worker 或读取出错时也会关闭 resultChan，需先检查是否有未处理的错误
+				select {
+				case uploadErr := <-errorChan:
+					err = uploadErr
+					return uploadErr
+				default:
+				}
 				goto complete
 			}
 			parts = append(parts, storage.CompletedPart{
 				PartNumber: result.partNumber,
 				ETag:       result.etag,
 			})
+			uploaded += result.size
 
 		case uploadErr := <-errorChan:
 			// 有错误发生
@@ -148,11 +349,16 @@ func (u *Uploader) Upload(ctx context.Context, key string, r io.Reader, opts sto
 	}
 
 complete:
+	// 数据源与预期大小不一致时不完成上传，避免生成截断的对象
+	if err = checkTotalBytes(u.totalBytes, uploaded); err != nil {
+		return err
+	}
+
 	// 按分块号排序
 	u.sortParts(parts)
 
 	// 完成上传
-	if completeErr := u.adapter.CompleteMultipartUpload(ctx, key, uploadID, parts); completeErr != nil {
+	if completeErr := u.adapter.CompleteMultipartUpload(ctx, key, uploadID, parts, opts); completeErr != nil {
 		err = fmt.Errorf("failed to complete multipart upload: %w", completeErr)
 		return err
 	}
@@ -163,8 +369,8 @@ complete:
 	return nil
 }
 
-// worker 处理分块上传
-func (u *Uploader) worker(ctx context.Context, wg *sync.WaitGroup, key, uploadID string,
+// worker 处理分块上传，id 为 worker 编号
+func (u *Uploader) worker(ctx context.Context, id int, wg *sync.WaitGroup, key, uploadID string,
 	chunkChan <-chan *chunk, resultChan chan<- *partResult, errorChan chan<- error) {
 
 	defer wg.Done()
@@ -176,14 +382,15 @@ func (u *Uploader) worker(ctx context.Context, wg *sync.WaitGroup, key, uploadID
 		default:
 		}
 
-		etag, err := u.adapter.UploadPart(ctx, key, uploadID, chunk.partNumber, bytes.NewReader(chunk.data), chunk.size)
+		etag, err := u.uploadPart(ctx, id, key, uploadID, chunk)
 		if err != nil {
-			errorChan <- fmt.Errorf("failed to upload part %d: %w", chunk.partNumber, err)
+			errorChan <- &partFailure{partNumber: chunk.partNumber, err: err}
 			return
 		}
 
 		// 更新进度
 		u.reporter.Add(chunk.size)
+		u.hb.partDone(chunk.size)
 
 		// 保存状态（用于断点续传）
 		if u.stateMgr != nil {
@@ -191,12 +398,15 @@ func (u *Uploader) worker(ctx context.Context, wg *sync.WaitGroup, key, uploadID
 				PartNumber: chunk.partNumber,
 				ETag:       etag,
 				Size:       chunk.size,
+				Offset:     chunk.offset,
+				Checksum:   state.PartChecksum(chunk.data),
 			})
 		}
 
 		resultChan <- &partResult{
 			partNumber: chunk.partNumber,
 			etag:       etag,
+			size:       chunk.size,
 		}
 
 		// 回收缓冲区
@@ -204,11 +414,185 @@ func (u *Uploader) worker(ctx context.Context, wg *sync.WaitGroup, key, uploadID
 	}
 }
 
+// checkTotalBytes 校验实际上传的字节数，expected 为 0 表示大小未知
+func checkTotalBytes(expected, uploaded int64) error {
+	if expected > 0 && uploaded != expected {
+		return fmt.Errorf("%w: uploaded %d bytes, expected %d", ErrSizeMismatch, uploaded, expected)
+	}
+	return nil
+}
+
+// uploadPart 上传单个分块
+// 遇到限流时暂停所有 worker 并按提供商建议的时间退避重试，启用并发控制时同时降低并发；
+// 遇到网络错误时当前 worker 等待后重试；返回的 ETag 与本地 MD5 不一致时立即重新上传
+func (u *partSender) uploadPart(ctx context.Context, worker int, key, uploadID string, c *chunk) (etag string, err error) {
+	ctx, span := startPartSpan(ctx, c)
+	attempt, mismatches := 0, 0
+	defer func() {
+		span.SetAttributes(attribute.Int("s3backup.retries", attempt))
+		tracing.End(span, err)
+	}()
+
+	for ; ; attempt++ {
+		if err := u.waitPause(ctx); err != nil {
+			return "", err
+		}
+
+		if u.controller != nil {
+			if err := u.controller.Acquire(ctx); err != nil {
+				return "", err
+			}
+		}
+
+		var data io.Reader = bytes.NewReader(c.data)
+		if u.observer != nil {
+			u.observer.PartStarted(worker, c.partNumber, c.size)
+			data = newPartReader(c.data, func(n int64) { u.observer.PartProgress(worker, n) })
+		}
+
+		start := time.Now()
+		etag, err := u.adapter.UploadPart(ctx, key, uploadID, c.partNumber, data, c.size)
+		elapsed := time.Since(start)
+
+		if u.controller != nil {
+			u.controller.Release()
+		}
+
+		if err == nil {
+			err = checkETag(etag, c.data, u.etagRetries)
+		}
+		if err == nil {
+			u.parts.Add(1)
+			// 记录吞吐量用于分块大小和并发调优
+			if u.tuner != nil {
+				u.tuner.Observe(c.size, elapsed)
+			}
+			if u.controller != nil {
+				u.controller.OnSuccess(c.size)
+			}
+			if u.observer != nil {
+				u.observer.PartDone(worker, c.partNumber)
+			}
+			return etag, nil
+		}
+
+		var throttled *storage.ThrottledError
+		switch {
+		case errors.Is(err, ErrETagMismatch):
+			// 数据在传输中被损坏，服务端已经接受了错误的数据，同一分块号重新上传会覆盖它
+			u.etagFails.Add(1)
+			if mismatches >= etagRetryLimit(u.etagRetries) {
+				return "", err
+			}
+			mismatches++
+
+		case errors.As(err, &throttled):
+			u.throttled.Add(1)
+			if attempt >= maxPartRetries {
+				return "", err
+			}
+			if u.controller != nil {
+				u.controller.OnThrottle()
+			}
+			u.pause(throttled.Backoff(attempt))
+
+		case errors.Is(err, storage.ErrNetwork):
+			// 网络错误只影响当前连接，仅当前 worker 等待后重试
+			if attempt >= maxPartRetries {
+				return "", err
+			}
+			if err := sleepContext(ctx, networkRetryDelay<<attempt); err != nil {
+				return "", err
+			}
+
+		default:
+			// 认证失败、存储桶不存在等错误重试无意义
+			return "", err
+		}
+		u.retries.Add(1)
+		if u.observer != nil {
+			u.observer.PartRetry(worker, c.partNumber, err)
+		}
+	}
+}
+
+// etagRetryLimit 返回 ETag 不一致时的重新上传次数，见 SetETagRetries
+func etagRetryLimit(n int) int {
+	if n == 0 {
+		return defaultETagRetries
+	}
+	return n
+}
+
+// checkETag 比较服务端返回的 ETag 与 data 的 MD5，retries 为负数时不校验
+// 只有形如 MD5 十六进制的 ETag 才比较，其他格式（部分提供商或服务端加密）无法校验
+func checkETag(etag string, data []byte, retries int) error {
+	if retries < 0 {
+		return nil
+	}
+	etag = trimETag(etag)
+	if len(etag) != 2*md5.Size {
+		return nil
+	}
+	if _, err := hex.DecodeString(etag); err != nil {
+		return nil
+	}
+	sum := md5.Sum(data)
+	if local := hex.EncodeToString(sum[:]); !strings.EqualFold(etag, local) {
+		return fmt.Errorf("%w: server returned %s, local MD5 is %s", ErrETagMismatch, etag, local)
+	}
+	return nil
+}
+
+// sleepContext 等待 d 时间，上下文取消时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pause 让所有 worker 暂停 d 时间，已有更长的暂停时保持不变
+func (u *partSender) pause(d time.Duration) {
+	u.pauseMu.Lock()
+	defer u.pauseMu.Unlock()
+
+	if until := time.Now().Add(d); until.After(u.pausedUntil) {
+		u.pausedUntil = until
+	}
+}
+
+// waitPause 等待全局退避结束
+func (u *partSender) waitPause(ctx context.Context) error {
+	for {
+		// 等待期间可能被其他 worker 延长，醒来后需要重新检查
+		u.pauseMu.Lock()
+		wait := time.Until(u.pausedUntil)
+		u.pauseMu.Unlock()
+
+		if wait <= 0 {
+			return nil
+		}
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
 // readChunks 读取数据并发送分块
 func (u *Uploader) readChunks(ctx context.Context, r io.Reader, chunkChan chan<- *chunk, errorChan chan<- error) {
 	defer close(chunkChan)
 
+	begin := time.Now()
+	defer func() { u.elapsed.Add(int64(time.Since(begin))) }()
+
 	partNumber := 1
+	var offset int64
 
 	for {
 		select {
@@ -217,11 +601,17 @@ func (u *Uploader) readChunks(ctx context.Context, r io.Reader, chunkChan chan<-
 		default:
 		}
 
-		// 获取缓冲区
-		buf := getBuffer(u.chunkSize)
+		// 获取缓冲区（启用调优时按当前观测结果和已使用的分块数确定分块大小）
+		size := u.chunkSize
+		if u.tuner != nil {
+			size = u.tuner.PartSize(partNumber, offset)
+		}
+		buf := getBuffer(size)[:size]
 
 		// 读取数据
+		start := time.Now()
 		n, err := io.ReadFull(r, buf)
+		u.readWait.Add(int64(time.Since(start)))
 		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 			putBuffer(buf)
 			errorChan <- fmt.Errorf("failed to read data: %w", err)
@@ -233,17 +623,35 @@ func (u *Uploader) readChunks(ctx context.Context, r io.Reader, chunkChan chan<-
 			return
 		}
 
-		// 发送分块
+		// 先记入上传队列再交给 worker，续传时可以按记录的边界重建未完成的分块
+		if u.stateMgr != nil {
+			u.stateMgr.AddPendingPart(state.PendingPart{PartNumber: partNumber, Offset: offset, Size: int64(n)})
+		}
+
+		// 发送分块，分块通道已满时阻塞直到有 worker 空闲
+		start = time.Now()
 		chunkChan <- &chunk{
 			partNumber: partNumber,
+			offset:     offset,
 			data:       buf[:n],
 			size:       int64(n),
 		}
+		offset += int64(n)
+		u.sendWait.Add(int64(time.Since(start)))
 
 		partNumber++
 	}
 }
 
+// startPartSpan 开始上传单个分块的 span
+func startPartSpan(ctx context.Context, c *chunk) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "upload_part",
+		attribute.Int("s3backup.part", c.partNumber),
+		attribute.Int64("s3backup.offset", c.offset),
+		attribute.Int64("s3backup.size", c.size),
+	)
+}
+
 // sortParts 按分块号排序
 func (u *Uploader) sortParts(parts []storage.CompletedPart) {
 	sort.Slice(parts, func(i, j int) bool {
@@ -254,14 +662,40 @@ func (u *Uploader) sortParts(parts []storage.CompletedPart) {
 // chunk 数据分块
 type chunk struct {
 	partNumber int
+	offset     int64 // 在数据流中的偏移
 	data       []byte
 	size       int64
 }
 
+// partReader 读取分块数据并报告已发送的位置
+// 适配器可能 Seek 后重新读取（如计算校验和），报告的是当前位置而不是累计读取量
+type partReader struct {
+	*bytes.Reader
+	onRead func(pos int64)
+}
+
+func newPartReader(data []byte, onRead func(pos int64)) *partReader {
+	return &partReader{Reader: bytes.NewReader(data), onRead: onRead}
+}
+
+func (r *partReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.onRead(r.Size() - int64(r.Len()))
+	}
+	return n, err
+}
+
+// WriteTo 经过 Read 写出，避免 io.Copy 使用 bytes.Reader.WriteTo 绕过进度报告
+func (r *partReader) WriteTo(w io.Writer) (int64, error) {
+	return io.Copy(w, struct{ io.Reader }{r})
+}
+
 // partResult 分块上传结果
 type partResult struct {
 	partNumber int
 	etag       string
+	size       int64
 }
 
 // 缓冲池
@@ -3,15 +3,21 @@ package uploader
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/lukelzlz/s3backup/pkg/progress"
+	"github.com/lukelzlz/s3backup/pkg/state"
 	"github.com/lukelzlz/s3backup/pkg/storage"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // mockAdapter 是用于测试的模拟存储适配器
@@ -22,6 +28,7 @@ type mockAdapter struct {
 	abortCalled        atomic.Int64
 	mu                 sync.Mutex
 	uploadedParts      []storage.CompletedPart
+	serverParts        []storage.PartInfo // ListParts 返回的服务端已有分块
 	shouldFailInit     bool
 	shouldFailPart     bool
 	shouldFailComplete bool
@@ -60,7 +67,7 @@ func (m *mockAdapter) UploadPart(ctx context.Context, key, uploadID string, part
 	return fmt.Sprintf("etag-%d", len(data)), nil
 }
 
-func (m *mockAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []storage.CompletedPart) error {
+func (m *mockAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []storage.CompletedPart, opts storage.UploadOptions) error {
 	m.completeCalled.Add(1)
 	if m.shouldFailComplete {
 		return storage.ErrMockCompleteFailed
@@ -73,6 +80,12 @@ func (m *mockAdapter) AbortMultipartUpload(ctx context.Context, key, uploadID st
 	return nil
 }
 
+func (m *mockAdapter) ListParts(ctx context.Context, key, uploadID string) ([]storage.PartInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.serverParts, nil
+}
+
 func (m *mockAdapter) SupportedStorageClasses() []storage.StorageClass {
 	return []storage.StorageClass{storage.StorageClassStandard}
 }
@@ -258,6 +271,46 @@ func TestUploadCompleteFailure(t *testing.T) {
 	}
 }
 
+// TestUploadTotalBytes 测试预期大小写入状态文件，且不会覆盖调用者保存的信息
+func TestUploadTotalBytes(t *testing.T) {
+	adapter := &mockAdapter{}
+	sm := state.NewStateManager(t.TempDir(), "test-key")
+	sm.Save(&state.UploadState{Key: "test-key", Bucket: "bucket", Provider: "aws"})
+
+	u := NewUploader(adapter, 1024, 2)
+	u.SetStateManager(sm)
+	u.SetTotalBytes(3000)
+	if err := u.Upload(context.Background(), "test-key", bytes.NewReader(make([]byte, 3000)), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	saved, err := sm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.TotalBytes != 3000 || saved.UploadID != "mock-upload-id" || saved.Bucket != "bucket" || saved.Provider != "aws" {
+		t.Errorf("unexpected saved state: %+v", saved)
+	}
+}
+
+// TestUploadSizeMismatch 测试实际数据与预期大小不一致时取消上传
+func TestUploadSizeMismatch(t *testing.T) {
+	adapter := &mockAdapter{}
+
+	u := NewUploader(adapter, 1024, 2)
+	u.SetTotalBytes(4096)
+	err := u.Upload(context.Background(), "test-key", bytes.NewReader(make([]byte, 3000)), storage.UploadOptions{})
+	if !errors.Is(err, ErrSizeMismatch) {
+		t.Fatalf("expected ErrSizeMismatch, got %v", err)
+	}
+	if adapter.completeCalled.Load() != 0 {
+		t.Error("CompleteMultipartUpload should not be called for truncated data")
+	}
+	if adapter.abortCalled.Load() == 0 {
+		t.Error("AbortMultipartUpload should be called for truncated data")
+	}
+}
+
 // TestUploadContextCancellation 测试上下文取消
 func TestUploadContextCancellation(t *testing.T) {
 	adapter := &mockAdapter{}
@@ -539,3 +592,132 @@ func TestConcurrentUpload(t *testing.T) {
 		}
 	}
 }
+
+// TestUploadSpans 测试上传的 span 结构：每个分块一个 upload_part span，父 span 为 upload
+func TestUploadSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	adapter := &mockAdapter{}
+	u := NewUploader(adapter, 5*1024*1024, 2)
+	u.SetProgressReporter(progress.NewSilent())
+	data := make([]byte, 12*1024*1024)
+	if err := u.Upload(context.Background(), "test-key", bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+		t.Fatalf("Upload() failed: %v", err)
+	}
+
+	var upload sdktrace.ReadOnlySpan
+	var parts []sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		switch s.Name() {
+		case "upload":
+			upload = s
+		case "upload_part":
+			parts = append(parts, s)
+		}
+	}
+	if upload == nil {
+		t.Fatal("missing upload span")
+	}
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 upload_part spans, got %d", len(parts))
+	}
+	for _, p := range parts {
+		if p.Parent().SpanID() != upload.SpanContext().SpanID() {
+			t.Errorf("upload_part span is not a child of upload span")
+		}
+	}
+}
+
+// gatedAdapter UploadPart 阻塞到 gate 关闭的模拟适配器
+type gatedAdapter struct {
+	mockAdapter
+	gate chan struct{}
+}
+
+func (a *gatedAdapter) UploadPart(ctx context.Context, key, uploadID string, partNumber int, r io.Reader, size int64) (string, error) {
+	<-a.gate
+	return a.mockAdapter.UploadPart(ctx, key, uploadID, partNumber, r, size)
+}
+
+// countingReader 统计已读取字节数的 reader
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// TestUploadReadAhead 测试上传阻塞时预读的分块数受 SetReadAhead 限制
+func TestUploadReadAhead(t *testing.T) {
+	const chunkSize = 1024
+	tests := []struct {
+		readAhead   int
+		concurrency int
+		want        int // 上传中 + 通道中 + 读取 goroutine 等待发送的分块
+	}{
+		{readAhead: 0, concurrency: 2, want: 2 + 4 + 1},
+		{readAhead: 1, concurrency: 2, want: 2 + 1 + 1},
+		{readAhead: 8, concurrency: 1, want: 1 + 8 + 1},
+	}
+	for _, tt := range tests {
+		adapter := &gatedAdapter{gate: make(chan struct{})}
+		r := &countingReader{r: bytes.NewReader(bytes.Repeat([]byte("x"), 32*chunkSize))}
+		upl := NewUploader(adapter, chunkSize, tt.concurrency)
+		upl.SetReadAhead(tt.readAhead)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- upl.Upload(context.Background(), "read-ahead", r, storage.UploadOptions{})
+		}()
+
+		// 等待读取 goroutine 被通道阻塞
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) && r.n.Load() < int64(tt.want*chunkSize) {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if got := r.n.Load() / chunkSize; got != int64(tt.want) {
+			t.Errorf("readAhead=%d concurrency=%d: %d chunks read while blocked, want %d", tt.readAhead, tt.concurrency, got, tt.want)
+		}
+
+		close(adapter.gate)
+		if err := <-done; err != nil {
+			t.Fatalf("Upload() error = %v", err)
+		}
+	}
+}
+
+func TestCheckETag(t *testing.T) {
+	data := []byte("hello")
+	tests := []struct {
+		name    string
+		etag    string
+		retries int
+		wantErr bool
+	}{
+		{"matching quoted", `"5d41402abc4b2a76b9719d911017c592"`, 0, false},
+		{"matching upper case", "5D41402ABC4B2A76B9719D911017C592", 0, false},
+		{"mismatch", `"00000000000000000000000000000000"`, 0, true},
+		{"mismatch not checked", `"00000000000000000000000000000000"`, -1, false},
+		{"not an MD5", `"5d41402abc4b2a76b9719d911017c592-1"`, 0, false},
+		{"non-hex", `"zz41402abc4b2a76b9719d911017c592"`, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkETag(tt.etag, data, tt.retries)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkETag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrETagMismatch) {
+				t.Errorf("checkETag() error = %v, want ErrETagMismatch", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package uploader
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/lukelzlz/s3backup/pkg/storage"
+)
+
+// Writer 将写入的数据分块并以 Multipart Upload 上传到一个对象
+// 上传在后台 goroutine 中进行，Write 在分块通道已满时阻塞；上传失败后 Write 返回上传的错误
+type Writer struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+	err  error // 上传结果，done 关闭后有效
+}
+
+// NewWriter 开始上传对象 key，写入返回的 Writer 的数据按分块上传
+// 必须调用 Close 完成上传，或调用 CloseWithError 放弃上传（取消 Multipart Upload）
+func (u *Uploader) NewWriter(ctx context.Context, key string, opts storage.UploadOptions) *Writer {
+	pr, pw := io.Pipe()
+	w := &Writer{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		w.err = u.Upload(ctx, key, pr, opts)
+		// 上传提前结束时让阻塞的 Write 返回，而不是永远等待读取
+		if w.err != nil {
+			pr.CloseWithError(w.err)
+		} else {
+			pr.CloseWithError(io.ErrClosedPipe)
+		}
+	}()
+	return w
+}
+
+// Write 写入数据，上传已经失败时返回上传的错误
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// Close 结束写入并等待上传完成，返回上传的错误
+func (w *Writer) Close() error {
+	w.pw.Close()
+	<-w.done
+	return w.err
+}
+
+// CloseWithError 以 cause 结束写入（数据源出错），上传取消后返回
+// 上传因 cause 取消时返回 cause；上传在此之前已经因其他原因失败时返回上传的错误。cause 为 nil 时与 Close 相同
+func (w *Writer) CloseWithError(cause error) error {
+	w.pw.CloseWithError(cause)
+	<-w.done
+	if w.err == nil || errors.Is(w.err, cause) {
+		return cause
+	}
+	return w.err
+}
@@ -0,0 +1,69 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/lukelzlz/s3backup/pkg/storage"
+	"github.com/lukelzlz/s3backup/pkg/storage/mock"
+)
+
+// TestWriter 测试写入 Writer 的数据分块上传为一个对象
+func TestWriter(t *testing.T) {
+	adapter := mock.New()
+	data := chaosData(1024, 4)
+
+	w := NewUploader(adapter, 1024, 2).NewWriter(context.Background(), "sink", storage.UploadOptions{})
+	// 每次写入的大小与分块大小无关
+	for rest := data; len(rest) > 0; {
+		n := min(333, len(rest))
+		if _, err := w.Write(rest[:n]); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		rest = rest[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	obj, ok := adapter.Object("sink")
+	if !ok || !bytes.Equal(obj.Data, data) {
+		t.Fatal("uploaded object does not match written data")
+	}
+}
+
+// TestWriterCloseWithError 测试数据源出错时取消上传并返回数据源的错误
+func TestWriterCloseWithError(t *testing.T) {
+	adapter := mock.New()
+	w := NewUploader(adapter, 1024, 2).NewWriter(context.Background(), "sink", storage.UploadOptions{})
+	w.Write(chaosData(1024, 3))
+
+	cause := errors.New("archive failed")
+	if err := w.CloseWithError(cause); err != cause {
+		t.Errorf("CloseWithError() = %v, want %v", err, cause)
+	}
+	if _, ok := adapter.Object("sink"); ok {
+		t.Error("object should not exist after CloseWithError")
+	}
+	if len(adapter.PendingUploads()) != 0 {
+		t.Errorf("upload should be aborted, pending: %v", adapter.PendingUploads())
+	}
+}
+
+// TestWriterUploadFailure 测试上传失败后 Write 返回上传的错误，而不是一直阻塞
+func TestWriterUploadFailure(t *testing.T) {
+	adapter := mock.New()
+	adapter.FailPart(1, 0, nil, 0)
+	w := NewUploader(adapter, 1024, 1).NewWriter(context.Background(), "sink", storage.UploadOptions{})
+
+	_, err := io.Copy(w, bytes.NewReader(chaosData(1024, 64)))
+	if !errors.Is(err, mock.ErrInjected) {
+		t.Fatalf("Write() error = %v, want ErrInjected", err)
+	}
+	if err := w.CloseWithError(err); !errors.Is(err, mock.ErrInjected) {
+		t.Errorf("CloseWithError() = %v, want ErrInjected", err)
+	}
+}
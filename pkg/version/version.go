@@ -0,0 +1,114 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// 构建信息，发布时通过 ldflags 注入:
+//
+//	go build -ldflags "-X github.com/lukelzlz/s3backup/pkg/version.Version=v1.0.1 \
+//	  -X github.com/lukelzlz/s3backup/pkg/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/lukelzlz/s3backup/pkg/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = "none"
+	BuildDate = "unknown"
+)
+
+// ReleasesURL GitHub 最新发布版本查询地址
+var ReleasesURL = "https://api.github.com/repos/lukelzlz/s3backup/releases/latest"
+
+// GoVersion 返回编译使用的 Go 版本
+func GoVersion() string {
+	return runtime.Version()
+}
+
+// String 返回单行版本描述
+func String() string {
+	return fmt.Sprintf("s3backup %s (commit %s, built %s, %s %s/%s)",
+		Version, Commit, BuildDate, GoVersion(), runtime.GOOS, runtime.GOARCH)
+}
+
+// CheckLatest 查询 GitHub 最新发布版本，返回最新版本号以及是否比当前版本新
+func CheckLatest(ctx context.Context, client *http.Client) (latest string, newer bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ReleasesURL, nil)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to query releases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("failed to query releases: unexpected status %s", resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", false, fmt.Errorf("failed to decode release: %w", err)
+	}
+	if release.TagName == "" {
+		return "", false, fmt.Errorf("failed to decode release: missing tag_name")
+	}
+
+	return release.TagName, Compare(release.TagName, Version) > 0, nil
+}
+
+// Compare 比较两个 vX.Y.Z 形式的版本号，返回 -1、0 或 1
+// 无法解析的版本（如 dev）视为最旧
+func Compare(a, b string) int {
+	pa, okA := parse(a)
+	pb, okB := parse(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+
+	for i := 0; i < 3; i++ {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// parse 解析 vX.Y.Z 版本号，忽略预发布后缀
+func parse(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
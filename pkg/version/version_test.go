@@ -0,0 +1,79 @@
+package version
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestCompare 测试版本号比较
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.0.1", "v1.0.0", 1},
+		{"v1.0.0", "v1.0.1", -1},
+		{"v1.2.0", "v1.2", 0},
+		{"v2.0.0", "v1.9.9", 1},
+		{"1.10.0", "v1.9.0", 1},
+		{"v1.0.1-rc1", "v1.0.1", 0},
+		{"v1.0.0", "dev", 1},
+		{"dev", "v1.0.0", -1},
+		{"dev", "dev", 0},
+	}
+
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestString 测试版本描述包含构建信息
+func TestString(t *testing.T) {
+	s := String()
+	for _, part := range []string{Version, Commit, BuildDate, GoVersion()} {
+		if !strings.Contains(s, part) {
+			t.Errorf("String() = %q, should contain %q", s, part)
+		}
+	}
+}
+
+// TestCheckLatest 测试查询最新版本
+func TestCheckLatest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "v9.9.9"}`))
+	}))
+	defer server.Close()
+
+	oldURL, oldVersion := ReleasesURL, Version
+	ReleasesURL, Version = server.URL, "v1.0.1"
+	defer func() { ReleasesURL, Version = oldURL, oldVersion }()
+
+	latest, newer, err := CheckLatest(context.Background(), server.Client())
+	if err != nil {
+		t.Fatalf("CheckLatest() error = %v", err)
+	}
+	if latest != "v9.9.9" || !newer {
+		t.Errorf("CheckLatest() = %q, %v; want v9.9.9, true", latest, newer)
+	}
+}
+
+// TestCheckLatestHTTPError 测试查询失败
+func TestCheckLatestHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	oldURL := ReleasesURL
+	ReleasesURL = server.URL
+	defer func() { ReleasesURL = oldURL }()
+
+	if _, _, err := CheckLatest(context.Background(), server.Client()); err == nil {
+		t.Error("expected error for non-200 response")
+	}
+}
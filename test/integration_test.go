@@ -70,7 +70,7 @@ func (m *mockStorageAdapter) UploadPart(ctx context.Context, key, uploadID strin
 	return etag, nil
 }
 
-func (m *mockStorageAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []storage.CompletedPart) error {
+func (m *mockStorageAdapter) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []storage.CompletedPart, opts storage.UploadOptions) error {
 	m.completeCalled = true
 	return nil
 }
@@ -82,6 +82,17 @@ func (m *mockStorageAdapter) AbortMultipartUpload(ctx context.Context, key, uplo
 	return nil
 }
 
+func (m *mockStorageAdapter) ListParts(ctx context.Context, key, uploadID string) ([]storage.PartInfo, error) {
+	if m.uploadIDs[key] != uploadID {
+		return nil, storage.ErrUploadNotFound
+	}
+	var parts []storage.PartInfo
+	for _, p := range m.parts[key] {
+		parts = append(parts, storage.PartInfo{PartNumber: p.PartNumber, ETag: p.ETag})
+	}
+	return parts, nil
+}
+
 func (m *mockStorageAdapter) SupportedStorageClasses() []storage.StorageClass {
 	return []storage.StorageClass{
 		storage.StorageClassStandard,